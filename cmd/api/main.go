@@ -1,7 +1,11 @@
 package main
 
 import (
+	"fmt"
 	"net/http"
+	"os"
+	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
@@ -9,9 +13,23 @@ import (
 	echoSwagger "github.com/swaggo/echo-swagger"
 
 	_ "github.com/hinha/los-technical/docs"
+	borrowerHandler "github.com/hinha/los-technical/internal/api/handler/borrower"
+	documentHandler "github.com/hinha/los-technical/internal/api/handler/document"
+	emailHandler "github.com/hinha/los-technical/internal/api/handler/email"
+	investorHandler "github.com/hinha/los-technical/internal/api/handler/investor"
 	loanHandler "github.com/hinha/los-technical/internal/api/handler/loan"
+	notificationHandler "github.com/hinha/los-technical/internal/api/handler/notification"
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	"github.com/hinha/los-technical/internal/infrastructure/agreement"
+	"github.com/hinha/los-technical/internal/infrastructure/database/sqlite"
+	"github.com/hinha/los-technical/internal/infrastructure/document"
 	"github.com/hinha/los-technical/internal/infrastructure/email"
+	"github.com/hinha/los-technical/internal/infrastructure/eventstore"
+	"github.com/hinha/los-technical/internal/infrastructure/notification"
+	"github.com/hinha/los-technical/internal/infrastructure/pricing"
 	loanRepo "github.com/hinha/los-technical/internal/infrastructure/repository/loan"
+	"github.com/hinha/los-technical/internal/infrastructure/scoring"
+	"github.com/hinha/los-technical/internal/pkg/money"
 	"github.com/hinha/los-technical/internal/usecase/loan"
 )
 
@@ -29,10 +47,138 @@ func main() {
 	log.SetLevel(logrus.InfoLevel)
 
 	// Create repository
-	repository := loanRepo.NewInMemoryRepository(log)
-	emailSender := email.NewConsoleEmailSender(log)
-	loanService := loan.NewLoanService(repository, emailSender, log)
-	handler := loanHandler.NewHandler(loanService)
+	repos, err := newLoanRepository(log)
+	if err != nil {
+		log.Fatalf("Failed to create loan repository: %v", err)
+	}
+	defer repos.close()
+
+	eventStore, closeEventStore, err := newEventStore(log)
+	if err != nil {
+		log.Fatalf("Failed to create event store: %v", err)
+	}
+	defer closeEventStore()
+
+	// The in-memory read model starts empty, so it is rebuilt from the stream. A persistent one is kept
+	// beside the stream instead, which is started for the loans stored before their events were recorded.
+	if getEnv("LOAN_REPOSITORY", "memory") == "memory" {
+		if _, err := loan.NewProjection(repos.loans, log).Rebuild(eventStore); err != nil {
+			log.Fatalf("Failed to rebuild loans from the event store: %v", err)
+		}
+	} else if _, err := loan.NewProjection(repos.loans, log).Seed(eventStore); err != nil {
+		log.Fatalf("Failed to seed the event store from the loans: %v", err)
+	}
+
+	daysPastDue, err := getEnvInt("DEFAULT_DAYS_PAST_DUE", domain.DefaultDaysPastDueThreshold)
+	if err != nil {
+		log.Fatalf("Invalid DEFAULT_DAYS_PAST_DUE: %v", err)
+	}
+	checkInterval, err := time.ParseDuration(getEnv("DEFAULT_CHECK_INTERVAL", "1h"))
+	if err != nil {
+		log.Fatalf("Invalid DEFAULT_CHECK_INTERVAL: %v", err)
+	}
+	lateFee, err := money.Parse(getEnv("LATE_FEE", "0"))
+	if err != nil {
+		log.Fatalf("Invalid LATE_FEE: %v", err)
+	}
+	if lateFee.Sign() < 0 {
+		log.Fatalf("Invalid LATE_FEE: must not be negative, got %s", lateFee)
+	}
+	reminderDays, err := getEnvInt("INSTALLMENT_REMINDER_DAYS", domain.DefaultReminderLeadDays)
+	if err != nil {
+		log.Fatalf("Invalid INSTALLMENT_REMINDER_DAYS: %v", err)
+	}
+	reminderInterval, err := time.ParseDuration(getEnv("REMINDER_CHECK_INTERVAL", "1h"))
+	if err != nil {
+		log.Fatalf("Invalid REMINDER_CHECK_INTERVAL: %v", err)
+	}
+
+	maxActiveLoans, err := getEnvInt("BORROWER_MAX_ACTIVE_LOANS", loan.DefaultMaxActiveLoans)
+	if err != nil {
+		log.Fatalf("Invalid BORROWER_MAX_ACTIVE_LOANS: %v", err)
+	}
+	loanCooldown, err := time.ParseDuration(getEnv("BORROWER_LOAN_COOLDOWN", loan.DefaultLoanCooldown.String()))
+	if err != nil {
+		log.Fatalf("Invalid BORROWER_LOAN_COOLDOWN: %v", err)
+	}
+
+	maxAttempts, err := getEnvInt("OUTBOX_MAX_ATTEMPTS", loan.DefaultMaxDeliveryAttempts)
+	if err != nil {
+		log.Fatalf("Invalid OUTBOX_MAX_ATTEMPTS: %v", err)
+	}
+	retryBackoff, err := time.ParseDuration(getEnv("OUTBOX_RETRY_BACKOFF", loan.DefaultRetryBackoff.String()))
+	if err != nil {
+		log.Fatalf("Invalid OUTBOX_RETRY_BACKOFF: %v", err)
+	}
+	dispatchInterval, err := time.ParseDuration(getEnv("OUTBOX_DISPATCH_INTERVAL", "10s"))
+	if err != nil {
+		log.Fatalf("Invalid OUTBOX_DISPATCH_INTERVAL: %v", err)
+	}
+
+	templates, err := email.LoadTemplates(getEnv("EMAIL_TEMPLATE_DIR", "templates/email"), getEnv("EMAIL_DEFAULT_LOCALE", email.DefaultLocale), log)
+	if err != nil {
+		log.Fatalf("Failed to load email templates: %v", err)
+	}
+
+	emailSender, closeEmailSender, err := newEmailSender(templates, log)
+	if err != nil {
+		log.Fatalf("Failed to create email sender: %v", err)
+	}
+	defer closeEmailSender()
+
+	notificationService := loan.NewNotificationService(repos.preferences, repos.inbox, log,
+		emailSender,
+		notification.NewConsoleSMSSender(templates, log),
+		notification.NewInboxChannel(repos.inbox, templates, log),
+	)
+
+	documents, err := newDocumentStore(log)
+	if err != nil {
+		log.Fatalf("Failed to create document store: %v", err)
+	}
+	maxDocumentSize, err := getEnvInt("DOCUMENT_MAX_SIZE", domain.DefaultMaxDocumentSize)
+	if err != nil {
+		log.Fatalf("Invalid DOCUMENT_MAX_SIZE: %v", err)
+	}
+	agreements, err := agreement.LoadRenderer(getEnv("AGREEMENT_TEMPLATE", "templates/agreement/agreement.txt.tmpl"), log)
+	if err != nil {
+		log.Fatalf("Failed to load agreement template: %v", err)
+	}
+
+	scorecard, err := scoring.LoadScorecard(getEnv("SCORECARD_PATH", "config/scorecard.yaml"), log)
+	if err != nil {
+		log.Fatalf("Failed to load scorecard: %v", err)
+	}
+	rateCard, err := pricing.LoadRateCard(getEnv("RATE_CARD_PATH", "config/ratecard.yaml"), log)
+	if err != nil {
+		log.Fatalf("Failed to load rate card: %v", err)
+	}
+	minimumGrade := domain.RiskGrade(getEnv("APPROVAL_MIN_GRADE", string(domain.DefaultMinimumGrade)))
+	if err := minimumGrade.Validate(); err != nil {
+		log.Fatalf("Invalid APPROVAL_MIN_GRADE: %v", err)
+	}
+
+	borrowerLocks := loan.NewBorrowerLocks()
+	loanService := loan.NewLoanService(repos.loans, repos.investors, repos.borrowers, eventStore, repos.auditLog, documents, agreements, agreement.NewVerifier(log), scorecard, rateCard, log,
+		loan.WithDaysPastDueThreshold(daysPastDue),
+		loan.WithReminderLeadDays(reminderDays),
+		loan.WithLateFee(lateFee),
+		loan.WithMaxDocumentSize(int64(maxDocumentSize)),
+		loan.WithBorrowerPolicy(loan.BorrowerPolicy{MaxActiveLoans: maxActiveLoans, Cooldown: loanCooldown}),
+		loan.WithMinimumGrade(minimumGrade),
+		loan.WithBorrowerLocks(borrowerLocks),
+	)
+	dispatcher := loan.NewDispatcher(eventStore, notificationService, log, loan.WithMaxDeliveryAttempts(maxAttempts), loan.WithRetryBackoff(retryBackoff))
+	handler := loanHandler.NewHandler(loanService, loanHandler.WithMaxDocumentSize(int64(maxDocumentSize)))
+	previewHandler := emailHandler.NewHandler(loanService, templates)
+	recipientHandler := notificationHandler.NewHandler(notificationService)
+	downloadHandler := documentHandler.NewHandler(documents)
+	profileHandler := investorHandler.NewHandler(loan.NewInvestorService(repos.investors, repos.loans, log))
+	applicantHandler := borrowerHandler.NewHandler(loan.NewBorrowerService(repos.borrowers, repos.loans, borrowerLocks, log))
+
+	go runDefaultCheck(loanService, checkInterval, log)
+	go runReminders(loanService, reminderInterval, log)
+	go runDispatcher(dispatcher, dispatchInterval, log)
 
 	// Initialize Echo
 	e := echo.New()
@@ -43,6 +189,11 @@ func main() {
 
 	// Register routes
 	handler.RegisterRoutes(e)
+	previewHandler.RegisterRoutes(e)
+	recipientHandler.RegisterRoutes(e)
+	downloadHandler.RegisterRoutes(e)
+	profileHandler.RegisterRoutes(e)
+	applicantHandler.RegisterRoutes(e)
 
 	// Serve Swagger UI
 	e.Static("/", "web")
@@ -59,3 +210,226 @@ func main() {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
+
+// repositories are the stores of the loan read model and of the data kept beside it
+type repositories struct {
+	loans       domain.LoanRepository
+	auditLog    domain.AuditLog
+	investors   domain.InvestorRepository
+	borrowers   domain.BorrowerRepository
+	preferences domain.PreferenceStore
+	inbox       domain.Inbox
+	close       func()
+}
+
+// newLoanRepository creates the loan repository, audit log, investor and borrower repositories, notification
+// preferences and inbox selected by the LOAN_REPOSITORY environment variable. Supported backends are "memory"
+// (default) and "sqlite"; the SQLite file is taken from SQLITE_PATH.
+func newLoanRepository(log *logrus.Logger) (*repositories, error) {
+	backend := getEnv("LOAN_REPOSITORY", "memory")
+	switch backend {
+	case "memory":
+		return &repositories{
+			loans:       loanRepo.NewInMemoryRepository(log),
+			auditLog:    loanRepo.NewInMemoryAuditLog(log),
+			investors:   loanRepo.NewInMemoryInvestorRepository(log),
+			borrowers:   loanRepo.NewInMemoryBorrowerRepository(log),
+			preferences: loanRepo.NewInMemoryPreferenceStore(log),
+			inbox:       loanRepo.NewInMemoryInbox(log),
+			close:       func() {},
+		}, nil
+	case "sqlite":
+		path := getEnv("SQLITE_PATH", "los.db")
+		db, err := sqlite.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := sqlite.Migrate(db, log); err != nil {
+			_ = db.Close()
+			return nil, err
+		}
+		log.WithField("path", path).Info("Using SQLite loan repository")
+		return &repositories{
+			loans:       loanRepo.NewSQLiteRepository(db, log),
+			auditLog:    loanRepo.NewSQLiteAuditLog(db, log),
+			investors:   loanRepo.NewSQLiteInvestorRepository(db, log),
+			borrowers:   loanRepo.NewSQLiteBorrowerRepository(db, log),
+			preferences: loanRepo.NewSQLitePreferenceStore(db, log),
+			inbox:       loanRepo.NewSQLiteInbox(db, log),
+			close:       func() { _ = db.Close() },
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown loan repository backend %q", backend)
+	}
+}
+
+// newDocumentStore creates the document store selected by the DOCUMENT_STORE environment variable.
+// Supported backends are "memory" and "filesystem"; the directory is taken from DOCUMENT_DIR. The store defaults
+// to "filesystem" with LOAN_REPOSITORY=sqlite and to "memory" otherwise, since the loans of a persistent repository
+// keep referring to their proofs, signed agreements and letters after a restart. Documents are served under
+// DOCUMENT_BASE_URL.
+func newDocumentStore(log *logrus.Logger) (domain.DocumentStore, error) {
+	baseURL := getEnv("DOCUMENT_BASE_URL", "http://localhost:7002/documents")
+	repository := getEnv("LOAN_REPOSITORY", "memory")
+	fallback := "memory"
+	if repository != "memory" {
+		fallback = "filesystem"
+	}
+	backend := getEnv("DOCUMENT_STORE", fallback)
+	switch backend {
+	case "memory":
+		if repository != "memory" {
+			return nil, fmt.Errorf("document store backend %q does not persist the documents of loan repository %q, use \"filesystem\"", backend, repository)
+		}
+		return document.NewInMemoryDocumentStore(baseURL, log), nil
+	case "filesystem":
+		dir := getEnv("DOCUMENT_DIR", "documents")
+		store, err := document.NewFileSystemDocumentStore(dir, baseURL, log)
+		if err != nil {
+			return nil, err
+		}
+		log.WithField("dir", dir).Info("Using filesystem document store")
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unknown document store backend %q", backend)
+	}
+}
+
+// eventStore is an event store that also keeps the outbox its commits enqueue
+type eventStore interface {
+	domain.EventStore
+	domain.Outbox
+}
+
+// newEventStore creates the event store selected by the EVENT_STORE environment variable.
+// Supported backends are "memory" and "file"; the event file is taken from EVENT_STORE_PATH. The store defaults
+// to "file" with LOAN_REPOSITORY=sqlite and to "memory" otherwise. A persistent repository needs a persistent
+// store: the loans it keeps would otherwise lose their streams, agreement history and outbox on restart.
+func newEventStore(log *logrus.Logger) (eventStore, func(), error) {
+	repository := getEnv("LOAN_REPOSITORY", "memory")
+	fallback := "memory"
+	if repository != "memory" {
+		fallback = "file"
+	}
+	backend := getEnv("EVENT_STORE", fallback)
+	switch backend {
+	case "memory":
+		if repository != "memory" {
+			return nil, nil, fmt.Errorf("event store backend %q does not persist the events of loan repository %q, use \"file\"", backend, repository)
+		}
+		return eventstore.NewInMemoryEventStore(log), func() {}, nil
+	case "file":
+		path := getEnv("EVENT_STORE_PATH", "events.jsonl")
+		store, err := eventstore.OpenFileEventStore(path, log)
+		if err != nil {
+			return nil, nil, err
+		}
+		log.WithField("path", path).Info("Using file event store")
+		return store, func() { _ = store.Close() }, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown event store backend %q", backend)
+	}
+}
+
+// newEmailSender creates the email channel selected by the EMAIL_SENDER environment variable.
+// Supported senders are "console" (default), which only logs emails, and "smtp", configured by the SMTP_* variables.
+func newEmailSender(renderer domain.EmailRenderer, log *logrus.Logger) (domain.NotificationChannel, func(), error) {
+	sender := getEnv("EMAIL_SENDER", "console")
+	switch sender {
+	case "console":
+		return email.NewConsoleEmailSender(renderer, log), func() {}, nil
+	case "smtp":
+		port, err := getEnvInt("SMTP_PORT", email.DefaultSMTPPort)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid SMTP_PORT: %w", err)
+		}
+		timeout, err := time.ParseDuration(getEnv("SMTP_TIMEOUT", email.DefaultSMTPTimeout.String()))
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid SMTP_TIMEOUT: %w", err)
+		}
+		maxAttempts, err := getEnvInt("SMTP_MAX_ATTEMPTS", email.DefaultSMTPMaxAttempts)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid SMTP_MAX_ATTEMPTS: %w", err)
+		}
+		backoff, err := time.ParseDuration(getEnv("SMTP_RETRY_BACKOFF", email.DefaultSMTPRetryBackoff.String()))
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid SMTP_RETRY_BACKOFF: %w", err)
+		}
+
+		smtpSender, err := email.NewSMTPEmailSender(email.SMTPConfig{
+			Host:         getEnv("SMTP_HOST", ""),
+			Port:         port,
+			Username:     getEnv("SMTP_USERNAME", ""),
+			Password:     getEnv("SMTP_PASSWORD", ""),
+			From:         getEnv("SMTP_FROM", ""),
+			TLSMode:      email.TLSMode(getEnv("SMTP_TLS", string(email.TLSOpportunistic))),
+			Timeout:      timeout,
+			MaxAttempts:  maxAttempts,
+			RetryBackoff: backoff,
+		}, renderer, log)
+		if err != nil {
+			return nil, nil, err
+		}
+		log.WithField("host", getEnv("SMTP_HOST", "")).Info("Using SMTP email sender")
+		return smtpSender, func() { _ = smtpSender.Close() }, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown email sender %q", sender)
+	}
+}
+
+// runDefaultCheck charges late fees on overdue installments and marks overdue loans as defaulted every interval
+// until the process exits
+func runDefaultCheck(service domain.Service, interval time.Duration, log *logrus.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := service.ChargeLateFees(); err != nil {
+			log.WithError(err).Error("Late fee check failed")
+		}
+		if _, err := service.MarkDefaultedLoans(); err != nil {
+			log.WithError(err).Error("Default check failed")
+		}
+	}
+}
+
+// runReminders reminds borrowers of installments falling due every interval until the process exits
+func runReminders(service domain.Service, interval time.Duration, log *logrus.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := service.RemindDueInstallments(); err != nil {
+			log.WithError(err).Error("Installment reminders failed")
+		}
+	}
+}
+
+// runDispatcher sends the due outbox notifications every interval until the process exits
+func runDispatcher(dispatcher *loan.Dispatcher, interval time.Duration, log *logrus.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		if _, err := dispatcher.Dispatch(now); err != nil {
+			log.WithError(err).Error("Outbox dispatch failed")
+		}
+	}
+}
+
+// getEnvInt returns an environment variable parsed as an integer or the fallback when it is unset
+func getEnvInt(key string, fallback int) (int, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// getEnv returns the value of an environment variable or the fallback when it is unset
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return fallback
+}
@@ -5,7 +5,7 @@ package docs
 import "github.com/swaggo/swag/v2"
 
 const docTemplate = `{
-    "schemes": {{ marshal .Schemes }},"swagger":"2.0","info":{"description":"{{escape .Description}}","title":"{{.Title}}","termsOfService":"http://swagger.io/terms/","contact":{"name":"Martinus Dawan","email":"martinuz.dawan9@gmail.com"},"version":"{{.Version}}"},"host":"{{.Host}}","basePath":"{{.BasePath}}","paths":{"/loans":{"get":{"description":"Retrieves all loans with pagination","consumes":["application/json"],"produces":["application/json"],"tags":["loans"],"summary":"Get all loans","parameters":[{"type":"integer","description":"Page number (default: 1)","name":"page","in":"query"},{"type":"integer","description":"Number of items per page (default: 10)","name":"limit","in":"query"}],"responses":{"200":{"description":"List of loans","schema":{"type":"array","items":{"$ref":"#/definitions/response.Response"}}},"500":{"description":"Internal server error","schema":{"$ref":"#/definitions/response.Response"}}}},"post":{"description":"Creates a new loan with the given borrower and loan details","consumes":["application/json"],"produces":["application/json"],"tags":["loans"],"summary":"Create a new loan","parameters":[{"description":"Loan creation request","name":"request","in":"body","required":true,"schema":{"$ref":"#/definitions/loan.CreateLoanRequest"}}],"responses":{"201":{"description":"Loan created successfully","schema":{"$ref":"#/definitions/response.Response"}},"400":{"description":"Invalid request or validation error","schema":{"$ref":"#/definitions/response.Response"}},"500":{"description":"Internal server error","schema":{"$ref":"#/definitions/response.Response"}}}}},"/loans/borrower/{borrowerId}":{"get":{"description":"Retrieves all loans associated with a borrower","consumes":["application/json"],"produces":["application/json"],"tags":["loans"],"summary":"Get loans by borrower","parameters":[{"type":"string","description":"Borrower ID","name":"borrowerId","in":"path","required":true}],"responses":{"200":{"description":"List of loans\" \"List of loans","schema":{"type":"array","items":{"$ref":"#/definitions/response.Response"}}},"500":{"description":"Internal server error","schema":{"type":"string"}}}}},"/loans/state/{state}":{"get":{"description":"Retrieves all loans in a specific state","consumes":["application/json"],"produces":["application/json"],"tags":["loans"],"summary":"Get loans by state","parameters":[{"enum":["PROPOSED","APPROVED","INVESTED","DISBURSED"],"type":"string","description":"Loan state","name":"state","in":"path","required":true}],"responses":{"200":{"description":"List of loans","schema":{"type":"array","items":{"$ref":"#/definitions/response.Response"}}},"400":{"description":"Invalid state","schema":{"type":"string"}},"500":{"description":"Internal server error","schema":{"type":"string"}}}}},"/loans/{id}":{"get":{"description":"Retrieves a loan by its ID","consumes":["application/json"],"produces":["application/json"],"tags":["loans"],"summary":"Get loan by ID","parameters":[{"type":"string","description":"Loan ID","name":"id","in":"path","required":true}],"responses":{"200":{"description":"Loan details retrieved successfully","schema":{"$ref":"#/definitions/response.Response"}},"404":{"description":"Loan not found","schema":{"$ref":"#/definitions/response.Response"}}}}},"/loans/{id}/agreement":{"post":{"description":"Generates an agreement letter for a loan","consumes":["application/json"],"produces":["application/json"],"tags":["loans"],"summary":"Generate agreement letter","parameters":[{"type":"string","description":"Loan ID","name":"id","in":"path","required":true},{"description":"Agreement letter details","name":"request","in":"body","required":true,"schema":{"$ref":"#/definitions/loan.GenerateAgreementLetterRequest"}}],"responses":{"200":{"description":"Agreement letter generated successfully","schema":{"type":"string"}},"400":{"description":"Invalid request","schema":{"type":"string"}}}}},"/loans/{id}/approve":{"post":{"description":"Approves a loan with validator details","consumes":["application/json"],"produces":["application/json"],"tags":["loans"],"summary":"Approve a loan","parameters":[{"type":"string","description":"Loan ID","name":"id","in":"path","required":true},{"description":"Loan approval request","name":"request","in":"body","required":true,"schema":{"$ref":"#/definitions/loan.ApproveLoanRequest"}}],"responses":{"200":{"description":"Loan approved successfully","schema":{"$ref":"#/definitions/response.Response"}},"400":{"description":"Invalid request or state validation error","schema":{"$ref":"#/definitions/response.Response"}}}}},"/loans/{id}/disburse":{"post":{"description":"Disburses an approved and invested loan","consumes":["application/json"],"produces":["application/json"],"tags":["loans"],"parameters":[{"type":"string","description":"Loan ID","name":"id","in":"path","required":true},{"description":"Disbursement details","name":"request","in":"body","required":true,"schema":{"$ref":"#/definitions/loan.DisburseLoanRequest"}}],"responses":{"200":{"description":"Loan disbursed successfully","schema":{"type":"string"}},"400":{"description":"Invalid request or state validation error","schema":{"type":"string"}}}}},"/loans/{id}/invest":{"post":{"description":"Adds an investment to an existing loan","consumes":["application/json"],"produces":["application/json"],"tags":["loans"],"summary":"Add investment to loan","parameters":[{"type":"string","description":"Loan ID","name":"id","in":"path","required":true},{"description":"Investment details","name":"request","in":"body","required":true,"schema":{"$ref":"#/definitions/loan.AddInvestmentRequest"}}],"responses":{"200":{"description":"Investment added successfully","schema":{"type":"string"}},"400":{"description":"Invalid request or state validation error","schema":{"type":"string"}}}}}},"definitions":{"loan.AddInvestmentRequest":{"type":"object","required":["amount","email","investor_id"],"properties":{"amount":{"type":"number","example":100000},"email":{"type":"string","example":"client@mail.com"},"investor_id":{"type":"string","example":"investor-001"}}},"loan.ApproveLoanRequest":{"type":"object","required":["proof_url","validator_id"],"properties":{"proof_url":{"type":"string","example":"https://storage.your.com/loan-proof/visit123.jpeg"},"validator_id":{"type":"string","example":"LOS-123"}}},"loan.CreateLoanRequest":{"type":"object","required":["borrower_id","principal_amount","rate","roi"],"properties":{"borrower_id":{"type":"string","example":"amr-001"},"principal_amount":{"type":"number","example":1000000},"rate":{"type":"number","minimum":0,"example":12.5},"roi":{"type":"number","minimum":0,"example":10}}},"loan.DisburseLoanRequest":{"type":"object","required":["field_officer_id","signed_agreement"],"properties":{"field_officer_id":{"type":"string","example":"OFC-001"},"signed_agreement":{"type":"string","example":"https://storage.your.com/loan-agreement/signed123.pdf"}}},"loan.GenerateAgreementLetterRequest":{"type":"object","required":["letter_url"],"properties":{"letter_url":{"type":"string"}}},"response.Response":{"type":"object","properties":{"code":{"type":"integer","example":200},"data":{},"errors":{},"message":{"type":"string","example":"OK"}}}}}`
+    "schemes": {{ marshal .Schemes }},"swagger":"2.0","info":{"description":"{{escape .Description}}","title":"{{.Title}}","termsOfService":"http://swagger.io/terms/","contact":{"name":"Martinus Dawan","email":"martinuz.dawan9@gmail.com"},"version":"{{.Version}}"},"host":"{{.Host}}","basePath":"{{.BasePath}}","paths":{"/borrowers":{"get":{"description":"Lists the borrowers in the order they registered","produces":["application/json"],"tags":["borrowers"],"summary":"Get all borrowers","parameters":[{"type":"integer","description":"Page number (default: 1)","name":"page","in":"query"},{"type":"integer","description":"Number of items per page (default: 10)","name":"limit","in":"query"}],"responses":{"200":{"description":"List of borrowers","schema":{"$ref":"#/definitions/response.Response"}},"500":{"description":"Internal server error","schema":{"$ref":"#/definitions/response.Response"}}}},"post":{"description":"Registers a borrower with their identity, monthly income and obligations. Only registered borrowers may apply for loans.","consumes":["application/json"],"produces":["application/json"],"tags":["borrowers"],"summary":"Register borrower","parameters":[{"description":"Borrower details","name":"request","in":"body","required":true,"schema":{"$ref":"#/definitions/borrower.BorrowerRequest"}}],"responses":{"201":{"description":"Borrower registered","schema":{"$ref":"#/definitions/response.Response"}},"400":{"description":"Invalid request or validation error","schema":{"$ref":"#/definitions/response.Response"}},"409":{"description":"Borrower ID already taken","schema":{"$ref":"#/definitions/response.Response"}},"500":{"description":"Internal server error","schema":{"$ref":"#/definitions/response.Response"}}}}},"/borrowers/{id}":{"get":{"description":"Retrieves a borrower with their credit profile, computed from their income, obligations and loans, and every loan they have applied for","produces":["application/json"],"tags":["borrowers"],"summary":"Get borrower","parameters":[{"type":"string","description":"Borrower ID","name":"id","in":"path","required":true}],"responses":{"200":{"description":"Borrower","schema":{"$ref":"#/definitions/response.Response"}},"404":{"description":"Borrower not found","schema":{"$ref":"#/definitions/response.Response"}},"500":{"description":"Internal server error","schema":{"$ref":"#/definitions/response.Response"}}}},"put":{"description":"Replaces the identity, monthly income and obligations and the blacklisting of a borrower","consumes":["application/json"],"produces":["application/json"],"tags":["borrowers"],"summary":"Update borrower","parameters":[{"type":"string","description":"Borrower ID","name":"id","in":"path","required":true},{"description":"Borrower details","name":"request","in":"body","required":true,"schema":{"$ref":"#/definitions/borrower.BorrowerRequest"}}],"responses":{"200":{"description":"Borrower updated","schema":{"$ref":"#/definitions/response.Response"}},"400":{"description":"Invalid request or validation error","schema":{"$ref":"#/definitions/response.Response"}},"404":{"description":"Borrower not found","schema":{"$ref":"#/definitions/response.Response"}},"500":{"description":"Internal server error","schema":{"$ref":"#/definitions/response.Response"}}}},"delete":{"description":"Removes a borrower who has not applied for any loan","produces":["application/json"],"tags":["borrowers"],"summary":"Delete borrower","parameters":[{"type":"string","description":"Borrower ID","name":"id","in":"path","required":true}],"responses":{"200":{"description":"Borrower deleted","schema":{"$ref":"#/definitions/response.Response"}},"404":{"description":"Borrower not found","schema":{"$ref":"#/definitions/response.Response"}},"409":{"description":"Borrower has loans","schema":{"$ref":"#/definitions/response.Response"}},"500":{"description":"Internal server error","schema":{"$ref":"#/definitions/response.Response"}}}}},"/documents/{id}":{"get":{"description":"Returns the content of a stored document, such as a generated agreement letter","produces":["application/pdf"],"tags":["documents"],"summary":"Download document","parameters":[{"type":"string","description":"Document ID","name":"id","in":"path","required":true}],"responses":{"200":{"description":"Document content","schema":{"type":"file"}},"404":{"description":"Document not found","schema":{"$ref":"#/definitions/response.Response"}},"500":{"description":"Internal server error","schema":{"$ref":"#/definitions/response.Response"}}}}},"/emails/agreement/preview":{"get":{"description":"Renders the agreement email of an investor of a loan, or of sample data when no loan is given. The locale overrides the investor locale.","produces":["application/json","text/html","text/plain"],"tags":["emails"],"summary":"Preview agreement email","parameters":[{"type":"string","description":"Loan ID, sample data is used when empty","name":"loan_id","in":"query"},{"type":"string","description":"Investor ID, defaults to the first investor of the loan","name":"investor_id","in":"query"},{"type":"string","description":"Locale of the template, e.g. id","name":"locale","in":"query"},{"type":"string","description":"Response format: json (default), html or text","name":"format","in":"query"}],"responses":{"200":{"description":"Rendered email","schema":{"$ref":"#/definitions/response.Response"}},"400":{"description":"Invalid request","schema":{"$ref":"#/definitions/response.Response"}},"404":{"description":"Loan or investor not found","schema":{"$ref":"#/definitions/response.Response"}},"500":{"description":"Failed to render email","schema":{"$ref":"#/definitions/response.Response"}}}}},"/investors":{"post":{"description":"Registers an investor pending their KYC review. Only verified investors may invest.","consumes":["application/json"],"produces":["application/json"],"tags":["investors"],"summary":"Register investor","parameters":[{"description":"Investor details","name":"request","in":"body","required":true,"schema":{"$ref":"#/definitions/investor.InvestorRequest"}}],"responses":{"201":{"description":"Investor registered","schema":{"$ref":"#/definitions/response.Response"}},"400":{"description":"Invalid request or validation error","schema":{"$ref":"#/definitions/response.Response"}},"409":{"description":"Investor ID already taken","schema":{"$ref":"#/definitions/response.Response"}},"500":{"description":"Internal server error","schema":{"$ref":"#/definitions/response.Response"}}}}},"/investors/{id}":{"get":{"description":"Retrieves the contact details, KYC status and investment limits of an investor","produces":["application/json"],"tags":["investors"],"summary":"Get investor","parameters":[{"type":"string","description":"Investor ID","name":"id","in":"path","required":true}],"responses":{"200":{"description":"Investor","schema":{"$ref":"#/definitions/response.Response"}},"404":{"description":"Investor not found","schema":{"$ref":"#/definitions/response.Response"}},"500":{"description":"Internal server error","schema":{"$ref":"#/definitions/response.Response"}}}},"put":{"description":"Replaces the contact details and investment limits of an investor, their KYC status is kept","consumes":["application/json"],"produces":["application/json"],"tags":["investors"],"summary":"Update investor","parameters":[{"type":"string","description":"Investor ID","name":"id","in":"path","required":true},{"description":"Investor details","name":"request","in":"body","required":true,"schema":{"$ref":"#/definitions/investor.InvestorRequest"}}],"responses":{"200":{"description":"Investor updated","schema":{"$ref":"#/definitions/response.Response"}},"400":{"description":"Invalid request or validation error","schema":{"$ref":"#/definitions/response.Response"}},"404":{"description":"Investor not found","schema":{"$ref":"#/definitions/response.Response"}},"500":{"description":"Internal server error","schema":{"$ref":"#/definitions/response.Response"}}}}},"/investors/{id}/kyc":{"put":{"description":"Records whether the identity of an investor is verified. Only VERIFIED investors may invest.","consumes":["application/json"],"produces":["application/json"],"tags":["investors"],"summary":"Set KYC status","parameters":[{"type":"string","description":"Investor ID","name":"id","in":"path","required":true},{"description":"KYC status","name":"request","in":"body","required":true,"schema":{"$ref":"#/definitions/investor.KYCRequest"}}],"responses":{"200":{"description":"KYC status recorded","schema":{"$ref":"#/definitions/response.Response"}},"400":{"description":"Invalid request or validation error","schema":{"$ref":"#/definitions/response.Response"}},"404":{"description":"Investor not found","schema":{"$ref":"#/definitions/response.Response"}},"500":{"description":"Internal server error","schema":{"$ref":"#/definitions/response.Response"}}}}},"/investors/{id}/portfolio":{"get":{"description":"Lists every loan the investor has funded with the amount committed, the expected return and the current loan state, and totals the outstanding loans per currency","produces":["application/json"],"tags":["investors"],"summary":"Get investor portfolio","parameters":[{"type":"string","description":"Investor ID","name":"id","in":"path","required":true}],"responses":{"200":{"description":"Portfolio","schema":{"$ref":"#/definitions/response.Response"}},"404":{"description":"Investor not found","schema":{"$ref":"#/definitions/response.Response"}},"500":{"description":"Internal server error","schema":{"$ref":"#/definitions/response.Response"}}}}},"/loans":{"get":{"description":"Retrieves all loans with pagination","consumes":["application/json"],"produces":["application/json"],"tags":["loans"],"summary":"Get all loans","parameters":[{"type":"integer","description":"Page number (default: 1)","name":"page","in":"query"},{"type":"integer","description":"Number of items per page (default: 10)","name":"limit","in":"query"},{"enum":["IDR","USD"],"type":"string","description":"Only loans in this currency","name":"currency","in":"query"}],"responses":{"200":{"description":"List of loans","schema":{"type":"array","items":{"$ref":"#/definitions/response.Response"}}},"400":{"description":"Invalid currency","schema":{"$ref":"#/definitions/response.Response"}},"500":{"description":"Internal server error","schema":{"$ref":"#/definitions/response.Response"}}}},"post":{"description":"Creates a new loan with the given borrower and loan details. The borrower must be registered, not blacklisted and allowed another loan by the borrower policy. The rate and ROI are priced by the rate card from the risk grade, tenor and principal of the loan; values given instead must lie within the corridors around the priced ones, with the ROI below the rate.","consumes":["application/json"],"produces":["application/json"],"tags":["loans"],"summary":"Create a new loan","parameters":[{"description":"Loan creation request","name":"request","in":"body","required":true,"schema":{"$ref":"#/definitions/loan.CreateLoanRequest"}}],"responses":{"201":{"description":"Loan created successfully","schema":{"$ref":"#/definitions/response.Response"},"headers":{"ETag":{"type":"string","description":"Loan version"}}},"400":{"description":"Invalid request, validation error, unknown borrower, or rate or ROI outside its corridor or not leaving a margin","schema":{"$ref":"#/definitions/response.Response"}},"403":{"description":"Borrower blacklisted","schema":{"$ref":"#/definitions/response.Response"}},"409":{"description":"Borrower holds too many active loans or applied too recently","schema":{"$ref":"#/definitions/response.Response"}},"500":{"description":"Internal server error","schema":{"$ref":"#/definitions/response.Response"}}}}},"/loans/borrower/{borrowerId}":{"get":{"description":"Retrieves all loans associated with a borrower","consumes":["application/json"],"produces":["application/json"],"tags":["loans"],"summary":"Get loans by borrower","parameters":[{"type":"string","description":"Borrower ID","name":"borrowerId","in":"path","required":true},{"enum":["IDR","USD"],"type":"string","description":"Only loans in this currency","name":"currency","in":"query"}],"responses":{"200":{"description":"List of loans\" \"List of loans","schema":{"type":"array","items":{"$ref":"#/definitions/response.Response"}}},"400":{"description":"Invalid currency","schema":{"$ref":"#/definitions/response.Response"}},"500":{"description":"Internal server error","schema":{"type":"string"}}}}},"/loans/state/{state}":{"get":{"description":"Retrieves all loans in a specific state","consumes":["application/json"],"produces":["application/json"],"tags":["loans"],"summary":"Get loans by state","parameters":[{"enum":["PROPOSED","APPROVED","INVESTED","DISBURSED"],"type":"string","description":"Loan state","name":"state","in":"path","required":true},{"enum":["IDR","USD"],"type":"string","description":"Only loans in this currency","name":"currency","in":"query"}],"responses":{"200":{"description":"List of loans","schema":{"type":"array","items":{"$ref":"#/definitions/response.Response"}}},"400":{"description":"Invalid state","schema":{"type":"string"}},"500":{"description":"Internal server error","schema":{"type":"string"}}}}},"/loans/{id}":{"get":{"description":"Retrieves a loan by its ID","consumes":["application/json"],"produces":["application/json"],"tags":["loans"],"summary":"Get loan by ID","parameters":[{"type":"string","description":"Loan ID","name":"id","in":"path","required":true}],"responses":{"200":{"description":"Loan details retrieved successfully","schema":{"$ref":"#/definitions/response.Response"},"headers":{"ETag":{"type":"string","description":"Loan version"}}},"404":{"description":"Loan not found","schema":{"$ref":"#/definitions/response.Response"}}}}},"/loans/{id}/agreement":{"post":{"description":"Renders the agreement letter of an APPROVED or INVESTED loan again as a PDF from its current terms and investors, stores it and attaches its URL to the loan. The letter is generated when the loan is approved; regenerating it keeps every earlier version.","produces":["application/json"],"tags":["loans"],"summary":"Regenerate agreement letter","parameters":[{"type":"string","description":"Loan ID","name":"id","in":"path","required":true},{"type":"string","description":"Expected loan version (ETag)","name":"If-Match","in":"header"}],"responses":{"200":{"description":"Agreement letter generated successfully","schema":{"$ref":"#/definitions/response.Response"},"headers":{"ETag":{"type":"string","description":"Loan version"}}},"400":{"description":"Loan is not APPROVED or INVESTED","schema":{"$ref":"#/definitions/response.Response"}},"404":{"description":"Loan not found","schema":{"$ref":"#/definitions/response.Response"}},"409":{"description":"Loan was modified concurrently","schema":{"$ref":"#/definitions/response.Response"}},"412":{"description":"If-Match does not match the loan version","schema":{"$ref":"#/definitions/response.Response"}},"500":{"description":"Failed to render or store the agreement letter","schema":{"$ref":"#/definitions/response.Response"}}}}},"/loans/{id}/agreements":{"get":{"description":"Lists every agreement letter generated for a loan, oldest first, with its version, document and URL. The current letter, the one to sign, is marked.","produces":["application/json"],"tags":["loans"],"summary":"List agreement letters","parameters":[{"type":"string","description":"Loan ID","name":"id","in":"path","required":true}],"responses":{"200":{"description":"Agreement letters","schema":{"$ref":"#/definitions/response.Response"}},"404":{"description":"Loan not found","schema":{"$ref":"#/definitions/response.Response"}},"500":{"description":"Internal server error","schema":{"$ref":"#/definitions/response.Response"}}}}},"/loans/{id}/approve":{"post":{"description":"Approves a loan with validator details and the proof of the field visit, uploaded beforehand as a PROOF document of the loan, and generates its agreement letter. A loan graded riskier than the minimum grade needs an override justification.","consumes":["application/json"],"produces":["application/json"],"tags":["loans"],"summary":"Approve a loan","parameters":[{"type":"string","description":"Loan ID","name":"id","in":"path","required":true},{"description":"Loan approval request","name":"request","in":"body","required":true,"schema":{"$ref":"#/definitions/loan.ApproveLoanRequest"}},{"type":"string","description":"Expected loan version (ETag)","name":"If-Match","in":"header"}],"responses":{"200":{"description":"Loan approved successfully","schema":{"$ref":"#/definitions/response.Response"},"headers":{"ETag":{"type":"string","description":"Loan version"}}},"400":{"description":"Invalid request or state validation error","schema":{"$ref":"#/definitions/response.Response"}},"403":{"description":"Loan grade is below the minimum and no override justification was given","schema":{"$ref":"#/definitions/response.Response"}},"404":{"description":"Loan not found","schema":{"$ref":"#/definitions/response.Response"}},"409":{"description":"Loan was modified concurrently","schema":{"$ref":"#/definitions/response.Response"}},"412":{"description":"If-Match does not match the loan version","schema":{"$ref":"#/definitions/response.Response"}}}}},"/loans/{id}/cancel":{"post":{"description":"Cancels a proposed or approved loan; investors of a partially funded loan are refunded and notified","consumes":["application/json"],"produces":["application/json"],"tags":["loans"],"summary":"Cancel a loan","parameters":[{"type":"string","description":"Loan ID","name":"id","in":"path","required":true},{"description":"Loan cancellation request","name":"request","in":"body","required":true,"schema":{"$ref":"#/definitions/loan.CloseLoanRequest"}},{"type":"string","description":"Expected loan version (ETag)","name":"If-Match","in":"header"}],"responses":{"200":{"description":"Loan cancelled successfully","schema":{"$ref":"#/definitions/response.Response"},"headers":{"ETag":{"type":"string","description":"Loan version"}}},"400":{"description":"Invalid request or state validation error","schema":{"$ref":"#/definitions/response.Response"}},"404":{"description":"Loan not found","schema":{"$ref":"#/definitions/response.Response"}},"409":{"description":"Loan was modified concurrently","schema":{"$ref":"#/definitions/response.Response"}},"412":{"description":"If-Match does not match the loan version","schema":{"$ref":"#/definitions/response.Response"}}}}},"/loans/{id}/disburse":{"post":{"description":"Disburses an approved and invested loan against the agreement signed by the borrower, uploaded beforehand as a SIGNED_AGREEMENT document of the loan. The signed agreement must be the agreement letter generated for the loan with the required signatures appended, otherwise the disbursement is refused with the problems found.","consumes":["application/json"],"produces":["application/json"],"tags":["loans"],"parameters":[{"type":"string","description":"Loan ID","name":"id","in":"path","required":true},{"description":"Disbursement details","name":"request","in":"body","required":true,"schema":{"$ref":"#/definitions/loan.DisburseLoanRequest"}},{"type":"string","description":"Expected loan version (ETag)","name":"If-Match","in":"header"}],"responses":{"200":{"description":"Loan disbursed successfully","schema":{"type":"string"},"headers":{"ETag":{"type":"string","description":"Loan version"}}},"400":{"description":"Invalid request, state validation error or unverified signed agreement","schema":{"type":"string"}},"404":{"description":"Loan not found","schema":{"$ref":"#/definitions/response.Response"}},"409":{"description":"Loan was modified concurrently","schema":{"$ref":"#/definitions/response.Response"}},"412":{"description":"If-Match does not match the loan version","schema":{"$ref":"#/definitions/response.Response"}}}}},"/loans/{id}/documents":{"post":{"description":"Uploads the proof of the field visit (JPEG, PNG or PDF) or the signed agreement (PDF) of a loan. The content type is detected from the content; the response holds the document ID to approve or disburse the loan with and the SHA-256 checksum of the content.","consumes":["multipart/form-data"],"produces":["application/json"],"tags":["loans"],"summary":"Upload a document","parameters":[{"type":"string","description":"Loan ID","name":"id","in":"path","required":true},{"enum":["PROOF","SIGNED_AGREEMENT"],"type":"string","description":"Document kind","name":"kind","in":"formData","required":true},{"type":"file","description":"Document content","name":"file","in":"formData","required":true}],"responses":{"201":{"description":"Document uploaded successfully","schema":{"$ref":"#/definitions/response.Response"}},"400":{"description":"Invalid request or validation error","schema":{"$ref":"#/definitions/response.Response"}},"404":{"description":"Loan not found","schema":{"$ref":"#/definitions/response.Response"}},"413":{"description":"Document too large","schema":{"$ref":"#/definitions/response.Response"}},"415":{"description":"Content type not accepted for the kind","schema":{"$ref":"#/definitions/response.Response"}},"500":{"description":"Failed to store the document","schema":{"$ref":"#/definitions/response.Response"}}}}},"/loans/{id}/history":{"get":{"description":"Retrieves every recorded mutation of a loan, oldest first, with the actor, action, state before and after, payload digest and timestamp","consumes":["application/json"],"produces":["application/json"],"tags":["loans"],"summary":"Get loan history","parameters":[{"type":"string","description":"Loan ID","name":"id","in":"path","required":true}],"responses":{"200":{"description":"Loan history","schema":{"$ref":"#/definitions/response.Response"}},"404":{"description":"Loan not found","schema":{"$ref":"#/definitions/response.Response"}},"500":{"description":"Internal server error","schema":{"$ref":"#/definitions/response.Response"}}}}},"/loans/{id}/invest":{"post":{"description":"Adds an investment to an existing loan. The investor must be registered and KYC verified, and the investment must stay within their limits.","consumes":["application/json"],"produces":["application/json"],"tags":["loans"],"summary":"Add investment to loan","parameters":[{"type":"string","description":"Loan ID","name":"id","in":"path","required":true},{"description":"Investment details","name":"request","in":"body","required":true,"schema":{"$ref":"#/definitions/loan.AddInvestmentRequest"}},{"type":"string","description":"Expected loan version (ETag)","name":"If-Match","in":"header"}],"responses":{"200":{"description":"Investment added successfully","schema":{"type":"string"},"headers":{"ETag":{"type":"string","description":"Loan version"}}},"400":{"description":"Invalid request, state validation error, unknown investor or investment limit exceeded","schema":{"type":"string"}},"403":{"description":"Investor is not KYC verified","schema":{"$ref":"#/definitions/response.Response"}},"404":{"description":"Loan not found","schema":{"$ref":"#/definitions/response.Response"}},"409":{"description":"Loan was modified concurrently","schema":{"$ref":"#/definitions/response.Response"}},"412":{"description":"If-Match does not match the loan version","schema":{"$ref":"#/definitions/response.Response"}}}}},"/loans/{id}/reject":{"post":{"description":"Rejects a proposed loan with the reason code and the actor who rejected it","consumes":["application/json"],"produces":["application/json"],"tags":["loans"],"summary":"Reject a loan","parameters":[{"type":"string","description":"Loan ID","name":"id","in":"path","required":true},{"description":"Loan rejection request","name":"request","in":"body","required":true,"schema":{"$ref":"#/definitions/loan.CloseLoanRequest"}},{"type":"string","description":"Expected loan version (ETag)","name":"If-Match","in":"header"}],"responses":{"200":{"description":"Loan rejected successfully","schema":{"$ref":"#/definitions/response.Response"},"headers":{"ETag":{"type":"string","description":"Loan version"}}},"400":{"description":"Invalid request or state validation error","schema":{"$ref":"#/definitions/response.Response"}},"404":{"description":"Loan not found","schema":{"$ref":"#/definitions/response.Response"}},"409":{"description":"Loan was modified concurrently","schema":{"$ref":"#/definitions/response.Response"}},"412":{"description":"If-Match does not match the loan version","schema":{"$ref":"#/definitions/response.Response"}}}}},"/loans/{id}/repayments":{"post":{"description":"Records a borrower payment and allocates it to the installments, fees first, then interest, then principal","consumes":["application/json"],"produces":["application/json"],"tags":["loans"],"summary":"Record a repayment","parameters":[{"type":"string","description":"Loan ID","name":"id","in":"path","required":true},{"description":"Repayment details","name":"request","in":"body","required":true,"schema":{"$ref":"#/definitions/loan.RecordRepaymentRequest"}},{"type":"string","description":"Expected loan version (ETag)","name":"If-Match","in":"header"}],"responses":{"201":{"description":"Repayment recorded successfully","schema":{"$ref":"#/definitions/response.Response"},"headers":{"ETag":{"type":"string","description":"Loan version"}}},"400":{"description":"Invalid request, state validation error or overpayment","schema":{"$ref":"#/definitions/response.Response"}},"404":{"description":"Loan not found","schema":{"$ref":"#/definitions/response.Response"}},"409":{"description":"Loan was modified concurrently","schema":{"$ref":"#/definitions/response.Response"}},"412":{"description":"If-Match does not match the loan version","schema":{"$ref":"#/definitions/response.Response"}}}}},"/loans/{id}/schedule":{"get":{"description":"Retrieves the installments of a disbursed loan with their due date, principal and interest","consumes":["application/json"],"produces":["application/json"],"tags":["loans"],"summary":"Get repayment schedule","parameters":[{"type":"string","description":"Loan ID","name":"id","in":"path","required":true}],"responses":{"200":{"description":"Repayment schedule","schema":{"$ref":"#/definitions/response.Response"}},"404":{"description":"Loan not found or not disbursed yet","schema":{"$ref":"#/definitions/response.Response"}},"500":{"description":"Internal server error","schema":{"$ref":"#/definitions/response.Response"}}}}},"/loans/{id}/transitions":{"get":{"description":"Lists the actions allowed in the current state of a loan and the states each may lead to","consumes":["application/json"],"produces":["application/json"],"tags":["loans"],"summary":"Get allowed transitions","parameters":[{"type":"string","description":"Loan ID","name":"id","in":"path","required":true}],"responses":{"200":{"description":"Allowed transitions","schema":{"$ref":"#/definitions/response.Response"}},"404":{"description":"Loan not found","schema":{"$ref":"#/definitions/response.Response"}},"500":{"description":"Internal server error","schema":{"$ref":"#/definitions/response.Response"}}}}},"/recipients/{id}/notifications":{"get":{"description":"Lists the notifications delivered to the in-app inbox of a borrower or investor, newest first","produces":["application/json"],"tags":["notifications"],"summary":"Get in-app notifications","parameters":[{"type":"string","description":"Borrower or investor ID","name":"id","in":"path","required":true}],"responses":{"200":{"description":"In-app notifications","schema":{"$ref":"#/definitions/response.Response"}},"500":{"description":"Internal server error","schema":{"$ref":"#/definitions/response.Response"}}}}},"/recipients/{id}/notifications/{notificationId}/read":{"post":{"description":"Records that a borrower or investor read one of their in-app notifications","produces":["application/json"],"tags":["notifications"],"summary":"Mark notification as read","parameters":[{"type":"string","description":"Borrower or investor ID","name":"id","in":"path","required":true},{"type":"string","description":"Notification ID","name":"notificationId","in":"path","required":true}],"responses":{"200":{"description":"Notification marked as read","schema":{"$ref":"#/definitions/response.Response"}},"404":{"description":"Notification not found","schema":{"$ref":"#/definitions/response.Response"}},"500":{"description":"Internal server error","schema":{"$ref":"#/definitions/response.Response"}}}}},"/recipients/{id}/preferences":{"get":{"description":"Retrieves how a borrower or investor is notified, the defaults when they have not set any","produces":["application/json"],"tags":["notifications"],"summary":"Get notification preferences","parameters":[{"type":"string","description":"Borrower or investor ID","name":"id","in":"path","required":true}],"responses":{"200":{"description":"Notification preferences","schema":{"$ref":"#/definitions/response.Response"}},"500":{"description":"Internal server error","schema":{"$ref":"#/definitions/response.Response"}}}},"put":{"description":"Replaces the channels, contact details and muted notification kinds of a borrower or investor. Agreements and refunds are sent even when muted.","consumes":["application/json"],"produces":["application/json"],"tags":["notifications"],"summary":"Set notification preferences","parameters":[{"type":"string","description":"Borrower or investor ID","name":"id","in":"path","required":true},{"description":"Notification preferences","name":"request","in":"body","required":true,"schema":{"$ref":"#/definitions/notification.PreferencesRequest"}}],"responses":{"200":{"description":"Preferences saved","schema":{"$ref":"#/definitions/response.Response"}},"400":{"description":"Invalid request or validation error","schema":{"$ref":"#/definitions/response.Response"}},"500":{"description":"Internal server error","schema":{"$ref":"#/definitions/response.Response"}}}}}},"definitions":{"borrower.BorrowerRequest":{"type":"object","required":["email","name","national_id"],"properties":{"address":{"type":"string","maxLength":255,"example":"Jl. Sudirman 1, Jakarta"},"blacklist_reason":{"type":"string","maxLength":255,"example":""},"blacklisted":{"description":"Blacklisted borrowers may not apply for new loans, BlacklistReason is then required","type":"boolean","example":false},"currency":{"description":"Currency of the income and obligations, the default currency when left out","type":"string","example":"IDR"},"email":{"type":"string","example":"siti@example.com"},"id":{"description":"ID is generated when a borrower is registered without one, it is ignored on update","type":"string","maxLength":64,"example":"borrower-001"},"monthly_income":{"description":"MonthlyIncome and MonthlyObligations are in Currency","type":"string","minLength":0,"example":"10000000"},"monthly_obligations":{"type":"string","minLength":0,"example":"1500000"},"name":{"type":"string","maxLength":100,"example":"Siti Rahayu"},"national_id":{"type":"string","maxLength":32,"example":"3171234567890001"},"phone":{"type":"string","example":"+628123456789"}}},"investor.InvestorRequest":{"type":"object","required":["email","name"],"properties":{"email":{"type":"string","example":"budi@example.com"},"id":{"description":"ID is generated when an investor is registered without one, it is ignored on update","type":"string","maxLength":64,"example":"investor-001"},"locale":{"type":"string","example":"id"},"name":{"type":"string","maxLength":100,"example":"Budi Santoso"},"per_loan_limit":{"description":"PerLoanLimit caps what the investor commits to a single loan, not enforced when left out","allOf":[{"$ref":"#/definitions/investor.LimitRequest"}]},"phone":{"type":"string","example":"+628123456789"},"total_limit":{"description":"TotalLimit caps what the investor has committed to outstanding loans, not enforced when left out","allOf":[{"$ref":"#/definitions/investor.LimitRequest"}]}}},"investor.KYCRequest":{"type":"object","required":["status"],"properties":{"status":{"type":"string","enum":["PENDING","VERIFIED","REJECTED"],"example":"VERIFIED"}}},"investor.LimitRequest":{"type":"object","required":["amount"],"properties":{"amount":{"type":"string","example":"50000000"},"currency":{"type":"string","example":"IDR"}}},"loan.AddInvestmentRequest":{"type":"object","required":["amount","investor_id"],"properties":{"amount":{"type":"string","example":"100000"},"currency":{"type":"string","example":"IDR"},"email":{"type":"string","example":"client@mail.com"},"investor_id":{"type":"string","example":"investor-001"},"locale":{"description":"Locale picks the language of the agreement email","type":"string","example":"id"},"name":{"description":"Name, Email and Locale default to the registered investor profile","type":"string","maxLength":100,"example":"Budi Santoso"}}},"loan.ApproveLoanRequest":{"type":"object","required":["proof_document_id","validator_id"],"properties":{"override_justification":{"description":"OverrideJustification is required to approve a loan graded riskier than the minimum grade","type":"string","maxLength":500,"example":"Collateral covers the principal twice"},"proof_document_id":{"description":"ProofDocumentID is the ID of a PROOF document uploaded to the loan","type":"string","example":"5f0c7a52-4be0-4c6b-9d6c-1f0f0e5b8a11"},"validator_id":{"type":"string","example":"LOS-123"}}},"loan.CloseLoanRequest":{"type":"object","required":["actor_id","reason_code"],"properties":{"actor_id":{"type":"string","example":"LOS-123"},"reason_code":{"type":"string","enum":["INCOMPLETE_DOCUMENTS","CREDIT_RISK","FRAUD_SUSPECTED","BORROWER_WITHDRAWN","FUNDING_EXPIRED","OTHER"],"example":"CREDIT_RISK"}}},"loan.CreateLoanRequest":{"type":"object","required":["borrower_id","principal_amount"],"properties":{"borrower_id":{"type":"string","example":"amr-001"},"currency":{"type":"string","example":"IDR"},"principal_amount":{"type":"string","example":"1000000"},"rate":{"type":"string","minLength":0,"example":"12.5"},"repayment_scheme":{"type":"string","enum":["FLAT","ANNUITY","BULLET"],"example":"ANNUITY"},"roi":{"type":"string","minLength":0,"example":"10"},"tenor_months":{"type":"integer","maximum":360,"minimum":1,"example":12}}},"loan.DisburseLoanRequest":{"type":"object","required":["field_officer_id","signed_agreement_document_id"],"properties":{"field_officer_id":{"type":"string","example":"OFC-001"},"signed_agreement_document_id":{"description":"SignedAgreementDocumentID is the ID of a SIGNED_AGREEMENT document uploaded to the loan","type":"string","example":"9b2d1e3f-6a7c-4d8e-b1f2-3c4d5e6f7a8b"}}},"loan.RecordRepaymentRequest":{"type":"object","required":["amount"],"properties":{"amount":{"type":"string","example":"88000"},"currency":{"type":"string","example":"IDR"}}},"notification.PreferencesRequest":{"type":"object","properties":{"channels":{"type":"array","items":{"type":"string"},"example":["EMAIL","IN_APP"]},"email":{"type":"string","example":"budi@example.com"},"locale":{"type":"string","example":"id"},"muted":{"type":"array","items":{"type":"string"},"example":["REPAYMENT_DISTRIBUTED"]},"phone":{"type":"string","example":"+628123456789"}}},"response.Response":{"type":"object","properties":{"code":{"type":"integer","example":200},"data":{},"errors":{},"message":{"type":"string","example":"OK"}}}}}`
 
 // SwaggerInfo holds exported Swagger Info so clients can modify it
 var SwaggerInfo = &swag.Spec{
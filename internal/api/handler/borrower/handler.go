@@ -0,0 +1,216 @@
+package borrower
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	"github.com/hinha/los-technical/internal/domain/response"
+	"github.com/hinha/los-technical/internal/pkg/money"
+	"github.com/hinha/los-technical/internal/pkg/utils"
+	"github.com/labstack/echo/v4"
+)
+
+// Handler handles HTTP requests for borrowers and their credit profiles
+type Handler struct {
+	service   domain.BorrowerService
+	validator *validator.Validate
+}
+
+// NewHandler creates a new borrower handler
+func NewHandler(service domain.BorrowerService) *Handler {
+	validate := validator.New()
+	_ = validate.RegisterValidation("supportedCurrency", utils.ValidateCurrency)
+	validate.RegisterCustomTypeFunc(utils.DecimalValue, money.Decimal{})
+
+	return &Handler{
+		service:   service,
+		validator: validate,
+	}
+}
+
+// RegisterRoutes registers the borrower routes
+func (h *Handler) RegisterRoutes(e *echo.Echo) {
+	e.POST("/borrowers", h.RegisterBorrower)
+	e.GET("/borrowers", h.GetBorrowers)
+	e.GET("/borrowers/:id", h.GetBorrower)
+	e.PUT("/borrowers/:id", h.UpdateBorrower)
+	e.DELETE("/borrowers/:id", h.DeleteBorrower)
+}
+
+// errorStatus maps service errors that have a dedicated HTTP status, falling back to the given one
+func errorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, domain.ErrBorrowerNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrBorrowerAlreadyExists),
+		errors.Is(err, domain.ErrBorrowerHasLoans):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrInvalidBorrower),
+		errors.Is(err, money.ErrUnsupportedCurrency),
+		errors.Is(err, money.ErrTooPrecise):
+		return http.StatusBadRequest
+	}
+	return fallback
+}
+
+// BorrowerRequest represents the request body for registering or updating a borrower
+type BorrowerRequest struct {
+	// ID is generated when a borrower is registered without one, it is ignored on update
+	ID         string `json:"id" validate:"omitempty,max=64" example:"borrower-001"`
+	Name       string `json:"name" validate:"required,max=100" example:"Siti Rahayu"`
+	NationalID string `json:"national_id" validate:"required,max=32" example:"3171234567890001"`
+	Email      string `json:"email" validate:"required,email" example:"siti@example.com"`
+	Phone      string `json:"phone" validate:"omitempty,e164" example:"+628123456789"`
+	Address    string `json:"address" validate:"omitempty,max=255" example:"Jl. Sudirman 1, Jakarta"`
+	// MonthlyIncome and MonthlyObligations are in Currency
+	MonthlyIncome      money.Decimal `json:"monthly_income" validate:"gte=0" swaggertype:"string" example:"10000000"`
+	MonthlyObligations money.Decimal `json:"monthly_obligations" validate:"gte=0" swaggertype:"string" example:"1500000"`
+	// Currency of the income and obligations, the default currency when left out
+	Currency string `json:"currency" validate:"omitempty,supportedCurrency" example:"IDR"`
+	// Blacklisted borrowers may not apply for new loans, BlacklistReason is then required
+	Blacklisted     bool   `json:"blacklisted" example:"false"`
+	BlacklistReason string `json:"blacklist_reason" validate:"required_if=Blacklisted true,max=255" example:""`
+}
+
+// borrower returns the borrower the request describes
+func (r BorrowerRequest) borrower(id string) domain.Borrower {
+	currency := r.Currency
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+	return domain.Borrower{
+		ID:                 id,
+		Name:               r.Name,
+		NationalID:         r.NationalID,
+		Email:              r.Email,
+		Phone:              r.Phone,
+		Address:            r.Address,
+		MonthlyIncome:      money.New(r.MonthlyIncome, currency),
+		MonthlyObligations: money.New(r.MonthlyObligations, currency),
+		Blacklisted:        r.Blacklisted,
+		BlacklistReason:    r.BlacklistReason,
+	}
+}
+
+// RegisterBorrower handles registering a new borrower
+// @Summary Register borrower
+// @Description Registers a borrower with their identity, monthly income and obligations. Only registered borrowers may apply for loans.
+// @Tags borrowers
+// @Accept json
+// @Produce json
+// @Param request body BorrowerRequest true "Borrower details"
+// @Success 201 {object} response.Response "Borrower registered"
+// @Failure 400 {object} response.Response "Invalid request or validation error"
+// @Failure 409 {object} response.Response "Borrower ID already taken"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /borrowers [post]
+func (h *Handler) RegisterBorrower(c echo.Context) error {
+	var req BorrowerRequest
+	if err := c.Bind(&req); err != nil {
+		return response.DefaultResponse(c, "Invalid request body", nil, nil, http.StatusBadRequest)
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return response.DefaultResponse(c, "Validation error", nil, err.Error(), http.StatusBadRequest)
+	}
+
+	borrower, err := h.service.RegisterBorrower(req.borrower(req.ID))
+	if err != nil {
+		return response.DefaultResponse(c, "Failed to register borrower", nil, err.Error(), errorStatus(err, http.StatusInternalServerError))
+	}
+	return response.DefaultResponse(c, "OK", borrower, nil, http.StatusCreated)
+}
+
+// GetBorrowers handles listing the borrowers with pagination
+// @Summary Get all borrowers
+// @Description Lists the borrowers in the order they registered
+// @Tags borrowers
+// @Produce json
+// @Param page query int false "Page number (default: 1)"
+// @Param limit query int false "Number of items per page (default: 10)"
+// @Success 200 {object} response.Response "List of borrowers"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /borrowers [get]
+func (h *Handler) GetBorrowers(c echo.Context) error {
+	page, err := strconv.Atoi(c.QueryParam("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(c.QueryParam("limit"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+
+	borrowers, err := h.service.GetBorrowers(page, limit)
+	if err != nil {
+		return response.DefaultResponse(c, "Failed to retrieve borrowers", nil, err.Error(), http.StatusInternalServerError)
+	}
+	return response.DefaultResponse(c, "OK", borrowers, nil, http.StatusOK)
+}
+
+// GetBorrower handles retrieving a borrower with their credit profile and loans
+// @Summary Get borrower
+// @Description Retrieves a borrower with their credit profile, computed from their income, obligations and loans, and every loan they have applied for
+// @Tags borrowers
+// @Produce json
+// @Param id path string true "Borrower ID"
+// @Success 200 {object} response.Response "Borrower"
+// @Failure 404 {object} response.Response "Borrower not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /borrowers/{id} [get]
+func (h *Handler) GetBorrower(c echo.Context) error {
+	borrower, err := h.service.GetBorrower(c.Param("id"))
+	if err != nil {
+		return response.DefaultResponse(c, "Failed to retrieve borrower", nil, err.Error(), errorStatus(err, http.StatusInternalServerError))
+	}
+	return response.DefaultResponse(c, "OK", borrower, nil, http.StatusOK)
+}
+
+// UpdateBorrower handles replacing the details of a borrower
+// @Summary Update borrower
+// @Description Replaces the identity, monthly income and obligations and the blacklisting of a borrower
+// @Tags borrowers
+// @Accept json
+// @Produce json
+// @Param id path string true "Borrower ID"
+// @Param request body BorrowerRequest true "Borrower details"
+// @Success 200 {object} response.Response "Borrower updated"
+// @Failure 400 {object} response.Response "Invalid request or validation error"
+// @Failure 404 {object} response.Response "Borrower not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /borrowers/{id} [put]
+func (h *Handler) UpdateBorrower(c echo.Context) error {
+	var req BorrowerRequest
+	if err := c.Bind(&req); err != nil {
+		return response.DefaultResponse(c, "Invalid request body", nil, nil, http.StatusBadRequest)
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return response.DefaultResponse(c, "Validation error", nil, err.Error(), http.StatusBadRequest)
+	}
+
+	borrower, err := h.service.UpdateBorrower(req.borrower(c.Param("id")))
+	if err != nil {
+		return response.DefaultResponse(c, "Failed to update borrower", nil, err.Error(), errorStatus(err, http.StatusInternalServerError))
+	}
+	return response.DefaultResponse(c, "OK", borrower, nil, http.StatusOK)
+}
+
+// DeleteBorrower handles removing a borrower
+// @Summary Delete borrower
+// @Description Removes a borrower who has not applied for any loan
+// @Tags borrowers
+// @Produce json
+// @Param id path string true "Borrower ID"
+// @Success 200 {object} response.Response "Borrower deleted"
+// @Failure 404 {object} response.Response "Borrower not found"
+// @Failure 409 {object} response.Response "Borrower has loans"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /borrowers/{id} [delete]
+func (h *Handler) DeleteBorrower(c echo.Context) error {
+	if err := h.service.DeleteBorrower(c.Param("id")); err != nil {
+		return response.DefaultResponse(c, "Failed to delete borrower", nil, err.Error(), errorStatus(err, http.StatusInternalServerError))
+	}
+	return response.DefaultResponse(c, "OK", nil, nil, http.StatusOK)
+}
@@ -0,0 +1,227 @@
+package borrower
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	mock "github.com/hinha/los-technical/internal/domain/loan/mock"
+	"github.com/hinha/los-technical/internal/pkg/money"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// call runs the handler on a request about borrower-1 and returns the recorded response and its message
+func call(t *testing.T, method, body string, handle func(echo.Context) error) (*httptest.ResponseRecorder, string) {
+	t.Helper()
+	req := httptest.NewRequest(method, "/", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("borrower-1")
+
+	assert.NoError(t, handle(c))
+
+	var response map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &response)
+	message, _ := response["message"].(string)
+	return rec, message
+}
+
+func TestRegisterBorrower(t *testing.T) {
+	testCases := []struct {
+		name           string
+		body           string
+		mockSetup      func(*mock.MockBorrowerService)
+		expectedStatus int
+		expectedMsg    string
+	}{
+		{
+			name: "Success",
+			body: `{"id":"borrower-1","name":"Siti","national_id":"3171234567890001","email":"siti@example.com","phone":"+628123456789","monthly_income":"10000000","monthly_obligations":"1500000"}`,
+			mockSetup: func(service *mock.MockBorrowerService) {
+				borrower := domain.Borrower{
+					ID:                 "borrower-1",
+					Name:               "Siti",
+					NationalID:         "3171234567890001",
+					Email:              "siti@example.com",
+					Phone:              "+628123456789",
+					MonthlyIncome:      money.MustNew("10000000", money.DefaultCurrency),
+					MonthlyObligations: money.MustNew("1500000", money.DefaultCurrency),
+				}
+				service.EXPECT().RegisterBorrower(borrower).DoAndReturn(func(borrower domain.Borrower) (*domain.Borrower, error) {
+					return &borrower, nil
+				})
+			},
+			expectedStatus: http.StatusCreated,
+			expectedMsg:    "OK",
+		},
+		{
+			name:           "Missing National ID",
+			body:           `{"name":"Siti","email":"siti@example.com","monthly_income":"10000000"}`,
+			mockSetup:      func(service *mock.MockBorrowerService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Validation error",
+		},
+		{
+			name:           "Negative Obligations",
+			body:           `{"name":"Siti","national_id":"3171234567890001","email":"siti@example.com","monthly_income":"10000000","monthly_obligations":"-1"}`,
+			mockSetup:      func(service *mock.MockBorrowerService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Validation error",
+		},
+		{
+			name:           "Blacklisted Without Reason",
+			body:           `{"name":"Siti","national_id":"3171234567890001","email":"siti@example.com","monthly_income":"10000000","blacklisted":true}`,
+			mockSetup:      func(service *mock.MockBorrowerService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Validation error",
+		},
+		{
+			name:           "Unsupported Currency",
+			body:           `{"name":"Siti","national_id":"3171234567890001","email":"siti@example.com","monthly_income":"10000000","currency":"XXX"}`,
+			mockSetup:      func(service *mock.MockBorrowerService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Validation error",
+		},
+		{
+			name: "ID Taken",
+			body: `{"id":"borrower-1","name":"Siti","national_id":"3171234567890001","email":"siti@example.com","monthly_income":"10000000"}`,
+			mockSetup: func(service *mock.MockBorrowerService) {
+				service.EXPECT().RegisterBorrower(gomock.Any()).Return(nil, fmt.Errorf("failed to save borrower: %w", domain.ErrBorrowerAlreadyExists))
+			},
+			expectedStatus: http.StatusConflict,
+			expectedMsg:    "Failed to register borrower",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := mock.NewMockBorrowerService(ctrl)
+			tc.mockSetup(mockService)
+
+			rec, message := call(t, http.MethodPost, tc.body, NewHandler(mockService).RegisterBorrower)
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.Equal(t, tc.expectedMsg, message)
+		})
+	}
+}
+
+func TestGetBorrower(t *testing.T) {
+	testCases := []struct {
+		name           string
+		mockSetup      func(*mock.MockBorrowerService)
+		expectedStatus int
+		expectedMsg    string
+	}{
+		{
+			name: "Success",
+			mockSetup: func(service *mock.MockBorrowerService) {
+				dti := money.MustParse("20")
+				service.EXPECT().GetBorrower("borrower-1").Return(&domain.BorrowerDetails{
+					Borrower: domain.Borrower{ID: "borrower-1", Name: "Siti"},
+					CreditProfile: domain.CreditProfile{
+						MonthlyDebt:  money.MustNew("2000000", money.DefaultCurrency),
+						DebtToIncome: &dti,
+						TotalLoans:   1,
+						ActiveLoans:  1,
+					},
+					Loans: []*domain.Loan{{ID: "loan-1", BorrowerID: "borrower-1", State: domain.StateDisbursed}},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedMsg:    "OK",
+		},
+		{
+			name: "Borrower Not Found",
+			mockSetup: func(service *mock.MockBorrowerService) {
+				service.EXPECT().GetBorrower("borrower-1").Return(nil, fmt.Errorf("failed to find borrower: %w", domain.ErrBorrowerNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedMsg:    "Failed to retrieve borrower",
+		},
+		{
+			name: "Service Error",
+			mockSetup: func(service *mock.MockBorrowerService) {
+				service.EXPECT().GetBorrower("borrower-1").Return(nil, errors.New("database error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedMsg:    "Failed to retrieve borrower",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := mock.NewMockBorrowerService(ctrl)
+			tc.mockSetup(mockService)
+
+			rec, message := call(t, http.MethodGet, "", NewHandler(mockService).GetBorrower)
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.Equal(t, tc.expectedMsg, message)
+			if tc.expectedStatus == http.StatusOK {
+				assert.Contains(t, rec.Body.String(), `"debt_to_income":"20"`)
+				assert.Contains(t, rec.Body.String(), `"loans":[{"id":"loan-1"`)
+			}
+		})
+	}
+}
+
+func TestGetBorrowers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mock.NewMockBorrowerService(ctrl)
+	mockService.EXPECT().GetBorrowers(1, 10).Return([]*domain.Borrower{{ID: "borrower-1"}}, nil)
+	rec, message := call(t, http.MethodGet, "", NewHandler(mockService).GetBorrowers)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "OK", message)
+}
+
+func TestUpdateBorrower(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mock.NewMockBorrowerService(ctrl)
+	mockService.EXPECT().UpdateBorrower(domain.Borrower{
+		ID:                 "borrower-1",
+		Name:               "Siti",
+		NationalID:         "3171234567890001",
+		Email:              "siti@example.com",
+		MonthlyIncome:      money.MustNew("1000", "USD"),
+		MonthlyObligations: money.MustNew("0", "USD"),
+		Blacklisted:        true,
+		BlacklistReason:    "fraud",
+	}).Return(&domain.Borrower{ID: "borrower-1"}, nil)
+	rec, message := call(t, http.MethodPut, `{"id":"ignored","name":"Siti","national_id":"3171234567890001","email":"siti@example.com","monthly_income":"1000","currency":"USD","blacklisted":true,"blacklist_reason":"fraud"}`, NewHandler(mockService).UpdateBorrower)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "OK", message)
+}
+
+func TestDeleteBorrower(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mock.NewMockBorrowerService(ctrl)
+	mockService.EXPECT().DeleteBorrower("borrower-1").Return(nil)
+	rec, message := call(t, http.MethodDelete, "", NewHandler(mockService).DeleteBorrower)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "OK", message)
+
+	mockService.EXPECT().DeleteBorrower("borrower-1").Return(fmt.Errorf("%w: borrower borrower-1 has 1 loans", domain.ErrBorrowerHasLoans))
+	rec, message = call(t, http.MethodDelete, "", NewHandler(mockService).DeleteBorrower)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, "Failed to delete borrower", message)
+}
@@ -0,0 +1,55 @@
+package document
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	"github.com/hinha/los-technical/internal/domain/response"
+	"github.com/labstack/echo/v4"
+)
+
+// Handler handles HTTP requests for stored documents
+type Handler struct {
+	store domain.DocumentStore
+}
+
+// NewHandler creates a new document handler
+func NewHandler(store domain.DocumentStore) *Handler {
+	return &Handler{
+		store: store,
+	}
+}
+
+// RegisterRoutes registers the document routes
+func (h *Handler) RegisterRoutes(e *echo.Echo) {
+	e.GET("/documents/:id", h.GetDocument)
+}
+
+// GetDocument handles downloading a stored document
+// @Summary Download document
+// @Description Returns the content of a stored document, such as a generated agreement letter
+// @Tags documents
+// @Produce application/pdf
+// @Param id path string true "Document ID"
+// @Success 200 {file} file "Document content"
+// @Failure 404 {object} response.Response "Document not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /documents/{id} [get]
+func (h *Handler) GetDocument(c echo.Context) error {
+	id := c.Param("id")
+
+	document, err := h.store.Find(id)
+	if err == nil {
+		var content []byte
+		if content, err = h.store.Read(id); err == nil {
+			c.Response().Header().Set(echo.HeaderContentDisposition, mime.FormatMediaType("inline", map[string]string{"filename": document.Name}))
+			return c.Blob(http.StatusOK, document.ContentType, content)
+		}
+	}
+	if errors.Is(err, domain.ErrDocumentNotFound) {
+		return response.DefaultResponse(c, "Document not found", nil, err.Error(), http.StatusNotFound)
+	}
+	return response.DefaultResponse(c, "Failed to retrieve document", nil, err.Error(), http.StatusInternalServerError)
+}
@@ -0,0 +1,104 @@
+package document
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	mock "github.com/hinha/los-technical/internal/domain/loan/mock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetDocument(t *testing.T) {
+	document := &domain.Document{
+		ID:          "document-1",
+		LoanID:      "loan-123",
+		Kind:        domain.DocumentAgreement,
+		Name:        "agreement-loan-123.pdf",
+		ContentType: domain.ContentTypePDF,
+		Size:        8,
+	}
+
+	// Define test cases
+	testCases := []struct {
+		name           string
+		mockSetup      func(*mock.MockDocumentStore)
+		expectedStatus int
+		expectedBody   string
+		expectedMsg    string
+	}{
+		{
+			name: "Success",
+			mockSetup: func(store *mock.MockDocumentStore) {
+				store.EXPECT().Find("document-1").Return(document, nil)
+				store.EXPECT().Read("document-1").Return([]byte("%PDF-1.4"), nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "%PDF-1.4",
+		},
+		{
+			name: "Document Not Found",
+			mockSetup: func(store *mock.MockDocumentStore) {
+				store.EXPECT().Find("document-1").Return(nil, domain.ErrDocumentNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedMsg:    "Document not found",
+		},
+		{
+			name: "Read Error",
+			mockSetup: func(store *mock.MockDocumentStore) {
+				store.EXPECT().Find("document-1").Return(document, nil)
+				store.EXPECT().Read("document-1").Return(nil, errors.New("disk error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedMsg:    "Failed to retrieve document",
+		},
+	}
+
+	// Run test cases
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockDocumentStore(ctrl)
+			tc.mockSetup(store)
+
+			handler := NewHandler(store)
+
+			// Create request
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			rec := httptest.NewRecorder()
+			e := echo.New()
+			c := e.NewContext(req, rec)
+			c.SetPath("/documents/:id")
+			c.SetParamNames("id")
+			c.SetParamValues("document-1")
+
+			// Execute
+			err := handler.GetDocument(c)
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+
+			if tc.expectedBody != "" {
+				assert.Equal(t, tc.expectedBody, rec.Body.String())
+				assert.Equal(t, domain.ContentTypePDF, rec.Header().Get(echo.HeaderContentType))
+				assert.Equal(t, "inline; filename=agreement-loan-123.pdf", rec.Header().Get(echo.HeaderContentDisposition))
+				return
+			}
+
+			// Parse response
+			var response map[string]interface{}
+			json.Unmarshal(rec.Body.Bytes(), &response)
+			assert.Equal(t, tc.expectedMsg, response["message"])
+		})
+	}
+}
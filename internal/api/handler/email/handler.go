@@ -0,0 +1,163 @@
+package email
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	"github.com/hinha/los-technical/internal/domain/response"
+	"github.com/hinha/los-technical/internal/pkg/money"
+	"github.com/labstack/echo/v4"
+)
+
+// errInvestorNotFound is returned when the previewed investor has not invested in the loan
+var errInvestorNotFound = errors.New("investor has not invested in the loan")
+
+// Handler handles HTTP requests for email previews
+type Handler struct {
+	service   domain.Service
+	renderer  domain.EmailRenderer
+	validator *validator.Validate
+}
+
+// NewHandler creates a new email handler
+func NewHandler(service domain.Service, renderer domain.EmailRenderer) *Handler {
+	return &Handler{
+		service:   service,
+		renderer:  renderer,
+		validator: validator.New(),
+	}
+}
+
+// RegisterRoutes registers the email routes
+func (h *Handler) RegisterRoutes(e *echo.Echo) {
+	e.GET("/emails/agreement/preview", h.PreviewAgreementEmail)
+}
+
+// PreviewAgreementRequest selects the data the agreement email is previewed with
+type PreviewAgreementRequest struct {
+	LoanID     string `query:"loan_id" example:"loan-001"`
+	InvestorID string `query:"investor_id" example:"investor-001"`
+	Locale     string `query:"locale" validate:"omitempty,bcp47_language_tag" example:"id"`
+	Format     string `query:"format" validate:"omitempty,oneof=json html text" example:"html"`
+}
+
+// PreviewAgreementEmail handles rendering the agreement email without sending it
+// @Summary Preview agreement email
+// @Description Renders the agreement email of an investor of a loan, or of sample data when no loan is given. The locale overrides the investor locale.
+// @Tags emails
+// @Produce json
+// @Produce html
+// @Produce plain
+// @Param loan_id query string false "Loan ID, sample data is used when empty"
+// @Param investor_id query string false "Investor ID, defaults to the first investor of the loan"
+// @Param locale query string false "Locale of the template, e.g. id"
+// @Param format query string false "Response format: json (default), html or text"
+// @Success 200 {object} response.Response "Rendered email"
+// @Failure 400 {object} response.Response "Invalid request"
+// @Failure 404 {object} response.Response "Loan or investor not found"
+// @Failure 500 {object} response.Response "Failed to render email"
+// @Router /emails/agreement/preview [get]
+func (h *Handler) PreviewAgreementEmail(c echo.Context) error {
+	var req PreviewAgreementRequest
+	if err := c.Bind(&req); err != nil {
+		return response.DefaultResponse(c, "Invalid request", nil, nil, http.StatusBadRequest)
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return response.DefaultResponse(c, "Validation error", nil, err.Error(), http.StatusBadRequest)
+	}
+
+	notification, err := h.agreement(req)
+	if err != nil {
+		if errors.Is(err, domain.ErrLoanNotFound) || errors.Is(err, errInvestorNotFound) {
+			return response.DefaultResponse(c, "Not found", nil, err.Error(), http.StatusNotFound)
+		}
+		return response.DefaultResponse(c, "Failed to address agreement email", nil, err.Error(), http.StatusInternalServerError)
+	}
+	if req.Locale != "" {
+		notification.Recipient.Locale = req.Locale
+	}
+
+	rendered, err := h.renderer.RenderNotification(notification)
+	if err != nil {
+		return response.DefaultResponse(c, "Failed to render agreement email", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	switch req.Format {
+	case "html":
+		return c.HTML(http.StatusOK, rendered.HTML)
+	case "text":
+		return c.String(http.StatusOK, rendered.Text)
+	default:
+		return response.DefaultResponse(c, "OK", rendered, nil, http.StatusOK)
+	}
+}
+
+// agreement addresses the previewed notification from the loan, or from sample data when no loan is requested
+func (h *Handler) agreement(req PreviewAgreementRequest) (domain.Notification, error) {
+	if req.LoanID == "" {
+		return agreementNotification(sampleAgreement())
+	}
+
+	loan, err := h.service.GetLoan(req.LoanID)
+	if err != nil {
+		return domain.Notification{}, err
+	}
+
+	// A loan without investors yet is previewed with a sample investor funding all of it
+	_, investor := sampleAgreement()
+	investor.Amount = loan.PrincipalAmount
+	switch {
+	case req.InvestorID != "":
+		found := false
+		for _, inv := range loan.Investors {
+			if inv.ID == req.InvestorID {
+				investor, found = inv, true
+				break
+			}
+		}
+		if !found {
+			return domain.Notification{}, errInvestorNotFound
+		}
+	case len(loan.Investors) > 0:
+		investor = loan.Investors[0]
+	}
+	return agreementNotification(loan, investor)
+}
+
+// agreementNotification is the agreement notification of the loan to one of its investors
+func agreementNotification(loan *domain.Loan, investor domain.Investor) (domain.Notification, error) {
+	agreement, err := domain.NewAgreementEmail(loan, investor)
+	if err != nil {
+		return domain.Notification{}, err
+	}
+	return domain.Notification{
+		Kind:      domain.NotificationAgreement,
+		LoanID:    loan.ID,
+		Recipient: domain.InvestorRecipient(investor),
+		Amount:    &agreement.Amount,
+		Agreement: &agreement,
+	}, nil
+}
+
+// sampleAgreement is a funded loan and one of its investors to preview templates with
+func sampleAgreement() (*domain.Loan, domain.Investor) {
+	loan := &domain.Loan{
+		ID:              "sample-loan",
+		BorrowerID:      "sample-borrower",
+		PrincipalAmount: money.MustNew("10000000", money.DefaultCurrency),
+		Rate:            money.MustParse("12"),
+		ROI:             money.MustParse("10"),
+		Terms:           domain.RepaymentTerms{TenorMonths: domain.DefaultTenorMonths, Scheme: domain.DefaultRepaymentScheme},
+		State:           domain.StateInvested,
+		AgreementLetter: "https://example.com/agreements/sample-loan.pdf",
+	}
+	investor := domain.Investor{
+		ID:     "sample-investor",
+		Name:   "Sample Investor",
+		Amount: money.MustNew("2500000", money.DefaultCurrency),
+		Email:  "investor@example.com",
+	}
+	return loan, investor
+}
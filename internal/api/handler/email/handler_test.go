@@ -0,0 +1,165 @@
+package email
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	mock "github.com/hinha/los-technical/internal/domain/loan/mock"
+	"github.com/hinha/los-technical/internal/pkg/money"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreviewAgreementEmail(t *testing.T) {
+	rendered := &domain.RenderedEmail{Subject: "Your agreement", Text: "Hello\n", HTML: "<p>Hello</p>"}
+	loan := &domain.Loan{
+		ID:              "loan-123",
+		PrincipalAmount: money.MustNew("1000", money.DefaultCurrency),
+		ROI:             money.MustParse("10"),
+		Terms:           domain.RepaymentTerms{TenorMonths: 12, Scheme: domain.SchemeAnnuity},
+		AgreementLetter: "http://example.com/agreement",
+		Investors: []domain.Investor{
+			{ID: "investor-1", Email: "one@example.com", Amount: money.MustNew("600", money.DefaultCurrency), Locale: "id"},
+			{ID: "investor-2", Email: "two@example.com", Amount: money.MustNew("400", money.DefaultCurrency)},
+		},
+	}
+
+	// Define test cases
+	testCases := []struct {
+		name           string
+		query          string
+		mockSetup      func(*mock.MockService, *mock.MockEmailRenderer)
+		expectedStatus int
+		expectedBody   string
+		expectedMsg    string
+	}{
+		{
+			name:  "Sample Data",
+			query: "",
+			mockSetup: func(service *mock.MockService, renderer *mock.MockEmailRenderer) {
+				renderer.EXPECT().RenderNotification(gomock.Any()).DoAndReturn(func(notification domain.Notification) (*domain.RenderedEmail, error) {
+					assert.Equal(t, domain.NotificationAgreement, notification.Kind)
+					assert.Equal(t, "sample-loan", notification.LoanID)
+					assert.Equal(t, money.MustNew("250000", money.DefaultCurrency), notification.Agreement.ExpectedReturn)
+					return rendered, nil
+				})
+			},
+			expectedStatus: http.StatusOK,
+			expectedMsg:    "OK",
+		},
+		{
+			name:  "Investor Of Loan As HTML",
+			query: "?loan_id=loan-123&investor_id=investor-2&format=html",
+			mockSetup: func(service *mock.MockService, renderer *mock.MockEmailRenderer) {
+				service.EXPECT().GetLoan("loan-123").Return(loan, nil)
+				amount := money.MustNew("400", money.DefaultCurrency)
+				renderer.EXPECT().RenderNotification(domain.Notification{
+					Kind:      domain.NotificationAgreement,
+					LoanID:    "loan-123",
+					Recipient: domain.Recipient{ID: "investor-2", Role: domain.RoleInvestor, Email: "two@example.com"},
+					Amount:    &amount,
+					Agreement: &domain.AgreementEmail{
+						Email:          "two@example.com",
+						LoanID:         "loan-123",
+						Amount:         amount,
+						ROI:            money.MustParse("10"),
+						ExpectedReturn: money.MustNew("40", money.DefaultCurrency),
+						Terms:          domain.RepaymentTerms{TenorMonths: 12, Scheme: domain.SchemeAnnuity},
+						AgreementURL:   "http://example.com/agreement",
+					},
+				}).Return(rendered, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "<p>Hello</p>",
+		},
+		{
+			name:  "Locale Overrides Investor Locale",
+			query: "?loan_id=loan-123&locale=en&format=text",
+			mockSetup: func(service *mock.MockService, renderer *mock.MockEmailRenderer) {
+				service.EXPECT().GetLoan("loan-123").Return(loan, nil)
+				renderer.EXPECT().RenderNotification(gomock.Any()).DoAndReturn(func(notification domain.Notification) (*domain.RenderedEmail, error) {
+					assert.Equal(t, "one@example.com", notification.Recipient.Email)
+					assert.Equal(t, "en", notification.Recipient.Locale)
+					return rendered, nil
+				})
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "Hello\n",
+		},
+		{
+			name:  "Loan Not Found",
+			query: "?loan_id=loan-404",
+			mockSetup: func(service *mock.MockService, renderer *mock.MockEmailRenderer) {
+				service.EXPECT().GetLoan("loan-404").Return(nil, domain.ErrLoanNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedMsg:    "Not found",
+		},
+		{
+			name:  "Investor Not Found",
+			query: "?loan_id=loan-123&investor_id=investor-9",
+			mockSetup: func(service *mock.MockService, renderer *mock.MockEmailRenderer) {
+				service.EXPECT().GetLoan("loan-123").Return(loan, nil)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedMsg:    "Not found",
+		},
+		{
+			name:           "Invalid Format",
+			query:          "?format=pdf",
+			mockSetup:      func(service *mock.MockService, renderer *mock.MockEmailRenderer) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Validation error",
+		},
+		{
+			name:  "Render Error",
+			query: "",
+			mockSetup: func(service *mock.MockService, renderer *mock.MockEmailRenderer) {
+				renderer.EXPECT().RenderNotification(gomock.Any()).Return(nil, errors.New("template error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedMsg:    "Failed to render agreement email",
+		},
+	}
+
+	// Run test cases
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := mock.NewMockService(ctrl)
+			mockRenderer := mock.NewMockEmailRenderer(ctrl)
+			tc.mockSetup(mockService, mockRenderer)
+
+			handler := NewHandler(mockService, mockRenderer)
+
+			// Create request
+			req := httptest.NewRequest(http.MethodGet, "/emails/agreement/preview"+tc.query, nil)
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+
+			// Execute
+			err := handler.PreviewAgreementEmail(c)
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			if tc.expectedBody != "" {
+				assert.Equal(t, tc.expectedBody, rec.Body.String())
+				return
+			}
+
+			// Parse response
+			var response map[string]interface{}
+			json.Unmarshal(rec.Body.Bytes(), &response)
+			assert.Equal(t, tc.expectedMsg, response["message"])
+		})
+	}
+}
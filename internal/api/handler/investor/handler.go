@@ -0,0 +1,227 @@
+package investor
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	"github.com/hinha/los-technical/internal/domain/response"
+	"github.com/hinha/los-technical/internal/pkg/money"
+	"github.com/hinha/los-technical/internal/pkg/utils"
+	"github.com/labstack/echo/v4"
+)
+
+// Handler handles HTTP requests for investors and their portfolios
+type Handler struct {
+	service   domain.InvestorService
+	validator *validator.Validate
+}
+
+// NewHandler creates a new investor handler
+func NewHandler(service domain.InvestorService) *Handler {
+	validate := validator.New()
+	_ = validate.RegisterValidation("supportedCurrency", utils.ValidateCurrency)
+	validate.RegisterCustomTypeFunc(utils.DecimalValue, money.Decimal{})
+
+	return &Handler{
+		service:   service,
+		validator: validate,
+	}
+}
+
+// RegisterRoutes registers the investor routes
+func (h *Handler) RegisterRoutes(e *echo.Echo) {
+	e.POST("/investors", h.RegisterInvestor)
+	e.GET("/investors/:id", h.GetInvestor)
+	e.PUT("/investors/:id", h.UpdateInvestor)
+	e.PUT("/investors/:id/kyc", h.SetKYCStatus)
+	e.GET("/investors/:id/portfolio", h.GetPortfolio)
+}
+
+// errorStatus maps service errors that have a dedicated HTTP status, falling back to the given one
+func errorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, domain.ErrInvestorNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrInvestorAlreadyExists):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrInvalidInvestor),
+		errors.Is(err, money.ErrUnsupportedCurrency),
+		errors.Is(err, money.ErrTooPrecise):
+		return http.StatusBadRequest
+	}
+	return fallback
+}
+
+// LimitRequest is an investment limit, in the default currency when none is given
+type LimitRequest struct {
+	Amount   money.Decimal `json:"amount" validate:"required,gt=0" swaggertype:"string" example:"50000000"`
+	Currency string        `json:"currency" validate:"omitempty,supportedCurrency" example:"IDR"`
+}
+
+// limit returns the limit as an amount, nil when no limit is given
+func (r *LimitRequest) limit() *money.Money {
+	if r == nil {
+		return nil
+	}
+	currency := r.Currency
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+	limit := money.New(r.Amount, currency)
+	return &limit
+}
+
+// InvestorRequest represents the request body for registering or updating an investor
+type InvestorRequest struct {
+	// ID is generated when an investor is registered without one, it is ignored on update
+	ID     string `json:"id" validate:"omitempty,max=64" example:"investor-001"`
+	Name   string `json:"name" validate:"required,max=100" example:"Budi Santoso"`
+	Email  string `json:"email" validate:"required,email" example:"budi@example.com"`
+	Phone  string `json:"phone" validate:"omitempty,e164" example:"+628123456789"`
+	Locale string `json:"locale" validate:"omitempty,bcp47_language_tag" example:"id"`
+	// PerLoanLimit caps what the investor commits to a single loan, not enforced when left out
+	PerLoanLimit *LimitRequest `json:"per_loan_limit"`
+	// TotalLimit caps what the investor has committed to outstanding loans, not enforced when left out
+	TotalLimit *LimitRequest `json:"total_limit"`
+}
+
+// profile returns the investor the request describes
+func (r InvestorRequest) profile(id string) domain.InvestorProfile {
+	return domain.InvestorProfile{
+		ID:     id,
+		Name:   r.Name,
+		Email:  r.Email,
+		Phone:  r.Phone,
+		Locale: r.Locale,
+		Limits: domain.InvestmentLimits{PerLoan: r.PerLoanLimit.limit(), Total: r.TotalLimit.limit()},
+	}
+}
+
+// KYCRequest represents the request body for recording the KYC review of an investor
+type KYCRequest struct {
+	Status string `json:"status" validate:"required,oneof=PENDING VERIFIED REJECTED" example:"VERIFIED"`
+}
+
+// RegisterInvestor handles registering a new investor
+// @Summary Register investor
+// @Description Registers an investor pending their KYC review. Only verified investors may invest.
+// @Tags investors
+// @Accept json
+// @Produce json
+// @Param request body InvestorRequest true "Investor details"
+// @Success 201 {object} response.Response "Investor registered"
+// @Failure 400 {object} response.Response "Invalid request or validation error"
+// @Failure 409 {object} response.Response "Investor ID already taken"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /investors [post]
+func (h *Handler) RegisterInvestor(c echo.Context) error {
+	var req InvestorRequest
+	if err := c.Bind(&req); err != nil {
+		return response.DefaultResponse(c, "Invalid request body", nil, nil, http.StatusBadRequest)
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return response.DefaultResponse(c, "Validation error", nil, err.Error(), http.StatusBadRequest)
+	}
+
+	investor, err := h.service.RegisterInvestor(req.profile(req.ID))
+	if err != nil {
+		return response.DefaultResponse(c, "Failed to register investor", nil, err.Error(), errorStatus(err, http.StatusInternalServerError))
+	}
+	return response.DefaultResponse(c, "OK", investor, nil, http.StatusCreated)
+}
+
+// GetInvestor handles retrieving an investor
+// @Summary Get investor
+// @Description Retrieves the contact details, KYC status and investment limits of an investor
+// @Tags investors
+// @Produce json
+// @Param id path string true "Investor ID"
+// @Success 200 {object} response.Response "Investor"
+// @Failure 404 {object} response.Response "Investor not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /investors/{id} [get]
+func (h *Handler) GetInvestor(c echo.Context) error {
+	investor, err := h.service.GetInvestor(c.Param("id"))
+	if err != nil {
+		return response.DefaultResponse(c, "Failed to retrieve investor", nil, err.Error(), errorStatus(err, http.StatusInternalServerError))
+	}
+	return response.DefaultResponse(c, "OK", investor, nil, http.StatusOK)
+}
+
+// UpdateInvestor handles replacing the contact details and limits of an investor
+// @Summary Update investor
+// @Description Replaces the contact details and investment limits of an investor, their KYC status is kept
+// @Tags investors
+// @Accept json
+// @Produce json
+// @Param id path string true "Investor ID"
+// @Param request body InvestorRequest true "Investor details"
+// @Success 200 {object} response.Response "Investor updated"
+// @Failure 400 {object} response.Response "Invalid request or validation error"
+// @Failure 404 {object} response.Response "Investor not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /investors/{id} [put]
+func (h *Handler) UpdateInvestor(c echo.Context) error {
+	var req InvestorRequest
+	if err := c.Bind(&req); err != nil {
+		return response.DefaultResponse(c, "Invalid request body", nil, nil, http.StatusBadRequest)
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return response.DefaultResponse(c, "Validation error", nil, err.Error(), http.StatusBadRequest)
+	}
+
+	investor, err := h.service.UpdateInvestor(req.profile(c.Param("id")))
+	if err != nil {
+		return response.DefaultResponse(c, "Failed to update investor", nil, err.Error(), errorStatus(err, http.StatusInternalServerError))
+	}
+	return response.DefaultResponse(c, "OK", investor, nil, http.StatusOK)
+}
+
+// SetKYCStatus handles recording the outcome of the KYC review of an investor
+// @Summary Set KYC status
+// @Description Records whether the identity of an investor is verified. Only VERIFIED investors may invest.
+// @Tags investors
+// @Accept json
+// @Produce json
+// @Param id path string true "Investor ID"
+// @Param request body KYCRequest true "KYC status"
+// @Success 200 {object} response.Response "KYC status recorded"
+// @Failure 400 {object} response.Response "Invalid request or validation error"
+// @Failure 404 {object} response.Response "Investor not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /investors/{id}/kyc [put]
+func (h *Handler) SetKYCStatus(c echo.Context) error {
+	var req KYCRequest
+	if err := c.Bind(&req); err != nil {
+		return response.DefaultResponse(c, "Invalid request body", nil, nil, http.StatusBadRequest)
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return response.DefaultResponse(c, "Validation error", nil, err.Error(), http.StatusBadRequest)
+	}
+
+	investor, err := h.service.SetKYCStatus(c.Param("id"), domain.KYCStatus(req.Status))
+	if err != nil {
+		return response.DefaultResponse(c, "Failed to set KYC status", nil, err.Error(), errorStatus(err, http.StatusInternalServerError))
+	}
+	return response.DefaultResponse(c, "OK", investor, nil, http.StatusOK)
+}
+
+// GetPortfolio handles listing the loans an investor has funded
+// @Summary Get investor portfolio
+// @Description Lists every loan the investor has funded with the amount committed, the expected return and the current loan state, and totals the outstanding loans per currency
+// @Tags investors
+// @Produce json
+// @Param id path string true "Investor ID"
+// @Success 200 {object} response.Response "Portfolio"
+// @Failure 404 {object} response.Response "Investor not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /investors/{id}/portfolio [get]
+func (h *Handler) GetPortfolio(c echo.Context) error {
+	portfolio, err := h.service.GetPortfolio(c.Param("id"))
+	if err != nil {
+		return response.DefaultResponse(c, "Failed to retrieve portfolio", nil, err.Error(), errorStatus(err, http.StatusInternalServerError))
+	}
+	return response.DefaultResponse(c, "OK", portfolio, nil, http.StatusOK)
+}
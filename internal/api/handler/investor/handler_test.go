@@ -0,0 +1,250 @@
+package investor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	mock "github.com/hinha/los-technical/internal/domain/loan/mock"
+	"github.com/hinha/los-technical/internal/pkg/money"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// call runs the handler on a request about investor-1 and returns the recorded response and its message
+func call(t *testing.T, method, body string, handle func(echo.Context) error) (*httptest.ResponseRecorder, string) {
+	t.Helper()
+	req := httptest.NewRequest(method, "/", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("investor-1")
+
+	assert.NoError(t, handle(c))
+
+	var response map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &response)
+	message, _ := response["message"].(string)
+	return rec, message
+}
+
+func TestRegisterInvestor(t *testing.T) {
+	perLoan := money.MustNew("5000000", money.DefaultCurrency)
+	total := money.MustNew("1000", "USD")
+
+	testCases := []struct {
+		name           string
+		body           string
+		mockSetup      func(*mock.MockInvestorService)
+		expectedStatus int
+		expectedMsg    string
+	}{
+		{
+			name: "Success",
+			body: `{"id":"investor-1","name":"Budi","email":"budi@example.com","phone":"+628123456789","per_loan_limit":{"amount":"5000000"},"total_limit":{"amount":"1000","currency":"USD"}}`,
+			mockSetup: func(service *mock.MockInvestorService) {
+				investor := domain.InvestorProfile{
+					ID:     "investor-1",
+					Name:   "Budi",
+					Email:  "budi@example.com",
+					Phone:  "+628123456789",
+					Limits: domain.InvestmentLimits{PerLoan: &perLoan, Total: &total},
+				}
+				service.EXPECT().RegisterInvestor(investor).DoAndReturn(func(investor domain.InvestorProfile) (*domain.InvestorProfile, error) {
+					investor.KYCStatus = domain.KYCPending
+					return &investor, nil
+				})
+			},
+			expectedStatus: http.StatusCreated,
+			expectedMsg:    "OK",
+		},
+		{
+			name:           "Missing Email",
+			body:           `{"name":"Budi"}`,
+			mockSetup:      func(service *mock.MockInvestorService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Validation error",
+		},
+		{
+			name:           "Limit Not Positive",
+			body:           `{"name":"Budi","email":"budi@example.com","total_limit":{"amount":"0"}}`,
+			mockSetup:      func(service *mock.MockInvestorService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Validation error",
+		},
+		{
+			name:           "Unsupported Limit Currency",
+			body:           `{"name":"Budi","email":"budi@example.com","per_loan_limit":{"amount":"10","currency":"XXX"}}`,
+			mockSetup:      func(service *mock.MockInvestorService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Validation error",
+		},
+		{
+			name: "ID Taken",
+			body: `{"id":"investor-1","name":"Budi","email":"budi@example.com"}`,
+			mockSetup: func(service *mock.MockInvestorService) {
+				service.EXPECT().RegisterInvestor(gomock.Any()).Return(nil, fmt.Errorf("failed to save investor: %w", domain.ErrInvestorAlreadyExists))
+			},
+			expectedStatus: http.StatusConflict,
+			expectedMsg:    "Failed to register investor",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := mock.NewMockInvestorService(ctrl)
+			tc.mockSetup(mockService)
+
+			rec, message := call(t, http.MethodPost, tc.body, NewHandler(mockService).RegisterInvestor)
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.Equal(t, tc.expectedMsg, message)
+		})
+	}
+}
+
+func TestGetInvestor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mock.NewMockInvestorService(ctrl)
+	mockService.EXPECT().GetInvestor("investor-1").Return(&domain.InvestorProfile{ID: "investor-1", KYCStatus: domain.KYCVerified}, nil)
+	rec, message := call(t, http.MethodGet, "", NewHandler(mockService).GetInvestor)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "OK", message)
+	assert.Contains(t, rec.Body.String(), `"kyc_status":"VERIFIED"`)
+
+	mockService.EXPECT().GetInvestor("investor-1").Return(nil, fmt.Errorf("failed to find investor: %w", domain.ErrInvestorNotFound))
+	rec, _ = call(t, http.MethodGet, "", NewHandler(mockService).GetInvestor)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestUpdateInvestor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mock.NewMockInvestorService(ctrl)
+	mockService.EXPECT().UpdateInvestor(domain.InvestorProfile{ID: "investor-1", Name: "Budi", Email: "budi@example.com", Locale: "id"}).
+		Return(&domain.InvestorProfile{ID: "investor-1"}, nil)
+	rec, message := call(t, http.MethodPut, `{"id":"ignored","name":"Budi","email":"budi@example.com","locale":"id"}`, NewHandler(mockService).UpdateInvestor)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "OK", message)
+}
+
+func TestSetKYCStatus(t *testing.T) {
+	testCases := []struct {
+		name           string
+		body           string
+		mockSetup      func(*mock.MockInvestorService)
+		expectedStatus int
+		expectedMsg    string
+	}{
+		{
+			name: "Success",
+			body: `{"status":"VERIFIED"}`,
+			mockSetup: func(service *mock.MockInvestorService) {
+				service.EXPECT().SetKYCStatus("investor-1", domain.KYCVerified).Return(&domain.InvestorProfile{ID: "investor-1", KYCStatus: domain.KYCVerified}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedMsg:    "OK",
+		},
+		{
+			name:           "Unknown Status",
+			body:           `{"status":"APPROVED"}`,
+			mockSetup:      func(service *mock.MockInvestorService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Validation error",
+		},
+		{
+			name: "Investor Not Found",
+			body: `{"status":"REJECTED"}`,
+			mockSetup: func(service *mock.MockInvestorService) {
+				service.EXPECT().SetKYCStatus("investor-1", domain.KYCRejected).Return(nil, fmt.Errorf("failed to find investor: %w", domain.ErrInvestorNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedMsg:    "Failed to set KYC status",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := mock.NewMockInvestorService(ctrl)
+			tc.mockSetup(mockService)
+
+			rec, message := call(t, http.MethodPut, tc.body, NewHandler(mockService).SetKYCStatus)
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.Equal(t, tc.expectedMsg, message)
+		})
+	}
+}
+
+func TestGetPortfolio(t *testing.T) {
+	testCases := []struct {
+		name           string
+		mockSetup      func(*mock.MockInvestorService)
+		expectedStatus int
+		expectedMsg    string
+	}{
+		{
+			name: "Success",
+			mockSetup: func(service *mock.MockInvestorService) {
+				service.EXPECT().GetPortfolio("investor-1").Return(&domain.Portfolio{
+					InvestorID: "investor-1",
+					Loans: []domain.PortfolioLoan{{
+						LoanID:         "loan-1",
+						State:          domain.StateDisbursed,
+						Committed:      money.MustNew("1000", money.DefaultCurrency),
+						ExpectedReturn: money.MustNew("100", money.DefaultCurrency),
+					}},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedMsg:    "OK",
+		},
+		{
+			name: "Investor Not Found",
+			mockSetup: func(service *mock.MockInvestorService) {
+				service.EXPECT().GetPortfolio("investor-1").Return(nil, fmt.Errorf("failed to find investor: %w", domain.ErrInvestorNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedMsg:    "Failed to retrieve portfolio",
+		},
+		{
+			name: "Service Error",
+			mockSetup: func(service *mock.MockInvestorService) {
+				service.EXPECT().GetPortfolio("investor-1").Return(nil, errors.New("database error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedMsg:    "Failed to retrieve portfolio",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := mock.NewMockInvestorService(ctrl)
+			tc.mockSetup(mockService)
+
+			rec, message := call(t, http.MethodGet, "", NewHandler(mockService).GetPortfolio)
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.Equal(t, tc.expectedMsg, message)
+			if tc.expectedStatus == http.StatusOK {
+				assert.Contains(t, rec.Body.String(), `"expected_return":{"amount":"100","currency":"IDR"}`)
+			}
+		})
+	}
+}
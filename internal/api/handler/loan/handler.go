@@ -2,14 +2,20 @@ package loan
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 	domain "github.com/hinha/los-technical/internal/domain/loan"
 	"github.com/hinha/los-technical/internal/domain/response"
+	"github.com/hinha/los-technical/internal/pkg/money"
 	"github.com/hinha/los-technical/internal/pkg/utils"
 	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
 )
 
 // @title Loan API
@@ -21,67 +27,234 @@ import (
 type Handler struct {
 	service   domain.Service
 	validator *validator.Validate
+	machine   *domain.StateMachine
+	// maxDocumentSize is the largest document that can be uploaded, in bytes
+	maxDocumentSize int64
+}
+
+// Option configures optional behaviour of the Handler
+type Option func(*Handler)
+
+// WithMaxDocumentSize sets the largest document that can be uploaded, in bytes. It should match the limit of the service,
+// the handler stops reading an upload past it.
+func WithMaxDocumentSize(size int64) Option {
+	return func(h *Handler) {
+		h.maxDocumentSize = size
+	}
 }
 
 // NewHandler creates a new loan handler
-func NewHandler(service domain.Service) *Handler {
+func NewHandler(service domain.Service, opts ...Option) *Handler {
 	validate := validator.New()
 
 	// Register custom validation for loan state
 	_ = validate.RegisterValidation("validLoanState", utils.ValidateLoanState)
-
-	return &Handler{
-		service:   service,
-		validator: validate,
+	_ = validate.RegisterValidation("supportedCurrency", utils.ValidateCurrency)
+	validate.RegisterCustomTypeFunc(utils.DecimalValue, money.Decimal{})
+
+	h := &Handler{
+		service:         service,
+		validator:       validate,
+		machine:         domain.NewStateMachine(),
+		maxDocumentSize: domain.DefaultMaxDocumentSize,
+	}
+	for _, opt := range opts {
+		opt(h)
 	}
+	return h
 }
 
-// validateLoanStateForAction validates if a loan is in the correct state for a specific action
-func (h *Handler) validateLoanStateForAction(loanID string, requiredStates ...domain.LoanState) error {
+// validateLoanStateForAction validates if a loan is at a version the request allows and in the correct state
+// for a specific action
+func (h *Handler) validateLoanStateForAction(loanID string, action domain.Action, match domain.IfMatch) error {
 	loanData, err := h.service.GetLoan(loanID)
 	if err != nil {
 		return err
 	}
+	if err := match.Check(loanData); err != nil {
+		return err
+	}
+	return h.machine.Allows(loanData, action)
+}
+
+// stateValidationResponse responds to a request refused by validateLoanStateForAction
+func stateValidationResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, domain.ErrLoanNotFound):
+		return response.DefaultResponse(c, "Loan not found", nil, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrPreconditionFailed):
+		return response.DefaultResponse(c, "Precondition failed", nil, err.Error(), http.StatusPreconditionFailed)
+	}
+	return response.DefaultResponse(c, "State validation error", nil, err.Error(), http.StatusBadRequest)
+}
+
+const (
+	headerETag    = "ETag"
+	headerIfMatch = "If-Match"
+)
 
-	for _, state := range requiredStates {
-		if loanData.State == state {
+// formatETag renders a loan version as a strong entity tag
+func formatETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// setETag exposes the loan version so clients can send it back in If-Match
+func setETag(c echo.Context, version int64) {
+	c.Response().Header().Set(headerETag, formatETag(version))
+}
+
+// ifMatch reads the loan versions allowed by the If-Match request header. Requests without the header or
+// with "*" are not conditional on a version. An entity tag that is not a strong loan version is kept as
+// version 0, which no stored loan has, so it never matches.
+func ifMatch(c echo.Context) domain.IfMatch {
+	header := c.Request().Header.Get(headerIfMatch)
+	if header == "" {
+		return nil
+	}
+
+	var match domain.IfMatch
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
 			return nil
 		}
+		var version int64
+		if len(tag) > 2 && strings.HasPrefix(tag, `"`) && strings.HasSuffix(tag, `"`) {
+			if parsed, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64); err == nil && parsed > 0 {
+				version = parsed
+			}
+		}
+		match = append(match, version)
+	}
+	return match
+}
+
+// errorStatus maps service errors that have a dedicated HTTP status, falling back to the given one
+func errorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, domain.ErrLoanNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, domain.ErrVersionConflict),
+		errors.Is(err, domain.ErrBorrowerLoanLimit):
+		return http.StatusConflict
+	case errors.Is(err, money.ErrUnsupportedCurrency),
+		errors.Is(err, money.ErrTooPrecise),
+		errors.Is(err, money.ErrCurrencyMismatch),
+		errors.Is(err, domain.ErrInvalidRepaymentTerms),
+		errors.Is(err, domain.ErrInvalidRepayment),
+		errors.Is(err, domain.ErrInvalidReasonCode),
+		errors.Is(err, domain.ErrInvalidTransition),
+		errors.Is(err, domain.ErrInvalidDocumentKind),
+		errors.Is(err, domain.ErrDocumentNotFound),
+		errors.Is(err, domain.ErrDocumentMismatch),
+		errors.Is(err, domain.ErrAgreementNotVerified),
+		errors.Is(err, domain.ErrInvestorNotFound),
+		errors.Is(err, domain.ErrInvestmentLimitExceeded),
+		errors.Is(err, domain.ErrBorrowerNotFound),
+		errors.Is(err, domain.ErrLoanNotPriced),
+		errors.Is(err, domain.ErrOutsideCorridor),
+		errors.Is(err, domain.ErrNegativeMargin):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrInvestorNotVerified),
+		errors.Is(err, domain.ErrBorrowerBlacklisted),
+		errors.Is(err, domain.ErrRiskGradeTooLow):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrUnsupportedDocumentType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, domain.ErrDocumentTooLarge):
+		return http.StatusRequestEntityTooLarge
+	}
+	return fallback
+}
+
+// LoanFilterRequest represents the query parameters shared by the loan listing endpoints
+type LoanFilterRequest struct {
+	Currency string `query:"currency" validate:"omitempty,supportedCurrency"`
+}
+
+// bindLoanFilter reads and validates the listing filter from the query string
+func (h *Handler) bindLoanFilter(c echo.Context) (domain.LoanFilter, error) {
+	req := LoanFilterRequest{
+		Currency: c.QueryParam("currency"),
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return domain.LoanFilter{}, err
 	}
+	return domain.LoanFilter{Currency: req.Currency}, nil
+}
 
-	return errors.New("loanData is not in the required state for this action")
+// currencyOrDefault returns the requested currency, or the default currency when none was given
+func currencyOrDefault(currency string) string {
+	if currency == "" {
+		return money.DefaultCurrency
+	}
+	return currency
 }
 
+// multipartOverhead is how much larger than its document an upload request may be, for the form field and part headers
+const multipartOverhead = 64 << 10
+
 // RegisterRoutes registers the loan API routes
 func (h *Handler) RegisterRoutes(e *echo.Echo) {
 	e.POST("/loans", h.CreateLoan)
 	e.GET("/loans", h.GetLoans)
 	e.GET("/loans/:id", h.GetLoan)
+	e.GET("/loans/:id/schedule", h.GetSchedule)
+	e.GET("/loans/:id/transitions", h.GetTransitions)
+	e.GET("/loans/:id/history", h.GetHistory)
 	e.POST("/loans/:id/approve", h.ApproveLoan)
+	e.POST("/loans/:id/reject", h.RejectLoan)
+	e.POST("/loans/:id/cancel", h.CancelLoan)
 	e.POST("/loans/:id/invest", h.AddInvestment)
 	e.POST("/loans/:id/disburse", h.DisburseLoan)
+	e.POST("/loans/:id/repayments", h.RecordRepayment)
 	e.POST("/loans/:id/agreement", h.GenerateAgreementLetter)
+	e.GET("/loans/:id/agreements", h.GetAgreements)
+	e.POST("/loans/:id/documents", h.UploadDocument, middleware.BodyLimit(strconv.FormatInt(h.maxDocumentSize+multipartOverhead, 10)))
 	e.GET("/loans/borrower/:borrowerId", h.GetLoansByBorrower)
 	e.GET("/loans/state/:state", h.GetLoansByState)
 }
 
 // CreateLoanRequest represents the request body for creating a loan
 type CreateLoanRequest struct {
-	BorrowerID      string  `json:"borrower_id" validate:"required" example:"amr-001"`
-	PrincipalAmount float64 `json:"principal_amount" validate:"required,gt=0" example:"1000000"`
-	Rate            float64 `json:"rate" validate:"required,gte=0" example:"12.5"`
-	ROI             float64 `json:"roi" validate:"required,gte=0" example:"10"`
+	BorrowerID      string         `json:"borrower_id" validate:"required" example:"amr-001"`
+	PrincipalAmount money.Decimal  `json:"principal_amount" validate:"required,gt=0" swaggertype:"string" example:"1000000"`
+	Rate            *money.Decimal `json:"rate,omitempty" validate:"omitempty,gte=0" swaggertype:"string" example:"12.5"`
+	ROI             *money.Decimal `json:"roi,omitempty" validate:"omitempty,gte=0" swaggertype:"string" example:"10"`
+	Currency        string         `json:"currency" validate:"omitempty,supportedCurrency" example:"IDR"`
+	TenorMonths     int            `json:"tenor_months" validate:"omitempty,gte=1,lte=360" example:"12"`
+	RepaymentScheme string         `json:"repayment_scheme" validate:"omitempty,oneof=FLAT ANNUITY BULLET" example:"ANNUITY"`
+}
+
+// terms returns the requested repayment terms, falling back to the defaults for omitted fields
+func (r CreateLoanRequest) terms() domain.RepaymentTerms {
+	terms := domain.RepaymentTerms{
+		TenorMonths: r.TenorMonths,
+		Scheme:      domain.RepaymentScheme(r.RepaymentScheme),
+	}
+	if terms.TenorMonths == 0 {
+		terms.TenorMonths = domain.DefaultTenorMonths
+	}
+	if terms.Scheme == "" {
+		terms.Scheme = domain.DefaultRepaymentScheme
+	}
+	return terms
 }
 
 // CreateLoan handles the creation of a new loan
 // @Summary Create a new loan
-// @Description Creates a new loan with the given borrower and loan details
+// @Description Creates a new loan with the given borrower and loan details. The borrower must be registered, not blacklisted and allowed another loan by the borrower policy. The rate and ROI are priced by the rate card from the risk grade, tenor and principal of the loan; values given instead must lie within the corridors around the priced ones, with the ROI below the rate.
 // @Tags loans
 // @Accept json
 // @Produce json
 // @Param request body CreateLoanRequest true "Loan creation request"
 // @Success 201 {object} response.Response "Loan created successfully"
-// @Failure 400 {object} response.Response "Invalid request or validation error"
+// @Header 201 {string} ETag "Loan version"
+// @Failure 400 {object} response.Response "Invalid request, validation error, unknown borrower, or rate or ROI outside its corridor or not leaving a margin"
+// @Failure 403 {object} response.Response "Borrower blacklisted"
+// @Failure 409 {object} response.Response "Borrower holds too many active loans or applied too recently"
 // @Failure 500 {object} response.Response "Internal server error"
 // @Router /loans [post]
 func (h *Handler) CreateLoan(c echo.Context) error {
@@ -94,11 +267,13 @@ func (h *Handler) CreateLoan(c echo.Context) error {
 		return response.DefaultResponse(c, "Validation error", nil, err.Error(), http.StatusBadRequest)
 	}
 
-	loan, err := h.service.CreateLoan(req.BorrowerID, req.PrincipalAmount, req.Rate, req.ROI)
+	principal := money.New(req.PrincipalAmount, currencyOrDefault(req.Currency))
+	loan, err := h.service.CreateLoan(req.BorrowerID, principal, req.Rate, req.ROI, req.terms())
 	if err != nil {
-		return response.DefaultResponse(c, "Failed to create loan", nil, err.Error(), http.StatusInternalServerError)
+		return response.DefaultResponse(c, "Failed to create loan", nil, err.Error(), errorStatus(err, http.StatusInternalServerError))
 	}
 
+	setETag(c, loan.Version)
 	return response.DefaultResponse(c, "Loan created successfully", loan, nil, http.StatusCreated)
 }
 
@@ -110,6 +285,7 @@ func (h *Handler) CreateLoan(c echo.Context) error {
 // @Produce json
 // @Param id path string true "Loan ID"
 // @Success 200 {object} response.Response "Loan details retrieved successfully"
+// @Header 200 {string} ETag "Loan version"
 // @Failure 404 {object} response.Response "Loan not found"
 // @Router /loans/{id} [get]
 func (h *Handler) GetLoan(c echo.Context) error {
@@ -120,25 +296,110 @@ func (h *Handler) GetLoan(c echo.Context) error {
 		return response.DefaultResponse(c, "Loan not found", nil, err.Error(), http.StatusNotFound)
 	}
 
+	setETag(c, loan.Version)
 	return response.DefaultResponse(c, "OK", loan, nil, http.StatusOK)
 }
 
+// GetSchedule handles retrieving the repayment schedule of a loan
+// @Summary Get repayment schedule
+// @Description Retrieves the installments of a disbursed loan with their due date, principal and interest
+// @Tags loans
+// @Accept json
+// @Produce json
+// @Param id path string true "Loan ID"
+// @Success 200 {object} response.Response "Repayment schedule"
+// @Failure 404 {object} response.Response "Loan not found or not disbursed yet"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /loans/{id}/schedule [get]
+func (h *Handler) GetSchedule(c echo.Context) error {
+	id := c.Param("id")
+
+	schedule, err := h.service.GetSchedule(id)
+	if errors.Is(err, domain.ErrLoanNotFound) || errors.Is(err, domain.ErrScheduleNotFound) {
+		return response.DefaultResponse(c, "Schedule not found", nil, err.Error(), http.StatusNotFound)
+	}
+	if err != nil {
+		return response.DefaultResponse(c, "Failed to retrieve schedule", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return response.DefaultResponse(c, "OK", schedule, nil, http.StatusOK)
+}
+
+// GetTransitions handles listing the actions allowed next on a loan
+// @Summary Get allowed transitions
+// @Description Lists the actions allowed in the current state of a loan and the states each may lead to
+// @Tags loans
+// @Accept json
+// @Produce json
+// @Param id path string true "Loan ID"
+// @Success 200 {object} response.Response "Allowed transitions"
+// @Failure 404 {object} response.Response "Loan not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /loans/{id}/transitions [get]
+func (h *Handler) GetTransitions(c echo.Context) error {
+	id := c.Param("id")
+
+	transitions, err := h.service.GetTransitions(id)
+	if errors.Is(err, domain.ErrLoanNotFound) {
+		return response.DefaultResponse(c, "Loan not found", nil, err.Error(), http.StatusNotFound)
+	}
+	if err != nil {
+		return response.DefaultResponse(c, "Failed to retrieve transitions", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return response.DefaultResponse(c, "OK", transitions, nil, http.StatusOK)
+}
+
+// GetHistory handles retrieving the audit trail of a loan
+// @Summary Get loan history
+// @Description Retrieves every recorded mutation of a loan, oldest first, with the actor, action, state before and after, payload digest and timestamp
+// @Tags loans
+// @Accept json
+// @Produce json
+// @Param id path string true "Loan ID"
+// @Success 200 {object} response.Response "Loan history"
+// @Failure 404 {object} response.Response "Loan not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /loans/{id}/history [get]
+func (h *Handler) GetHistory(c echo.Context) error {
+	id := c.Param("id")
+
+	history, err := h.service.GetHistory(id)
+	if errors.Is(err, domain.ErrLoanNotFound) {
+		return response.DefaultResponse(c, "Loan not found", nil, err.Error(), http.StatusNotFound)
+	}
+	if err != nil {
+		return response.DefaultResponse(c, "Failed to retrieve history", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return response.DefaultResponse(c, "OK", history, nil, http.StatusOK)
+}
+
 // ApproveLoanRequest represents the request body for approving a loan
 type ApproveLoanRequest struct {
 	ValidatorID string `json:"validator_id" validate:"required" example:"LOS-123"`
-	ProofURL    string `json:"proof_url" validate:"required" example:"https://storage.your.com/loan-proof/visit123.jpeg"`
+	// ProofDocumentID is the ID of a PROOF document uploaded to the loan
+	ProofDocumentID string `json:"proof_document_id" validate:"required" example:"5f0c7a52-4be0-4c6b-9d6c-1f0f0e5b8a11"`
+	// OverrideJustification is required to approve a loan graded riskier than the minimum grade
+	OverrideJustification string `json:"override_justification,omitempty" validate:"max=500" example:"Collateral covers the principal twice"`
 }
 
 // ApproveLoan handles the approval of a loan
 // @Summary Approve a loan
-// @Description Approves a loan with validator details
+// @Description Approves a loan with validator details and the proof of the field visit, uploaded beforehand as a PROOF document of the loan, and generates its agreement letter. A loan graded riskier than the minimum grade needs an override justification.
 // @Tags loans
 // @Accept json
 // @Produce json
 // @Param id path string true "Loan ID"
 // @Param request body ApproveLoanRequest true "Loan approval request"
+// @Param If-Match header string false "Expected loan version (ETag)"
 // @Success 200 {object} response.Response "Loan approved successfully"
+// @Header 200 {string} ETag "Loan version"
 // @Failure 400 {object} response.Response "Invalid request or state validation error"
+// @Failure 403 {object} response.Response "Loan grade is below the minimum and no override justification was given"
+// @Failure 404 {object} response.Response "Loan not found"
+// @Failure 409 {object} response.Response "Loan was modified concurrently"
+// @Failure 412 {object} response.Response "If-Match does not match the loan version"
 // @Router /loans/{id}/approve [post]
 func (h *Handler) ApproveLoan(c echo.Context) error {
 	id := c.Param("id")
@@ -152,35 +413,147 @@ func (h *Handler) ApproveLoan(c echo.Context) error {
 		return response.DefaultResponse(c, "Validation error", nil, err.Error(), http.StatusBadRequest)
 	}
 
-	// Validate loan state - must be PROPOSED to be approved
-	if err := h.validateLoanStateForAction(id, domain.StateProposed); err != nil {
-		return response.DefaultResponse(c, "State validation error", nil, err.Error(), http.StatusBadRequest)
+	match := ifMatch(c)
+
+	// Validate loan version and state against the transition table
+	if err := h.validateLoanStateForAction(id, domain.ActionApprove, match); err != nil {
+		return stateValidationResponse(c, err)
+	}
+
+	version, err := h.service.ApproveLoan(id, req.ValidatorID, req.ProofDocumentID, req.OverrideJustification, match)
+	if err != nil {
+		return response.DefaultResponse(c, "Failed to approve loan", nil, err.Error(), errorStatus(err, http.StatusBadRequest))
+	}
+
+	setETag(c, version)
+
+	return response.DefaultResponse(c, "OK", req, nil, http.StatusOK)
+}
+
+// CloseLoanRequest represents the request body for rejecting or cancelling a loan
+type CloseLoanRequest struct {
+	ActorID    string `json:"actor_id" validate:"required" example:"LOS-123"`
+	ReasonCode string `json:"reason_code" validate:"required,oneof=INCOMPLETE_DOCUMENTS CREDIT_RISK FRAUD_SUSPECTED BORROWER_WITHDRAWN FUNDING_EXPIRED OTHER" example:"CREDIT_RISK"`
+}
+
+// RejectLoan handles the rejection of a loan
+// @Summary Reject a loan
+// @Description Rejects a proposed loan with the reason code and the actor who rejected it
+// @Tags loans
+// @Accept json
+// @Produce json
+// @Param id path string true "Loan ID"
+// @Param request body CloseLoanRequest true "Loan rejection request"
+// @Param If-Match header string false "Expected loan version (ETag)"
+// @Success 200 {object} response.Response "Loan rejected successfully"
+// @Header 200 {string} ETag "Loan version"
+// @Failure 400 {object} response.Response "Invalid request or state validation error"
+// @Failure 404 {object} response.Response "Loan not found"
+// @Failure 409 {object} response.Response "Loan was modified concurrently"
+// @Failure 412 {object} response.Response "If-Match does not match the loan version"
+// @Router /loans/{id}/reject [post]
+func (h *Handler) RejectLoan(c echo.Context) error {
+	id := c.Param("id")
+
+	var req CloseLoanRequest
+	if err := c.Bind(&req); err != nil {
+		return response.DefaultResponse(c, "Invalid request body", nil, nil, http.StatusBadRequest)
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return response.DefaultResponse(c, "Validation error", nil, err.Error(), http.StatusBadRequest)
+	}
+
+	match := ifMatch(c)
+
+	// Validate loan version and state against the transition table
+	if err := h.validateLoanStateForAction(id, domain.ActionReject, match); err != nil {
+		return stateValidationResponse(c, err)
 	}
 
-	if err := h.service.ApproveLoan(id, req.ValidatorID, req.ProofURL); err != nil {
-		return response.DefaultResponse(c, "Failed to approve loan", nil, err.Error(), http.StatusBadRequest)
+	version, err := h.service.RejectLoan(id, req.ActorID, domain.ReasonCode(req.ReasonCode), match)
+	if err != nil {
+		return response.DefaultResponse(c, "Failed to reject loan", nil, err.Error(), errorStatus(err, http.StatusBadRequest))
 	}
 
+	setETag(c, version)
+
+	return response.DefaultResponse(c, "OK", req, nil, http.StatusOK)
+}
+
+// CancelLoan handles the cancellation of a loan
+// @Summary Cancel a loan
+// @Description Cancels a proposed or approved loan; investors of a partially funded loan are refunded and notified
+// @Tags loans
+// @Accept json
+// @Produce json
+// @Param id path string true "Loan ID"
+// @Param request body CloseLoanRequest true "Loan cancellation request"
+// @Param If-Match header string false "Expected loan version (ETag)"
+// @Success 200 {object} response.Response "Loan cancelled successfully"
+// @Header 200 {string} ETag "Loan version"
+// @Failure 400 {object} response.Response "Invalid request or state validation error"
+// @Failure 404 {object} response.Response "Loan not found"
+// @Failure 409 {object} response.Response "Loan was modified concurrently"
+// @Failure 412 {object} response.Response "If-Match does not match the loan version"
+// @Router /loans/{id}/cancel [post]
+func (h *Handler) CancelLoan(c echo.Context) error {
+	id := c.Param("id")
+
+	var req CloseLoanRequest
+	if err := c.Bind(&req); err != nil {
+		return response.DefaultResponse(c, "Invalid request body", nil, nil, http.StatusBadRequest)
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		return response.DefaultResponse(c, "Validation error", nil, err.Error(), http.StatusBadRequest)
+	}
+
+	match := ifMatch(c)
+
+	// Validate loan version and state against the transition table
+	if err := h.validateLoanStateForAction(id, domain.ActionCancel, match); err != nil {
+		return stateValidationResponse(c, err)
+	}
+
+	version, err := h.service.CancelLoan(id, req.ActorID, domain.ReasonCode(req.ReasonCode), match)
+	if err != nil {
+		return response.DefaultResponse(c, "Failed to cancel loan", nil, err.Error(), errorStatus(err, http.StatusBadRequest))
+	}
+
+	setETag(c, version)
+
 	return response.DefaultResponse(c, "OK", req, nil, http.StatusOK)
 }
 
 // AddInvestmentRequest represents the request body for adding an investment
 type AddInvestmentRequest struct {
-	InvestorID string  `json:"investor_id" validate:"required" example:"investor-001"`
-	Email      string  `json:"email" validate:"required,email" example:"client@mail.com"`
-	Amount     float64 `json:"amount" validate:"required,gt=0" example:"100000"`
+	InvestorID string `json:"investor_id" validate:"required" example:"investor-001"`
+	// Name, Email and Locale default to the registered investor profile
+	Name     string        `json:"name" validate:"omitempty,max=100" example:"Budi Santoso"`
+	Email    string        `json:"email" validate:"omitempty,email" example:"client@mail.com"`
+	Amount   money.Decimal `json:"amount" validate:"required,gt=0" swaggertype:"string" example:"100000"`
+	Currency string        `json:"currency" validate:"omitempty,supportedCurrency" example:"IDR"`
+	// Locale picks the language of the agreement email
+	Locale string `json:"locale" validate:"omitempty,bcp47_language_tag" example:"id"`
 }
 
 // AddInvestment handles adding an investment to a loan
 // @Summary Add investment to loan
-// @Description Adds an investment to an existing loan
+// @Description Adds an investment to an existing loan. The investor must be registered and KYC verified, and the investment must stay within their limits.
 // @Tags loans
 // @Accept json
 // @Produce json
 // @Param id path string true "Loan ID"
 // @Param request body AddInvestmentRequest true "Investment details"
+// @Param If-Match header string false "Expected loan version (ETag)"
 // @Success 200 {string} string "Investment added successfully"
-// @Failure 400 {string} string "Invalid request or state validation error"
+// @Header 200 {string} ETag "Loan version"
+// @Failure 400 {string} string "Invalid request, state validation error, unknown investor or investment limit exceeded"
+// @Failure 403 {object} response.Response "Investor is not KYC verified"
+// @Failure 404 {object} response.Response "Loan not found"
+// @Failure 409 {object} response.Response "Loan was modified concurrently"
+// @Failure 412 {object} response.Response "If-Match does not match the loan version"
 // @Router /loans/{id}/invest [post]
 func (h *Handler) AddInvestment(c echo.Context) error {
 	id := c.Param("id")
@@ -194,33 +567,51 @@ func (h *Handler) AddInvestment(c echo.Context) error {
 		return response.DefaultResponse(c, "Validation error", nil, err.Error(), http.StatusBadRequest)
 	}
 
-	// Validate loan state - must be APPROVED or INVESTED to add investment
-	if err := h.validateLoanStateForAction(id, domain.StateApproved, domain.StateInvested); err != nil {
-		return response.DefaultResponse(c, "State validation error", nil, err.Error(), http.StatusBadRequest)
+	match := ifMatch(c)
+
+	// Validate loan version and state against the transition table
+	if err := h.validateLoanStateForAction(id, domain.ActionInvest, match); err != nil {
+		return stateValidationResponse(c, err)
 	}
 
-	if err := h.service.AddInvestment(id, req.InvestorID, req.Email, req.Amount); err != nil {
-		return response.DefaultResponse(c, "Failed to add investment", nil, err.Error(), http.StatusBadRequest)
+	investor := domain.Investor{
+		ID:     req.InvestorID,
+		Name:   req.Name,
+		Amount: money.New(req.Amount, currencyOrDefault(req.Currency)),
+		Email:  req.Email,
+		Locale: req.Locale,
+	}
+	version, err := h.service.AddInvestment(id, investor, match)
+	if err != nil {
+		return response.DefaultResponse(c, "Failed to add investment", nil, err.Error(), errorStatus(err, http.StatusBadRequest))
 	}
 
+	setETag(c, version)
+
 	return response.DefaultResponse(c, "OK", req, nil, http.StatusOK)
 }
 
 // DisburseLoanRequest represents the request body for disbursing a loan
 type DisburseLoanRequest struct {
-	FieldOfficerID  string `json:"field_officer_id" validate:"required" example:"OFC-001"`
-	SignedAgreement string `json:"signed_agreement" validate:"required" example:"https://storage.your.com/loan-agreement/signed123.pdf"`
+	FieldOfficerID string `json:"field_officer_id" validate:"required" example:"OFC-001"`
+	// SignedAgreementDocumentID is the ID of a SIGNED_AGREEMENT document uploaded to the loan
+	SignedAgreementDocumentID string `json:"signed_agreement_document_id" validate:"required" example:"9b2d1e3f-6a7c-4d8e-b1f2-3c4d5e6f7a8b"`
 }
 
 // DisburseLoan handles the disbursement of a loan
-// @Description Disburses an approved and invested loan
+// @Description Disburses an approved and invested loan against the agreement signed by the borrower, uploaded beforehand as a SIGNED_AGREEMENT document of the loan. The signed agreement must be the agreement letter generated for the loan with the required signatures appended, otherwise the disbursement is refused with the problems found.
 // @Tags loans
 // @Accept json
 // @Produce json
 // @Param id path string true "Loan ID"
 // @Param request body DisburseLoanRequest true "Disbursement details"
+// @Param If-Match header string false "Expected loan version (ETag)"
 // @Success 200 {string} string "Loan disbursed successfully"
-// @Failure 400 {string} string "Invalid request or state validation error"
+// @Header 200 {string} ETag "Loan version"
+// @Failure 400 {string} string "Invalid request, state validation error or unverified signed agreement"
+// @Failure 404 {object} response.Response "Loan not found"
+// @Failure 409 {object} response.Response "Loan was modified concurrently"
+// @Failure 412 {object} response.Response "If-Match does not match the loan version"
 // @Router /loans/{id}/disburse [post]
 func (h *Handler) DisburseLoan(c echo.Context) error {
 
@@ -235,38 +626,49 @@ func (h *Handler) DisburseLoan(c echo.Context) error {
 		return response.DefaultResponse(c, "Validation error", nil, err.Error(), http.StatusBadRequest)
 	}
 
-	// Validate loan state - must be INVESTED to be disbursed
-	if err := h.validateLoanStateForAction(id, domain.StateInvested); err != nil {
-		return response.DefaultResponse(c, "State validation error", nil, err.Error(), http.StatusBadRequest)
+	match := ifMatch(c)
+
+	// Validate loan version and state against the transition table
+	if err := h.validateLoanStateForAction(id, domain.ActionDisburse, match); err != nil {
+		return stateValidationResponse(c, err)
 	}
 
-	if err := h.service.DisburseLoan(id, req.FieldOfficerID, req.SignedAgreement); err != nil {
-		return response.DefaultResponse(c, "Failed to disburse loan", nil, err.Error(), http.StatusBadRequest)
+	version, err := h.service.DisburseLoan(id, req.FieldOfficerID, req.SignedAgreementDocumentID, match)
+	if err != nil {
+		return response.DefaultResponse(c, "Failed to disburse loan", nil, err.Error(), errorStatus(err, http.StatusBadRequest))
 	}
 
+	setETag(c, version)
+
 	return response.DefaultResponse(c, "OK", req, nil, http.StatusOK)
 }
 
-// GenerateAgreementLetterRequest represents the request body for generating an agreement letter
-type GenerateAgreementLetterRequest struct {
-	LetterURL string `json:"letter_url" validate:"required"`
+// RecordRepaymentRequest represents the request body for recording a repayment
+type RecordRepaymentRequest struct {
+	Amount   money.Decimal `json:"amount" validate:"required,gt=0" swaggertype:"string" example:"88000"`
+	Currency string        `json:"currency" validate:"omitempty,supportedCurrency" example:"IDR"`
 }
 
-// GenerateAgreementLetter handles generating an agreement letter for a loan
-// @Summary Generate agreement letter
-// @Description Generates an agreement letter for a loan
+// RecordRepayment handles recording a borrower repayment
+// @Summary Record a repayment
+// @Description Records a borrower payment and allocates it to the installments, fees first, then interest, then principal
 // @Tags loans
 // @Accept json
 // @Produce json
 // @Param id path string true "Loan ID"
-// @Param request body GenerateAgreementLetterRequest true "Agreement letter details"
-// @Success 200 {string} string "Agreement letter generated successfully"
-// @Failure 400 {string} string "Invalid request"
-// @Router /loans/{id}/agreement [post]
-func (h *Handler) GenerateAgreementLetter(c echo.Context) error {
+// @Param request body RecordRepaymentRequest true "Repayment details"
+// @Param If-Match header string false "Expected loan version (ETag)"
+// @Success 201 {object} response.Response "Repayment recorded successfully"
+// @Header 201 {string} ETag "Loan version"
+// @Failure 400 {object} response.Response "Invalid request, state validation error or overpayment"
+// @Failure 404 {object} response.Response "Loan not found"
+// @Failure 409 {object} response.Response "Loan was modified concurrently"
+// @Failure 412 {object} response.Response "If-Match does not match the loan version"
+// @Router /loans/{id}/repayments [post]
+func (h *Handler) RecordRepayment(c echo.Context) error {
 	id := c.Param("id")
 
-	var req GenerateAgreementLetterRequest
+	var req RecordRepaymentRequest
 	if err := c.Bind(&req); err != nil {
 		return response.DefaultResponse(c, "Invalid request body", nil, nil, http.StatusBadRequest)
 	}
@@ -275,10 +677,150 @@ func (h *Handler) GenerateAgreementLetter(c echo.Context) error {
 		return response.DefaultResponse(c, "Validation error", nil, err.Error(), http.StatusBadRequest)
 	}
 
-	if err := h.service.GenerateAgreementLetter(id, req.LetterURL); err != nil {
-		return response.DefaultResponse(c, "Failed to generate agreement letter", nil, err.Error(), http.StatusBadRequest)
+	match := ifMatch(c)
+
+	// Validate loan version and state against the transition table
+	if err := h.validateLoanStateForAction(id, domain.ActionRepay, match); err != nil {
+		return stateValidationResponse(c, err)
 	}
-	return response.DefaultResponse(c, "OK", nil, nil, http.StatusOK)
+
+	repayment, version, err := h.service.RecordRepayment(id, money.New(req.Amount, currencyOrDefault(req.Currency)), match)
+	if err != nil {
+		return response.DefaultResponse(c, "Failed to record repayment", nil, err.Error(), errorStatus(err, http.StatusBadRequest))
+	}
+
+	setETag(c, version)
+
+	return response.DefaultResponse(c, "Repayment recorded successfully", repayment, nil, http.StatusCreated)
+}
+
+// GenerateAgreementLetter handles regenerating the agreement letter of a loan
+// @Summary Regenerate agreement letter
+// @Description Renders the agreement letter of an APPROVED or INVESTED loan again as a PDF from its current terms and investors, stores it and attaches its URL to the loan. The letter is generated when the loan is approved; regenerating it keeps every earlier version.
+// @Tags loans
+// @Produce json
+// @Param id path string true "Loan ID"
+// @Param If-Match header string false "Expected loan version (ETag)"
+// @Success 200 {object} response.Response "Agreement letter generated successfully"
+// @Header 200 {string} ETag "Loan version"
+// @Failure 400 {object} response.Response "Loan is not APPROVED or INVESTED"
+// @Failure 404 {object} response.Response "Loan not found"
+// @Failure 409 {object} response.Response "Loan was modified concurrently"
+// @Failure 412 {object} response.Response "If-Match does not match the loan version"
+// @Failure 500 {object} response.Response "Failed to render or store the agreement letter"
+// @Router /loans/{id}/agreement [post]
+func (h *Handler) GenerateAgreementLetter(c echo.Context) error {
+	id := c.Param("id")
+
+	document, version, err := h.service.GenerateAgreementLetter(id, ifMatch(c))
+	if err != nil {
+		if errors.Is(err, domain.ErrLoanNotFound) {
+			return response.DefaultResponse(c, "Loan not found", nil, err.Error(), http.StatusNotFound)
+		}
+		return response.DefaultResponse(c, "Failed to generate agreement letter", nil, err.Error(), errorStatus(err, http.StatusInternalServerError))
+	}
+
+	setETag(c, version)
+	return response.DefaultResponse(c, "OK", document, nil, http.StatusOK)
+}
+
+// GetAgreements handles listing the agreement letters generated for a loan
+// @Summary List agreement letters
+// @Description Lists every agreement letter generated for a loan, oldest first, with its version, document and URL. The current letter, the one to sign, is marked.
+// @Tags loans
+// @Produce json
+// @Param id path string true "Loan ID"
+// @Success 200 {object} response.Response "Agreement letters"
+// @Failure 404 {object} response.Response "Loan not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /loans/{id}/agreements [get]
+func (h *Handler) GetAgreements(c echo.Context) error {
+	id := c.Param("id")
+
+	versions, err := h.service.GetAgreements(id)
+	if errors.Is(err, domain.ErrLoanNotFound) {
+		return response.DefaultResponse(c, "Loan not found", nil, err.Error(), http.StatusNotFound)
+	}
+	if err != nil {
+		return response.DefaultResponse(c, "Failed to retrieve agreement letters", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return response.DefaultResponse(c, "OK", versions, nil, http.StatusOK)
+}
+
+// UploadDocumentRequest represents the form fields of a document upload besides the file
+type UploadDocumentRequest struct {
+	Kind string `form:"kind" validate:"required,oneof=PROOF SIGNED_AGREEMENT" example:"PROOF"`
+}
+
+// UploadDocument handles uploading a document of a loan
+// @Summary Upload a document
+// @Description Uploads the proof of the field visit (JPEG, PNG or PDF) or the signed agreement (PDF) of a loan. The content type is detected from the content; the response holds the document ID to approve or disburse the loan with and the SHA-256 checksum of the content.
+// @Tags loans
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Loan ID"
+// @Param kind formData string true "Document kind" Enums(PROOF, SIGNED_AGREEMENT)
+// @Param file formData file true "Document content"
+// @Success 201 {object} response.Response "Document uploaded successfully"
+// @Failure 400 {object} response.Response "Invalid request or validation error"
+// @Failure 404 {object} response.Response "Loan not found"
+// @Failure 413 {object} response.Response "Document too large"
+// @Failure 415 {object} response.Response "Content type not accepted for the kind"
+// @Failure 500 {object} response.Response "Failed to store the document"
+// @Router /loans/{id}/documents [post]
+func (h *Handler) UploadDocument(c echo.Context) error {
+	id := c.Param("id")
+
+	// Parsing the form reads the body, which the route limits to the document and the multipart overhead
+	if _, err := c.MultipartForm(); errors.Is(err, echo.ErrStatusRequestEntityTooLarge) {
+		return response.DefaultResponse(c, "Document too large", nil, err.Error(), http.StatusRequestEntityTooLarge)
+	}
+
+	req := UploadDocumentRequest{Kind: c.FormValue("kind")}
+	if err := h.validator.Struct(req); err != nil {
+		return response.DefaultResponse(c, "Validation error", nil, err.Error(), http.StatusBadRequest)
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		return response.DefaultResponse(c, "Invalid request body", nil, err.Error(), http.StatusBadRequest)
+	}
+	if header.Size > h.maxDocumentSize {
+		return h.documentTooLarge(c, header.Size)
+	}
+	file, err := header.Open()
+	if err != nil {
+		return response.DefaultResponse(c, "Invalid request body", nil, err.Error(), http.StatusBadRequest)
+	}
+	defer file.Close()
+	// Read at most one byte past the limit, so the buffer stays bounded whatever the part holds
+	content, err := io.ReadAll(io.LimitReader(file, h.maxDocumentSize+1))
+	if err != nil {
+		return response.DefaultResponse(c, "Invalid request body", nil, err.Error(), http.StatusBadRequest)
+	}
+	if int64(len(content)) > h.maxDocumentSize {
+		return h.documentTooLarge(c, int64(len(content)))
+	}
+
+	document, err := h.service.UploadDocument(id, domain.DocumentUpload{
+		Kind:    domain.DocumentKind(req.Kind),
+		Name:    filepath.Base(header.Filename),
+		Content: content,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrLoanNotFound) {
+			return response.DefaultResponse(c, "Loan not found", nil, err.Error(), http.StatusNotFound)
+		}
+		return response.DefaultResponse(c, "Failed to upload document", nil, err.Error(), errorStatus(err, http.StatusInternalServerError))
+	}
+	return response.DefaultResponse(c, "Document uploaded successfully", document, nil, http.StatusCreated)
+}
+
+// documentTooLarge responds to an upload of size bytes over the maximum document size
+func (h *Handler) documentTooLarge(c echo.Context, size int64) error {
+	err := fmt.Errorf("%w: %d bytes exceeds the limit of %d bytes", domain.ErrDocumentTooLarge, size, h.maxDocumentSize)
+	return response.DefaultResponse(c, "Document too large", nil, err.Error(), http.StatusRequestEntityTooLarge)
 }
 
 // GetLoansByBorrower handles retrieving all loans for a borrower
@@ -288,14 +830,21 @@ func (h *Handler) GenerateAgreementLetter(c echo.Context) error {
 // @Accept json
 // @Produce json
 // @Param borrowerId path string true "Borrower ID"
+// @Param currency query string false "Only loans in this currency" Enums(IDR, USD)
 // @Success 200 {array} response.Response "List of loans" "List of loans"
+// @Failure 400 {object} response.Response "Invalid currency"
 // @Failure 500 {string} string "Internal server error"
 // @Router /loans/borrower/{borrowerId} [get]
 func (h *Handler) GetLoansByBorrower(c echo.Context) error {
 
 	borrowerId := c.Param("borrowerId")
 
-	loans, err := h.service.GetLoansByBorrower(borrowerId)
+	filter, err := h.bindLoanFilter(c)
+	if err != nil {
+		return response.DefaultResponse(c, "Validation error", nil, err.Error(), http.StatusBadRequest)
+	}
+
+	loans, err := h.service.GetLoansByBorrower(borrowerId, filter)
 	if err != nil {
 		return response.DefaultResponse(c, "Failed to retrieve loans", nil, err.Error(), http.StatusInternalServerError)
 	}
@@ -315,6 +864,7 @@ type GetLoansByStateRequest struct {
 // @Accept json
 // @Produce json
 // @Param state path string true "Loan state" Enums(PROPOSED, APPROVED, INVESTED, DISBURSED)
+// @Param currency query string false "Only loans in this currency" Enums(IDR, USD)
 // @Success 200 {array} response.Response "List of loans"
 // @Failure 400 {string} string "Invalid state"
 // @Failure 500 {string} string "Internal server error"
@@ -330,7 +880,12 @@ func (h *Handler) GetLoansByState(c echo.Context) error {
 		return response.DefaultResponse(c, "Validation error", nil, err.Error(), http.StatusBadRequest)
 	}
 
-	loans, err := h.service.GetLoansByState(req.State)
+	filter, err := h.bindLoanFilter(c)
+	if err != nil {
+		return response.DefaultResponse(c, "Validation error", nil, err.Error(), http.StatusBadRequest)
+	}
+
+	loans, err := h.service.GetLoansByState(req.State, filter)
 	if err != nil {
 		return response.DefaultResponse(c, "Failed to retrieve loans", nil, err.Error(), http.StatusInternalServerError)
 	}
@@ -346,7 +901,9 @@ func (h *Handler) GetLoansByState(c echo.Context) error {
 // @Produce json
 // @Param page query int false "Page number (default: 1)"
 // @Param limit query int false "Number of items per page (default: 10)"
+// @Param currency query string false "Only loans in this currency" Enums(IDR, USD)
 // @Success 200 {array} response.Response "List of loans"
+// @Failure 400 {object} response.Response "Invalid currency"
 // @Failure 500 {object} response.Response "Internal server error"
 // @Router /loans [get]
 func (h *Handler) GetLoans(c echo.Context) error {
@@ -372,7 +929,12 @@ func (h *Handler) GetLoans(c echo.Context) error {
 		}
 	}
 
-	loans, err := h.service.GetLoans(page, limit)
+	filter, err := h.bindLoanFilter(c)
+	if err != nil {
+		return response.DefaultResponse(c, "Validation error", nil, err.Error(), http.StatusBadRequest)
+	}
+
+	loans, err := h.service.GetLoans(filter, page, limit)
 	if err != nil {
 		return response.DefaultResponse(c, "Failed to retrieve loans", nil, err.Error(), http.StatusInternalServerError)
 	}
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -11,10 +13,17 @@ import (
 	"github.com/golang/mock/gomock"
 	domain "github.com/hinha/los-technical/internal/domain/loan"
 	mock "github.com/hinha/los-technical/internal/domain/loan/mock"
+	"github.com/hinha/los-technical/internal/pkg/money"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 )
 
+// newTestDecimal returns a pointer to the decimal, for rates and ROIs given by hand
+func newTestDecimal(value string) *money.Decimal {
+	decimal := money.MustParse(value)
+	return &decimal
+}
+
 func TestCreateLoan(t *testing.T) {
 	// Define test cases
 	testCases := []struct {
@@ -33,18 +42,222 @@ func TestCreateLoan(t *testing.T) {
 				"roi":              10.0,
 			},
 			mockSetup: func(mockService *mock.MockService) {
-				mockService.EXPECT().CreateLoan("borrower-123", 1000.0, 5.0, 10.0).Return(&domain.Loan{
+				mockService.EXPECT().CreateLoan("borrower-123", money.MustNew("1000", money.DefaultCurrency), newTestDecimal("5"), newTestDecimal("10"), domain.RepaymentTerms{TenorMonths: domain.DefaultTenorMonths, Scheme: domain.DefaultRepaymentScheme}).Return(&domain.Loan{
+					ID:              "loan-123",
+					BorrowerID:      "borrower-123",
+					PrincipalAmount: money.MustNew("1000", money.DefaultCurrency),
+					Rate:            money.MustParse("5"),
+					ROI:             money.MustParse("10"),
+					State:           domain.StateProposed,
+				}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedMsg:    "Loan created successfully",
+		},
+		{
+			name: "Success - Decimal Strings",
+			requestBody: map[string]interface{}{
+				"borrower_id":      "borrower-123",
+				"principal_amount": "1000000.25",
+				"rate":             "12.5",
+				"roi":              "10",
+			},
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().CreateLoan("borrower-123", money.MustNew("1000000.25", money.DefaultCurrency), newTestDecimal("12.5"), newTestDecimal("10"), domain.RepaymentTerms{TenorMonths: domain.DefaultTenorMonths, Scheme: domain.DefaultRepaymentScheme}).Return(&domain.Loan{
+					ID:              "loan-123",
+					BorrowerID:      "borrower-123",
+					PrincipalAmount: money.MustNew("1000000.25", money.DefaultCurrency),
+					Rate:            money.MustParse("12.5"),
+					ROI:             money.MustParse("10"),
+					State:           domain.StateProposed,
+				}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedMsg:    "Loan created successfully",
+		},
+		{
+			name: "Invalid Request - Malformed Amount",
+			requestBody: map[string]interface{}{
+				"borrower_id":      "borrower-123",
+				"principal_amount": "1,000",
+				"rate":             "12.5",
+				"roi":              "10",
+			},
+			mockSetup:      func(mockService *mock.MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Invalid request body",
+		},
+		{
+			name: "Invalid Request - Negative Amount",
+			requestBody: map[string]interface{}{
+				"borrower_id":      "borrower-123",
+				"principal_amount": "-1000",
+				"rate":             "12.5",
+				"roi":              "10",
+			},
+			mockSetup:      func(mockService *mock.MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Validation error",
+		},
+		{
+			name: "Success - USD",
+			requestBody: map[string]interface{}{
+				"borrower_id":      "borrower-123",
+				"principal_amount": "2500.50",
+				"rate":             "12.5",
+				"roi":              "10",
+				"currency":         "USD",
+			},
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().CreateLoan("borrower-123", money.MustNew("2500.50", "USD"), newTestDecimal("12.5"), newTestDecimal("10"), domain.RepaymentTerms{TenorMonths: domain.DefaultTenorMonths, Scheme: domain.DefaultRepaymentScheme}).Return(&domain.Loan{
+					ID:              "loan-123",
+					BorrowerID:      "borrower-123",
+					PrincipalAmount: money.MustNew("2500.50", "USD"),
+					Rate:            money.MustParse("12.5"),
+					ROI:             money.MustParse("10"),
+					State:           domain.StateProposed,
+				}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedMsg:    "Loan created successfully",
+		},
+		{
+			name: "Success - Repayment Terms",
+			requestBody: map[string]interface{}{
+				"borrower_id":      "borrower-123",
+				"principal_amount": "1000000",
+				"rate":             "12",
+				"roi":              "10",
+				"tenor_months":     6,
+				"repayment_scheme": "FLAT",
+			},
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().CreateLoan("borrower-123", money.MustNew("1000000", money.DefaultCurrency), newTestDecimal("12"), newTestDecimal("10"), domain.RepaymentTerms{TenorMonths: 6, Scheme: domain.SchemeFlat}).Return(&domain.Loan{
+					ID:              "loan-123",
+					BorrowerID:      "borrower-123",
+					PrincipalAmount: money.MustNew("1000000", money.DefaultCurrency),
+					Rate:            money.MustParse("12"),
+					ROI:             money.MustParse("10"),
+					Terms:           domain.RepaymentTerms{TenorMonths: 6, Scheme: domain.SchemeFlat},
+					State:           domain.StateProposed,
+				}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedMsg:    "Loan created successfully",
+		},
+		{
+			name: "Invalid Request - Unknown Repayment Scheme",
+			requestBody: map[string]interface{}{
+				"borrower_id":      "borrower-123",
+				"principal_amount": "1000000",
+				"rate":             "12",
+				"roi":              "10",
+				"repayment_scheme": "BALLOON",
+			},
+			mockSetup:      func(mockService *mock.MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Validation error",
+		},
+		{
+			name: "Invalid Request - Tenor Too Long",
+			requestBody: map[string]interface{}{
+				"borrower_id":      "borrower-123",
+				"principal_amount": "1000000",
+				"rate":             "12",
+				"roi":              "10",
+				"tenor_months":     361,
+			},
+			mockSetup:      func(mockService *mock.MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Validation error",
+		},
+		{
+			name: "Invalid Request - Unsupported Currency",
+			requestBody: map[string]interface{}{
+				"borrower_id":      "borrower-123",
+				"principal_amount": "1000",
+				"rate":             "12.5",
+				"roi":              "10",
+				"currency":         "XYZ",
+			},
+			mockSetup:      func(mockService *mock.MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Validation error",
+		},
+		{
+			name: "Amount Finer Than Currency Minor Units",
+			requestBody: map[string]interface{}{
+				"borrower_id":      "borrower-123",
+				"principal_amount": "1000.5",
+				"rate":             "12.5",
+				"roi":              "10",
+			},
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().CreateLoan("borrower-123", money.MustNew("1000.5", "IDR"), newTestDecimal("12.5"), newTestDecimal("10"), domain.RepaymentTerms{TenorMonths: domain.DefaultTenorMonths, Scheme: domain.DefaultRepaymentScheme}).
+					Return(nil, fmt.Errorf("invalid principal amount: %w", money.ErrTooPrecise))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Failed to create loan",
+		},
+		{
+			name: "Success - Priced By Rate Card",
+			requestBody: map[string]interface{}{
+				"borrower_id":      "borrower-123",
+				"principal_amount": "1000000",
+			},
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().CreateLoan("borrower-123", money.MustNew("1000000", money.DefaultCurrency), nil, nil, domain.RepaymentTerms{TenorMonths: domain.DefaultTenorMonths, Scheme: domain.DefaultRepaymentScheme}).Return(&domain.Loan{
 					ID:              "loan-123",
 					BorrowerID:      "borrower-123",
-					PrincipalAmount: 1000.0,
-					Rate:            5.0,
-					ROI:             10.0,
+					PrincipalAmount: money.MustNew("1000000", money.DefaultCurrency),
+					Rate:            money.MustParse("13"),
+					ROI:             money.MustParse("10"),
 					State:           domain.StateProposed,
 				}, nil)
 			},
 			expectedStatus: http.StatusCreated,
 			expectedMsg:    "Loan created successfully",
 		},
+		{
+			name: "Invalid Request - Negative Rate",
+			requestBody: map[string]interface{}{
+				"borrower_id":      "borrower-123",
+				"principal_amount": "1000000",
+				"rate":             "-1",
+			},
+			mockSetup:      func(mockService *mock.MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Validation error",
+		},
+		{
+			name: "Rate Outside Corridor",
+			requestBody: map[string]interface{}{
+				"borrower_id":      "borrower-123",
+				"principal_amount": "1000000",
+				"rate":             "30",
+			},
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().CreateLoan("borrower-123", gomock.Any(), newTestDecimal("30"), nil, gomock.Any()).
+					Return(nil, fmt.Errorf("rate 30 is %w of 11 to 15", domain.ErrOutsideCorridor))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Failed to create loan",
+		},
+		{
+			name: "Negative Margin",
+			requestBody: map[string]interface{}{
+				"borrower_id":      "borrower-123",
+				"principal_amount": "1000000",
+				"rate":             "12",
+				"roi":              "12",
+			},
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().CreateLoan("borrower-123", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, fmt.Errorf("%w: ROI 12, rate 12", domain.ErrNegativeMargin))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Failed to create loan",
+		},
 		{
 			name: "Invalid Request - Missing Required Field",
 			requestBody: map[string]interface{}{
@@ -57,6 +270,51 @@ func TestCreateLoan(t *testing.T) {
 			expectedStatus: http.StatusBadRequest,
 			expectedMsg:    "Validation error",
 		},
+		{
+			name: "Unknown Borrower",
+			requestBody: map[string]interface{}{
+				"borrower_id":      "borrower-123",
+				"principal_amount": "1000",
+				"rate":             "12",
+				"roi":              "10",
+			},
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().CreateLoan("borrower-123", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, fmt.Errorf("failed to find borrower: %w", domain.ErrBorrowerNotFound))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Failed to create loan",
+		},
+		{
+			name: "Blacklisted Borrower",
+			requestBody: map[string]interface{}{
+				"borrower_id":      "borrower-123",
+				"principal_amount": "1000",
+				"rate":             "12",
+				"roi":              "10",
+			},
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().CreateLoan("borrower-123", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, fmt.Errorf("%w: borrower borrower-123: fraud", domain.ErrBorrowerBlacklisted))
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedMsg:    "Failed to create loan",
+		},
+		{
+			name: "Borrower Loan Limit Reached",
+			requestBody: map[string]interface{}{
+				"borrower_id":      "borrower-123",
+				"principal_amount": "1000",
+				"rate":             "12",
+				"roi":              "10",
+			},
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().CreateLoan("borrower-123", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, &domain.BorrowerLoanLimitError{BorrowerID: "borrower-123", ActiveLoans: 1, MaxActiveLoans: 1})
+			},
+			expectedStatus: http.StatusConflict,
+			expectedMsg:    "Failed to create loan",
+		},
 		{
 			name: "Service Error",
 			requestBody: map[string]interface{}{
@@ -66,7 +324,7 @@ func TestCreateLoan(t *testing.T) {
 				"roi":              10.0,
 			},
 			mockSetup: func(mockService *mock.MockService) {
-				mockService.EXPECT().CreateLoan("borrower-123", 1000.0, 5.0, 10.0).Return(nil, errors.New("service error"))
+				mockService.EXPECT().CreateLoan("borrower-123", money.MustNew("1000", money.DefaultCurrency), newTestDecimal("5"), newTestDecimal("10"), domain.RepaymentTerms{TenorMonths: domain.DefaultTenorMonths, Scheme: domain.DefaultRepaymentScheme}).Return(nil, errors.New("service error"))
 			},
 			expectedStatus: http.StatusInternalServerError,
 			expectedMsg:    "Failed to create loan",
@@ -116,32 +374,1114 @@ func TestGetLoan(t *testing.T) {
 		mockSetup      func(*mock.MockService)
 		expectedStatus int
 		expectedMsg    string
+		expectedETag   string
+	}{
+		{
+			name:   "Success",
+			loanID: "loan-123",
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{
+					ID:              "loan-123",
+					BorrowerID:      "borrower-123",
+					PrincipalAmount: money.MustNew("1000", money.DefaultCurrency),
+					Rate:            money.MustParse("5"),
+					ROI:             money.MustParse("10"),
+					State:           domain.StateProposed,
+					Version:         3,
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedMsg:    "OK",
+			expectedETag:   `"3"`,
+		},
+		{
+			name:   "Loan Not Found",
+			loanID: "non-existent",
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetLoan("non-existent").Return(nil, errors.New("loan not found"))
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedMsg:    "Loan not found",
+		},
+	}
+
+	// Run test cases
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := mock.NewMockService(ctrl)
+			tc.mockSetup(mockService)
+
+			handler := NewHandler(mockService)
+
+			// Create request
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			rec := httptest.NewRecorder()
+			e := echo.New()
+			c := e.NewContext(req, rec)
+			c.SetPath("/loans/:id")
+			c.SetParamNames("id")
+			c.SetParamValues(tc.loanID)
+
+			// Execute
+			err := handler.GetLoan(c)
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.Equal(t, tc.expectedETag, rec.Header().Get("ETag"))
+
+			// Parse response
+			var response map[string]interface{}
+			json.Unmarshal(rec.Body.Bytes(), &response)
+			assert.Equal(t, tc.expectedMsg, response["message"])
+		})
+	}
+}
+
+func TestGetSchedule(t *testing.T) {
+	// Define test cases
+	testCases := []struct {
+		name           string
+		loanID         string
+		mockSetup      func(*mock.MockService)
+		expectedStatus int
+		expectedMsg    string
+		expectedCount  int
 	}{
 		{
-			name:   "Success",
-			loanID: "loan-123",
+			name:   "Success",
+			loanID: "loan-123",
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetSchedule("loan-123").Return([]domain.Installment{
+					{Number: 1, Total: money.MustNew("500", money.DefaultCurrency)},
+					{Number: 2, Total: money.MustNew("500", money.DefaultCurrency)},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedMsg:    "OK",
+			expectedCount:  2,
+		},
+		{
+			name:   "Loan Not Disbursed",
+			loanID: "loan-123",
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetSchedule("loan-123").Return(nil, fmt.Errorf("%w: loan loan-123 is INVESTED", domain.ErrScheduleNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedMsg:    "Schedule not found",
+		},
+		{
+			name:   "Loan Not Found",
+			loanID: "non-existent",
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetSchedule("non-existent").Return(nil, fmt.Errorf("failed to find loan: %w", domain.ErrLoanNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedMsg:    "Schedule not found",
+		},
+		{
+			name:   "Service Error",
+			loanID: "loan-123",
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetSchedule("loan-123").Return(nil, errors.New("database error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedMsg:    "Failed to retrieve schedule",
+		},
+	}
+
+	// Run test cases
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := mock.NewMockService(ctrl)
+			tc.mockSetup(mockService)
+
+			handler := NewHandler(mockService)
+
+			// Create request
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			rec := httptest.NewRecorder()
+			e := echo.New()
+			c := e.NewContext(req, rec)
+			c.SetPath("/loans/:id/schedule")
+			c.SetParamNames("id")
+			c.SetParamValues(tc.loanID)
+
+			// Execute
+			err := handler.GetSchedule(c)
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+
+			// Parse response
+			var response map[string]interface{}
+			json.Unmarshal(rec.Body.Bytes(), &response)
+			assert.Equal(t, tc.expectedMsg, response["message"])
+			if tc.expectedCount > 0 {
+				assert.Len(t, response["data"], tc.expectedCount)
+			}
+		})
+	}
+}
+
+func TestGetTransitions(t *testing.T) {
+	// Define test cases
+	testCases := []struct {
+		name           string
+		loanID         string
+		mockSetup      func(*mock.MockService)
+		expectedStatus int
+		expectedMsg    string
+		expectedCount  int
+	}{
+		{
+			name:   "Success",
+			loanID: "loan-123",
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetTransitions("loan-123").Return([]domain.NextTransition{
+					{Action: domain.ActionApprove, To: []domain.LoanState{domain.StateApproved}},
+					{Action: domain.ActionReject, To: []domain.LoanState{domain.StateRejected}},
+					{Action: domain.ActionCancel, To: []domain.LoanState{domain.StateCancelled}},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedMsg:    "OK",
+			expectedCount:  3,
+		},
+		{
+			name:   "Loan Not Found",
+			loanID: "non-existent",
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetTransitions("non-existent").Return(nil, fmt.Errorf("failed to find loan: %w", domain.ErrLoanNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedMsg:    "Loan not found",
+		},
+		{
+			name:   "Service Error",
+			loanID: "loan-123",
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetTransitions("loan-123").Return(nil, errors.New("database error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedMsg:    "Failed to retrieve transitions",
+		},
+	}
+
+	// Run test cases
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := mock.NewMockService(ctrl)
+			tc.mockSetup(mockService)
+
+			handler := NewHandler(mockService)
+
+			// Create request
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			rec := httptest.NewRecorder()
+			e := echo.New()
+			c := e.NewContext(req, rec)
+			c.SetPath("/loans/:id/transitions")
+			c.SetParamNames("id")
+			c.SetParamValues(tc.loanID)
+
+			// Execute
+			err := handler.GetTransitions(c)
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+
+			// Parse response
+			var response map[string]interface{}
+			json.Unmarshal(rec.Body.Bytes(), &response)
+			assert.Equal(t, tc.expectedMsg, response["message"])
+			if tc.expectedCount > 0 {
+				assert.Len(t, response["data"], tc.expectedCount)
+			}
+		})
+	}
+}
+
+func TestGetHistory(t *testing.T) {
+	// Define test cases
+	testCases := []struct {
+		name           string
+		loanID         string
+		mockSetup      func(*mock.MockService)
+		expectedStatus int
+		expectedMsg    string
+		expectedCount  int
+	}{
+		{
+			name:   "Success",
+			loanID: "loan-123",
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetHistory("loan-123").Return([]domain.AuditEntry{
+					{LoanID: "loan-123", Actor: "borrower-123", Action: domain.ActionCreate, ToState: domain.StateProposed},
+					{LoanID: "loan-123", Actor: "validator-123", Action: domain.ActionApprove, FromState: domain.StateProposed, ToState: domain.StateApproved},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedMsg:    "OK",
+			expectedCount:  2,
+		},
+		{
+			name:   "Loan Not Found",
+			loanID: "non-existent",
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetHistory("non-existent").Return(nil, fmt.Errorf("failed to find loan: %w", domain.ErrLoanNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedMsg:    "Loan not found",
+		},
+		{
+			name:   "Service Error",
+			loanID: "loan-123",
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetHistory("loan-123").Return(nil, errors.New("database error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedMsg:    "Failed to retrieve history",
+		},
+	}
+
+	// Run test cases
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := mock.NewMockService(ctrl)
+			tc.mockSetup(mockService)
+
+			handler := NewHandler(mockService)
+
+			// Create request
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			rec := httptest.NewRecorder()
+			e := echo.New()
+			c := e.NewContext(req, rec)
+			c.SetPath("/loans/:id/history")
+			c.SetParamNames("id")
+			c.SetParamValues(tc.loanID)
+
+			// Execute
+			err := handler.GetHistory(c)
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+
+			// Parse response
+			var response map[string]interface{}
+			json.Unmarshal(rec.Body.Bytes(), &response)
+			assert.Equal(t, tc.expectedMsg, response["message"])
+			if tc.expectedCount > 0 {
+				assert.Len(t, response["data"], tc.expectedCount)
+			}
+		})
+	}
+}
+
+func TestGetAgreements(t *testing.T) {
+	// Define test cases
+	testCases := []struct {
+		name           string
+		loanID         string
+		mockSetup      func(*mock.MockService)
+		expectedStatus int
+		expectedMsg    string
+		expectedCount  int
+	}{
+		{
+			name:   "Success",
+			loanID: "loan-123",
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetAgreements("loan-123").Return([]domain.AgreementVersion{
+					{Version: 1, DocumentID: "agreement-1", URL: "http://example.com/documents/agreement-1"},
+					{Version: 2, DocumentID: "agreement-2", URL: "http://example.com/documents/agreement-2", Current: true},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedMsg:    "OK",
+			expectedCount:  2,
+		},
+		{
+			name:   "Loan Not Found",
+			loanID: "non-existent",
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetAgreements("non-existent").Return(nil, fmt.Errorf("failed to find loan: %w", domain.ErrLoanNotFound))
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedMsg:    "Loan not found",
+		},
+		{
+			name:   "Service Error",
+			loanID: "loan-123",
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetAgreements("loan-123").Return(nil, errors.New("database error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedMsg:    "Failed to retrieve agreement letters",
+		},
+	}
+
+	// Run test cases
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := mock.NewMockService(ctrl)
+			tc.mockSetup(mockService)
+
+			handler := NewHandler(mockService)
+
+			// Create request
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			rec := httptest.NewRecorder()
+			e := echo.New()
+			c := e.NewContext(req, rec)
+			c.SetPath("/loans/:id/agreements")
+			c.SetParamNames("id")
+			c.SetParamValues(tc.loanID)
+
+			// Execute
+			err := handler.GetAgreements(c)
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+
+			// Parse response
+			var response map[string]interface{}
+			json.Unmarshal(rec.Body.Bytes(), &response)
+			assert.Equal(t, tc.expectedMsg, response["message"])
+			if tc.expectedCount > 0 {
+				assert.Len(t, response["data"], tc.expectedCount)
+			}
+		})
+	}
+}
+
+func TestApproveLoan(t *testing.T) {
+	// Define test cases
+	testCases := []struct {
+		name           string
+		loanID         string
+		requestBody    map[string]interface{}
+		mockSetup      func(*mock.MockService)
+		expectedStatus int
+		expectedMsg    string
+	}{
+		{
+			name:   "Success",
+			loanID: "loan-123",
+			requestBody: map[string]interface{}{
+				"validator_id":      "validator-123",
+				"proof_document_id": "proof-1",
+			},
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{
+					ID:    "loan-123",
+					State: domain.StateProposed,
+				}, nil)
+				mockService.EXPECT().ApproveLoan("loan-123", "validator-123", "proof-1", "", domain.IfMatch(nil)).Return(int64(2), nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedMsg:    "OK",
+		},
+		{
+			name:   "Invalid Request - Missing Required Field",
+			loanID: "loan-123",
+			requestBody: map[string]interface{}{
+				"validator_id": "validator-123",
+				// Missing proof_document_id
+			},
+			mockSetup:      func(mockService *mock.MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Validation error",
+		},
+		{
+			name:   "Invalid State",
+			loanID: "loan-123",
+			requestBody: map[string]interface{}{
+				"validator_id":      "validator-123",
+				"proof_document_id": "proof-1",
+			},
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{
+					ID:    "loan-123",
+					State: domain.StateApproved, // Already approved
+				}, nil)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "State validation error",
+		},
+		{
+			name:   "Service Error",
+			loanID: "loan-123",
+			requestBody: map[string]interface{}{
+				"validator_id":      "validator-123",
+				"proof_document_id": "proof-1",
+			},
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{
+					ID:    "loan-123",
+					State: domain.StateProposed,
+				}, nil)
+				mockService.EXPECT().ApproveLoan("loan-123", "validator-123", "proof-1", "", domain.IfMatch(nil)).Return(int64(0), errors.New("service error"))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Failed to approve loan",
+		},
+		{
+			name:   "Proof Of Another Loan",
+			loanID: "loan-123",
+			requestBody: map[string]interface{}{
+				"validator_id":      "validator-123",
+				"proof_document_id": "proof-1",
+			},
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{
+					ID:    "loan-123",
+					State: domain.StateProposed,
+				}, nil)
+				mockService.EXPECT().ApproveLoan("loan-123", "validator-123", "proof-1", "", domain.IfMatch(nil)).Return(int64(0), fmt.Errorf("failed to find document: %w", domain.ErrDocumentMismatch))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Failed to approve loan",
+		},
+		{
+			name:   "Grade Below Minimum",
+			loanID: "loan-123",
+			requestBody: map[string]interface{}{
+				"validator_id":      "validator-123",
+				"proof_document_id": "proof-1",
+			},
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{
+					ID:    "loan-123",
+					State: domain.StateProposed,
+				}, nil)
+				mockService.EXPECT().ApproveLoan("loan-123", "validator-123", "proof-1", "", domain.IfMatch(nil)).Return(int64(0), fmt.Errorf("%w: loan loan-123 is graded D", domain.ErrRiskGradeTooLow))
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedMsg:    "Failed to approve loan",
+		},
+		{
+			name:   "Success - Override Justification",
+			loanID: "loan-123",
+			requestBody: map[string]interface{}{
+				"validator_id":           "validator-123",
+				"proof_document_id":      "proof-1",
+				"override_justification": "Collateral covers the principal twice",
+			},
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{
+					ID:    "loan-123",
+					State: domain.StateProposed,
+				}, nil)
+				mockService.EXPECT().ApproveLoan("loan-123", "validator-123", "proof-1", "Collateral covers the principal twice", domain.IfMatch(nil)).Return(int64(2), nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedMsg:    "OK",
+		},
+	}
+
+	// Run test cases
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := mock.NewMockService(ctrl)
+			tc.mockSetup(mockService)
+
+			handler := NewHandler(mockService)
+
+			// Create request
+			reqBody, _ := json.Marshal(tc.requestBody)
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(reqBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			e := echo.New()
+			c := e.NewContext(req, rec)
+			c.SetPath("/loans/:id/approve")
+			c.SetParamNames("id")
+			c.SetParamValues(tc.loanID)
+
+			// Execute
+			err := handler.ApproveLoan(c)
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+
+			// Parse response
+			var response map[string]interface{}
+			json.Unmarshal(rec.Body.Bytes(), &response)
+			assert.Equal(t, tc.expectedMsg, response["message"])
+		})
+	}
+}
+
+func TestRejectLoan(t *testing.T) {
+	// Define test cases
+	testCases := []struct {
+		name           string
+		loanID         string
+		requestBody    map[string]interface{}
+		mockSetup      func(*mock.MockService)
+		expectedStatus int
+		expectedMsg    string
+	}{
+		{
+			name:   "Success",
+			loanID: "loan-123",
+			requestBody: map[string]interface{}{
+				"actor_id":    "validator-123",
+				"reason_code": "CREDIT_RISK",
+			},
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{ID: "loan-123", State: domain.StateProposed}, nil)
+				mockService.EXPECT().RejectLoan("loan-123", "validator-123", domain.ReasonCreditRisk, domain.IfMatch(nil)).Return(int64(2), nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedMsg:    "OK",
+		},
+		{
+			name:   "Invalid Request - Unknown Reason Code",
+			loanID: "loan-123",
+			requestBody: map[string]interface{}{
+				"actor_id":    "validator-123",
+				"reason_code": "BAD_VIBES",
+			},
+			mockSetup:      func(mockService *mock.MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Validation error",
+		},
+		{
+			name:   "Invalid Request - Missing Actor",
+			loanID: "loan-123",
+			requestBody: map[string]interface{}{
+				"reason_code": "CREDIT_RISK",
+			},
+			mockSetup:      func(mockService *mock.MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Validation error",
+		},
+		{
+			name:   "Invalid State",
+			loanID: "loan-123",
+			requestBody: map[string]interface{}{
+				"actor_id":    "validator-123",
+				"reason_code": "CREDIT_RISK",
+			},
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{ID: "loan-123", State: domain.StateApproved}, nil)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "State validation error",
+		},
+	}
+
+	// Run test cases
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := mock.NewMockService(ctrl)
+			tc.mockSetup(mockService)
+
+			handler := NewHandler(mockService)
+
+			// Create request
+			reqBody, _ := json.Marshal(tc.requestBody)
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(reqBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			e := echo.New()
+			c := e.NewContext(req, rec)
+			c.SetPath("/loans/:id/reject")
+			c.SetParamNames("id")
+			c.SetParamValues(tc.loanID)
+
+			// Execute
+			err := handler.RejectLoan(c)
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+
+			// Parse response
+			var response map[string]interface{}
+			json.Unmarshal(rec.Body.Bytes(), &response)
+			assert.Equal(t, tc.expectedMsg, response["message"])
+		})
+	}
+}
+
+func TestCancelLoan(t *testing.T) {
+	// Define test cases
+	testCases := []struct {
+		name           string
+		loanID         string
+		requestBody    map[string]interface{}
+		mockSetup      func(*mock.MockService)
+		expectedStatus int
+		expectedMsg    string
+	}{
+		{
+			name:   "Success - Proposed Loan",
+			loanID: "loan-123",
+			requestBody: map[string]interface{}{
+				"actor_id":    "borrower-123",
+				"reason_code": "BORROWER_WITHDRAWN",
+			},
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{ID: "loan-123", State: domain.StateProposed}, nil)
+				mockService.EXPECT().CancelLoan("loan-123", "borrower-123", domain.ReasonBorrowerWithdrawn, domain.IfMatch(nil)).Return(int64(2), nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedMsg:    "OK",
+		},
+		{
+			name:   "Success - Approved Loan",
+			loanID: "loan-123",
+			requestBody: map[string]interface{}{
+				"actor_id":    "officer-123",
+				"reason_code": "FUNDING_EXPIRED",
+			},
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{ID: "loan-123", State: domain.StateApproved}, nil)
+				mockService.EXPECT().CancelLoan("loan-123", "officer-123", domain.ReasonFundingExpired, domain.IfMatch(nil)).Return(int64(2), nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedMsg:    "OK",
+		},
+		{
+			name:   "Invalid State",
+			loanID: "loan-123",
+			requestBody: map[string]interface{}{
+				"actor_id":    "officer-123",
+				"reason_code": "OTHER",
+			},
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{ID: "loan-123", State: domain.StateInvested}, nil)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "State validation error",
+		},
+		{
+			name:   "Version Conflict",
+			loanID: "loan-123",
+			requestBody: map[string]interface{}{
+				"actor_id":    "officer-123",
+				"reason_code": "OTHER",
+			},
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{ID: "loan-123", State: domain.StateApproved}, nil)
+				mockService.EXPECT().CancelLoan("loan-123", "officer-123", domain.ReasonOther, domain.IfMatch(nil)).
+					Return(int64(0), &domain.VersionConflictError{LoanID: "loan-123", Expected: 1, Actual: 2})
+			},
+			expectedStatus: http.StatusConflict,
+			expectedMsg:    "Failed to cancel loan",
+		},
+	}
+
+	// Run test cases
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := mock.NewMockService(ctrl)
+			tc.mockSetup(mockService)
+
+			handler := NewHandler(mockService)
+
+			// Create request
+			reqBody, _ := json.Marshal(tc.requestBody)
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(reqBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			e := echo.New()
+			c := e.NewContext(req, rec)
+			c.SetPath("/loans/:id/cancel")
+			c.SetParamNames("id")
+			c.SetParamValues(tc.loanID)
+
+			// Execute
+			err := handler.CancelLoan(c)
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+
+			// Parse response
+			var response map[string]interface{}
+			json.Unmarshal(rec.Body.Bytes(), &response)
+			assert.Equal(t, tc.expectedMsg, response["message"])
+		})
+	}
+}
+
+func TestAddInvestment(t *testing.T) {
+	// Define test cases
+	testCases := []struct {
+		name           string
+		loanID         string
+		requestBody    map[string]interface{}
+		mockSetup      func(*mock.MockService)
+		expectedStatus int
+		expectedMsg    string
+	}{
+		{
+			name:   "Success",
+			loanID: "loan-123",
+			requestBody: map[string]interface{}{
+				"investor_id": "investor-123",
+				"email":       "investor@example.com",
+				"amount":      500.0,
+			},
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{
+					ID:    "loan-123",
+					State: domain.StateApproved,
+				}, nil)
+				mockService.EXPECT().AddInvestment("loan-123", domain.Investor{ID: "investor-123", Email: "investor@example.com", Amount: money.MustNew("500", money.DefaultCurrency)}, domain.IfMatch(nil)).Return(int64(2), nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedMsg:    "OK",
+		},
+		{
+			name:   "Success - Name And Locale",
+			loanID: "loan-123",
+			requestBody: map[string]interface{}{
+				"investor_id": "investor-123",
+				"name":        "Budi Santoso",
+				"email":       "investor@example.com",
+				"amount":      500.0,
+				"locale":      "id-ID",
+			},
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{
+					ID:    "loan-123",
+					State: domain.StateApproved,
+				}, nil)
+				mockService.EXPECT().AddInvestment("loan-123", domain.Investor{
+					ID:     "investor-123",
+					Name:   "Budi Santoso",
+					Email:  "investor@example.com",
+					Amount: money.MustNew("500", money.DefaultCurrency),
+					Locale: "id-ID",
+				}, domain.IfMatch(nil)).Return(int64(2), nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedMsg:    "OK",
+		},
+		{
+			name:   "Invalid Request - Invalid Locale",
+			loanID: "loan-123",
+			requestBody: map[string]interface{}{
+				"investor_id": "investor-123",
+				"email":       "investor@example.com",
+				"amount":      500.0,
+				"locale":      "not a locale",
+			},
+			mockSetup:      func(mockService *mock.MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Validation error",
+		},
+		{
+			name:   "Invalid Request - Missing Required Field",
+			loanID: "loan-123",
+			requestBody: map[string]interface{}{
+				"investor_id": "investor-123",
+				"email":       "investor@example.com",
+				// Missing amount
+			},
+			mockSetup:      func(mockService *mock.MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Validation error",
+		},
+		{
+			name:   "Invalid Email Format",
+			loanID: "loan-123",
+			requestBody: map[string]interface{}{
+				"investor_id": "investor-123",
+				"email":       "invalid-email",
+				"amount":      500.0,
+			},
+			mockSetup:      func(mockService *mock.MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Validation error",
+		},
+		{
+			name:   "Success - Contact Details From Investor Profile",
+			loanID: "loan-123",
+			requestBody: map[string]interface{}{
+				"investor_id": "investor-123",
+				"amount":      500.0,
+			},
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{
+					ID:    "loan-123",
+					State: domain.StateApproved,
+				}, nil)
+				mockService.EXPECT().AddInvestment("loan-123", domain.Investor{ID: "investor-123", Amount: money.MustNew("500", money.DefaultCurrency)}, domain.IfMatch(nil)).Return(int64(2), nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedMsg:    "OK",
+		},
+		{
+			name:   "Investor Not Verified",
+			loanID: "loan-123",
+			requestBody: map[string]interface{}{
+				"investor_id": "investor-123",
+				"amount":      500.0,
+			},
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{
+					ID:    "loan-123",
+					State: domain.StateApproved,
+				}, nil)
+				mockService.EXPECT().AddInvestment("loan-123", gomock.Any(), domain.IfMatch(nil)).Return(int64(0), fmt.Errorf("%w: investor investor-123 is PENDING", domain.ErrInvestorNotVerified))
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedMsg:    "Failed to add investment",
+		},
+		{
+			name:   "Investment Limit Exceeded",
+			loanID: "loan-123",
+			requestBody: map[string]interface{}{
+				"investor_id": "investor-123",
+				"amount":      500.0,
+			},
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{
+					ID:    "loan-123",
+					State: domain.StateApproved,
+				}, nil)
+				mockService.EXPECT().AddInvestment("loan-123", gomock.Any(), domain.IfMatch(nil)).Return(int64(0), fmt.Errorf("%w: per loan commitment of 500 IDR would exceed the limit of 300 IDR", domain.ErrInvestmentLimitExceeded))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Failed to add investment",
+		},
+		{
+			name:   "Invalid State",
+			loanID: "loan-123",
+			requestBody: map[string]interface{}{
+				"investor_id": "investor-123",
+				"email":       "investor@example.com",
+				"amount":      500.0,
+			},
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{
+					ID:    "loan-123",
+					State: domain.StateProposed, // Not approved yet
+				}, nil)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "State validation error",
+		},
+		{
+			name:   "Service Error",
+			loanID: "loan-123",
+			requestBody: map[string]interface{}{
+				"investor_id": "investor-123",
+				"email":       "investor@example.com",
+				"amount":      500.0,
+			},
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{
+					ID:    "loan-123",
+					State: domain.StateApproved,
+				}, nil)
+				mockService.EXPECT().AddInvestment("loan-123", domain.Investor{ID: "investor-123", Email: "investor@example.com", Amount: money.MustNew("500", money.DefaultCurrency)}, domain.IfMatch(nil)).Return(int64(0), errors.New("service error"))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Failed to add investment",
+		},
+	}
+
+	// Run test cases
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := mock.NewMockService(ctrl)
+			tc.mockSetup(mockService)
+
+			handler := NewHandler(mockService)
+
+			// Create request
+			reqBody, _ := json.Marshal(tc.requestBody)
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(reqBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			e := echo.New()
+			c := e.NewContext(req, rec)
+			c.SetPath("/loans/:id/invest")
+			c.SetParamNames("id")
+			c.SetParamValues(tc.loanID)
+
+			// Execute
+			err := handler.AddInvestment(c)
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+
+			// Parse response
+			var response map[string]interface{}
+			json.Unmarshal(rec.Body.Bytes(), &response)
+			assert.Equal(t, tc.expectedMsg, response["message"])
+		})
+	}
+}
+
+func TestAddInvestment_OptimisticLocking(t *testing.T) {
+	// Define test cases
+	testCases := []struct {
+		name           string
+		ifMatch        string
+		mockSetup      func(*mock.MockService)
+		expectedStatus int
+		expectedMsg    string
+		expectedETag   string
+	}{
+		{
+			name:    "Matching If-Match",
+			ifMatch: `"2"`,
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{
+					ID:      "loan-123",
+					State:   domain.StateApproved,
+					Version: 2,
+				}, nil)
+				mockService.EXPECT().AddInvestment("loan-123", domain.Investor{ID: "investor-123", Email: "investor@example.com", Amount: money.MustNew("500", money.DefaultCurrency)}, domain.IfMatch{2}).Return(int64(3), nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedMsg:    "OK",
+			expectedETag:   `"3"`,
+		},
+		{
+			name:    "One Of Several If-Match Tags",
+			ifMatch: `"1", "2"`,
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{
+					ID:      "loan-123",
+					State:   domain.StateApproved,
+					Version: 2,
+				}, nil)
+				mockService.EXPECT().AddInvestment("loan-123", domain.Investor{ID: "investor-123", Email: "investor@example.com", Amount: money.MustNew("500", money.DefaultCurrency)}, domain.IfMatch{1, 2}).Return(int64(3), nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedMsg:    "OK",
+			expectedETag:   `"3"`,
+		},
+		{
+			name:    "Wildcard If-Match",
+			ifMatch: "*",
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{
+					ID:      "loan-123",
+					State:   domain.StateApproved,
+					Version: 7,
+				}, nil)
+				mockService.EXPECT().AddInvestment("loan-123", domain.Investor{ID: "investor-123", Email: "investor@example.com", Amount: money.MustNew("500", money.DefaultCurrency)}, domain.IfMatch(nil)).Return(int64(8), nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedMsg:    "OK",
+			expectedETag:   `"8"`,
+		},
+		{
+			name:    "Stale If-Match",
+			ifMatch: `"1"`,
 			mockSetup: func(mockService *mock.MockService) {
 				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{
-					ID:              "loan-123",
-					BorrowerID:      "borrower-123",
-					PrincipalAmount: 1000.0,
-					Rate:            5.0,
-					ROI:             10.0,
-					State:           domain.StateProposed,
+					ID:      "loan-123",
+					State:   domain.StateApproved,
+					Version: 2,
 				}, nil)
 			},
-			expectedStatus: http.StatusOK,
-			expectedMsg:    "OK",
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedMsg:    "Precondition failed",
 		},
 		{
-			name:   "Loan Not Found",
-			loanID: "non-existent",
+			name:    "Weak If-Match",
+			ifMatch: `W/"2"`,
 			mockSetup: func(mockService *mock.MockService) {
-				mockService.EXPECT().GetLoan("non-existent").Return(nil, errors.New("loan not found"))
+				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{
+					ID:      "loan-123",
+					State:   domain.StateApproved,
+					Version: 2,
+				}, nil)
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedMsg:    "Precondition failed",
+		},
+		{
+			name:    "Modified After The Precondition Was Checked",
+			ifMatch: `"2"`,
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{
+					ID:      "loan-123",
+					State:   domain.StateApproved,
+					Version: 2,
+				}, nil)
+				mockService.EXPECT().AddInvestment("loan-123", domain.Investor{ID: "investor-123", Email: "investor@example.com", Amount: money.MustNew("500", money.DefaultCurrency)}, domain.IfMatch{2}).
+					Return(int64(0), &domain.PreconditionError{LoanID: "loan-123", Expected: domain.IfMatch{2}, Actual: 3})
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedMsg:    "Failed to add investment",
+		},
+		{
+			name:    "Loan Not Found",
+			ifMatch: `"2"`,
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetLoan("loan-123").Return(nil, fmt.Errorf("failed to find loan: %w", domain.ErrLoanNotFound))
 			},
 			expectedStatus: http.StatusNotFound,
 			expectedMsg:    "Loan not found",
 		},
+		{
+			name: "Version Conflict",
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{
+					ID:    "loan-123",
+					State: domain.StateApproved,
+				}, nil)
+				mockService.EXPECT().AddInvestment("loan-123", domain.Investor{ID: "investor-123", Email: "investor@example.com", Amount: money.MustNew("500", money.DefaultCurrency)}, domain.IfMatch(nil)).
+					Return(int64(0), &domain.VersionConflictError{LoanID: "loan-123", Expected: 1, Actual: 2})
+			},
+			expectedStatus: http.StatusConflict,
+			expectedMsg:    "Failed to add investment",
+		},
 	}
 
 	// Run test cases
@@ -157,20 +1497,30 @@ func TestGetLoan(t *testing.T) {
 			handler := NewHandler(mockService)
 
 			// Create request
-			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			reqBody, _ := json.Marshal(map[string]interface{}{
+				"investor_id": "investor-123",
+				"email":       "investor@example.com",
+				"amount":      500.0,
+			})
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(reqBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
 			rec := httptest.NewRecorder()
 			e := echo.New()
 			c := e.NewContext(req, rec)
-			c.SetPath("/loans/:id")
+			c.SetPath("/loans/:id/invest")
 			c.SetParamNames("id")
-			c.SetParamValues(tc.loanID)
+			c.SetParamValues("loan-123")
 
 			// Execute
-			err := handler.GetLoan(c)
+			err := handler.AddInvestment(c)
 
 			// Assert
 			assert.NoError(t, err)
 			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.Equal(t, tc.expectedETag, rec.Header().Get("ETag"))
 
 			// Parse response
 			var response map[string]interface{}
@@ -180,7 +1530,7 @@ func TestGetLoan(t *testing.T) {
 	}
 }
 
-func TestApproveLoan(t *testing.T) {
+func TestDisburseLoan(t *testing.T) {
 	// Define test cases
 	testCases := []struct {
 		name           string
@@ -194,15 +1544,15 @@ func TestApproveLoan(t *testing.T) {
 			name:   "Success",
 			loanID: "loan-123",
 			requestBody: map[string]interface{}{
-				"validator_id": "validator-123",
-				"proof_url":    "http://example.com/proof",
+				"field_officer_id":             "officer-123",
+				"signed_agreement_document_id": "signed-1",
 			},
 			mockSetup: func(mockService *mock.MockService) {
 				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{
 					ID:    "loan-123",
-					State: domain.StateProposed,
+					State: domain.StateInvested,
 				}, nil)
-				mockService.EXPECT().ApproveLoan("loan-123", "validator-123", "http://example.com/proof").Return(nil)
+				mockService.EXPECT().DisburseLoan("loan-123", "officer-123", "signed-1", domain.IfMatch(nil)).Return(int64(2), nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedMsg:    "OK",
@@ -211,8 +1561,8 @@ func TestApproveLoan(t *testing.T) {
 			name:   "Invalid Request - Missing Required Field",
 			loanID: "loan-123",
 			requestBody: map[string]interface{}{
-				"validator_id": "validator-123",
-				// Missing proof_url
+				"field_officer_id": "officer-123",
+				// Missing signed_agreement_document_id
 			},
 			mockSetup:      func(mockService *mock.MockService) {},
 			expectedStatus: http.StatusBadRequest,
@@ -222,13 +1572,13 @@ func TestApproveLoan(t *testing.T) {
 			name:   "Invalid State",
 			loanID: "loan-123",
 			requestBody: map[string]interface{}{
-				"validator_id": "validator-123",
-				"proof_url":    "http://example.com/proof",
+				"field_officer_id":             "officer-123",
+				"signed_agreement_document_id": "signed-1",
 			},
 			mockSetup: func(mockService *mock.MockService) {
 				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{
 					ID:    "loan-123",
-					State: domain.StateApproved, // Already approved
+					State: domain.StateApproved, // Not invested yet
 				}, nil)
 			},
 			expectedStatus: http.StatusBadRequest,
@@ -238,18 +1588,36 @@ func TestApproveLoan(t *testing.T) {
 			name:   "Service Error",
 			loanID: "loan-123",
 			requestBody: map[string]interface{}{
-				"validator_id": "validator-123",
-				"proof_url":    "http://example.com/proof",
+				"field_officer_id":             "officer-123",
+				"signed_agreement_document_id": "signed-1",
 			},
 			mockSetup: func(mockService *mock.MockService) {
 				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{
 					ID:    "loan-123",
-					State: domain.StateProposed,
+					State: domain.StateInvested,
 				}, nil)
-				mockService.EXPECT().ApproveLoan("loan-123", "validator-123", "http://example.com/proof").Return(errors.New("service error"))
+				mockService.EXPECT().DisburseLoan("loan-123", "officer-123", "signed-1", domain.IfMatch(nil)).Return(int64(0), errors.New("service error"))
 			},
 			expectedStatus: http.StatusBadRequest,
-			expectedMsg:    "Failed to approve loan",
+			expectedMsg:    "Failed to disburse loan",
+		},
+		{
+			name:   "Signed Agreement Not Verified",
+			loanID: "loan-123",
+			requestBody: map[string]interface{}{
+				"field_officer_id":             "officer-123",
+				"signed_agreement_document_id": "signed-1",
+			},
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{
+					ID:    "loan-123",
+					State: domain.StateInvested,
+				}, nil)
+				mockService.EXPECT().DisburseLoan("loan-123", "officer-123", "signed-1", domain.IfMatch(nil)).
+					Return(int64(0), &domain.AgreementVerificationError{LoanID: "loan-123", Problems: []string{"the BORROWER signature is missing"}})
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Failed to disburse loan",
 		},
 	}
 
@@ -272,12 +1640,12 @@ func TestApproveLoan(t *testing.T) {
 			rec := httptest.NewRecorder()
 			e := echo.New()
 			c := e.NewContext(req, rec)
-			c.SetPath("/loans/:id/approve")
+			c.SetPath("/loans/:id/disburse")
 			c.SetParamNames("id")
 			c.SetParamValues(tc.loanID)
 
 			// Execute
-			err := handler.ApproveLoan(c)
+			err := handler.DisburseLoan(c)
 
 			// Assert
 			assert.NoError(t, err)
@@ -291,7 +1659,7 @@ func TestApproveLoan(t *testing.T) {
 	}
 }
 
-func TestAddInvestment(t *testing.T) {
+func TestRecordRepayment(t *testing.T) {
 	// Define test cases
 	testCases := []struct {
 		name           string
@@ -305,39 +1673,46 @@ func TestAddInvestment(t *testing.T) {
 			name:   "Success",
 			loanID: "loan-123",
 			requestBody: map[string]interface{}{
-				"investor_id": "investor-123",
-				"email":       "investor@example.com",
-				"amount":      500.0,
+				"amount": "412000",
 			},
 			mockSetup: func(mockService *mock.MockService) {
 				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{
 					ID:    "loan-123",
-					State: domain.StateApproved,
+					State: domain.StateDisbursed,
 				}, nil)
-				mockService.EXPECT().AddInvestment("loan-123", "investor-123", "investor@example.com", 500.0).Return(nil)
+				mockService.EXPECT().RecordRepayment("loan-123", money.MustNew("412000", money.DefaultCurrency), domain.IfMatch(nil)).Return(&domain.Repayment{
+					ID:     "repayment-1",
+					Amount: money.MustNew("412000", money.DefaultCurrency),
+				}, int64(2), nil)
 			},
-			expectedStatus: http.StatusOK,
-			expectedMsg:    "OK",
+			expectedStatus: http.StatusCreated,
+			expectedMsg:    "Repayment recorded successfully",
 		},
 		{
-			name:   "Invalid Request - Missing Required Field",
+			name:   "Success - Defaulted Loan",
 			loanID: "loan-123",
 			requestBody: map[string]interface{}{
-				"investor_id": "investor-123",
-				"email":       "investor@example.com",
-				// Missing amount
+				"amount":   "10.50",
+				"currency": "USD",
 			},
-			mockSetup:      func(mockService *mock.MockService) {},
-			expectedStatus: http.StatusBadRequest,
-			expectedMsg:    "Validation error",
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{
+					ID:    "loan-123",
+					State: domain.StateDefaulted,
+				}, nil)
+				mockService.EXPECT().RecordRepayment("loan-123", money.MustNew("10.50", "USD"), domain.IfMatch(nil)).Return(&domain.Repayment{
+					ID:     "repayment-1",
+					Amount: money.MustNew("10.50", "USD"),
+				}, int64(2), nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedMsg:    "Repayment recorded successfully",
 		},
 		{
-			name:   "Invalid Email Format",
+			name:   "Invalid Request - Missing Amount",
 			loanID: "loan-123",
 			requestBody: map[string]interface{}{
-				"investor_id": "investor-123",
-				"email":       "invalid-email",
-				"amount":      500.0,
+				"currency": "IDR",
 			},
 			mockSetup:      func(mockService *mock.MockService) {},
 			expectedStatus: http.StatusBadRequest,
@@ -347,36 +1722,50 @@ func TestAddInvestment(t *testing.T) {
 			name:   "Invalid State",
 			loanID: "loan-123",
 			requestBody: map[string]interface{}{
-				"investor_id": "investor-123",
-				"email":       "investor@example.com",
-				"amount":      500.0,
+				"amount": "1000",
 			},
 			mockSetup: func(mockService *mock.MockService) {
 				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{
 					ID:    "loan-123",
-					State: domain.StateProposed, // Not approved yet
+					State: domain.StateRepaid,
 				}, nil)
 			},
 			expectedStatus: http.StatusBadRequest,
 			expectedMsg:    "State validation error",
 		},
 		{
-			name:   "Service Error",
+			name:   "Overpayment",
 			loanID: "loan-123",
 			requestBody: map[string]interface{}{
-				"investor_id": "investor-123",
-				"email":       "investor@example.com",
-				"amount":      500.0,
+				"amount": "99999999",
 			},
 			mockSetup: func(mockService *mock.MockService) {
 				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{
 					ID:    "loan-123",
-					State: domain.StateApproved,
+					State: domain.StateDisbursed,
 				}, nil)
-				mockService.EXPECT().AddInvestment("loan-123", "investor-123", "investor@example.com", 500.0).Return(errors.New("service error"))
+				mockService.EXPECT().RecordRepayment("loan-123", money.MustNew("99999999", money.DefaultCurrency), domain.IfMatch(nil)).
+					Return(nil, int64(0), fmt.Errorf("failed to allocate repayment: %w", domain.ErrInvalidRepayment))
 			},
 			expectedStatus: http.StatusBadRequest,
-			expectedMsg:    "Failed to add investment",
+			expectedMsg:    "Failed to record repayment",
+		},
+		{
+			name:   "Version Conflict",
+			loanID: "loan-123",
+			requestBody: map[string]interface{}{
+				"amount": "1000",
+			},
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{
+					ID:    "loan-123",
+					State: domain.StateDisbursed,
+				}, nil)
+				mockService.EXPECT().RecordRepayment("loan-123", money.MustNew("1000", money.DefaultCurrency), domain.IfMatch(nil)).
+					Return(nil, int64(0), &domain.VersionConflictError{LoanID: "loan-123", Expected: 1, Actual: 2})
+			},
+			expectedStatus: http.StatusConflict,
+			expectedMsg:    "Failed to record repayment",
 		},
 	}
 
@@ -399,12 +1788,12 @@ func TestAddInvestment(t *testing.T) {
 			rec := httptest.NewRecorder()
 			e := echo.New()
 			c := e.NewContext(req, rec)
-			c.SetPath("/loans/:id/invest")
+			c.SetPath("/loans/:id/repayments")
 			c.SetParamNames("id")
 			c.SetParamValues(tc.loanID)
 
 			// Execute
-			err := handler.AddInvestment(c)
+			err := handler.RecordRepayment(c)
 
 			// Assert
 			assert.NoError(t, err)
@@ -418,12 +1807,20 @@ func TestAddInvestment(t *testing.T) {
 	}
 }
 
-func TestDisburseLoan(t *testing.T) {
+func TestGenerateAgreementLetter(t *testing.T) {
+	document := &domain.Document{
+		ID:          "document-1",
+		LoanID:      "loan-123",
+		Kind:        domain.DocumentAgreement,
+		ContentType: domain.ContentTypePDF,
+		URL:         "http://example.com/documents/document-1",
+	}
+
 	// Define test cases
 	testCases := []struct {
 		name           string
 		loanID         string
-		requestBody    map[string]interface{}
+		ifMatch        string
 		mockSetup      func(*mock.MockService)
 		expectedStatus int
 		expectedMsg    string
@@ -431,63 +1828,59 @@ func TestDisburseLoan(t *testing.T) {
 		{
 			name:   "Success",
 			loanID: "loan-123",
-			requestBody: map[string]interface{}{
-				"field_officer_id": "officer-123",
-				"signed_agreement": "http://example.com/signed",
-			},
 			mockSetup: func(mockService *mock.MockService) {
-				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{
-					ID:    "loan-123",
-					State: domain.StateInvested,
-				}, nil)
-				mockService.EXPECT().DisburseLoan("loan-123", "officer-123", "http://example.com/signed").Return(nil)
+				mockService.EXPECT().GenerateAgreementLetter("loan-123", domain.IfMatch(nil)).Return(document, int64(2), nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedMsg:    "OK",
 		},
 		{
-			name:   "Invalid Request - Missing Required Field",
+			name:   "Loan Not Found",
 			loanID: "loan-123",
-			requestBody: map[string]interface{}{
-				"field_officer_id": "officer-123",
-				// Missing signed_agreement
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GenerateAgreementLetter("loan-123", domain.IfMatch(nil)).Return(nil, int64(0), fmt.Errorf("failed to find loan: %w", domain.ErrLoanNotFound))
 			},
-			mockSetup:      func(mockService *mock.MockService) {},
-			expectedStatus: http.StatusBadRequest,
-			expectedMsg:    "Validation error",
+			expectedStatus: http.StatusNotFound,
+			expectedMsg:    "Loan not found",
 		},
 		{
-			name:   "Invalid State",
+			name:   "Service Error",
 			loanID: "loan-123",
-			requestBody: map[string]interface{}{
-				"field_officer_id": "officer-123",
-				"signed_agreement": "http://example.com/signed",
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GenerateAgreementLetter("loan-123", domain.IfMatch(nil)).Return(nil, int64(0), errors.New("service error"))
 			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedMsg:    "Failed to generate agreement letter",
+		},
+		{
+			name:   "Loan Not Approved",
+			loanID: "loan-123",
 			mockSetup: func(mockService *mock.MockService) {
-				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{
-					ID:    "loan-123",
-					State: domain.StateApproved, // Not invested yet
-				}, nil)
+				mockService.EXPECT().GenerateAgreementLetter("loan-123", domain.IfMatch(nil)).Return(nil, int64(0), fmt.Errorf("%w: loan loan-123 is PROPOSED", domain.ErrInvalidTransition))
 			},
 			expectedStatus: http.StatusBadRequest,
-			expectedMsg:    "State validation error",
+			expectedMsg:    "Failed to generate agreement letter",
 		},
 		{
-			name:   "Service Error",
-			loanID: "loan-123",
-			requestBody: map[string]interface{}{
-				"field_officer_id": "officer-123",
-				"signed_agreement": "http://example.com/signed",
+			name:    "Matching If-Match",
+			loanID:  "loan-123",
+			ifMatch: `"4"`,
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().GenerateAgreementLetter("loan-123", domain.IfMatch{4}).Return(document, int64(5), nil)
 			},
+			expectedStatus: http.StatusOK,
+			expectedMsg:    "OK",
+		},
+		{
+			name:    "Stale If-Match",
+			loanID:  "loan-123",
+			ifMatch: `"3"`,
 			mockSetup: func(mockService *mock.MockService) {
-				mockService.EXPECT().GetLoan("loan-123").Return(&domain.Loan{
-					ID:    "loan-123",
-					State: domain.StateInvested,
-				}, nil)
-				mockService.EXPECT().DisburseLoan("loan-123", "officer-123", "http://example.com/signed").Return(errors.New("service error"))
+				mockService.EXPECT().GenerateAgreementLetter("loan-123", domain.IfMatch{3}).
+					Return(nil, int64(0), &domain.PreconditionError{LoanID: "loan-123", Expected: domain.IfMatch{3}, Actual: 4})
 			},
-			expectedStatus: http.StatusBadRequest,
-			expectedMsg:    "Failed to disburse loan",
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedMsg:    "Failed to generate agreement letter",
 		},
 	}
 
@@ -504,18 +1897,19 @@ func TestDisburseLoan(t *testing.T) {
 			handler := NewHandler(mockService)
 
 			// Create request
-			reqBody, _ := json.Marshal(tc.requestBody)
-			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(reqBody))
-			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
 			rec := httptest.NewRecorder()
 			e := echo.New()
 			c := e.NewContext(req, rec)
-			c.SetPath("/loans/:id/disburse")
+			c.SetPath("/loans/:id/agreement")
 			c.SetParamNames("id")
 			c.SetParamValues(tc.loanID)
 
 			// Execute
-			err := handler.DisburseLoan(c)
+			err := handler.GenerateAgreementLetter(c)
 
 			// Assert
 			assert.NoError(t, err)
@@ -525,53 +1919,113 @@ func TestDisburseLoan(t *testing.T) {
 			var response map[string]interface{}
 			json.Unmarshal(rec.Body.Bytes(), &response)
 			assert.Equal(t, tc.expectedMsg, response["message"])
+			if tc.expectedStatus == http.StatusOK {
+				data := response["data"].(map[string]interface{})
+				assert.Equal(t, "http://example.com/documents/document-1", data["url"])
+			}
 		})
 	}
 }
 
-func TestGenerateAgreementLetter(t *testing.T) {
+func TestUploadDocument(t *testing.T) {
+	content := []byte("\xff\xd8\xff\xe0 visit photo")
+	document := &domain.Document{
+		ID:          "document-1",
+		LoanID:      "loan-123",
+		Kind:        domain.DocumentProof,
+		Name:        "visit.jpg",
+		ContentType: domain.ContentTypeJPEG,
+		Checksum:    domain.Checksum(content),
+	}
+
 	// Define test cases
 	testCases := []struct {
 		name           string
-		loanID         string
-		requestBody    map[string]interface{}
+		kind           string
+		withFile       bool
+		maxSize        int64
 		mockSetup      func(*mock.MockService)
 		expectedStatus int
 		expectedMsg    string
 	}{
 		{
-			name:   "Success",
-			loanID: "loan-123",
-			requestBody: map[string]interface{}{
-				"letter_url": "http://example.com/letter",
-			},
+			name:     "Success",
+			kind:     "PROOF",
+			withFile: true,
 			mockSetup: func(mockService *mock.MockService) {
-				mockService.EXPECT().GenerateAgreementLetter("loan-123", "http://example.com/letter").Return(nil)
+				mockService.EXPECT().UploadDocument("loan-123", domain.DocumentUpload{
+					Kind:    domain.DocumentProof,
+					Name:    "visit.jpg",
+					Content: content,
+				}).Return(document, nil)
 			},
-			expectedStatus: http.StatusOK,
-			expectedMsg:    "OK",
+			expectedStatus: http.StatusCreated,
+			expectedMsg:    "Document uploaded successfully",
 		},
 		{
-			name:        "Invalid Request - Missing Required Field",
-			loanID:      "loan-123",
-			requestBody: map[string]interface{}{
-				// Missing letter_url
-			},
+			name:           "Invalid Kind",
+			kind:           "AGREEMENT",
+			withFile:       true,
 			mockSetup:      func(mockService *mock.MockService) {},
 			expectedStatus: http.StatusBadRequest,
 			expectedMsg:    "Validation error",
 		},
 		{
-			name:   "Service Error",
-			loanID: "loan-123",
-			requestBody: map[string]interface{}{
-				"letter_url": "http://example.com/letter",
+			name:           "Missing File",
+			kind:           "PROOF",
+			mockSetup:      func(mockService *mock.MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Invalid request body",
+		},
+		{
+			name:     "Loan Not Found",
+			kind:     "PROOF",
+			withFile: true,
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().UploadDocument("loan-123", gomock.Any()).Return(nil, fmt.Errorf("failed to find loan: %w", domain.ErrLoanNotFound))
 			},
+			expectedStatus: http.StatusNotFound,
+			expectedMsg:    "Loan not found",
+		},
+		{
+			name:     "Unsupported Type",
+			kind:     "SIGNED_AGREEMENT",
+			withFile: true,
 			mockSetup: func(mockService *mock.MockService) {
-				mockService.EXPECT().GenerateAgreementLetter("loan-123", "http://example.com/letter").Return(errors.New("service error"))
+				mockService.EXPECT().UploadDocument("loan-123", gomock.Any()).Return(nil, domain.ErrUnsupportedDocumentType)
 			},
-			expectedStatus: http.StatusBadRequest,
-			expectedMsg:    "Failed to generate agreement letter",
+			expectedStatus: http.StatusUnsupportedMediaType,
+			expectedMsg:    "Failed to upload document",
+		},
+		{
+			name:     "Too Large",
+			kind:     "PROOF",
+			withFile: true,
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().UploadDocument("loan-123", gomock.Any()).Return(nil, domain.ErrDocumentTooLarge)
+			},
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedMsg:    "Failed to upload document",
+		},
+		{
+			name:     "At The Handler Limit",
+			kind:     "PROOF",
+			withFile: true,
+			maxSize:  int64(len(content)),
+			mockSetup: func(mockService *mock.MockService) {
+				mockService.EXPECT().UploadDocument("loan-123", gomock.Any()).Return(document, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedMsg:    "Document uploaded successfully",
+		},
+		{
+			name:           "Over The Handler Limit",
+			kind:           "PROOF",
+			withFile:       true,
+			maxSize:        int64(len(content)) - 1,
+			mockSetup:      func(mockService *mock.MockService) {},
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedMsg:    "Document too large",
 		},
 	}
 
@@ -585,21 +2039,32 @@ func TestGenerateAgreementLetter(t *testing.T) {
 			mockService := mock.NewMockService(ctrl)
 			tc.mockSetup(mockService)
 
-			handler := NewHandler(mockService)
+			var opts []Option
+			if tc.maxSize > 0 {
+				opts = append(opts, WithMaxDocumentSize(tc.maxSize))
+			}
+			handler := NewHandler(mockService, opts...)
 
 			// Create request
-			reqBody, _ := json.Marshal(tc.requestBody)
-			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(reqBody))
-			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			body := &bytes.Buffer{}
+			form := multipart.NewWriter(body)
+			_ = form.WriteField("kind", tc.kind)
+			if tc.withFile {
+				part, _ := form.CreateFormFile("file", "visit.jpg")
+				_, _ = part.Write(content)
+			}
+			_ = form.Close()
+			req := httptest.NewRequest(http.MethodPost, "/", body)
+			req.Header.Set(echo.HeaderContentType, form.FormDataContentType())
 			rec := httptest.NewRecorder()
 			e := echo.New()
 			c := e.NewContext(req, rec)
-			c.SetPath("/loans/:id/agreement")
+			c.SetPath("/loans/:id/documents")
 			c.SetParamNames("id")
-			c.SetParamValues(tc.loanID)
+			c.SetParamValues("loan-123")
 
 			// Execute
-			err := handler.GenerateAgreementLetter(c)
+			err := handler.UploadDocument(c)
 
 			// Assert
 			assert.NoError(t, err)
@@ -609,10 +2074,52 @@ func TestGenerateAgreementLetter(t *testing.T) {
 			var response map[string]interface{}
 			json.Unmarshal(rec.Body.Bytes(), &response)
 			assert.Equal(t, tc.expectedMsg, response["message"])
+			if tc.expectedStatus == http.StatusCreated {
+				data := response["data"].(map[string]interface{})
+				assert.Equal(t, domain.Checksum(content), data["checksum"])
+			}
 		})
 	}
 }
 
+func TestUploadDocument_BodyLimit(t *testing.T) {
+	content := bytes.Repeat([]byte{0xff}, multipartOverhead+32<<10)
+	upload := func(t *testing.T) *http.Request {
+		body := &bytes.Buffer{}
+		form := multipart.NewWriter(body)
+		_ = form.WriteField("kind", "PROOF")
+		part, _ := form.CreateFormFile("file", "visit.jpg")
+		_, _ = part.Write(content)
+		_ = form.Close()
+		req := httptest.NewRequest(http.MethodPost, "/loans/loan-123/documents", body)
+		req.Header.Set(echo.HeaderContentType, form.FormDataContentType())
+		return req
+	}
+
+	// The request body may exceed the document by the multipart overhead only, so the body is refused
+	// before the handler buffers the document
+	e := echo.New()
+	NewHandler(mock.NewMockService(gomock.NewController(t)), WithMaxDocumentSize(16<<10)).RegisterRoutes(e)
+
+	t.Run("Declared Length", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, upload(t))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	})
+
+	t.Run("Unknown Length", func(t *testing.T) {
+		req := upload(t)
+		req.ContentLength = -1
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+		var response map[string]interface{}
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		assert.Equal(t, "Document too large", response["message"])
+	})
+}
+
 func TestGetLoansByBorrower(t *testing.T) {
 	// Define test cases
 	testCases := []struct {
@@ -638,7 +2145,7 @@ func TestGetLoansByBorrower(t *testing.T) {
 						State:      domain.StateApproved,
 					},
 				}
-				mockService.EXPECT().GetLoansByBorrower("borrower-123").Return(loans, nil)
+				mockService.EXPECT().GetLoansByBorrower("borrower-123", domain.LoanFilter{}).Return(loans, nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedMsg:    "OK",
@@ -647,7 +2154,7 @@ func TestGetLoansByBorrower(t *testing.T) {
 			name:       "No Loans Found",
 			borrowerID: "borrower-456",
 			mockSetup: func(mockService *mock.MockService) {
-				mockService.EXPECT().GetLoansByBorrower("borrower-456").Return([]*domain.Loan{}, nil)
+				mockService.EXPECT().GetLoansByBorrower("borrower-456", domain.LoanFilter{}).Return([]*domain.Loan{}, nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedMsg:    "OK",
@@ -656,7 +2163,7 @@ func TestGetLoansByBorrower(t *testing.T) {
 			name:       "Service Error",
 			borrowerID: "borrower-123",
 			mockSetup: func(mockService *mock.MockService) {
-				mockService.EXPECT().GetLoansByBorrower("borrower-123").Return(nil, errors.New("service error"))
+				mockService.EXPECT().GetLoansByBorrower("borrower-123", domain.LoanFilter{}).Return(nil, errors.New("service error"))
 			},
 			expectedStatus: http.StatusInternalServerError,
 			expectedMsg:    "Failed to retrieve loans",
@@ -722,7 +2229,7 @@ func TestGetLoansByState(t *testing.T) {
 						State: domain.StateApproved,
 					},
 				}
-				mockService.EXPECT().GetLoansByState(domain.StateApproved).Return(loans, nil)
+				mockService.EXPECT().GetLoansByState(domain.StateApproved, domain.LoanFilter{}).Return(loans, nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedMsg:    "OK",
@@ -731,7 +2238,7 @@ func TestGetLoansByState(t *testing.T) {
 			name:  "No Loans Found",
 			state: "DISBURSED",
 			mockSetup: func(mockService *mock.MockService) {
-				mockService.EXPECT().GetLoansByState(domain.StateDisbursed).Return([]*domain.Loan{}, nil)
+				mockService.EXPECT().GetLoansByState(domain.StateDisbursed, domain.LoanFilter{}).Return([]*domain.Loan{}, nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedMsg:    "OK",
@@ -747,7 +2254,7 @@ func TestGetLoansByState(t *testing.T) {
 			name:  "Service Error",
 			state: "PROPOSED",
 			mockSetup: func(mockService *mock.MockService) {
-				mockService.EXPECT().GetLoansByState(domain.StateProposed).Return(nil, errors.New("service error"))
+				mockService.EXPECT().GetLoansByState(domain.StateProposed, domain.LoanFilter{}).Return(nil, errors.New("service error"))
 			},
 			expectedStatus: http.StatusInternalServerError,
 			expectedMsg:    "Failed to retrieve loans",
@@ -796,6 +2303,7 @@ func TestGetLoans(t *testing.T) {
 		name           string
 		page           string
 		limit          string
+		currency       string
 		mockSetup      func(*mock.MockService)
 		expectedStatus int
 		expectedMsg    string
@@ -815,7 +2323,7 @@ func TestGetLoans(t *testing.T) {
 						State: domain.StateApproved,
 					},
 				}
-				mockService.EXPECT().GetLoans(1, 10).Return(loans, nil)
+				mockService.EXPECT().GetLoans(domain.LoanFilter{}, 1, 10).Return(loans, nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedMsg:    "OK",
@@ -835,7 +2343,7 @@ func TestGetLoans(t *testing.T) {
 						State: domain.StateApproved,
 					},
 				}
-				mockService.EXPECT().GetLoans(1, 10).Return(loans, nil)
+				mockService.EXPECT().GetLoans(domain.LoanFilter{}, 1, 10).Return(loans, nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedMsg:    "OK",
@@ -855,7 +2363,7 @@ func TestGetLoans(t *testing.T) {
 						State: domain.StateApproved,
 					},
 				}
-				mockService.EXPECT().GetLoans(1, 10).Return(loans, nil)
+				mockService.EXPECT().GetLoans(domain.LoanFilter{}, 1, 10).Return(loans, nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedMsg:    "OK",
@@ -875,17 +2383,44 @@ func TestGetLoans(t *testing.T) {
 						State: domain.StateApproved,
 					},
 				}
-				mockService.EXPECT().GetLoans(1, 10).Return(loans, nil)
+				mockService.EXPECT().GetLoans(domain.LoanFilter{}, 1, 10).Return(loans, nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedMsg:    "OK",
 		},
+		{
+			name:     "Currency Filter",
+			page:     "1",
+			limit:    "10",
+			currency: "USD",
+			mockSetup: func(mockService *mock.MockService) {
+				loans := []*domain.Loan{
+					{
+						ID:              "loan-1",
+						State:           domain.StateProposed,
+						PrincipalAmount: money.MustNew("100", "USD"),
+					},
+				}
+				mockService.EXPECT().GetLoans(domain.LoanFilter{Currency: "USD"}, 1, 10).Return(loans, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedMsg:    "OK",
+		},
+		{
+			name:           "Unsupported Currency",
+			page:           "1",
+			limit:          "10",
+			currency:       "XYZ",
+			mockSetup:      func(mockService *mock.MockService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Validation error",
+		},
 		{
 			name:  "Service Error",
 			page:  "1",
 			limit: "10",
 			mockSetup: func(mockService *mock.MockService) {
-				mockService.EXPECT().GetLoans(1, 10).Return(nil, errors.New("service error"))
+				mockService.EXPECT().GetLoans(domain.LoanFilter{}, 1, 10).Return(nil, errors.New("service error"))
 			},
 			expectedStatus: http.StatusInternalServerError,
 			expectedMsg:    "Failed to retrieve loans",
@@ -918,6 +2453,9 @@ func TestGetLoans(t *testing.T) {
 			if tc.limit != "" {
 				q.Add("limit", tc.limit)
 			}
+			if tc.currency != "" {
+				q.Add("currency", tc.currency)
+			}
 			req.URL.RawQuery = q.Encode()
 
 			// Execute
@@ -0,0 +1,142 @@
+package notification
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	"github.com/hinha/los-technical/internal/domain/response"
+	"github.com/labstack/echo/v4"
+)
+
+// Handler handles HTTP requests for notification preferences and the in-app inbox
+type Handler struct {
+	service   domain.NotificationService
+	validator *validator.Validate
+}
+
+// NewHandler creates a new notification handler
+func NewHandler(service domain.NotificationService) *Handler {
+	return &Handler{
+		service:   service,
+		validator: validator.New(),
+	}
+}
+
+// RegisterRoutes registers the notification routes
+func (h *Handler) RegisterRoutes(e *echo.Echo) {
+	e.GET("/recipients/:id/preferences", h.GetPreferences)
+	e.PUT("/recipients/:id/preferences", h.SetPreferences)
+	e.GET("/recipients/:id/notifications", h.GetInbox)
+	e.POST("/recipients/:id/notifications/:notificationId/read", h.MarkRead)
+}
+
+// PreferencesRequest represents the request body for setting the notification preferences of a recipient
+type PreferencesRequest struct {
+	Email    string   `json:"email" validate:"omitempty,email" example:"budi@example.com"`
+	Phone    string   `json:"phone" validate:"omitempty,e164" example:"+628123456789"`
+	Locale   string   `json:"locale" validate:"omitempty,bcp47_language_tag" example:"id"`
+	Channels []string `json:"channels" validate:"omitempty,dive,oneof=EMAIL SMS IN_APP" example:"EMAIL,IN_APP"`
+	Muted    []string `json:"muted" example:"REPAYMENT_DISTRIBUTED"`
+}
+
+// GetPreferences handles retrieving the notification preferences of a recipient
+// @Summary Get notification preferences
+// @Description Retrieves how a borrower or investor is notified, the defaults when they have not set any
+// @Tags notifications
+// @Produce json
+// @Param id path string true "Borrower or investor ID"
+// @Success 200 {object} response.Response "Notification preferences"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /recipients/{id}/preferences [get]
+func (h *Handler) GetPreferences(c echo.Context) error {
+	preferences, err := h.service.GetPreferences(c.Param("id"))
+	if err != nil {
+		return response.DefaultResponse(c, "Failed to retrieve preferences", nil, err.Error(), http.StatusInternalServerError)
+	}
+	return response.DefaultResponse(c, "OK", preferences, nil, http.StatusOK)
+}
+
+// SetPreferences handles replacing the notification preferences of a recipient
+// @Summary Set notification preferences
+// @Description Replaces the channels, contact details and muted notification kinds of a borrower or investor. Agreements and refunds are sent even when muted.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param id path string true "Borrower or investor ID"
+// @Param request body PreferencesRequest true "Notification preferences"
+// @Success 200 {object} response.Response "Preferences saved"
+// @Failure 400 {object} response.Response "Invalid request or validation error"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /recipients/{id}/preferences [put]
+func (h *Handler) SetPreferences(c echo.Context) error {
+	var req PreferencesRequest
+	if err := c.Bind(&req); err != nil {
+		return response.DefaultResponse(c, "Invalid request body", nil, nil, http.StatusBadRequest)
+	}
+	if err := h.validator.Struct(req); err != nil {
+		return response.DefaultResponse(c, "Validation error", nil, err.Error(), http.StatusBadRequest)
+	}
+
+	preferences := domain.NotificationPreferences{
+		RecipientID: c.Param("id"),
+		Email:       req.Email,
+		Phone:       req.Phone,
+		Locale:      req.Locale,
+	}
+	for _, channel := range req.Channels {
+		preferences.Channels = append(preferences.Channels, domain.Channel(channel))
+	}
+	for _, kind := range req.Muted {
+		preferences.Muted = append(preferences.Muted, domain.NotificationKind(kind))
+	}
+
+	if err := h.service.SetPreferences(preferences); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, domain.ErrInvalidPreferences) {
+			status = http.StatusBadRequest
+		}
+		return response.DefaultResponse(c, "Failed to save preferences", nil, err.Error(), status)
+	}
+	return response.DefaultResponse(c, "OK", preferences, nil, http.StatusOK)
+}
+
+// GetInbox handles listing the in-app notifications of a recipient
+// @Summary Get in-app notifications
+// @Description Lists the notifications delivered to the in-app inbox of a borrower or investor, newest first
+// @Tags notifications
+// @Produce json
+// @Param id path string true "Borrower or investor ID"
+// @Success 200 {object} response.Response "In-app notifications"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /recipients/{id}/notifications [get]
+func (h *Handler) GetInbox(c echo.Context) error {
+	items, err := h.service.GetInbox(c.Param("id"))
+	if err != nil {
+		return response.DefaultResponse(c, "Failed to retrieve notifications", nil, err.Error(), http.StatusInternalServerError)
+	}
+	return response.DefaultResponse(c, "OK", items, nil, http.StatusOK)
+}
+
+// MarkRead handles marking an in-app notification as read
+// @Summary Mark notification as read
+// @Description Records that a borrower or investor read one of their in-app notifications
+// @Tags notifications
+// @Produce json
+// @Param id path string true "Borrower or investor ID"
+// @Param notificationId path string true "Notification ID"
+// @Success 200 {object} response.Response "Notification marked as read"
+// @Failure 404 {object} response.Response "Notification not found"
+// @Failure 500 {object} response.Response "Internal server error"
+// @Router /recipients/{id}/notifications/{notificationId}/read [post]
+func (h *Handler) MarkRead(c echo.Context) error {
+	err := h.service.MarkRead(c.Param("id"), c.Param("notificationId"))
+	if errors.Is(err, domain.ErrInboxItemNotFound) {
+		return response.DefaultResponse(c, "Notification not found", nil, err.Error(), http.StatusNotFound)
+	}
+	if err != nil {
+		return response.DefaultResponse(c, "Failed to mark notification as read", nil, err.Error(), http.StatusInternalServerError)
+	}
+	return response.DefaultResponse(c, "OK", nil, nil, http.StatusOK)
+}
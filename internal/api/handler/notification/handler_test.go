@@ -0,0 +1,182 @@
+package notification
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	mock "github.com/hinha/los-technical/internal/domain/loan/mock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// call runs the handler on a request about notification-1 of recipient-1 and returns the recorded response and its message
+func call(t *testing.T, method, body string, handle func(echo.Context) error) (*httptest.ResponseRecorder, string) {
+	t.Helper()
+	req := httptest.NewRequest(method, "/", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.SetParamNames("id", "notificationId")
+	c.SetParamValues("recipient-1", "notification-1")
+
+	assert.NoError(t, handle(c))
+
+	var response map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &response)
+	message, _ := response["message"].(string)
+	return rec, message
+}
+
+func TestGetPreferences(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mock.NewMockNotificationService(ctrl)
+	defaults := domain.DefaultPreferences("recipient-1")
+	mockService.EXPECT().GetPreferences("recipient-1").Return(&defaults, nil)
+
+	rec, message := call(t, http.MethodGet, "", NewHandler(mockService).GetPreferences)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "OK", message)
+	assert.Contains(t, rec.Body.String(), `"channels":["EMAIL"]`)
+}
+
+func TestSetPreferences(t *testing.T) {
+	testCases := []struct {
+		name           string
+		body           string
+		mockSetup      func(*mock.MockNotificationService)
+		expectedStatus int
+		expectedMsg    string
+	}{
+		{
+			name: "Success",
+			body: `{"phone":"+628123456789","locale":"id","channels":["SMS","IN_APP"],"muted":["REPAYMENT_DISTRIBUTED"]}`,
+			mockSetup: func(service *mock.MockNotificationService) {
+				service.EXPECT().SetPreferences(domain.NotificationPreferences{
+					RecipientID: "recipient-1",
+					Phone:       "+628123456789",
+					Locale:      "id",
+					Channels:    []domain.Channel{domain.ChannelSMS, domain.ChannelInApp},
+					Muted:       []domain.NotificationKind{domain.NotificationRepaymentDistributed},
+				}).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedMsg:    "OK",
+		},
+		{
+			name:           "Unknown Channel",
+			body:           `{"channels":["PIGEON"]}`,
+			mockSetup:      func(service *mock.MockNotificationService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Validation error",
+		},
+		{
+			name:           "Invalid Email",
+			body:           `{"email":"not-an-email"}`,
+			mockSetup:      func(service *mock.MockNotificationService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Validation error",
+		},
+		{
+			name:           "Invalid Body",
+			body:           `{"channels":`,
+			mockSetup:      func(service *mock.MockNotificationService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Invalid request body",
+		},
+		{
+			name: "Invalid Preferences",
+			body: `{"channels":["SMS"]}`,
+			mockSetup: func(service *mock.MockNotificationService) {
+				service.EXPECT().SetPreferences(gomock.Any()).Return(fmt.Errorf("%w: SMS needs a phone number", domain.ErrInvalidPreferences))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedMsg:    "Failed to save preferences",
+		},
+		{
+			name: "Store Error",
+			body: `{}`,
+			mockSetup: func(service *mock.MockNotificationService) {
+				service.EXPECT().SetPreferences(gomock.Any()).Return(errors.New("database error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedMsg:    "Failed to save preferences",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := mock.NewMockNotificationService(ctrl)
+			tc.mockSetup(mockService)
+
+			rec, message := call(t, http.MethodPut, tc.body, NewHandler(mockService).SetPreferences)
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.Equal(t, tc.expectedMsg, message)
+		})
+	}
+}
+
+func TestGetInbox(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mock.NewMockNotificationService(ctrl)
+	mockService.EXPECT().GetInbox("recipient-1").Return([]domain.InboxItem{{ID: "notification-1", RecipientID: "recipient-1", Subject: "Your loan is approved"}}, nil)
+
+	rec, message := call(t, http.MethodGet, "", NewHandler(mockService).GetInbox)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "OK", message)
+	assert.Contains(t, rec.Body.String(), "Your loan is approved")
+}
+
+func TestMarkRead(t *testing.T) {
+	testCases := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedMsg    string
+	}{
+		{
+			name:           "Success",
+			expectedStatus: http.StatusOK,
+			expectedMsg:    "OK",
+		},
+		{
+			name:           "Not Found",
+			err:            fmt.Errorf("%w: notification-1", domain.ErrInboxItemNotFound),
+			expectedStatus: http.StatusNotFound,
+			expectedMsg:    "Notification not found",
+		},
+		{
+			name:           "Inbox Error",
+			err:            errors.New("database error"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedMsg:    "Failed to mark notification as read",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := mock.NewMockNotificationService(ctrl)
+			mockService.EXPECT().MarkRead("recipient-1", "notification-1").Return(tc.err)
+
+			rec, message := call(t, http.MethodPost, "", NewHandler(mockService).MarkRead)
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.Equal(t, tc.expectedMsg, message)
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package loan
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Actions that are audited but are not transitions of the state machine
+const (
+	ActionCreate            Action = "CREATE"
+	ActionAttachAgreement   Action = "ATTACH_AGREEMENT"
+	ActionRemindInstallment Action = "REMIND_INSTALLMENT"
+	ActionChargeLateFee     Action = "CHARGE_LATE_FEE"
+)
+
+// SystemActor is recorded for mutations that are not requested by a person, e.g. the default check
+const SystemActor = "system"
+
+// AuditEntry records one mutation of a loan: who did it, what it did and the state before and after
+type AuditEntry struct {
+	ID        string    `json:"id"`
+	LoanID    string    `json:"loan_id"`
+	Actor     string    `json:"actor"`
+	Action    Action    `json:"action"`
+	FromState LoanState `json:"from_state,omitempty"`
+	ToState   LoanState `json:"to_state"`
+	// PayloadDigest is the hex SHA-256 of the JSON encoded request payload
+	PayloadDigest string    `json:"payload_digest"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// PayloadDigest returns the hex SHA-256 of the JSON encoding of payload.
+// Maps are encoded with sorted keys, so the same payload always gives the same digest.
+func PayloadDigest(payload any) (string, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode audit payload: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,29 @@
+package loan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hinha/los-technical/internal/pkg/money"
+)
+
+func TestPayloadDigest(t *testing.T) {
+	payload := map[string]any{"investor_id": "investor-1", "amount": money.MustNew("500", "IDR")}
+
+	digest, err := PayloadDigest(payload)
+	assert.NoError(t, err)
+	assert.Len(t, digest, 64)
+
+	// Map keys are encoded in sorted order, so building the payload differently gives the same digest
+	same, err := PayloadDigest(map[string]any{"amount": money.MustNew("500", "IDR"), "investor_id": "investor-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, digest, same)
+
+	other, err := PayloadDigest(map[string]any{"investor_id": "investor-1", "amount": money.MustNew("501", "IDR")})
+	assert.NoError(t, err)
+	assert.NotEqual(t, digest, other)
+
+	_, err = PayloadDigest(map[string]any{"callback": func() {}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,148 @@
+package loan
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/hinha/los-technical/internal/pkg/money"
+)
+
+// Borrower is a person registered to apply for loans, with what they earn and already owe each month
+type Borrower struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// NationalID is the number of the identity card of the borrower
+	NationalID string `json:"national_id"`
+	Email      string `json:"email"`
+	Phone      string `json:"phone,omitempty"`
+	Address    string `json:"address,omitempty"`
+	// MonthlyIncome is what the borrower earns each month
+	MonthlyIncome money.Money `json:"monthly_income"`
+	// MonthlyObligations is what the borrower pays each month on debts outside of this platform
+	MonthlyObligations money.Money `json:"monthly_obligations"`
+	// Blacklisted borrowers may not apply for new loans
+	Blacklisted     bool      `json:"blacklisted"`
+	BlacklistReason string    `json:"blacklist_reason,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// Validate checks the identity, the income and obligations and the blacklisting of the borrower
+func (b Borrower) Validate() error {
+	if b.ID == "" {
+		return fmt.Errorf("%w: borrower ID is required", ErrInvalidBorrower)
+	}
+	if strings.TrimSpace(b.Name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidBorrower)
+	}
+	if strings.TrimSpace(b.NationalID) == "" {
+		return fmt.Errorf("%w: national ID is required", ErrInvalidBorrower)
+	}
+	if b.Email == "" {
+		return fmt.Errorf("%w: email is required", ErrInvalidBorrower)
+	}
+	if err := validateMonthly("income", b.MonthlyIncome); err != nil {
+		return err
+	}
+	if err := validateMonthly("obligations", b.MonthlyObligations); err != nil {
+		return err
+	}
+	if b.MonthlyObligations.Currency != b.MonthlyIncome.Currency {
+		return fmt.Errorf("%w: monthly obligations in %s but income in %s", ErrInvalidBorrower, b.MonthlyObligations.Currency, b.MonthlyIncome.Currency)
+	}
+	if b.Blacklisted && strings.TrimSpace(b.BlacklistReason) == "" {
+		return fmt.Errorf("%w: a blacklisted borrower needs a reason", ErrInvalidBorrower)
+	}
+	return nil
+}
+
+func validateMonthly(name string, amount money.Money) error {
+	if err := amount.Validate(); err != nil {
+		return fmt.Errorf("%w: monthly %s: %w", ErrInvalidBorrower, name, err)
+	}
+	if amount.Amount.Sign() < 0 {
+		return fmt.Errorf("%w: monthly %s must not be negative", ErrInvalidBorrower, name)
+	}
+	return nil
+}
+
+// CheckEligibility returns why the borrower may not apply for a new loan
+func (b *Borrower) CheckEligibility() error {
+	if b.Blacklisted {
+		return fmt.Errorf("%w: borrower %s: %s", ErrBorrowerBlacklisted, b.ID, b.BlacklistReason)
+	}
+	return nil
+}
+
+// CreditProfile sums up what a borrower can afford given their income, obligations and loans
+type CreditProfile struct {
+	// MonthlyDebt is the monthly obligations plus the next installment of every loan being repaid
+	MonthlyDebt money.Money `json:"monthly_debt"`
+	// DebtToIncome is MonthlyDebt as a percentage of the monthly income, nil without income
+	DebtToIncome *money.Decimal `json:"debt_to_income,omitempty" swaggertype:"string" example:"35.5"`
+	// DisposableIncome is what is left of the monthly income once MonthlyDebt is paid, negative when the
+	// borrower owes more than they earn
+	DisposableIncome money.Money `json:"disposable_income"`
+	TotalLoans       int         `json:"total_loans"`
+	// ActiveLoans are the loans that are neither repaid nor closed before disbursement
+	ActiveLoans    int `json:"active_loans"`
+	RepaidLoans    int `json:"repaid_loans"`
+	DefaultedLoans int `json:"defaulted_loans"`
+}
+
+// NewCreditProfile computes the credit profile of the borrower from their loans. Installments in another
+// currency than the income are left out of the monthly debt, as they cannot be compared with it.
+func NewCreditProfile(borrower *Borrower, loans []*Loan) (*CreditProfile, error) {
+	currency := borrower.MonthlyIncome.Currency
+	profile := &CreditProfile{TotalLoans: len(loans)}
+	debts := []money.Money{borrower.MonthlyObligations}
+	for _, l := range loans {
+		switch l.State {
+		case StateRepaid:
+			profile.RepaidLoans++
+		case StateDefaulted:
+			profile.DefaultedLoans++
+		}
+		if !l.State.IsTerminal() {
+			profile.ActiveLoans++
+		}
+		if l.State != StateDisbursed && l.State != StateDefaulted {
+			continue
+		}
+		for _, installment := range l.Schedule {
+			if installment.IsSettled() {
+				continue
+			}
+			if installment.Total.Currency == currency {
+				debts = append(debts, installment.Total)
+			}
+			break
+		}
+	}
+
+	var err error
+	if profile.MonthlyDebt, err = money.Sum(currency, debts...); err != nil {
+		return nil, fmt.Errorf("failed to add up the monthly debt of borrower %s: %w", borrower.ID, err)
+	}
+	if profile.DisposableIncome, err = borrower.MonthlyIncome.Sub(profile.MonthlyDebt); err != nil {
+		return nil, err
+	}
+	if borrower.MonthlyIncome.IsPositive() {
+		ratio := new(big.Rat).Quo(profile.MonthlyDebt.Amount.Rat(), borrower.MonthlyIncome.Amount.Rat())
+		dti, err := money.NewFromRat(ratio.Mul(ratio, big.NewRat(100, 1)), 2)
+		if err != nil {
+			return nil, err
+		}
+		profile.DebtToIncome = &dti
+	}
+	return profile, nil
+}
+
+// BorrowerDetails is a borrower with their credit profile and every loan they have applied for
+type BorrowerDetails struct {
+	Borrower
+	CreditProfile CreditProfile `json:"credit_profile"`
+	Loans         []*Loan       `json:"loans"`
+}
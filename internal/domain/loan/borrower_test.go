@@ -0,0 +1,93 @@
+package loan
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hinha/los-technical/internal/pkg/money"
+)
+
+func TestBorrower_Validate(t *testing.T) {
+	valid := func() Borrower {
+		return Borrower{
+			ID:                 "borrower-1",
+			Name:               "Budi",
+			NationalID:         "3171234567890001",
+			Email:              "budi@example.com",
+			MonthlyIncome:      money.MustNew("10000000", money.DefaultCurrency),
+			MonthlyObligations: money.MustNew("1500000", money.DefaultCurrency),
+		}
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(*Borrower)
+		wantErr error
+	}{
+		{name: "Valid", modify: func(*Borrower) {}},
+		{name: "Without obligations", modify: func(b *Borrower) { b.MonthlyObligations = money.ZeroOf(money.DefaultCurrency) }},
+		{name: "Missing national ID", modify: func(b *Borrower) { b.NationalID = " " }, wantErr: ErrInvalidBorrower},
+		{name: "Missing email", modify: func(b *Borrower) { b.Email = "" }, wantErr: ErrInvalidBorrower},
+		{name: "Negative income", modify: func(b *Borrower) { b.MonthlyIncome = money.MustNew("-1", money.DefaultCurrency) }, wantErr: ErrInvalidBorrower},
+		{name: "Unsupported currency", modify: func(b *Borrower) { b.MonthlyIncome = money.MustNew("1", "XYZ") }, wantErr: ErrInvalidBorrower},
+		{name: "Obligations in another currency", modify: func(b *Borrower) { b.MonthlyObligations = money.MustNew("1", "USD") }, wantErr: ErrInvalidBorrower},
+		{name: "Blacklisted without reason", modify: func(b *Borrower) { b.Blacklisted = true }, wantErr: ErrInvalidBorrower},
+		{name: "Blacklisted with reason", modify: func(b *Borrower) { b.Blacklisted, b.BlacklistReason = true, "fraud" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			borrower := valid()
+			tt.modify(&borrower)
+			err := borrower.Validate()
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestNewCreditProfile(t *testing.T) {
+	idr := func(amount string) money.Money { return money.MustNew(amount, money.DefaultCurrency) }
+	paidAt := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	borrower := &Borrower{ID: "borrower-1", MonthlyIncome: idr("10000000"), MonthlyObligations: idr("1000000")}
+	loans := []*Loan{
+		{
+			ID:    "loan-1",
+			State: StateDisbursed,
+			Schedule: []Installment{
+				{Number: 1, Total: idr("900000"), PaidAt: &paidAt},
+				{Number: 2, Total: idr("880000")},
+				{Number: 3, Total: idr("860000")},
+			},
+		},
+		{ID: "loan-2", State: StateDefaulted, Schedule: []Installment{{Number: 1, Total: idr("620000")}}},
+		{ID: "loan-3", State: StateRepaid, Schedule: []Installment{{Number: 1, Total: idr("500000"), PaidAt: &paidAt}}},
+		{ID: "loan-4", State: StateProposed},
+		{ID: "loan-5", State: StateRejected},
+		{ID: "loan-6", State: StateDisbursed, Schedule: []Installment{{Number: 1, Total: money.MustNew("100", "USD")}}},
+	}
+
+	profile, err := NewCreditProfile(borrower, loans)
+	assert.NoError(t, err)
+	dti := money.MustParse("25")
+	assert.Equal(t, &CreditProfile{
+		MonthlyDebt:      idr("2500000"),
+		DebtToIncome:     &dti,
+		DisposableIncome: idr("7500000"),
+		TotalLoans:       6,
+		ActiveLoans:      4,
+		RepaidLoans:      1,
+		DefaultedLoans:   1,
+	}, profile)
+
+	t.Run("Without income", func(t *testing.T) {
+		profile, err := NewCreditProfile(&Borrower{ID: "borrower-2", MonthlyIncome: idr("0"), MonthlyObligations: idr("100")}, nil)
+		assert.NoError(t, err)
+		assert.Nil(t, profile.DebtToIncome)
+		assert.Equal(t, idr("-100"), profile.DisposableIncome)
+	})
+}
@@ -0,0 +1,45 @@
+package loan
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hinha/los-technical/internal/pkg/money"
+)
+
+// ReasonCode classifies why a loan was rejected or cancelled
+type ReasonCode string
+
+const (
+	ReasonIncompleteDocuments ReasonCode = "INCOMPLETE_DOCUMENTS"
+	ReasonCreditRisk          ReasonCode = "CREDIT_RISK"
+	ReasonFraudSuspected      ReasonCode = "FRAUD_SUSPECTED"
+	ReasonBorrowerWithdrawn   ReasonCode = "BORROWER_WITHDRAWN"
+	ReasonFundingExpired      ReasonCode = "FUNDING_EXPIRED"
+	ReasonOther               ReasonCode = "OTHER"
+)
+
+// Validate checks that the reason code is one of the known codes
+func (r ReasonCode) Validate() error {
+	switch r {
+	case ReasonIncompleteDocuments, ReasonCreditRisk, ReasonFraudSuspected,
+		ReasonBorrowerWithdrawn, ReasonFundingExpired, ReasonOther:
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidReasonCode, r)
+	}
+}
+
+// Closure records who ended a loan before disbursement and why
+type Closure struct {
+	ActorID    string     `json:"actor_id"`
+	ReasonCode ReasonCode `json:"reason_code"`
+	Date       time.Time  `json:"date"`
+}
+
+// Refund is an investment returned to an investor when a loan is cancelled
+type Refund struct {
+	InvestorID string      `json:"investor_id"`
+	Amount     money.Money `json:"amount"`
+	Email      string      `json:"email"`
+}
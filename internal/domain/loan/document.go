@@ -0,0 +1,97 @@
+package loan
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"net/http"
+	"time"
+)
+
+// DocumentKind is what a stored document is for
+type DocumentKind string
+
+const (
+	// DocumentAgreement is an agreement letter generated from the loan
+	DocumentAgreement DocumentKind = "AGREEMENT"
+	// DocumentProof is the proof of the field visit that approves a loan, e.g. a photo of the borrower
+	DocumentProof DocumentKind = "PROOF"
+	// DocumentSignedAgreement is the agreement letter signed by the borrower, required to disburse a loan
+	DocumentSignedAgreement DocumentKind = "SIGNED_AGREEMENT"
+)
+
+// Content types of stored documents
+const (
+	ContentTypePDF  = "application/pdf"
+	ContentTypeJPEG = "image/jpeg"
+	ContentTypePNG  = "image/png"
+)
+
+// DefaultMaxDocumentSize is the largest document that can be uploaded, in bytes
+const DefaultMaxDocumentSize = 10 << 20
+
+// uploadContentTypes are the content types each kind can be uploaded as, generated kinds cannot be uploaded
+var uploadContentTypes = map[DocumentKind][]string{
+	DocumentProof:           {ContentTypeJPEG, ContentTypePNG, ContentTypePDF},
+	DocumentSignedAgreement: {ContentTypePDF},
+}
+
+// Document is a file of a loan kept in a DocumentStore
+type Document struct {
+	ID          string       `json:"id"`
+	LoanID      string       `json:"loan_id"`
+	Kind        DocumentKind `json:"kind"`
+	Name        string       `json:"name" example:"agreement.pdf"`
+	ContentType string       `json:"content_type" example:"application/pdf"`
+	Size        int64        `json:"size"`
+	// Checksum is the hex encoded SHA-256 digest of the content
+	Checksum string `json:"checksum"`
+	// URL is where the document is downloaded from
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AgreementVersion is one agreement letter generated for a loan. Every letter is kept, the loan refers to the last one.
+type AgreementVersion struct {
+	// Version numbers the letters of the loan from 1 in the order they were generated
+	Version int `json:"version" example:"1"`
+	// DocumentID is empty for letters attached by URL before agreements were generated as documents
+	DocumentID  string    `json:"document_id,omitempty"`
+	URL         string    `json:"url"`
+	GeneratedAt time.Time `json:"generated_at"`
+	// Current is set on the letter the loan refers to, the one the borrower signs
+	Current bool `json:"current"`
+}
+
+// DocumentUpload is a file uploaded to a loan
+type DocumentUpload struct {
+	Kind    DocumentKind
+	Name    string
+	Content []byte
+}
+
+// Checksum returns the hex encoded SHA-256 digest of content
+func Checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// SniffContentType detects the content type of an upload from its content, the type the client claims is not trusted.
+// Returns ErrUnsupportedDocumentType when the kind cannot be uploaded as the detected type.
+func (u DocumentUpload) SniffContentType() (string, error) {
+	allowed, ok := uploadContentTypes[u.Kind]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrInvalidDocumentKind, u.Kind)
+	}
+	detected, _, err := mime.ParseMediaType(http.DetectContentType(u.Content))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrUnsupportedDocumentType, err)
+	}
+	for _, contentType := range allowed {
+		if detected == contentType {
+			return detected, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s cannot be uploaded as %s", ErrUnsupportedDocumentType, u.Kind, detected)
+}
@@ -0,0 +1,45 @@
+package loan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocumentUpload_SniffContentType(t *testing.T) {
+	pdf := []byte("%PDF-1.4\n")
+	jpeg := []byte("\xff\xd8\xff\xe0\x00\x10JFIF")
+	png := []byte("\x89PNG\r\n\x1a\n")
+
+	tests := []struct {
+		name    string
+		upload  DocumentUpload
+		want    string
+		wantErr error
+	}{
+		{name: "Proof as JPEG", upload: DocumentUpload{Kind: DocumentProof, Content: jpeg}, want: ContentTypeJPEG},
+		{name: "Proof as PNG", upload: DocumentUpload{Kind: DocumentProof, Content: png}, want: ContentTypePNG},
+		{name: "Proof as PDF", upload: DocumentUpload{Kind: DocumentProof, Content: pdf}, want: ContentTypePDF},
+		{name: "Signed agreement as PDF", upload: DocumentUpload{Kind: DocumentSignedAgreement, Content: pdf}, want: ContentTypePDF},
+		{name: "Signed agreement as image", upload: DocumentUpload{Kind: DocumentSignedAgreement, Content: png}, wantErr: ErrUnsupportedDocumentType},
+		{name: "Name does not decide the type", upload: DocumentUpload{Kind: DocumentProof, Name: "visit.jpg", Content: []byte("<html>")}, wantErr: ErrUnsupportedDocumentType},
+		{name: "Generated kind", upload: DocumentUpload{Kind: DocumentAgreement, Content: pdf}, wantErr: ErrInvalidDocumentKind},
+		{name: "Unknown kind", upload: DocumentUpload{Kind: "PAYSLIP", Content: pdf}, wantErr: ErrInvalidDocumentKind},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.upload.SniffContentType()
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	assert.Equal(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", Checksum(nil))
+	assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", Checksum([]byte("hello")))
+}
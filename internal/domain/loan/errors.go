@@ -0,0 +1,193 @@
+package loan
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrLoanNotFound is returned by a LoanRepository when no loan matches the requested ID
+	ErrLoanNotFound = errors.New("loan not found")
+
+	// ErrLoanAlreadyExists is returned by a LoanRepository when saving a loan whose ID is already stored
+	ErrLoanAlreadyExists = errors.New("loan already exists")
+
+	// ErrInvalidRepaymentTerms is returned when a tenor or repayment scheme cannot produce a schedule
+	ErrInvalidRepaymentTerms = errors.New("invalid repayment terms")
+
+	// ErrScheduleNotFound is returned when a loan has no repayment schedule because it was not disbursed yet
+	ErrScheduleNotFound = errors.New("repayment schedule not found")
+
+	// ErrInvalidRepayment is returned when a repayment is not positive or exceeds what is still owed
+	ErrInvalidRepayment = errors.New("invalid repayment")
+
+	// ErrInvalidReasonCode is returned when a rejection or cancellation carries an unknown reason code
+	ErrInvalidReasonCode = errors.New("invalid reason code")
+
+	// ErrInvalidEvent is returned by an EventStore when an event does not belong to the stream it is appended to
+	ErrInvalidEvent = errors.New("invalid event")
+
+	// ErrOutboxMessageNotFound is returned by an Outbox when no message matches the requested ID
+	ErrOutboxMessageNotFound = errors.New("outbox message not found")
+
+	// ErrPreferencesNotFound is returned by a PreferenceStore when a recipient has not set preferences
+	ErrPreferencesNotFound = errors.New("notification preferences not found")
+
+	// ErrInvalidPreferences is returned when preferences name an unknown channel or kind
+	ErrInvalidPreferences = errors.New("invalid notification preferences")
+
+	// ErrInboxItemNotFound is returned by an Inbox when the recipient has no item with the requested ID
+	ErrInboxItemNotFound = errors.New("inbox item not found")
+
+	// ErrDocumentNotFound is returned by a DocumentStore when no document matches the requested ID
+	ErrDocumentNotFound = errors.New("document not found")
+
+	// ErrInvalidDocumentKind is returned when a document of a kind that cannot be uploaded is uploaded
+	ErrInvalidDocumentKind = errors.New("invalid document kind")
+
+	// ErrUnsupportedDocumentType is returned when the content of an upload is not of a type its kind accepts
+	ErrUnsupportedDocumentType = errors.New("unsupported document type")
+
+	// ErrDocumentTooLarge is returned when an upload exceeds the maximum document size
+	ErrDocumentTooLarge = errors.New("document too large")
+
+	// ErrDocumentMismatch is returned when a referenced document belongs to another loan or is of the wrong kind
+	ErrDocumentMismatch = errors.New("document does not match")
+
+	// ErrAgreementNotGenerated is returned when a loan is invested in before its agreement letter is generated
+	ErrAgreementNotGenerated = errors.New("agreement letter not generated")
+
+	// ErrInvestorNotFound is returned by an InvestorRepository when no investor matches the requested ID
+	ErrInvestorNotFound = errors.New("investor not found")
+
+	// ErrInvestorAlreadyExists is returned by an InvestorRepository when saving an investor whose ID is already stored
+	ErrInvestorAlreadyExists = errors.New("investor already exists")
+
+	// ErrInvalidInvestor is returned when an investor profile is missing its identity or has an invalid limit
+	ErrInvalidInvestor = errors.New("invalid investor")
+
+	// ErrInvestorNotVerified is returned when an investor whose KYC status is not VERIFIED invests
+	ErrInvestorNotVerified = errors.New("investor not verified")
+
+	// ErrInvestmentLimitExceeded is returned when an investment would take an investor over one of their limits
+	ErrInvestmentLimitExceeded = errors.New("investment limit exceeded")
+
+	// ErrBorrowerNotFound is returned by a BorrowerRepository when no borrower matches the requested ID
+	ErrBorrowerNotFound = errors.New("borrower not found")
+
+	// ErrBorrowerAlreadyExists is returned by a BorrowerRepository when saving a borrower whose ID is already stored
+	ErrBorrowerAlreadyExists = errors.New("borrower already exists")
+
+	// ErrInvalidBorrower is returned when a borrower is missing their identity or has an invalid income or obligations
+	ErrInvalidBorrower = errors.New("invalid borrower")
+
+	// ErrBorrowerBlacklisted is returned when a blacklisted borrower applies for a loan
+	ErrBorrowerBlacklisted = errors.New("borrower blacklisted")
+
+	// ErrBorrowerHasLoans is returned when deleting a borrower who has applied for loans
+	ErrBorrowerHasLoans = errors.New("borrower has loans")
+
+	// ErrInvalidRiskGrade is returned when a risk grade is not one of A to E
+	ErrInvalidRiskGrade = errors.New("invalid risk grade")
+
+	// ErrRiskGradeTooLow is returned when approving a loan graded riskier than the minimum without an override
+	ErrRiskGradeTooLow = errors.New("risk grade below the approval minimum")
+
+	// ErrLoanNotPriced is returned when the rate card has no pricing for a loan, e.g. in a currency it does not price
+	ErrLoanNotPriced = errors.New("loan not priced by the rate card")
+
+	// ErrOutsideCorridor is returned when a manual rate or ROI is outside the corridor the rate card allows
+	ErrOutsideCorridor = errors.New("outside the allowed corridor")
+
+	// ErrNegativeMargin is returned when the ROI of a loan is not below its rate, leaving the platform no margin
+	ErrNegativeMargin = errors.New("ROI must be below the rate")
+
+	// ErrRecipientUnreachable is returned by a Notifier when no registered channel reaches the recipient
+	ErrRecipientUnreachable = errors.New("recipient unreachable")
+)
+
+// ErrVersionConflict is matched by VersionConflictError through errors.Is
+var ErrVersionConflict = errors.New("loan version conflict")
+
+// VersionConflictError is returned by LoanRepository.Update when the stored loan has moved past the version the caller read
+type VersionConflictError struct {
+	LoanID   string
+	Expected int64
+	Actual   int64
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("loan %s was modified concurrently: expected version %d, found %d", e.LoanID, e.Expected, e.Actual)
+}
+
+// Is reports whether target is ErrVersionConflict
+func (e *VersionConflictError) Is(target error) bool {
+	return target == ErrVersionConflict
+}
+
+// ErrPreconditionFailed is matched by PreconditionError through errors.Is
+var ErrPreconditionFailed = errors.New("loan precondition failed")
+
+// PreconditionError is returned when a conditional mutation finds the loan at another version than the client read
+type PreconditionError struct {
+	LoanID   string
+	Expected IfMatch
+	Actual   int64
+}
+
+func (e *PreconditionError) Error() string {
+	return fmt.Sprintf("loan %s has been modified since it was retrieved: expected version %s, found %d", e.LoanID, e.Expected, e.Actual)
+}
+
+// Is reports whether target is ErrPreconditionFailed
+func (e *PreconditionError) Is(target error) bool {
+	return target == ErrPreconditionFailed
+}
+
+// ErrAgreementNotVerified is matched by AgreementVerificationError through errors.Is
+var ErrAgreementNotVerified = errors.New("signed agreement could not be verified")
+
+// AgreementVerificationError is returned when a signed agreement does not match the agreement letter generated
+// for the loan or lacks the signatures of its parties
+type AgreementVerificationError struct {
+	LoanID string
+	// Problems describes every check that failed
+	Problems []string
+}
+
+func (e *AgreementVerificationError) Error() string {
+	return fmt.Sprintf("signed agreement of loan %s could not be verified: %s", e.LoanID, strings.Join(e.Problems, "; "))
+}
+
+// Is reports whether target is ErrAgreementNotVerified
+func (e *AgreementVerificationError) Is(target error) bool {
+	return target == ErrAgreementNotVerified
+}
+
+// ErrBorrowerLoanLimit is matched by BorrowerLoanLimitError through errors.Is
+var ErrBorrowerLoanLimit = errors.New("borrower loan limit reached")
+
+// BorrowerLoanLimitError is returned when a borrower applies for a loan while they hold as many active loans as
+// they may, or before the cooldown after their previous application has passed
+type BorrowerLoanLimitError struct {
+	BorrowerID string
+	// ActiveLoans is how many loans of the borrower are neither repaid nor closed before disbursement
+	ActiveLoans    int
+	MaxActiveLoans int
+	// RetryAt is when the cooldown after the previous application ends, zero when the active loans are the limit
+	RetryAt time.Time
+}
+
+func (e *BorrowerLoanLimitError) Error() string {
+	if !e.RetryAt.IsZero() {
+		return fmt.Sprintf("borrower %s applied for a loan too recently and may apply again from %s", e.BorrowerID, e.RetryAt.UTC().Format(time.RFC3339))
+	}
+	return fmt.Sprintf("borrower %s already has %d active loans, at most %d are allowed at a time", e.BorrowerID, e.ActiveLoans, e.MaxActiveLoans)
+}
+
+// Is reports whether target is ErrBorrowerLoanLimit
+func (e *BorrowerLoanLimitError) Is(target error) bool {
+	return target == ErrBorrowerLoanLimit
+}
@@ -0,0 +1,332 @@
+package loan
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hinha/los-technical/internal/pkg/money"
+)
+
+// EventType names a fact that happened to a loan
+type EventType string
+
+const (
+	EventLoanProposed        EventType = "LoanProposed"
+	EventLoanScored          EventType = "LoanScored"
+	EventLoanApproved        EventType = "LoanApproved"
+	EventLoanRejected        EventType = "LoanRejected"
+	EventLoanCancelled       EventType = "LoanCancelled"
+	EventInvestmentAdded     EventType = "InvestmentAdded"
+	EventLoanFullyFunded     EventType = "LoanFullyFunded"
+	EventLoanDisbursed       EventType = "LoanDisbursed"
+	EventAgreementGenerated  EventType = "AgreementGenerated"
+	EventRepaymentRecorded   EventType = "RepaymentRecorded"
+	EventLoanRepaid          EventType = "LoanRepaid"
+	EventLoanDefaulted       EventType = "LoanDefaulted"
+	EventInstallmentReminded EventType = "InstallmentReminded"
+	EventLateFeeCharged      EventType = "LateFeeCharged"
+	// EventLoanImported starts the stream of a loan kept in a read model from before its events were recorded
+	EventLoanImported EventType = "LoanImported"
+)
+
+// Event is an entry of the stream a loan is rebuilt from
+type Event struct {
+	LoanID string `json:"loan_id"`
+	// Version is the loan version the event brings the loan to, the events raised by one command share it
+	Version    int64           `json:"version"`
+	Type       EventType       `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// LoanProposedData is the payload of EventLoanProposed
+type LoanProposedData struct {
+	BorrowerID      string         `json:"borrower_id"`
+	PrincipalAmount money.Money    `json:"principal_amount"`
+	Rate            money.Decimal  `json:"rate"`
+	ROI             money.Decimal  `json:"roi"`
+	Terms           RepaymentTerms `json:"terms"`
+}
+
+// LoanScoredData is the payload of EventLoanScored
+type LoanScoredData struct {
+	Score CreditScore `json:"score"`
+}
+
+// LoanApprovedData is the payload of EventLoanApproved
+type LoanApprovedData struct {
+	Approval Approval `json:"approval"`
+}
+
+// LoanClosedData is the payload of EventLoanRejected and EventLoanCancelled
+type LoanClosedData struct {
+	Closure Closure `json:"closure"`
+	// Refunds are only recorded when a partially funded loan is cancelled
+	Refunds []Refund `json:"refunds,omitempty"`
+}
+
+// InvestmentAddedData is the payload of EventInvestmentAdded
+type InvestmentAddedData struct {
+	Investor Investor `json:"investor"`
+}
+
+// LoanDisbursedData is the payload of EventLoanDisbursed, the schedule is recorded as it was generated
+type LoanDisbursedData struct {
+	Disbursement Disbursement  `json:"disbursement"`
+	Schedule     []Installment `json:"schedule"`
+}
+
+// AgreementGeneratedData is the payload of EventAgreementGenerated
+type AgreementGeneratedData struct {
+	LetterURL string `json:"letter_url"`
+	// DocumentID is empty for letters attached by URL before agreements were generated as documents
+	DocumentID string `json:"document_id,omitempty"`
+}
+
+// RepaymentRecordedData is the payload of EventRepaymentRecorded, the allocation is derived again on replay
+type RepaymentRecordedData struct {
+	RepaymentID string      `json:"repayment_id"`
+	Amount      money.Money `json:"amount"`
+	PaidAt      time.Time   `json:"paid_at"`
+}
+
+// LoanDefaultedData is the payload of EventLoanDefaulted
+type LoanDefaultedData struct {
+	DaysPastDue int `json:"days_past_due"`
+}
+
+// InstallmentRemindedData is the payload of EventInstallmentReminded
+type InstallmentRemindedData struct {
+	Number int `json:"number"`
+}
+
+// LateFeeChargedData is the payload of EventLateFeeCharged
+type LateFeeChargedData struct {
+	Number int         `json:"number"`
+	Fee    money.Money `json:"fee"`
+}
+
+// LoanImportedData is the payload of EventLoanImported, the loan as the read model held it
+type LoanImportedData struct {
+	Loan Loan `json:"loan"`
+}
+
+// NewEvent encodes data as the payload of an event that brings the loan to the given version
+func NewEvent(loanID string, version int64, eventType EventType, data any, occurredAt time.Time) (Event, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+	return Event{
+		LoanID:     loanID,
+		Version:    version,
+		Type:       eventType,
+		OccurredAt: occurredAt,
+		Data:       payload,
+	}, nil
+}
+
+// Decode reads the event payload into data
+func (e Event) Decode(data any) error {
+	if err := json.Unmarshal(e.Data, data); err != nil {
+		return fmt.Errorf("failed to decode %s event of loan %s: %w", e.Type, e.LoanID, err)
+	}
+	return nil
+}
+
+// Apply changes the loan as recorded by the event. Events are facts, so state rules are not checked again;
+// the version is left to the repository.
+func (l *Loan) Apply(e Event) error {
+	switch e.Type {
+	case EventLoanProposed:
+		var data LoanProposedData
+		if err := e.Decode(&data); err != nil {
+			return err
+		}
+		l.ID = e.LoanID
+		l.BorrowerID = data.BorrowerID
+		l.PrincipalAmount = data.PrincipalAmount
+		l.Rate = data.Rate
+		l.ROI = data.ROI
+		l.Terms = data.Terms
+		l.State = StateProposed
+		l.CreatedAt = e.OccurredAt
+	case EventLoanScored:
+		var data LoanScoredData
+		if err := e.Decode(&data); err != nil {
+			return err
+		}
+		l.CreditScore = &data.Score
+	case EventLoanApproved:
+		var data LoanApprovedData
+		if err := e.Decode(&data); err != nil {
+			return err
+		}
+		l.ApprovedInfo = &data.Approval
+		l.State = StateApproved
+	case EventLoanRejected:
+		var data LoanClosedData
+		if err := e.Decode(&data); err != nil {
+			return err
+		}
+		l.RejectedInfo = &data.Closure
+		l.State = StateRejected
+	case EventLoanCancelled:
+		var data LoanClosedData
+		if err := e.Decode(&data); err != nil {
+			return err
+		}
+		l.CancelledInfo = &data.Closure
+		l.Refunds = data.Refunds
+		l.State = StateCancelled
+	case EventInvestmentAdded:
+		var data InvestmentAddedData
+		if err := e.Decode(&data); err != nil {
+			return err
+		}
+		l.Investors = append(l.Investors, data.Investor)
+	case EventLoanFullyFunded:
+		l.State = StateInvested
+	case EventLoanDisbursed:
+		var data LoanDisbursedData
+		if err := e.Decode(&data); err != nil {
+			return err
+		}
+		l.DisbursedInfo = &data.Disbursement
+		l.Schedule = data.Schedule
+		l.State = StateDisbursed
+	case EventAgreementGenerated:
+		var data AgreementGeneratedData
+		if err := e.Decode(&data); err != nil {
+			return err
+		}
+		l.AgreementLetter = data.LetterURL
+		l.AgreementDocumentID = data.DocumentID
+	case EventRepaymentRecorded:
+		var data RepaymentRecordedData
+		if err := e.Decode(&data); err != nil {
+			return err
+		}
+		if _, err := l.ApplyRepayment(data.RepaymentID, data.Amount, data.PaidAt); err != nil {
+			return err
+		}
+	case EventLoanRepaid:
+		l.State = StateRepaid
+	case EventLoanDefaulted:
+		l.State = StateDefaulted
+	case EventInstallmentReminded:
+		var data InstallmentRemindedData
+		if err := e.Decode(&data); err != nil {
+			return err
+		}
+		if data.Number < 1 || data.Number > len(l.Schedule) {
+			return fmt.Errorf("%w: loan %s has no installment %d", ErrInvalidEvent, e.LoanID, data.Number)
+		}
+		remindedAt := e.OccurredAt
+		l.Schedule[data.Number-1].RemindedAt = &remindedAt
+	case EventLateFeeCharged:
+		var data LateFeeChargedData
+		if err := e.Decode(&data); err != nil {
+			return err
+		}
+		if data.Number < 1 || data.Number > len(l.Schedule) {
+			return fmt.Errorf("%w: loan %s has no installment %d", ErrInvalidEvent, e.LoanID, data.Number)
+		}
+		if err := l.Schedule[data.Number-1].ChargeFee(data.Fee); err != nil {
+			return err
+		}
+	case EventLoanImported:
+		var data LoanImportedData
+		if err := e.Decode(&data); err != nil {
+			return err
+		}
+		*l = data.Loan
+	default:
+		return fmt.Errorf("unknown event type %q of loan %s", e.Type, e.LoanID)
+	}
+	l.UpdatedAt = e.OccurredAt
+	return nil
+}
+
+// CheckAppend validates a batch passed to EventStore.Append against the version of the stored stream.
+// An EventLoanImported starts a stream at the version the read model holds the loan at.
+func CheckAppend(loanID string, expectedVersion, storedVersion int64, events []Event) error {
+	if len(events) == 0 {
+		return fmt.Errorf("%w: no events to append to loan %s", ErrInvalidEvent, loanID)
+	}
+	if storedVersion == 0 && events[0].Type == EventLoanImported {
+		storedVersion = expectedVersion
+	}
+	for _, e := range events {
+		if e.LoanID != loanID || e.Version != expectedVersion+1 {
+			return fmt.Errorf("%w: %s event of loan %s at version %d appended to loan %s at version %d",
+				ErrInvalidEvent, e.Type, e.LoanID, e.Version, loanID, expectedVersion+1)
+		}
+	}
+	if storedVersion != expectedVersion {
+		return &VersionConflictError{LoanID: loanID, Expected: expectedVersion, Actual: storedVersion}
+	}
+	return nil
+}
+
+// CloneEvents copies events including their payloads, so stores never share memory with callers
+func CloneEvents(events []Event) []Event {
+	clones := make([]Event, len(events))
+	for i, e := range events {
+		e.Data = append(json.RawMessage(nil), e.Data...)
+		clones[i] = e
+	}
+	return clones
+}
+
+// Replay rebuilds a loan from its events in stream order, failing with ErrLoanNotFound when there are none
+func Replay(events []Event) (*Loan, error) {
+	if len(events) == 0 {
+		return nil, ErrLoanNotFound
+	}
+	loan := &Loan{}
+	for _, e := range events {
+		if err := loan.Apply(e); err != nil {
+			return nil, err
+		}
+		loan.Version = e.Version
+	}
+	return loan, nil
+}
+
+// AgreementVersions lists the agreement letters recorded in the events of a loan, oldest first
+func AgreementVersions(events []Event) ([]AgreementVersion, error) {
+	var versions []AgreementVersion
+	for _, e := range events {
+		var data AgreementGeneratedData
+		switch e.Type {
+		case EventAgreementGenerated:
+			if err := e.Decode(&data); err != nil {
+				return nil, err
+			}
+		case EventLoanImported:
+			// Only the current letter of an imported loan is known
+			var imported LoanImportedData
+			if err := e.Decode(&imported); err != nil {
+				return nil, err
+			}
+			if imported.Loan.AgreementLetter == "" {
+				continue
+			}
+			data = AgreementGeneratedData{LetterURL: imported.Loan.AgreementLetter, DocumentID: imported.Loan.AgreementDocumentID}
+		default:
+			continue
+		}
+		versions = append(versions, AgreementVersion{
+			Version:     len(versions) + 1,
+			DocumentID:  data.DocumentID,
+			URL:         data.LetterURL,
+			GeneratedAt: e.OccurredAt,
+		})
+	}
+	if len(versions) > 0 {
+		versions[len(versions)-1].Current = true
+	}
+	return versions, nil
+}
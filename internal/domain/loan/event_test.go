@@ -0,0 +1,174 @@
+package loan
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hinha/los-technical/internal/pkg/money"
+)
+
+func mustEvent(t *testing.T, version int64, eventType EventType, data any, at time.Time) Event {
+	t.Helper()
+	event, err := NewEvent("loan-1", version, eventType, data, at)
+	if err != nil {
+		t.Fatalf("failed to build event: %v", err)
+	}
+	return event
+}
+
+func TestReplay(t *testing.T) {
+	proposedAt := time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+	terms := RepaymentTerms{TenorMonths: 3, Scheme: SchemeFlat}
+	schedule, err := GenerateSchedule(money.MustNew("1200", "USD"), money.MustParse("4"), terms, disbursedAt)
+	if err != nil {
+		t.Fatalf("failed to generate schedule: %v", err)
+	}
+
+	events := []Event{
+		mustEvent(t, 1, EventLoanProposed, LoanProposedData{
+			BorrowerID:      "borrower-1",
+			PrincipalAmount: money.MustNew("1200", "USD"),
+			Rate:            money.MustParse("4"),
+			ROI:             money.MustParse("3"),
+			Terms:           terms,
+		}, proposedAt),
+		mustEvent(t, 1, EventLoanScored, LoanScoredData{Score: CreditScore{
+			Score: 710, Grade: GradeB, Reasons: []string{"PREVIOUS_DEFAULT"}, Scorecard: "2025-01", ScoredAt: proposedAt,
+		}}, proposedAt),
+		mustEvent(t, 2, EventLoanApproved, LoanApprovedData{Approval: Approval{ValidatorID: "validator-1", Date: proposedAt}}, proposedAt),
+		mustEvent(t, 3, EventInvestmentAdded, InvestmentAddedData{Investor: Investor{ID: "investor-1", Amount: money.MustNew("1200", "USD")}}, proposedAt),
+		mustEvent(t, 3, EventLoanFullyFunded, struct{}{}, proposedAt),
+		mustEvent(t, 4, EventLoanDisbursed, LoanDisbursedData{
+			Disbursement: Disbursement{FieldOfficerID: "officer-1", SignedAgreement: "signed.pdf", Date: disbursedAt},
+			Schedule:     schedule,
+		}, disbursedAt),
+		mustEvent(t, 5, EventAgreementGenerated, AgreementGeneratedData{LetterURL: "letter.pdf"}, disbursedAt),
+		mustEvent(t, 6, EventRepaymentRecorded, RepaymentRecordedData{RepaymentID: "repayment-1", Amount: money.MustNew("1212", "USD"), PaidAt: disbursedAt}, disbursedAt),
+		mustEvent(t, 6, EventLoanRepaid, struct{}{}, disbursedAt),
+	}
+
+	loan, err := Replay(events)
+	assert.NoError(t, err)
+	assert.Equal(t, "loan-1", loan.ID)
+	assert.Equal(t, "borrower-1", loan.BorrowerID)
+	assert.Equal(t, StateRepaid, loan.State)
+	assert.Equal(t, int64(6), loan.Version)
+	assert.Equal(t, proposedAt, loan.CreatedAt)
+	assert.Equal(t, disbursedAt, loan.UpdatedAt)
+	assert.Equal(t, &CreditScore{Score: 710, Grade: GradeB, Reasons: []string{"PREVIOUS_DEFAULT"}, Scorecard: "2025-01", ScoredAt: proposedAt}, loan.CreditScore)
+	assert.Equal(t, "validator-1", loan.ApprovedInfo.ValidatorID)
+	assert.Len(t, loan.Investors, 1)
+	assert.Equal(t, "officer-1", loan.DisbursedInfo.FieldOfficerID)
+	assert.Equal(t, "letter.pdf", loan.AgreementLetter)
+	assert.Len(t, loan.Repayments, 1)
+	assert.True(t, loan.IsFullyRepaid(), "the repayment is allocated again on replay")
+
+	// Replaying a prefix gives the loan as it was at that version
+	funded, err := Replay(events[:5])
+	assert.NoError(t, err)
+	assert.Equal(t, StateInvested, funded.State)
+	assert.Equal(t, int64(3), funded.Version)
+	assert.Nil(t, funded.Schedule)
+
+	remindedAt := disbursedAt.AddDate(0, 1, -3)
+	reminded, err := Replay(append(events[:6:6], mustEvent(t, 5, EventInstallmentReminded, InstallmentRemindedData{Number: 1}, remindedAt)))
+	assert.NoError(t, err)
+	assert.Equal(t, &remindedAt, reminded.Schedule[0].RemindedAt)
+	assert.Nil(t, reminded.Schedule[1].RemindedAt)
+
+	// A late fee is owed on top of the installment and paid before its interest
+	charged, err := Replay(append(events[:6:6],
+		mustEvent(t, 5, EventLateFeeCharged, LateFeeChargedData{Number: 1, Fee: money.MustNew("10", "USD")}, remindedAt),
+		mustEvent(t, 6, EventRepaymentRecorded, RepaymentRecordedData{RepaymentID: "repayment-1", Amount: money.MustNew("12", "USD"), PaidAt: remindedAt}, remindedAt),
+	))
+	assert.NoError(t, err)
+	assert.Equal(t, money.MustNew("10", "USD"), charged.Schedule[0].FeePaid)
+	assert.Equal(t, money.MustNew("2", "USD"), charged.Repayments[0].Interest)
+
+	_, err = Replay(append(events[:6:6], mustEvent(t, 5, EventLateFeeCharged, LateFeeChargedData{Number: 4, Fee: money.MustNew("10", "USD")}, remindedAt)))
+	assert.ErrorIs(t, err, ErrInvalidEvent)
+
+	// A loan imported at a version continues from it
+	imported, err := Replay([]Event{
+		mustEvent(t, 3, EventLoanImported, LoanImportedData{Loan: *funded}, funded.UpdatedAt),
+		mustEvent(t, 4, EventLoanDisbursed, LoanDisbursedData{Disbursement: Disbursement{FieldOfficerID: "officer-1"}, Schedule: schedule}, disbursedAt),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, StateDisbursed, imported.State)
+	assert.Equal(t, int64(4), imported.Version)
+	assert.Equal(t, funded.Investors, imported.Investors)
+	assert.Equal(t, funded.CreditScore, imported.CreditScore)
+}
+
+func TestReplay_Errors(t *testing.T) {
+	_, err := Replay(nil)
+	assert.ErrorIs(t, err, ErrLoanNotFound)
+
+	_, err = Replay([]Event{{LoanID: "loan-1", Version: 1, Type: "LoanRenamed", Data: []byte(`{}`)}})
+	assert.ErrorContains(t, err, `unknown event type "LoanRenamed"`)
+
+	_, err = Replay([]Event{{LoanID: "loan-1", Version: 1, Type: EventLoanApproved, Data: []byte(`[`)}})
+	assert.ErrorContains(t, err, "failed to decode LoanApproved event of loan loan-1")
+}
+
+func TestAgreementVersions(t *testing.T) {
+	approvedAt := time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+	fundedAt := approvedAt.AddDate(0, 0, 7)
+	events := []Event{
+		mustEvent(t, 1, EventLoanProposed, LoanProposedData{BorrowerID: "borrower-1"}, approvedAt),
+		mustEvent(t, 2, EventLoanApproved, LoanApprovedData{Approval: Approval{ValidatorID: "validator-1"}}, approvedAt),
+		mustEvent(t, 2, EventAgreementGenerated, AgreementGeneratedData{LetterURL: "letter-1.pdf", DocumentID: "agreement-1"}, approvedAt),
+		mustEvent(t, 3, EventAgreementGenerated, AgreementGeneratedData{LetterURL: "letter-2.pdf", DocumentID: "agreement-2"}, fundedAt),
+	}
+
+	versions, err := AgreementVersions(events)
+	assert.NoError(t, err)
+	assert.Equal(t, []AgreementVersion{
+		{Version: 1, DocumentID: "agreement-1", URL: "letter-1.pdf", GeneratedAt: approvedAt},
+		{Version: 2, DocumentID: "agreement-2", URL: "letter-2.pdf", GeneratedAt: fundedAt, Current: true},
+	}, versions)
+
+	versions, err = AgreementVersions(events[:2])
+	assert.NoError(t, err)
+	assert.Empty(t, versions)
+
+	// Only the current letter of an imported loan is known
+	versions, err = AgreementVersions([]Event{
+		mustEvent(t, 4, EventLoanImported, LoanImportedData{Loan: Loan{AgreementLetter: "letter-1.pdf", AgreementDocumentID: "agreement-1"}}, approvedAt),
+		mustEvent(t, 5, EventAgreementGenerated, AgreementGeneratedData{LetterURL: "letter-2.pdf", DocumentID: "agreement-2"}, fundedAt),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []AgreementVersion{
+		{Version: 1, DocumentID: "agreement-1", URL: "letter-1.pdf", GeneratedAt: approvedAt},
+		{Version: 2, DocumentID: "agreement-2", URL: "letter-2.pdf", GeneratedAt: fundedAt, Current: true},
+	}, versions)
+	versions, err = AgreementVersions([]Event{mustEvent(t, 1, EventLoanImported, LoanImportedData{}, approvedAt)})
+	assert.NoError(t, err)
+	assert.Empty(t, versions)
+
+	_, err = AgreementVersions([]Event{{LoanID: "loan-1", Version: 1, Type: EventAgreementGenerated, Data: []byte(`[`)}})
+	assert.ErrorContains(t, err, "failed to decode AgreementGenerated event of loan loan-1")
+}
+
+func TestCheckAppend(t *testing.T) {
+	at := time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+	approved := mustEvent(t, 2, EventLoanApproved, LoanApprovedData{}, at)
+
+	assert.NoError(t, CheckAppend("loan-1", 1, 1, []Event{approved}))
+	assert.ErrorIs(t, CheckAppend("loan-1", 1, 1, nil), ErrInvalidEvent)
+	assert.ErrorIs(t, CheckAppend("loan-2", 1, 1, []Event{approved}), ErrInvalidEvent)
+	assert.ErrorIs(t, CheckAppend("loan-1", 0, 0, []Event{approved}), ErrInvalidEvent)
+
+	err := CheckAppend("loan-1", 1, 2, []Event{approved})
+	var conflict *VersionConflictError
+	assert.True(t, errors.As(err, &conflict))
+	assert.Equal(t, int64(2), conflict.Actual)
+
+	// An import starts a stream at the version of the loan, but never continues one
+	imported := mustEvent(t, 5, EventLoanImported, LoanImportedData{}, at)
+	assert.NoError(t, CheckAppend("loan-1", 4, 0, []Event{imported}))
+	assert.ErrorIs(t, CheckAppend("loan-1", 4, 2, []Event{imported}), ErrVersionConflict)
+}
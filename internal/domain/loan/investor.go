@@ -0,0 +1,266 @@
+package loan
+
+import (
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hinha/los-technical/internal/pkg/money"
+)
+
+// KYCStatus is how far the identity of an investor has been verified
+type KYCStatus string
+
+const (
+	// KYCPending is the status of a registered investor whose identity is not reviewed yet
+	KYCPending KYCStatus = "PENDING"
+	// KYCVerified investors may invest
+	KYCVerified KYCStatus = "VERIFIED"
+	// KYCRejected investors failed the review and may not invest
+	KYCRejected KYCStatus = "REJECTED"
+)
+
+// InvestmentLimits cap what an investor may commit. A nil limit is not enforced; an investment in another
+// currency than a limit is refused, as it cannot be checked against it.
+type InvestmentLimits struct {
+	// PerLoan caps what the investor commits to a single loan
+	PerLoan *money.Money `json:"per_loan,omitempty"`
+	// Total caps what the investor has committed to loans that are still outstanding
+	Total *money.Money `json:"total,omitempty"`
+}
+
+// InvestorProfile is an investor registered to fund loans. It links the investments of the same investor
+// across loans, which only record the investor ID and the contact details at the time of the investment.
+type InvestorProfile struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Email  string `json:"email"`
+	Phone  string `json:"phone,omitempty"`
+	Locale string `json:"locale,omitempty"`
+	// KYCStatus must be VERIFIED for the investor to invest
+	KYCStatus KYCStatus `json:"kyc_status"`
+	// KYCReviewedAt is when the KYC status was last set, nil while it is pending its first review
+	KYCReviewedAt *time.Time       `json:"kyc_reviewed_at,omitempty"`
+	Limits        InvestmentLimits `json:"limits"`
+	CreatedAt     time.Time        `json:"created_at"`
+	UpdatedAt     time.Time        `json:"updated_at"`
+}
+
+// Validate checks the identity, the KYC status and the limits of the investor
+func (p InvestorProfile) Validate() error {
+	if p.ID == "" {
+		return fmt.Errorf("%w: investor ID is required", ErrInvalidInvestor)
+	}
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidInvestor)
+	}
+	if p.Email == "" {
+		return fmt.Errorf("%w: email is required", ErrInvalidInvestor)
+	}
+	switch p.KYCStatus {
+	case KYCPending, KYCVerified, KYCRejected:
+	default:
+		return fmt.Errorf("%w: unknown KYC status %q", ErrInvalidInvestor, p.KYCStatus)
+	}
+	if err := validateLimit("per loan", p.Limits.PerLoan); err != nil {
+		return err
+	}
+	return validateLimit("total", p.Limits.Total)
+}
+
+func validateLimit(name string, limit *money.Money) error {
+	if limit == nil {
+		return nil
+	}
+	if err := limit.Validate(); err != nil {
+		return fmt.Errorf("%w: %s limit: %w", ErrInvalidInvestor, name, err)
+	}
+	if !limit.IsPositive() {
+		return fmt.Errorf("%w: %s limit must be positive", ErrInvalidInvestor, name)
+	}
+	return nil
+}
+
+// Clone returns a copy of the profile that shares no pointers with it
+func (p *InvestorProfile) Clone() *InvestorProfile {
+	if p == nil {
+		return nil
+	}
+	clone := *p
+	if p.KYCReviewedAt != nil {
+		reviewedAt := *p.KYCReviewedAt
+		clone.KYCReviewedAt = &reviewedAt
+	}
+	if p.Limits.PerLoan != nil {
+		limit := *p.Limits.PerLoan
+		clone.Limits.PerLoan = &limit
+	}
+	if p.Limits.Total != nil {
+		limit := *p.Limits.Total
+		clone.Limits.Total = &limit
+	}
+	return &clone
+}
+
+// CheckInvestment returns why the investor may not invest amount in the loan, given the loans they have
+// already invested in
+func (p *InvestorProfile) CheckInvestment(loan *Loan, amount money.Money, invested []*Loan) error {
+	if p.KYCStatus != KYCVerified {
+		return fmt.Errorf("%w: investor %s is %s", ErrInvestorNotVerified, p.ID, p.KYCStatus)
+	}
+
+	if limit := p.Limits.PerLoan; limit != nil {
+		committed, err := committedTo(p.ID, amount, loan)
+		if err != nil {
+			return err
+		}
+		if err := checkLimit("per loan", *limit, committed); err != nil {
+			return err
+		}
+	}
+
+	if limit := p.Limits.Total; limit != nil {
+		loans := slices.DeleteFunc(slices.Clone(invested), func(l *Loan) bool { return l.ID == loan.ID || !l.HoldsInvestments() })
+		committed, err := committedTo(p.ID, amount, append(loans, loan)...)
+		if err != nil {
+			return err
+		}
+		if err := checkLimit("total", *limit, committed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// committedTo adds up amount and what the investor has invested in the loans, in the currency of amount
+func committedTo(investorID string, amount money.Money, loans ...*Loan) (money.Money, error) {
+	amounts := []money.Money{amount}
+	for _, l := range loans {
+		for _, inv := range l.Investors {
+			if inv.ID == investorID && inv.Amount.Currency == amount.Currency {
+				amounts = append(amounts, inv.Amount)
+			}
+		}
+	}
+	return money.Sum(amount.Currency, amounts...)
+}
+
+// checkLimit returns ErrInvestmentLimitExceeded when committed is over the limit
+func checkLimit(name string, limit, committed money.Money) error {
+	cmp, err := committed.Cmp(limit)
+	if err != nil {
+		return fmt.Errorf("cannot check the %s investment limit: %w", name, err)
+	}
+	if cmp > 0 {
+		return fmt.Errorf("%w: %s commitment of %s would exceed the limit of %s", ErrInvestmentLimitExceeded, name, committed, limit)
+	}
+	return nil
+}
+
+// HoldsInvestments reports whether the loan still holds the money of its investors: it is neither repaid
+// nor closed before disbursement
+func (l *Loan) HoldsInvestments() bool {
+	return !l.State.IsTerminal()
+}
+
+// PortfolioLoan is a loan in the portfolio of an investor
+type PortfolioLoan struct {
+	LoanID string    `json:"loan_id"`
+	State  LoanState `json:"state"`
+	// Committed is what the investor has invested in the loan
+	Committed money.Money   `json:"committed"`
+	ROI       money.Decimal `json:"roi" swaggertype:"string" example:"10"`
+	// ExpectedReturn is the annual ROI applied to the committed amount over the tenor
+	ExpectedReturn  money.Money    `json:"expected_return"`
+	Terms           RepaymentTerms `json:"terms"`
+	AgreementLetter string         `json:"agreement_letter,omitempty"`
+}
+
+// PortfolioTotal adds up the outstanding loans of a portfolio in one currency
+type PortfolioTotal struct {
+	Committed      money.Money `json:"committed"`
+	ExpectedReturn money.Money `json:"expected_return"`
+}
+
+// Portfolio is every loan an investor has funded
+type Portfolio struct {
+	InvestorID string          `json:"investor_id"`
+	Loans      []PortfolioLoan `json:"loans"`
+	// Totals sum the outstanding loans per currency, ordered by currency
+	Totals []PortfolioTotal `json:"totals"`
+}
+
+// ExpectedReturn is what an investment earns at roi, an annual percentage, over the tenor of the terms,
+// rounded to the minor units of its currency
+func ExpectedReturn(invested money.Money, roi money.Decimal, terms RepaymentTerms) (money.Money, error) {
+	expected := new(big.Rat).Mul(invested.Amount.Rat(), roi.Rat())
+	expected.Mul(expected, big.NewRat(int64(terms.TenorMonths), 1200))
+	return money.FromRat(expected, invested.Currency)
+}
+
+// NewPortfolio builds the portfolio of the investor from the loans they have invested in
+func NewPortfolio(investorID string, loans []*Loan) (*Portfolio, error) {
+	portfolio := &Portfolio{InvestorID: investorID, Loans: []PortfolioLoan{}, Totals: []PortfolioTotal{}}
+	totals := make(map[string]PortfolioTotal)
+	for _, l := range loans {
+		var committed *money.Money
+		for _, inv := range l.Investors {
+			if inv.ID != investorID {
+				continue
+			}
+			if committed == nil {
+				committed = &inv.Amount
+				continue
+			}
+			sum, err := committed.Add(inv.Amount)
+			if err != nil {
+				return nil, fmt.Errorf("failed to add up investments in loan %s: %w", l.ID, err)
+			}
+			committed = &sum
+		}
+		if committed == nil {
+			continue
+		}
+
+		expectedReturn, err := ExpectedReturn(*committed, l.ROI, l.Terms)
+		if err != nil {
+			return nil, err
+		}
+		portfolio.Loans = append(portfolio.Loans, PortfolioLoan{
+			LoanID:          l.ID,
+			State:           l.State,
+			Committed:       *committed,
+			ROI:             l.ROI,
+			ExpectedReturn:  expectedReturn,
+			Terms:           l.Terms,
+			AgreementLetter: l.AgreementLetter,
+		})
+
+		if !l.HoldsInvestments() {
+			continue
+		}
+		total, ok := totals[committed.Currency]
+		if !ok {
+			total = PortfolioTotal{Committed: money.ZeroOf(committed.Currency), ExpectedReturn: money.ZeroOf(committed.Currency)}
+		}
+		if total.Committed, err = total.Committed.Add(*committed); err != nil {
+			return nil, err
+		}
+		if total.ExpectedReturn, err = total.ExpectedReturn.Add(expectedReturn); err != nil {
+			return nil, err
+		}
+		totals[committed.Currency] = total
+	}
+
+	currencies := make([]string, 0, len(totals))
+	for currency := range totals {
+		currencies = append(currencies, currency)
+	}
+	slices.Sort(currencies)
+	for _, currency := range currencies {
+		portfolio.Totals = append(portfolio.Totals, totals[currency])
+	}
+	return portfolio, nil
+}
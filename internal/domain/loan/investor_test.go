@@ -0,0 +1,140 @@
+package loan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hinha/los-technical/internal/pkg/money"
+)
+
+func TestInvestorProfile_CheckInvestment(t *testing.T) {
+	idr := func(amount string) money.Money { return money.MustNew(amount, money.DefaultCurrency) }
+	limit := func(amount string) *money.Money { m := idr(amount); return &m }
+	invested := func(id string, state LoanState, amount string) *Loan {
+		return &Loan{ID: id, State: state, Investors: []Investor{{ID: "investor-1", Amount: idr(amount)}}}
+	}
+	loan := &Loan{ID: "loan-1", Investors: []Investor{{ID: "investor-1", Amount: idr("200")}, {ID: "investor-2", Amount: idr("500")}}}
+
+	tests := []struct {
+		name     string
+		status   KYCStatus
+		limits   InvestmentLimits
+		amount   money.Money
+		invested []*Loan
+		wantErr  error
+	}{
+		{name: "Verified without limits", status: KYCVerified, amount: idr("1000000")},
+		{name: "Pending", status: KYCPending, amount: idr("100"), wantErr: ErrInvestorNotVerified},
+		{name: "Rejected", status: KYCRejected, amount: idr("100"), wantErr: ErrInvestorNotVerified},
+		{name: "Up to the per loan limit", status: KYCVerified, limits: InvestmentLimits{PerLoan: limit("300")}, amount: idr("100")},
+		{name: "Over the per loan limit with earlier investments", status: KYCVerified, limits: InvestmentLimits{PerLoan: limit("300")}, amount: idr("101"), wantErr: ErrInvestmentLimitExceeded},
+		{name: "Limit in another currency", status: KYCVerified, limits: InvestmentLimits{PerLoan: limit("300")}, amount: money.MustNew("1", "USD"), wantErr: money.ErrCurrencyMismatch},
+		{
+			name:     "Total limit counts outstanding loans",
+			status:   KYCVerified,
+			limits:   InvestmentLimits{Total: limit("1000")},
+			amount:   idr("301"),
+			invested: []*Loan{invested("loan-2", StateDisbursed, "500"), loan},
+			wantErr:  ErrInvestmentLimitExceeded,
+		},
+		{
+			name:     "Total limit ignores repaid and closed loans",
+			status:   KYCVerified,
+			limits:   InvestmentLimits{Total: limit("1000")},
+			amount:   idr("300"),
+			invested: []*Loan{invested("loan-2", StateDisbursed, "500"), invested("loan-3", StateRepaid, "900"), invested("loan-4", StateCancelled, "900"), loan},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile := &InvestorProfile{ID: "investor-1", KYCStatus: tt.status, Limits: tt.limits}
+			err := profile.CheckInvestment(loan, tt.amount, tt.invested)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestNewPortfolio(t *testing.T) {
+	terms := RepaymentTerms{TenorMonths: 12, Scheme: SchemeFlat}
+	loans := []*Loan{
+		{
+			ID:              "loan-1",
+			State:           StateDisbursed,
+			ROI:             money.MustParse("10"),
+			Terms:           terms,
+			AgreementLetter: "http://example.com/agreement-1",
+			Investors: []Investor{
+				{ID: "investor-1", Amount: money.MustNew("1000", money.DefaultCurrency)},
+				{ID: "investor-2", Amount: money.MustNew("3000", money.DefaultCurrency)},
+				{ID: "investor-1", Amount: money.MustNew("500", money.DefaultCurrency)},
+			},
+		},
+		{
+			ID:        "loan-2",
+			State:     StateRepaid,
+			ROI:       money.MustParse("8"),
+			Terms:     RepaymentTerms{TenorMonths: 24, Scheme: SchemeFlat},
+			Investors: []Investor{{ID: "investor-1", Amount: money.MustNew("2000", money.DefaultCurrency)}},
+		},
+		{
+			ID:        "loan-3",
+			State:     StateApproved,
+			ROI:       money.MustParse("12.5"),
+			Terms:     RepaymentTerms{TenorMonths: 6, Scheme: SchemeAnnuity},
+			Investors: []Investor{{ID: "investor-1", Amount: money.MustNew("10.00", "USD")}},
+		},
+		{
+			ID:        "loan-4",
+			State:     StateApproved,
+			Investors: []Investor{{ID: "investor-2", Amount: money.MustNew("10", money.DefaultCurrency)}},
+		},
+	}
+
+	portfolio, err := NewPortfolio("investor-1", loans)
+	assert.NoError(t, err)
+	assert.Equal(t, &Portfolio{
+		InvestorID: "investor-1",
+		Loans: []PortfolioLoan{
+			{
+				LoanID:          "loan-1",
+				State:           StateDisbursed,
+				Committed:       money.MustNew("1500", money.DefaultCurrency),
+				ROI:             money.MustParse("10"),
+				ExpectedReturn:  money.MustNew("150", money.DefaultCurrency),
+				Terms:           terms,
+				AgreementLetter: "http://example.com/agreement-1",
+			},
+			{
+				LoanID:    "loan-2",
+				State:     StateRepaid,
+				Committed: money.MustNew("2000", money.DefaultCurrency),
+				ROI:       money.MustParse("8"),
+				// 8% a year over two years
+				ExpectedReturn: money.MustNew("320", money.DefaultCurrency),
+				Terms:          RepaymentTerms{TenorMonths: 24, Scheme: SchemeFlat},
+			},
+			{
+				LoanID:    "loan-3",
+				State:     StateApproved,
+				Committed: money.MustNew("10.00", "USD"),
+				ROI:       money.MustParse("12.5"),
+				// 12.5% a year over half a year is 0.625, rounded to cents
+				ExpectedReturn: money.MustNew("0.63", "USD"),
+				Terms:          RepaymentTerms{TenorMonths: 6, Scheme: SchemeAnnuity},
+			},
+		},
+		Totals: []PortfolioTotal{
+			{Committed: money.MustNew("1500", money.DefaultCurrency), ExpectedReturn: money.MustNew("150", money.DefaultCurrency)},
+			{Committed: money.MustNew("10.00", "USD"), ExpectedReturn: money.MustNew("0.63", "USD")},
+		},
+	}, portfolio)
+
+	empty, err := NewPortfolio("investor-3", loans)
+	assert.NoError(t, err)
+	assert.Equal(t, &Portfolio{InvestorID: "investor-3", Loans: []PortfolioLoan{}, Totals: []PortfolioTotal{}}, empty)
+}
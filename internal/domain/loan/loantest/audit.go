@@ -0,0 +1,103 @@
+package loantest
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+)
+
+// AuditLogFactory returns an empty audit log; it is called once per sub-test
+type AuditLogFactory func(t *testing.T) domain.AuditLog
+
+// NewAuditEntry builds a fixture entry for the loan whose Timestamp is offset by the given number of minutes
+func NewAuditEntry(id, loanID string, action domain.Action, from, to domain.LoanState, minute int) domain.AuditEntry {
+	return domain.AuditEntry{
+		ID:            id,
+		LoanID:        loanID,
+		Actor:         "actor-" + id,
+		Action:        action,
+		FromState:     from,
+		ToState:       to,
+		PayloadDigest: fmt.Sprintf("%064d", minute),
+		Timestamp:     baseTime.Add(time.Duration(minute) * time.Minute),
+	}
+}
+
+// RunAuditLogContract runs every contract check against audit logs built by newAuditLog
+func RunAuditLogContract(t *testing.T, newAuditLog AuditLogFactory) {
+	t.Run("Append and FindByLoanID", func(t *testing.T) {
+		log := newAuditLog(t)
+		want := []domain.AuditEntry{
+			NewAuditEntry("entry-1", "loan-1", domain.ActionCreate, "", domain.StateProposed, 0),
+			NewAuditEntry("entry-2", "loan-1", domain.ActionApprove, domain.StateProposed, domain.StateApproved, 1),
+			NewAuditEntry("entry-3", "loan-1", domain.ActionInvest, domain.StateApproved, domain.StateApproved, 2),
+		}
+		other := NewAuditEntry("entry-4", "loan-2", domain.ActionCreate, "", domain.StateProposed, 1)
+
+		assert.NoError(t, log.Append(want[0]))
+		assert.NoError(t, log.Append(other))
+		assert.NoError(t, log.Append(want[1]))
+		assert.NoError(t, log.Append(want[2]))
+
+		got, err := log.FindByLoanID("loan-1")
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("Keeps append order when timestamps tie", func(t *testing.T) {
+		log := newAuditLog(t)
+		first := NewAuditEntry("entry-b", "loan-1", domain.ActionApprove, domain.StateProposed, domain.StateApproved, 0)
+		second := NewAuditEntry("entry-a", "loan-1", domain.ActionCancel, domain.StateApproved, domain.StateCancelled, 0)
+		assert.NoError(t, log.Append(first))
+		assert.NoError(t, log.Append(second))
+
+		got, err := log.FindByLoanID("loan-1")
+		assert.NoError(t, err)
+		assert.Equal(t, []domain.AuditEntry{first, second}, got)
+	})
+
+	t.Run("Empty history", func(t *testing.T) {
+		got, err := newAuditLog(t).FindByLoanID("non-existent")
+		assert.NoError(t, err)
+		assert.NotNil(t, got, "FindByLoanID must return an empty slice rather than nil")
+		assert.Empty(t, got)
+	})
+
+	t.Run("Entries handed out cannot change the history", func(t *testing.T) {
+		log := newAuditLog(t)
+		entry := NewAuditEntry("entry-1", "loan-1", domain.ActionCreate, "", domain.StateProposed, 0)
+		assert.NoError(t, log.Append(entry))
+
+		found, _ := log.FindByLoanID("loan-1")
+		found[0].Actor = "tampered"
+
+		got, err := log.FindByLoanID("loan-1")
+		assert.NoError(t, err)
+		assert.Equal(t, []domain.AuditEntry{entry}, got)
+	})
+
+	t.Run("Concurrent appends", func(t *testing.T) {
+		log := newAuditLog(t)
+		const writers = 20
+
+		var wg sync.WaitGroup
+		for i := 0; i < writers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				entry := NewAuditEntry(fmt.Sprintf("entry-%d", i), "loan-1", domain.ActionInvest, domain.StateApproved, domain.StateApproved, i)
+				assert.NoError(t, log.Append(entry))
+			}(i)
+		}
+		wg.Wait()
+
+		got, err := log.FindByLoanID("loan-1")
+		assert.NoError(t, err)
+		assert.Len(t, got, writers)
+	})
+}
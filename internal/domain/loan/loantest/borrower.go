@@ -0,0 +1,168 @@
+package loantest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	"github.com/hinha/los-technical/internal/pkg/money"
+)
+
+// BorrowerRepositoryFactory returns an empty borrower repository; it is called once per sub-test
+type BorrowerRepositoryFactory func(t *testing.T) domain.BorrowerRepository
+
+// NewBorrower builds a fixture borrower registered the given number of minutes after the base time
+func NewBorrower(id string, minute int) *domain.Borrower {
+	createdAt := baseTime.Add(time.Duration(minute) * time.Minute)
+	return &domain.Borrower{
+		ID:                 id,
+		Name:               "Borrower " + id,
+		NationalID:         "3171" + id,
+		Email:              id + "@example.com",
+		MonthlyIncome:      money.MustNew("10000000", money.DefaultCurrency),
+		MonthlyObligations: money.MustNew("1500000.50", money.DefaultCurrency),
+		CreatedAt:          createdAt,
+		UpdatedAt:          createdAt,
+	}
+}
+
+// RunBorrowerRepositoryContract runs every contract check against borrower repositories built by newRepository
+func RunBorrowerRepositoryContract(t *testing.T, newRepository BorrowerRepositoryFactory) {
+	t.Run("Save and FindByID", func(t *testing.T) {
+		repo := newRepository(t)
+		want := NewBorrower("borrower-1", 0)
+		want.Phone = "+628123"
+		want.Address = "Jl. Sudirman 1, Jakarta"
+		want.Blacklisted = true
+		want.BlacklistReason = "fraudulent documents"
+		assert.NoError(t, repo.Save(want))
+
+		got, err := repo.FindByID("borrower-1")
+		assert.NoError(t, err)
+		assertBorrower(t, want, got)
+	})
+
+	t.Run("Save duplicate ID", func(t *testing.T) {
+		repo := newRepository(t)
+		assert.NoError(t, repo.Save(NewBorrower("borrower-1", 0)))
+		err := repo.Save(NewBorrower("borrower-1", 1))
+		assert.True(t, errors.Is(err, domain.ErrBorrowerAlreadyExists))
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		_, err := newRepository(t).FindByID("non-existent")
+		assert.True(t, errors.Is(err, domain.ErrBorrowerNotFound))
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repo := newRepository(t)
+		assert.NoError(t, repo.Save(NewBorrower("borrower-1", 0)))
+
+		want := NewBorrower("borrower-1", 0)
+		want.MonthlyIncome = money.MustNew("2500.00", "USD")
+		want.MonthlyObligations = money.MustNew("300.25", "USD")
+		want.Blacklisted = true
+		want.BlacklistReason = "fraud"
+		want.UpdatedAt = baseTime.Add(time.Hour)
+		assert.NoError(t, repo.Update(want))
+
+		got, err := repo.FindByID("borrower-1")
+		assert.NoError(t, err)
+		assertBorrower(t, want, got)
+	})
+
+	t.Run("Update missing borrower", func(t *testing.T) {
+		err := newRepository(t).Update(NewBorrower("borrower-1", 0))
+		assert.True(t, errors.Is(err, domain.ErrBorrowerNotFound), "Update must not create borrowers")
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		repo := newRepository(t)
+		assert.NoError(t, repo.Save(NewBorrower("borrower-1", 0)))
+		assert.NoError(t, repo.Delete("borrower-1"))
+
+		_, err := repo.FindByID("borrower-1")
+		assert.True(t, errors.Is(err, domain.ErrBorrowerNotFound))
+		assert.True(t, errors.Is(repo.Delete("borrower-1"), domain.ErrBorrowerNotFound))
+	})
+
+	t.Run("FindAll paginates in creation order", func(t *testing.T) {
+		repo := newRepository(t)
+		for i, minute := range []int{2, 0, 1, 0} {
+			assert.NoError(t, repo.Save(NewBorrower(fmt.Sprintf("borrower-%d", i), minute)))
+		}
+
+		page1, err := repo.FindAll(1, 3)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"borrower-1", "borrower-3", "borrower-2"}, borrowerIDs(page1))
+
+		page2, err := repo.FindAll(2, 3)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"borrower-0"}, borrowerIDs(page2))
+
+		page3, err := repo.FindAll(3, 3)
+		assert.NoError(t, err)
+		assert.NotNil(t, page3)
+		assert.Empty(t, page3)
+	})
+
+	t.Run("Borrowers handed out cannot change the repository", func(t *testing.T) {
+		repo := newRepository(t)
+		want := NewBorrower("borrower-1", 0)
+		assert.NoError(t, repo.Save(want))
+		want.Name = "changed"
+
+		found, _ := repo.FindByID("borrower-1")
+		found.Blacklisted = true
+
+		got, err := repo.FindByID("borrower-1")
+		assert.NoError(t, err)
+		assert.Equal(t, "Borrower borrower-1", got.Name)
+		assert.False(t, got.Blacklisted)
+	})
+
+	t.Run("Concurrent saves", func(t *testing.T) {
+		repo := newRepository(t)
+		var wg sync.WaitGroup
+		for i := range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, repo.Save(NewBorrower(fmt.Sprintf("borrower-%d", i), i)))
+			}()
+		}
+		wg.Wait()
+
+		all, err := repo.FindAll(1, 20)
+		assert.NoError(t, err)
+		assert.Len(t, all, 10)
+	})
+}
+
+func borrowerIDs(borrowers []*domain.Borrower) []string {
+	ids := make([]string, 0, len(borrowers))
+	for _, b := range borrowers {
+		ids = append(ids, b.ID)
+	}
+	return ids
+}
+
+// assertBorrower compares borrowers by the instants of their timestamps, whatever their location
+func assertBorrower(t *testing.T, want, got *domain.Borrower) {
+	t.Helper()
+	if !assert.NotNil(t, got) {
+		return
+	}
+	assert.True(t, want.CreatedAt.Equal(got.CreatedAt), "CreatedAt: want %s, got %s", want.CreatedAt, got.CreatedAt)
+	assert.True(t, want.UpdatedAt.Equal(got.UpdatedAt), "UpdatedAt: want %s, got %s", want.UpdatedAt, got.UpdatedAt)
+
+	wantCopy, gotCopy := *want, *got
+	wantCopy.CreatedAt, wantCopy.UpdatedAt = time.Time{}, time.Time{}
+	gotCopy.CreatedAt, gotCopy.UpdatedAt = time.Time{}, time.Time{}
+	assert.Equal(t, wantCopy, gotCopy)
+}
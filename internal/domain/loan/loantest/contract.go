@@ -0,0 +1,542 @@
+// Package loantest provides a conformance test suite for loan.LoanRepository implementations.
+//
+// A backend opts in from its own tests:
+//
+//	func TestMyRepository_Contract(t *testing.T) {
+//		loantest.RunRepositoryContract(t, func(t *testing.T) loan.LoanRepository {
+//			return NewMyRepository(...)
+//		})
+//	}
+package loantest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	"github.com/hinha/los-technical/internal/pkg/money"
+)
+
+// RepositoryFactory returns an empty repository; it is called once per sub-test
+type RepositoryFactory func(t *testing.T) domain.LoanRepository
+
+// baseTime is the creation time of the first fixture loan, later loans are one minute apart
+var baseTime = time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+
+// NewLoan builds a PROPOSED fixture loan whose CreatedAt is offset by the given number of minutes
+func NewLoan(id, borrowerID string, minute int) *domain.Loan {
+	createdAt := baseTime.Add(time.Duration(minute) * time.Minute)
+	return &domain.Loan{
+		ID:              id,
+		BorrowerID:      borrowerID,
+		PrincipalAmount: money.MustNew("10000", money.DefaultCurrency),
+		Rate:            money.MustParse("12.5"),
+		ROI:             money.MustParse("10"),
+		Terms:           domain.RepaymentTerms{TenorMonths: domain.DefaultTenorMonths, Scheme: domain.DefaultRepaymentScheme},
+		State:           domain.StateProposed,
+		CreatedAt:       createdAt,
+		UpdatedAt:       createdAt,
+	}
+}
+
+// RunRepositoryContract runs every contract check against repositories built by newRepository
+func RunRepositoryContract(t *testing.T, newRepository RepositoryFactory) {
+	t.Run("Save", func(t *testing.T) { testSave(t, newRepository) })
+	t.Run("FindByID", func(t *testing.T) { testFindByID(t, newRepository) })
+	t.Run("Update", func(t *testing.T) { testUpdate(t, newRepository) })
+	t.Run("FindByBorrowerID", func(t *testing.T) { testFindByBorrowerID(t, newRepository) })
+	t.Run("FindByInvestorID", func(t *testing.T) { testFindByInvestorID(t, newRepository) })
+	t.Run("FindByState", func(t *testing.T) { testFindByState(t, newRepository) })
+	t.Run("FindAll", func(t *testing.T) { testFindAll(t, newRepository) })
+	t.Run("Isolation", func(t *testing.T) { testIsolation(t, newRepository) })
+	t.Run("ConcurrentAccess", func(t *testing.T) { testConcurrentAccess(t, newRepository) })
+}
+
+func testSave(t *testing.T, newRepository RepositoryFactory) {
+	t.Run("Save new loan successfully", func(t *testing.T) {
+		repo := newRepository(t)
+		loan := NewLoan("loan-1", "borrower-1", 0)
+
+		assert.NoError(t, repo.Save(loan))
+		assert.Equal(t, int64(1), loan.Version, "Save must set the initial version")
+
+		want := NewLoan("loan-1", "borrower-1", 0)
+		want.Version = 1
+		got, err := repo.FindByID("loan-1")
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("Error when saving a duplicate loan ID", func(t *testing.T) {
+		repo := newRepository(t)
+		assert.NoError(t, repo.Save(NewLoan("loan-1", "borrower-1", 0)))
+
+		err := repo.Save(NewLoan("loan-1", "borrower-2", 1))
+		assert.ErrorIs(t, err, domain.ErrLoanAlreadyExists)
+
+		// The stored loan must be left untouched
+		got, err := repo.FindByID("loan-1")
+		assert.NoError(t, err)
+		assert.Equal(t, "borrower-1", got.BorrowerID)
+	})
+
+	t.Run("Save several loans of the same borrower", func(t *testing.T) {
+		// How many loans a borrower may hold is a business rule of the service, not of the repository
+		repo := newRepository(t)
+		assert.NoError(t, repo.Save(NewLoan("loan-1", "borrower-1", 0)))
+		assert.NoError(t, repo.Save(NewLoan("loan-2", "borrower-1", 1)))
+
+		got, err := repo.FindByBorrowerID("borrower-1")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"loan-1", "loan-2"}, loanIDs(got))
+	})
+}
+
+func testFindByID(t *testing.T, newRepository RepositoryFactory) {
+	repo := newRepository(t)
+	assert.NoError(t, repo.Save(NewLoan("loan-1", "borrower-1", 0)))
+
+	t.Run("Find existing loan", func(t *testing.T) {
+		got, err := repo.FindByID("loan-1")
+		assert.NoError(t, err)
+		if assert.NotNil(t, got) {
+			assert.Equal(t, "loan-1", got.ID)
+		}
+	})
+
+	t.Run("Error when loan not found", func(t *testing.T) {
+		got, err := repo.FindByID("non-existent-id")
+		assert.ErrorIs(t, err, domain.ErrLoanNotFound)
+		assert.Nil(t, got)
+	})
+}
+
+func testUpdate(t *testing.T, newRepository RepositoryFactory) {
+	t.Run("Update persists state and details", func(t *testing.T) {
+		repo := newRepository(t)
+		assert.NoError(t, repo.Save(NewLoan("loan-1", "borrower-1", 0)))
+
+		loan, err := repo.FindByID("loan-1")
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		approvedAt := baseTime.Add(time.Hour)
+		disbursedAt := baseTime.Add(2 * time.Hour)
+		loan.State = domain.StateDisbursed
+		loan.AgreementLetter = "http://example.com/agreement"
+		loan.AgreementDocumentID = "document-1"
+		loan.CreditScore = &domain.CreditScore{
+			Score:     420,
+			Grade:     domain.GradeD,
+			Reasons:   []string{"HIGH_DEBT_TO_INCOME", "NO_REPAYMENT_HISTORY"},
+			Scorecard: "2025-01",
+			ScoredAt:  baseTime,
+		}
+		loan.ApprovedInfo = &domain.Approval{
+			ValidatorID:           "validator-1",
+			ProofDocumentID:       "proof-1",
+			ProofURL:              "http://example.com/proof",
+			OverrideJustification: "Collateral covers the principal twice",
+			Date:                  approvedAt,
+		}
+		loan.Investors = []domain.Investor{
+			{ID: "investor-1", Name: "Investor One", Amount: money.MustNew("4000", money.DefaultCurrency), Email: "one@example.com", Locale: "id"},
+			{ID: "investor-2", Name: "Investor Two", Amount: money.MustNew("6000", money.DefaultCurrency), Email: "two@example.com", Locale: "en"},
+		}
+		loan.DisbursedInfo = &domain.Disbursement{
+			SignedAgreementDocumentID: "signed-1",
+			SignedAgreement:           "http://example.com/signed",
+			FieldOfficerID:            "officer-1",
+			Date:                      disbursedAt,
+		}
+		loan.Terms = domain.RepaymentTerms{TenorMonths: 3, Scheme: domain.SchemeFlat}
+		loan.Schedule, err = domain.GenerateSchedule(loan.PrincipalAmount, loan.Rate, loan.Terms, disbursedAt)
+		if !assert.NoError(t, err) {
+			return
+		}
+		// Settles the first installment and pays part of the second
+		_, err = loan.ApplyRepayment("repayment-1", money.MustNew("5000", money.DefaultCurrency), disbursedAt.Add(time.Hour))
+		if !assert.NoError(t, err) {
+			return
+		}
+		remindedAt := disbursedAt.AddDate(0, 2, -3)
+		loan.Schedule[1].RemindedAt = &remindedAt
+		loan.UpdatedAt = disbursedAt
+		assert.NoError(t, repo.Update(loan))
+		assert.Equal(t, int64(2), loan.Version, "Update must write the new version back")
+
+		got, err := repo.FindByID("loan-1")
+		assert.NoError(t, err)
+		assert.Equal(t, loan, got)
+	})
+
+	t.Run("Update persists rejection and cancellation", func(t *testing.T) {
+		repo := newRepository(t)
+		assert.NoError(t, repo.Save(NewLoan("loan-1", "borrower-1", 0)))
+		assert.NoError(t, repo.Save(NewLoan("loan-2", "borrower-2", 1)))
+
+		rejected := NewLoan("loan-1", "borrower-1", 0)
+		rejected.Version = 1
+		rejected.State = domain.StateRejected
+		rejected.RejectedInfo = &domain.Closure{ActorID: "validator-1", ReasonCode: domain.ReasonCreditRisk, Date: baseTime.Add(time.Hour)}
+		assert.NoError(t, repo.Update(rejected))
+
+		cancelled := NewLoan("loan-2", "borrower-2", 1)
+		cancelled.Version = 1
+		cancelled.State = domain.StateCancelled
+		cancelled.Investors = []domain.Investor{{ID: "investor-1", Amount: money.MustNew("4000", money.DefaultCurrency), Email: "one@example.com"}}
+		cancelled.Refunds = []domain.Refund{{InvestorID: "investor-1", Amount: money.MustNew("4000", money.DefaultCurrency), Email: "one@example.com"}}
+		cancelled.CancelledInfo = &domain.Closure{ActorID: "borrower-2", ReasonCode: domain.ReasonBorrowerWithdrawn, Date: baseTime.Add(time.Hour)}
+		assert.NoError(t, repo.Update(cancelled))
+
+		got, err := repo.FindByID("loan-1")
+		assert.NoError(t, err)
+		assert.Equal(t, rejected, got)
+
+		got, err = repo.FindByID("loan-2")
+		assert.NoError(t, err)
+		assert.Equal(t, cancelled, got)
+	})
+
+	t.Run("Update replaces investors instead of appending", func(t *testing.T) {
+		repo := newRepository(t)
+		loan := NewLoan("loan-1", "borrower-1", 0)
+		loan.Investors = []domain.Investor{
+			{ID: "investor-1", Amount: money.MustNew("4000", money.DefaultCurrency), Email: "one@example.com"},
+			{ID: "investor-2", Amount: money.MustNew("6000", money.DefaultCurrency), Email: "two@example.com"},
+		}
+		assert.NoError(t, repo.Save(loan))
+
+		updated := NewLoan("loan-1", "borrower-1", 0)
+		updated.Version = 1
+		updated.Investors = []domain.Investor{{ID: "investor-3", Amount: money.MustNew("10000", money.DefaultCurrency), Email: "three@example.com"}}
+		assert.NoError(t, repo.Update(updated))
+
+		got, err := repo.FindByID("loan-1")
+		assert.NoError(t, err)
+		assert.Equal(t, updated.Investors, got.Investors)
+	})
+
+	t.Run("Error when updating a stale version", func(t *testing.T) {
+		repo := newRepository(t)
+		assert.NoError(t, repo.Save(NewLoan("loan-1", "borrower-1", 0)))
+
+		first := NewLoan("loan-1", "borrower-1", 0)
+		first.Version = 1
+		first.State = domain.StateApproved
+		assert.NoError(t, repo.Update(first))
+
+		// A second writer that also read version 1 must lose
+		stale := NewLoan("loan-1", "borrower-1", 0)
+		stale.Version = 1
+		stale.State = domain.StateInvested
+		err := repo.Update(stale)
+		assert.ErrorIs(t, err, domain.ErrVersionConflict)
+
+		var conflict *domain.VersionConflictError
+		if assert.ErrorAs(t, err, &conflict) {
+			assert.Equal(t, "loan-1", conflict.LoanID)
+			assert.Equal(t, int64(1), conflict.Expected)
+			assert.Equal(t, int64(2), conflict.Actual)
+		}
+
+		got, err := repo.FindByID("loan-1")
+		assert.NoError(t, err)
+		assert.Equal(t, domain.StateApproved, got.State)
+		assert.Equal(t, int64(2), got.Version)
+	})
+
+	t.Run("Error when updating non-existent loan", func(t *testing.T) {
+		repo := newRepository(t)
+
+		err := repo.Update(NewLoan("non-existent-id", "borrower-1", 0))
+		assert.ErrorIs(t, err, domain.ErrLoanNotFound)
+
+		_, err = repo.FindByID("non-existent-id")
+		assert.ErrorIs(t, err, domain.ErrLoanNotFound, "Update must not create loans")
+	})
+}
+
+func testFindByBorrowerID(t *testing.T, newRepository RepositoryFactory) {
+	repo := newRepository(t)
+	assert.NoError(t, repo.Save(NewLoan("loan-1", "borrower-1", 0)))
+	assert.NoError(t, repo.Save(NewLoan("loan-2", "borrower-2", 1)))
+
+	tests := []struct {
+		name       string
+		borrowerID string
+		wantIDs    []string
+	}{
+		{"Find loans for borrower-1", "borrower-1", []string{"loan-1"}},
+		{"Find loans for borrower-2", "borrower-2", []string{"loan-2"}},
+		{"Find loans for non-existent borrower", "non-existent-borrower", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.FindByBorrowerID(tt.borrowerID)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantIDs, loanIDs(got))
+		})
+	}
+}
+
+func testFindByInvestorID(t *testing.T, newRepository RepositoryFactory) {
+	repo := newRepository(t)
+	amount := money.MustNew("2000", money.DefaultCurrency)
+	// Saved out of creation order so implementations cannot rely on insertion order
+	for _, minute := range []int{2, 0, 1} {
+		loan := NewLoan(fmt.Sprintf("loan-%d", minute), fmt.Sprintf("borrower-%d", minute), minute)
+		loan.State = domain.StateApproved
+		loan.Investors = []domain.Investor{{ID: "investor-1", Amount: amount, Email: "one@example.com"}}
+		if minute == 1 {
+			loan.Investors = []domain.Investor{{ID: "investor-2", Amount: amount, Email: "two@example.com"}}
+		}
+		if minute == 2 {
+			// Investing twice in the same loan lists it once
+			loan.Investors = append(loan.Investors, loan.Investors[0], domain.Investor{ID: "investor-2", Amount: amount, Email: "two@example.com"})
+		}
+		assert.NoError(t, repo.Save(loan))
+	}
+
+	tests := []struct {
+		name       string
+		investorID string
+		wantIDs    []string
+	}{
+		{"Find loans for investor-1 in creation order", "investor-1", []string{"loan-0", "loan-2"}},
+		{"Find loans for investor-2 in creation order", "investor-2", []string{"loan-1", "loan-2"}},
+		{"Find loans for non-existent investor", "non-existent-investor", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.FindByInvestorID(tt.investorID)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantIDs, loanIDs(got))
+		})
+	}
+}
+
+func testFindByState(t *testing.T, newRepository RepositoryFactory) {
+	repo := newRepository(t)
+
+	// Saved out of creation order so implementations cannot rely on insertion order
+	for _, minute := range []int{3, 0, 4, 1, 2} {
+		loan := NewLoan(fmt.Sprintf("loan-%d", minute), fmt.Sprintf("borrower-%d", minute), minute)
+		if minute%2 == 1 {
+			loan.State = domain.StateApproved
+		}
+		assert.NoError(t, repo.Save(loan))
+	}
+
+	tests := []struct {
+		name    string
+		state   domain.LoanState
+		wantIDs []string
+	}{
+		{"Find PROPOSED loans in creation order", domain.StateProposed, []string{"loan-0", "loan-2", "loan-4"}},
+		{"Find APPROVED loans in creation order", domain.StateApproved, []string{"loan-1", "loan-3"}},
+		{"Find INVESTED loans (none exist)", domain.StateInvested, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.FindByState(tt.state)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantIDs, loanIDs(got))
+			for _, loan := range got {
+				assert.Equal(t, tt.state, loan.State)
+			}
+		})
+	}
+}
+
+func testFindAll(t *testing.T, newRepository RepositoryFactory) {
+	repo := newRepository(t)
+
+	// Two loans share a creation time to check the ID tie-break, even-numbered loans are booked in USD
+	for i := 10; i >= 1; i-- {
+		minute := i
+		if i == 10 {
+			minute = 9
+		}
+		loan := NewLoan(fmt.Sprintf("loan-%02d", i), fmt.Sprintf("borrower-%d", i), minute)
+		if i%2 == 0 {
+			loan.PrincipalAmount = money.MustNew("250.50", "USD")
+		}
+		assert.NoError(t, repo.Save(loan))
+	}
+
+	usd := domain.LoanFilter{Currency: "USD"}
+	idr := domain.LoanFilter{Currency: "IDR"}
+	tests := []struct {
+		name    string
+		filter  domain.LoanFilter
+		page    int
+		limit   int
+		wantIDs []string
+	}{
+		{"First page with 4 items", domain.LoanFilter{}, 1, 4, []string{"loan-01", "loan-02", "loan-03", "loan-04"}},
+		{"Second page with 4 items", domain.LoanFilter{}, 2, 4, []string{"loan-05", "loan-06", "loan-07", "loan-08"}},
+		{"Last partial page", domain.LoanFilter{}, 3, 4, []string{"loan-09", "loan-10"}},
+		{"Page beyond available data", domain.LoanFilter{}, 4, 4, []string{}},
+		{"Get all items with large limit", domain.LoanFilter{}, 1, 20, []string{
+			"loan-01", "loan-02", "loan-03", "loan-04", "loan-05",
+			"loan-06", "loan-07", "loan-08", "loan-09", "loan-10",
+		}},
+		{"Currency filter applies before paging", usd, 2, 2, []string{"loan-06", "loan-08"}},
+		{"Currency filter last partial page", idr, 3, 2, []string{"loan-09"}},
+		{"Currency filter keeps the ID tie-break", usd, 1, 10, []string{"loan-02", "loan-04", "loan-06", "loan-08", "loan-10"}},
+		{"Currency without loans", domain.LoanFilter{Currency: "EUR"}, 1, 10, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.FindAll(tt.filter, tt.page, tt.limit)
+			assert.NoError(t, err)
+			assert.NotNil(t, got, "FindAll must return an empty slice rather than nil")
+			assert.Equal(t, tt.wantIDs, loanIDs(got))
+		})
+	}
+}
+
+func testIsolation(t *testing.T, newRepository RepositoryFactory) {
+	repo := newRepository(t)
+
+	loan := NewLoan("loan-1", "borrower-1", 0)
+	loan.CreditScore = &domain.CreditScore{Score: 700, Grade: domain.GradeB, Reasons: []string{"NO_REPAYMENT_HISTORY"}, Scorecard: "2025-01", ScoredAt: baseTime}
+	loan.ApprovedInfo = &domain.Approval{ValidatorID: "validator-1", ProofURL: "http://example.com/proof", Date: baseTime}
+	loan.Investors = []domain.Investor{{ID: "investor-1", Amount: money.MustNew("4000", money.DefaultCurrency), Email: "one@example.com"}}
+	schedule, err := domain.GenerateSchedule(loan.PrincipalAmount, loan.Rate, loan.Terms, baseTime)
+	if !assert.NoError(t, err) {
+		return
+	}
+	remindedAt := baseTime.AddDate(0, 1, -3)
+	schedule[0].RemindedAt = &remindedAt
+	loan.Schedule = schedule
+	assert.NoError(t, repo.Save(loan))
+	want, err := repo.FindByID("loan-1")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// Changing the saved value after the fact must not leak into the repository
+	loan.State = domain.StateDisbursed
+	loan.ApprovedInfo.ValidatorID = "tampered"
+	loan.CreditScore.Reasons[0] = "tampered"
+	loan.Investors[0].Amount = money.MustNew("1", money.DefaultCurrency)
+	*loan.Schedule[0].RemindedAt = baseTime
+
+	// Neither may changes to loans handed out by the finders
+	found, _ := repo.FindByID("loan-1")
+	found.State = domain.StateDisbursed
+	found.ApprovedInfo.ProofURL = "tampered"
+	found.CreditScore.Grade = domain.GradeE
+	found.Investors[0].Email = "tampered@example.com"
+	*found.Schedule[0].RemindedAt = baseTime
+	found.Investors = append(found.Investors, domain.Investor{ID: "investor-2", Amount: money.MustNew("6000", money.DefaultCurrency)})
+
+	listed, _ := repo.FindAll(domain.LoanFilter{}, 1, 10)
+	for _, l := range listed {
+		l.Investors[0].ID = "tampered"
+	}
+	byState, _ := repo.FindByState(domain.StateProposed)
+	for _, l := range byState {
+		l.ApprovedInfo = nil
+	}
+	byBorrower, _ := repo.FindByBorrowerID("borrower-1")
+	for _, l := range byBorrower {
+		l.Investors = nil
+	}
+
+	got, err := repo.FindByID("loan-1")
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+	// Checked by value too: want would change along with a time pointer the copies failed to duplicate
+	if assert.NotNil(t, got.Schedule[0].RemindedAt) {
+		assert.Equal(t, baseTime.AddDate(0, 1, -3), *got.Schedule[0].RemindedAt)
+	}
+}
+
+func testConcurrentAccess(t *testing.T, newRepository RepositoryFactory) {
+	const workers = 20
+
+	repo := newRepository(t)
+	assert.NoError(t, repo.Save(NewLoan("shared", "borrower-shared", 0)))
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*4)
+	for i := 1; i <= workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			id := fmt.Sprintf("loan-%02d", i)
+			if err := repo.Save(NewLoan(id, fmt.Sprintf("borrower-%02d", i), i)); err != nil {
+				errs <- fmt.Errorf("save %s: %w", id, err)
+			}
+
+			// Every worker rewrites the shared loan, retrying until its compare-and-swap wins
+			for {
+				current, err := repo.FindByID("shared")
+				if err != nil {
+					errs <- fmt.Errorf("find shared: %w", err)
+					break
+				}
+				shared := NewLoan("shared", "borrower-shared", 0)
+				shared.Version = current.Version
+				shared.UpdatedAt = baseTime.Add(time.Duration(i) * time.Second)
+
+				err = repo.Update(shared)
+				if errors.Is(err, domain.ErrVersionConflict) {
+					continue
+				}
+				if err != nil {
+					errs <- fmt.Errorf("update shared: %w", err)
+				}
+				break
+			}
+
+			if _, err := repo.FindByID(id); err != nil {
+				errs <- fmt.Errorf("find %s: %w", id, err)
+			}
+			if _, err := repo.FindAll(domain.LoanFilter{}, 1, workers); err != nil {
+				errs <- fmt.Errorf("find all: %w", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	all, err := repo.FindAll(domain.LoanFilter{}, 1, workers*2)
+	assert.NoError(t, err)
+	assert.Len(t, all, workers+1)
+
+	// One successful update per worker on top of the initial save
+	shared, err := repo.FindByID("shared")
+	assert.NoError(t, err)
+	if assert.NotNil(t, shared) {
+		assert.Equal(t, domain.StateProposed, shared.State)
+		assert.Equal(t, int64(workers+1), shared.Version)
+	}
+}
+
+// loanIDs returns the IDs of the given loans, never nil so it compares equal to an empty expectation
+func loanIDs(loans []*domain.Loan) []string {
+	ids := []string{}
+	for _, loan := range loans {
+		ids = append(ids, loan.ID)
+	}
+	return ids
+}
@@ -0,0 +1,149 @@
+package loantest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+)
+
+// DocumentStoreFactory returns an empty document store; it is called once per sub-test
+type DocumentStoreFactory func(t *testing.T) domain.DocumentStore
+
+// NewDocument builds a fixture agreement document of the loan, the store fills in the rest
+func NewDocument(loanID string) domain.Document {
+	return domain.Document{
+		LoanID:      loanID,
+		Kind:        domain.DocumentAgreement,
+		Name:        "agreement-" + loanID + ".pdf",
+		ContentType: domain.ContentTypePDF,
+	}
+}
+
+// RunDocumentStoreContract runs every contract check against document stores built by newStore
+func RunDocumentStoreContract(t *testing.T, newStore DocumentStoreFactory) {
+	t.Run("Save and Find", func(t *testing.T) {
+		store := newStore(t)
+		content := []byte("%PDF-1.4 agreement")
+		saved, err := store.Save(NewDocument("loan-1"), content)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.NotEmpty(t, saved.ID)
+		assert.NotEmpty(t, saved.URL)
+		assert.Equal(t, int64(len(content)), saved.Size)
+		assert.Equal(t, domain.Checksum(content), saved.Checksum)
+		assert.False(t, saved.CreatedAt.IsZero())
+		assert.Equal(t, "loan-1", saved.LoanID)
+		assert.Equal(t, domain.DocumentAgreement, saved.Kind)
+
+		found, err := store.Find(saved.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, saved.ID, found.ID)
+		assert.Equal(t, saved.URL, found.URL)
+		assert.Equal(t, saved.Name, found.Name)
+		assert.Equal(t, saved.ContentType, found.ContentType)
+		assert.Equal(t, saved.Size, found.Size)
+		assert.Equal(t, saved.Checksum, found.Checksum)
+		assert.True(t, saved.CreatedAt.Equal(found.CreatedAt))
+
+		read, err := store.Read(saved.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, content, read)
+	})
+
+	t.Run("Every save is a new document", func(t *testing.T) {
+		store := newStore(t)
+		first, err := store.Save(NewDocument("loan-1"), []byte("first"))
+		assert.NoError(t, err)
+		second, err := store.Save(NewDocument("loan-1"), []byte("second"))
+		assert.NoError(t, err)
+		assert.NotEqual(t, first.ID, second.ID)
+		assert.NotEqual(t, first.URL, second.URL)
+
+		read, err := store.Read(first.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("first"), read)
+	})
+
+	t.Run("Content is copied", func(t *testing.T) {
+		store := newStore(t)
+		content := []byte("original")
+		saved, err := store.Save(NewDocument("loan-1"), content)
+		assert.NoError(t, err)
+		copy(content, "modified")
+
+		read, err := store.Read(saved.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("original"), read)
+		read[0] = 'X'
+
+		again, err := store.Read(saved.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("original"), again)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		store := newStore(t)
+		kept, err := store.Save(NewDocument("loan-1"), []byte("kept"))
+		assert.NoError(t, err)
+		deleted, err := store.Save(NewDocument("loan-1"), []byte("deleted"))
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.NoError(t, store.Delete(deleted.ID))
+		_, err = store.Find(deleted.ID)
+		assert.True(t, errors.Is(err, domain.ErrDocumentNotFound), "Find: %v", err)
+		_, err = store.Read(deleted.ID)
+		assert.True(t, errors.Is(err, domain.ErrDocumentNotFound), "Read: %v", err)
+		err = store.Delete(deleted.ID)
+		assert.True(t, errors.Is(err, domain.ErrDocumentNotFound), "Delete again: %v", err)
+
+		read, err := store.Read(kept.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("kept"), read)
+	})
+
+	t.Run("Unknown document", func(t *testing.T) {
+		store := newStore(t)
+		_, err := store.Find("missing")
+		assert.True(t, errors.Is(err, domain.ErrDocumentNotFound), "Find: %v", err)
+		_, err = store.Read("missing")
+		assert.True(t, errors.Is(err, domain.ErrDocumentNotFound), "Read: %v", err)
+		_, err = store.Find("")
+		assert.True(t, errors.Is(err, domain.ErrDocumentNotFound), "Find empty ID: %v", err)
+		_, err = store.Read("../missing")
+		assert.True(t, errors.Is(err, domain.ErrDocumentNotFound), "Read path: %v", err)
+		err = store.Delete("../missing")
+		assert.True(t, errors.Is(err, domain.ErrDocumentNotFound), "Delete path: %v", err)
+	})
+
+	t.Run("Concurrent saves", func(t *testing.T) {
+		store := newStore(t)
+		const writers = 10
+		ids := make([]string, writers)
+		var wg sync.WaitGroup
+		for i := 0; i < writers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				saved, err := store.Save(NewDocument(fmt.Sprintf("loan-%d", i)), []byte(fmt.Sprintf("content %d", i)))
+				if assert.NoError(t, err) {
+					ids[i] = saved.ID
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		for i, id := range ids {
+			read, err := store.Read(id)
+			assert.NoError(t, err)
+			assert.Equal(t, []byte(fmt.Sprintf("content %d", i)), read)
+		}
+	})
+}
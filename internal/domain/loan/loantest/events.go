@@ -0,0 +1,141 @@
+package loantest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+)
+
+// EventStoreFactory returns an empty event store; it is called once per sub-test
+type EventStoreFactory func(t *testing.T) domain.EventStore
+
+// NewEvent builds a fixture event whose OccurredAt is offset by the given number of minutes
+func NewEvent(t *testing.T, loanID string, version int64, eventType domain.EventType, data any, minute int) domain.Event {
+	t.Helper()
+	event, err := domain.NewEvent(loanID, version, eventType, data, baseTime.Add(time.Duration(minute)*time.Minute))
+	if err != nil {
+		t.Fatalf("failed to build event: %v", err)
+	}
+	return event
+}
+
+// RunEventStoreContract runs every contract check against event stores built by newEventStore
+func RunEventStoreContract(t *testing.T, newEventStore EventStoreFactory) {
+	proposed := func(t *testing.T, loanID string, minute int) domain.Event {
+		return NewEvent(t, loanID, 1, domain.EventLoanProposed, domain.LoanProposedData{BorrowerID: "borrower-" + loanID}, minute)
+	}
+	invested := func(t *testing.T, loanID string, version int64, minute int) []domain.Event {
+		return []domain.Event{
+			NewEvent(t, loanID, version, domain.EventInvestmentAdded, domain.InvestmentAddedData{Investor: domain.Investor{ID: "investor-1"}}, minute),
+			NewEvent(t, loanID, version, domain.EventLoanFullyFunded, struct{}{}, minute),
+		}
+	}
+
+	t.Run("Append and Load", func(t *testing.T) {
+		store := newEventStore(t)
+		first := proposed(t, "loan-1", 0)
+		other := proposed(t, "loan-2", 1)
+		batch := invested(t, "loan-1", 2, 2)
+
+		assert.NoError(t, store.Append("loan-1", 0, first))
+		assert.NoError(t, store.Append("loan-2", 0, other))
+		assert.NoError(t, store.Append("loan-1", 1, batch...))
+
+		got, err := store.Load("loan-1")
+		assert.NoError(t, err)
+		assert.Equal(t, append([]domain.Event{first}, batch...), got)
+
+		all, err := store.LoadAll()
+		assert.NoError(t, err)
+		assert.Equal(t, []domain.Event{first, other, batch[0], batch[1]}, all)
+	})
+
+	t.Run("Empty stream", func(t *testing.T) {
+		got, err := newEventStore(t).Load("non-existent")
+		assert.NoError(t, err)
+		assert.NotNil(t, got, "Load must return an empty slice rather than nil")
+		assert.Empty(t, got)
+	})
+
+	t.Run("Error when appending at a stale version", func(t *testing.T) {
+		store := newEventStore(t)
+		assert.NoError(t, store.Append("loan-1", 0, proposed(t, "loan-1", 0)))
+
+		err := store.Append("loan-1", 0, proposed(t, "loan-1", 1))
+		assert.ErrorIs(t, err, domain.ErrVersionConflict)
+		var conflict *domain.VersionConflictError
+		if assert.True(t, errors.As(err, &conflict)) {
+			assert.Equal(t, int64(0), conflict.Expected)
+			assert.Equal(t, int64(1), conflict.Actual)
+		}
+
+		got, _ := store.Load("loan-1")
+		assert.Len(t, got, 1, "a rejected append must not store anything")
+	})
+
+	t.Run("Error when events do not belong to the stream", func(t *testing.T) {
+		store := newEventStore(t)
+		assert.ErrorIs(t, store.Append("loan-1", 0, proposed(t, "loan-2", 0)), domain.ErrInvalidEvent)
+		assert.ErrorIs(t, store.Append("loan-1", 0, NewEvent(t, "loan-1", 2, domain.EventLoanApproved, domain.LoanApprovedData{}, 0)), domain.ErrInvalidEvent)
+		assert.ErrorIs(t, store.Append("loan-1", 0), domain.ErrInvalidEvent)
+
+		all, err := store.LoadAll()
+		assert.NoError(t, err)
+		assert.Empty(t, all)
+	})
+
+	t.Run("Events handed out cannot change the stream", func(t *testing.T) {
+		store := newEventStore(t)
+		event := proposed(t, "loan-1", 0)
+		want := domain.CloneEvents([]domain.Event{event})
+		assert.NoError(t, store.Append("loan-1", 0, event))
+
+		event.Data[0] = 'x'
+		found, _ := store.Load("loan-1")
+		found[0].Data[0] = 'x'
+		all, _ := store.LoadAll()
+		all[0].Type = "tampered"
+
+		got, err := store.Load("loan-1")
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("Concurrent appends to one stream", func(t *testing.T) {
+		store := newEventStore(t)
+		assert.NoError(t, store.Append("loan-1", 0, proposed(t, "loan-1", 0)))
+
+		// Every writer races to append version 2, exactly one may win
+		const writers = 20
+		var (
+			wg        sync.WaitGroup
+			succeeded atomic.Int32
+		)
+		for i := 0; i < writers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				event := NewEvent(t, "loan-1", 2, domain.EventAgreementGenerated, domain.AgreementGeneratedData{LetterURL: fmt.Sprintf("letter-%d", i)}, i)
+				err := store.Append("loan-1", 1, event)
+				if err == nil {
+					succeeded.Add(1)
+					return
+				}
+				assert.ErrorIs(t, err, domain.ErrVersionConflict)
+			}(i)
+		}
+		wg.Wait()
+
+		assert.Equal(t, int32(1), succeeded.Load())
+		got, err := store.Load("loan-1")
+		assert.NoError(t, err)
+		assert.Len(t, got, 2)
+	})
+}
@@ -0,0 +1,151 @@
+package loantest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	"github.com/hinha/los-technical/internal/pkg/money"
+)
+
+// InvestorRepositoryFactory returns an empty investor repository; it is called once per sub-test
+type InvestorRepositoryFactory func(t *testing.T) domain.InvestorRepository
+
+// NewInvestor builds a PENDING fixture investor registered the given number of minutes after the base time
+func NewInvestor(id string, minute int) *domain.InvestorProfile {
+	createdAt := baseTime.Add(time.Duration(minute) * time.Minute)
+	return &domain.InvestorProfile{
+		ID:        id,
+		Name:      "Investor " + id,
+		Email:     id + "@example.com",
+		KYCStatus: domain.KYCPending,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+}
+
+// RunInvestorRepositoryContract runs every contract check against investor repositories built by newRepository
+func RunInvestorRepositoryContract(t *testing.T, newRepository InvestorRepositoryFactory) {
+	t.Run("Save and FindByID", func(t *testing.T) {
+		repo := newRepository(t)
+		reviewedAt := baseTime.Add(time.Hour)
+		perLoan := money.MustNew("5000000.50", money.DefaultCurrency)
+		total := money.MustNew("1000", "USD")
+		want := NewInvestor("investor-1", 0)
+		want.Phone = "+628123"
+		want.Locale = "id"
+		want.KYCStatus = domain.KYCVerified
+		want.KYCReviewedAt = &reviewedAt
+		want.Limits = domain.InvestmentLimits{PerLoan: &perLoan, Total: &total}
+		assert.NoError(t, repo.Save(want))
+
+		got, err := repo.FindByID("investor-1")
+		assert.NoError(t, err)
+		assertInvestor(t, want, got)
+	})
+
+	t.Run("Save without limits", func(t *testing.T) {
+		repo := newRepository(t)
+		want := NewInvestor("investor-1", 0)
+		assert.NoError(t, repo.Save(want))
+
+		got, err := repo.FindByID("investor-1")
+		assert.NoError(t, err)
+		assertInvestor(t, want, got)
+	})
+
+	t.Run("Save duplicate ID", func(t *testing.T) {
+		repo := newRepository(t)
+		assert.NoError(t, repo.Save(NewInvestor("investor-1", 0)))
+		err := repo.Save(NewInvestor("investor-1", 1))
+		assert.True(t, errors.Is(err, domain.ErrInvestorAlreadyExists))
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		_, err := newRepository(t).FindByID("non-existent")
+		assert.True(t, errors.Is(err, domain.ErrInvestorNotFound))
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repo := newRepository(t)
+		assert.NoError(t, repo.Save(NewInvestor("investor-1", 0)))
+
+		want := NewInvestor("investor-1", 0)
+		reviewedAt := baseTime.Add(time.Hour)
+		total := money.MustNew("20000", money.DefaultCurrency)
+		want.Email = "new@example.com"
+		want.KYCStatus = domain.KYCRejected
+		want.KYCReviewedAt = &reviewedAt
+		want.Limits.Total = &total
+		want.UpdatedAt = reviewedAt
+		assert.NoError(t, repo.Update(want))
+
+		got, err := repo.FindByID("investor-1")
+		assert.NoError(t, err)
+		assertInvestor(t, want, got)
+	})
+
+	t.Run("Update missing investor", func(t *testing.T) {
+		err := newRepository(t).Update(NewInvestor("investor-1", 0))
+		assert.True(t, errors.Is(err, domain.ErrInvestorNotFound), "Update must not create investors")
+	})
+
+	t.Run("Investors handed out cannot change the repository", func(t *testing.T) {
+		repo := newRepository(t)
+		limit := money.MustNew("1000", money.DefaultCurrency)
+		want := NewInvestor("investor-1", 0)
+		want.Limits.PerLoan = &limit
+		assert.NoError(t, repo.Save(want))
+		want.Limits.PerLoan.Amount = money.MustParse("1")
+
+		found, _ := repo.FindByID("investor-1")
+		found.Limits.PerLoan.Amount = money.MustParse("2")
+
+		got, err := repo.FindByID("investor-1")
+		assert.NoError(t, err)
+		assert.Equal(t, "1000", got.Limits.PerLoan.Amount.String())
+	})
+
+	t.Run("Concurrent saves", func(t *testing.T) {
+		repo := newRepository(t)
+		var wg sync.WaitGroup
+		for i := range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, repo.Save(NewInvestor(fmt.Sprintf("investor-%d", i), i)))
+			}()
+		}
+		wg.Wait()
+
+		for i := range 10 {
+			_, err := repo.FindByID(fmt.Sprintf("investor-%d", i))
+			assert.NoError(t, err)
+		}
+	})
+}
+
+// assertInvestor compares investors by the instants of their timestamps, whatever their location
+func assertInvestor(t *testing.T, want, got *domain.InvestorProfile) {
+	t.Helper()
+	if !assert.NotNil(t, got) {
+		return
+	}
+	assert.True(t, want.CreatedAt.Equal(got.CreatedAt), "CreatedAt: want %s, got %s", want.CreatedAt, got.CreatedAt)
+	assert.True(t, want.UpdatedAt.Equal(got.UpdatedAt), "UpdatedAt: want %s, got %s", want.UpdatedAt, got.UpdatedAt)
+	if want.KYCReviewedAt == nil {
+		assert.Nil(t, got.KYCReviewedAt)
+	} else if assert.NotNil(t, got.KYCReviewedAt) {
+		assert.True(t, want.KYCReviewedAt.Equal(*got.KYCReviewedAt))
+	}
+
+	wantCopy, gotCopy := *want, *got
+	wantCopy.CreatedAt, wantCopy.UpdatedAt, wantCopy.KYCReviewedAt = time.Time{}, time.Time{}, nil
+	gotCopy.CreatedAt, gotCopy.UpdatedAt, gotCopy.KYCReviewedAt = time.Time{}, time.Time{}, nil
+	assert.Equal(t, wantCopy, gotCopy)
+}
@@ -0,0 +1,160 @@
+package loantest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+)
+
+// PreferenceStoreFactory returns an empty preference store; it is called once per sub-test
+type PreferenceStoreFactory func(t *testing.T) domain.PreferenceStore
+
+// InboxFactory returns an empty inbox; it is called once per sub-test
+type InboxFactory func(t *testing.T) domain.Inbox
+
+// NewInboxItem builds a fixture item for the recipient created the given number of minutes after the base time
+func NewInboxItem(id, recipientID string, minute int) domain.InboxItem {
+	return domain.InboxItem{
+		ID:          id,
+		RecipientID: recipientID,
+		Kind:        domain.NotificationLoanApproved,
+		LoanID:      "loan-1",
+		Subject:     "Subject of " + id,
+		Body:        "Body of " + id,
+		CreatedAt:   baseTime.Add(time.Duration(minute) * time.Minute),
+	}
+}
+
+// RunPreferenceStoreContract runs every contract check against preference stores built by newStore
+func RunPreferenceStoreContract(t *testing.T, newStore PreferenceStoreFactory) {
+	t.Run("Save and Find", func(t *testing.T) {
+		store := newStore(t)
+		want := domain.NotificationPreferences{
+			RecipientID: "investor-1",
+			Email:       "one@example.com",
+			Phone:       "+628123",
+			Locale:      "id",
+			Channels:    []domain.Channel{domain.ChannelSMS, domain.ChannelInApp},
+			Muted:       []domain.NotificationKind{domain.NotificationRepaymentDistributed},
+		}
+		assert.NoError(t, store.Save(want))
+
+		got, err := store.Find("investor-1")
+		assert.NoError(t, err)
+		assert.Equal(t, &want, got)
+	})
+
+	t.Run("Save replaces", func(t *testing.T) {
+		store := newStore(t)
+		assert.NoError(t, store.Save(domain.NotificationPreferences{RecipientID: "investor-1", Channels: []domain.Channel{domain.ChannelSMS}, Phone: "+628123"}))
+		want := domain.DefaultPreferences("investor-1")
+		assert.NoError(t, store.Save(want))
+
+		got, err := store.Find("investor-1")
+		assert.NoError(t, err)
+		assert.Equal(t, &want, got)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		_, err := newStore(t).Find("non-existent")
+		assert.True(t, errors.Is(err, domain.ErrPreferencesNotFound))
+	})
+
+	t.Run("Preferences handed out cannot change the store", func(t *testing.T) {
+		store := newStore(t)
+		want := domain.DefaultPreferences("investor-1")
+		assert.NoError(t, store.Save(want))
+
+		found, _ := store.Find("investor-1")
+		found.Channels[0] = domain.ChannelSMS
+
+		got, err := store.Find("investor-1")
+		assert.NoError(t, err)
+		assert.Equal(t, &want, got)
+	})
+}
+
+// RunInboxContract runs every contract check against inboxes built by newInbox
+func RunInboxContract(t *testing.T, newInbox InboxFactory) {
+	t.Run("Add and FindByRecipient newest first", func(t *testing.T) {
+		inbox := newInbox(t)
+		first := NewInboxItem("item-1", "borrower-1", 0)
+		second := NewInboxItem("item-2", "borrower-1", 2)
+		third := NewInboxItem("item-3", "borrower-1", 1)
+		assert.NoError(t, inbox.Add(first))
+		assert.NoError(t, inbox.Add(NewInboxItem("item-4", "borrower-2", 3)))
+		assert.NoError(t, inbox.Add(second))
+		assert.NoError(t, inbox.Add(third))
+
+		got, err := inbox.FindByRecipient("borrower-1")
+		assert.NoError(t, err)
+		assert.Equal(t, []domain.InboxItem{second, third, first}, got)
+	})
+
+	t.Run("Add is idempotent", func(t *testing.T) {
+		inbox := newInbox(t)
+		item := NewInboxItem("item-1", "borrower-1", 0)
+		assert.NoError(t, inbox.Add(item))
+		again := item
+		again.Subject = "Sent again"
+		assert.NoError(t, inbox.Add(again))
+
+		got, err := inbox.FindByRecipient("borrower-1")
+		assert.NoError(t, err)
+		assert.Equal(t, []domain.InboxItem{item}, got)
+	})
+
+	t.Run("Empty inbox", func(t *testing.T) {
+		got, err := newInbox(t).FindByRecipient("non-existent")
+		assert.NoError(t, err)
+		assert.NotNil(t, got, "FindByRecipient must return an empty slice rather than nil")
+		assert.Empty(t, got)
+	})
+
+	t.Run("MarkRead keeps the first read time", func(t *testing.T) {
+		inbox := newInbox(t)
+		assert.NoError(t, inbox.Add(NewInboxItem("item-1", "borrower-1", 0)))
+		readAt := baseTime.Add(time.Hour)
+		assert.NoError(t, inbox.MarkRead("borrower-1", "item-1", readAt))
+		assert.NoError(t, inbox.MarkRead("borrower-1", "item-1", readAt.Add(time.Hour)))
+
+		got, err := inbox.FindByRecipient("borrower-1")
+		assert.NoError(t, err)
+		if assert.Len(t, got, 1) && assert.NotNil(t, got[0].ReadAt) {
+			assert.True(t, readAt.Equal(*got[0].ReadAt))
+		}
+	})
+
+	t.Run("MarkRead of another recipient's item", func(t *testing.T) {
+		inbox := newInbox(t)
+		assert.NoError(t, inbox.Add(NewInboxItem("item-1", "borrower-1", 0)))
+
+		err := inbox.MarkRead("borrower-2", "item-1", baseTime)
+		assert.True(t, errors.Is(err, domain.ErrInboxItemNotFound))
+	})
+
+	t.Run("Concurrent adds", func(t *testing.T) {
+		inbox := newInbox(t)
+		const writers = 20
+
+		var wg sync.WaitGroup
+		for i := 0; i < writers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				assert.NoError(t, inbox.Add(NewInboxItem(fmt.Sprintf("item-%d", i), "borrower-1", i)))
+			}(i)
+		}
+		wg.Wait()
+
+		got, err := inbox.FindByRecipient("borrower-1")
+		assert.NoError(t, err)
+		assert.Len(t, got, writers)
+	})
+}
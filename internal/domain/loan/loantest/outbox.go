@@ -0,0 +1,160 @@
+package loantest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	"github.com/hinha/los-technical/internal/pkg/money"
+)
+
+// OutboxStore is an event store that keeps the outbox its commits enqueue
+type OutboxStore interface {
+	domain.EventStore
+	domain.Outbox
+}
+
+// OutboxStoreFactory returns an empty outbox store; it is called once per sub-test
+type OutboxStoreFactory func(t *testing.T) OutboxStore
+
+// NewOutboxMessage builds a pending agreement notification created the given number of minutes after the base time
+func NewOutboxMessage(id, loanID string, minute int) domain.OutboxMessage {
+	createdAt := baseTime.Add(time.Duration(minute) * time.Minute)
+	return domain.OutboxMessage{
+		Notification: domain.Notification{
+			ID:     id,
+			Kind:   domain.NotificationAgreement,
+			LoanID: loanID,
+			Recipient: domain.Recipient{
+				ID:    "investor-" + id,
+				Role:  domain.RoleInvestor,
+				Email: id + "@example.com",
+			},
+			Agreement: &domain.AgreementEmail{
+				Email:        id + "@example.com",
+				LoanID:       loanID,
+				AgreementURL: "http://example.com/agreement/" + loanID,
+			},
+		},
+		Status:        domain.OutboxPending,
+		NextAttemptAt: createdAt,
+		CreatedAt:     createdAt,
+	}
+}
+
+// RunOutboxContract runs every outbox contract check against stores built by newStore
+func RunOutboxContract(t *testing.T, newStore OutboxStoreFactory) {
+	proposed := func(t *testing.T, loanID string) domain.Event {
+		return NewEvent(t, loanID, 1, domain.EventLoanProposed, domain.LoanProposedData{BorrowerID: "borrower-" + loanID}, 0)
+	}
+
+	t.Run("Commit enqueues messages with the events", func(t *testing.T) {
+		store := newStore(t)
+		first := NewOutboxMessage("message-1", "loan-1", 0)
+		second := NewOutboxMessage("message-2", "loan-1", 1)
+
+		assert.NoError(t, store.Commit("loan-1", 0, []domain.Event{proposed(t, "loan-1")}, []domain.OutboxMessage{first, second}))
+
+		events, err := store.Load("loan-1")
+		assert.NoError(t, err)
+		assert.Len(t, events, 1)
+
+		due, err := store.FindDue(baseTime.Add(time.Hour), 10)
+		assert.NoError(t, err)
+		assert.Equal(t, []domain.OutboxMessage{first, second}, due)
+	})
+
+	t.Run("A rejected commit enqueues nothing", func(t *testing.T) {
+		store := newStore(t)
+		assert.NoError(t, store.Append("loan-1", 0, proposed(t, "loan-1")))
+
+		err := store.Commit("loan-1", 0, []domain.Event{proposed(t, "loan-1")}, []domain.OutboxMessage{NewOutboxMessage("message-1", "loan-1", 0)})
+		assert.ErrorIs(t, err, domain.ErrVersionConflict)
+
+		err = store.Commit("loan-2", 0, []domain.Event{proposed(t, "loan-2")}, []domain.OutboxMessage{NewOutboxMessage("message-1", "loan-1", 0)})
+		assert.ErrorIs(t, err, domain.ErrInvalidEvent)
+
+		pending, err := store.FindByStatus(domain.OutboxPending)
+		assert.NoError(t, err)
+		assert.Empty(t, pending)
+		events, _ := store.Load("loan-2")
+		assert.Empty(t, events, "the events of a rejected commit must not be stored either")
+	})
+
+	t.Run("FindDue skips messages that are not due and honours the limit", func(t *testing.T) {
+		store := newStore(t)
+		now := baseTime.Add(10 * time.Minute)
+		later := NewOutboxMessage("message-later", "loan-1", 0)
+		later.NextAttemptAt = now.Add(time.Minute)
+		sent := NewOutboxMessage("message-sent", "loan-1", 1)
+		sent.MarkSent(now)
+		messages := []domain.OutboxMessage{
+			later,
+			sent,
+			NewOutboxMessage("message-1", "loan-1", 2),
+			NewOutboxMessage("message-2", "loan-1", 3),
+			NewOutboxMessage("message-3", "loan-1", 4),
+		}
+		assert.NoError(t, store.Commit("loan-1", 0, []domain.Event{proposed(t, "loan-1")}, messages))
+
+		due, err := store.FindDue(now, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, messages[2:4], due)
+
+		all, err := store.FindDue(now.Add(time.Minute), 10)
+		assert.NoError(t, err)
+		assert.Len(t, all, 4)
+	})
+
+	t.Run("Update stores the delivery state", func(t *testing.T) {
+		store := newStore(t)
+		message := NewOutboxMessage("message-1", "loan-1", 0)
+		refund := NewOutboxMessage("message-2", "loan-1", 1)
+		amount := money.MustNew("300", money.DefaultCurrency)
+		refund.Kind, refund.Amount, refund.Reason = domain.NotificationRefund, &amount, domain.ReasonFundingExpired
+		assert.NoError(t, store.Commit("loan-1", 0, []domain.Event{proposed(t, "loan-1")}, []domain.OutboxMessage{message, refund}))
+
+		message.MarkFailed(errors.New("smtp down"), baseTime, 1, time.Minute)
+		assert.NoError(t, store.Update(message))
+		refund.MarkSent(baseTime)
+		assert.NoError(t, store.Update(refund))
+
+		dead, err := store.FindByStatus(domain.OutboxDead)
+		assert.NoError(t, err)
+		assert.Equal(t, []domain.OutboxMessage{message}, dead)
+		sent, err := store.FindByStatus(domain.OutboxSent)
+		assert.NoError(t, err)
+		assert.Equal(t, []domain.OutboxMessage{refund}, sent)
+
+		due, err := store.FindDue(baseTime.Add(time.Hour), 10)
+		assert.NoError(t, err)
+		assert.Empty(t, due)
+	})
+
+	t.Run("Error when updating an unknown message", func(t *testing.T) {
+		err := newStore(t).Update(NewOutboxMessage("non-existent", "loan-1", 0))
+		assert.ErrorIs(t, err, domain.ErrOutboxMessageNotFound)
+	})
+
+	t.Run("Messages handed out cannot change the outbox", func(t *testing.T) {
+		store := newStore(t)
+		message := NewOutboxMessage("message-1", "loan-1", 0)
+		amount := money.MustNew("300", money.DefaultCurrency)
+		message.Amount = &amount
+		assert.NoError(t, store.Commit("loan-1", 0, []domain.Event{proposed(t, "loan-1")}, []domain.OutboxMessage{message}))
+
+		amount.Currency = "USD"
+		due, _ := store.FindDue(baseTime, 10)
+		due[0].Amount.Currency = "USD"
+		due[0].Status = domain.OutboxDead
+
+		pending, err := store.FindByStatus(domain.OutboxPending)
+		assert.NoError(t, err)
+		if assert.Len(t, pending, 1) {
+			assert.Equal(t, money.DefaultCurrency, pending[0].Amount.Currency)
+		}
+	})
+}
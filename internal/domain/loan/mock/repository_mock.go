@@ -6,6 +6,7 @@ package provider
 
 import (
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	loan "github.com/hinha/los-technical/internal/domain/loan"
@@ -35,18 +36,18 @@ func (m *MockLoanRepository) EXPECT() *MockLoanRepositoryMockRecorder {
 }
 
 // FindAll mocks base method.
-func (m *MockLoanRepository) FindAll(page, limit int) ([]*loan.Loan, error) {
+func (m *MockLoanRepository) FindAll(filter loan.LoanFilter, page, limit int) ([]*loan.Loan, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindAll", page, limit)
+	ret := m.ctrl.Call(m, "FindAll", filter, page, limit)
 	ret0, _ := ret[0].([]*loan.Loan)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // FindAll indicates an expected call of FindAll.
-func (mr *MockLoanRepositoryMockRecorder) FindAll(page, limit interface{}) *gomock.Call {
+func (mr *MockLoanRepositoryMockRecorder) FindAll(filter, page, limit interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAll", reflect.TypeOf((*MockLoanRepository)(nil).FindAll), page, limit)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAll", reflect.TypeOf((*MockLoanRepository)(nil).FindAll), filter, page, limit)
 }
 
 // FindByBorrowerID mocks base method.
@@ -79,6 +80,21 @@ func (mr *MockLoanRepositoryMockRecorder) FindByID(id interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByID", reflect.TypeOf((*MockLoanRepository)(nil).FindByID), id)
 }
 
+// FindByInvestorID mocks base method.
+func (m *MockLoanRepository) FindByInvestorID(investorID string) ([]*loan.Loan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByInvestorID", investorID)
+	ret0, _ := ret[0].([]*loan.Loan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByInvestorID indicates an expected call of FindByInvestorID.
+func (mr *MockLoanRepositoryMockRecorder) FindByInvestorID(investorID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByInvestorID", reflect.TypeOf((*MockLoanRepository)(nil).FindByInvestorID), investorID)
+}
+
 // FindByState mocks base method.
 func (m *MockLoanRepository) FindByState(state loan.LoanState) ([]*loan.Loan, error) {
 	m.ctrl.T.Helper()
@@ -121,3 +137,569 @@ func (mr *MockLoanRepositoryMockRecorder) Update(loan interface{}) *gomock.Call
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockLoanRepository)(nil).Update), loan)
 }
+
+// MockInvestorRepository is a mock of InvestorRepository interface.
+type MockInvestorRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockInvestorRepositoryMockRecorder
+}
+
+// MockInvestorRepositoryMockRecorder is the mock recorder for MockInvestorRepository.
+type MockInvestorRepositoryMockRecorder struct {
+	mock *MockInvestorRepository
+}
+
+// NewMockInvestorRepository creates a new mock instance.
+func NewMockInvestorRepository(ctrl *gomock.Controller) *MockInvestorRepository {
+	mock := &MockInvestorRepository{ctrl: ctrl}
+	mock.recorder = &MockInvestorRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInvestorRepository) EXPECT() *MockInvestorRepositoryMockRecorder {
+	return m.recorder
+}
+
+// FindByID mocks base method.
+func (m *MockInvestorRepository) FindByID(id string) (*loan.InvestorProfile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByID", id)
+	ret0, _ := ret[0].(*loan.InvestorProfile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByID indicates an expected call of FindByID.
+func (mr *MockInvestorRepositoryMockRecorder) FindByID(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByID", reflect.TypeOf((*MockInvestorRepository)(nil).FindByID), id)
+}
+
+// Save mocks base method.
+func (m *MockInvestorRepository) Save(investor *loan.InvestorProfile) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", investor)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockInvestorRepositoryMockRecorder) Save(investor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockInvestorRepository)(nil).Save), investor)
+}
+
+// Update mocks base method.
+func (m *MockInvestorRepository) Update(investor *loan.InvestorProfile) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", investor)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockInvestorRepositoryMockRecorder) Update(investor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockInvestorRepository)(nil).Update), investor)
+}
+
+// MockBorrowerRepository is a mock of BorrowerRepository interface.
+type MockBorrowerRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockBorrowerRepositoryMockRecorder
+}
+
+// MockBorrowerRepositoryMockRecorder is the mock recorder for MockBorrowerRepository.
+type MockBorrowerRepositoryMockRecorder struct {
+	mock *MockBorrowerRepository
+}
+
+// NewMockBorrowerRepository creates a new mock instance.
+func NewMockBorrowerRepository(ctrl *gomock.Controller) *MockBorrowerRepository {
+	mock := &MockBorrowerRepository{ctrl: ctrl}
+	mock.recorder = &MockBorrowerRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBorrowerRepository) EXPECT() *MockBorrowerRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockBorrowerRepository) Delete(id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockBorrowerRepositoryMockRecorder) Delete(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockBorrowerRepository)(nil).Delete), id)
+}
+
+// FindAll mocks base method.
+func (m *MockBorrowerRepository) FindAll(page, limit int) ([]*loan.Borrower, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAll", page, limit)
+	ret0, _ := ret[0].([]*loan.Borrower)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAll indicates an expected call of FindAll.
+func (mr *MockBorrowerRepositoryMockRecorder) FindAll(page, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAll", reflect.TypeOf((*MockBorrowerRepository)(nil).FindAll), page, limit)
+}
+
+// FindByID mocks base method.
+func (m *MockBorrowerRepository) FindByID(id string) (*loan.Borrower, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByID", id)
+	ret0, _ := ret[0].(*loan.Borrower)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByID indicates an expected call of FindByID.
+func (mr *MockBorrowerRepositoryMockRecorder) FindByID(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByID", reflect.TypeOf((*MockBorrowerRepository)(nil).FindByID), id)
+}
+
+// Save mocks base method.
+func (m *MockBorrowerRepository) Save(borrower *loan.Borrower) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", borrower)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockBorrowerRepositoryMockRecorder) Save(borrower interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockBorrowerRepository)(nil).Save), borrower)
+}
+
+// Update mocks base method.
+func (m *MockBorrowerRepository) Update(borrower *loan.Borrower) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", borrower)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockBorrowerRepositoryMockRecorder) Update(borrower interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockBorrowerRepository)(nil).Update), borrower)
+}
+
+// MockAuditLog is a mock of AuditLog interface.
+type MockAuditLog struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditLogMockRecorder
+}
+
+// MockAuditLogMockRecorder is the mock recorder for MockAuditLog.
+type MockAuditLogMockRecorder struct {
+	mock *MockAuditLog
+}
+
+// NewMockAuditLog creates a new mock instance.
+func NewMockAuditLog(ctrl *gomock.Controller) *MockAuditLog {
+	mock := &MockAuditLog{ctrl: ctrl}
+	mock.recorder = &MockAuditLogMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditLog) EXPECT() *MockAuditLogMockRecorder {
+	return m.recorder
+}
+
+// Append mocks base method.
+func (m *MockAuditLog) Append(entry loan.AuditEntry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Append", entry)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Append indicates an expected call of Append.
+func (mr *MockAuditLogMockRecorder) Append(entry interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Append", reflect.TypeOf((*MockAuditLog)(nil).Append), entry)
+}
+
+// FindByLoanID mocks base method.
+func (m *MockAuditLog) FindByLoanID(loanID string) ([]loan.AuditEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByLoanID", loanID)
+	ret0, _ := ret[0].([]loan.AuditEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByLoanID indicates an expected call of FindByLoanID.
+func (mr *MockAuditLogMockRecorder) FindByLoanID(loanID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByLoanID", reflect.TypeOf((*MockAuditLog)(nil).FindByLoanID), loanID)
+}
+
+// MockEventStore is a mock of EventStore interface.
+type MockEventStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventStoreMockRecorder
+}
+
+// MockEventStoreMockRecorder is the mock recorder for MockEventStore.
+type MockEventStoreMockRecorder struct {
+	mock *MockEventStore
+}
+
+// NewMockEventStore creates a new mock instance.
+func NewMockEventStore(ctrl *gomock.Controller) *MockEventStore {
+	mock := &MockEventStore{ctrl: ctrl}
+	mock.recorder = &MockEventStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEventStore) EXPECT() *MockEventStoreMockRecorder {
+	return m.recorder
+}
+
+// Append mocks base method.
+func (m *MockEventStore) Append(loanID string, expectedVersion int64, events ...loan.Event) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{loanID, expectedVersion}
+	for _, a := range events {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Append", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Append indicates an expected call of Append.
+func (mr *MockEventStoreMockRecorder) Append(loanID, expectedVersion interface{}, events ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{loanID, expectedVersion}, events...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Append", reflect.TypeOf((*MockEventStore)(nil).Append), varargs...)
+}
+
+// Commit mocks base method.
+func (m *MockEventStore) Commit(loanID string, expectedVersion int64, events []loan.Event, messages []loan.OutboxMessage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Commit", loanID, expectedVersion, events, messages)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Commit indicates an expected call of Commit.
+func (mr *MockEventStoreMockRecorder) Commit(loanID, expectedVersion, events, messages interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Commit", reflect.TypeOf((*MockEventStore)(nil).Commit), loanID, expectedVersion, events, messages)
+}
+
+// Load mocks base method.
+func (m *MockEventStore) Load(loanID string) ([]loan.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Load", loanID)
+	ret0, _ := ret[0].([]loan.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Load indicates an expected call of Load.
+func (mr *MockEventStoreMockRecorder) Load(loanID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Load", reflect.TypeOf((*MockEventStore)(nil).Load), loanID)
+}
+
+// LoadAll mocks base method.
+func (m *MockEventStore) LoadAll() ([]loan.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadAll")
+	ret0, _ := ret[0].([]loan.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadAll indicates an expected call of LoadAll.
+func (mr *MockEventStoreMockRecorder) LoadAll() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadAll", reflect.TypeOf((*MockEventStore)(nil).LoadAll))
+}
+
+// MockOutbox is a mock of Outbox interface.
+type MockOutbox struct {
+	ctrl     *gomock.Controller
+	recorder *MockOutboxMockRecorder
+}
+
+// MockOutboxMockRecorder is the mock recorder for MockOutbox.
+type MockOutboxMockRecorder struct {
+	mock *MockOutbox
+}
+
+// NewMockOutbox creates a new mock instance.
+func NewMockOutbox(ctrl *gomock.Controller) *MockOutbox {
+	mock := &MockOutbox{ctrl: ctrl}
+	mock.recorder = &MockOutboxMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOutbox) EXPECT() *MockOutboxMockRecorder {
+	return m.recorder
+}
+
+// FindByStatus mocks base method.
+func (m *MockOutbox) FindByStatus(status loan.OutboxStatus) ([]loan.OutboxMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByStatus", status)
+	ret0, _ := ret[0].([]loan.OutboxMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByStatus indicates an expected call of FindByStatus.
+func (mr *MockOutboxMockRecorder) FindByStatus(status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByStatus", reflect.TypeOf((*MockOutbox)(nil).FindByStatus), status)
+}
+
+// FindDue mocks base method.
+func (m *MockOutbox) FindDue(now time.Time, limit int) ([]loan.OutboxMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindDue", now, limit)
+	ret0, _ := ret[0].([]loan.OutboxMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindDue indicates an expected call of FindDue.
+func (mr *MockOutboxMockRecorder) FindDue(now, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindDue", reflect.TypeOf((*MockOutbox)(nil).FindDue), now, limit)
+}
+
+// Update mocks base method.
+func (m *MockOutbox) Update(message loan.OutboxMessage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", message)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockOutboxMockRecorder) Update(message interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockOutbox)(nil).Update), message)
+}
+
+// MockPreferenceStore is a mock of PreferenceStore interface.
+type MockPreferenceStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockPreferenceStoreMockRecorder
+}
+
+// MockPreferenceStoreMockRecorder is the mock recorder for MockPreferenceStore.
+type MockPreferenceStoreMockRecorder struct {
+	mock *MockPreferenceStore
+}
+
+// NewMockPreferenceStore creates a new mock instance.
+func NewMockPreferenceStore(ctrl *gomock.Controller) *MockPreferenceStore {
+	mock := &MockPreferenceStore{ctrl: ctrl}
+	mock.recorder = &MockPreferenceStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPreferenceStore) EXPECT() *MockPreferenceStoreMockRecorder {
+	return m.recorder
+}
+
+// Find mocks base method.
+func (m *MockPreferenceStore) Find(recipientID string) (*loan.NotificationPreferences, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Find", recipientID)
+	ret0, _ := ret[0].(*loan.NotificationPreferences)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Find indicates an expected call of Find.
+func (mr *MockPreferenceStoreMockRecorder) Find(recipientID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*MockPreferenceStore)(nil).Find), recipientID)
+}
+
+// Save mocks base method.
+func (m *MockPreferenceStore) Save(preferences loan.NotificationPreferences) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", preferences)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockPreferenceStoreMockRecorder) Save(preferences interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockPreferenceStore)(nil).Save), preferences)
+}
+
+// MockDocumentStore is a mock of DocumentStore interface.
+type MockDocumentStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockDocumentStoreMockRecorder
+}
+
+// MockDocumentStoreMockRecorder is the mock recorder for MockDocumentStore.
+type MockDocumentStoreMockRecorder struct {
+	mock *MockDocumentStore
+}
+
+// NewMockDocumentStore creates a new mock instance.
+func NewMockDocumentStore(ctrl *gomock.Controller) *MockDocumentStore {
+	mock := &MockDocumentStore{ctrl: ctrl}
+	mock.recorder = &MockDocumentStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDocumentStore) EXPECT() *MockDocumentStoreMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockDocumentStore) Delete(id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockDocumentStoreMockRecorder) Delete(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockDocumentStore)(nil).Delete), id)
+}
+
+// Find mocks base method.
+func (m *MockDocumentStore) Find(id string) (*loan.Document, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Find", id)
+	ret0, _ := ret[0].(*loan.Document)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Find indicates an expected call of Find.
+func (mr *MockDocumentStoreMockRecorder) Find(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*MockDocumentStore)(nil).Find), id)
+}
+
+// Read mocks base method.
+func (m *MockDocumentStore) Read(id string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Read", id)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Read indicates an expected call of Read.
+func (mr *MockDocumentStoreMockRecorder) Read(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Read", reflect.TypeOf((*MockDocumentStore)(nil).Read), id)
+}
+
+// Save mocks base method.
+func (m *MockDocumentStore) Save(document loan.Document, content []byte) (*loan.Document, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", document, content)
+	ret0, _ := ret[0].(*loan.Document)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockDocumentStoreMockRecorder) Save(document, content interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockDocumentStore)(nil).Save), document, content)
+}
+
+// MockInbox is a mock of Inbox interface.
+type MockInbox struct {
+	ctrl     *gomock.Controller
+	recorder *MockInboxMockRecorder
+}
+
+// MockInboxMockRecorder is the mock recorder for MockInbox.
+type MockInboxMockRecorder struct {
+	mock *MockInbox
+}
+
+// NewMockInbox creates a new mock instance.
+func NewMockInbox(ctrl *gomock.Controller) *MockInbox {
+	mock := &MockInbox{ctrl: ctrl}
+	mock.recorder = &MockInboxMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInbox) EXPECT() *MockInboxMockRecorder {
+	return m.recorder
+}
+
+// Add mocks base method.
+func (m *MockInbox) Add(item loan.InboxItem) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Add", item)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Add indicates an expected call of Add.
+func (mr *MockInboxMockRecorder) Add(item interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockInbox)(nil).Add), item)
+}
+
+// FindByRecipient mocks base method.
+func (m *MockInbox) FindByRecipient(recipientID string) ([]loan.InboxItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByRecipient", recipientID)
+	ret0, _ := ret[0].([]loan.InboxItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByRecipient indicates an expected call of FindByRecipient.
+func (mr *MockInboxMockRecorder) FindByRecipient(recipientID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByRecipient", reflect.TypeOf((*MockInbox)(nil).FindByRecipient), recipientID)
+}
+
+// MarkRead mocks base method.
+func (m *MockInbox) MarkRead(recipientID, id string, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkRead", recipientID, id, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkRead indicates an expected call of MarkRead.
+func (mr *MockInboxMockRecorder) MarkRead(recipientID, id, at interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkRead", reflect.TypeOf((*MockInbox)(nil).MarkRead), recipientID, id, at)
+}
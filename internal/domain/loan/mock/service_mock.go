@@ -9,43 +9,574 @@ import (
 
 	gomock "github.com/golang/mock/gomock"
 	loan "github.com/hinha/los-technical/internal/domain/loan"
+	money "github.com/hinha/los-technical/internal/pkg/money"
 )
 
-// MockEmailSender is a mock of EmailSender interface.
-type MockEmailSender struct {
+// MockNotifier is a mock of Notifier interface.
+type MockNotifier struct {
 	ctrl     *gomock.Controller
-	recorder *MockEmailSenderMockRecorder
+	recorder *MockNotifierMockRecorder
 }
 
-// MockEmailSenderMockRecorder is the mock recorder for MockEmailSender.
-type MockEmailSenderMockRecorder struct {
-	mock *MockEmailSender
+// MockNotifierMockRecorder is the mock recorder for MockNotifier.
+type MockNotifierMockRecorder struct {
+	mock *MockNotifier
 }
 
-// NewMockEmailSender creates a new mock instance.
-func NewMockEmailSender(ctrl *gomock.Controller) *MockEmailSender {
-	mock := &MockEmailSender{ctrl: ctrl}
-	mock.recorder = &MockEmailSenderMockRecorder{mock}
+// NewMockNotifier creates a new mock instance.
+func NewMockNotifier(ctrl *gomock.Controller) *MockNotifier {
+	mock := &MockNotifier{ctrl: ctrl}
+	mock.recorder = &MockNotifierMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockEmailSender) EXPECT() *MockEmailSenderMockRecorder {
+func (m *MockNotifier) EXPECT() *MockNotifierMockRecorder {
 	return m.recorder
 }
 
-// SendAgreementEmail mocks base method.
-func (m *MockEmailSender) SendAgreementEmail(email, loanID, agreementURL string) error {
+// Notify mocks base method.
+func (m *MockNotifier) Notify(notification loan.Notification) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SendAgreementEmail", email, loanID, agreementURL)
+	ret := m.ctrl.Call(m, "Notify", notification)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// SendAgreementEmail indicates an expected call of SendAgreementEmail.
-func (mr *MockEmailSenderMockRecorder) SendAgreementEmail(email, loanID, agreementURL interface{}) *gomock.Call {
+// Notify indicates an expected call of Notify.
+func (mr *MockNotifierMockRecorder) Notify(notification interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendAgreementEmail", reflect.TypeOf((*MockEmailSender)(nil).SendAgreementEmail), email, loanID, agreementURL)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Notify", reflect.TypeOf((*MockNotifier)(nil).Notify), notification)
+}
+
+// MockNotificationChannel is a mock of NotificationChannel interface.
+type MockNotificationChannel struct {
+	ctrl     *gomock.Controller
+	recorder *MockNotificationChannelMockRecorder
+}
+
+// MockNotificationChannelMockRecorder is the mock recorder for MockNotificationChannel.
+type MockNotificationChannelMockRecorder struct {
+	mock *MockNotificationChannel
+}
+
+// NewMockNotificationChannel creates a new mock instance.
+func NewMockNotificationChannel(ctrl *gomock.Controller) *MockNotificationChannel {
+	mock := &MockNotificationChannel{ctrl: ctrl}
+	mock.recorder = &MockNotificationChannelMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNotificationChannel) EXPECT() *MockNotificationChannelMockRecorder {
+	return m.recorder
+}
+
+// Channel mocks base method.
+func (m *MockNotificationChannel) Channel() loan.Channel {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Channel")
+	ret0, _ := ret[0].(loan.Channel)
+	return ret0
+}
+
+// Channel indicates an expected call of Channel.
+func (mr *MockNotificationChannelMockRecorder) Channel() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Channel", reflect.TypeOf((*MockNotificationChannel)(nil).Channel))
+}
+
+// Send mocks base method.
+func (m *MockNotificationChannel) Send(notification loan.Notification) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Send", notification)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Send indicates an expected call of Send.
+func (mr *MockNotificationChannelMockRecorder) Send(notification interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockNotificationChannel)(nil).Send), notification)
+}
+
+// MockEmailRenderer is a mock of EmailRenderer interface.
+type MockEmailRenderer struct {
+	ctrl     *gomock.Controller
+	recorder *MockEmailRendererMockRecorder
+}
+
+// MockEmailRendererMockRecorder is the mock recorder for MockEmailRenderer.
+type MockEmailRendererMockRecorder struct {
+	mock *MockEmailRenderer
+}
+
+// NewMockEmailRenderer creates a new mock instance.
+func NewMockEmailRenderer(ctrl *gomock.Controller) *MockEmailRenderer {
+	mock := &MockEmailRenderer{ctrl: ctrl}
+	mock.recorder = &MockEmailRendererMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEmailRenderer) EXPECT() *MockEmailRendererMockRecorder {
+	return m.recorder
+}
+
+// RenderNotification mocks base method.
+func (m *MockEmailRenderer) RenderNotification(notification loan.Notification) (*loan.RenderedEmail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenderNotification", notification)
+	ret0, _ := ret[0].(*loan.RenderedEmail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RenderNotification indicates an expected call of RenderNotification.
+func (mr *MockEmailRendererMockRecorder) RenderNotification(notification interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenderNotification", reflect.TypeOf((*MockEmailRenderer)(nil).RenderNotification), notification)
+}
+
+// MockAgreementRenderer is a mock of AgreementRenderer interface.
+type MockAgreementRenderer struct {
+	ctrl     *gomock.Controller
+	recorder *MockAgreementRendererMockRecorder
+}
+
+// MockAgreementRendererMockRecorder is the mock recorder for MockAgreementRenderer.
+type MockAgreementRendererMockRecorder struct {
+	mock *MockAgreementRenderer
+}
+
+// NewMockAgreementRenderer creates a new mock instance.
+func NewMockAgreementRenderer(ctrl *gomock.Controller) *MockAgreementRenderer {
+	mock := &MockAgreementRenderer{ctrl: ctrl}
+	mock.recorder = &MockAgreementRendererMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAgreementRenderer) EXPECT() *MockAgreementRendererMockRecorder {
+	return m.recorder
+}
+
+// RenderAgreement mocks base method.
+func (m *MockAgreementRenderer) RenderAgreement(loan *loan.Loan) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenderAgreement", loan)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RenderAgreement indicates an expected call of RenderAgreement.
+func (mr *MockAgreementRendererMockRecorder) RenderAgreement(loan interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenderAgreement", reflect.TypeOf((*MockAgreementRenderer)(nil).RenderAgreement), loan)
+}
+
+// MockAgreementVerifier is a mock of AgreementVerifier interface.
+type MockAgreementVerifier struct {
+	ctrl     *gomock.Controller
+	recorder *MockAgreementVerifierMockRecorder
+}
+
+// MockAgreementVerifierMockRecorder is the mock recorder for MockAgreementVerifier.
+type MockAgreementVerifierMockRecorder struct {
+	mock *MockAgreementVerifier
+}
+
+// NewMockAgreementVerifier creates a new mock instance.
+func NewMockAgreementVerifier(ctrl *gomock.Controller) *MockAgreementVerifier {
+	mock := &MockAgreementVerifier{ctrl: ctrl}
+	mock.recorder = &MockAgreementVerifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAgreementVerifier) EXPECT() *MockAgreementVerifierMockRecorder {
+	return m.recorder
+}
+
+// VerifySignedAgreement mocks base method.
+func (m *MockAgreementVerifier) VerifySignedAgreement(loan *loan.Loan, agreement, signed []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifySignedAgreement", loan, agreement, signed)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifySignedAgreement indicates an expected call of VerifySignedAgreement.
+func (mr *MockAgreementVerifierMockRecorder) VerifySignedAgreement(loan, agreement, signed interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifySignedAgreement", reflect.TypeOf((*MockAgreementVerifier)(nil).VerifySignedAgreement), loan, agreement, signed)
+}
+
+// MockCreditScorer is a mock of CreditScorer interface.
+type MockCreditScorer struct {
+	ctrl     *gomock.Controller
+	recorder *MockCreditScorerMockRecorder
+}
+
+// MockCreditScorerMockRecorder is the mock recorder for MockCreditScorer.
+type MockCreditScorerMockRecorder struct {
+	mock *MockCreditScorer
+}
+
+// NewMockCreditScorer creates a new mock instance.
+func NewMockCreditScorer(ctrl *gomock.Controller) *MockCreditScorer {
+	mock := &MockCreditScorer{ctrl: ctrl}
+	mock.recorder = &MockCreditScorerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCreditScorer) EXPECT() *MockCreditScorerMockRecorder {
+	return m.recorder
+}
+
+// Score mocks base method.
+func (m *MockCreditScorer) Score(input loan.ScoringInput) (*loan.CreditScore, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Score", input)
+	ret0, _ := ret[0].(*loan.CreditScore)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Score indicates an expected call of Score.
+func (mr *MockCreditScorerMockRecorder) Score(input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Score", reflect.TypeOf((*MockCreditScorer)(nil).Score), input)
+}
+
+// MockPricingEngine is a mock of PricingEngine interface.
+type MockPricingEngine struct {
+	ctrl     *gomock.Controller
+	recorder *MockPricingEngineMockRecorder
+}
+
+// MockPricingEngineMockRecorder is the mock recorder for MockPricingEngine.
+type MockPricingEngineMockRecorder struct {
+	mock *MockPricingEngine
+}
+
+// NewMockPricingEngine creates a new mock instance.
+func NewMockPricingEngine(ctrl *gomock.Controller) *MockPricingEngine {
+	mock := &MockPricingEngine{ctrl: ctrl}
+	mock.recorder = &MockPricingEngineMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPricingEngine) EXPECT() *MockPricingEngineMockRecorder {
+	return m.recorder
+}
+
+// Price mocks base method.
+func (m *MockPricingEngine) Price(input loan.PricingInput) (*loan.Quote, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Price", input)
+	ret0, _ := ret[0].(*loan.Quote)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Price indicates an expected call of Price.
+func (mr *MockPricingEngineMockRecorder) Price(input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Price", reflect.TypeOf((*MockPricingEngine)(nil).Price), input)
+}
+
+// MockNotificationService is a mock of NotificationService interface.
+type MockNotificationService struct {
+	ctrl     *gomock.Controller
+	recorder *MockNotificationServiceMockRecorder
+}
+
+// MockNotificationServiceMockRecorder is the mock recorder for MockNotificationService.
+type MockNotificationServiceMockRecorder struct {
+	mock *MockNotificationService
+}
+
+// NewMockNotificationService creates a new mock instance.
+func NewMockNotificationService(ctrl *gomock.Controller) *MockNotificationService {
+	mock := &MockNotificationService{ctrl: ctrl}
+	mock.recorder = &MockNotificationServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNotificationService) EXPECT() *MockNotificationServiceMockRecorder {
+	return m.recorder
+}
+
+// GetInbox mocks base method.
+func (m *MockNotificationService) GetInbox(recipientID string) ([]loan.InboxItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInbox", recipientID)
+	ret0, _ := ret[0].([]loan.InboxItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInbox indicates an expected call of GetInbox.
+func (mr *MockNotificationServiceMockRecorder) GetInbox(recipientID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInbox", reflect.TypeOf((*MockNotificationService)(nil).GetInbox), recipientID)
+}
+
+// GetPreferences mocks base method.
+func (m *MockNotificationService) GetPreferences(recipientID string) (*loan.NotificationPreferences, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPreferences", recipientID)
+	ret0, _ := ret[0].(*loan.NotificationPreferences)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPreferences indicates an expected call of GetPreferences.
+func (mr *MockNotificationServiceMockRecorder) GetPreferences(recipientID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPreferences", reflect.TypeOf((*MockNotificationService)(nil).GetPreferences), recipientID)
+}
+
+// MarkRead mocks base method.
+func (m *MockNotificationService) MarkRead(recipientID, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkRead", recipientID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkRead indicates an expected call of MarkRead.
+func (mr *MockNotificationServiceMockRecorder) MarkRead(recipientID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkRead", reflect.TypeOf((*MockNotificationService)(nil).MarkRead), recipientID, id)
+}
+
+// Notify mocks base method.
+func (m *MockNotificationService) Notify(notification loan.Notification) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Notify", notification)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Notify indicates an expected call of Notify.
+func (mr *MockNotificationServiceMockRecorder) Notify(notification interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Notify", reflect.TypeOf((*MockNotificationService)(nil).Notify), notification)
+}
+
+// SetPreferences mocks base method.
+func (m *MockNotificationService) SetPreferences(preferences loan.NotificationPreferences) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPreferences", preferences)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPreferences indicates an expected call of SetPreferences.
+func (mr *MockNotificationServiceMockRecorder) SetPreferences(preferences interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPreferences", reflect.TypeOf((*MockNotificationService)(nil).SetPreferences), preferences)
+}
+
+// MockInvestorService is a mock of InvestorService interface.
+type MockInvestorService struct {
+	ctrl     *gomock.Controller
+	recorder *MockInvestorServiceMockRecorder
+}
+
+// MockInvestorServiceMockRecorder is the mock recorder for MockInvestorService.
+type MockInvestorServiceMockRecorder struct {
+	mock *MockInvestorService
+}
+
+// NewMockInvestorService creates a new mock instance.
+func NewMockInvestorService(ctrl *gomock.Controller) *MockInvestorService {
+	mock := &MockInvestorService{ctrl: ctrl}
+	mock.recorder = &MockInvestorServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInvestorService) EXPECT() *MockInvestorServiceMockRecorder {
+	return m.recorder
+}
+
+// GetInvestor mocks base method.
+func (m *MockInvestorService) GetInvestor(id string) (*loan.InvestorProfile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInvestor", id)
+	ret0, _ := ret[0].(*loan.InvestorProfile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInvestor indicates an expected call of GetInvestor.
+func (mr *MockInvestorServiceMockRecorder) GetInvestor(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInvestor", reflect.TypeOf((*MockInvestorService)(nil).GetInvestor), id)
+}
+
+// GetPortfolio mocks base method.
+func (m *MockInvestorService) GetPortfolio(id string) (*loan.Portfolio, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPortfolio", id)
+	ret0, _ := ret[0].(*loan.Portfolio)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPortfolio indicates an expected call of GetPortfolio.
+func (mr *MockInvestorServiceMockRecorder) GetPortfolio(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPortfolio", reflect.TypeOf((*MockInvestorService)(nil).GetPortfolio), id)
+}
+
+// RegisterInvestor mocks base method.
+func (m *MockInvestorService) RegisterInvestor(investor loan.InvestorProfile) (*loan.InvestorProfile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterInvestor", investor)
+	ret0, _ := ret[0].(*loan.InvestorProfile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegisterInvestor indicates an expected call of RegisterInvestor.
+func (mr *MockInvestorServiceMockRecorder) RegisterInvestor(investor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterInvestor", reflect.TypeOf((*MockInvestorService)(nil).RegisterInvestor), investor)
+}
+
+// SetKYCStatus mocks base method.
+func (m *MockInvestorService) SetKYCStatus(id string, status loan.KYCStatus) (*loan.InvestorProfile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetKYCStatus", id, status)
+	ret0, _ := ret[0].(*loan.InvestorProfile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetKYCStatus indicates an expected call of SetKYCStatus.
+func (mr *MockInvestorServiceMockRecorder) SetKYCStatus(id, status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetKYCStatus", reflect.TypeOf((*MockInvestorService)(nil).SetKYCStatus), id, status)
+}
+
+// UpdateInvestor mocks base method.
+func (m *MockInvestorService) UpdateInvestor(investor loan.InvestorProfile) (*loan.InvestorProfile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateInvestor", investor)
+	ret0, _ := ret[0].(*loan.InvestorProfile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateInvestor indicates an expected call of UpdateInvestor.
+func (mr *MockInvestorServiceMockRecorder) UpdateInvestor(investor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateInvestor", reflect.TypeOf((*MockInvestorService)(nil).UpdateInvestor), investor)
+}
+
+// MockBorrowerService is a mock of BorrowerService interface.
+type MockBorrowerService struct {
+	ctrl     *gomock.Controller
+	recorder *MockBorrowerServiceMockRecorder
+}
+
+// MockBorrowerServiceMockRecorder is the mock recorder for MockBorrowerService.
+type MockBorrowerServiceMockRecorder struct {
+	mock *MockBorrowerService
+}
+
+// NewMockBorrowerService creates a new mock instance.
+func NewMockBorrowerService(ctrl *gomock.Controller) *MockBorrowerService {
+	mock := &MockBorrowerService{ctrl: ctrl}
+	mock.recorder = &MockBorrowerServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBorrowerService) EXPECT() *MockBorrowerServiceMockRecorder {
+	return m.recorder
+}
+
+// DeleteBorrower mocks base method.
+func (m *MockBorrowerService) DeleteBorrower(id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBorrower", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteBorrower indicates an expected call of DeleteBorrower.
+func (mr *MockBorrowerServiceMockRecorder) DeleteBorrower(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBorrower", reflect.TypeOf((*MockBorrowerService)(nil).DeleteBorrower), id)
+}
+
+// GetBorrower mocks base method.
+func (m *MockBorrowerService) GetBorrower(id string) (*loan.BorrowerDetails, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBorrower", id)
+	ret0, _ := ret[0].(*loan.BorrowerDetails)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBorrower indicates an expected call of GetBorrower.
+func (mr *MockBorrowerServiceMockRecorder) GetBorrower(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBorrower", reflect.TypeOf((*MockBorrowerService)(nil).GetBorrower), id)
+}
+
+// GetBorrowers mocks base method.
+func (m *MockBorrowerService) GetBorrowers(page, limit int) ([]*loan.Borrower, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBorrowers", page, limit)
+	ret0, _ := ret[0].([]*loan.Borrower)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBorrowers indicates an expected call of GetBorrowers.
+func (mr *MockBorrowerServiceMockRecorder) GetBorrowers(page, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBorrowers", reflect.TypeOf((*MockBorrowerService)(nil).GetBorrowers), page, limit)
+}
+
+// RegisterBorrower mocks base method.
+func (m *MockBorrowerService) RegisterBorrower(borrower loan.Borrower) (*loan.Borrower, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterBorrower", borrower)
+	ret0, _ := ret[0].(*loan.Borrower)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegisterBorrower indicates an expected call of RegisterBorrower.
+func (mr *MockBorrowerServiceMockRecorder) RegisterBorrower(borrower interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterBorrower", reflect.TypeOf((*MockBorrowerService)(nil).RegisterBorrower), borrower)
+}
+
+// UpdateBorrower mocks base method.
+func (m *MockBorrowerService) UpdateBorrower(borrower loan.Borrower) (*loan.Borrower, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateBorrower", borrower)
+	ret0, _ := ret[0].(*loan.Borrower)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateBorrower indicates an expected call of UpdateBorrower.
+func (mr *MockBorrowerServiceMockRecorder) UpdateBorrower(borrower interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBorrower", reflect.TypeOf((*MockBorrowerService)(nil).UpdateBorrower), borrower)
 }
 
 // MockService is a mock of Service interface.
@@ -72,74 +603,139 @@ func (m *MockService) EXPECT() *MockServiceMockRecorder {
 }
 
 // AddInvestment mocks base method.
-func (m *MockService) AddInvestment(id, investorID, email string, amount float64) error {
+func (m *MockService) AddInvestment(id string, investor loan.Investor, match loan.IfMatch) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddInvestment", id, investorID, email, amount)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "AddInvestment", id, investor, match)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // AddInvestment indicates an expected call of AddInvestment.
-func (mr *MockServiceMockRecorder) AddInvestment(id, investorID, email, amount interface{}) *gomock.Call {
+func (mr *MockServiceMockRecorder) AddInvestment(id, investor, match interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddInvestment", reflect.TypeOf((*MockService)(nil).AddInvestment), id, investorID, email, amount)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddInvestment", reflect.TypeOf((*MockService)(nil).AddInvestment), id, investor, match)
 }
 
 // ApproveLoan mocks base method.
-func (m *MockService) ApproveLoan(id, validatorID, proofURL string) error {
+func (m *MockService) ApproveLoan(id, validatorID, proofDocumentID, overrideJustification string, match loan.IfMatch) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ApproveLoan", id, validatorID, proofURL)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "ApproveLoan", id, validatorID, proofDocumentID, overrideJustification, match)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // ApproveLoan indicates an expected call of ApproveLoan.
-func (mr *MockServiceMockRecorder) ApproveLoan(id, validatorID, proofURL interface{}) *gomock.Call {
+func (mr *MockServiceMockRecorder) ApproveLoan(id, validatorID, proofDocumentID, overrideJustification, match interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApproveLoan", reflect.TypeOf((*MockService)(nil).ApproveLoan), id, validatorID, proofDocumentID, overrideJustification, match)
+}
+
+// CancelLoan mocks base method.
+func (m *MockService) CancelLoan(id, actorID string, reason loan.ReasonCode, match loan.IfMatch) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelLoan", id, actorID, reason, match)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelLoan indicates an expected call of CancelLoan.
+func (mr *MockServiceMockRecorder) CancelLoan(id, actorID, reason, match interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelLoan", reflect.TypeOf((*MockService)(nil).CancelLoan), id, actorID, reason, match)
+}
+
+// ChargeLateFees mocks base method.
+func (m *MockService) ChargeLateFees() (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChargeLateFees")
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChargeLateFees indicates an expected call of ChargeLateFees.
+func (mr *MockServiceMockRecorder) ChargeLateFees() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApproveLoan", reflect.TypeOf((*MockService)(nil).ApproveLoan), id, validatorID, proofURL)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChargeLateFees", reflect.TypeOf((*MockService)(nil).ChargeLateFees))
 }
 
 // CreateLoan mocks base method.
-func (m *MockService) CreateLoan(borrowerID string, principal, rate, roi float64) (*loan.Loan, error) {
+func (m *MockService) CreateLoan(borrowerID string, principal money.Money, rate, roi *money.Decimal, terms loan.RepaymentTerms) (*loan.Loan, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateLoan", borrowerID, principal, rate, roi)
+	ret := m.ctrl.Call(m, "CreateLoan", borrowerID, principal, rate, roi, terms)
 	ret0, _ := ret[0].(*loan.Loan)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateLoan indicates an expected call of CreateLoan.
-func (mr *MockServiceMockRecorder) CreateLoan(borrowerID, principal, rate, roi interface{}) *gomock.Call {
+func (mr *MockServiceMockRecorder) CreateLoan(borrowerID, principal, rate, roi, terms interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateLoan", reflect.TypeOf((*MockService)(nil).CreateLoan), borrowerID, principal, rate, roi)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateLoan", reflect.TypeOf((*MockService)(nil).CreateLoan), borrowerID, principal, rate, roi, terms)
 }
 
 // DisburseLoan mocks base method.
-func (m *MockService) DisburseLoan(id, fieldOfficerID, signedAgreement string) error {
+func (m *MockService) DisburseLoan(id, fieldOfficerID, signedAgreementDocumentID string, match loan.IfMatch) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DisburseLoan", id, fieldOfficerID, signedAgreement)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "DisburseLoan", id, fieldOfficerID, signedAgreementDocumentID, match)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // DisburseLoan indicates an expected call of DisburseLoan.
-func (mr *MockServiceMockRecorder) DisburseLoan(id, fieldOfficerID, signedAgreement interface{}) *gomock.Call {
+func (mr *MockServiceMockRecorder) DisburseLoan(id, fieldOfficerID, signedAgreementDocumentID, match interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisburseLoan", reflect.TypeOf((*MockService)(nil).DisburseLoan), id, fieldOfficerID, signedAgreement)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisburseLoan", reflect.TypeOf((*MockService)(nil).DisburseLoan), id, fieldOfficerID, signedAgreementDocumentID, match)
 }
 
 // GenerateAgreementLetter mocks base method.
-func (m *MockService) GenerateAgreementLetter(id, letterURL string) error {
+func (m *MockService) GenerateAgreementLetter(id string, match loan.IfMatch) (*loan.Document, int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GenerateAgreementLetter", id, letterURL)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "GenerateAgreementLetter", id, match)
+	ret0, _ := ret[0].(*loan.Document)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
 }
 
 // GenerateAgreementLetter indicates an expected call of GenerateAgreementLetter.
-func (mr *MockServiceMockRecorder) GenerateAgreementLetter(id, letterURL interface{}) *gomock.Call {
+func (mr *MockServiceMockRecorder) GenerateAgreementLetter(id, match interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateAgreementLetter", reflect.TypeOf((*MockService)(nil).GenerateAgreementLetter), id, match)
+}
+
+// GetAgreements mocks base method.
+func (m *MockService) GetAgreements(id string) ([]loan.AgreementVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAgreements", id)
+	ret0, _ := ret[0].([]loan.AgreementVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAgreements indicates an expected call of GetAgreements.
+func (mr *MockServiceMockRecorder) GetAgreements(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAgreements", reflect.TypeOf((*MockService)(nil).GetAgreements), id)
+}
+
+// GetHistory mocks base method.
+func (m *MockService) GetHistory(id string) ([]loan.AuditEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHistory", id)
+	ret0, _ := ret[0].([]loan.AuditEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHistory indicates an expected call of GetHistory.
+func (mr *MockServiceMockRecorder) GetHistory(id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateAgreementLetter", reflect.TypeOf((*MockService)(nil).GenerateAgreementLetter), id, letterURL)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHistory", reflect.TypeOf((*MockService)(nil).GetHistory), id)
 }
 
 // GetLoan mocks base method.
@@ -158,46 +754,152 @@ func (mr *MockServiceMockRecorder) GetLoan(id interface{}) *gomock.Call {
 }
 
 // GetLoans mocks base method.
-func (m *MockService) GetLoans(page, limit int) ([]*loan.Loan, error) {
+func (m *MockService) GetLoans(filter loan.LoanFilter, page, limit int) ([]*loan.Loan, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetLoans", page, limit)
+	ret := m.ctrl.Call(m, "GetLoans", filter, page, limit)
 	ret0, _ := ret[0].([]*loan.Loan)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetLoans indicates an expected call of GetLoans.
-func (mr *MockServiceMockRecorder) GetLoans(page, limit interface{}) *gomock.Call {
+func (mr *MockServiceMockRecorder) GetLoans(filter, page, limit interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoans", reflect.TypeOf((*MockService)(nil).GetLoans), page, limit)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoans", reflect.TypeOf((*MockService)(nil).GetLoans), filter, page, limit)
 }
 
 // GetLoansByBorrower mocks base method.
-func (m *MockService) GetLoansByBorrower(borrowerID string) ([]*loan.Loan, error) {
+func (m *MockService) GetLoansByBorrower(borrowerID string, filter loan.LoanFilter) ([]*loan.Loan, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetLoansByBorrower", borrowerID)
+	ret := m.ctrl.Call(m, "GetLoansByBorrower", borrowerID, filter)
 	ret0, _ := ret[0].([]*loan.Loan)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetLoansByBorrower indicates an expected call of GetLoansByBorrower.
-func (mr *MockServiceMockRecorder) GetLoansByBorrower(borrowerID interface{}) *gomock.Call {
+func (mr *MockServiceMockRecorder) GetLoansByBorrower(borrowerID, filter interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoansByBorrower", reflect.TypeOf((*MockService)(nil).GetLoansByBorrower), borrowerID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoansByBorrower", reflect.TypeOf((*MockService)(nil).GetLoansByBorrower), borrowerID, filter)
 }
 
 // GetLoansByState mocks base method.
-func (m *MockService) GetLoansByState(state loan.LoanState) ([]*loan.Loan, error) {
+func (m *MockService) GetLoansByState(state loan.LoanState, filter loan.LoanFilter) ([]*loan.Loan, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetLoansByState", state)
+	ret := m.ctrl.Call(m, "GetLoansByState", state, filter)
 	ret0, _ := ret[0].([]*loan.Loan)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetLoansByState indicates an expected call of GetLoansByState.
-func (mr *MockServiceMockRecorder) GetLoansByState(state interface{}) *gomock.Call {
+func (mr *MockServiceMockRecorder) GetLoansByState(state, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoansByState", reflect.TypeOf((*MockService)(nil).GetLoansByState), state, filter)
+}
+
+// GetSchedule mocks base method.
+func (m *MockService) GetSchedule(id string) ([]loan.Installment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSchedule", id)
+	ret0, _ := ret[0].([]loan.Installment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSchedule indicates an expected call of GetSchedule.
+func (mr *MockServiceMockRecorder) GetSchedule(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSchedule", reflect.TypeOf((*MockService)(nil).GetSchedule), id)
+}
+
+// GetTransitions mocks base method.
+func (m *MockService) GetTransitions(id string) ([]loan.NextTransition, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransitions", id)
+	ret0, _ := ret[0].([]loan.NextTransition)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransitions indicates an expected call of GetTransitions.
+func (mr *MockServiceMockRecorder) GetTransitions(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransitions", reflect.TypeOf((*MockService)(nil).GetTransitions), id)
+}
+
+// MarkDefaultedLoans mocks base method.
+func (m *MockService) MarkDefaultedLoans() ([]*loan.Loan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkDefaultedLoans")
+	ret0, _ := ret[0].([]*loan.Loan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkDefaultedLoans indicates an expected call of MarkDefaultedLoans.
+func (mr *MockServiceMockRecorder) MarkDefaultedLoans() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkDefaultedLoans", reflect.TypeOf((*MockService)(nil).MarkDefaultedLoans))
+}
+
+// RecordRepayment mocks base method.
+func (m *MockService) RecordRepayment(id string, amount money.Money, match loan.IfMatch) (*loan.Repayment, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordRepayment", id, amount, match)
+	ret0, _ := ret[0].(*loan.Repayment)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// RecordRepayment indicates an expected call of RecordRepayment.
+func (mr *MockServiceMockRecorder) RecordRepayment(id, amount, match interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordRepayment", reflect.TypeOf((*MockService)(nil).RecordRepayment), id, amount, match)
+}
+
+// RejectLoan mocks base method.
+func (m *MockService) RejectLoan(id, actorID string, reason loan.ReasonCode, match loan.IfMatch) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RejectLoan", id, actorID, reason, match)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RejectLoan indicates an expected call of RejectLoan.
+func (mr *MockServiceMockRecorder) RejectLoan(id, actorID, reason, match interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RejectLoan", reflect.TypeOf((*MockService)(nil).RejectLoan), id, actorID, reason, match)
+}
+
+// RemindDueInstallments mocks base method.
+func (m *MockService) RemindDueInstallments() (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemindDueInstallments")
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemindDueInstallments indicates an expected call of RemindDueInstallments.
+func (mr *MockServiceMockRecorder) RemindDueInstallments() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemindDueInstallments", reflect.TypeOf((*MockService)(nil).RemindDueInstallments))
+}
+
+// UploadDocument mocks base method.
+func (m *MockService) UploadDocument(id string, upload loan.DocumentUpload) (*loan.Document, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadDocument", id, upload)
+	ret0, _ := ret[0].(*loan.Document)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UploadDocument indicates an expected call of UploadDocument.
+func (mr *MockServiceMockRecorder) UploadDocument(id, upload interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoansByState", reflect.TypeOf((*MockService)(nil).GetLoansByState), state)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadDocument", reflect.TypeOf((*MockService)(nil).UploadDocument), id, upload)
 }
@@ -1,6 +1,10 @@
 package loan
 
-import "time"
+import (
+	"time"
+
+	"github.com/hinha/los-technical/internal/pkg/money"
+)
 
 type LoanState string
 
@@ -9,38 +13,160 @@ const (
 	StateApproved  LoanState = "APPROVED"
 	StateInvested  LoanState = "INVESTED"
 	StateDisbursed LoanState = "DISBURSED"
+	// StateRepaid is reached once every installment of the schedule is paid
+	StateRepaid LoanState = "REPAID"
+	// StateDefaulted is reached when an installment stays unpaid past the days-past-due threshold
+	StateDefaulted LoanState = "DEFAULTED"
+	// StateRejected is reached when a PROPOSED loan is turned down
+	StateRejected LoanState = "REJECTED"
+	// StateCancelled is reached when a loan is withdrawn before it is fully funded
+	StateCancelled LoanState = "CANCELLED"
 )
 
+// IsTerminal reports whether no transition leaves the state: the loan is repaid or closed before disbursement
+func (s LoanState) IsTerminal() bool {
+	switch s {
+	case StateRepaid, StateRejected, StateCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
 type Loan struct {
-	ID              string  `json:"id"`
-	BorrowerID      string  `json:"borrower_id"`
-	PrincipalAmount float64 `json:"principal_amount"`
-	Rate            float64 `json:"rate"`
-	ROI             float64 `json:"roi"`
-	AgreementLetter string  `json:"agreement_letter"`
+	ID              string        `json:"id"`
+	BorrowerID      string        `json:"borrower_id"`
+	PrincipalAmount money.Money   `json:"principal_amount"`
+	Rate            money.Decimal `json:"rate" swaggertype:"string" example:"12.5"`
+	ROI             money.Decimal `json:"roi" swaggertype:"string" example:"10"`
+	AgreementLetter string        `json:"agreement_letter"`
+	// AgreementDocumentID is the stored document the agreement letter was generated as
+	AgreementDocumentID string         `json:"agreement_document_id,omitempty"`
+	Terms               RepaymentTerms `json:"terms"`
+
+	// CreditScore is the credit risk assessed when the loan was proposed, nil for loans proposed before scoring
+	CreditScore *CreditScore `json:"credit_score,omitempty"`
 
 	State         LoanState     `json:"state"`
 	ApprovedInfo  *Approval     `json:"approved_info"`
 	Investors     []Investor    `json:"investors"`
 	DisbursedInfo *Disbursement `json:"disbursed_info"`
+	Schedule      []Installment `json:"schedule,omitempty"`
+	Repayments    []Repayment   `json:"repayments,omitempty"`
+	RejectedInfo  *Closure      `json:"rejected_info,omitempty"`
+	CancelledInfo *Closure      `json:"cancelled_info,omitempty"`
+	Refunds       []Refund      `json:"refunds,omitempty"`
 	CreatedAt     time.Time     `json:"created_at"`
 	UpdatedAt     time.Time     `json:"updated_at"`
+
+	// Version is incremented by the repository on every successful update and is used for optimistic locking
+	Version int64 `json:"version"`
+}
+
+// LoanFilter narrows loan listings, zero-valued fields match every loan
+type LoanFilter struct {
+	// Currency is the ISO 4217 code of the principal amount
+	Currency string
+}
+
+// Matches reports whether the loan passes the filter
+func (f LoanFilter) Matches(loan *Loan) bool {
+	return f.Currency == "" || loan.PrincipalAmount.Currency == f.Currency
 }
 
 type Approval struct {
-	ValidatorID string    `json:"validator_id"`
-	ProofURL    string    `json:"proof_url"`
-	Date        time.Time `json:"date"`
+	ValidatorID string `json:"validator_id"`
+	// ProofDocumentID references the uploaded proof, ProofURL is where it is downloaded from
+	ProofDocumentID string `json:"proof_document_id,omitempty"`
+	ProofURL        string `json:"proof_url"`
+	// OverrideJustification explains why a loan graded below the approval minimum was approved
+	OverrideJustification string    `json:"override_justification,omitempty"`
+	Date                  time.Time `json:"date"`
 }
 
 type Investor struct {
-	ID     string  `json:"id"`
-	Amount float64 `json:"amount"`
-	Email  string  `json:"email"`
+	ID     string      `json:"id"`
+	Name   string      `json:"name,omitempty"`
+	Amount money.Money `json:"amount"`
+	Email  string      `json:"email"`
+	// Locale picks the language of the emails sent to the investor, e.g. "id"
+	Locale string `json:"locale,omitempty"`
 }
 
 type Disbursement struct {
-	SignedAgreement string    `json:"signed_agreement"`
-	FieldOfficerID  string    `json:"field_officer_id"`
-	Date            time.Time `json:"date"`
+	// SignedAgreementDocumentID references the uploaded signed agreement, SignedAgreement is where it is downloaded from
+	SignedAgreementDocumentID string    `json:"signed_agreement_document_id,omitempty"`
+	SignedAgreement           string    `json:"signed_agreement"`
+	FieldOfficerID            string    `json:"field_officer_id"`
+	Date                      time.Time `json:"date"`
+}
+
+// Clone returns a deep copy of the loan, including its credit score, investors, schedule, repayments, refunds
+// and the approval, disbursement, rejection and cancellation details
+func (l *Loan) Clone() *Loan {
+	if l == nil {
+		return nil
+	}
+
+	clone := *l
+	if l.CreditScore != nil {
+		score := *l.CreditScore
+		if l.CreditScore.Reasons != nil {
+			score.Reasons = make([]string, len(l.CreditScore.Reasons))
+			copy(score.Reasons, l.CreditScore.Reasons)
+		}
+		clone.CreditScore = &score
+	}
+	if l.ApprovedInfo != nil {
+		approval := *l.ApprovedInfo
+		clone.ApprovedInfo = &approval
+	}
+	if l.Investors != nil {
+		clone.Investors = make([]Investor, len(l.Investors))
+		copy(clone.Investors, l.Investors)
+	}
+	if l.DisbursedInfo != nil {
+		disbursement := *l.DisbursedInfo
+		clone.DisbursedInfo = &disbursement
+	}
+	if l.Schedule != nil {
+		clone.Schedule = make([]Installment, len(l.Schedule))
+		copy(clone.Schedule, l.Schedule)
+		for i := range clone.Schedule {
+			if paidAt := clone.Schedule[i].PaidAt; paidAt != nil {
+				settledAt := *paidAt
+				clone.Schedule[i].PaidAt = &settledAt
+			}
+			if remindedAt := clone.Schedule[i].RemindedAt; remindedAt != nil {
+				reminded := *remindedAt
+				clone.Schedule[i].RemindedAt = &reminded
+			}
+		}
+	}
+	if l.Repayments != nil {
+		clone.Repayments = make([]Repayment, len(l.Repayments))
+		copy(clone.Repayments, l.Repayments)
+	}
+	if l.RejectedInfo != nil {
+		rejection := *l.RejectedInfo
+		clone.RejectedInfo = &rejection
+	}
+	if l.CancelledInfo != nil {
+		cancellation := *l.CancelledInfo
+		clone.CancelledInfo = &cancellation
+	}
+	if l.Refunds != nil {
+		clone.Refunds = make([]Refund, len(l.Refunds))
+		copy(clone.Refunds, l.Refunds)
+	}
+	return &clone
+}
+
+// InvestedAmount returns the sum of all investments in the loan currency
+func (l *Loan) InvestedAmount() (money.Money, error) {
+	amounts := make([]money.Money, 0, len(l.Investors))
+	for _, inv := range l.Investors {
+		amounts = append(amounts, inv.Amount)
+	}
+	return money.Sum(l.PrincipalAmount.Currency, amounts...)
 }
@@ -0,0 +1,248 @@
+package loan
+
+import (
+	"fmt"
+	"math/big"
+	"slices"
+	"time"
+
+	"github.com/hinha/los-technical/internal/pkg/money"
+)
+
+// NotificationKind is what a notification tells its recipient
+type NotificationKind string
+
+// Borrower notifications
+const (
+	NotificationLoanApproved   NotificationKind = "LOAN_APPROVED"
+	NotificationLoanFunded     NotificationKind = "LOAN_FUNDED"
+	NotificationLoanDisbursed  NotificationKind = "LOAN_DISBURSED"
+	NotificationInstallmentDue NotificationKind = "INSTALLMENT_DUE"
+)
+
+// Investor notifications
+const (
+	NotificationInvestmentReceived   NotificationKind = "INVESTMENT_RECEIVED"
+	NotificationAgreement            NotificationKind = "AGREEMENT"
+	NotificationInvestmentDisbursed  NotificationKind = "INVESTMENT_DISBURSED"
+	NotificationRepaymentDistributed NotificationKind = "REPAYMENT_DISTRIBUTED"
+	NotificationRefund               NotificationKind = "REFUND"
+)
+
+// notificationKinds lists every kind, borrower notifications first
+var notificationKinds = []NotificationKind{
+	NotificationLoanApproved, NotificationLoanFunded, NotificationLoanDisbursed, NotificationInstallmentDue,
+	NotificationInvestmentReceived, NotificationAgreement, NotificationInvestmentDisbursed, NotificationRepaymentDistributed, NotificationRefund,
+}
+
+// mandatoryNotifications cannot be muted: they carry the loan agreement or return money
+var mandatoryNotifications = []NotificationKind{NotificationAgreement, NotificationRefund}
+
+// Channel is a way of reaching a recipient
+type Channel string
+
+const (
+	ChannelEmail Channel = "EMAIL"
+	ChannelSMS   Channel = "SMS"
+	// ChannelInApp keeps the notification in the recipient's inbox
+	ChannelInApp Channel = "IN_APP"
+)
+
+// RecipientRole is the part a recipient plays in the loan a notification is about
+type RecipientRole string
+
+const (
+	RoleBorrower RecipientRole = "BORROWER"
+	RoleInvestor RecipientRole = "INVESTOR"
+)
+
+// Recipient is who a notification is addressed to. Contact details missing here are taken from
+// the recipient's preferences.
+type Recipient struct {
+	ID     string        `json:"id"`
+	Role   RecipientRole `json:"role"`
+	Name   string        `json:"name,omitempty"`
+	Email  string        `json:"email,omitempty"`
+	Phone  string        `json:"phone,omitempty"`
+	Locale string        `json:"locale,omitempty"`
+}
+
+// Reaches reports whether the recipient has the contact details the channel needs
+func (r Recipient) Reaches(channel Channel) bool {
+	switch channel {
+	case ChannelEmail:
+		return r.Email != ""
+	case ChannelSMS:
+		return r.Phone != ""
+	case ChannelInApp:
+		return r.ID != ""
+	default:
+		return false
+	}
+}
+
+// Notification is a message about a loan to one of its borrowers or investors
+type Notification struct {
+	ID        string           `json:"id"`
+	Kind      NotificationKind `json:"kind"`
+	LoanID    string           `json:"loan_id"`
+	Recipient Recipient        `json:"recipient"`
+	// Amount is what the notification is about: the principal, an investment, a refund,
+	// an investor's share of a repayment or the amount due on an installment
+	Amount *money.Money `json:"amount,omitempty"`
+	// Reason is set on refunds
+	Reason ReasonCode `json:"reason,omitempty"`
+	// Installment and DueDate are set on installment reminders
+	Installment int        `json:"installment,omitempty"`
+	DueDate     *time.Time `json:"due_date,omitempty"`
+	// Agreement is the content of an agreement notification
+	Agreement *AgreementEmail `json:"agreement,omitempty"`
+}
+
+// NotificationPreferences are how a recipient wants to be notified and the contact details to use
+type NotificationPreferences struct {
+	RecipientID string `json:"recipient_id"`
+	Email       string `json:"email,omitempty"`
+	Phone       string `json:"phone,omitempty"`
+	Locale      string `json:"locale,omitempty"`
+	// Channels are used for every notification the recipient has not muted, email when empty
+	Channels []Channel `json:"channels,omitempty"`
+	// Muted are the kinds the recipient does not want, agreements and refunds are sent regardless
+	Muted []NotificationKind `json:"muted,omitempty"`
+}
+
+// DefaultPreferences are used for recipients who have not set any: every notification by email
+func DefaultPreferences(recipientID string) NotificationPreferences {
+	return NotificationPreferences{RecipientID: recipientID, Channels: []Channel{ChannelEmail}}
+}
+
+// Validate checks the channels and kinds and that SMS comes with a phone number
+func (p NotificationPreferences) Validate() error {
+	if p.RecipientID == "" {
+		return fmt.Errorf("%w: recipient ID is required", ErrInvalidPreferences)
+	}
+	for _, channel := range p.Channels {
+		switch channel {
+		case ChannelEmail, ChannelInApp:
+		case ChannelSMS:
+			if p.Phone == "" {
+				return fmt.Errorf("%w: SMS needs a phone number", ErrInvalidPreferences)
+			}
+		default:
+			return fmt.Errorf("%w: unknown channel %q", ErrInvalidPreferences, channel)
+		}
+	}
+	for _, kind := range p.Muted {
+		if !slices.Contains(notificationKinds, kind) {
+			return fmt.Errorf("%w: unknown notification kind %q", ErrInvalidPreferences, kind)
+		}
+	}
+	return nil
+}
+
+// ChannelsFor returns the channels a notification of the kind is sent over, none when it is muted
+func (p NotificationPreferences) ChannelsFor(kind NotificationKind) []Channel {
+	if slices.Contains(p.Muted, kind) && !slices.Contains(mandatoryNotifications, kind) {
+		return nil
+	}
+	if len(p.Channels) == 0 {
+		return []Channel{ChannelEmail}
+	}
+	return p.Channels
+}
+
+// Address fills in the contact details of the recipient from the preferences; a locale set in the
+// preferences wins over the one the notification was raised with
+func (p NotificationPreferences) Address(recipient Recipient) Recipient {
+	if p.Email != "" {
+		recipient.Email = p.Email
+	}
+	if p.Phone != "" {
+		recipient.Phone = p.Phone
+	}
+	if p.Locale != "" {
+		recipient.Locale = p.Locale
+	}
+	return recipient
+}
+
+// InboxItem is a notification kept for the in-app channel
+type InboxItem struct {
+	// ID is the ID of the notification, so a notification is kept once however often it is sent
+	ID          string           `json:"id"`
+	RecipientID string           `json:"recipient_id"`
+	Kind        NotificationKind `json:"kind"`
+	LoanID      string           `json:"loan_id"`
+	Subject     string           `json:"subject"`
+	Body        string           `json:"body"`
+	CreatedAt   time.Time        `json:"created_at"`
+	ReadAt      *time.Time       `json:"read_at,omitempty"`
+}
+
+// InvestorRecipient addresses a notification to an investor of the loan
+func InvestorRecipient(investor Investor) Recipient {
+	return Recipient{ID: investor.ID, Role: RoleInvestor, Name: investor.Name, Email: investor.Email, Locale: investor.Locale}
+}
+
+// InvestorShare returns the part of a repayment that goes to the investor, in proportion to their
+// investment in the principal and rounded to the minor units of the currency. Investors are repaid the
+// principal and the interest at the loan ROI; fees and the interest above the ROI stay with the platform.
+func (l *Loan) InvestorShare(repayment Repayment, investor Investor) (money.Money, error) {
+	if l.PrincipalAmount.Amount.IsZero() {
+		return money.Money{}, fmt.Errorf("loan %s has no principal to share repayments by", l.ID)
+	}
+	// The interest was charged at the borrower rate, investors earn the ROI part of it
+	investors := new(big.Rat)
+	if l.Rate.IsPositive() {
+		investors.Mul(repayment.Interest.Amount.Rat(), l.ROI.Rat())
+		investors.Quo(investors, l.Rate.Rat())
+	}
+	investors.Add(investors, repayment.Principal.Amount.Rat())
+
+	share := new(big.Rat).Mul(investors, investor.Amount.Amount.Rat())
+	share.Quo(share, l.PrincipalAmount.Amount.Rat())
+	return money.FromRat(share, repayment.Amount.Currency)
+}
+
+// AgreementEmail is what the agreement email of a funded loan tells one of its investors
+type AgreementEmail struct {
+	Email        string `json:"email"`
+	Locale       string `json:"locale,omitempty"`
+	LoanID       string `json:"loan_id"`
+	InvestorName string `json:"investor_name,omitempty"`
+	// Amount is what the investor put into the loan
+	Amount money.Money `json:"amount"`
+	// ROI is the return on investment promised by the loan, in percent
+	ROI money.Decimal `json:"roi" swaggertype:"string" example:"10"`
+	// ExpectedReturn is the annual ROI applied to the invested amount over the tenor
+	ExpectedReturn money.Money    `json:"expected_return"`
+	Terms          RepaymentTerms `json:"terms"`
+	AgreementURL   string         `json:"agreement_url"`
+}
+
+// NewAgreementEmail addresses the agreement email of the loan to one of its investors
+func NewAgreementEmail(loan *Loan, investor Investor) (AgreementEmail, error) {
+	expectedReturn, err := ExpectedReturn(investor.Amount, loan.ROI, loan.Terms)
+	if err != nil {
+		return AgreementEmail{}, err
+	}
+
+	return AgreementEmail{
+		Email:          investor.Email,
+		Locale:         investor.Locale,
+		LoanID:         loan.ID,
+		InvestorName:   investor.Name,
+		Amount:         investor.Amount,
+		ROI:            loan.ROI,
+		ExpectedReturn: expectedReturn,
+		Terms:          loan.Terms,
+		AgreementURL:   loan.AgreementLetter,
+	}, nil
+}
+
+// RenderedEmail is an email ready to be sent, with a plain text and an HTML body
+type RenderedEmail struct {
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	HTML    string `json:"html"`
+}
@@ -0,0 +1,139 @@
+package loan
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hinha/los-technical/internal/pkg/money"
+)
+
+func TestNewAgreementEmail(t *testing.T) {
+	loan := &Loan{
+		ID:              "loan-1",
+		PrincipalAmount: money.MustNew("1000", "USD"),
+		ROI:             money.MustParse("7.5"),
+		Terms:           RepaymentTerms{TenorMonths: 12, Scheme: SchemeAnnuity},
+		AgreementLetter: "http://example.com/agreement",
+	}
+	investor := Investor{ID: "investor-1", Name: "Ani", Email: "ani@example.com", Locale: "id", Amount: money.MustNew("333.33", "USD")}
+
+	agreement, err := NewAgreementEmail(loan, investor)
+	assert.NoError(t, err)
+	assert.Equal(t, AgreementEmail{
+		Email:        "ani@example.com",
+		Locale:       "id",
+		LoanID:       "loan-1",
+		InvestorName: "Ani",
+		Amount:       money.MustNew("333.33", "USD"),
+		ROI:          money.MustParse("7.5"),
+		// 24.99975 rounded to cents
+		ExpectedReturn: money.MustNew("25.00", "USD"),
+		Terms:          RepaymentTerms{TenorMonths: 12, Scheme: SchemeAnnuity},
+		AgreementURL:   "http://example.com/agreement",
+	}, agreement)
+
+	// The ROI is a yearly rate, so it is pro-rated over the tenor
+	for tenor, want := range map[int]string{6: "12.50", 24: "50.00"} {
+		loan.Terms.TenorMonths = tenor
+		agreement, err := NewAgreementEmail(loan, investor)
+		assert.NoError(t, err)
+		assert.Equal(t, money.MustNew(want, "USD"), agreement.ExpectedReturn, "%d months", tenor)
+	}
+}
+
+func TestNotificationPreferences_ChannelsFor(t *testing.T) {
+	preferences := NotificationPreferences{
+		RecipientID: "investor-1",
+		Channels:    []Channel{ChannelSMS, ChannelInApp},
+		Muted:       []NotificationKind{NotificationRepaymentDistributed, NotificationRefund},
+	}
+
+	assert.Equal(t, []Channel{ChannelSMS, ChannelInApp}, preferences.ChannelsFor(NotificationInvestmentReceived))
+	assert.Empty(t, preferences.ChannelsFor(NotificationRepaymentDistributed))
+	assert.Equal(t, []Channel{ChannelSMS, ChannelInApp}, preferences.ChannelsFor(NotificationRefund), "refunds cannot be muted")
+	assert.Equal(t, []Channel{ChannelEmail}, NotificationPreferences{}.ChannelsFor(NotificationLoanApproved))
+}
+
+func TestNotificationPreferences_Validate(t *testing.T) {
+	tests := []struct {
+		name        string
+		preferences NotificationPreferences
+		errorMsg    string
+	}{
+		{
+			name:        "Defaults",
+			preferences: DefaultPreferences("borrower-1"),
+		},
+		{
+			name:        "SMS With Phone",
+			preferences: NotificationPreferences{RecipientID: "borrower-1", Phone: "+628123", Channels: []Channel{ChannelSMS}},
+		},
+		{
+			name:        "Missing Recipient",
+			preferences: NotificationPreferences{},
+			errorMsg:    "recipient ID is required",
+		},
+		{
+			name:        "SMS Without Phone",
+			preferences: NotificationPreferences{RecipientID: "borrower-1", Channels: []Channel{ChannelSMS}},
+			errorMsg:    "SMS needs a phone number",
+		},
+		{
+			name:        "Unknown Channel",
+			preferences: NotificationPreferences{RecipientID: "borrower-1", Channels: []Channel{"PIGEON"}},
+			errorMsg:    `unknown channel "PIGEON"`,
+		},
+		{
+			name:        "Unknown Kind",
+			preferences: NotificationPreferences{RecipientID: "borrower-1", Muted: []NotificationKind{"NEWSLETTER"}},
+			errorMsg:    `unknown notification kind "NEWSLETTER"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.preferences.Validate()
+			if tt.errorMsg == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, ErrInvalidPreferences))
+			assert.Contains(t, err.Error(), tt.errorMsg)
+		})
+	}
+}
+
+func TestNotificationPreferences_Address(t *testing.T) {
+	preferences := NotificationPreferences{RecipientID: "investor-1", Phone: "+628123", Locale: "id"}
+	recipient := Recipient{ID: "investor-1", Role: RoleInvestor, Email: "ani@example.com", Locale: "en"}
+
+	addressed := preferences.Address(recipient)
+	assert.Equal(t, Recipient{ID: "investor-1", Role: RoleInvestor, Email: "ani@example.com", Phone: "+628123", Locale: "id"}, addressed)
+	assert.True(t, addressed.Reaches(ChannelSMS))
+	assert.False(t, recipient.Reaches(ChannelSMS))
+}
+
+func TestLoan_InvestorShare(t *testing.T) {
+	loan := &Loan{ID: "loan-1", PrincipalAmount: money.MustNew("1000", "USD"), Rate: money.MustParse("12"), ROI: money.MustParse("9")}
+	repayment := Repayment{
+		Amount:    money.MustNew("111", "USD"),
+		Fee:       money.MustNew("10", "USD"),
+		Interest:  money.MustNew("4", "USD"),
+		Principal: money.MustNew("97", "USD"),
+	}
+
+	share, err := loan.InvestorShare(repayment, Investor{ID: "investor-1", Amount: money.MustNew("333.33", "USD")})
+	assert.NoError(t, err)
+	// The fee is left out and 3 of the 4 interest is at the ROI: 100 × 0.33333 = 33.333 rounded to cents
+	assert.Equal(t, money.MustNew("33.33", "USD"), share)
+
+	// Without a rate no interest was charged to share
+	loan.Rate = money.Zero
+	share, err = loan.InvestorShare(Repayment{Amount: money.MustNew("97", "USD"), Principal: money.MustNew("97", "USD")}, Investor{ID: "investor-1", Amount: money.MustNew("500", "USD")})
+	assert.NoError(t, err)
+	assert.Equal(t, money.MustNew("48.50", "USD"), share)
+
+	_, err = (&Loan{ID: "loan-1"}).InvestorShare(repayment, Investor{ID: "investor-1"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,45 @@
+package loan
+
+import "time"
+
+// OutboxStatus is the delivery state of an outbox message
+type OutboxStatus string
+
+const (
+	OutboxPending OutboxStatus = "PENDING"
+	OutboxSent    OutboxStatus = "SENT"
+	// OutboxDead marks a message that failed every delivery attempt and is no longer retried
+	OutboxDead OutboxStatus = "DEAD"
+)
+
+// OutboxMessage is a notification committed together with the events that cause it and delivered afterwards
+type OutboxMessage struct {
+	Notification
+
+	Status        OutboxStatus `json:"status"`
+	Attempts      int          `json:"attempts"`
+	LastError     string       `json:"last_error,omitempty"`
+	NextAttemptAt time.Time    `json:"next_attempt_at"`
+	CreatedAt     time.Time    `json:"created_at"`
+	SentAt        *time.Time   `json:"sent_at,omitempty"`
+}
+
+// MarkSent records a successful delivery
+func (m *OutboxMessage) MarkSent(at time.Time) {
+	m.Attempts++
+	m.Status = OutboxSent
+	m.LastError = ""
+	m.SentAt = &at
+}
+
+// MarkFailed records a failed delivery. The message is dead-lettered once maxAttempts are used up,
+// otherwise it is retried after backoff, doubled for every earlier attempt.
+func (m *OutboxMessage) MarkFailed(cause error, at time.Time, maxAttempts int, backoff time.Duration) {
+	m.Attempts++
+	m.LastError = cause.Error()
+	if m.Attempts >= maxAttempts {
+		m.Status = OutboxDead
+		return
+	}
+	m.NextAttemptAt = at.Add(backoff << (m.Attempts - 1))
+}
@@ -0,0 +1,38 @@
+package loan
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutboxMessage_MarkFailed(t *testing.T) {
+	at := time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+	message := OutboxMessage{Notification: Notification{ID: "message-1"}, Status: OutboxPending, NextAttemptAt: at}
+
+	// The backoff doubles with every attempt until the last one dead-letters the message
+	for attempt, wait := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute} {
+		message.MarkFailed(errors.New("smtp down"), at, 4, time.Minute)
+		assert.Equal(t, attempt+1, message.Attempts)
+		assert.Equal(t, OutboxPending, message.Status)
+		assert.Equal(t, at.Add(wait), message.NextAttemptAt)
+	}
+
+	message.MarkFailed(errors.New("mailbox full"), at, 4, time.Minute)
+	assert.Equal(t, OutboxDead, message.Status)
+	assert.Equal(t, 4, message.Attempts)
+	assert.Equal(t, "mailbox full", message.LastError)
+}
+
+func TestOutboxMessage_MarkSent(t *testing.T) {
+	at := time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+	message := OutboxMessage{Notification: Notification{ID: "message-1"}, Status: OutboxPending, Attempts: 1, LastError: "smtp down"}
+
+	message.MarkSent(at)
+	assert.Equal(t, OutboxSent, message.Status)
+	assert.Equal(t, 2, message.Attempts)
+	assert.Empty(t, message.LastError)
+	assert.Equal(t, at, *message.SentAt)
+}
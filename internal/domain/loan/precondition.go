@@ -0,0 +1,31 @@
+package loan
+
+import (
+	"strconv"
+	"strings"
+)
+
+// IfMatch holds the versions of a loan a client allows a mutation to apply to, the entity tags of its
+// If-Match header. An empty IfMatch allows any version.
+type IfMatch []int64
+
+// Check returns a *PreconditionError unless the loan is at one of the versions
+func (m IfMatch) Check(loan *Loan) error {
+	if len(m) == 0 {
+		return nil
+	}
+	for _, version := range m {
+		if loan.Version == version {
+			return nil
+		}
+	}
+	return &PreconditionError{LoanID: loan.ID, Expected: m, Actual: loan.Version}
+}
+
+func (m IfMatch) String() string {
+	versions := make([]string, len(m))
+	for i, version := range m {
+		versions[i] = strconv.FormatInt(version, 10)
+	}
+	return strings.Join(versions, " or ")
+}
@@ -0,0 +1,19 @@
+package loan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIfMatch_Check(t *testing.T) {
+	loan := &Loan{ID: "loan-1", Version: 2}
+
+	assert.NoError(t, IfMatch(nil).Check(loan), "an empty IfMatch allows any version")
+	assert.NoError(t, IfMatch{2}.Check(loan))
+	assert.NoError(t, IfMatch{1, 2}.Check(loan))
+
+	err := IfMatch{1, 3}.Check(loan)
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+	assert.EqualError(t, err, "loan loan-1 has been modified since it was retrieved: expected version 1 or 3, found 2")
+}
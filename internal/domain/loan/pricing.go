@@ -0,0 +1,58 @@
+package loan
+
+import (
+	"fmt"
+
+	"github.com/hinha/los-technical/internal/pkg/money"
+)
+
+// PricingInput is what the rate and ROI of a loan are derived from
+type PricingInput struct {
+	Grade     RiskGrade
+	Principal money.Money
+	Terms     RepaymentTerms
+}
+
+// Corridor is the range from Min to Max, both included, a manual rate or ROI must stay in
+type Corridor struct {
+	Min money.Decimal
+	Max money.Decimal
+}
+
+// Contains reports whether the value is within the corridor
+func (c Corridor) Contains(value money.Decimal) bool {
+	return value.Cmp(c.Min) >= 0 && value.Cmp(c.Max) <= 0
+}
+
+// Quote is the pricing of a loan from a rate card: the annual rate the borrower pays and the ROI investors earn,
+// in percent, and the corridors manual values must stay in
+type Quote struct {
+	Rate         money.Decimal
+	ROI          money.Decimal
+	RateCorridor Corridor
+	ROICorridor  Corridor
+	// RateCard is the version of the rate card the loan was priced with
+	RateCard string
+}
+
+// Resolve returns the rate and ROI of the loan: the quoted ones, unless rate or roi is given to set it manually
+// within its corridor. The ROI must stay below the rate so the platform margin is never negative.
+func (q *Quote) Resolve(rate, roi *money.Decimal) (money.Decimal, money.Decimal, error) {
+	resolvedRate, resolvedROI := q.Rate, q.ROI
+	if rate != nil {
+		if !q.RateCorridor.Contains(*rate) {
+			return money.Zero, money.Zero, fmt.Errorf("rate %s is %w of %s to %s", rate, ErrOutsideCorridor, q.RateCorridor.Min, q.RateCorridor.Max)
+		}
+		resolvedRate = *rate
+	}
+	if roi != nil {
+		if !q.ROICorridor.Contains(*roi) {
+			return money.Zero, money.Zero, fmt.Errorf("ROI %s is %w of %s to %s", roi, ErrOutsideCorridor, q.ROICorridor.Min, q.ROICorridor.Max)
+		}
+		resolvedROI = *roi
+	}
+	if resolvedROI.Cmp(resolvedRate) >= 0 {
+		return money.Zero, money.Zero, fmt.Errorf("%w: ROI %s, rate %s", ErrNegativeMargin, resolvedROI, resolvedRate)
+	}
+	return resolvedRate, resolvedROI, nil
+}
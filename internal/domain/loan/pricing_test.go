@@ -0,0 +1,58 @@
+package loan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hinha/los-technical/internal/pkg/money"
+)
+
+func TestQuote_Resolve(t *testing.T) {
+	quote := &Quote{
+		Rate:         money.MustParse("12"),
+		ROI:          money.MustParse("10"),
+		RateCorridor: Corridor{Min: money.MustParse("10"), Max: money.MustParse("14")},
+		ROICorridor:  Corridor{Min: money.MustParse("8.5"), Max: money.MustParse("11.5")},
+		RateCard:     "test",
+	}
+	decimal := func(value string) *money.Decimal {
+		d := money.MustParse(value)
+		return &d
+	}
+
+	tests := []struct {
+		name     string
+		rate     *money.Decimal
+		roi      *money.Decimal
+		wantRate string
+		wantROI  string
+		wantErr  error
+		errMsg   string
+	}{
+		{name: "Quoted values", wantRate: "12", wantROI: "10"},
+		{name: "Manual values within the corridors", rate: decimal("14"), roi: decimal("8.5"), wantRate: "14", wantROI: "8.5"},
+		{name: "Manual rate only", rate: decimal("10.5"), wantRate: "10.5", wantROI: "10"},
+		{name: "Rate below its corridor", rate: decimal("9.99"), wantErr: ErrOutsideCorridor, errMsg: "rate 9.99 is outside the allowed corridor of 10 to 14"},
+		{name: "ROI above its corridor", roi: decimal("12"), wantErr: ErrOutsideCorridor, errMsg: "ROI 12 is outside the allowed corridor of 8.5 to 11.5"},
+		{name: "ROI equal to the rate", rate: decimal("11"), roi: decimal("11"), wantErr: ErrNegativeMargin, errMsg: "ROI must be below the rate: ROI 11, rate 11"},
+		{name: "Manual rate below the quoted ROI", rate: decimal("10"), wantErr: ErrNegativeMargin, errMsg: "ROI must be below the rate: ROI 10, rate 10"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, roi, err := quote.Resolve(tt.rate, tt.roi)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.EqualError(t, err, tt.errMsg)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, money.MustParse(tt.wantRate), rate)
+			assert.Equal(t, money.MustParse(tt.wantROI), roi)
+		})
+	}
+
+	assert.True(t, quote.RateCorridor.Contains(money.MustParse("10")), "the corridor includes its bounds")
+	assert.True(t, quote.RateCorridor.Contains(money.MustParse("14")), "the corridor includes its bounds")
+}
@@ -0,0 +1,221 @@
+package loan
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hinha/los-technical/internal/pkg/money"
+)
+
+// DefaultDaysPastDueThreshold is how many days an installment may stay unpaid before the loan is defaulted
+const DefaultDaysPastDueThreshold = 90
+
+// DefaultReminderLeadDays is how many days before its due date the borrower is reminded of an installment
+const DefaultReminderLeadDays = 3
+
+// Repayment is a borrower payment and how it was allocated over the installments
+type Repayment struct {
+	ID        string      `json:"id"`
+	Amount    money.Money `json:"amount"`
+	Fee       money.Money `json:"fee"`
+	Interest  money.Money `json:"interest"`
+	Principal money.Money `json:"principal"`
+	PaidAt    time.Time   `json:"paid_at"`
+}
+
+// IsSettled reports whether the installment has been paid in full
+func (i Installment) IsSettled() bool {
+	return i.PaidAt != nil
+}
+
+// Outstanding returns the fee, interest and principal still owed on the installment
+func (i Installment) Outstanding() (money.Money, error) {
+	fee, err := i.Fee.Sub(i.FeePaid)
+	if err != nil {
+		return money.Money{}, err
+	}
+	interest, err := i.Interest.Sub(i.InterestPaid)
+	if err != nil {
+		return money.Money{}, err
+	}
+	principal, err := i.Principal.Sub(i.PrincipalPaid)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return money.Sum(i.Principal.Currency, fee, interest, principal)
+}
+
+// Outstanding returns everything still owed on the repayment schedule
+func (l *Loan) Outstanding() (money.Money, error) {
+	amounts := make([]money.Money, 0, len(l.Schedule))
+	for _, installment := range l.Schedule {
+		owed, err := installment.Outstanding()
+		if err != nil {
+			return money.Money{}, err
+		}
+		amounts = append(amounts, owed)
+	}
+	return money.Sum(l.PrincipalAmount.Currency, amounts...)
+}
+
+// IsFullyRepaid reports whether every installment of the schedule is settled
+func (l *Loan) IsFullyRepaid() bool {
+	if len(l.Schedule) == 0 {
+		return false
+	}
+	for _, installment := range l.Schedule {
+		if !installment.IsSettled() {
+			return false
+		}
+	}
+	return true
+}
+
+// DaysPastDue returns how many whole days the oldest unsettled installment is overdue at now, 0 when none is
+func (l *Loan) DaysPastDue(now time.Time) int {
+	today := startOfDay(now)
+	for _, installment := range l.Schedule {
+		if installment.IsSettled() {
+			continue
+		}
+		if !installment.DueDate.Before(today) {
+			return 0
+		}
+		return int(today.Sub(startOfDay(installment.DueDate)).Hours() / 24)
+	}
+	return 0
+}
+
+// DueForReminder returns the unsettled installments the borrower has not been reminded of that fall due
+// within leadDays of now, overdue ones included
+func (l *Loan) DueForReminder(now time.Time, leadDays int) []Installment {
+	until := startOfDay(now).AddDate(0, 0, leadDays+1)
+	var due []Installment
+	for _, installment := range l.Schedule {
+		if installment.IsSettled() || installment.RemindedAt != nil || !installment.DueDate.Before(until) {
+			continue
+		}
+		due = append(due, installment)
+	}
+	return due
+}
+
+// DueForLateFee returns the unsettled installments overdue at now that have not been charged a fee yet
+func (l *Loan) DueForLateFee(now time.Time) []Installment {
+	today := startOfDay(now)
+	var due []Installment
+	for _, installment := range l.Schedule {
+		if installment.IsSettled() || !installment.DueDate.Before(today) || installment.Fee.IsPositive() {
+			continue
+		}
+		due = append(due, installment)
+	}
+	return due
+}
+
+// ChargeFee adds fee to what is owed on the installment, which is no longer settled afterwards
+func (i *Installment) ChargeFee(fee money.Money) error {
+	if !fee.IsPositive() {
+		return fmt.Errorf("%w: fee must be positive", ErrInvalidRepayment)
+	}
+	charged, err := i.Fee.Add(fee)
+	if err != nil {
+		return err
+	}
+	i.Fee = charged
+	i.PaidAt = nil
+	return nil
+}
+
+// ApplyRepayment allocates a payment to the unsettled installments in due order, paying fees first,
+// then interest, then principal. Payments above the outstanding amount are rejected, and the loan is
+// left untouched on error.
+func (l *Loan) ApplyRepayment(id string, amount money.Money, paidAt time.Time) (*Repayment, error) {
+	if l.Schedule == nil {
+		return nil, fmt.Errorf("%w: loan %s is %s", ErrScheduleNotFound, l.ID, l.State)
+	}
+	if amount.Currency != l.PrincipalAmount.Currency {
+		return nil, fmt.Errorf("%w: repayment in %s, loan in %s", money.ErrCurrencyMismatch, amount.Currency, l.PrincipalAmount.Currency)
+	}
+	if !amount.IsPositive() {
+		return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidRepayment)
+	}
+
+	outstanding, err := l.Outstanding()
+	if err != nil {
+		return nil, err
+	}
+	if exceeds, _ := amount.Cmp(outstanding); exceeds > 0 {
+		return nil, fmt.Errorf("%w: %s exceeds the outstanding %s", ErrInvalidRepayment, amount, outstanding)
+	}
+
+	currency := l.PrincipalAmount.Currency
+	repayment := &Repayment{
+		ID:        id,
+		Amount:    amount,
+		Fee:       money.ZeroOf(currency),
+		Interest:  money.ZeroOf(currency),
+		Principal: money.ZeroOf(currency),
+		PaidAt:    paidAt,
+	}
+
+	schedule := make([]Installment, len(l.Schedule))
+	copy(schedule, l.Schedule)
+	remaining := amount
+	for i := range schedule {
+		installment := &schedule[i]
+		if remaining.IsZero() {
+			break
+		}
+		if installment.IsSettled() {
+			continue
+		}
+
+		parts := []struct{ due, paid, allocated *money.Money }{
+			{&installment.Fee, &installment.FeePaid, &repayment.Fee},
+			{&installment.Interest, &installment.InterestPaid, &repayment.Interest},
+			{&installment.Principal, &installment.PrincipalPaid, &repayment.Principal},
+		}
+		for _, part := range parts {
+			if remaining, err = allocate(*part.due, part.paid, part.allocated, remaining); err != nil {
+				return nil, err
+			}
+		}
+
+		owed, err := installment.Outstanding()
+		if err != nil {
+			return nil, err
+		}
+		if owed.IsZero() {
+			settledAt := paidAt
+			installment.PaidAt = &settledAt
+		}
+	}
+
+	l.Schedule = schedule
+	l.Repayments = append(l.Repayments, *repayment)
+	return repayment, nil
+}
+
+// allocate pays as much of due as is still owed out of remaining and returns what is left of remaining
+func allocate(due money.Money, paid, allocated *money.Money, remaining money.Money) (money.Money, error) {
+	owed, err := due.Sub(*paid)
+	if err != nil {
+		return money.Money{}, err
+	}
+	if !owed.IsPositive() {
+		return remaining, nil
+	}
+	payment := owed
+	if more, _ := owed.Cmp(remaining); more > 0 {
+		payment = remaining
+	}
+
+	if *paid, err = paid.Add(payment); err != nil {
+		return money.Money{}, err
+	}
+	if *allocated, err = allocated.Add(payment); err != nil {
+		return money.Money{}, err
+	}
+	return remaining.Sub(payment)
+}
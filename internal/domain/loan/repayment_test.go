@@ -0,0 +1,277 @@
+package loan
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hinha/los-technical/internal/pkg/money"
+)
+
+var disbursedAt = time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC)
+
+// newDisbursedLoan returns a 1,200 USD loan at 12% a year repaid FLAT over 3 months: 400 principal and 4 interest a month
+func newDisbursedLoan(t *testing.T) *Loan {
+	t.Helper()
+	loan := &Loan{
+		ID:              "loan-1",
+		PrincipalAmount: money.MustNew("1200", "USD"),
+		Rate:            money.MustParse("4"),
+		Terms:           RepaymentTerms{TenorMonths: 3, Scheme: SchemeFlat},
+		State:           StateDisbursed,
+	}
+	schedule, err := GenerateSchedule(loan.PrincipalAmount, loan.Rate, loan.Terms, disbursedAt)
+	if err != nil {
+		t.Fatalf("failed to generate schedule: %v", err)
+	}
+	loan.Schedule = schedule
+	return loan
+}
+
+func TestLoan_ApplyRepayment(t *testing.T) {
+	paidAt := disbursedAt.AddDate(0, 1, 0)
+
+	t.Run("Allocates fees, then interest, then principal", func(t *testing.T) {
+		loan := newDisbursedLoan(t)
+		loan.Schedule[0].Fee = money.MustNew("10", "USD")
+
+		repayment, err := loan.ApplyRepayment("repayment-1", money.MustNew("12", "USD"), paidAt)
+		assert.NoError(t, err)
+		assert.Equal(t, money.MustNew("10", "USD"), repayment.Fee)
+		assert.Equal(t, money.MustNew("2", "USD"), repayment.Interest)
+		assert.Equal(t, money.MustNew("0", "USD"), repayment.Principal)
+
+		first := loan.Schedule[0]
+		assert.Equal(t, money.MustNew("10", "USD"), first.FeePaid)
+		assert.Equal(t, money.MustNew("2", "USD"), first.InterestPaid)
+		assert.False(t, first.IsSettled())
+		assert.Len(t, loan.Repayments, 1)
+	})
+
+	t.Run("Settles installments in due order", func(t *testing.T) {
+		loan := newDisbursedLoan(t)
+
+		repayment, err := loan.ApplyRepayment("repayment-1", money.MustNew("500", "USD"), paidAt)
+		assert.NoError(t, err)
+		assert.Equal(t, money.MustNew("8", "USD"), repayment.Interest)
+		assert.Equal(t, money.MustNew("492", "USD"), repayment.Principal)
+
+		assert.True(t, loan.Schedule[0].IsSettled())
+		assert.Equal(t, paidAt, *loan.Schedule[0].PaidAt)
+		assert.False(t, loan.Schedule[1].IsSettled())
+		assert.Equal(t, money.MustNew("92", "USD"), loan.Schedule[1].PrincipalPaid)
+
+		outstanding, err := loan.Outstanding()
+		assert.NoError(t, err)
+		assert.Equal(t, money.MustNew("712", "USD"), outstanding)
+		assert.False(t, loan.IsFullyRepaid())
+	})
+
+	t.Run("Paying the outstanding amount settles the loan", func(t *testing.T) {
+		loan := newDisbursedLoan(t)
+
+		_, err := loan.ApplyRepayment("repayment-1", money.MustNew("1212", "USD"), paidAt)
+		assert.NoError(t, err)
+		assert.True(t, loan.IsFullyRepaid())
+	})
+
+	errorTests := []struct {
+		name   string
+		amount money.Money
+		setup  func(*Loan)
+		want   error
+	}{
+		{
+			name:   "Overpayment",
+			amount: money.MustNew("1212.01", "USD"),
+			want:   ErrInvalidRepayment,
+		},
+		{
+			name:   "Zero Amount",
+			amount: money.MustNew("0", "USD"),
+			want:   ErrInvalidRepayment,
+		},
+		{
+			name:   "Currency Mismatch",
+			amount: money.MustNew("100", "IDR"),
+			want:   money.ErrCurrencyMismatch,
+		},
+		{
+			name:   "Not Disbursed",
+			amount: money.MustNew("100", "USD"),
+			setup:  func(loan *Loan) { loan.Schedule = nil },
+			want:   ErrScheduleNotFound,
+		},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			loan := newDisbursedLoan(t)
+			if tt.setup != nil {
+				tt.setup(loan)
+			}
+			before := loan.Clone()
+
+			repayment, err := loan.ApplyRepayment("repayment-1", tt.amount, paidAt)
+			assert.True(t, errors.Is(err, tt.want), "got %v, want %v", err, tt.want)
+			assert.Nil(t, repayment)
+			assert.Equal(t, before, loan, "a rejected repayment must not change the loan")
+		})
+	}
+}
+
+func TestLoan_DaysPastDue(t *testing.T) {
+	firstDue := time.Date(2025, 2, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		now   time.Time
+		setup func(*Loan)
+		want  int
+	}{
+		{
+			name: "Before First Due Date",
+			now:  firstDue.Add(-time.Hour),
+			want: 0,
+		},
+		{
+			name: "On Due Date",
+			now:  firstDue.Add(20 * time.Hour),
+			want: 0,
+		},
+		{
+			name: "Overdue",
+			now:  firstDue.AddDate(0, 0, 30).Add(time.Hour),
+			want: 30,
+		},
+		{
+			name: "Counts From Oldest Unsettled Installment",
+			now:  firstDue.AddDate(0, 0, 40),
+			setup: func(loan *Loan) {
+				paidAt := firstDue
+				loan.Schedule[0].PaidAt = &paidAt
+			},
+			want: 12,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loan := newDisbursedLoan(t)
+			if tt.setup != nil {
+				tt.setup(loan)
+			}
+			assert.Equal(t, tt.want, loan.DaysPastDue(tt.now))
+		})
+	}
+}
+
+func TestLoan_DueForReminder(t *testing.T) {
+	firstDue := time.Date(2025, 2, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		now   time.Time
+		setup func(*Loan)
+		want  []int
+	}{
+		{
+			name: "Before Lead Time",
+			now:  firstDue.AddDate(0, 0, -4),
+			want: nil,
+		},
+		{
+			name: "Within Lead Time",
+			now:  firstDue.AddDate(0, 0, -3).Add(20 * time.Hour),
+			want: []int{1},
+		},
+		{
+			name: "Includes Overdue Installments",
+			now:  firstDue.AddDate(0, 1, -2),
+			want: []int{1, 2},
+		},
+		{
+			name: "Skips Reminded And Settled Installments",
+			now:  firstDue.AddDate(0, 2, 0),
+			setup: func(loan *Loan) {
+				at := firstDue
+				loan.Schedule[0].PaidAt = &at
+				loan.Schedule[1].RemindedAt = &at
+			},
+			want: []int{3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loan := newDisbursedLoan(t)
+			if tt.setup != nil {
+				tt.setup(loan)
+			}
+			var got []int
+			for _, installment := range loan.DueForReminder(tt.now, DefaultReminderLeadDays) {
+				got = append(got, installment.Number)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLoan_DueForLateFee(t *testing.T) {
+	firstDue := time.Date(2025, 2, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		now   time.Time
+		setup func(*Loan)
+		want  []int
+	}{
+		{
+			name: "On The Due Date",
+			now:  firstDue.Add(20 * time.Hour),
+			want: nil,
+		},
+		{
+			name: "Overdue",
+			now:  firstDue.AddDate(0, 0, 1),
+			want: []int{1},
+		},
+		{
+			name: "Skips Charged And Settled Installments",
+			now:  firstDue.AddDate(0, 2, 1),
+			setup: func(loan *Loan) {
+				at := firstDue
+				loan.Schedule[0].PaidAt = &at
+				loan.Schedule[1].Fee = money.MustNew("10", "USD")
+			},
+			want: []int{3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loan := newDisbursedLoan(t)
+			if tt.setup != nil {
+				tt.setup(loan)
+			}
+			var got []int
+			for _, installment := range loan.DueForLateFee(tt.now) {
+				got = append(got, installment.Number)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestInstallment_ChargeFee(t *testing.T) {
+	loan := newDisbursedLoan(t)
+	installment := loan.Schedule[0]
+	paidAt := disbursedAt
+	installment.PaidAt = &paidAt
+
+	assert.NoError(t, installment.ChargeFee(money.MustNew("10", "USD")))
+	assert.NoError(t, installment.ChargeFee(money.MustNew("5", "USD")))
+	assert.Equal(t, money.MustNew("15", "USD"), installment.Fee)
+	assert.False(t, installment.IsSettled(), "a charged fee is owed again")
+
+	assert.ErrorIs(t, installment.ChargeFee(money.ZeroOf("USD")), ErrInvalidRepayment)
+	assert.ErrorIs(t, installment.ChargeFee(money.MustNew("10", "EUR")), money.ErrCurrencyMismatch)
+}
@@ -1,23 +1,152 @@
 //go:generate mockgen -source=repository.go -destination=mock/repository_mock.go -package provider github.com/hinha/los-technical
 package loan
 
-// LoanRepository defines the interface for loan data persistence
+import "time"
+
+// LoanRepository defines the interface for loan data persistence.
+// Implementations must be safe for concurrent use and return lists ordered by CreatedAt, then ID.
+// The loantest package provides a contract test suite every implementation is expected to pass.
 type LoanRepository interface {
-	// Save persists a new loan to the repository
+	// Save persists a new loan to the repository, failing with ErrLoanAlreadyExists if the ID is taken.
+	// The stored loan starts at version 1 and loan.Version is set accordingly.
 	Save(loan *Loan) error
 
-	// FindByID retrieves a loan by its ID
+	// FindByID retrieves a loan by its ID, failing with ErrLoanNotFound if it does not exist
 	FindByID(id string) (*Loan, error)
 
-	// Update updates an existing loan in the repository
+	// Update updates an existing loan in the repository, failing with ErrLoanNotFound if it does not exist.
+	// It is a compare-and-swap on loan.Version: a *VersionConflictError is returned when the stored
+	// version differs, otherwise the version is incremented and written back to loan.Version.
 	Update(loan *Loan) error
 
 	// FindByBorrowerID retrieves all loans for a specific borrower
 	FindByBorrowerID(borrowerID string) ([]*Loan, error)
 
+	// FindByInvestorID retrieves all loans the investor has invested in
+	FindByInvestorID(investorID string) ([]*Loan, error)
+
 	// FindByState retrieves all loans in a specific state
 	FindByState(state LoanState) ([]*Loan, error)
 
-	// FindAll retrieves all loans with pagination
-	FindAll(page, limit int) ([]*Loan, error)
+	// FindAll retrieves the loans matching the filter with pagination, page numbers start at 1
+	FindAll(filter LoanFilter, page, limit int) ([]*Loan, error)
+}
+
+// InvestorRepository keeps the profiles of investors.
+// Implementations must be safe for concurrent use.
+type InvestorRepository interface {
+	// Save stores a new investor, failing with ErrInvestorAlreadyExists if the ID is taken
+	Save(investor *InvestorProfile) error
+
+	// FindByID retrieves an investor, failing with ErrInvestorNotFound if it does not exist
+	FindByID(id string) (*InvestorProfile, error)
+
+	// Update replaces a stored investor, failing with ErrInvestorNotFound if it does not exist
+	Update(investor *InvestorProfile) error
+}
+
+// BorrowerRepository keeps the borrowers.
+// Implementations must be safe for concurrent use and return lists ordered by CreatedAt, then ID.
+type BorrowerRepository interface {
+	// Save stores a new borrower, failing with ErrBorrowerAlreadyExists if the ID is taken
+	Save(borrower *Borrower) error
+
+	// FindByID retrieves a borrower, failing with ErrBorrowerNotFound if it does not exist
+	FindByID(id string) (*Borrower, error)
+
+	// Update replaces a stored borrower, failing with ErrBorrowerNotFound if it does not exist
+	Update(borrower *Borrower) error
+
+	// Delete removes a borrower, failing with ErrBorrowerNotFound if it does not exist
+	Delete(id string) error
+
+	// FindAll retrieves the borrowers with pagination, page numbers start at 1
+	FindAll(page, limit int) ([]*Borrower, error)
+}
+
+// AuditLog is the append-only history of loan mutations.
+// Implementations must be safe for concurrent use and never change or remove an appended entry.
+type AuditLog interface {
+	// Append stores a new entry at the end of the history of its loan
+	Append(entry AuditEntry) error
+
+	// FindByLoanID retrieves the entries of a loan in the order they were appended, empty when there are none
+	FindByLoanID(loanID string) ([]AuditEntry, error)
+}
+
+// EventStore is the append-only stream of loan events the loans are rebuilt from.
+// Implementations must be safe for concurrent use.
+type EventStore interface {
+	// Append adds the events of one command to the stream of a loan. It is a compare-and-swap on the stream
+	// version: a *VersionConflictError is returned when the last stored event of the loan is not at
+	// expectedVersion, 0 for a new loan. Every event must belong to the loan and be at expectedVersion+1.
+	Append(loanID string, expectedVersion int64, events ...Event) error
+
+	// Commit appends the events like Append and enqueues the outbox messages in the same write,
+	// so either both are stored or neither is
+	Commit(loanID string, expectedVersion int64, events []Event, messages []OutboxMessage) error
+
+	// Load retrieves the events of a loan in the order they were appended, empty when there are none
+	Load(loanID string) ([]Event, error)
+
+	// LoadAll retrieves the events of every loan in the order they were appended
+	LoadAll() ([]Event, error)
+}
+
+// Outbox holds the notifications committed with loan events until they are delivered.
+// Implementations must be safe for concurrent use.
+type Outbox interface {
+	// FindDue retrieves up to limit pending messages whose next attempt is due at now, oldest first
+	FindDue(now time.Time, limit int) ([]OutboxMessage, error)
+
+	// Update stores the delivery state of a message
+	// Returns ErrOutboxMessageNotFound when the message does not exist
+	Update(message OutboxMessage) error
+
+	// FindByStatus retrieves the messages in the status, oldest first
+	FindByStatus(status OutboxStatus) ([]OutboxMessage, error)
+}
+
+// PreferenceStore keeps the notification preferences of borrowers and investors.
+// Implementations must be safe for concurrent use.
+type PreferenceStore interface {
+	// Find retrieves the preferences of a recipient
+	// Returns ErrPreferencesNotFound when the recipient has not set any
+	Find(recipientID string) (*NotificationPreferences, error)
+
+	// Save creates or replaces the preferences of a recipient
+	Save(preferences NotificationPreferences) error
+}
+
+// DocumentStore keeps the files of loans, e.g. their generated agreement letters and uploaded proofs.
+// Implementations must be safe for concurrent use.
+type DocumentStore interface {
+	// Save stores the content as a new document and returns it with its ID, size, checksum, URL and creation time set
+	Save(document Document, content []byte) (*Document, error)
+
+	// Find retrieves a document
+	// Returns ErrDocumentNotFound when no document has the ID
+	Find(id string) (*Document, error)
+
+	// Read retrieves the content of a document
+	// Returns ErrDocumentNotFound when no document has the ID
+	Read(id string) ([]byte, error)
+
+	// Delete removes a document, for one that was saved but never referenced
+	// Returns ErrDocumentNotFound when no document has the ID
+	Delete(id string) error
+}
+
+// Inbox keeps the notifications delivered over the in-app channel.
+// Implementations must be safe for concurrent use.
+type Inbox interface {
+	// Add stores an item, an item whose ID is already stored is left as it is
+	Add(item InboxItem) error
+
+	// FindByRecipient retrieves the items of a recipient, newest first
+	FindByRecipient(recipientID string) ([]InboxItem, error)
+
+	// MarkRead records when the recipient read an item, an item already read keeps its first time
+	// Returns ErrInboxItemNotFound when the recipient has no item with the ID
+	MarkRead(recipientID, id string, at time.Time) error
 }
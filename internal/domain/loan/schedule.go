@@ -0,0 +1,216 @@
+package loan
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/hinha/los-technical/internal/pkg/money"
+)
+
+// RepaymentScheme decides how principal and interest are spread over the installments
+type RepaymentScheme string
+
+const (
+	// SchemeFlat charges interest on the original principal every month and repays principal in equal parts
+	SchemeFlat RepaymentScheme = "FLAT"
+	// SchemeAnnuity charges interest on the outstanding balance with equal total installments
+	SchemeAnnuity RepaymentScheme = "ANNUITY"
+	// SchemeBullet charges interest every month and repays the whole principal with the last installment
+	SchemeBullet RepaymentScheme = "BULLET"
+)
+
+const (
+	// DefaultTenorMonths is the tenor used when a loan is created without one
+	DefaultTenorMonths = 12
+	// DefaultRepaymentScheme is the scheme used when a loan is created without one
+	DefaultRepaymentScheme = SchemeAnnuity
+	// MaxTenorMonths is the longest supported tenor
+	MaxTenorMonths = 360
+)
+
+// RepaymentTerms describe how a loan is paid back once disbursed
+type RepaymentTerms struct {
+	TenorMonths int             `json:"tenor_months" example:"12"`
+	Scheme      RepaymentScheme `json:"scheme" example:"ANNUITY"`
+}
+
+// Validate checks the tenor and scheme
+func (t RepaymentTerms) Validate() error {
+	if t.TenorMonths < 1 || t.TenorMonths > MaxTenorMonths {
+		return fmt.Errorf("%w: tenor must be between 1 and %d months, got %d", ErrInvalidRepaymentTerms, MaxTenorMonths, t.TenorMonths)
+	}
+	switch t.Scheme {
+	case SchemeFlat, SchemeAnnuity, SchemeBullet:
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown repayment scheme %q", ErrInvalidRepaymentTerms, t.Scheme)
+	}
+}
+
+// Installment is a single scheduled repayment, the schedule is generated when a loan is disbursed
+type Installment struct {
+	Number    int         `json:"number"`
+	DueDate   time.Time   `json:"due_date"`
+	Principal money.Money `json:"principal"`
+	Interest  money.Money `json:"interest"`
+	// Total is the scheduled principal plus interest, fees charged later come on top
+	Total money.Money `json:"total"`
+	// Balance is the principal still outstanding once this installment is paid
+	Balance money.Money `json:"balance"`
+	// Fee holds the late fees charged on the installment once it is overdue
+	Fee money.Money `json:"fee"`
+
+	FeePaid       money.Money `json:"fee_paid"`
+	InterestPaid  money.Money `json:"interest_paid"`
+	PrincipalPaid money.Money `json:"principal_paid"`
+	// PaidAt is set once fee, interest and principal are paid in full
+	PaidAt *time.Time `json:"paid_at,omitempty"`
+	// RemindedAt is set once the borrower has been reminded that the installment falls due
+	RemindedAt *time.Time `json:"reminded_at,omitempty"`
+}
+
+// GenerateSchedule builds the monthly installments of a loan disbursed at the given time.
+// The rate is an annual percentage, e.g. 12.5 for 12.5% a year.
+// Every amount is rounded to the minor units of the principal currency and the last installment
+// absorbs any rounding difference, so the principal parts always add up to the principal.
+func GenerateSchedule(principal money.Money, annualRate money.Decimal, terms RepaymentTerms, disbursedAt time.Time) ([]Installment, error) {
+	if err := terms.Validate(); err != nil {
+		return nil, err
+	}
+	if !principal.IsPositive() {
+		return nil, fmt.Errorf("%w: principal must be positive", ErrInvalidRepaymentTerms)
+	}
+	if annualRate.Sign() < 0 {
+		return nil, fmt.Errorf("%w: rate must not be negative", ErrInvalidRepaymentTerms)
+	}
+
+	// Monthly rate as an exact fraction: annual percentage / 100 / 12
+	monthlyRate := new(big.Rat).Quo(annualRate.Rat(), big.NewRat(1200, 1))
+
+	var payments paymentFunc
+	switch terms.Scheme {
+	case SchemeFlat:
+		payments = flatPayments(principal, monthlyRate, terms.TenorMonths)
+	case SchemeAnnuity:
+		payments = annuityPayments(principal, monthlyRate, terms.TenorMonths)
+	case SchemeBullet:
+		payments = bulletPayments(principal, monthlyRate, terms.TenorMonths)
+	}
+
+	start := startOfDay(disbursedAt)
+	balance := principal
+	schedule := make([]Installment, 0, terms.TenorMonths)
+	for number := 1; number <= terms.TenorMonths; number++ {
+		principalPart, interest, err := payments(balance, number)
+		if err != nil {
+			return nil, err
+		}
+
+		// Rounded parts must never repay more than is outstanding, and the last
+		// installment clears whatever is left after rounding
+		if exceeds, _ := principalPart.Cmp(balance); exceeds > 0 || number == terms.TenorMonths {
+			principalPart = balance
+		}
+
+		if balance, err = balance.Sub(principalPart); err != nil {
+			return nil, err
+		}
+		total, err := principalPart.Add(interest)
+		if err != nil {
+			return nil, err
+		}
+
+		schedule = append(schedule, Installment{
+			Number:    number,
+			DueDate:   addMonths(start, number),
+			Principal: principalPart,
+			Interest:  interest,
+			Total:     total,
+			Balance:   balance,
+
+			Fee:           money.ZeroOf(principal.Currency),
+			FeePaid:       money.ZeroOf(principal.Currency),
+			InterestPaid:  money.ZeroOf(principal.Currency),
+			PrincipalPaid: money.ZeroOf(principal.Currency),
+		})
+	}
+	return schedule, nil
+}
+
+// paymentFunc returns the principal and interest due for an installment given the outstanding balance
+type paymentFunc func(balance money.Money, number int) (principal, interest money.Money, err error)
+
+func flatPayments(principal money.Money, monthlyRate *big.Rat, tenor int) paymentFunc {
+	return func(balance money.Money, number int) (money.Money, money.Money, error) {
+		part, err := money.FromRat(new(big.Rat).Quo(principal.Amount.Rat(), big.NewRat(int64(tenor), 1)), principal.Currency)
+		if err != nil {
+			return money.Money{}, money.Money{}, err
+		}
+		interest, err := money.FromRat(new(big.Rat).Mul(principal.Amount.Rat(), monthlyRate), principal.Currency)
+		if err != nil {
+			return money.Money{}, money.Money{}, err
+		}
+		return part, interest, nil
+	}
+}
+
+func annuityPayments(principal money.Money, monthlyRate *big.Rat, tenor int) paymentFunc {
+	// Level payment P*r*(1+r)^n / ((1+r)^n - 1), or P/n without interest
+	var payment *big.Rat
+	if monthlyRate.Sign() == 0 {
+		payment = new(big.Rat).Quo(principal.Amount.Rat(), big.NewRat(int64(tenor), 1))
+	} else {
+		growth := new(big.Rat).Add(big.NewRat(1, 1), monthlyRate)
+		compound := big.NewRat(1, 1)
+		for i := 0; i < tenor; i++ {
+			compound.Mul(compound, growth)
+		}
+		payment = new(big.Rat).Mul(principal.Amount.Rat(), monthlyRate)
+		payment.Mul(payment, compound)
+		payment.Quo(payment, new(big.Rat).Sub(compound, big.NewRat(1, 1)))
+	}
+
+	return func(balance money.Money, number int) (money.Money, money.Money, error) {
+		level, err := money.FromRat(payment, principal.Currency)
+		if err != nil {
+			return money.Money{}, money.Money{}, err
+		}
+		interest, err := money.FromRat(new(big.Rat).Mul(balance.Amount.Rat(), monthlyRate), principal.Currency)
+		if err != nil {
+			return money.Money{}, money.Money{}, err
+		}
+		part, err := level.Sub(interest)
+		if err != nil {
+			return money.Money{}, money.Money{}, err
+		}
+		return part, interest, nil
+	}
+}
+
+func bulletPayments(principal money.Money, monthlyRate *big.Rat, tenor int) paymentFunc {
+	return func(balance money.Money, number int) (money.Money, money.Money, error) {
+		interest, err := money.FromRat(new(big.Rat).Mul(principal.Amount.Rat(), monthlyRate), principal.Currency)
+		if err != nil {
+			return money.Money{}, money.Money{}, err
+		}
+		// Principal is repaid by the last installment, which takes the whole balance
+		return money.ZeroOf(principal.Currency), interest, nil
+	}
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// addMonths adds whole months, clamping to the last day of shorter months (Jan 31 + 1 month = Feb 28)
+func addMonths(t time.Time, months int) time.Time {
+	year, month, day := t.Date()
+	firstOfTarget := time.Date(year, month+time.Month(months), 1, 0, 0, 0, 0, t.Location())
+	lastDay := firstOfTarget.AddDate(0, 1, -1).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(firstOfTarget.Year(), firstOfTarget.Month(), day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
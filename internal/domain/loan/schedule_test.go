@@ -0,0 +1,144 @@
+package loan
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hinha/los-technical/internal/pkg/money"
+)
+
+func TestGenerateSchedule(t *testing.T) {
+	disbursedAt := time.Date(2025, 1, 31, 15, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		principal money.Money
+		rate      money.Decimal
+		terms     RepaymentTerms
+		// want lists principal and interest of selected installments by number
+		want map[int][2]string
+	}{
+		{
+			name:      "Annuity",
+			principal: money.MustNew("1000000", "IDR"),
+			rate:      money.MustParse("12"),
+			terms:     RepaymentTerms{TenorMonths: 12, Scheme: SchemeAnnuity},
+			want: map[int][2]string{
+				1:  {"78849", "10000"},
+				2:  {"79637", "9212"},
+				12: {"87967", "880"},
+			},
+		},
+		{
+			name:      "Annuity Without Interest",
+			principal: money.MustNew("100.00", "USD"),
+			rate:      money.Zero,
+			terms:     RepaymentTerms{TenorMonths: 3, Scheme: SchemeAnnuity},
+			want: map[int][2]string{
+				1: {"33.33", "0"},
+				2: {"33.33", "0"},
+				3: {"33.34", "0"},
+			},
+		},
+		{
+			name:      "Flat",
+			principal: money.MustNew("1200.00", "USD"),
+			rate:      money.MustParse("12.5"),
+			terms:     RepaymentTerms{TenorMonths: 6, Scheme: SchemeFlat},
+			want: map[int][2]string{
+				1: {"200", "12.5"},
+				6: {"200", "12.5"},
+			},
+		},
+		{
+			name:      "Flat Rounding Never Overpays",
+			principal: money.MustNew("2", "IDR"),
+			rate:      money.Zero,
+			terms:     RepaymentTerms{TenorMonths: 4, Scheme: SchemeFlat},
+			want: map[int][2]string{
+				1: {"1", "0"},
+				2: {"1", "0"},
+				3: {"0", "0"},
+				4: {"0", "0"},
+			},
+		},
+		{
+			name:      "Bullet",
+			principal: money.MustNew("1000000", "IDR"),
+			rate:      money.MustParse("10"),
+			terms:     RepaymentTerms{TenorMonths: 3, Scheme: SchemeBullet},
+			want: map[int][2]string{
+				1: {"0", "8333"},
+				2: {"0", "8333"},
+				3: {"1000000", "8333"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := GenerateSchedule(tt.principal, tt.rate, tt.terms, disbursedAt)
+			assert.NoError(t, err)
+			if !assert.Len(t, schedule, tt.terms.TenorMonths) {
+				return
+			}
+
+			repaid := money.ZeroOf(tt.principal.Currency)
+			for i, installment := range schedule {
+				assert.Equal(t, i+1, installment.Number)
+				total, _ := installment.Principal.Add(installment.Interest)
+				assert.Equal(t, total, installment.Total)
+				repaid, _ = repaid.Add(installment.Principal)
+				remaining, _ := tt.principal.Sub(repaid)
+				assert.Equal(t, remaining, installment.Balance)
+				assert.NoError(t, installment.Principal.Validate(), "installment %d", installment.Number)
+				assert.NoError(t, installment.Interest.Validate(), "installment %d", installment.Number)
+				assert.GreaterOrEqual(t, installment.Principal.Amount.Sign(), 0)
+			}
+			assert.Equal(t, tt.principal, repaid, "principal parts must add up to the principal")
+			assert.True(t, schedule[len(schedule)-1].Balance.IsZero())
+
+			for number, want := range tt.want {
+				installment := schedule[number-1]
+				assert.Equal(t, want[0], installment.Principal.Amount.String(), "principal of installment %d", number)
+				assert.Equal(t, want[1], installment.Interest.Amount.String(), "interest of installment %d", number)
+			}
+		})
+	}
+}
+
+func TestGenerateSchedule_DueDates(t *testing.T) {
+	disbursedAt := time.Date(2025, 1, 31, 15, 4, 5, 0, time.UTC)
+	schedule, err := GenerateSchedule(money.MustNew("1000", "IDR"), money.MustParse("12"), RepaymentTerms{TenorMonths: 3, Scheme: SchemeAnnuity}, disbursedAt)
+	assert.NoError(t, err)
+
+	// Due dates fall on the same day of the month, clamped to the end of shorter months
+	assert.Equal(t, time.Date(2025, 2, 28, 0, 0, 0, 0, time.UTC), schedule[0].DueDate)
+	assert.Equal(t, time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC), schedule[1].DueDate)
+	assert.Equal(t, time.Date(2025, 4, 30, 0, 0, 0, 0, time.UTC), schedule[2].DueDate)
+}
+
+func TestGenerateSchedule_InvalidTerms(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal money.Money
+		rate      money.Decimal
+		terms     RepaymentTerms
+	}{
+		{"Zero Tenor", money.MustNew("1000", "IDR"), money.MustParse("12"), RepaymentTerms{TenorMonths: 0, Scheme: SchemeFlat}},
+		{"Tenor Too Long", money.MustNew("1000", "IDR"), money.MustParse("12"), RepaymentTerms{TenorMonths: MaxTenorMonths + 1, Scheme: SchemeFlat}},
+		{"Unknown Scheme", money.MustNew("1000", "IDR"), money.MustParse("12"), RepaymentTerms{TenorMonths: 12, Scheme: "BALLOON"}},
+		{"Zero Principal", money.ZeroOf("IDR"), money.MustParse("12"), RepaymentTerms{TenorMonths: 12, Scheme: SchemeFlat}},
+		{"Negative Rate", money.MustNew("1000", "IDR"), money.MustParse("-1"), RepaymentTerms{TenorMonths: 12, Scheme: SchemeFlat}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := GenerateSchedule(tt.principal, tt.rate, tt.terms, time.Now())
+			assert.True(t, errors.Is(err, ErrInvalidRepaymentTerms), "got %v", err)
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package loan
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RiskGrade ranks the credit risk of a loan from A, the lowest risk, to E, the highest
+type RiskGrade string
+
+const (
+	GradeA RiskGrade = "A"
+	GradeB RiskGrade = "B"
+	GradeC RiskGrade = "C"
+	GradeD RiskGrade = "D"
+	GradeE RiskGrade = "E"
+)
+
+// DefaultMinimumGrade is the riskiest grade a loan may have to be approved without an override
+const DefaultMinimumGrade = GradeC
+
+// riskGrades lists the grades from the lowest risk to the highest
+var riskGrades = []RiskGrade{GradeA, GradeB, GradeC, GradeD, GradeE}
+
+// RiskGrades returns every grade from the lowest risk to the highest
+func RiskGrades() []RiskGrade {
+	return append([]RiskGrade(nil), riskGrades...)
+}
+
+// rank returns the position of the grade from the lowest risk, -1 for an unknown grade
+func (g RiskGrade) rank() int {
+	for i, grade := range riskGrades {
+		if grade == g {
+			return i
+		}
+	}
+	return -1
+}
+
+// Validate checks that the grade is one of A to E
+func (g RiskGrade) Validate() error {
+	if g.rank() < 0 {
+		return fmt.Errorf("%w: %q", ErrInvalidRiskGrade, g)
+	}
+	return nil
+}
+
+// AtLeast reports whether the grade carries no more risk than minimum
+func (g RiskGrade) AtLeast(minimum RiskGrade) bool {
+	rank := g.rank()
+	return rank >= 0 && rank <= minimum.rank()
+}
+
+// CreditScore is the credit risk of a loan as assessed when it was scored
+type CreditScore struct {
+	// Score ranges from 0, the highest risk, to 1000
+	Score int       `json:"score" example:"640"`
+	Grade RiskGrade `json:"grade" example:"B"`
+	// Reasons are the codes of what lowered the score, e.g. HIGH_DEBT_TO_INCOME
+	Reasons []string `json:"reasons"`
+	// Scorecard is the version of the scorecard the loan was scored with
+	Scorecard string    `json:"scorecard" example:"2025-01"`
+	ScoredAt  time.Time `json:"scored_at"`
+}
+
+// ScoringInput is what a loan is scored on
+type ScoringInput struct {
+	Loan     *Loan
+	Borrower *Borrower
+	// Profile is the credit profile of the borrower from their loans other than the scored one
+	Profile *CreditProfile
+}
+
+// CheckApproval returns why a loan scored with this grade may not be approved: its grade is riskier than minimum
+// and no justification overrides it. A blank justification does not override.
+func (s *CreditScore) CheckApproval(loanID string, minimum RiskGrade, overrideJustification string) error {
+	if s.Grade.AtLeast(minimum) || strings.TrimSpace(overrideJustification) != "" {
+		return nil
+	}
+	return fmt.Errorf("%w: loan %s is graded %s, approval requires %s or better unless overridden with a justification",
+		ErrRiskGradeTooLow, loanID, s.Grade, minimum)
+}
@@ -0,0 +1,30 @@
+package loan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRiskGrade_AtLeast(t *testing.T) {
+	assert.True(t, GradeA.AtLeast(GradeC))
+	assert.True(t, GradeC.AtLeast(GradeC))
+	assert.False(t, GradeD.AtLeast(GradeC))
+	assert.False(t, RiskGrade("Z").AtLeast(GradeE), "an unknown grade never qualifies")
+
+	assert.NoError(t, GradeE.Validate())
+	assert.ErrorIs(t, RiskGrade("F").Validate(), ErrInvalidRiskGrade)
+	assert.ErrorIs(t, RiskGrade("").Validate(), ErrInvalidRiskGrade)
+}
+
+func TestCreditScore_CheckApproval(t *testing.T) {
+	score := &CreditScore{Score: 420, Grade: GradeD}
+
+	err := score.CheckApproval("loan-1", GradeC, "")
+	assert.ErrorIs(t, err, ErrRiskGradeTooLow)
+	assert.EqualError(t, err, "risk grade below the approval minimum: loan loan-1 is graded D, approval requires C or better unless overridden with a justification")
+
+	assert.NoError(t, score.CheckApproval("loan-1", GradeC, "Collateral covers the principal twice"))
+	assert.ErrorIs(t, score.CheckApproval("loan-1", GradeC, " \t\n"), ErrRiskGradeTooLow)
+	assert.NoError(t, score.CheckApproval("loan-1", GradeD, ""))
+}
@@ -1,20 +1,99 @@
 //go:generate mockgen -source=service.go -destination=mock/service_mock.go -package provider github.com/hinha/los-technical
 package loan
 
-// EmailSender defines the interface for sending emails
-type EmailSender interface {
-	SendAgreementEmail(email, loanID, agreementURL string) error
+import "github.com/hinha/los-technical/internal/pkg/money"
+
+// Notifier delivers a notification to its recipient over the channels they prefer
+type Notifier interface {
+	Notify(notification Notification) error
+}
+
+// NotificationChannel delivers notifications over one channel, e.g. email or SMS
+type NotificationChannel interface {
+	Channel() Channel
+	Send(notification Notification) error
+}
+
+// EmailRenderer renders the emails of notifications
+type EmailRenderer interface {
+	// RenderNotification renders the email of the notification in the locale of its recipient,
+	// falling back to the default locale
+	RenderNotification(notification Notification) (*RenderedEmail, error)
+}
+
+// AgreementRenderer renders the agreement letters of loans
+type AgreementRenderer interface {
+	// RenderAgreement returns the agreement letter of the loan as a PDF document
+	RenderAgreement(loan *Loan) ([]byte, error)
+}
+
+// AgreementVerifier checks signed agreements against the agreement letters they were signed from
+type AgreementVerifier interface {
+	// VerifySignedAgreement checks that signed is the agreement letter of the loan with the signatures of its
+	// parties added. Returns an *AgreementVerificationError naming every problem found when it is not.
+	VerifySignedAgreement(loan *Loan, agreement, signed []byte) error
+}
+
+// CreditScorer assesses the credit risk of loans
+type CreditScorer interface {
+	// Score returns the score, risk grade and reason codes of the loan
+	Score(input ScoringInput) (*CreditScore, error)
+}
+
+// PricingEngine prices loans from a rate card
+type PricingEngine interface {
+	// Price returns the rate and ROI of a loan of the grade, principal and terms, and the corridors around them
+	Price(input PricingInput) (*Quote, error)
+}
+
+// NotificationService delivers notifications and manages what recipients receive
+type NotificationService interface {
+	Notifier
+	GetPreferences(recipientID string) (*NotificationPreferences, error)
+	SetPreferences(preferences NotificationPreferences) error
+	GetInbox(recipientID string) ([]InboxItem, error)
+	MarkRead(recipientID, id string) error
+}
+
+// InvestorService registers investors, reviews their KYC and reports what they have funded
+type InvestorService interface {
+	RegisterInvestor(investor InvestorProfile) (*InvestorProfile, error)
+	GetInvestor(id string) (*InvestorProfile, error)
+	UpdateInvestor(investor InvestorProfile) (*InvestorProfile, error)
+	SetKYCStatus(id string, status KYCStatus) (*InvestorProfile, error)
+	GetPortfolio(id string) (*Portfolio, error)
+}
+
+// BorrowerService registers borrowers and reports their credit profile and loan history
+type BorrowerService interface {
+	RegisterBorrower(borrower Borrower) (*Borrower, error)
+	GetBorrower(id string) (*BorrowerDetails, error)
+	GetBorrowers(page, limit int) ([]*Borrower, error)
+	UpdateBorrower(borrower Borrower) (*Borrower, error)
+	DeleteBorrower(id string) error
 }
 
-// Service defines the interface for loan operations
+// Service defines the interface for loan operations.
+// A mutation taking an IfMatch only applies to the loan at one of its versions and returns the version it stored.
 type Service interface {
-	CreateLoan(borrowerID string, principal, rate, roi float64) (*Loan, error)
-	ApproveLoan(id, validatorID, proofURL string) error
-	AddInvestment(id, investorID, email string, amount float64) error
-	DisburseLoan(id, fieldOfficerID, signedAgreement string) error
-	GenerateAgreementLetter(id string, letterURL string) error
+	CreateLoan(borrowerID string, principal money.Money, rate, roi *money.Decimal, terms RepaymentTerms) (*Loan, error)
+	ApproveLoan(id, validatorID, proofDocumentID, overrideJustification string, match IfMatch) (int64, error)
+	RejectLoan(id, actorID string, reason ReasonCode, match IfMatch) (int64, error)
+	CancelLoan(id, actorID string, reason ReasonCode, match IfMatch) (int64, error)
+	AddInvestment(id string, investor Investor, match IfMatch) (int64, error)
+	DisburseLoan(id, fieldOfficerID, signedAgreementDocumentID string, match IfMatch) (int64, error)
+	GenerateAgreementLetter(id string, match IfMatch) (*Document, int64, error)
+	GetAgreements(id string) ([]AgreementVersion, error)
+	UploadDocument(id string, upload DocumentUpload) (*Document, error)
 	GetLoan(id string) (*Loan, error)
-	GetLoansByBorrower(borrowerID string) ([]*Loan, error)
-	GetLoansByState(state LoanState) ([]*Loan, error)
-	GetLoans(page, limit int) ([]*Loan, error)
+	GetSchedule(id string) ([]Installment, error)
+	GetTransitions(id string) ([]NextTransition, error)
+	GetHistory(id string) ([]AuditEntry, error)
+	RecordRepayment(id string, amount money.Money, match IfMatch) (*Repayment, int64, error)
+	MarkDefaultedLoans() ([]*Loan, error)
+	RemindDueInstallments() (int, error)
+	ChargeLateFees() (int, error)
+	GetLoansByBorrower(borrowerID string, filter LoanFilter) ([]*Loan, error)
+	GetLoansByState(state LoanState, filter LoanFilter) ([]*Loan, error)
+	GetLoans(filter LoanFilter, page, limit int) ([]*Loan, error)
 }
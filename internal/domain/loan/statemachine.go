@@ -0,0 +1,251 @@
+package loan
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Action is an operation that may move a loan to another state
+type Action string
+
+const (
+	ActionApprove  Action = "APPROVE"
+	ActionReject   Action = "REJECT"
+	ActionCancel   Action = "CANCEL"
+	ActionInvest   Action = "INVEST"
+	ActionDisburse Action = "DISBURSE"
+	ActionRepay    Action = "REPAY"
+	ActionDefault  Action = "DEFAULT"
+)
+
+// Effect is a side effect of a transition. Its notifications are committed to the outbox with the
+// events of the transition and delivered once they are stored.
+type Effect string
+
+const (
+	// EffectNotifyApproval tells the borrower that the loan is approved
+	EffectNotifyApproval Effect = "NOTIFY_APPROVAL"
+	// EffectNotifyInvestment tells the investor that their investment is received
+	EffectNotifyInvestment Effect = "NOTIFY_INVESTMENT"
+	// EffectNotifyFunded tells the borrower that the loan is fully funded
+	EffectNotifyFunded Effect = "NOTIFY_FUNDED"
+	// EffectSendAgreements mails the agreement letter to every investor
+	EffectSendAgreements Effect = "SEND_AGREEMENTS"
+	// EffectNotifyDisbursement tells the borrower and every investor that the loan is disbursed
+	EffectNotifyDisbursement Effect = "NOTIFY_DISBURSEMENT"
+	// EffectDistributeRepayment tells every investor their share of a repayment
+	EffectDistributeRepayment Effect = "DISTRIBUTE_REPAYMENT"
+	// EffectNotifyRefunds tells every refunded investor that their investment is returned
+	EffectNotifyRefunds Effect = "NOTIFY_REFUNDS"
+)
+
+// Guard returns why a transition may not be taken, or nil when it may
+type Guard func(loan *Loan) error
+
+// Transition is one row of the transition table
+type Transition struct {
+	Action Action
+	From   LoanState
+	To     LoanState
+	// Guards must all pass for the transition to be taken
+	Guards []Guard
+	// Effects are delivered after the loan has been stored in its new state
+	Effects []Effect
+}
+
+// transitions is the loan lifecycle. When an action has several rows for the same state,
+// the first one whose guards pass is taken, so conditional targets come before self-loops.
+var transitions = []Transition{
+	{Action: ActionApprove, From: StateProposed, To: StateApproved, Effects: []Effect{EffectNotifyApproval}},
+	{Action: ActionReject, From: StateProposed, To: StateRejected},
+	{Action: ActionCancel, From: StateProposed, To: StateCancelled, Effects: []Effect{EffectNotifyRefunds}},
+	{Action: ActionCancel, From: StateApproved, To: StateCancelled, Effects: []Effect{EffectNotifyRefunds}},
+	{Action: ActionInvest, From: StateApproved, To: StateInvested, Guards: []Guard{hasAgreement, fullyFunded}, Effects: []Effect{EffectNotifyInvestment, EffectNotifyFunded, EffectSendAgreements}},
+	{Action: ActionInvest, From: StateApproved, To: StateApproved, Guards: []Guard{hasAgreement}, Effects: []Effect{EffectNotifyInvestment}},
+	{Action: ActionDisburse, From: StateInvested, To: StateDisbursed, Guards: []Guard{validRepaymentTerms}, Effects: []Effect{EffectNotifyDisbursement}},
+	{Action: ActionRepay, From: StateDisbursed, To: StateRepaid, Guards: []Guard{hasSchedule, fullyRepaid}, Effects: []Effect{EffectDistributeRepayment}},
+	{Action: ActionRepay, From: StateDisbursed, To: StateDisbursed, Guards: []Guard{hasSchedule}, Effects: []Effect{EffectDistributeRepayment}},
+	{Action: ActionRepay, From: StateDefaulted, To: StateRepaid, Guards: []Guard{hasSchedule, fullyRepaid}, Effects: []Effect{EffectDistributeRepayment}},
+	{Action: ActionRepay, From: StateDefaulted, To: StateDefaulted, Guards: []Guard{hasSchedule}, Effects: []Effect{EffectDistributeRepayment}},
+	{Action: ActionDefault, From: StateDisbursed, To: StateDefaulted},
+}
+
+func fullyFunded(loan *Loan) error {
+	invested, err := loan.InvestedAmount()
+	if err != nil {
+		return err
+	}
+	if invested.Amount.Cmp(loan.PrincipalAmount.Amount) != 0 {
+		return fmt.Errorf("loan is funded %s of %s", invested, loan.PrincipalAmount)
+	}
+	return nil
+}
+
+// hasAgreement keeps investors from investing before there is an agreement letter to send them
+func hasAgreement(loan *Loan) error {
+	if loan.AgreementLetter == "" {
+		return ErrAgreementNotGenerated
+	}
+	return nil
+}
+
+func validRepaymentTerms(loan *Loan) error {
+	return loan.Terms.Validate()
+}
+
+func hasSchedule(loan *Loan) error {
+	if loan.Schedule == nil {
+		return ErrScheduleNotFound
+	}
+	return nil
+}
+
+func fullyRepaid(loan *Loan) error {
+	if !loan.IsFullyRepaid() {
+		return errors.New("installments are still outstanding")
+	}
+	return nil
+}
+
+// ErrInvalidTransition is matched by TransitionError through errors.Is
+var ErrInvalidTransition = errors.New("invalid state transition")
+
+// TransitionError is returned when an action is not allowed in the current state of a loan
+type TransitionError struct {
+	LoanID string
+	Action Action
+	State  LoanState
+	// Allowed lists the states the action may be taken from
+	Allowed []LoanState
+	// Reason is the failing guard, nil when the state itself does not allow the action
+	Reason error
+}
+
+func (e *TransitionError) Error() string {
+	verb := strings.ToLower(string(e.Action))
+	if e.Reason != nil {
+		return fmt.Sprintf("cannot %s loan %s: %v", verb, e.LoanID, e.Reason)
+	}
+	states := make([]string, len(e.Allowed))
+	for i, state := range e.Allowed {
+		states[i] = string(state)
+	}
+	return fmt.Sprintf("loan must be in %s state to %s, loan %s is %s", strings.Join(states, " or "), verb, e.LoanID, e.State)
+}
+
+// Is reports whether target is ErrInvalidTransition
+func (e *TransitionError) Is(target error) bool {
+	return target == ErrInvalidTransition
+}
+
+// Unwrap returns the failing guard so callers can match its error
+func (e *TransitionError) Unwrap() error {
+	return e.Reason
+}
+
+// NextTransition is an action allowed in the current state and the states it may lead to
+type NextTransition struct {
+	Action Action      `json:"action" example:"APPROVE"`
+	To     []LoanState `json:"to"`
+}
+
+// StateMachine applies the transition table to loans
+type StateMachine struct {
+	transitions []Transition
+}
+
+// NewStateMachine returns a state machine for the loan lifecycle
+func NewStateMachine() *StateMachine {
+	return &StateMachine{transitions: transitions}
+}
+
+// Can reports whether the action may be taken on the loan in its current state
+func (m *StateMachine) Can(loan *Loan, action Action) error {
+	_, err := m.find(loan, action)
+	return err
+}
+
+// Allows reports whether the action may be taken from the loan state without running the guards.
+// It suits callers that only hold a summary of the loan, the service still checks the guards.
+func (m *StateMachine) Allows(loan *Loan, action Action) error {
+	var allowed []LoanState
+	for _, t := range m.transitions {
+		if t.Action != action {
+			continue
+		}
+		if t.From == loan.State {
+			return nil
+		}
+		if !slices.Contains(allowed, t.From) {
+			allowed = append(allowed, t.From)
+		}
+	}
+	return &TransitionError{LoanID: loan.ID, Action: action, State: loan.State, Allowed: allowed}
+}
+
+// Resolve returns the first transition of the action whose guards pass without changing the loan,
+// for callers that record the new state as events
+func (m *StateMachine) Resolve(loan *Loan, action Action) (Transition, error) {
+	return m.find(loan, action)
+}
+
+// Next lists the actions allowed in the current state of the loan, in table order
+func (m *StateMachine) Next(loan *Loan) []NextTransition {
+	var next []NextTransition
+	index := make(map[Action]int)
+	for _, t := range m.transitions {
+		if t.From != loan.State {
+			continue
+		}
+		i, seen := index[t.Action]
+		if !seen {
+			if m.Can(loan, t.Action) != nil {
+				continue
+			}
+			i = len(next)
+			index[t.Action] = i
+			next = append(next, NextTransition{Action: t.Action})
+		}
+		next[i].To = append(next[i].To, t.To)
+	}
+	return next
+}
+
+// find returns the first transition of the action from the loan state whose guards all pass
+func (m *StateMachine) find(loan *Loan, action Action) (Transition, error) {
+	var (
+		allowed []LoanState
+		reason  error
+	)
+	for _, t := range m.transitions {
+		if t.Action != action {
+			continue
+		}
+		if t.From != loan.State {
+			if !slices.Contains(allowed, t.From) {
+				allowed = append(allowed, t.From)
+			}
+			continue
+		}
+
+		err := checkGuards(t, loan)
+		if err == nil {
+			return t, nil
+		}
+		if reason == nil {
+			reason = err
+		}
+	}
+	return Transition{}, &TransitionError{LoanID: loan.ID, Action: action, State: loan.State, Allowed: allowed, Reason: reason}
+}
+
+func checkGuards(t Transition, loan *Loan) error {
+	for _, guard := range t.Guards {
+		if err := guard(loan); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package loan
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hinha/los-technical/internal/pkg/money"
+)
+
+func TestStateMachine_Resolve(t *testing.T) {
+	machine := NewStateMachine()
+
+	tests := []struct {
+		name        string
+		loan        func(t *testing.T) *Loan
+		action      Action
+		wantState   LoanState
+		wantEffects []Effect
+		wantErr     error
+		errorMsg    string
+	}{
+		{
+			name:        "Approve Proposed Loan",
+			loan:        func(t *testing.T) *Loan { return &Loan{ID: "loan-1", State: StateProposed} },
+			action:      ActionApprove,
+			wantState:   StateApproved,
+			wantEffects: []Effect{EffectNotifyApproval},
+		},
+		{
+			name:     "Approve Approved Loan",
+			loan:     func(t *testing.T) *Loan { return &Loan{ID: "loan-1", State: StateApproved} },
+			action:   ActionApprove,
+			wantErr:  ErrInvalidTransition,
+			errorMsg: "loan must be in PROPOSED state to approve, loan loan-1 is APPROVED",
+		},
+		{
+			name:        "Cancel Approved Loan",
+			loan:        func(t *testing.T) *Loan { return &Loan{ID: "loan-1", State: StateApproved} },
+			action:      ActionCancel,
+			wantState:   StateCancelled,
+			wantEffects: []Effect{EffectNotifyRefunds},
+		},
+		{
+			name: "Partial Investment Keeps Loan Approved",
+			loan: func(t *testing.T) *Loan {
+				return &Loan{
+					ID:              "loan-1",
+					State:           StateApproved,
+					PrincipalAmount: money.MustNew("1000", "IDR"),
+					AgreementLetter: "http://example.com/documents/agreement-1",
+					Investors:       []Investor{{ID: "investor-1", Amount: money.MustNew("400", "IDR")}},
+				}
+			},
+			action:      ActionInvest,
+			wantState:   StateApproved,
+			wantEffects: []Effect{EffectNotifyInvestment},
+		},
+		{
+			name: "Full Investment Moves Loan To Invested",
+			loan: func(t *testing.T) *Loan {
+				return &Loan{
+					ID:              "loan-1",
+					State:           StateApproved,
+					PrincipalAmount: money.MustNew("1000", "IDR"),
+					AgreementLetter: "http://example.com/documents/agreement-1",
+					Investors: []Investor{
+						{ID: "investor-1", Amount: money.MustNew("400", "IDR")},
+						{ID: "investor-2", Amount: money.MustNew("600", "IDR")},
+					},
+				}
+			},
+			action:      ActionInvest,
+			wantState:   StateInvested,
+			wantEffects: []Effect{EffectNotifyInvestment, EffectNotifyFunded, EffectSendAgreements},
+		},
+		{
+			name: "Invest Before Agreement Letter",
+			loan: func(t *testing.T) *Loan {
+				return &Loan{ID: "loan-1", State: StateApproved, PrincipalAmount: money.MustNew("1000", "IDR")}
+			},
+			action:   ActionInvest,
+			wantErr:  ErrAgreementNotGenerated,
+			errorMsg: "cannot invest loan loan-1: agreement letter not generated",
+		},
+		{
+			name: "Disburse Without Repayment Terms",
+			loan: func(t *testing.T) *Loan {
+				return &Loan{ID: "loan-1", State: StateInvested}
+			},
+			action:   ActionDisburse,
+			wantErr:  ErrInvalidRepaymentTerms,
+			errorMsg: "cannot disburse loan loan-1",
+		},
+		{
+			name:        "Partial Repayment Keeps Loan Disbursed",
+			loan:        newDisbursedLoan,
+			action:      ActionRepay,
+			wantState:   StateDisbursed,
+			wantEffects: []Effect{EffectDistributeRepayment},
+		},
+		{
+			name: "Full Repayment Moves Loan To Repaid",
+			loan: func(t *testing.T) *Loan {
+				loan := newDisbursedLoan(t)
+				loan.State = StateDefaulted
+				if _, err := loan.ApplyRepayment("repayment-1", money.MustNew("1212", "USD"), disbursedAt); err != nil {
+					t.Fatalf("failed to repay loan: %v", err)
+				}
+				return loan
+			},
+			action:      ActionRepay,
+			wantState:   StateRepaid,
+			wantEffects: []Effect{EffectDistributeRepayment},
+		},
+		{
+			name: "Repay Without Schedule",
+			loan: func(t *testing.T) *Loan {
+				return &Loan{ID: "loan-1", State: StateDisbursed}
+			},
+			action:  ActionRepay,
+			wantErr: ErrScheduleNotFound,
+		},
+		{
+			name:     "Repay Proposed Loan",
+			loan:     func(t *testing.T) *Loan { return &Loan{ID: "loan-1", State: StateProposed} },
+			action:   ActionRepay,
+			wantErr:  ErrInvalidTransition,
+			errorMsg: "loan must be in DISBURSED or DEFAULTED state to repay",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loan := tt.loan(t)
+			from := loan.State
+
+			transition, err := machine.Resolve(loan, tt.action)
+			assert.Equal(t, from, loan.State, "resolving a transition must not change the state")
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v, want %v", err, tt.wantErr)
+				assert.True(t, errors.Is(err, ErrInvalidTransition))
+				assert.Contains(t, err.Error(), tt.errorMsg)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantState, transition.To)
+			assert.Equal(t, tt.wantEffects, transition.Effects)
+		})
+	}
+}
+
+func TestStateMachine_Allows(t *testing.T) {
+	machine := NewStateMachine()
+
+	// Allows only looks at the state, so an invested loan without terms may still be disbursed
+	assert.NoError(t, machine.Allows(&Loan{ID: "loan-1", State: StateInvested}, ActionDisburse))
+	assert.ErrorIs(t, machine.Allows(&Loan{ID: "loan-1", State: StateRepaid}, ActionRepay), ErrInvalidTransition)
+}
+
+func TestStateMachine_Next(t *testing.T) {
+	machine := NewStateMachine()
+
+	tests := []struct {
+		name string
+		loan func(t *testing.T) *Loan
+		want []NextTransition
+	}{
+		{
+			name: "Proposed",
+			loan: func(t *testing.T) *Loan { return &Loan{State: StateProposed} },
+			want: []NextTransition{
+				{Action: ActionApprove, To: []LoanState{StateApproved}},
+				{Action: ActionReject, To: []LoanState{StateRejected}},
+				{Action: ActionCancel, To: []LoanState{StateCancelled}},
+			},
+		},
+		{
+			name: "Disbursed",
+			loan: newDisbursedLoan,
+			want: []NextTransition{
+				{Action: ActionRepay, To: []LoanState{StateRepaid, StateDisbursed}},
+				{Action: ActionDefault, To: []LoanState{StateDefaulted}},
+			},
+		},
+		{
+			name: "Invested Without Repayment Terms",
+			loan: func(t *testing.T) *Loan { return &Loan{State: StateInvested} },
+		},
+		{
+			name: "Repaid",
+			loan: func(t *testing.T) *Loan { return &Loan{State: StateRepaid} },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, machine.Next(tt.loan(t)))
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package agreement
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/sirupsen/logrus"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	"github.com/hinha/los-technical/internal/pkg/money"
+	"github.com/hinha/los-technical/internal/pkg/pdf"
+)
+
+// Markup of the agreement template, every other non-empty line is a paragraph
+const (
+	titlePrefix   = "# "
+	headingPrefix = "## "
+)
+
+// Letter is the data the agreement template is executed with
+type Letter struct {
+	Loan *domain.Loan
+	// Investors are the investors of the loan with their share of the principal
+	Investors []InvestorShare
+	// Invested is the total of the investments so far
+	Invested money.Money
+}
+
+// InvestorShare is an investor of the loan and the percentage of the principal they funded
+type InvestorShare struct {
+	domain.Investor
+	Share money.Decimal
+}
+
+// Renderer renders agreement letters into PDF documents from a text template. In the template a line
+// starting with "# " is the title, one starting with "## " a section heading, an empty line separates
+// paragraphs and any other line is a paragraph wrapped to the page width.
+type Renderer struct {
+	template *texttemplate.Template
+	logger   *logrus.Logger
+}
+
+// LoadRenderer parses the agreement template at path
+func LoadRenderer(path string, logger *logrus.Logger) (*Renderer, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read agreement template: %w", err)
+	}
+	tmpl, err := texttemplate.New(filepath.Base(path)).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse agreement template %s: %w", filepath.Base(path), err)
+	}
+
+	logger.WithFields(logrus.Fields{
+		"layer":    "agreement",
+		"function": "LoadRenderer",
+		"path":     path,
+	}).Info("Agreement template loaded")
+	return &Renderer{template: tmpl, logger: logger}, nil
+}
+
+// RenderAgreement renders the agreement letter of the loan as a PDF document
+func (r *Renderer) RenderAgreement(loan *domain.Loan) ([]byte, error) {
+	letter, err := NewLetter(loan)
+	if err != nil {
+		return nil, err
+	}
+
+	var text bytes.Buffer
+	if err := r.template.Execute(&text, letter); err != nil {
+		r.logger.WithFields(logrus.Fields{
+			"layer":    "agreement",
+			"function": "RenderAgreement",
+			"loan_id":  loan.ID,
+			"error":    err.Error(),
+		}).Error("Failed to execute agreement template")
+		return nil, fmt.Errorf("failed to execute agreement template: %w", err)
+	}
+
+	document := pdf.New("Loan agreement " + loan.ID)
+	Layout(document, text.String())
+	content := document.Bytes()
+
+	r.logger.WithFields(logrus.Fields{
+		"layer":    "agreement",
+		"function": "RenderAgreement",
+		"loan_id":  loan.ID,
+		"pages":    document.PageCount(),
+		"size":     len(content),
+	}).Info("Agreement letter rendered")
+	return content, nil
+}
+
+// NewLetter collects the data of the agreement letter of the loan
+func NewLetter(loan *domain.Loan) (*Letter, error) {
+	invested, err := loan.InvestedAmount()
+	if err != nil {
+		return nil, fmt.Errorf("failed to total investments of loan %s: %w", loan.ID, err)
+	}
+	letter := &Letter{Loan: loan, Invested: invested}
+	for _, investor := range loan.Investors {
+		share := money.Zero
+		if !loan.PrincipalAmount.Amount.IsZero() {
+			ratio := new(big.Rat).Quo(investor.Amount.Amount.Rat(), loan.PrincipalAmount.Amount.Rat())
+			if share, err = money.NewFromRat(ratio.Mul(ratio, big.NewRat(100, 1)), 2); err != nil {
+				return nil, fmt.Errorf("failed to share principal of loan %s: %w", loan.ID, err)
+			}
+		}
+		letter.Investors = append(letter.Investors, InvestorShare{Investor: investor, Share: share})
+	}
+	return letter, nil
+}
+
+// Layout adds the rendered template text to the document block by block
+func Layout(document *pdf.Document, text string) {
+	var paragraph []string
+	flush := func() {
+		if len(paragraph) > 0 {
+			document.Paragraph(strings.Join(paragraph, "\n"))
+			paragraph = nil
+		}
+	}
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		switch {
+		case strings.HasPrefix(line, titlePrefix):
+			flush()
+			document.Title(strings.TrimPrefix(line, titlePrefix))
+			document.Space()
+		case strings.HasPrefix(line, headingPrefix):
+			flush()
+			document.Space()
+			document.Heading(strings.TrimPrefix(line, headingPrefix))
+		case line == "":
+			flush()
+			document.Space()
+		default:
+			paragraph = append(paragraph, line)
+		}
+	}
+	flush()
+}
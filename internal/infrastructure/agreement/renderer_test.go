@@ -0,0 +1,106 @@
+package agreement
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	"github.com/hinha/los-technical/internal/pkg/money"
+	"github.com/hinha/los-technical/internal/pkg/pdf"
+)
+
+const templatePath = "../../../templates/agreement/agreement.txt.tmpl"
+
+func testLoan() *domain.Loan {
+	return &domain.Loan{
+		ID:              "loan-123",
+		BorrowerID:      "borrower-123",
+		PrincipalAmount: money.MustNew("1200000", money.DefaultCurrency),
+		Rate:            money.MustParse("12"),
+		ROI:             money.MustParse("10"),
+		Terms:           domain.RepaymentTerms{TenorMonths: 6, Scheme: domain.SchemeAnnuity},
+		State:           domain.StateInvested,
+		Investors: []domain.Investor{
+			{ID: "investor-1", Name: "Budi Santoso", Amount: money.MustNew("800000", money.DefaultCurrency)},
+			{ID: "investor-2", Amount: money.MustNew("400000", money.DefaultCurrency)},
+		},
+	}
+}
+
+func TestRenderer_RenderAgreement(t *testing.T) {
+	renderer, err := LoadRenderer(templatePath, logrus.New())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	content, err := renderer.RenderAgreement(testLoan())
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(content, []byte("%PDF-1.4")))
+	for _, text := range []string{
+		"(Loan Agreement) Tj",
+		"(Borrower: borrower-123) Tj",
+		"(Principal amount: 1200000 IDR) Tj",
+		"(Interest rate: 12% a year) Tj",
+		"(Return on investment: 10% for the investors) Tj",
+		"(investor-1 \\(Budi Santoso\\): 800000 IDR, 66.67% of the principal) Tj",
+		"(investor-2: 400000 IDR, 33.33% of the principal) Tj",
+		"(Repayment scheme: ANNUITY) Tj",
+	} {
+		assert.Contains(t, string(content), text)
+	}
+
+	again, err := renderer.RenderAgreement(testLoan())
+	assert.NoError(t, err)
+	assert.Equal(t, content, again, "the same loan renders the same letter")
+}
+
+func TestRenderer_NoInvestors(t *testing.T) {
+	renderer, err := LoadRenderer(templatePath, logrus.New())
+	if !assert.NoError(t, err) {
+		return
+	}
+	loan := testLoan()
+	loan.Investors = nil
+
+	content, err := renderer.RenderAgreement(loan)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "(No investment has been made yet.) Tj")
+	assert.Contains(t, string(content), "(Funded so far: 0 IDR of 1200000 IDR) Tj")
+}
+
+func TestLoadRenderer_Errors(t *testing.T) {
+	dir := t.TempDir()
+	broken := filepath.Join(dir, "broken.txt.tmpl")
+	assert.NoError(t, os.WriteFile(broken, []byte("{{.Loan.ID"), 0o600))
+	missingKey := filepath.Join(dir, "missing.txt.tmpl")
+	assert.NoError(t, os.WriteFile(missingKey, []byte("{{.Borrower}}"), 0o600))
+
+	_, err := LoadRenderer(filepath.Join(dir, "missing-file.txt.tmpl"), logrus.New())
+	assert.ErrorContains(t, err, "failed to read agreement template")
+
+	_, err = LoadRenderer(broken, logrus.New())
+	assert.ErrorContains(t, err, "failed to parse agreement template")
+
+	renderer, err := LoadRenderer(missingKey, logrus.New())
+	if assert.NoError(t, err) {
+		_, err = renderer.RenderAgreement(testLoan())
+		assert.ErrorContains(t, err, "failed to execute agreement template")
+	}
+}
+
+func TestLayout(t *testing.T) {
+	document := pdf.New("Layout")
+	Layout(document, "# Title\n\nfirst line\nsecond line\n## Section\nbody\n")
+	out := string(document.Bytes())
+
+	assert.Contains(t, out, "/F2 16 Tf 56 764 Td (Title) Tj")
+	assert.Contains(t, out, "/F1 10 Tf 56 734 Td (first line) Tj")
+	assert.Contains(t, out, "/F1 10 Tf 56 720 Td (second line) Tj")
+	assert.Contains(t, out, "(Section) Tj")
+	assert.NotContains(t, out, "(# Title)")
+}
@@ -0,0 +1,131 @@
+package agreement
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	"github.com/hinha/los-technical/internal/pkg/pdf"
+)
+
+// SignatureRole is the party of the loan a signature is made for
+type SignatureRole string
+
+// SignatureBorrower is the signature of the borrower of the loan
+const SignatureBorrower SignatureRole = "BORROWER"
+
+// Signature is a signature added to an agreement letter. It is kept in the PDF as a signature dictionary
+// appended to the letter, e.g. << /Type /Sig /Role (BORROWER) /SignerID (b-1) /Name (Budi) /Digest (...) >>.
+type Signature struct {
+	Role SignatureRole
+	// SignerID identifies who signed, the borrower ID for the borrower
+	SignerID string
+	Name     string
+	// Digest is the hex encoded SHA-256 digest of the agreement letter that was signed
+	Digest string
+}
+
+var (
+	signaturePattern = regexp.MustCompile(`<<\s*/Type\s*/Sig\b((?:\((?:\\.|[^\\)])*\)|[^>(])*)>>`)
+	fieldPattern     = regexp.MustCompile(`/(Role|SignerID|Name|Digest)\s*\(((?:\\.|[^\\)])*)\)`)
+	unescaper        = strings.NewReplacer(`\\`, `\`, `\(`, `(`, `\)`, `)`)
+)
+
+// Sign adds the signature to the agreement letter, leaving the bytes of the letter untouched
+func Sign(letter []byte, signature Signature) ([]byte, error) {
+	return pdf.Append(letter, fmt.Sprintf("<< /Type /Sig /Role %s /SignerID %s /Name %s /Digest %s >>",
+		pdf.Literal(string(signature.Role)), pdf.Literal(signature.SignerID), pdf.Literal(signature.Name), pdf.Literal(signature.Digest)))
+}
+
+// ReadSignatures returns the signatures in a signed agreement, in the order they were added
+func ReadSignatures(signed []byte) []Signature {
+	var signatures []Signature
+	for _, match := range signaturePattern.FindAllSubmatch(signed, -1) {
+		var signature Signature
+		for _, field := range fieldPattern.FindAllSubmatch(match[1], -1) {
+			value := unescaper.Replace(string(field[2]))
+			switch string(field[1]) {
+			case "Role":
+				signature.Role = SignatureRole(value)
+			case "SignerID":
+				signature.SignerID = value
+			case "Name":
+				signature.Name = value
+			case "Digest":
+				signature.Digest = value
+			}
+		}
+		signatures = append(signatures, signature)
+	}
+	return signatures
+}
+
+// Verifier checks that a signed agreement is the agreement letter generated for the loan with the required
+// signatures added. The signatures are not checked cryptographically; a signature is accepted when it is made
+// for the digest of the generated letter by the party its role names.
+type Verifier struct {
+	// required are the roles that must have signed
+	required []SignatureRole
+	logger   *logrus.Logger
+}
+
+// NewVerifier creates a verifier that requires a signature for every role in required, or of the borrower
+// when none are given
+func NewVerifier(logger *logrus.Logger, required ...SignatureRole) *Verifier {
+	if len(required) == 0 {
+		required = []SignatureRole{SignatureBorrower}
+	}
+	return &Verifier{required: required, logger: logger}
+}
+
+// VerifySignedAgreement checks the signed agreement against the agreement letter it was signed from
+func (v *Verifier) VerifySignedAgreement(loan *domain.Loan, agreement, signed []byte) error {
+	var problems []string
+
+	digest := domain.Checksum(agreement)
+	if len(signed) < len(agreement) || !bytes.Equal(signed[:len(agreement)], agreement) {
+		problems = append(problems, "its content differs from the generated agreement letter")
+	}
+
+	signatures := ReadSignatures(signed)
+	for _, role := range v.required {
+		found := false
+		for _, signature := range signatures {
+			if signature.Role != role {
+				continue
+			}
+			found = true
+			if signature.Digest != digest {
+				problems = append(problems, fmt.Sprintf("the %s signature was made on a different document", role))
+			}
+			if role == SignatureBorrower && signature.SignerID != loan.BorrowerID {
+				problems = append(problems, fmt.Sprintf("the %s signature is by %q, not the borrower %q", role, signature.SignerID, loan.BorrowerID))
+			}
+		}
+		if !found {
+			problems = append(problems, fmt.Sprintf("the %s signature is missing", role))
+		}
+	}
+
+	if len(problems) > 0 {
+		v.logger.WithFields(logrus.Fields{
+			"layer":    "agreement",
+			"function": "VerifySignedAgreement",
+			"loan_id":  loan.ID,
+			"problems": problems,
+		}).Warn("Signed agreement rejected")
+		return &domain.AgreementVerificationError{LoanID: loan.ID, Problems: problems}
+	}
+
+	v.logger.WithFields(logrus.Fields{
+		"layer":      "agreement",
+		"function":   "VerifySignedAgreement",
+		"loan_id":    loan.ID,
+		"signatures": len(signatures),
+	}).Info("Signed agreement verified")
+	return nil
+}
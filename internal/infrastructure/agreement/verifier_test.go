@@ -0,0 +1,113 @@
+package agreement
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+)
+
+func TestReadSignatures(t *testing.T) {
+	letter := testLetter(t)
+	signed, err := Sign(letter, Signature{Role: SignatureBorrower, SignerID: "borrower-123", Name: "Budi (Santoso)", Digest: "abc"})
+	assert.NoError(t, err)
+	signed, err = Sign(signed, Signature{Role: "WITNESS", SignerID: "officer-1", Name: "A > B"})
+	assert.NoError(t, err)
+
+	assert.Empty(t, ReadSignatures(letter))
+	assert.Equal(t, []Signature{
+		{Role: SignatureBorrower, SignerID: "borrower-123", Name: "Budi (Santoso)", Digest: "abc"},
+		{Role: "WITNESS", SignerID: "officer-1", Name: "A > B"},
+	}, ReadSignatures(signed))
+}
+
+func TestVerifier_VerifySignedAgreement(t *testing.T) {
+	letter := testLetter(t)
+	digest := domain.Checksum(letter)
+	sign := func(t *testing.T, letter []byte, signatures ...Signature) []byte {
+		for _, signature := range signatures {
+			var err error
+			letter, err = Sign(letter, signature)
+			assert.NoError(t, err)
+		}
+		return letter
+	}
+	borrower := Signature{Role: SignatureBorrower, SignerID: "borrower-123", Name: "Budi", Digest: digest}
+
+	tests := []struct {
+		name     string
+		signed   func(t *testing.T) []byte
+		required []SignatureRole
+		problems []string
+	}{
+		{
+			name:   "Signed by the borrower",
+			signed: func(t *testing.T) []byte { return sign(t, letter, borrower) },
+		},
+		{
+			name:     "Missing signature",
+			signed:   func(t *testing.T) []byte { return letter },
+			problems: []string{"the BORROWER signature is missing"},
+		},
+		{
+			name: "Signed by someone else",
+			signed: func(t *testing.T) []byte {
+				return sign(t, letter, Signature{Role: SignatureBorrower, SignerID: "borrower-999", Digest: digest})
+			},
+			problems: []string{`the BORROWER signature is by "borrower-999", not the borrower "borrower-123"`},
+		},
+		{
+			name: "Signature of another letter",
+			signed: func(t *testing.T) []byte {
+				return sign(t, letter, Signature{Role: SignatureBorrower, SignerID: "borrower-123", Digest: domain.Checksum([]byte("older letter"))})
+			},
+			problems: []string{"the BORROWER signature was made on a different document"},
+		},
+		{
+			name: "Letter altered before signing",
+			signed: func(t *testing.T) []byte {
+				altered := append([]byte(nil), letter...)
+				altered[len(altered)/2] ^= 1
+				return sign(t, altered, borrower)
+			},
+			problems: []string{"its content differs from the generated agreement letter"},
+		},
+		{
+			name:     "Every required role signs",
+			signed:   func(t *testing.T) []byte { return sign(t, letter, borrower) },
+			required: []SignatureRole{SignatureBorrower, "WITNESS"},
+			problems: []string{"the WITNESS signature is missing"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := NewVerifier(logrus.New(), tt.required...)
+			err := verifier.VerifySignedAgreement(testLoan(), letter, tt.signed(t))
+			if tt.problems == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, domain.ErrAgreementNotVerified)
+			var verification *domain.AgreementVerificationError
+			if assert.ErrorAs(t, err, &verification) {
+				assert.Equal(t, "loan-123", verification.LoanID)
+				assert.Equal(t, tt.problems, verification.Problems)
+			}
+		})
+	}
+}
+
+// testLetter renders the agreement letter of the test loan
+func testLetter(t *testing.T) []byte {
+	renderer, err := LoadRenderer(templatePath, logrus.New())
+	if err != nil {
+		t.Fatalf("failed to load renderer: %v", err)
+	}
+	letter, err := renderer.RenderAgreement(testLoan())
+	if err != nil {
+		t.Fatalf("failed to render letter: %v", err)
+	}
+	return letter
+}
@@ -0,0 +1,40 @@
+CREATE TABLE loans (
+    id               TEXT PRIMARY KEY,
+    borrower_id      TEXT NOT NULL,
+    principal_amount REAL NOT NULL,
+    rate             REAL NOT NULL,
+    roi              REAL NOT NULL,
+    agreement_letter TEXT NOT NULL DEFAULT '',
+    state            TEXT NOT NULL,
+    created_at       TEXT NOT NULL,
+    updated_at       TEXT NOT NULL
+);
+
+CREATE INDEX idx_loans_borrower_id ON loans (borrower_id);
+CREATE INDEX idx_loans_state ON loans (state);
+CREATE INDEX idx_loans_created_at ON loans (created_at, id);
+
+CREATE TABLE loan_approvals (
+    loan_id      TEXT PRIMARY KEY REFERENCES loans (id) ON DELETE CASCADE,
+    validator_id TEXT NOT NULL,
+    proof_url    TEXT NOT NULL,
+    approved_at  TEXT NOT NULL
+);
+
+CREATE TABLE loan_investors (
+    loan_id     TEXT    NOT NULL REFERENCES loans (id) ON DELETE CASCADE,
+    position    INTEGER NOT NULL,
+    investor_id TEXT    NOT NULL,
+    amount      REAL    NOT NULL,
+    email       TEXT    NOT NULL,
+    PRIMARY KEY (loan_id, position)
+);
+
+CREATE INDEX idx_loan_investors_investor_id ON loan_investors (investor_id);
+
+CREATE TABLE loan_disbursements (
+    loan_id          TEXT PRIMARY KEY REFERENCES loans (id) ON DELETE CASCADE,
+    signed_agreement TEXT NOT NULL,
+    field_officer_id TEXT NOT NULL,
+    disbursed_at     TEXT NOT NULL
+);
@@ -0,0 +1 @@
+ALTER TABLE loans ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
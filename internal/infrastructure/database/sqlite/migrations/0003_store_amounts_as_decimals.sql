@@ -0,0 +1,22 @@
+-- Amounts were stored as REAL, which cannot hold most decimal fractions exactly.
+-- Rewrite them as decimal strings and record the currency of every amount.
+ALTER TABLE loans RENAME COLUMN principal_amount TO principal_amount_real;
+ALTER TABLE loans RENAME COLUMN rate TO rate_real;
+ALTER TABLE loans RENAME COLUMN roi TO roi_real;
+ALTER TABLE loans ADD COLUMN principal_amount TEXT NOT NULL DEFAULT '0';
+ALTER TABLE loans ADD COLUMN currency TEXT NOT NULL DEFAULT 'IDR';
+ALTER TABLE loans ADD COLUMN rate TEXT NOT NULL DEFAULT '0';
+ALTER TABLE loans ADD COLUMN roi TEXT NOT NULL DEFAULT '0';
+UPDATE loans SET
+    principal_amount = printf('%.4f', principal_amount_real),
+    rate = printf('%.4f', rate_real),
+    roi = printf('%.4f', roi_real);
+ALTER TABLE loans DROP COLUMN principal_amount_real;
+ALTER TABLE loans DROP COLUMN rate_real;
+ALTER TABLE loans DROP COLUMN roi_real;
+
+ALTER TABLE loan_investors RENAME COLUMN amount TO amount_real;
+ALTER TABLE loan_investors ADD COLUMN amount TEXT NOT NULL DEFAULT '0';
+ALTER TABLE loan_investors ADD COLUMN currency TEXT NOT NULL DEFAULT 'IDR';
+UPDATE loan_investors SET amount = printf('%.4f', amount_real);
+ALTER TABLE loan_investors DROP COLUMN amount_real;
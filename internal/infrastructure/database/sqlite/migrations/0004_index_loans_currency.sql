@@ -0,0 +1 @@
+CREATE INDEX idx_loans_currency ON loans (currency, created_at, id);
@@ -0,0 +1,15 @@
+-- Loans created before repayment terms existed get the API defaults
+ALTER TABLE loans ADD COLUMN tenor_months INTEGER NOT NULL DEFAULT 12;
+ALTER TABLE loans ADD COLUMN repayment_scheme TEXT NOT NULL DEFAULT 'ANNUITY';
+
+CREATE TABLE loan_installments (
+    loan_id   TEXT    NOT NULL REFERENCES loans (id) ON DELETE CASCADE,
+    number    INTEGER NOT NULL,
+    due_date  TEXT    NOT NULL,
+    principal TEXT    NOT NULL,
+    interest  TEXT    NOT NULL,
+    total     TEXT    NOT NULL,
+    balance   TEXT    NOT NULL,
+    currency  TEXT    NOT NULL,
+    PRIMARY KEY (loan_id, number)
+);
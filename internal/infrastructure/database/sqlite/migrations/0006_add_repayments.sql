@@ -0,0 +1,18 @@
+ALTER TABLE loan_installments ADD COLUMN fee TEXT NOT NULL DEFAULT '0';
+ALTER TABLE loan_installments ADD COLUMN fee_paid TEXT NOT NULL DEFAULT '0';
+ALTER TABLE loan_installments ADD COLUMN interest_paid TEXT NOT NULL DEFAULT '0';
+ALTER TABLE loan_installments ADD COLUMN principal_paid TEXT NOT NULL DEFAULT '0';
+ALTER TABLE loan_installments ADD COLUMN paid_at TEXT;
+
+CREATE TABLE loan_repayments (
+    loan_id   TEXT    NOT NULL REFERENCES loans (id) ON DELETE CASCADE,
+    position  INTEGER NOT NULL,
+    id        TEXT    NOT NULL,
+    amount    TEXT    NOT NULL,
+    fee       TEXT    NOT NULL,
+    interest  TEXT    NOT NULL,
+    principal TEXT    NOT NULL,
+    currency  TEXT    NOT NULL,
+    paid_at   TEXT    NOT NULL,
+    PRIMARY KEY (loan_id, position)
+);
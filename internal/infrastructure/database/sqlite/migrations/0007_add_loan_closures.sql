@@ -0,0 +1,18 @@
+-- kind is REJECTED or CANCELLED, a loan is closed at most once
+CREATE TABLE loan_closures (
+    loan_id     TEXT PRIMARY KEY REFERENCES loans (id) ON DELETE CASCADE,
+    kind        TEXT NOT NULL,
+    actor_id    TEXT NOT NULL,
+    reason_code TEXT NOT NULL,
+    closed_at   TEXT NOT NULL
+);
+
+CREATE TABLE loan_refunds (
+    loan_id     TEXT    NOT NULL REFERENCES loans (id) ON DELETE CASCADE,
+    position    INTEGER NOT NULL,
+    investor_id TEXT    NOT NULL,
+    amount      TEXT    NOT NULL,
+    currency    TEXT    NOT NULL,
+    email       TEXT    NOT NULL,
+    PRIMARY KEY (loan_id, position)
+);
@@ -0,0 +1,24 @@
+-- Append-only history of loan mutations, seq keeps the order entries were written in
+CREATE TABLE loan_audit_log (
+    seq            INTEGER PRIMARY KEY AUTOINCREMENT,
+    id             TEXT    NOT NULL UNIQUE,
+    loan_id        TEXT    NOT NULL,
+    actor          TEXT    NOT NULL,
+    action         TEXT    NOT NULL,
+    from_state     TEXT    NOT NULL,
+    to_state       TEXT    NOT NULL,
+    payload_digest TEXT    NOT NULL,
+    recorded_at    TEXT    NOT NULL
+);
+
+CREATE INDEX idx_loan_audit_log_loan_id ON loan_audit_log (loan_id, seq);
+
+CREATE TRIGGER loan_audit_log_no_update BEFORE UPDATE ON loan_audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'loan_audit_log is append-only');
+END;
+
+CREATE TRIGGER loan_audit_log_no_delete BEFORE DELETE ON loan_audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'loan_audit_log is append-only');
+END;
@@ -0,0 +1,2 @@
+-- agreement_document_id references the generated letter in the document store
+ALTER TABLE loans ADD COLUMN agreement_document_id TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+-- The proof and signed agreement are uploaded documents, the URLs are kept for the loans approved and disbursed before
+ALTER TABLE loan_approvals ADD COLUMN proof_document_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE loan_disbursements ADD COLUMN signed_agreement_document_id TEXT NOT NULL DEFAULT '';
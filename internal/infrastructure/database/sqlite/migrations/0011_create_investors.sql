@@ -0,0 +1,16 @@
+-- Registered investors. A limit is NULL when it is not enforced.
+CREATE TABLE investors (
+    id                      TEXT PRIMARY KEY,
+    name                    TEXT NOT NULL,
+    email                   TEXT NOT NULL,
+    phone                   TEXT NOT NULL DEFAULT '',
+    locale                  TEXT NOT NULL DEFAULT '',
+    kyc_status              TEXT NOT NULL,
+    kyc_reviewed_at         TEXT,
+    per_loan_limit          TEXT,
+    per_loan_limit_currency TEXT,
+    total_limit             TEXT,
+    total_limit_currency    TEXT,
+    created_at              TEXT NOT NULL,
+    updated_at              TEXT NOT NULL
+);
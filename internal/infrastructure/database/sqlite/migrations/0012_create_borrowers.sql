@@ -0,0 +1,18 @@
+-- Registered borrowers. Income and obligations share the currency column.
+CREATE TABLE borrowers (
+    id                  TEXT PRIMARY KEY,
+    name                TEXT NOT NULL,
+    national_id         TEXT NOT NULL,
+    email               TEXT NOT NULL,
+    phone               TEXT NOT NULL DEFAULT '',
+    address             TEXT NOT NULL DEFAULT '',
+    monthly_income      TEXT NOT NULL,
+    monthly_obligations TEXT NOT NULL,
+    currency            TEXT NOT NULL,
+    blacklisted         INTEGER NOT NULL DEFAULT 0,
+    blacklist_reason    TEXT NOT NULL DEFAULT '',
+    created_at          TEXT NOT NULL,
+    updated_at          TEXT NOT NULL
+);
+
+CREATE INDEX idx_borrowers_created_at ON borrowers (created_at, id);
@@ -0,0 +1,18 @@
+-- A loan is scored once, reasons keep the order the scorecard gave them
+CREATE TABLE loan_credit_scores (
+    loan_id   TEXT PRIMARY KEY REFERENCES loans (id) ON DELETE CASCADE,
+    score     INTEGER NOT NULL,
+    grade     TEXT    NOT NULL,
+    scorecard TEXT    NOT NULL,
+    scored_at TEXT    NOT NULL
+);
+
+CREATE TABLE loan_score_reasons (
+    loan_id  TEXT    NOT NULL REFERENCES loans (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    code     TEXT    NOT NULL,
+    PRIMARY KEY (loan_id, position)
+);
+
+-- Approvals of loans graded below the minimum grade are justified
+ALTER TABLE loan_approvals ADD COLUMN override_justification TEXT NOT NULL DEFAULT '';
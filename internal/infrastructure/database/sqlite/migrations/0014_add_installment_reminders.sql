@@ -0,0 +1,2 @@
+-- Installments are reminded once, so the time of the reminder is kept with them
+ALTER TABLE loan_installments ADD COLUMN reminded_at TEXT;
@@ -0,0 +1,3 @@
+-- Emails to investors are addressed by name in their language, investments made before keep the defaults
+ALTER TABLE loan_investors ADD COLUMN name TEXT NOT NULL DEFAULT '';
+ALTER TABLE loan_investors ADD COLUMN locale TEXT NOT NULL DEFAULT '';
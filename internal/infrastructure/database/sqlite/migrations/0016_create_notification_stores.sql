@@ -0,0 +1,37 @@
+-- Notification preferences of borrowers and investors, channels and muted kinds keep the order they were set in
+CREATE TABLE notification_preferences (
+    recipient_id TEXT PRIMARY KEY,
+    email        TEXT NOT NULL DEFAULT '',
+    phone        TEXT NOT NULL DEFAULT '',
+    locale       TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE notification_preference_channels (
+    recipient_id TEXT    NOT NULL REFERENCES notification_preferences (recipient_id) ON DELETE CASCADE,
+    position     INTEGER NOT NULL,
+    channel      TEXT    NOT NULL,
+    PRIMARY KEY (recipient_id, position)
+);
+
+CREATE TABLE notification_preference_muted (
+    recipient_id TEXT    NOT NULL REFERENCES notification_preferences (recipient_id) ON DELETE CASCADE,
+    position     INTEGER NOT NULL,
+    kind         TEXT    NOT NULL,
+    PRIMARY KEY (recipient_id, position)
+);
+
+-- Notifications delivered in-app, seq keeps the order items were added in for items created at the same time
+CREATE TABLE inbox_items (
+    seq          INTEGER PRIMARY KEY AUTOINCREMENT,
+    id           TEXT NOT NULL,
+    recipient_id TEXT NOT NULL,
+    kind         TEXT NOT NULL,
+    loan_id      TEXT NOT NULL,
+    subject      TEXT NOT NULL,
+    body         TEXT NOT NULL,
+    created_at   TEXT NOT NULL,
+    read_at      TEXT,
+    UNIQUE (recipient_id, id)
+);
+
+CREATE INDEX idx_inbox_items_recipient ON inbox_items (recipient_id, created_at, seq);
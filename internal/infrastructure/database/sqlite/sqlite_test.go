@@ -0,0 +1,98 @@
+package sqlite
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrations(t *testing.T) {
+	migrations, err := Migrations()
+	assert.NoError(t, err)
+	if assert.NotEmpty(t, migrations) {
+		assert.Equal(t, 1, migrations[0].Version)
+		assert.Equal(t, "create_loans", migrations[0].Name)
+	}
+
+	// Versions must be strictly increasing so they apply in a stable order
+	for i := 1; i < len(migrations); i++ {
+		assert.Less(t, migrations[i-1].Version, migrations[i].Version)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	// Setup test logger
+	logger := logrus.New()
+	logger.SetOutput(logrus.StandardLogger().Out)
+
+	db, err := Open(filepath.Join(t.TempDir(), "los.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Running migrations twice must be a no-op the second time
+	assert.NoError(t, Migrate(db, logger))
+	assert.NoError(t, Migrate(db, logger))
+
+	migrations, err := Migrations()
+	assert.NoError(t, err)
+
+	var applied int
+	assert.NoError(t, db.QueryRow(`SELECT COUNT(1) FROM schema_migrations`).Scan(&applied))
+	assert.Equal(t, len(migrations), applied)
+
+	for _, table := range []string{"loans", "loan_approvals", "loan_investors", "loan_disbursements"} {
+		var name string
+		err := db.QueryRow(`SELECT name FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&name)
+		assert.NoError(t, err, "table %s should exist", table)
+	}
+}
+
+func TestMigrate_StoreAmountsAsDecimals(t *testing.T) {
+	// Setup test logger
+	logger := logrus.New()
+	logger.SetOutput(logrus.StandardLogger().Out)
+
+	db, err := Open(filepath.Join(t.TempDir(), "los.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Bring the database to the schema that still stored amounts as REAL
+	migrations, err := Migrations()
+	assert.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE schema_migrations (version INTEGER PRIMARY KEY, name TEXT NOT NULL, applied_at TEXT NOT NULL)`)
+	assert.NoError(t, err)
+	for _, migration := range migrations[:2] {
+		_, err := db.Exec(migration.SQL)
+		assert.NoError(t, err)
+		_, err = db.Exec(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, '')`, migration.Version, migration.Name)
+		assert.NoError(t, err)
+	}
+
+	_, err = db.Exec(`INSERT INTO loans (id, borrower_id, principal_amount, rate, roi, state, created_at, updated_at)
+		VALUES ('loan-1', 'borrower-1', 1000000.5, 12.5, 10, 'APPROVED', '', '')`)
+	assert.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO loan_investors (loan_id, position, investor_id, amount, email)
+		VALUES ('loan-1', 0, 'investor-1', 0.1, 'one@example.com')`)
+	assert.NoError(t, err)
+
+	assert.NoError(t, Migrate(db, logger))
+
+	var principal, currency, rate, roi, amount, investorCurrency string
+	assert.NoError(t, db.QueryRow(`SELECT principal_amount, currency, rate, roi FROM loans WHERE id = 'loan-1'`).
+		Scan(&principal, &currency, &rate, &roi))
+	assert.NoError(t, db.QueryRow(`SELECT amount, currency FROM loan_investors WHERE loan_id = 'loan-1'`).
+		Scan(&amount, &investorCurrency))
+
+	assert.Equal(t, "1000000.5000", principal)
+	assert.Equal(t, "IDR", currency)
+	assert.Equal(t, "12.5000", rate)
+	assert.Equal(t, "10.0000", roi)
+	assert.Equal(t, "0.1000", amount)
+	assert.Equal(t, "IDR", investorCurrency)
+}
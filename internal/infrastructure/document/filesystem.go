@@ -0,0 +1,180 @@
+package document
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	"github.com/hinha/los-technical/internal/pkg/utils"
+)
+
+// FileSystemDocumentStore keeps documents as files in a local directory. Every document is two files named
+// after its ID: the content and the metadata in <id>.json, which is written last so a document is only
+// found once its content is complete.
+type FileSystemDocumentStore struct {
+	dir string
+	// baseURL is the URL documents are served under, the document ID is appended to it
+	baseURL string
+	logger  *logrus.Logger
+}
+
+// NewFileSystemDocumentStore creates a document store in dir, creating the directory when it does not exist,
+// whose documents are served under baseURL
+func NewFileSystemDocumentStore(dir, baseURL string, logger *logrus.Logger) (*FileSystemDocumentStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create document directory: %w", err)
+	}
+	return &FileSystemDocumentStore{
+		dir:     dir,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		logger:  logger,
+	}, nil
+}
+
+// Save stores the content as a new document
+func (s *FileSystemDocumentStore) Save(document domain.Document, content []byte) (*domain.Document, error) {
+	document.ID = utils.GenerateUUID()
+	document.Size = int64(len(content))
+	document.Checksum = domain.Checksum(content)
+	document.URL = s.baseURL + "/" + document.ID
+	document.CreatedAt = time.Now()
+
+	s.logger.WithFields(logrus.Fields{
+		"layer":       "repository",
+		"function":    "Save",
+		"document_id": document.ID,
+		"loan_id":     document.LoanID,
+		"kind":        document.Kind,
+		"size":        document.Size,
+	}).Info("Saving document")
+
+	metadata, err := json.Marshal(document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode document: %w", err)
+	}
+	if err := s.writeFile(document.ID, content); err != nil {
+		return nil, err
+	}
+	if err := s.writeFile(document.ID+".json", metadata); err != nil {
+		_ = os.Remove(filepath.Join(s.dir, document.ID))
+		return nil, err
+	}
+	return &document, nil
+}
+
+// Find retrieves a document
+func (s *FileSystemDocumentStore) Find(id string) (*domain.Document, error) {
+	s.logger.WithFields(logrus.Fields{
+		"layer":       "repository",
+		"function":    "Find",
+		"document_id": id,
+	}).Info("Finding document")
+
+	metadata, err := s.readFile(id, ".json")
+	if err != nil {
+		return nil, err
+	}
+	var document domain.Document
+	if err := json.Unmarshal(metadata, &document); err != nil {
+		return nil, fmt.Errorf("failed to decode document %s: %w", id, err)
+	}
+	return &document, nil
+}
+
+// Read retrieves the content of a document
+func (s *FileSystemDocumentStore) Read(id string) ([]byte, error) {
+	s.logger.WithFields(logrus.Fields{
+		"layer":       "repository",
+		"function":    "Read",
+		"document_id": id,
+	}).Info("Reading document")
+
+	// A document without metadata was never completely saved
+	if _, err := s.readFile(id, ".json"); err != nil {
+		return nil, err
+	}
+	return s.readFile(id, "")
+}
+
+// Delete removes a document. The metadata goes first, so the document is no longer found even if removing
+// its content fails.
+func (s *FileSystemDocumentStore) Delete(id string) error {
+	s.logger.WithFields(logrus.Fields{
+		"layer":       "repository",
+		"function":    "Delete",
+		"document_id": id,
+	}).Info("Deleting document")
+
+	if !validID(id) {
+		return fmt.Errorf("%w: %s", domain.ErrDocumentNotFound, id)
+	}
+	err := os.Remove(filepath.Join(s.dir, id+".json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %s", domain.ErrDocumentNotFound, id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete document %s: %w", id, err)
+	}
+	if err := os.Remove(filepath.Join(s.dir, id)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete document %s: %w", id, err)
+	}
+	return nil
+}
+
+// readFile reads the file of the document with the given suffix
+func (s *FileSystemDocumentStore) readFile(id, suffix string) ([]byte, error) {
+	// IDs are generated UUIDs, anything else could name a file outside the directory
+	if !validID(id) {
+		return nil, fmt.Errorf("%w: %s", domain.ErrDocumentNotFound, id)
+	}
+	content, err := os.ReadFile(filepath.Join(s.dir, id+suffix))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", domain.ErrDocumentNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read document %s: %w", id, err)
+	}
+	return content, nil
+}
+
+// writeFile writes the file through a temporary file, so it is either complete or missing
+func (s *FileSystemDocumentStore) writeFile(name string, content []byte) error {
+	temp, err := os.CreateTemp(s.dir, ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to write document: %w", err)
+	}
+	defer func() { _ = os.Remove(temp.Name()) }()
+
+	if _, err := temp.Write(content); err != nil {
+		_ = temp.Close()
+		return fmt.Errorf("failed to write document: %w", err)
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("failed to write document: %w", err)
+	}
+	if err := os.Rename(temp.Name(), filepath.Join(s.dir, name)); err != nil {
+		return fmt.Errorf("failed to write document: %w", err)
+	}
+	return nil
+}
+
+// validID reports whether id only has the letters, digits and dashes of a UUID
+func validID(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
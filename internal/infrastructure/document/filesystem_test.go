@@ -0,0 +1,57 @@
+package document
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	"github.com/hinha/los-technical/internal/domain/loan/loantest"
+)
+
+func TestFileSystemDocumentStore_Contract(t *testing.T) {
+	loantest.RunDocumentStoreContract(t, func(t *testing.T) domain.DocumentStore {
+		store, err := NewFileSystemDocumentStore(t.TempDir(), "http://localhost:7002/documents", logrus.New())
+		if err != nil {
+			t.Fatalf("failed to create document store: %v", err)
+		}
+		return store
+	})
+}
+
+func TestFileSystemDocumentStore_Reopen(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "documents")
+	store, err := NewFileSystemDocumentStore(dir, "http://localhost:7002/documents", logrus.New())
+	assert.NoError(t, err)
+	saved, err := store.Save(loantest.NewDocument("loan-1"), []byte("content"))
+	assert.NoError(t, err)
+
+	// Documents outlive the store that saved them
+	reopened, err := NewFileSystemDocumentStore(dir, "http://localhost:7002/documents", logrus.New())
+	assert.NoError(t, err)
+	found, err := reopened.Find(saved.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, saved.Checksum, found.Checksum)
+	content, err := reopened.Read(saved.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("content"), content)
+
+	// Only the content and its metadata are left behind
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestFileSystemDocumentStore_IncompleteDocument(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileSystemDocumentStore(dir, "http://localhost:7002/documents", logrus.New())
+	assert.NoError(t, err)
+
+	// Content without metadata is what a crash during Save leaves behind
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "partial"), []byte("content"), 0o600))
+	_, err = store.Read("partial")
+	assert.ErrorIs(t, err, domain.ErrDocumentNotFound)
+}
@@ -0,0 +1,116 @@
+package document
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	"github.com/hinha/los-technical/internal/pkg/utils"
+)
+
+// storedDocument is a document with its content
+type storedDocument struct {
+	document domain.Document
+	content  []byte
+}
+
+// InMemoryDocumentStore is an in-memory implementation of the DocumentStore interface
+type InMemoryDocumentStore struct {
+	// baseURL is the URL documents are served under, the document ID is appended to it
+	baseURL   string
+	documents map[string]storedDocument
+	mutex     sync.RWMutex
+	logger    *logrus.Logger
+}
+
+// NewInMemoryDocumentStore creates a new in-memory document store whose documents are served under baseURL
+func NewInMemoryDocumentStore(baseURL string, logger *logrus.Logger) *InMemoryDocumentStore {
+	return &InMemoryDocumentStore{
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		documents: make(map[string]storedDocument),
+		logger:    logger,
+	}
+}
+
+// Save stores the content as a new document
+func (s *InMemoryDocumentStore) Save(document domain.Document, content []byte) (*domain.Document, error) {
+	document.ID = utils.GenerateUUID()
+	document.Size = int64(len(content))
+	document.Checksum = domain.Checksum(content)
+	document.URL = s.baseURL + "/" + document.ID
+	document.CreatedAt = time.Now()
+
+	s.logger.WithFields(logrus.Fields{
+		"layer":       "repository",
+		"function":    "Save",
+		"document_id": document.ID,
+		"loan_id":     document.LoanID,
+		"kind":        document.Kind,
+		"size":        document.Size,
+	}).Info("Saving document")
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.documents[document.ID] = storedDocument{document: document, content: append([]byte(nil), content...)}
+	return &document, nil
+}
+
+// Find retrieves a document
+func (s *InMemoryDocumentStore) Find(id string) (*domain.Document, error) {
+	s.logger.WithFields(logrus.Fields{
+		"layer":       "repository",
+		"function":    "Find",
+		"document_id": id,
+	}).Info("Finding document")
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	stored, ok := s.documents[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", domain.ErrDocumentNotFound, id)
+	}
+	document := stored.document
+	return &document, nil
+}
+
+// Read retrieves the content of a document
+func (s *InMemoryDocumentStore) Read(id string) ([]byte, error) {
+	s.logger.WithFields(logrus.Fields{
+		"layer":       "repository",
+		"function":    "Read",
+		"document_id": id,
+	}).Info("Reading document")
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	stored, ok := s.documents[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", domain.ErrDocumentNotFound, id)
+	}
+	return append([]byte(nil), stored.content...), nil
+}
+
+// Delete removes a document
+func (s *InMemoryDocumentStore) Delete(id string) error {
+	s.logger.WithFields(logrus.Fields{
+		"layer":       "repository",
+		"function":    "Delete",
+		"document_id": id,
+	}).Info("Deleting document")
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.documents[id]; !ok {
+		return fmt.Errorf("%w: %s", domain.ErrDocumentNotFound, id)
+	}
+	delete(s.documents, id)
+	return nil
+}
@@ -0,0 +1,26 @@
+package document
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	"github.com/hinha/los-technical/internal/domain/loan/loantest"
+)
+
+func TestInMemoryDocumentStore_Contract(t *testing.T) {
+	loantest.RunDocumentStoreContract(t, func(t *testing.T) domain.DocumentStore {
+		return NewInMemoryDocumentStore("http://localhost:7002/documents", logrus.New())
+	})
+}
+
+func TestInMemoryDocumentStore_URL(t *testing.T) {
+	store := NewInMemoryDocumentStore("http://localhost:7002/documents/", logrus.New())
+	saved, err := store.Save(loantest.NewDocument("loan-1"), []byte("content"))
+	assert.NoError(t, err)
+	assert.Equal(t, "http://localhost:7002/documents/"+saved.ID, saved.URL)
+	assert.False(t, strings.Contains(strings.TrimPrefix(saved.URL, "http://"), "//"))
+}
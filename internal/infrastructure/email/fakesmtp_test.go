@@ -0,0 +1,266 @@
+package email
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"math/big"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeMessage is a message the fake SMTP server accepted
+type fakeMessage struct {
+	From string
+	To   []string
+	Data string
+	// TLS and User record how the session that delivered the message was secured and authenticated
+	TLS  bool
+	User string
+}
+
+// fakeSMTPServer is an in-process SMTP server that captures the messages delivered to it
+type fakeSMTPServer struct {
+	listener net.Listener
+	// tlsConfig makes the server offer STARTTLS
+	tlsConfig *tls.Config
+	// username and password make the server require AUTH PLAIN
+	username string
+	password string
+	// stall keeps connections open without ever greeting the client
+	stall bool
+
+	mu          sync.Mutex
+	messages    []fakeMessage
+	dataReplies []string
+	connections int
+	open        []net.Conn
+}
+
+// newFakeSMTPServer starts a fake server on a local port, configure is applied before it accepts connections
+func newFakeSMTPServer(t *testing.T, configure func(*fakeSMTPServer)) *fakeSMTPServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	s := &fakeSMTPServer{listener: listener}
+	if configure != nil {
+		configure(s)
+	}
+	go s.serve()
+	t.Cleanup(func() {
+		_ = listener.Close()
+		s.dropConnections()
+	})
+	return s
+}
+
+// port is the port the server listens on
+func (s *fakeSMTPServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+// replyToData queues replies the server sends after the next messages instead of accepting them
+func (s *fakeSMTPServer) replyToData(replies ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dataReplies = append(s.dataReplies, replies...)
+}
+
+// received returns the accepted messages
+func (s *fakeSMTPServer) received() []fakeMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]fakeMessage(nil), s.messages...)
+}
+
+// connectionCount is the number of connections accepted so far
+func (s *fakeSMTPServer) connectionCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.connections
+}
+
+// dropConnections closes every open connection, as a server does with idle clients
+func (s *fakeSMTPServer) dropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.open {
+		_ = conn.Close()
+	}
+	s.open = nil
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.connections++
+		s.open = append(s.open, conn)
+		s.mu.Unlock()
+		go s.handle(conn)
+	}
+}
+
+// session is the state of one SMTP conversation
+type session struct {
+	tls  bool
+	user string
+	from string
+	to   []string
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+	if s.stall {
+		_, _ = bufio.NewReader(conn).ReadByte()
+		return
+	}
+
+	text := textproto.NewConn(conn)
+	state := &session{}
+	reply := func(line string) bool {
+		return text.PrintfLine("%s", line) == nil
+	}
+	if !reply("220 fake.example.com ESMTP") {
+		return
+	}
+
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			lines := []string{"250-fake.example.com", "250-8BITMIME"}
+			if s.tlsConfig != nil && !state.tls {
+				lines = append(lines, "250-STARTTLS")
+			}
+			if s.username != "" {
+				lines = append(lines, "250-AUTH PLAIN")
+			}
+			lines = append(lines, "250 SMTPUTF8")
+			for _, l := range lines {
+				if !reply(l) {
+					return
+				}
+			}
+		case "HELO", "NOOP":
+			reply("250 OK")
+		case "STARTTLS":
+			if s.tlsConfig == nil || state.tls {
+				reply("502 STARTTLS not available")
+				continue
+			}
+			reply("220 Ready to start TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			text = textproto.NewConn(conn)
+			state = &session{tls: true}
+		case "AUTH":
+			mechanism, initial, _ := strings.Cut(arg, " ")
+			credentials, err := base64.StdEncoding.DecodeString(initial)
+			parts := strings.Split(string(credentials), "\x00")
+			if !strings.EqualFold(mechanism, "PLAIN") || err != nil || len(parts) != 3 ||
+				parts[1] != s.username || parts[2] != s.password {
+				reply("535 5.7.8 Authentication credentials invalid")
+				continue
+			}
+			state.user = parts[1]
+			reply("235 2.7.0 Authentication successful")
+		case "MAIL":
+			if s.username != "" && state.user == "" {
+				reply("530 5.7.0 Authentication required")
+				continue
+			}
+			state.from = address(arg)
+			state.to = nil
+			reply("250 OK")
+		case "RCPT":
+			state.to = append(state.to, address(arg))
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			reply(s.accept(state, string(data)))
+		case "RSET":
+			state.from, state.to = "", nil
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+// address reads the path of a MAIL FROM:<path> or RCPT TO:<path> argument, ignoring its parameters
+func address(arg string) string {
+	_, path, _ := strings.Cut(arg, ":")
+	path, _, _ = strings.Cut(path, " ")
+	return strings.Trim(path, "<>")
+}
+
+// accept stores the message unless a reply was queued for it, and returns the reply to send
+func (s *fakeSMTPServer) accept(state *session, data string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.dataReplies) > 0 {
+		reply := s.dataReplies[0]
+		s.dataReplies = s.dataReplies[1:]
+		return reply
+	}
+	s.messages = append(s.messages, fakeMessage{From: state.from, To: state.to, Data: data, TLS: state.tls, User: state.user})
+	return "250 OK queued"
+}
+
+// newTestCertificate returns a self-signed certificate for 127.0.0.1 and a pool that trusts it
+func newTestCertificate(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "fake.example.com"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(certificate)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pool
+}
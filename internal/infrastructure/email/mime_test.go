@@ -0,0 +1,63 @@
+package email
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+)
+
+func TestBuildMessage(t *testing.T) {
+	email := &domain.RenderedEmail{
+		Subject: "Perjanjian pinjaman – LOAN123",
+		Text:    "Halo, silakan unduh perjanjian Anda.\n",
+		HTML:    "<p>Halo, <a href=\"https://example.com/a.pdf\">unduh</a></p>",
+	}
+
+	data, err := BuildMessage("loans@example.com", "investor@example.com", email)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	message, err := mail.ReadMessage(bytes.NewReader(data))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "loans@example.com", message.Header.Get("From"))
+	assert.Equal(t, "investor@example.com", message.Header.Get("To"))
+	subject, err := new(mime.WordDecoder).DecodeHeader(message.Header.Get("Subject"))
+	assert.NoError(t, err)
+	assert.Equal(t, email.Subject, subject)
+
+	mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	assert.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+
+	// The multipart reader decodes quoted-printable parts
+	reader := multipart.NewReader(message.Body, params["boundary"])
+	var parts []string
+	var types []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if !assert.NoError(t, err) {
+			return
+		}
+		content, err := io.ReadAll(part)
+		assert.NoError(t, err)
+		types = append(types, part.Header.Get("Content-Type"))
+		parts = append(parts, string(content))
+	}
+	assert.Equal(t, []string{"text/plain; charset=UTF-8", "text/html; charset=UTF-8"}, types)
+	// Line breaks are sent as CRLF
+	assert.Equal(t, []string{strings.ReplaceAll(email.Text, "\n", "\r\n"), email.HTML}, parts)
+}
@@ -1,61 +1,60 @@
 package email
 
 import (
+	"fmt"
+
 	"github.com/sirupsen/logrus"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
 )
 
-// ConsoleEmailSender is a simple implementation of the EmailSender interface that logs emails to the console
+// ConsoleEmailSender is the email channel of development setups, it renders emails and logs them
+// instead of sending them
 type ConsoleEmailSender struct {
-	logger *logrus.Logger
+	renderer domain.EmailRenderer
+	logger   *logrus.Logger
 }
 
-// NewConsoleEmailSender creates a new console email sender
-func NewConsoleEmailSender(logger *logrus.Logger) *ConsoleEmailSender {
+// NewConsoleEmailSender creates a new console email sender that renders emails with renderer
+func NewConsoleEmailSender(renderer domain.EmailRenderer, logger *logrus.Logger) *ConsoleEmailSender {
 	return &ConsoleEmailSender{
-		logger: logger,
+		renderer: renderer,
+		logger:   logger,
 	}
 }
 
-// SendAgreementEmail sends an agreement email to an investor
-func (s *ConsoleEmailSender) SendAgreementEmail(email, loanID, agreementURL string) error {
+// Channel returns ChannelEmail
+func (s *ConsoleEmailSender) Channel() domain.Channel {
+	return domain.ChannelEmail
+}
+
+// Send renders the email of the notification and logs it
+func (s *ConsoleEmailSender) Send(notification domain.Notification) error {
+	rendered, err := s.renderer.RenderNotification(notification)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "email",
+			"function": "Send",
+			"email":    notification.Recipient.Email,
+			"kind":     notification.Kind,
+			"loan_id":  notification.LoanID,
+			"error":    err.Error(),
+		}).Error("Failed to render email")
+		return fmt.Errorf("failed to render %s email: %w", notification.Kind, err)
+	}
+
 	s.logger.WithFields(logrus.Fields{
-		"layer":         "email",
-		"function":      "SendAgreementEmail",
-		"email":         email,
-		"loan_id":       loanID,
-		"agreement_url": agreementURL,
-	}).Info("Sending agreement email")
+		"layer":    "email",
+		"function": "Send",
+		"email":    notification.Recipient.Email,
+		"kind":     notification.Kind,
+		"loan_id":  notification.LoanID,
+		"locale":   notification.Recipient.Locale,
+		"subject":  rendered.Subject,
+	}).Info("Sending email")
 
 	// In a real implementation, this would send an actual email
 	// For now, just log the email details
 
 	return nil
 }
-
-// PDFGenerator generates PDF agreement letters
-//type PDFGenerator struct {
-//	logger *logrus.Logger
-//}
-//
-//// NewPDFGenerator creates a new PDF generator
-//func NewPDFGenerator(logger *logrus.Logger) *PDFGenerator {
-//	return &PDFGenerator{
-//		logger: logger,
-//	}
-//}
-//
-//// GenerateAgreementLetter generates a PDF agreement letter for a loan
-//func (g *PDFGenerator) GenerateAgreementLetter(loanID string) (string, error) {
-//	// In a real implementation, this would generate an actual PDF
-//	// For now, just return a fake URL
-//
-//	pdfURL := fmt.Sprintf("https://asia-southeast2-dummy.cloudfunctions.net/agreements/%s.pdf", loanID)
-//	g.logger.WithFields(logrus.Fields{
-//		"layer":    "email",
-//		"function": "GenerateAgreementLetter",
-//		"loan_id":  loanID,
-//		"pdf_url":  pdfURL,
-//	}).Info("Generated agreement letter")
-//
-//	return pdfURL, nil
-//}
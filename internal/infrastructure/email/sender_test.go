@@ -6,44 +6,42 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
 )
 
-func TestConsoleEmailSender_SendAgreementEmail(t *testing.T) {
+func TestConsoleEmailSender_Send(t *testing.T) {
+	templates := loadTestTemplates(t)
+
 	// Define test cases
 	testCases := []struct {
-		name         string
-		email        string
-		loanID       string
-		agreementURL string
-		expectError  bool
+		name            string
+		notification    domain.Notification
+		expectedSubject string
 	}{
 		{
-			name:         "Valid email parameters",
-			email:        "investor@example.com",
-			loanID:       "LOAN123",
-			agreementURL: "https://example.com/agreements/LOAN123.pdf",
-			expectError:  false,
+			name:            "Default locale",
+			notification:    testAgreement(""),
+			expectedSubject: "Your loan agreement for loan LOAN123",
 		},
 		{
-			name:         "Empty email address",
-			email:        "",
-			loanID:       "LOAN123",
-			agreementURL: "https://example.com/agreements/LOAN123.pdf",
-			expectError:  false,
+			name:            "Investor locale",
+			notification:    testAgreement("id"),
+			expectedSubject: "Perjanjian pinjaman LOAN123 Anda",
 		},
 		{
-			name:         "Empty loan ID",
-			email:        "investor@example.com",
-			loanID:       "",
-			agreementURL: "https://example.com/agreements/LOAN123.pdf",
-			expectError:  false, // Current implementation doesn't validate inputs
+			name: "Empty agreement URL",
+			notification: func() domain.Notification {
+				notification := testAgreement("")
+				notification.Agreement.AgreementURL = ""
+				return notification
+			}(),
+			expectedSubject: "Your loan agreement for loan LOAN123",
 		},
 		{
-			name:         "Empty agreement URL",
-			email:        "investor@example.com",
-			loanID:       "LOAN123",
-			agreementURL: "",
-			expectError:  false,
+			name:            "Refund",
+			notification:    testNotification(domain.NotificationRefund),
+			expectedSubject: "Your investment in loan LOAN123 is refunded",
 		},
 	}
 
@@ -53,31 +51,32 @@ func TestConsoleEmailSender_SendAgreementEmail(t *testing.T) {
 			logger, hook := test.NewNullLogger()
 			logger.SetLevel(logrus.InfoLevel)
 
-			sender := NewConsoleEmailSender(logger)
-
-			err := sender.SendAgreementEmail(tc.email, tc.loanID, tc.agreementURL)
+			sender := NewConsoleEmailSender(templates, logger)
 
-			// Assert expectations
-			if tc.expectError {
-				assert.Error(t, err)
-			} else {
-				assert.NoError(t, err)
-			}
+			err := sender.Send(tc.notification)
+			assert.NoError(t, err)
 
 			// Verify log entry was created
 			assert.Equal(t, 1, len(hook.Entries))
 			assert.Equal(t, logrus.InfoLevel, hook.LastEntry().Level)
-			assert.Equal(t, "Sending agreement email", hook.LastEntry().Message)
+			assert.Equal(t, "Sending email", hook.LastEntry().Message)
 
 			// Verify log fields
 			assert.Equal(t, "email", hook.LastEntry().Data["layer"])
-			assert.Equal(t, "SendAgreementEmail", hook.LastEntry().Data["function"])
-			assert.Equal(t, tc.email, hook.LastEntry().Data["email"])
-			assert.Equal(t, tc.loanID, hook.LastEntry().Data["loan_id"])
-			assert.Equal(t, tc.agreementURL, hook.LastEntry().Data["agreement_url"])
-
-			// Clear log entries for next test
-			hook.Reset()
+			assert.Equal(t, "Send", hook.LastEntry().Data["function"])
+			assert.Equal(t, tc.notification.Recipient.Email, hook.LastEntry().Data["email"])
+			assert.Equal(t, tc.notification.LoanID, hook.LastEntry().Data["loan_id"])
+			assert.Equal(t, tc.notification.Kind, hook.LastEntry().Data["kind"])
+			assert.Equal(t, tc.expectedSubject, hook.LastEntry().Data["subject"])
 		})
 	}
+
+	t.Run("Render failure", func(t *testing.T) {
+		logger, hook := test.NewNullLogger()
+		sender := NewConsoleEmailSender(&Templates{variants: map[string]map[string]*variant{}, logger: logger}, logger)
+
+		err := sender.Send(testAgreement(""))
+		assert.ErrorIs(t, err, ErrTemplateNotFound)
+		assert.Equal(t, "Failed to render email", hook.LastEntry().Message)
+	})
 }
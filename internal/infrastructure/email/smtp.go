@@ -0,0 +1,346 @@
+package email
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	"github.com/hinha/los-technical/internal/pkg/utils"
+)
+
+// TLSMode selects how the connection to the SMTP server is encrypted
+type TLSMode string
+
+const (
+	// TLSNone never encrypts the connection, only suitable for local relays
+	TLSNone TLSMode = "none"
+	// TLSOpportunistic upgrades the connection with STARTTLS when the server offers it
+	TLSOpportunistic TLSMode = "starttls"
+	// TLSRequired upgrades the connection with STARTTLS and refuses servers that do not offer it
+	TLSRequired TLSMode = "required"
+	// TLSImplicit connects over TLS from the start, usually on port 465
+	TLSImplicit TLSMode = "tls"
+)
+
+// Defaults of the SMTP sender
+const (
+	DefaultSMTPPort         = 587
+	DefaultSMTPTimeout      = 10 * time.Second
+	DefaultSMTPMaxAttempts  = 3
+	DefaultSMTPRetryBackoff = time.Second
+)
+
+// ErrStartTLSUnsupported is returned when TLS is required but the server does not offer STARTTLS
+var ErrStartTLSUnsupported = errors.New("SMTP server does not support STARTTLS")
+
+// SMTPConfig configures the SMTP sender, zero values take the defaults
+type SMTPConfig struct {
+	Host string
+	Port int
+	// Username and Password authenticate with AUTH PLAIN, no authentication is done without a username
+	Username string
+	Password string
+	// From is the sender of every email, e.g. "Loans <loans@example.com>"
+	From    string
+	TLSMode TLSMode
+	// TLSConfig overrides the TLS settings, e.g. to trust a private CA
+	TLSConfig *tls.Config
+	// Timeout bounds dialing and every delivery on the connection
+	Timeout time.Duration
+	// MaxAttempts is how often a delivery is tried when the server fails temporarily
+	MaxAttempts int
+	// RetryBackoff is the wait before the second attempt, doubled on every further attempt
+	RetryBackoff time.Duration
+}
+
+// withDefaults fills in the zero values
+func (c SMTPConfig) withDefaults() SMTPConfig {
+	if c.Port == 0 {
+		c.Port = DefaultSMTPPort
+	}
+	if c.TLSMode == "" {
+		c.TLSMode = TLSOpportunistic
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultSMTPTimeout
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = DefaultSMTPMaxAttempts
+	}
+	if c.RetryBackoff <= 0 {
+		c.RetryBackoff = DefaultSMTPRetryBackoff
+	}
+	return c
+}
+
+// Validate checks that the server and sender address are set and the TLS mode is known
+func (c SMTPConfig) Validate() error {
+	if c.Host == "" {
+		return errors.New("SMTP host is required")
+	}
+	if _, err := mail.ParseAddress(c.From); err != nil {
+		return fmt.Errorf("invalid SMTP sender address %q: %w", c.From, err)
+	}
+	switch c.TLSMode {
+	case "", TLSNone, TLSOpportunistic, TLSRequired, TLSImplicit:
+		return nil
+	default:
+		return fmt.Errorf("unknown SMTP TLS mode %q", c.TLSMode)
+	}
+}
+
+// SMTPEmailSender is the email channel that delivers rendered emails through an SMTP server. The connection is kept open
+// between deliveries and dialed again when the server has dropped it.
+type SMTPEmailSender struct {
+	config SMTPConfig
+	// sender is the bare address of config.From used in the envelope
+	sender   string
+	renderer domain.EmailRenderer
+	logger   *logrus.Logger
+
+	mu     sync.Mutex
+	conn   net.Conn
+	client *smtp.Client
+}
+
+// NewSMTPEmailSender creates an SMTP email sender that renders emails with renderer
+func NewSMTPEmailSender(config SMTPConfig, renderer domain.EmailRenderer, logger *logrus.Logger) (*SMTPEmailSender, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	from, _ := mail.ParseAddress(config.From)
+	return &SMTPEmailSender{
+		config:   config.withDefaults(),
+		sender:   from.Address,
+		renderer: renderer,
+		logger:   logger,
+	}, nil
+}
+
+// Channel returns ChannelEmail
+func (s *SMTPEmailSender) Channel() domain.Channel {
+	return domain.ChannelEmail
+}
+
+// Send renders the email of the notification and delivers it to its recipient
+func (s *SMTPEmailSender) Send(notification domain.Notification) error {
+	rendered, err := s.renderer.RenderNotification(notification)
+	if err != nil {
+		return fmt.Errorf("failed to render %s email: %w", notification.Kind, err)
+	}
+	return s.send("Send", notification.Recipient.Email, rendered)
+}
+
+// Close ends the open connection, if any
+func (s *SMTPEmailSender) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client == nil {
+		return nil
+	}
+	err := s.client.Quit()
+	s.drop()
+	return err
+}
+
+// send delivers the email, retrying with backoff while the server fails temporarily
+func (s *SMTPEmailSender) send(function, to string, rendered *domain.RenderedEmail) error {
+	message, err := BuildMessage(s.config.From, to, rendered)
+	if err != nil {
+		return err
+	}
+	message = append(envelopeHeaders(s.sender, time.Now()), message...)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	backoff := s.config.RetryBackoff
+	for attempt := 1; ; attempt++ {
+		err = s.deliver(to, message)
+		if err == nil {
+			s.logger.WithFields(logrus.Fields{
+				"layer":    "email",
+				"function": function,
+				"email":    to,
+				"subject":  rendered.Subject,
+				"attempts": attempt,
+			}).Info("Email sent")
+			return nil
+		}
+
+		// A server reply leaves the session usable, it is reset before the next transaction; any other
+		// failure may leave it in any state, so the next attempt dials afresh
+		var reply *textproto.Error
+		if !errors.As(err, &reply) {
+			s.drop()
+		}
+		if !isTransient(err) || attempt >= s.config.MaxAttempts {
+			s.logger.WithFields(logrus.Fields{
+				"layer":    "email",
+				"function": function,
+				"email":    to,
+				"attempts": attempt,
+				"error":    err.Error(),
+			}).Error("Failed to send email")
+			return fmt.Errorf("failed to send email to %s after %d attempts: %w", to, attempt, err)
+		}
+
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "email",
+			"function": function,
+			"email":    to,
+			"attempt":  attempt,
+			"retry_in": backoff.String(),
+			"error":    err.Error(),
+		}).Warn("Email delivery failed temporarily, retrying")
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// deliver runs one SMTP transaction on the open connection, dialing when there is none
+func (s *SMTPEmailSender) deliver(to string, message []byte) error {
+	if err := s.connect(); err != nil {
+		return err
+	}
+	if err := s.conn.SetDeadline(time.Now().Add(s.config.Timeout)); err != nil {
+		return err
+	}
+
+	if err := s.client.Mail(s.sender); err != nil {
+		return err
+	}
+	if err := s.client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := s.client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(message); err != nil {
+		_ = w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// connect reuses the open connection when the server still accepts commands on it, otherwise it dials
+func (s *SMTPEmailSender) connect() error {
+	if s.client != nil {
+		err := s.conn.SetDeadline(time.Now().Add(s.config.Timeout))
+		if err == nil {
+			err = s.client.Reset()
+		}
+		if err == nil {
+			return nil
+		}
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "email",
+			"function": "connect",
+			"error":    err.Error(),
+		}).Info("SMTP connection lost, reconnecting")
+		s.drop()
+	}
+
+	address := net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
+	dialer := &net.Dialer{Timeout: s.config.Timeout}
+	var (
+		conn net.Conn
+		err  error
+	)
+	if s.config.TLSMode == TLSImplicit {
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, s.tlsConfig())
+	} else {
+		conn, err = dialer.Dial("tcp", address)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server %s: %w", address, err)
+	}
+	if err := conn.SetDeadline(time.Now().Add(s.config.Timeout)); err != nil {
+		_ = conn.Close()
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, s.config.Host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	if err := s.startSession(client); err != nil {
+		_ = client.Close()
+		return err
+	}
+	s.conn, s.client = conn, client
+	return nil
+}
+
+// startSession upgrades the connection to TLS as configured and authenticates
+func (s *SMTPEmailSender) startSession(client *smtp.Client) error {
+	if s.config.TLSMode == TLSOpportunistic || s.config.TLSMode == TLSRequired {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(s.tlsConfig()); err != nil {
+				return err
+			}
+		} else if s.config.TLSMode == TLSRequired {
+			return ErrStartTLSUnsupported
+		}
+	}
+	if s.config.Username != "" {
+		auth := smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tlsConfig returns the configured TLS settings, verifying the certificate against the SMTP host
+func (s *SMTPEmailSender) tlsConfig() *tls.Config {
+	if s.config.TLSConfig != nil {
+		config := s.config.TLSConfig.Clone()
+		if config.ServerName == "" {
+			config.ServerName = s.config.Host
+		}
+		return config
+	}
+	return &tls.Config{ServerName: s.config.Host, MinVersion: tls.VersionTLS12}
+}
+
+// drop closes the connection without ending the session politely
+func (s *SMTPEmailSender) drop() {
+	if s.client != nil {
+		_ = s.client.Close()
+	}
+	s.conn, s.client = nil, nil
+}
+
+// isTransient reports whether a failed delivery may succeed when tried again: 4xx replies and
+// network errors are, 5xx replies, untrusted certificates and missing STARTTLS are not
+func isTransient(err error) bool {
+	var reply *textproto.Error
+	if errors.As(err, &reply) {
+		return reply.Code >= 400 && reply.Code < 500
+	}
+	var certificate *tls.CertificateVerificationError
+	return !errors.As(err, &certificate) && !errors.Is(err, ErrStartTLSUnsupported)
+}
+
+// envelopeHeaders are the headers every delivered message needs besides those of BuildMessage
+func envelopeHeaders(from string, now time.Time) []byte {
+	host := "localhost"
+	if i := strings.LastIndex(from, "@"); i >= 0 && i < len(from)-1 {
+		host = from[i+1:]
+	}
+	return []byte(fmt.Sprintf("Date: %s\r\nMessage-ID: <%s@%s>\r\n", now.Format(time.RFC1123Z), utils.GenerateUUID(), host))
+}
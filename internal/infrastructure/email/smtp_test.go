@@ -0,0 +1,328 @@
+package email
+
+import (
+	"crypto/tls"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+)
+
+// newTestSMTPSender returns a sender to the fake server without TLS that retries without waiting
+func newTestSMTPSender(t *testing.T, server *fakeSMTPServer, configure func(*SMTPConfig)) (*SMTPEmailSender, *test.Hook) {
+	t.Helper()
+	config := SMTPConfig{
+		Host:         "127.0.0.1",
+		Port:         server.port(),
+		From:         "Loans <loans@example.com>",
+		TLSMode:      TLSNone,
+		Timeout:      time.Second,
+		RetryBackoff: time.Millisecond,
+	}
+	if configure != nil {
+		configure(&config)
+	}
+	logger, hook := test.NewNullLogger()
+	sender, err := NewSMTPEmailSender(config, loadTestTemplates(t), logger)
+	if err != nil {
+		t.Fatalf("failed to create SMTP sender: %v", err)
+	}
+	t.Cleanup(func() { _ = sender.Close() })
+	return sender, hook
+}
+
+// readParts parses a delivered message and returns its headers and decoded text and HTML bodies
+func readParts(t *testing.T, data string) (mail.Header, []string) {
+	t.Helper()
+	message, err := mail.ReadMessage(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to parse message: %v", err)
+	}
+	_, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("failed to parse content type: %v", err)
+	}
+	reader := multipart.NewReader(message.Body, params["boundary"])
+	var parts []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return message.Header, parts
+		}
+		if err != nil {
+			t.Fatalf("failed to read part: %v", err)
+		}
+		content, _ := io.ReadAll(part)
+		parts = append(parts, string(content))
+	}
+}
+
+func TestSMTPEmailSender_Send(t *testing.T) {
+	server := newFakeSMTPServer(t, nil)
+	sender, hook := newTestSMTPSender(t, server, nil)
+
+	err := sender.Send(testAgreement("id"))
+	assert.NoError(t, err)
+
+	messages := server.received()
+	if !assert.Len(t, messages, 1) {
+		return
+	}
+	assert.Equal(t, "loans@example.com", messages[0].From)
+	assert.Equal(t, []string{"investor@example.com"}, messages[0].To)
+
+	header, parts := readParts(t, messages[0].Data)
+	assert.Equal(t, "Loans <loans@example.com>", header.Get("From"))
+	assert.Equal(t, "investor@example.com", header.Get("To"))
+	subject, _ := new(mime.WordDecoder).DecodeHeader(header.Get("Subject"))
+	assert.Equal(t, "Perjanjian pinjaman LOAN123 Anda", subject)
+	assert.NotEmpty(t, header.Get("Date"))
+	assert.True(t, strings.HasSuffix(header.Get("Message-ID"), "@example.com>"))
+	if assert.Len(t, parts, 2) {
+		assert.Contains(t, parts[0], "Jumlah investasi:      2500000 IDR")
+		assert.Contains(t, parts[1], `href="https://example.com/agreements/LOAN123.pdf"`)
+	}
+	assert.Equal(t, "Email sent", hook.LastEntry().Message)
+}
+
+func TestSMTPEmailSender_Send_Refund(t *testing.T) {
+	server := newFakeSMTPServer(t, nil)
+	sender, _ := newTestSMTPSender(t, server, nil)
+
+	refund := testNotification(domain.NotificationRefund)
+	refund.Reason = domain.ReasonBorrowerWithdrawn
+	err := sender.Send(refund)
+	assert.NoError(t, err)
+
+	messages := server.received()
+	if assert.Len(t, messages, 1) {
+		header, parts := readParts(t, messages[0].Data)
+		assert.Equal(t, "Your investment in loan LOAN123 is refunded", header.Get("Subject"))
+		assert.Equal(t, []string{"recipient@example.com"}, messages[0].To)
+		assert.Contains(t, parts[0], "BORROWER_WITHDRAWN")
+		assert.Contains(t, parts[0], "500000 IDR")
+	}
+}
+
+func TestSMTPEmailSender_Security(t *testing.T) {
+	certificate, pool := newTestCertificate(t)
+	secure := func(s *fakeSMTPServer) {
+		s.tlsConfig = &tls.Config{Certificates: []tls.Certificate{certificate}}
+		s.username = "loans"
+		s.password = "secret"
+	}
+
+	tests := []struct {
+		name      string
+		server    func(*fakeSMTPServer)
+		config    func(*SMTPConfig)
+		wantErr   string
+		wantTLS   bool
+		wantUser  string
+		wantConns int
+	}{
+		{
+			name:   "STARTTLS And Authentication",
+			server: secure,
+			config: func(c *SMTPConfig) {
+				c.TLSMode = TLSRequired
+				c.TLSConfig = &tls.Config{RootCAs: pool}
+				c.Username, c.Password = "loans", "secret"
+			},
+			wantTLS:   true,
+			wantUser:  "loans",
+			wantConns: 1,
+		},
+		{
+			name: "Opportunistic TLS Without STARTTLS",
+			config: func(c *SMTPConfig) {
+				c.TLSMode = TLSOpportunistic
+			},
+			wantConns: 1,
+		},
+		{
+			name: "Required TLS Without STARTTLS Is Not Retried",
+			config: func(c *SMTPConfig) {
+				c.TLSMode = TLSRequired
+			},
+			wantErr:   ErrStartTLSUnsupported.Error(),
+			wantConns: 1,
+		},
+		{
+			name:   "Untrusted Certificate Is Not Retried",
+			server: secure,
+			config: func(c *SMTPConfig) {
+				c.TLSMode = TLSRequired
+			},
+			wantErr:   "certificate signed by unknown authority",
+			wantConns: 1,
+		},
+		{
+			name:   "Wrong Password Is Not Retried",
+			server: secure,
+			config: func(c *SMTPConfig) {
+				c.TLSMode = TLSRequired
+				c.TLSConfig = &tls.Config{RootCAs: pool}
+				c.Username, c.Password = "loans", "wrong"
+			},
+			wantErr:   "Authentication credentials invalid",
+			wantConns: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeSMTPServer(t, tt.server)
+			sender, _ := newTestSMTPSender(t, server, tt.config)
+
+			err := sender.Send(testAgreement(""))
+			assert.Equal(t, tt.wantConns, server.connectionCount())
+			if tt.wantErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tt.wantErr)
+				}
+				assert.Empty(t, server.received())
+				return
+			}
+			assert.NoError(t, err)
+			if messages := server.received(); assert.Len(t, messages, 1) {
+				assert.Equal(t, tt.wantTLS, messages[0].TLS)
+				assert.Equal(t, tt.wantUser, messages[0].User)
+			}
+		})
+	}
+}
+
+func TestSMTPEmailSender_ConnectionReuse(t *testing.T) {
+	t.Run("Reuses the connection between emails", func(t *testing.T) {
+		server := newFakeSMTPServer(t, nil)
+		sender, _ := newTestSMTPSender(t, server, nil)
+
+		assert.NoError(t, sender.Send(testAgreement("")))
+		assert.NoError(t, sender.Send(testAgreement("id")))
+		assert.Len(t, server.received(), 2)
+		assert.Equal(t, 1, server.connectionCount())
+	})
+
+	t.Run("Reconnects when the server dropped the connection", func(t *testing.T) {
+		server := newFakeSMTPServer(t, nil)
+		sender, hook := newTestSMTPSender(t, server, nil)
+
+		assert.NoError(t, sender.Send(testAgreement("")))
+		server.dropConnections()
+		assert.NoError(t, sender.Send(testAgreement("")))
+		assert.Len(t, server.received(), 2)
+		assert.Equal(t, 2, server.connectionCount())
+
+		var reconnected bool
+		for _, entry := range hook.AllEntries() {
+			reconnected = reconnected || entry.Message == "SMTP connection lost, reconnecting"
+		}
+		assert.True(t, reconnected)
+	})
+
+	t.Run("Close ends the session", func(t *testing.T) {
+		server := newFakeSMTPServer(t, nil)
+		sender, _ := newTestSMTPSender(t, server, nil)
+
+		assert.NoError(t, sender.Send(testAgreement("")))
+		assert.NoError(t, sender.Close())
+		assert.NoError(t, sender.Close())
+		assert.NoError(t, sender.Send(testAgreement("")))
+		assert.Equal(t, 2, server.connectionCount())
+	})
+}
+
+func TestSMTPEmailSender_Retry(t *testing.T) {
+	tests := []struct {
+		name        string
+		replies     []string
+		maxAttempts int
+		wantErr     bool
+		wantSent    int
+		wantLevel   logrus.Level
+	}{
+		{
+			name:      "Temporary Failure Is Retried",
+			replies:   []string{"451 4.3.0 Try again later"},
+			wantSent:  1,
+			wantLevel: logrus.InfoLevel,
+		},
+		{
+			name:      "Gives Up After Max Attempts",
+			replies:   []string{"451 4.3.0 Try again later", "421 4.4.2 Timeout", "451 4.3.0 Try again later"},
+			wantErr:   true,
+			wantLevel: logrus.ErrorLevel,
+		},
+		{
+			name:      "Permanent Failure Is Not Retried",
+			replies:   []string{"554 5.7.1 Message rejected", "250 never reached"},
+			wantErr:   true,
+			wantLevel: logrus.ErrorLevel,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeSMTPServer(t, nil)
+			server.replyToData(tt.replies...)
+			sender, hook := newTestSMTPSender(t, server, nil)
+
+			err := sender.Send(testAgreement(""))
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Len(t, server.received(), tt.wantSent)
+			assert.Equal(t, tt.wantLevel, hook.LastEntry().Level)
+		})
+	}
+}
+
+func TestSMTPEmailSender_Timeout(t *testing.T) {
+	server := newFakeSMTPServer(t, func(s *fakeSMTPServer) { s.stall = true })
+	sender, _ := newTestSMTPSender(t, server, func(c *SMTPConfig) {
+		c.Timeout = 50 * time.Millisecond
+		c.MaxAttempts = 2
+	})
+
+	start := time.Now()
+	err := sender.Send(testAgreement(""))
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+	// A timeout is a network error, so it is retried
+	assert.Equal(t, 2, server.connectionCount())
+}
+
+func TestSMTPConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		config SMTPConfig
+		want   string
+	}{
+		{name: "Valid", config: SMTPConfig{Host: "smtp.example.com", From: "loans@example.com"}},
+		{name: "Missing Host", config: SMTPConfig{From: "loans@example.com"}, want: "SMTP host is required"},
+		{name: "Invalid Sender", config: SMTPConfig{Host: "smtp.example.com", From: "loans"}, want: "invalid SMTP sender address"},
+		{name: "Unknown TLS Mode", config: SMTPConfig{Host: "smtp.example.com", From: "loans@example.com", TLSMode: "ssl"}, want: `unknown SMTP TLS mode "ssl"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.want == "" {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.want)
+			}
+		})
+	}
+}
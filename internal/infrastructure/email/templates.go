@@ -0,0 +1,214 @@
+package email
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/sirupsen/logrus"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+)
+
+// DefaultLocale is used when a recipient has no locale or no template exists for it
+const DefaultLocale = "en"
+
+// subjectTemplate is the template defined in the text variant that renders the subject line
+const subjectTemplate = "subject"
+
+// ErrTemplateNotFound is returned when no variant of a template exists for a locale or the default locale
+var ErrTemplateNotFound = errors.New("email template not found")
+
+// variant is a template in one locale, both bodies are required
+type variant struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// Templates renders emails from the templates of a directory. Files are named <name>.txt.tmpl and
+// <name>.html.tmpl for the default locale and <name>.<locale>.txt.tmpl and <name>.<locale>.html.tmpl
+// for the others. The text variant defines the subject line as {{define "subject"}}.
+type Templates struct {
+	variants      map[string]map[string]*variant
+	defaultLocale string
+	logger        *logrus.Logger
+}
+
+// LoadTemplates parses every template of dir. It fails when a variant misses one of its bodies or
+// its subject, or when a template has no variant in the default locale.
+func LoadTemplates(dir, defaultLocale string, logger *logrus.Logger) (*Templates, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list email templates: %w", err)
+	}
+
+	t := &Templates{
+		variants:      make(map[string]map[string]*variant),
+		defaultLocale: normalizeLocale(defaultLocale),
+		logger:        logger,
+	}
+	for _, path := range paths {
+		if err := t.parse(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := t.check(); err != nil {
+		return nil, err
+	}
+
+	logger.WithFields(logrus.Fields{
+		"layer":          "email",
+		"function":       "LoadTemplates",
+		"dir":            dir,
+		"default_locale": t.defaultLocale,
+		"templates":      t.Names(),
+	}).Info("Email templates loaded")
+	return t, nil
+}
+
+// parse adds the template file to the variant its name stands for
+func (t *Templates) parse(path string) error {
+	base := filepath.Base(path)
+	parts := strings.Split(strings.TrimSuffix(base, ".tmpl"), ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return fmt.Errorf("email template %s is not named <name>[.<locale>].<txt|html>.tmpl", base)
+	}
+	name, format := parts[0], parts[len(parts)-1]
+	locale := t.defaultLocale
+	if len(parts) == 3 {
+		locale = normalizeLocale(parts[1])
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read email template %s: %w", base, err)
+	}
+
+	if t.variants[name] == nil {
+		t.variants[name] = make(map[string]*variant)
+	}
+	v := t.variants[name][locale]
+	if v == nil {
+		v = &variant{}
+		t.variants[name][locale] = v
+	}
+
+	switch format {
+	case "txt":
+		v.text, err = texttemplate.New(base).Option("missingkey=error").Parse(string(content))
+	case "html":
+		v.html, err = htmltemplate.New(base).Option("missingkey=error").Parse(string(content))
+	default:
+		return fmt.Errorf("email template %s has unknown format %q", base, format)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse email template %s: %w", base, err)
+	}
+	return nil
+}
+
+// check verifies that every variant is complete and every template has a default variant
+func (t *Templates) check() error {
+	for name, locales := range t.variants {
+		if locales[t.defaultLocale] == nil {
+			return fmt.Errorf("email template %s has no variant in the default locale %s", name, t.defaultLocale)
+		}
+		for locale, v := range locales {
+			switch {
+			case v.text == nil:
+				return fmt.Errorf("email template %s in locale %s has no text body", name, locale)
+			case v.html == nil:
+				return fmt.Errorf("email template %s in locale %s has no HTML body", name, locale)
+			case v.text.Lookup(subjectTemplate) == nil:
+				return fmt.Errorf("email template %s in locale %s does not define a subject", name, locale)
+			}
+		}
+	}
+	return nil
+}
+
+// Names lists the loaded templates in alphabetical order
+func (t *Templates) Names() []string {
+	names := make([]string, 0, len(t.variants))
+	for name := range t.variants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Locales lists the locales a template is available in, in alphabetical order
+func (t *Templates) Locales(name string) []string {
+	locales := make([]string, 0, len(t.variants[name]))
+	for locale := range t.variants[name] {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Render renders the template in the locale, falling back to its language and then to the default locale
+func (t *Templates) Render(name, locale string, data any) (*domain.RenderedEmail, error) {
+	v, err := t.lookup(name, locale)
+	if err != nil {
+		return nil, err
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := v.text.ExecuteTemplate(&subject, subjectTemplate, data); err != nil {
+		return nil, fmt.Errorf("failed to render subject of email template %s: %w", name, err)
+	}
+	if err := v.text.Execute(&text, data); err != nil {
+		return nil, fmt.Errorf("failed to render text of email template %s: %w", name, err)
+	}
+	if err := v.html.Execute(&html, data); err != nil {
+		return nil, fmt.Errorf("failed to render HTML of email template %s: %w", name, err)
+	}
+
+	return &domain.RenderedEmail{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    html.String(),
+	}, nil
+}
+
+// TemplateName is the name of the template of a notification kind, e.g. "loan_approved" for LOAN_APPROVED
+func TemplateName(kind domain.NotificationKind) string {
+	return strings.ToLower(string(kind))
+}
+
+// RenderNotification renders the email of the notification in the locale of its recipient
+func (t *Templates) RenderNotification(notification domain.Notification) (*domain.RenderedEmail, error) {
+	return t.Render(TemplateName(notification.Kind), notification.Recipient.Locale, notification)
+}
+
+// lookup finds the variant of the template for the locale, e.g. "id-ID", then "id", then the default
+func (t *Templates) lookup(name, locale string) (*variant, error) {
+	locales, ok := t.variants[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrTemplateNotFound, name)
+	}
+	locale = normalizeLocale(locale)
+	candidates := []string{locale}
+	if language, _, found := strings.Cut(locale, "-"); found {
+		candidates = append(candidates, language)
+	}
+	candidates = append(candidates, t.defaultLocale)
+	for _, candidate := range candidates {
+		if v, ok := locales[candidate]; ok {
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s in locale %s", ErrTemplateNotFound, name, locale)
+}
+
+// normalizeLocale lower-cases a locale and separates its parts with a hyphen, so "id_ID" matches "id-id"
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
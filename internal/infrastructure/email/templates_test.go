@@ -0,0 +1,211 @@
+package email
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	"github.com/hinha/los-technical/internal/pkg/money"
+)
+
+// templateDir holds the templates the service is deployed with
+const templateDir = "../../../templates/email"
+
+func loadTestTemplates(t *testing.T) *Templates {
+	t.Helper()
+	logger, _ := test.NewNullLogger()
+	templates, err := LoadTemplates(templateDir, DefaultLocale, logger)
+	if err != nil {
+		t.Fatalf("failed to load templates: %v", err)
+	}
+	return templates
+}
+
+// testAgreement is an agreement notification to an investor in the locale
+func testAgreement(locale string) domain.Notification {
+	amount := money.MustNew("2500000", "IDR")
+	return domain.Notification{
+		ID:        "notification-1",
+		Kind:      domain.NotificationAgreement,
+		LoanID:    "LOAN123",
+		Recipient: domain.Recipient{ID: "investor-1", Role: domain.RoleInvestor, Name: "Budi <Santoso>", Email: "investor@example.com", Locale: locale},
+		Amount:    &amount,
+		Agreement: &domain.AgreementEmail{
+			Email:          "investor@example.com",
+			Locale:         locale,
+			LoanID:         "LOAN123",
+			InvestorName:   "Budi <Santoso>",
+			Amount:         amount,
+			ROI:            money.MustParse("10"),
+			ExpectedReturn: money.MustNew("250000", "IDR"),
+			Terms:          domain.RepaymentTerms{TenorMonths: 12, Scheme: domain.SchemeAnnuity},
+			AgreementURL:   "https://example.com/agreements/LOAN123.pdf",
+		},
+	}
+}
+
+// testNotification is a notification of the kind to the borrower or an investor, with what its templates need
+func testNotification(kind domain.NotificationKind) domain.Notification {
+	amount := money.MustNew("500000", "IDR")
+	dueDate := time.Date(2025, 2, 15, 0, 0, 0, 0, time.UTC)
+	return domain.Notification{
+		ID:          "notification-1",
+		Kind:        kind,
+		LoanID:      "LOAN123",
+		Recipient:   domain.Recipient{ID: "recipient-1", Email: "recipient@example.com"},
+		Amount:      &amount,
+		Reason:      domain.ReasonFundingExpired,
+		Installment: 2,
+		DueDate:     &dueDate,
+	}
+}
+
+// writeTemplates writes the files to a new directory and returns it
+func writeTemplates(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestTemplates_RenderNotification_Agreement(t *testing.T) {
+	templates := loadTestTemplates(t)
+	assert.Equal(t, []string{"en", "id"}, templates.Locales(TemplateName(domain.NotificationAgreement)))
+
+	tests := []struct {
+		name    string
+		locale  string
+		subject string
+		text    []string
+	}{
+		{
+			name:    "Default Locale",
+			subject: "Your loan agreement for loan LOAN123",
+			text:    []string{"Dear Budi <Santoso>,", "Invested amount: 2500000 IDR", "Expected ROI:    10%", "Expected return: 250000 IDR", "12 months, ANNUITY repayment"},
+		},
+		{
+			name:    "Locale Variant",
+			locale:  "id",
+			subject: "Perjanjian pinjaman LOAN123 Anda",
+			text:    []string{"Yth. Budi <Santoso>,", "2500000 IDR", "12 bulan"},
+		},
+		{
+			name:    "Falls Back To Language",
+			locale:  "id_ID",
+			subject: "Perjanjian pinjaman LOAN123 Anda",
+		},
+		{
+			name:    "Falls Back To Default Locale",
+			locale:  "fr",
+			subject: "Your loan agreement for loan LOAN123",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered, err := templates.RenderNotification(testAgreement(tt.locale))
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.subject, rendered.Subject)
+			for _, text := range tt.text {
+				assert.Contains(t, rendered.Text, text)
+			}
+			assert.Contains(t, rendered.HTML, "Budi &lt;Santoso&gt;", "HTML must be escaped")
+			assert.Contains(t, rendered.HTML, `href="https://example.com/agreements/LOAN123.pdf"`)
+		})
+	}
+}
+
+func TestTemplates_RenderNotification(t *testing.T) {
+	templates := loadTestTemplates(t)
+
+	tests := []struct {
+		kind    domain.NotificationKind
+		subject string
+		text    string
+	}{
+		{domain.NotificationLoanApproved, "Your loan LOAN123 is approved", "Dear Borrower,"},
+		{domain.NotificationLoanFunded, "Your loan LOAN123 is fully funded", "of 500000 IDR is now fully funded"},
+		{domain.NotificationLoanDisbursed, "Your loan LOAN123 is disbursed", "of 500000 IDR has been disbursed"},
+		{domain.NotificationInstallmentDue, "Installment 2 of loan LOAN123 is due on 15 February 2025", "Amount due: 500000 IDR"},
+		{domain.NotificationInvestmentReceived, "Your investment in loan LOAN123 is received", "Dear Investor,"},
+		{domain.NotificationInvestmentDisbursed, "Loan LOAN123 is disbursed", "including your investment of 500000 IDR"},
+		{domain.NotificationRepaymentDistributed, "Your share of a repayment of loan LOAN123", "Your share: 500000 IDR"},
+		{domain.NotificationRefund, "Your investment in loan LOAN123 is refunded", "cancelled (FUNDING_EXPIRED)"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.kind), func(t *testing.T) {
+			rendered, err := templates.RenderNotification(testNotification(tt.kind))
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.subject, rendered.Subject)
+			assert.Contains(t, rendered.Text, tt.text)
+			assert.Contains(t, rendered.HTML, "LOAN123")
+		})
+	}
+}
+
+func TestLoadTemplates_Errors(t *testing.T) {
+	const (
+		text = `{{define "subject"}}Hi{{end}}Hello {{.Name}}`
+		html = `<p>Hello {{.Name}}</p>`
+	)
+
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name:  "Missing HTML Body",
+			files: map[string]string{"welcome.txt.tmpl": text},
+			want:  "email template welcome in locale en has no HTML body",
+		},
+		{
+			name:  "Missing Subject",
+			files: map[string]string{"welcome.txt.tmpl": "Hello", "welcome.html.tmpl": html},
+			want:  "email template welcome in locale en does not define a subject",
+		},
+		{
+			name:  "Missing Default Locale",
+			files: map[string]string{"welcome.id.txt.tmpl": text, "welcome.id.html.tmpl": html},
+			want:  "email template welcome has no variant in the default locale en",
+		},
+		{
+			name:  "Unknown Format",
+			files: map[string]string{"welcome.md.tmpl": text},
+			want:  `email template welcome.md.tmpl has unknown format "md"`,
+		},
+		{
+			name:  "Parse Error",
+			files: map[string]string{"welcome.txt.tmpl": "{{.Name"},
+			want:  "failed to parse email template welcome.txt.tmpl",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, _ := test.NewNullLogger()
+			_, err := LoadTemplates(writeTemplates(t, tt.files), DefaultLocale, logger)
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplates_Render_NotFound(t *testing.T) {
+	templates := loadTestTemplates(t)
+
+	_, err := templates.Render("welcome", DefaultLocale, nil)
+	assert.ErrorIs(t, err, ErrTemplateNotFound)
+}
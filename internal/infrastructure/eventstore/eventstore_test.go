@@ -0,0 +1,220 @@
+package eventstore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	"github.com/hinha/los-technical/internal/domain/loan/loantest"
+	"github.com/hinha/los-technical/internal/pkg/money"
+)
+
+func newTestLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(logrus.StandardLogger().Out)
+	return logger
+}
+
+func TestInMemoryEventStore_Contract(t *testing.T) {
+	loantest.RunEventStoreContract(t, func(t *testing.T) domain.EventStore {
+		return NewInMemoryEventStore(newTestLogger())
+	})
+}
+
+func TestFileEventStore_Contract(t *testing.T) {
+	loantest.RunEventStoreContract(t, func(t *testing.T) domain.EventStore {
+		store, err := OpenFileEventStore(filepath.Join(t.TempDir(), "events.jsonl"), newTestLogger())
+		if err != nil {
+			t.Fatalf("failed to open event store: %v", err)
+		}
+		t.Cleanup(func() { _ = store.Close() })
+		return store
+	})
+}
+
+func TestFileEventStore_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	store, err := OpenFileEventStore(path, newTestLogger())
+	if err != nil {
+		t.Fatalf("failed to open event store: %v", err)
+	}
+	proposed := loantest.NewEvent(t, "loan-1", 1, domain.EventLoanProposed, domain.LoanProposedData{BorrowerID: "borrower-1"}, 0)
+	approved := loantest.NewEvent(t, "loan-1", 2, domain.EventLoanApproved, domain.LoanApprovedData{Approval: domain.Approval{ValidatorID: "validator-1"}}, 1)
+	assert.NoError(t, store.Append("loan-1", 0, proposed))
+	assert.NoError(t, store.Append("loan-1", 1, approved))
+	assert.NoError(t, store.Close())
+
+	reopened, err := OpenFileEventStore(path, newTestLogger())
+	if err != nil {
+		t.Fatalf("failed to reopen event store: %v", err)
+	}
+	defer reopened.Close()
+
+	got, err := reopened.Load("loan-1")
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.Event{proposed, approved}, got)
+
+	// The stream version survives the restart
+	assert.ErrorIs(t, reopened.Append("loan-1", 1, approved), domain.ErrVersionConflict)
+}
+
+func TestFileEventStore_Corrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	content := "{\"loan_id\":\"loan-1\",\"version\":1,\"type\":\"LoanProp\n{\"loan_id\":\"loan-1\",\"version\":2}\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write event file: %v", err)
+	}
+
+	store, err := OpenFileEventStore(path, newTestLogger())
+	assert.Nil(t, store)
+	assert.ErrorContains(t, err, "failed to read event 1 of event store")
+}
+
+func TestFileEventStore_TornLastLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	store, err := OpenFileEventStore(path, newTestLogger())
+	if err != nil {
+		t.Fatalf("failed to open event store: %v", err)
+	}
+	proposed := loantest.NewEvent(t, "loan-1", 1, domain.EventLoanProposed, domain.LoanProposedData{BorrowerID: "borrower-1"}, 0)
+	assert.NoError(t, store.Append("loan-1", 0, proposed))
+	assert.NoError(t, store.Close())
+
+	// A write interrupted half way leaves a line without its end
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		t.Fatalf("failed to open event file: %v", err)
+	}
+	_, err = file.WriteString(`{"loan_id":"loan-1","version":2,"type":"LoanAppr`)
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+
+	reopened, err := OpenFileEventStore(path, newTestLogger())
+	if err != nil {
+		t.Fatalf("failed to reopen event store: %v", err)
+	}
+	got, err := reopened.Load("loan-1")
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.Event{proposed}, got)
+
+	// The torn line is gone, so later writes start on a line of their own
+	approved := loantest.NewEvent(t, "loan-1", 2, domain.EventLoanApproved, domain.LoanApprovedData{Approval: domain.Approval{ValidatorID: "validator-1"}}, 1)
+	assert.NoError(t, reopened.Append("loan-1", 1, approved))
+	assert.NoError(t, reopened.Close())
+
+	again, err := OpenFileEventStore(path, newTestLogger())
+	if err != nil {
+		t.Fatalf("failed to reopen event store: %v", err)
+	}
+	defer again.Close()
+	got, err = again.Load("loan-1")
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.Event{proposed, approved}, got)
+}
+
+func TestFileEventStore_Rollback(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	store, err := OpenFileEventStore(path, newTestLogger())
+	if err != nil {
+		t.Fatalf("failed to open event store: %v", err)
+	}
+	defer store.Close()
+	proposed := loantest.NewEvent(t, "loan-1", 1, domain.EventLoanProposed, domain.LoanProposedData{BorrowerID: "borrower-1"}, 0)
+	assert.NoError(t, store.Append("loan-1", 0, proposed))
+	stored, err := os.ReadFile(path)
+	assert.NoError(t, err)
+
+	// A write that fails after part of it reached the file is truncated away
+	_, err = store.file.WriteString(`{"loan_id":"loan-1","version":2,"type":"LoanAppr`)
+	assert.NoError(t, err)
+	cause := errors.New("disk full")
+	assert.Equal(t, cause, store.rollback(int64(len(stored)), cause))
+
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, stored, content)
+}
+
+func TestInMemoryEventStore_OutboxContract(t *testing.T) {
+	loantest.RunOutboxContract(t, func(t *testing.T) loantest.OutboxStore {
+		return NewInMemoryEventStore(newTestLogger())
+	})
+}
+
+func TestFileEventStore_OutboxContract(t *testing.T) {
+	loantest.RunOutboxContract(t, func(t *testing.T) loantest.OutboxStore {
+		store, err := OpenFileEventStore(filepath.Join(t.TempDir(), "events.jsonl"), newTestLogger())
+		if err != nil {
+			t.Fatalf("failed to open event store: %v", err)
+		}
+		t.Cleanup(func() { _ = store.Close() })
+		return store
+	})
+}
+
+func TestFileEventStore_ReopenOutbox(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	store, err := OpenFileEventStore(path, newTestLogger())
+	if err != nil {
+		t.Fatalf("failed to open event store: %v", err)
+	}
+	proposed := loantest.NewEvent(t, "loan-1", 1, domain.EventLoanProposed, domain.LoanProposedData{BorrowerID: "borrower-1"}, 0)
+	sent := loantest.NewOutboxMessage("message-1", "loan-1", 0)
+	pending := loantest.NewOutboxMessage("message-2", "loan-1", 1)
+	assert.NoError(t, store.Commit("loan-1", 0, []domain.Event{proposed}, []domain.OutboxMessage{sent, pending}))
+	sent.MarkSent(sent.CreatedAt.Add(time.Minute))
+	assert.NoError(t, store.Update(sent))
+	assert.NoError(t, store.Close())
+
+	reopened, err := OpenFileEventStore(path, newTestLogger())
+	if err != nil {
+		t.Fatalf("failed to reopen event store: %v", err)
+	}
+	defer reopened.Close()
+
+	// The last state of every message wins
+	all, err := reopened.LoadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.Event{proposed}, all)
+	due, err := reopened.FindDue(pending.CreatedAt.Add(time.Hour), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.OutboxMessage{pending}, due)
+	delivered, err := reopened.FindByStatus(domain.OutboxSent)
+	assert.NoError(t, err)
+	if assert.Len(t, delivered, 1) {
+		assert.Equal(t, sent.SentAt.UTC(), delivered[0].SentAt.UTC())
+	}
+}
+
+func TestCloneMessage(t *testing.T) {
+	at := time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC)
+	amount := money.MustNew("1000", money.DefaultCurrency)
+	dueDate, sentAt := at.AddDate(0, 1, 0), at.Add(time.Minute)
+	message := loantest.NewOutboxMessage("message-1", "loan-1", 0)
+	message.Amount = &amount
+	message.DueDate = &dueDate
+	message.SentAt = &sentAt
+	message.Agreement = &domain.AgreementEmail{LoanID: "loan-1", Amount: amount}
+
+	clone := cloneMessage(message)
+	*message.Amount = money.MustNew("1", money.DefaultCurrency)
+	*message.DueDate = at
+	*message.SentAt = at
+	message.Agreement.Amount = money.MustNew("1", money.DefaultCurrency)
+
+	// Every pointer field of the clone is its own copy
+	assert.Equal(t, money.MustNew("1000", money.DefaultCurrency), *clone.Amount)
+	assert.Equal(t, at.AddDate(0, 1, 0), *clone.DueDate)
+	assert.Equal(t, at.Add(time.Minute), *clone.SentAt)
+	assert.Equal(t, money.MustNew("1000", money.DefaultCurrency), clone.Agreement.Amount)
+}
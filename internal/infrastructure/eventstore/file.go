@@ -0,0 +1,201 @@
+package eventstore
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sirupsen/logrus"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+)
+
+// FileEventStore is an event store backed by a JSON Lines file, one event or outbox message per line.
+// The file is only ever appended to: a delivery update adds the new state of the message, and the last
+// line of a message wins when the file is read back. The whole file is read into memory when the store
+// is opened and every write is synced to disk before it becomes visible.
+type FileEventStore struct {
+	*InMemoryEventStore
+	file *os.File
+}
+
+// record is a line of the event file, holding either an event or the state of an outbox message
+type record struct {
+	*domain.Event
+	Outbox *domain.OutboxMessage `json:"outbox,omitempty"`
+}
+
+// OpenFileEventStore opens the event file at path, creating it when it does not exist
+func OpenFileEventStore(path string, logger *logrus.Logger) (*FileEventStore, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event store: %w", err)
+	}
+
+	store := &FileEventStore{
+		InMemoryEventStore: NewInMemoryEventStore(logger),
+		file:               file,
+	}
+	if err := store.load(); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	logger.WithFields(logrus.Fields{
+		"layer":    "repository",
+		"path":     path,
+		"events":   len(store.events),
+		"messages": len(store.messages),
+	}).Info("Event store opened")
+	return store, nil
+}
+
+// Append adds the events of one command to the stream of a loan and syncs them to disk
+func (s *FileEventStore) Append(loanID string, expectedVersion int64, events ...domain.Event) error {
+	return s.Commit(loanID, expectedVersion, events, nil)
+}
+
+// Commit adds the events of one command to the stream of a loan, enqueues the outbox messages with them
+// and syncs both to disk
+func (s *FileEventStore) Commit(loanID string, expectedVersion int64, events []domain.Event, messages []domain.OutboxMessage) error {
+	s.logger.WithFields(logrus.Fields{
+		"layer":            "repository",
+		"function":         "Commit",
+		"loan_id":          loanID,
+		"expected_version": expectedVersion,
+		"events":           len(events),
+		"messages":         len(messages),
+	}).Info("Appending events to stream")
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.check(loanID, expectedVersion, events, messages); err != nil {
+		return err
+	}
+
+	records := make([]any, 0, len(events)+len(messages))
+	for _, e := range events {
+		records = append(records, e)
+	}
+	for i := range messages {
+		records = append(records, record{Outbox: &messages[i]})
+	}
+	if err := s.write(records); err != nil {
+		return err
+	}
+
+	s.add(domain.CloneEvents(events))
+	s.enqueue(messages)
+	return nil
+}
+
+// Update stores the delivery state of an outbox message and syncs it to disk
+func (s *FileEventStore) Update(message domain.OutboxMessage) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.checkUpdate(message); err != nil {
+		return err
+	}
+	if err := s.write([]any{record{Outbox: &message}}); err != nil {
+		return err
+	}
+	s.enqueue([]domain.OutboxMessage{message})
+	return nil
+}
+
+// write appends the records with a single call and syncs them, so a commit is never stored half way on success.
+// A failed write is truncated away again, so no torn line is left behind. Callers hold the mutex.
+func (s *FileEventStore) write(records []any) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, r := range records {
+		if err := encoder.Encode(r); err != nil {
+			return fmt.Errorf("failed to encode event: %w", err)
+		}
+	}
+
+	info, err := s.file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to write events: %w", err)
+	}
+	if _, err := s.file.Write(buf.Bytes()); err != nil {
+		return s.rollback(info.Size(), fmt.Errorf("failed to write events: %w", err))
+	}
+	if err := s.file.Sync(); err != nil {
+		return s.rollback(info.Size(), fmt.Errorf("failed to sync events: %w", err))
+	}
+	return nil
+}
+
+// rollback truncates the event file back to size after a failed write and returns cause
+func (s *FileEventStore) rollback(size int64, cause error) error {
+	if err := s.file.Truncate(size); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "repository",
+			"function": "write",
+			"size":     size,
+			"error":    err.Error(),
+		}).Error("Failed to truncate the event store after a failed write")
+		return errors.Join(cause, fmt.Errorf("failed to truncate event store: %w", err))
+	}
+	return cause
+}
+
+// Close closes the event file
+func (s *FileEventStore) Close() error {
+	return s.file.Close()
+}
+
+// load reads every stored event and the last state of every outbox message into memory.
+// An incomplete last line, left by a write that was interrupted, is skipped and truncated away.
+func (s *FileEventStore) load() error {
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to read event store: %w", err)
+	}
+
+	reader := bufio.NewReader(s.file)
+	var offset int64
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(bytes.TrimSpace(data)) == 0 {
+				return nil
+			}
+			s.logger.WithFields(logrus.Fields{
+				"layer": "repository",
+				"line":  line,
+				"bytes": len(data),
+			}).Warn("Skipping the incomplete last line of the event store")
+			if err := s.file.Truncate(offset); err != nil {
+				return fmt.Errorf("failed to truncate the incomplete event %d of event store: %w", line, err)
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read event %d of event store: %w", line, err)
+		}
+		offset += int64(len(data))
+		if len(bytes.TrimSpace(data)) == 0 {
+			continue
+		}
+
+		var r record
+		if err := json.Unmarshal(data, &r); err != nil {
+			return fmt.Errorf("failed to read event %d of event store: %w", line, err)
+		}
+		switch {
+		case r.Outbox != nil:
+			s.enqueue([]domain.OutboxMessage{*r.Outbox})
+		case r.Event != nil:
+			s.add([]domain.Event{*r.Event})
+		default:
+			return fmt.Errorf("failed to read event %d of event store: empty record", line)
+		}
+	}
+}
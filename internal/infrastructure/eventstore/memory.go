@@ -0,0 +1,120 @@
+// Package eventstore provides loan.EventStore implementations
+package eventstore
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+)
+
+// InMemoryEventStore keeps the event stream and the outbox in memory.
+// Events are copied on the way in and out, so callers never share payloads with the stored stream.
+type InMemoryEventStore struct {
+	// events holds every event in append order, streams indexes them by loan
+	events  []domain.Event
+	streams map[string][]int
+	// messages holds the outbox in enqueue order, outbox indexes it by message ID
+	messages []domain.OutboxMessage
+	outbox   map[string]int
+	mutex    sync.RWMutex
+	logger   *logrus.Logger
+}
+
+// NewInMemoryEventStore creates a new in-memory event store
+func NewInMemoryEventStore(logger *logrus.Logger) *InMemoryEventStore {
+	return &InMemoryEventStore{
+		streams: make(map[string][]int),
+		outbox:  make(map[string]int),
+		logger:  logger,
+	}
+}
+
+// Append adds the events of one command to the stream of a loan
+func (s *InMemoryEventStore) Append(loanID string, expectedVersion int64, events ...domain.Event) error {
+	return s.Commit(loanID, expectedVersion, events, nil)
+}
+
+// Commit adds the events of one command to the stream of a loan and enqueues the outbox messages with them
+func (s *InMemoryEventStore) Commit(loanID string, expectedVersion int64, events []domain.Event, messages []domain.OutboxMessage) error {
+	s.logger.WithFields(logrus.Fields{
+		"layer":            "repository",
+		"function":         "Commit",
+		"loan_id":          loanID,
+		"expected_version": expectedVersion,
+		"events":           len(events),
+		"messages":         len(messages),
+	}).Info("Appending events to stream")
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.check(loanID, expectedVersion, events, messages); err != nil {
+		return err
+	}
+	s.add(domain.CloneEvents(events))
+	s.enqueue(messages)
+	return nil
+}
+
+// Load retrieves the events of a loan in the order they were appended
+func (s *InMemoryEventStore) Load(loanID string) ([]domain.Event, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	positions := s.streams[loanID]
+	events := make([]domain.Event, 0, len(positions))
+	for _, position := range positions {
+		events = append(events, s.events[position])
+	}
+	return domain.CloneEvents(events), nil
+}
+
+// LoadAll retrieves the events of every loan in the order they were appended
+func (s *InMemoryEventStore) LoadAll() ([]domain.Event, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return domain.CloneEvents(s.events), nil
+}
+
+// check validates a commit against the stored stream and outbox. Callers hold the mutex.
+func (s *InMemoryEventStore) check(loanID string, expectedVersion int64, events []domain.Event, messages []domain.OutboxMessage) error {
+	err := domain.CheckAppend(loanID, expectedVersion, s.version(loanID), events)
+	for _, m := range messages {
+		if err != nil {
+			break
+		}
+		if _, exists := s.outbox[m.ID]; exists || m.LoanID != loanID {
+			err = fmt.Errorf("%w: outbox message %s of loan %s committed to loan %s", domain.ErrInvalidEvent, m.ID, m.LoanID, loanID)
+		}
+	}
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "repository",
+			"function": "Commit",
+			"loan_id":  loanID,
+			"error":    err.Error(),
+		}).Error("Failed to append events")
+	}
+	return err
+}
+
+// version returns the version of the last stored event of the loan, 0 when it has none. Callers hold the mutex.
+func (s *InMemoryEventStore) version(loanID string) int64 {
+	positions := s.streams[loanID]
+	if len(positions) == 0 {
+		return 0
+	}
+	return s.events[positions[len(positions)-1]].Version
+}
+
+// add indexes events that are already validated and copied. Callers hold the mutex.
+func (s *InMemoryEventStore) add(events []domain.Event) {
+	for _, e := range events {
+		s.streams[e.LoanID] = append(s.streams[e.LoanID], len(s.events))
+		s.events = append(s.events, e)
+	}
+}
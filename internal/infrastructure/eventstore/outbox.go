@@ -0,0 +1,101 @@
+package eventstore
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+)
+
+// FindDue retrieves up to limit pending messages whose next attempt is due at now, oldest first
+func (s *InMemoryEventStore) FindDue(now time.Time, limit int) ([]domain.OutboxMessage, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	due := make([]domain.OutboxMessage, 0)
+	for _, m := range s.messages {
+		if len(due) == limit {
+			break
+		}
+		if m.Status == domain.OutboxPending && !m.NextAttemptAt.After(now) {
+			due = append(due, cloneMessage(m))
+		}
+	}
+	return due, nil
+}
+
+// Update stores the delivery state of a message
+func (s *InMemoryEventStore) Update(message domain.OutboxMessage) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.checkUpdate(message); err != nil {
+		return err
+	}
+	s.enqueue([]domain.OutboxMessage{message})
+	return nil
+}
+
+// FindByStatus retrieves the messages in the status, oldest first
+func (s *InMemoryEventStore) FindByStatus(status domain.OutboxStatus) ([]domain.OutboxMessage, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	result := make([]domain.OutboxMessage, 0)
+	for _, m := range s.messages {
+		if m.Status == status {
+			result = append(result, cloneMessage(m))
+		}
+	}
+	return result, nil
+}
+
+// checkUpdate returns ErrOutboxMessageNotFound for an unknown message. Callers hold the mutex.
+func (s *InMemoryEventStore) checkUpdate(message domain.OutboxMessage) error {
+	if _, exists := s.outbox[message.ID]; !exists {
+		s.logger.WithFields(logrus.Fields{
+			"layer":      "repository",
+			"function":   "Update",
+			"message_id": message.ID,
+		}).Error("Outbox message not found for update")
+		return fmt.Errorf("%w: %s", domain.ErrOutboxMessageNotFound, message.ID)
+	}
+	return nil
+}
+
+// enqueue adds new messages and replaces the stored state of known ones, keeping their place in the queue.
+// Callers hold the mutex.
+func (s *InMemoryEventStore) enqueue(messages []domain.OutboxMessage) {
+	for _, m := range messages {
+		m = cloneMessage(m)
+		if position, exists := s.outbox[m.ID]; exists {
+			s.messages[position] = m
+			continue
+		}
+		s.outbox[m.ID] = len(s.messages)
+		s.messages = append(s.messages, m)
+	}
+}
+
+// cloneMessage copies the pointer fields of a message, so callers never share them with the outbox
+func cloneMessage(m domain.OutboxMessage) domain.OutboxMessage {
+	if m.Amount != nil {
+		amount := *m.Amount
+		m.Amount = &amount
+	}
+	if m.SentAt != nil {
+		sentAt := *m.SentAt
+		m.SentAt = &sentAt
+	}
+	if m.DueDate != nil {
+		dueDate := *m.DueDate
+		m.DueDate = &dueDate
+	}
+	if m.Agreement != nil {
+		agreement := *m.Agreement
+		m.Agreement = &agreement
+	}
+	return m
+}
@@ -0,0 +1,101 @@
+// Package notification provides loan.NotificationChannel implementations besides email
+package notification
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+)
+
+// ConsoleSMSSender is a stub of the SMS channel that logs the text messages it would send. The text is
+// the subject line of the notification email, which is short enough for one message.
+type ConsoleSMSSender struct {
+	renderer domain.EmailRenderer
+	logger   *logrus.Logger
+}
+
+// NewConsoleSMSSender creates a console SMS sender that takes the text from the emails of renderer
+func NewConsoleSMSSender(renderer domain.EmailRenderer, logger *logrus.Logger) *ConsoleSMSSender {
+	return &ConsoleSMSSender{
+		renderer: renderer,
+		logger:   logger,
+	}
+}
+
+// Channel returns ChannelSMS
+func (s *ConsoleSMSSender) Channel() domain.Channel {
+	return domain.ChannelSMS
+}
+
+// Send renders the text of the notification and logs it
+func (s *ConsoleSMSSender) Send(notification domain.Notification) error {
+	rendered, err := s.renderer.RenderNotification(notification)
+	if err != nil {
+		return fmt.Errorf("failed to render %s text message: %w", notification.Kind, err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"layer":    "sms",
+		"function": "Send",
+		"phone":    notification.Recipient.Phone,
+		"kind":     notification.Kind,
+		"loan_id":  notification.LoanID,
+		"text":     rendered.Subject,
+	}).Info("Sending text message")
+
+	// In a real implementation, this would call an SMS gateway
+	return nil
+}
+
+// InboxChannel is the in-app channel, it keeps notifications in the inbox of their recipient
+type InboxChannel struct {
+	inbox    domain.Inbox
+	renderer domain.EmailRenderer
+	logger   *logrus.Logger
+}
+
+// NewInboxChannel creates an in-app channel that stores the subject and text of the emails of renderer
+func NewInboxChannel(inbox domain.Inbox, renderer domain.EmailRenderer, logger *logrus.Logger) *InboxChannel {
+	return &InboxChannel{
+		inbox:    inbox,
+		renderer: renderer,
+		logger:   logger,
+	}
+}
+
+// Channel returns ChannelInApp
+func (c *InboxChannel) Channel() domain.Channel {
+	return domain.ChannelInApp
+}
+
+// Send adds the notification to the inbox of its recipient, a notification already there is kept once
+func (c *InboxChannel) Send(notification domain.Notification) error {
+	rendered, err := c.renderer.RenderNotification(notification)
+	if err != nil {
+		return fmt.Errorf("failed to render %s inbox item: %w", notification.Kind, err)
+	}
+
+	err = c.inbox.Add(domain.InboxItem{
+		ID:          notification.ID,
+		RecipientID: notification.Recipient.ID,
+		Kind:        notification.Kind,
+		LoanID:      notification.LoanID,
+		Subject:     rendered.Subject,
+		Body:        rendered.Text,
+		CreatedAt:   time.Now(),
+	})
+	if err != nil {
+		c.logger.WithFields(logrus.Fields{
+			"layer":        "inbox",
+			"function":     "Send",
+			"recipient_id": notification.Recipient.ID,
+			"kind":         notification.Kind,
+			"error":        err.Error(),
+		}).Error("Failed to add inbox item")
+		return fmt.Errorf("failed to add inbox item: %w", err)
+	}
+	return nil
+}
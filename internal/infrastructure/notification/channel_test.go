@@ -0,0 +1,99 @@
+package notification
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	mock "github.com/hinha/los-technical/internal/domain/loan/mock"
+)
+
+var testNotification = domain.Notification{
+	ID:        "notification-1",
+	Kind:      domain.NotificationLoanApproved,
+	LoanID:    "loan-1",
+	Recipient: domain.Recipient{ID: "borrower-1", Role: domain.RoleBorrower, Phone: "+628123"},
+}
+
+var testEmail = &domain.RenderedEmail{Subject: "Your loan loan-1 is approved", Text: "Dear Borrower,\n", HTML: "<p>Dear Borrower,</p>"}
+
+func TestConsoleSMSSender_Send(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	renderer := mock.NewMockEmailRenderer(ctrl)
+	renderer.EXPECT().RenderNotification(testNotification).Return(testEmail, nil)
+	logger, hook := test.NewNullLogger()
+
+	sender := NewConsoleSMSSender(renderer, logger)
+	assert.Equal(t, domain.ChannelSMS, sender.Channel())
+	assert.NoError(t, sender.Send(testNotification))
+	assert.Equal(t, "Sending text message", hook.LastEntry().Message)
+	assert.Equal(t, "+628123", hook.LastEntry().Data["phone"])
+	assert.Equal(t, "Your loan loan-1 is approved", hook.LastEntry().Data["text"])
+}
+
+func TestInboxChannel_Send(t *testing.T) {
+	testCases := []struct {
+		name      string
+		mockSetup func(*mock.MockEmailRenderer, *mock.MockInbox)
+		errorMsg  string
+	}{
+		{
+			name: "Adds the rendered notification",
+			mockSetup: func(renderer *mock.MockEmailRenderer, inbox *mock.MockInbox) {
+				renderer.EXPECT().RenderNotification(testNotification).Return(testEmail, nil)
+				inbox.EXPECT().Add(gomock.Any()).DoAndReturn(func(item domain.InboxItem) error {
+					assert.Equal(t, "notification-1", item.ID)
+					assert.Equal(t, "borrower-1", item.RecipientID)
+					assert.Equal(t, domain.NotificationLoanApproved, item.Kind)
+					assert.Equal(t, testEmail.Subject, item.Subject)
+					assert.Equal(t, testEmail.Text, item.Body)
+					assert.False(t, item.CreatedAt.IsZero())
+					return nil
+				})
+			},
+		},
+		{
+			name: "Render failure",
+			mockSetup: func(renderer *mock.MockEmailRenderer, inbox *mock.MockInbox) {
+				renderer.EXPECT().RenderNotification(gomock.Any()).Return(nil, errors.New("template error"))
+			},
+			errorMsg: "failed to render LOAN_APPROVED inbox item: template error",
+		},
+		{
+			name: "Inbox failure",
+			mockSetup: func(renderer *mock.MockEmailRenderer, inbox *mock.MockInbox) {
+				renderer.EXPECT().RenderNotification(gomock.Any()).Return(testEmail, nil)
+				inbox.EXPECT().Add(gomock.Any()).Return(errors.New("database error"))
+			},
+			errorMsg: "failed to add inbox item: database error",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			renderer := mock.NewMockEmailRenderer(ctrl)
+			inbox := mock.NewMockInbox(ctrl)
+			tc.mockSetup(renderer, inbox)
+			logger, _ := test.NewNullLogger()
+
+			channel := NewInboxChannel(inbox, renderer, logger)
+			assert.Equal(t, domain.ChannelInApp, channel.Channel())
+
+			err := channel.Send(testNotification)
+			if tc.errorMsg != "" {
+				assert.EqualError(t, err, tc.errorMsg)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
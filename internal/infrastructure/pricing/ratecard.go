@@ -0,0 +1,313 @@
+package pricing
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	"github.com/hinha/los-technical/internal/pkg/money"
+)
+
+// ErrInvalidRateCard is returned when a rate card cannot price loans
+var ErrInvalidRateCard = errors.New("invalid rate card")
+
+// Price is an annual rate and ROI in percent, or an adjustment to them in percentage points
+type Price struct {
+	Rate money.Decimal
+	ROI  money.Decimal
+}
+
+// add returns the sum of both prices
+func (p Price) add(o Price) (Price, error) {
+	rate, err := p.Rate.Add(o.Rate)
+	if err != nil {
+		return Price{}, err
+	}
+	roi, err := p.ROI.Add(o.ROI)
+	if err != nil {
+		return Price{}, err
+	}
+	return Price{Rate: rate, ROI: roi}, nil
+}
+
+// TenorBand adjusts the price of the tenors from MinMonths up to the MinMonths of the next band
+type TenorBand struct {
+	MinMonths  int
+	Adjustment Price
+}
+
+// PrincipalBand adjusts the price of the principals from Min up to the Min of the next band
+type PrincipalBand struct {
+	Min        money.Decimal
+	Adjustment Price
+}
+
+// RateCard prices loans: the price of their risk grade, adjusted by the band of their tenor and of their
+// principal. Manual values may be up to Corridor percentage points away from the derived ones.
+type RateCard struct {
+	Version string
+	Grades  map[domain.RiskGrade]Price
+	// Tenors are ordered by MinMonths, the first one starting at one month
+	Tenors []TenorBand
+	// Principals are the bands of each currency loans are priced in, ordered by Min from zero
+	Principals map[string][]PrincipalBand
+	Corridor   Price
+	logger     *logrus.Logger
+}
+
+// priceConfig is a price as written in the rate card file, values are decimal strings
+type priceConfig struct {
+	Rate string `yaml:"rate"`
+	ROI  string `yaml:"roi"`
+}
+
+// config is the layout of the rate card file
+type config struct {
+	Version string                           `yaml:"version"`
+	Grades  map[domain.RiskGrade]priceConfig `yaml:"grades"`
+	Tenors  []struct {
+		MinMonths   int `yaml:"min_months"`
+		priceConfig `yaml:",inline"`
+	} `yaml:"tenors"`
+	Principals map[string][]struct {
+		Min         string `yaml:"min"`
+		priceConfig `yaml:",inline"`
+	} `yaml:"principals"`
+	Corridor priceConfig `yaml:"corridor"`
+}
+
+// LoadRateCard reads the rate card at path, see ParseRateCard for its layout
+func LoadRateCard(path string, logger *logrus.Logger) (*RateCard, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rate card: %w", err)
+	}
+	rateCard, err := ParseRateCard(content, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.WithFields(logrus.Fields{
+		"layer":     "pricing",
+		"function":  "LoadRateCard",
+		"path":      path,
+		"rate_card": rateCard.Version,
+	}).Info("Rate card loaded")
+	return rateCard, nil
+}
+
+// ParseRateCard parses a YAML rate card: its version, the price of every grade, the adjustments of the tenor
+// and principal bands, and the corridor around derived values. Every combination must leave the ROI below the
+// rate, so a derived price never has a negative margin.
+func ParseRateCard(content []byte, logger *logrus.Logger) (*RateCard, error) {
+	var cfg config
+	if err := yaml.Unmarshal(content, &cfg); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRateCard, err)
+	}
+	if cfg.Version == "" {
+		return nil, fmt.Errorf("%w: version is required", ErrInvalidRateCard)
+	}
+
+	r := &RateCard{
+		Version:    cfg.Version,
+		Grades:     make(map[domain.RiskGrade]Price),
+		Principals: make(map[string][]PrincipalBand),
+		logger:     logger,
+	}
+	var err error
+	for grade, priceCfg := range cfg.Grades {
+		if err := grade.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidRateCard, err)
+		}
+		if r.Grades[grade], err = newPrice("grade "+string(grade), priceCfg); err != nil {
+			return nil, err
+		}
+	}
+	for _, grade := range domain.RiskGrades() {
+		if _, ok := r.Grades[grade]; !ok {
+			return nil, fmt.Errorf("%w: grade %s has no price", ErrInvalidRateCard, grade)
+		}
+	}
+
+	if len(cfg.Tenors) == 0 || cfg.Tenors[0].MinMonths != 1 {
+		return nil, fmt.Errorf("%w: the first tenor band must start at 1 month", ErrInvalidRateCard)
+	}
+	for i, bandCfg := range cfg.Tenors {
+		if i > 0 && bandCfg.MinMonths <= cfg.Tenors[i-1].MinMonths {
+			return nil, fmt.Errorf("%w: tenor bands must be in increasing order of min_months", ErrInvalidRateCard)
+		}
+		adjustment, err := newPrice(fmt.Sprintf("tenor band from %d months", bandCfg.MinMonths), bandCfg.priceConfig)
+		if err != nil {
+			return nil, err
+		}
+		r.Tenors = append(r.Tenors, TenorBand{MinMonths: bandCfg.MinMonths, Adjustment: adjustment})
+	}
+
+	if len(cfg.Principals) == 0 {
+		return nil, fmt.Errorf("%w: principals need bands for at least one currency", ErrInvalidRateCard)
+	}
+	for currency, bandCfgs := range cfg.Principals {
+		if !money.IsSupportedCurrency(currency) {
+			return nil, fmt.Errorf("%w: principal bands: %w: %s", ErrInvalidRateCard, money.ErrUnsupportedCurrency, currency)
+		}
+		bands := make([]PrincipalBand, len(bandCfgs))
+		for i, bandCfg := range bandCfgs {
+			minimum, err := money.Parse(bandCfg.Min)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s principal band min: %w", ErrInvalidRateCard, currency, err)
+			}
+			if i == 0 && !minimum.IsZero() {
+				return nil, fmt.Errorf("%w: the first %s principal band must start at 0", ErrInvalidRateCard, currency)
+			}
+			if i > 0 && minimum.Cmp(bands[i-1].Min) <= 0 {
+				return nil, fmt.Errorf("%w: %s principal bands must be in increasing order of min", ErrInvalidRateCard, currency)
+			}
+			adjustment, err := newPrice(fmt.Sprintf("%s principal band from %s", currency, minimum), bandCfg.priceConfig)
+			if err != nil {
+				return nil, err
+			}
+			bands[i] = PrincipalBand{Min: minimum, Adjustment: adjustment}
+		}
+		if len(bands) == 0 {
+			return nil, fmt.Errorf("%w: %s principals need bands", ErrInvalidRateCard, currency)
+		}
+		r.Principals[currency] = bands
+	}
+
+	if r.Corridor, err = newPrice("corridor", cfg.Corridor); err != nil {
+		return nil, err
+	}
+	if r.Corridor.Rate.Sign() < 0 || r.Corridor.ROI.Sign() < 0 {
+		return nil, fmt.Errorf("%w: the corridor must not be negative", ErrInvalidRateCard)
+	}
+
+	if err := r.checkMargins(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func newPrice(name string, cfg priceConfig) (Price, error) {
+	rate, err := money.Parse(cfg.Rate)
+	if err != nil {
+		return Price{}, fmt.Errorf("%w: %s rate: %w", ErrInvalidRateCard, name, err)
+	}
+	roi, err := money.Parse(cfg.ROI)
+	if err != nil {
+		return Price{}, fmt.Errorf("%w: %s roi: %w", ErrInvalidRateCard, name, err)
+	}
+	return Price{Rate: rate, ROI: roi}, nil
+}
+
+// checkMargins checks that every grade, tenor and principal band prices a non-negative ROI below the rate
+func (r *RateCard) checkMargins() error {
+	for grade, gradePrice := range r.Grades {
+		for _, tenor := range r.Tenors {
+			for currency, bands := range r.Principals {
+				for _, band := range bands {
+					price, err := r.sum(gradePrice, tenor.Adjustment, band.Adjustment)
+					if err != nil {
+						return fmt.Errorf("%w: %w", ErrInvalidRateCard, err)
+					}
+					if price.ROI.Sign() < 0 || price.ROI.Cmp(price.Rate) >= 0 {
+						return fmt.Errorf("%w: grade %s, tenor from %d months and %s principal from %s price ROI %s at rate %s, the ROI must be from 0 to below the rate",
+							ErrInvalidRateCard, grade, tenor.MinMonths, currency, band.Min, price.ROI, price.Rate)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// sum adds the adjustments to the price
+func (r *RateCard) sum(price Price, adjustments ...Price) (Price, error) {
+	var err error
+	for _, adjustment := range adjustments {
+		if price, err = price.add(adjustment); err != nil {
+			return Price{}, err
+		}
+	}
+	return price, nil
+}
+
+// Price returns the rate and ROI of the grade adjusted for the tenor and principal of the loan, and the corridors
+// of Corridor percentage points around them, never below zero
+func (r *RateCard) Price(input domain.PricingInput) (*domain.Quote, error) {
+	gradePrice, ok := r.Grades[input.Grade]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", domain.ErrInvalidRiskGrade, input.Grade)
+	}
+	bands, ok := r.Principals[input.Principal.Currency]
+	if !ok {
+		return nil, fmt.Errorf("%w: no principal bands for %s", domain.ErrLoanNotPriced, input.Principal.Currency)
+	}
+	if input.Terms.TenorMonths < r.Tenors[0].MinMonths {
+		return nil, fmt.Errorf("%w: no tenor band for %d months", domain.ErrLoanNotPriced, input.Terms.TenorMonths)
+	}
+
+	tenor := r.Tenors[0]
+	for _, band := range r.Tenors[1:] {
+		if input.Terms.TenorMonths < band.MinMonths {
+			break
+		}
+		tenor = band
+	}
+	principal := bands[0]
+	for _, band := range bands[1:] {
+		if input.Principal.Amount.Cmp(band.Min) < 0 {
+			break
+		}
+		principal = band
+	}
+
+	price, err := r.sum(gradePrice, tenor.Adjustment, principal.Adjustment)
+	if err != nil {
+		return nil, err
+	}
+	rateCorridor, err := corridor(price.Rate, r.Corridor.Rate)
+	if err != nil {
+		return nil, err
+	}
+	roiCorridor, err := corridor(price.ROI, r.Corridor.ROI)
+	if err != nil {
+		return nil, err
+	}
+
+	r.logger.WithFields(logrus.Fields{
+		"layer":        "pricing",
+		"function":     "Price",
+		"grade":        input.Grade,
+		"tenor_months": input.Terms.TenorMonths,
+		"principal":    input.Principal.String(),
+		"rate":         price.Rate.String(),
+		"roi":          price.ROI.String(),
+	}).Info("Loan priced")
+	return &domain.Quote{
+		Rate:         price.Rate,
+		ROI:          price.ROI,
+		RateCorridor: rateCorridor,
+		ROICorridor:  roiCorridor,
+		RateCard:     r.Version,
+	}, nil
+}
+
+// corridor returns the values up to width away from value, never below zero
+func corridor(value, width money.Decimal) (domain.Corridor, error) {
+	minimum, err := value.Sub(width)
+	if err != nil {
+		return domain.Corridor{}, err
+	}
+	if minimum.Sign() < 0 {
+		minimum = money.Zero
+	}
+	maximum, err := value.Add(width)
+	if err != nil {
+		return domain.Corridor{}, err
+	}
+	return domain.Corridor{Min: minimum, Max: maximum}, nil
+}
@@ -0,0 +1,212 @@
+package pricing
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	"github.com/hinha/los-technical/internal/pkg/money"
+)
+
+const rateCardPath = "../../../config/ratecard.yaml"
+
+// validRateCard is the smallest rate card that loads, the invalid ones are made from it
+const validRateCard = `
+version: test
+grades:
+  A: {rate: "10", roi: "8"}
+  B: {rate: "12", roi: "9"}
+  C: {rate: "14", roi: "10"}
+  D: {rate: "16", roi: "11"}
+  E: {rate: "18", roi: "12"}
+tenors:
+  - {min_months: 1, rate: "0", roi: "0"}
+principals:
+  IDR:
+    - {min: "0", rate: "0", roi: "0"}
+corridor: {rate: "2", roi: "1"}
+`
+
+func TestLoadRateCard(t *testing.T) {
+	rateCard, err := LoadRateCard(rateCardPath, logrus.New())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "2025-01", rateCard.Version)
+	assert.Len(t, rateCard.Grades, len(domain.RiskGrades()))
+	assert.Equal(t, []TenorBand{
+		{MinMonths: 1, Adjustment: Price{Rate: money.MustParse("0"), ROI: money.MustParse("0")}},
+		{MinMonths: 13, Adjustment: Price{Rate: money.MustParse("1"), ROI: money.MustParse("0.5")}},
+		{MinMonths: 25, Adjustment: Price{Rate: money.MustParse("2"), ROI: money.MustParse("1")}},
+	}, rateCard.Tenors)
+	assert.Len(t, rateCard.Principals["IDR"], 3)
+	assert.Len(t, rateCard.Principals["USD"], 3)
+	assert.Equal(t, Price{Rate: money.MustParse("2"), ROI: money.MustParse("1.5")}, rateCard.Corridor)
+
+	_, err = LoadRateCard(filepath.Join(t.TempDir(), "missing.yaml"), logrus.New())
+	assert.ErrorContains(t, err, "failed to read rate card")
+
+	path := filepath.Join(t.TempDir(), "ratecard.yaml")
+	if assert.NoError(t, os.WriteFile(path, []byte("version: ["), 0o600)) {
+		_, err = LoadRateCard(path, logrus.New())
+		assert.ErrorIs(t, err, ErrInvalidRateCard)
+	}
+}
+
+func TestParseRateCard_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		old     string
+		new     string
+		wantErr string
+	}{
+		{name: "Missing version", old: "version: test", new: "", wantErr: "version is required"},
+		{name: "Unknown grade", old: `E: {rate: "18", roi: "12"}`, new: `S: {rate: "18", roi: "12"}`, wantErr: "invalid risk grade"},
+		{name: "Unpriced grade", old: `  E: {rate: "18", roi: "12"}` + "\n", new: "", wantErr: "grade E has no price"},
+		{name: "Malformed rate", old: `A: {rate: "10"`, new: `A: {rate: "ten"`, wantErr: "grade A rate"},
+		{name: "Tenor band not starting at one month", old: "min_months: 1,", new: "min_months: 3,", wantErr: "the first tenor band must start at 1 month"},
+		{name: "Tenor bands out of order", old: `{min_months: 1, rate: "0", roi: "0"}`, new: `{min_months: 1, rate: "0", roi: "0"}
+  - {min_months: 24, rate: "1", roi: "0"}
+  - {min_months: 12, rate: "0.5", roi: "0"}`, wantErr: "tenor bands must be in increasing order of min_months"},
+		{name: "Unsupported principal currency", old: "IDR:", new: "XYZ:", wantErr: "unsupported currency"},
+		{name: "Principal band not starting at zero", old: `{min: "0"`, new: `{min: "100"`, wantErr: "the first IDR principal band must start at 0"},
+		{name: "Principal bands out of order", old: `{min: "0", rate: "0", roi: "0"}`, new: `{min: "0", rate: "0", roi: "0"}
+    - {min: "5000", rate: "0", roi: "0"}
+    - {min: "1000", rate: "0", roi: "0"}`, wantErr: "IDR principal bands must be in increasing order of min"},
+		{name: "Negative corridor", old: `corridor: {rate: "2"`, new: `corridor: {rate: "-2"`, wantErr: "the corridor must not be negative"},
+		{name: "ROI not below the rate", old: `E: {rate: "18", roi: "12"}`, new: `E: {rate: "12", roi: "12"}`, wantErr: "grade E, tenor from 1 months and IDR principal from 0 price ROI 12 at rate 12"},
+		{name: "Adjustment leaving a negative margin", old: `{min_months: 1, rate: "0", roi: "0"}`, new: `{min_months: 1, rate: "-1", roi: "1"}`, wantErr: "the ROI must be from 0 to below the rate"},
+		{name: "Negative ROI", old: `{min: "0", rate: "0", roi: "0"}`, new: `{min: "0", rate: "0", roi: "-9"}`, wantErr: "grade A, tenor from 1 months and IDR principal from 0 price ROI -1 at rate 10"},
+	}
+
+	_, err := ParseRateCard([]byte(validRateCard), logrus.New())
+	assert.NoError(t, err)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := strings.Replace(validRateCard, tt.old, tt.new, 1)
+			if content == validRateCard {
+				t.Fatalf("the test case does not change the rate card")
+			}
+
+			rateCard, err := ParseRateCard([]byte(content), logrus.New())
+			assert.ErrorIs(t, err, ErrInvalidRateCard)
+			assert.ErrorContains(t, err, tt.wantErr)
+			assert.Nil(t, rateCard)
+		})
+	}
+}
+
+func TestRateCard_Price(t *testing.T) {
+	rateCard, err := LoadRateCard(rateCardPath, logrus.New())
+	if !assert.NoError(t, err) {
+		return
+	}
+	idr := func(amount string) money.Money { return money.MustNew(amount, money.DefaultCurrency) }
+	corridor := func(minimum, maximum string) domain.Corridor {
+		return domain.Corridor{Min: money.MustParse(minimum), Max: money.MustParse(maximum)}
+	}
+
+	tests := []struct {
+		name      string
+		grade     domain.RiskGrade
+		principal money.Money
+		tenor     int
+		want      domain.Quote
+	}{
+		{
+			name:      "Grade price",
+			grade:     domain.GradeB,
+			principal: idr("50000000"),
+			tenor:     12,
+			want: domain.Quote{
+				Rate: money.MustParse("13"), ROI: money.MustParse("10"),
+				RateCorridor: corridor("11", "15"), ROICorridor: corridor("8.5", "11.5"),
+			},
+		},
+		{
+			name:      "Small principal over a long tenor",
+			grade:     domain.GradeD,
+			principal: idr("5000000"),
+			tenor:     36,
+			want: domain.Quote{
+				Rate: money.MustParse("23.5"), ROI: money.MustParse("16.5"),
+				RateCorridor: corridor("21.5", "25.5"), ROICorridor: corridor("15", "18"),
+			},
+		},
+		{
+			name:      "Large principal from the first band of a tenor",
+			grade:     domain.GradeA,
+			principal: idr("100000000"),
+			tenor:     13,
+			want: domain.Quote{
+				Rate: money.MustParse("10"), ROI: money.MustParse("8"),
+				RateCorridor: corridor("8", "12"), ROICorridor: corridor("6.5", "9.5"),
+			},
+		},
+		{
+			name:      "USD principal",
+			grade:     domain.GradeE,
+			principal: money.MustNew("500", "USD"),
+			tenor:     6,
+			want: domain.Quote{
+				Rate: money.MustParse("26.5"), ROI: money.MustParse("18.5"),
+				RateCorridor: corridor("24.5", "28.5"), ROICorridor: corridor("17", "20"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quote, err := rateCard.Price(domain.PricingInput{
+				Grade:     tt.grade,
+				Principal: tt.principal,
+				Terms:     domain.RepaymentTerms{TenorMonths: tt.tenor, Scheme: domain.SchemeAnnuity},
+			})
+			assert.NoError(t, err)
+			tt.want.RateCard = "2025-01"
+			assert.Equal(t, &tt.want, quote)
+		})
+	}
+}
+
+func TestRateCard_Price_Errors(t *testing.T) {
+	rateCard, err := ParseRateCard([]byte(validRateCard), logrus.New())
+	if !assert.NoError(t, err) {
+		return
+	}
+	terms := domain.RepaymentTerms{TenorMonths: 12, Scheme: domain.SchemeAnnuity}
+
+	_, err = rateCard.Price(domain.PricingInput{Grade: "Z", Principal: money.MustNew("1000", "IDR"), Terms: terms})
+	assert.ErrorIs(t, err, domain.ErrInvalidRiskGrade)
+
+	// The rate card only has principal bands for IDR
+	_, err = rateCard.Price(domain.PricingInput{Grade: domain.GradeA, Principal: money.MustNew("1000", "USD"), Terms: terms})
+	assert.ErrorIs(t, err, domain.ErrLoanNotPriced)
+	assert.ErrorContains(t, err, "no principal bands for USD")
+
+	_, err = rateCard.Price(domain.PricingInput{Grade: domain.GradeA, Principal: money.MustNew("1000", "IDR"), Terms: domain.RepaymentTerms{}})
+	assert.ErrorIs(t, err, domain.ErrLoanNotPriced)
+}
+
+func TestRateCard_Price_CorridorNeverBelowZero(t *testing.T) {
+	content := strings.Replace(validRateCard, `corridor: {rate: "2", roi: "1"}`, `corridor: {rate: "11", roi: "9"}`, 1)
+	rateCard, err := ParseRateCard([]byte(content), logrus.New())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	quote, err := rateCard.Price(domain.PricingInput{
+		Grade:     domain.GradeA,
+		Principal: money.MustNew("1000", "IDR"),
+		Terms:     domain.RepaymentTerms{TenorMonths: 12, Scheme: domain.SchemeAnnuity},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Corridor{Min: money.Zero, Max: money.MustParse("21")}, quote.RateCorridor)
+	assert.Equal(t, domain.Corridor{Min: money.Zero, Max: money.MustParse("17")}, quote.ROICorridor)
+}
@@ -0,0 +1,57 @@
+package loan
+
+import (
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+)
+
+// InMemoryAuditLog is an in-memory implementation of the AuditLog interface
+type InMemoryAuditLog struct {
+	entries map[string][]domain.AuditEntry
+	mutex   sync.RWMutex
+	logger  *logrus.Logger
+}
+
+// NewInMemoryAuditLog creates a new in-memory audit log
+func NewInMemoryAuditLog(logger *logrus.Logger) *InMemoryAuditLog {
+	return &InMemoryAuditLog{
+		entries: make(map[string][]domain.AuditEntry),
+		logger:  logger,
+	}
+}
+
+// Append stores a new entry at the end of the history of its loan
+func (a *InMemoryAuditLog) Append(entry domain.AuditEntry) error {
+	a.logger.WithFields(logrus.Fields{
+		"layer":    "repository",
+		"function": "Append",
+		"loan_id":  entry.LoanID,
+		"action":   entry.Action,
+	}).Info("Appending audit entry")
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.entries[entry.LoanID] = append(a.entries[entry.LoanID], entry)
+	return nil
+}
+
+// FindByLoanID retrieves the entries of a loan in the order they were appended
+func (a *InMemoryAuditLog) FindByLoanID(loanID string) ([]domain.AuditEntry, error) {
+	a.logger.WithFields(logrus.Fields{
+		"layer":    "repository",
+		"function": "FindByLoanID",
+		"loan_id":  loanID,
+	}).Info("Finding audit entries by loan ID")
+
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	// Entries hold no pointers, so copying the slice is enough to isolate callers
+	entries := make([]domain.AuditEntry, len(a.entries[loanID]))
+	copy(entries, a.entries[loanID])
+	return entries, nil
+}
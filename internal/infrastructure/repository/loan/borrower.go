@@ -0,0 +1,138 @@
+package loan
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+)
+
+// InMemoryBorrowerRepository is an in-memory implementation of the BorrowerRepository interface
+type InMemoryBorrowerRepository struct {
+	borrowers map[string]*domain.Borrower
+	mutex     sync.RWMutex
+	logger    *logrus.Logger
+}
+
+// NewInMemoryBorrowerRepository creates a new in-memory borrower repository
+func NewInMemoryBorrowerRepository(logger *logrus.Logger) *InMemoryBorrowerRepository {
+	return &InMemoryBorrowerRepository{
+		borrowers: make(map[string]*domain.Borrower),
+		logger:    logger,
+	}
+}
+
+// Save stores a new borrower
+func (r *InMemoryBorrowerRepository) Save(borrower *domain.Borrower) error {
+	r.logger.WithFields(logrus.Fields{
+		"layer":       "repository",
+		"function":    "Save",
+		"borrower_id": borrower.ID,
+	}).Info("Saving borrower")
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.borrowers[borrower.ID]; exists {
+		return fmt.Errorf("%w: %s", domain.ErrBorrowerAlreadyExists, borrower.ID)
+	}
+	stored := *borrower
+	r.borrowers[borrower.ID] = &stored
+	return nil
+}
+
+// FindByID retrieves a borrower
+func (r *InMemoryBorrowerRepository) FindByID(id string) (*domain.Borrower, error) {
+	r.logger.WithFields(logrus.Fields{
+		"layer":       "repository",
+		"function":    "FindByID",
+		"borrower_id": id,
+	}).Info("Finding borrower by ID")
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	borrower, exists := r.borrowers[id]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", domain.ErrBorrowerNotFound, id)
+	}
+	found := *borrower
+	return &found, nil
+}
+
+// Update replaces a stored borrower
+func (r *InMemoryBorrowerRepository) Update(borrower *domain.Borrower) error {
+	r.logger.WithFields(logrus.Fields{
+		"layer":       "repository",
+		"function":    "Update",
+		"borrower_id": borrower.ID,
+	}).Info("Updating borrower")
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.borrowers[borrower.ID]; !exists {
+		return fmt.Errorf("%w: %s", domain.ErrBorrowerNotFound, borrower.ID)
+	}
+	stored := *borrower
+	r.borrowers[borrower.ID] = &stored
+	return nil
+}
+
+// Delete removes a borrower
+func (r *InMemoryBorrowerRepository) Delete(id string) error {
+	r.logger.WithFields(logrus.Fields{
+		"layer":       "repository",
+		"function":    "Delete",
+		"borrower_id": id,
+	}).Info("Deleting borrower")
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.borrowers[id]; !exists {
+		return fmt.Errorf("%w: %s", domain.ErrBorrowerNotFound, id)
+	}
+	delete(r.borrowers, id)
+	return nil
+}
+
+// FindAll retrieves the borrowers with pagination
+func (r *InMemoryBorrowerRepository) FindAll(page, limit int) ([]*domain.Borrower, error) {
+	r.logger.WithFields(logrus.Fields{
+		"layer":    "repository",
+		"function": "FindAll",
+		"page":     page,
+		"limit":    limit,
+	}).Info("Finding all borrowers with pagination")
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	all := make([]*domain.Borrower, 0, len(r.borrowers))
+	for _, borrower := range r.borrowers {
+		all = append(all, borrower)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if !all[i].CreatedAt.Equal(all[j].CreatedAt) {
+			return all[i].CreatedAt.Before(all[j].CreatedAt)
+		}
+		return all[i].ID < all[j].ID
+	})
+
+	startIndex := (page - 1) * limit
+	if startIndex >= len(all) {
+		return []*domain.Borrower{}, nil
+	}
+	endIndex := min(startIndex+limit, len(all))
+
+	result := make([]*domain.Borrower, 0, endIndex-startIndex)
+	for _, borrower := range all[startIndex:endIndex] {
+		found := *borrower
+		result = append(result, &found)
+	}
+	return result, nil
+}
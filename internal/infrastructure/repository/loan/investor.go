@@ -0,0 +1,79 @@
+package loan
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+)
+
+// InMemoryInvestorRepository is an in-memory implementation of the InvestorRepository interface
+type InMemoryInvestorRepository struct {
+	investors map[string]*domain.InvestorProfile
+	mutex     sync.RWMutex
+	logger    *logrus.Logger
+}
+
+// NewInMemoryInvestorRepository creates a new in-memory investor repository
+func NewInMemoryInvestorRepository(logger *logrus.Logger) *InMemoryInvestorRepository {
+	return &InMemoryInvestorRepository{
+		investors: make(map[string]*domain.InvestorProfile),
+		logger:    logger,
+	}
+}
+
+// Save stores a new investor
+func (r *InMemoryInvestorRepository) Save(investor *domain.InvestorProfile) error {
+	r.logger.WithFields(logrus.Fields{
+		"layer":       "repository",
+		"function":    "Save",
+		"investor_id": investor.ID,
+	}).Info("Saving investor")
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.investors[investor.ID]; exists {
+		return fmt.Errorf("%w: %s", domain.ErrInvestorAlreadyExists, investor.ID)
+	}
+	r.investors[investor.ID] = investor.Clone()
+	return nil
+}
+
+// FindByID retrieves an investor
+func (r *InMemoryInvestorRepository) FindByID(id string) (*domain.InvestorProfile, error) {
+	r.logger.WithFields(logrus.Fields{
+		"layer":       "repository",
+		"function":    "FindByID",
+		"investor_id": id,
+	}).Info("Finding investor by ID")
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	investor, exists := r.investors[id]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", domain.ErrInvestorNotFound, id)
+	}
+	return investor.Clone(), nil
+}
+
+// Update replaces a stored investor
+func (r *InMemoryInvestorRepository) Update(investor *domain.InvestorProfile) error {
+	r.logger.WithFields(logrus.Fields{
+		"layer":       "repository",
+		"function":    "Update",
+		"investor_id": investor.ID,
+	}).Info("Updating investor")
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.investors[investor.ID]; !exists {
+		return fmt.Errorf("%w: %s", domain.ErrInvestorNotFound, investor.ID)
+	}
+	r.investors[investor.ID] = investor.Clone()
+	return nil
+}
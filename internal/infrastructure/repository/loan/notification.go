@@ -0,0 +1,164 @@
+package loan
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+)
+
+// InMemoryPreferenceStore is an in-memory implementation of the PreferenceStore interface
+type InMemoryPreferenceStore struct {
+	preferences map[string]domain.NotificationPreferences
+	mutex       sync.RWMutex
+	logger      *logrus.Logger
+}
+
+// NewInMemoryPreferenceStore creates a new in-memory preference store
+func NewInMemoryPreferenceStore(logger *logrus.Logger) *InMemoryPreferenceStore {
+	return &InMemoryPreferenceStore{
+		preferences: make(map[string]domain.NotificationPreferences),
+		logger:      logger,
+	}
+}
+
+// Find retrieves the preferences of a recipient
+func (s *InMemoryPreferenceStore) Find(recipientID string) (*domain.NotificationPreferences, error) {
+	s.logger.WithFields(logrus.Fields{
+		"layer":        "repository",
+		"function":     "Find",
+		"recipient_id": recipientID,
+	}).Info("Finding notification preferences")
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	preferences, ok := s.preferences[recipientID]
+	if !ok {
+		return nil, fmt.Errorf("%w: recipient %s", domain.ErrPreferencesNotFound, recipientID)
+	}
+	preferences = clonePreferences(preferences)
+	return &preferences, nil
+}
+
+// Save creates or replaces the preferences of a recipient
+func (s *InMemoryPreferenceStore) Save(preferences domain.NotificationPreferences) error {
+	s.logger.WithFields(logrus.Fields{
+		"layer":        "repository",
+		"function":     "Save",
+		"recipient_id": preferences.RecipientID,
+	}).Info("Saving notification preferences")
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.preferences[preferences.RecipientID] = clonePreferences(preferences)
+	return nil
+}
+
+// clonePreferences copies the slices of the preferences, so callers never share them with the store
+func clonePreferences(preferences domain.NotificationPreferences) domain.NotificationPreferences {
+	preferences.Channels = slices.Clone(preferences.Channels)
+	preferences.Muted = slices.Clone(preferences.Muted)
+	return preferences
+}
+
+// InMemoryInbox is an in-memory implementation of the Inbox interface
+type InMemoryInbox struct {
+	// items holds the items of each recipient in the order they were added
+	items  map[string][]domain.InboxItem
+	mutex  sync.RWMutex
+	logger *logrus.Logger
+}
+
+// NewInMemoryInbox creates a new in-memory inbox
+func NewInMemoryInbox(logger *logrus.Logger) *InMemoryInbox {
+	return &InMemoryInbox{
+		items:  make(map[string][]domain.InboxItem),
+		logger: logger,
+	}
+}
+
+// Add stores an item, an item whose ID is already stored is left as it is
+func (i *InMemoryInbox) Add(item domain.InboxItem) error {
+	i.logger.WithFields(logrus.Fields{
+		"layer":        "repository",
+		"function":     "Add",
+		"recipient_id": item.RecipientID,
+		"item_id":      item.ID,
+		"kind":         item.Kind,
+	}).Info("Adding inbox item")
+
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	for _, stored := range i.items[item.RecipientID] {
+		if stored.ID == item.ID {
+			return nil
+		}
+	}
+	i.items[item.RecipientID] = append(i.items[item.RecipientID], cloneInboxItem(item))
+	return nil
+}
+
+// FindByRecipient retrieves the items of a recipient, newest first
+func (i *InMemoryInbox) FindByRecipient(recipientID string) ([]domain.InboxItem, error) {
+	i.logger.WithFields(logrus.Fields{
+		"layer":        "repository",
+		"function":     "FindByRecipient",
+		"recipient_id": recipientID,
+	}).Info("Finding inbox items by recipient")
+
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	stored := i.items[recipientID]
+	items := make([]domain.InboxItem, len(stored))
+	// Reversing the add order first keeps the last added item first when creation times tie
+	for n, item := range stored {
+		items[len(stored)-1-n] = cloneInboxItem(item)
+	}
+	sort.SliceStable(items, func(a, b int) bool {
+		return items[a].CreatedAt.After(items[b].CreatedAt)
+	})
+	return items, nil
+}
+
+// MarkRead records when the recipient read an item, an item already read keeps its first time
+func (i *InMemoryInbox) MarkRead(recipientID, id string, at time.Time) error {
+	i.logger.WithFields(logrus.Fields{
+		"layer":        "repository",
+		"function":     "MarkRead",
+		"recipient_id": recipientID,
+		"item_id":      id,
+	}).Info("Marking inbox item as read")
+
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	items := i.items[recipientID]
+	for n := range items {
+		if items[n].ID != id {
+			continue
+		}
+		if items[n].ReadAt == nil {
+			items[n].ReadAt = &at
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: %s of recipient %s", domain.ErrInboxItemNotFound, id, recipientID)
+}
+
+// cloneInboxItem copies the read time of the item, so callers never share it with the inbox
+func cloneInboxItem(item domain.InboxItem) domain.InboxItem {
+	if item.ReadAt != nil {
+		readAt := *item.ReadAt
+		item.ReadAt = &readAt
+	}
+	return item
+}
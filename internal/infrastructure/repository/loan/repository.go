@@ -2,6 +2,7 @@ package loan
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 
 	"github.com/sirupsen/logrus"
@@ -9,7 +10,8 @@ import (
 	domain "github.com/hinha/los-technical/internal/domain/loan"
 )
 
-// InMemoryRepository is a simple in-memory implementation of the LoanRepository interface
+// InMemoryRepository is a simple in-memory implementation of the LoanRepository interface.
+// Loans are copied on the way in and out, so callers never share pointers with the stored state.
 type InMemoryRepository struct {
 	loans  map[string]*domain.Loan
 	mutex  sync.RWMutex
@@ -35,24 +37,17 @@ func (r *InMemoryRepository) Save(loan *domain.Loan) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	var foundLoan bool
-	for _, existingLoan := range r.loans {
-		if existingLoan.BorrowerID == loan.BorrowerID {
-			foundLoan = true
-			break
-		}
-	}
-
-	if foundLoan {
+	if _, exists := r.loans[loan.ID]; exists {
 		r.logger.WithFields(logrus.Fields{
 			"layer":    "repository",
 			"function": "Save",
 			"loan_id":  loan.ID,
-		}).Error("Loan already exists")
-		return fmt.Errorf("loan with ID %s already exists", loan.BorrowerID)
+		}).Error("Loan ID already taken")
+		return fmt.Errorf("%w: %s", domain.ErrLoanAlreadyExists, loan.ID)
 	}
 
-	r.loans[loan.ID] = loan
+	loan.Version = 1
+	r.loans[loan.ID] = loan.Clone()
 	r.logger.WithFields(logrus.Fields{
 		"layer":    "repository",
 		"function": "Save",
@@ -79,7 +74,7 @@ func (r *InMemoryRepository) FindByID(id string) (*domain.Loan, error) {
 			"function": "FindByID",
 			"loan_id":  id,
 		}).Error("Loan not found")
-		return nil, fmt.Errorf("loan with ID %s not found", id)
+		return nil, fmt.Errorf("%w: %s", domain.ErrLoanNotFound, id)
 	}
 
 	r.logger.WithFields(logrus.Fields{
@@ -87,7 +82,7 @@ func (r *InMemoryRepository) FindByID(id string) (*domain.Loan, error) {
 		"function": "FindByID",
 		"loan_id":  id,
 	}).Info("Loan found successfully")
-	return loan, nil
+	return loan.Clone(), nil
 }
 
 // Update updates an existing loan in the repository
@@ -101,16 +96,29 @@ func (r *InMemoryRepository) Update(loan *domain.Loan) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	if _, exists := r.loans[loan.ID]; !exists {
+	stored, exists := r.loans[loan.ID]
+	if !exists {
 		r.logger.WithFields(logrus.Fields{
 			"layer":    "repository",
 			"function": "Update",
 			"loan_id":  loan.ID,
 		}).Error("Loan not found for update")
-		return fmt.Errorf("loan with ID %s not found", loan.ID)
+		return fmt.Errorf("%w: %s", domain.ErrLoanNotFound, loan.ID)
 	}
 
-	r.loans[loan.ID] = loan
+	if stored.Version != loan.Version {
+		r.logger.WithFields(logrus.Fields{
+			"layer":            "repository",
+			"function":         "Update",
+			"loan_id":          loan.ID,
+			"expected_version": loan.Version,
+			"actual_version":   stored.Version,
+		}).Warn("Loan version conflict")
+		return &domain.VersionConflictError{LoanID: loan.ID, Expected: loan.Version, Actual: stored.Version}
+	}
+
+	loan.Version++
+	r.loans[loan.ID] = loan.Clone()
 	r.logger.WithFields(logrus.Fields{
 		"layer":    "repository",
 		"function": "Update",
@@ -133,9 +141,10 @@ func (r *InMemoryRepository) FindByBorrowerID(borrowerID string) ([]*domain.Loan
 	var result []*domain.Loan
 	for _, loan := range r.loans {
 		if loan.BorrowerID == borrowerID {
-			result = append(result, loan)
+			result = append(result, loan.Clone())
 		}
 	}
+	sortLoans(result)
 
 	r.logger.WithFields(logrus.Fields{
 		"layer":       "repository",
@@ -146,6 +155,37 @@ func (r *InMemoryRepository) FindByBorrowerID(borrowerID string) ([]*domain.Loan
 	return result, nil
 }
 
+// FindByInvestorID retrieves all loans the investor has invested in
+func (r *InMemoryRepository) FindByInvestorID(investorID string) ([]*domain.Loan, error) {
+	r.logger.WithFields(logrus.Fields{
+		"layer":       "repository",
+		"function":    "FindByInvestorID",
+		"investor_id": investorID,
+	}).Info("Finding loans by investor ID")
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var result []*domain.Loan
+	for _, loan := range r.loans {
+		for _, investor := range loan.Investors {
+			if investor.ID == investorID {
+				result = append(result, loan.Clone())
+				break
+			}
+		}
+	}
+	sortLoans(result)
+
+	r.logger.WithFields(logrus.Fields{
+		"layer":       "repository",
+		"function":    "FindByInvestorID",
+		"investor_id": investorID,
+		"count":       len(result),
+	}).Info("Found loans for investor")
+	return result, nil
+}
+
 // FindByState retrieves all loans in a specific state
 func (r *InMemoryRepository) FindByState(state domain.LoanState) ([]*domain.Loan, error) {
 	r.logger.WithFields(logrus.Fields{
@@ -160,9 +200,10 @@ func (r *InMemoryRepository) FindByState(state domain.LoanState) ([]*domain.Loan
 	var result []*domain.Loan
 	for _, loan := range r.loans {
 		if loan.State == state {
-			result = append(result, loan)
+			result = append(result, loan.Clone())
 		}
 	}
+	sortLoans(result)
 
 	r.logger.WithFields(logrus.Fields{
 		"layer":    "repository",
@@ -173,11 +214,12 @@ func (r *InMemoryRepository) FindByState(state domain.LoanState) ([]*domain.Loan
 	return result, nil
 }
 
-// FindAll retrieves all loans with pagination
-func (r *InMemoryRepository) FindAll(page, limit int) ([]*domain.Loan, error) {
+// FindAll retrieves the loans matching the filter with pagination
+func (r *InMemoryRepository) FindAll(filter domain.LoanFilter, page, limit int) ([]*domain.Loan, error) {
 	r.logger.WithFields(logrus.Fields{
 		"layer":    "repository",
 		"function": "FindAll",
+		"currency": filter.Currency,
 		"page":     page,
 		"limit":    limit,
 	}).Info("Finding all loans with pagination")
@@ -185,11 +227,14 @@ func (r *InMemoryRepository) FindAll(page, limit int) ([]*domain.Loan, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
-	// Convert map to slice
+	// Convert map to slice, keeping only the loans that match the filter
 	var allLoans []*domain.Loan
 	for _, loan := range r.loans {
-		allLoans = append(allLoans, loan)
+		if filter.Matches(loan) {
+			allLoans = append(allLoans, loan)
+		}
 	}
+	sortLoans(allLoans)
 
 	// Calculate pagination
 	startIndex := (page - 1) * limit
@@ -212,8 +257,11 @@ func (r *InMemoryRepository) FindAll(page, limit int) ([]*domain.Loan, error) {
 		endIndex = len(allLoans)
 	}
 
-	// Get the paginated result
-	result := allLoans[startIndex:endIndex]
+	// Get the paginated result, copying only the loans on the requested page
+	result := make([]*domain.Loan, 0, endIndex-startIndex)
+	for _, loan := range allLoans[startIndex:endIndex] {
+		result = append(result, loan.Clone())
+	}
 
 	r.logger.WithFields(logrus.Fields{
 		"layer":    "repository",
@@ -224,3 +272,13 @@ func (r *InMemoryRepository) FindAll(page, limit int) ([]*domain.Loan, error) {
 	}).Info("Found loans with pagination")
 	return result, nil
 }
+
+// sortLoans orders loans by creation time, then ID, so results and pages are stable
+func sortLoans(loans []*domain.Loan) {
+	sort.Slice(loans, func(i, j int) bool {
+		if !loans[i].CreatedAt.Equal(loans[j].CreatedAt) {
+			return loans[i].CreatedAt.Before(loans[j].CreatedAt)
+		}
+		return loans[i].ID < loans[j].ID
+	})
+}
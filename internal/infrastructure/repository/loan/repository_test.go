@@ -1,7 +1,9 @@
 package loan
 
 import (
+	"errors"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -9,8 +11,18 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	domain "github.com/hinha/los-technical/internal/domain/loan"
+	"github.com/hinha/los-technical/internal/domain/loan/loantest"
+	"github.com/hinha/los-technical/internal/pkg/money"
 )
 
+func TestInMemoryRepository_Contract(t *testing.T) {
+	loantest.RunRepositoryContract(t, func(t *testing.T) domain.LoanRepository {
+		logger := logrus.New()
+		logger.SetOutput(logrus.StandardLogger().Out)
+		return NewInMemoryRepository(logger)
+	})
+}
+
 func TestInMemoryRepository_Save(t *testing.T) {
 	// Setup test logger
 	logger := logrus.New()
@@ -27,9 +39,9 @@ func TestInMemoryRepository_Save(t *testing.T) {
 			loan: &domain.Loan{
 				ID:              "loan-123",
 				BorrowerID:      "borrower-123",
-				PrincipalAmount: 10000,
-				Rate:            0.05,
-				ROI:             0.1,
+				PrincipalAmount: money.MustNew("10000", money.DefaultCurrency),
+				Rate:            money.MustParse("0.05"),
+				ROI:             money.MustParse("0.1"),
 				State:           domain.StateProposed,
 				CreatedAt:       time.Now(),
 				UpdatedAt:       time.Now(),
@@ -37,18 +49,18 @@ func TestInMemoryRepository_Save(t *testing.T) {
 			wantErr: false,
 		},
 		{
-			name: "Error when saving loan with duplicate borrower ID",
+			name: "Save second loan of the same borrower",
 			loan: &domain.Loan{
 				ID:              "loan-456",
 				BorrowerID:      "borrower-123", // Same as previous test
-				PrincipalAmount: 20000,
-				Rate:            0.06,
-				ROI:             0.12,
+				PrincipalAmount: money.MustNew("20000", money.DefaultCurrency),
+				Rate:            money.MustParse("0.06"),
+				ROI:             money.MustParse("0.12"),
 				State:           domain.StateProposed,
 				CreatedAt:       time.Now(),
 				UpdatedAt:       time.Now(),
 			},
-			wantErr: true,
+			wantErr: false,
 		},
 	}
 
@@ -57,14 +69,14 @@ func TestInMemoryRepository_Save(t *testing.T) {
 			// Create a new repository for each test
 			repo := NewInMemoryRepository(logger)
 
-			// For the duplicate test, first save the initial loan
-			if tt.name == "Error when saving loan with duplicate borrower ID" {
+			// For the second loan test, first save the initial loan
+			if tt.name == "Save second loan of the same borrower" {
 				initialLoan := &domain.Loan{
 					ID:              "loan-123",
 					BorrowerID:      "borrower-123",
-					PrincipalAmount: 10000,
-					Rate:            0.05,
-					ROI:             0.1,
+					PrincipalAmount: money.MustNew("10000", money.DefaultCurrency),
+					Rate:            money.MustParse("0.05"),
+					ROI:             money.MustParse("0.1"),
 					State:           domain.StateProposed,
 					CreatedAt:       time.Now(),
 					UpdatedAt:       time.Now(),
@@ -102,9 +114,9 @@ func TestInMemoryRepository_FindByID(t *testing.T) {
 	testLoan := &domain.Loan{
 		ID:              "loan-123",
 		BorrowerID:      "borrower-123",
-		PrincipalAmount: 10000,
-		Rate:            0.05,
-		ROI:             0.1,
+		PrincipalAmount: money.MustNew("10000", money.DefaultCurrency),
+		Rate:            money.MustParse("0.05"),
+		ROI:             money.MustParse("0.1"),
 		State:           domain.StateProposed,
 		CreatedAt:       time.Now(),
 		UpdatedAt:       time.Now(),
@@ -170,9 +182,9 @@ func TestInMemoryRepository_Update(t *testing.T) {
 				loan := &domain.Loan{
 					ID:              "loan-123",
 					BorrowerID:      "borrower-123",
-					PrincipalAmount: 10000,
-					Rate:            0.05,
-					ROI:             0.1,
+					PrincipalAmount: money.MustNew("10000", money.DefaultCurrency),
+					Rate:            money.MustParse("0.05"),
+					ROI:             money.MustParse("0.1"),
 					State:           domain.StateProposed,
 					CreatedAt:       time.Now(),
 					UpdatedAt:       time.Now(),
@@ -197,9 +209,9 @@ func TestInMemoryRepository_Update(t *testing.T) {
 				return &domain.Loan{
 					ID:              "non-existent-id",
 					BorrowerID:      "borrower-456",
-					PrincipalAmount: 20000,
-					Rate:            0.06,
-					ROI:             0.12,
+					PrincipalAmount: money.MustNew("20000", money.DefaultCurrency),
+					Rate:            money.MustParse("0.06"),
+					ROI:             money.MustParse("0.12"),
 					State:           domain.StateProposed,
 					CreatedAt:       time.Now(),
 					UpdatedAt:       time.Now(),
@@ -256,9 +268,9 @@ func TestInMemoryRepository_FindByBorrowerID(t *testing.T) {
 	loan1 := &domain.Loan{
 		ID:              "loan-123",
 		BorrowerID:      "borrower-123",
-		PrincipalAmount: 10000,
-		Rate:            0.05,
-		ROI:             0.1,
+		PrincipalAmount: money.MustNew("10000", money.DefaultCurrency),
+		Rate:            money.MustParse("0.05"),
+		ROI:             money.MustParse("0.1"),
 		State:           domain.StateProposed,
 		CreatedAt:       time.Now(),
 		UpdatedAt:       time.Now(),
@@ -269,9 +281,9 @@ func TestInMemoryRepository_FindByBorrowerID(t *testing.T) {
 	loan3 := &domain.Loan{
 		ID:              "loan-789",
 		BorrowerID:      "borrower-456",
-		PrincipalAmount: 30000,
-		Rate:            0.07,
-		ROI:             0.14,
+		PrincipalAmount: money.MustNew("30000", money.DefaultCurrency),
+		Rate:            money.MustParse("0.07"),
+		ROI:             money.MustParse("0.14"),
 		State:           domain.StateProposed,
 		CreatedAt:       time.Now(),
 		UpdatedAt:       time.Now(),
@@ -338,9 +350,9 @@ func TestInMemoryRepository_FindByState(t *testing.T) {
 	loan1 := &domain.Loan{
 		ID:              "loan-123",
 		BorrowerID:      "borrower-123",
-		PrincipalAmount: 10000,
-		Rate:            0.05,
-		ROI:             0.1,
+		PrincipalAmount: money.MustNew("10000", money.DefaultCurrency),
+		Rate:            money.MustParse("0.05"),
+		ROI:             money.MustParse("0.1"),
 		State:           domain.StateProposed,
 		CreatedAt:       time.Now(),
 		UpdatedAt:       time.Now(),
@@ -350,9 +362,9 @@ func TestInMemoryRepository_FindByState(t *testing.T) {
 	loan2 := &domain.Loan{
 		ID:              "loan-456",
 		BorrowerID:      "borrower-456",
-		PrincipalAmount: 20000,
-		Rate:            0.06,
-		ROI:             0.12,
+		PrincipalAmount: money.MustNew("20000", money.DefaultCurrency),
+		Rate:            money.MustParse("0.06"),
+		ROI:             money.MustParse("0.12"),
 		State:           domain.StateApproved,
 		CreatedAt:       time.Now(),
 		UpdatedAt:       time.Now(),
@@ -362,9 +374,9 @@ func TestInMemoryRepository_FindByState(t *testing.T) {
 	loan3 := &domain.Loan{
 		ID:              "loan-789",
 		BorrowerID:      "borrower-789",
-		PrincipalAmount: 30000,
-		Rate:            0.07,
-		ROI:             0.14,
+		PrincipalAmount: money.MustNew("30000", money.DefaultCurrency),
+		Rate:            money.MustParse("0.07"),
+		ROI:             money.MustParse("0.14"),
 		State:           domain.StateProposed,
 		CreatedAt:       time.Now(),
 		UpdatedAt:       time.Now(),
@@ -432,9 +444,9 @@ func TestInMemoryRepository_FindAll(t *testing.T) {
 		loan := &domain.Loan{
 			ID:              fmt.Sprintf("loan-%d", i),
 			BorrowerID:      fmt.Sprintf("borrower-%d", i),
-			PrincipalAmount: float64(i * 10000),
-			Rate:            0.05,
-			ROI:             0.1,
+			PrincipalAmount: money.New(money.NewFromInt(int64(i*10000)), money.DefaultCurrency),
+			Rate:            money.MustParse("0.05"),
+			ROI:             money.MustParse("0.1"),
 			State:           domain.StateProposed,
 			CreatedAt:       time.Now(),
 			UpdatedAt:       time.Now(),
@@ -483,7 +495,7 @@ func TestInMemoryRepository_FindAll(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Execute the test
-			got, err := repo.FindAll(tt.page, tt.limit)
+			got, err := repo.FindAll(domain.LoanFilter{}, tt.page, tt.limit)
 
 			// Assert the result
 			if tt.wantErr {
@@ -495,3 +507,137 @@ func TestInMemoryRepository_FindAll(t *testing.T) {
 		})
 	}
 }
+
+// TestInMemoryRepository_ConcurrentHandlers simulates handlers working on the same loan at once.
+// Run it with -race: before loans were copied, readers and writers shared the stored pointer.
+func TestInMemoryRepository_ConcurrentHandlers(t *testing.T) {
+	const investors = 20
+	amount := money.MustNew("1000", money.DefaultCurrency)
+
+	// Setup test logger
+	logger := logrus.New()
+	logger.SetOutput(logrus.StandardLogger().Out)
+
+	repo := NewInMemoryRepository(logger)
+	_ = repo.Save(&domain.Loan{
+		ID:              "loan-123",
+		BorrowerID:      "borrower-123",
+		PrincipalAmount: money.MustNew("10000", money.DefaultCurrency),
+		State:           domain.StateApproved,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	})
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		accepted int
+	)
+	for i := 0; i < investors; i++ {
+		wg.Add(2)
+
+		// Investor: read-modify-write with the same checks as LoanService.AddInvestment
+		go func(i int) {
+			defer wg.Done()
+			for {
+				loan, err := repo.FindByID("loan-123")
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				total := amount
+				for _, inv := range loan.Investors {
+					total, _ = total.Add(inv.Amount)
+				}
+				funded, _ := total.Cmp(loan.PrincipalAmount)
+				if funded > 0 {
+					return
+				}
+
+				loan.Investors = append(loan.Investors, domain.Investor{ID: fmt.Sprintf("investor-%d", i), Amount: amount})
+				if funded == 0 {
+					loan.State = domain.StateInvested
+				}
+
+				err = repo.Update(loan)
+				if errors.Is(err, domain.ErrVersionConflict) {
+					continue
+				}
+				if assert.NoError(t, err) {
+					mu.Lock()
+					accepted++
+					mu.Unlock()
+				}
+				return
+			}
+		}(i)
+
+		// Careless reader: scribbles on the loan it got back without calling Update
+		go func() {
+			defer wg.Done()
+			loan, err := repo.FindByID("loan-123")
+			if !assert.NoError(t, err) {
+				return
+			}
+			loan.State = domain.StateDisbursed
+			loan.Investors = append(loan.Investors, domain.Investor{ID: "intruder", Amount: amount})
+			for i := range loan.Investors {
+				loan.Investors[i].Amount = money.ZeroOf(money.DefaultCurrency)
+			}
+		}()
+	}
+	wg.Wait()
+
+	loan, err := repo.FindByID("loan-123")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.StateInvested, loan.State)
+	assert.Equal(t, investors/2, accepted)
+	assert.Len(t, loan.Investors, investors/2)
+
+	total := money.ZeroOf(money.DefaultCurrency)
+	for _, inv := range loan.Investors {
+		assert.NotEqual(t, "intruder", inv.ID)
+		total, _ = total.Add(inv.Amount)
+	}
+	assert.Equal(t, loan.PrincipalAmount, total)
+}
+
+func TestInMemoryAuditLog_Contract(t *testing.T) {
+	loantest.RunAuditLogContract(t, func(t *testing.T) domain.AuditLog {
+		logger := logrus.New()
+		logger.SetOutput(logrus.StandardLogger().Out)
+		return NewInMemoryAuditLog(logger)
+	})
+}
+
+func TestInMemoryPreferenceStore_Contract(t *testing.T) {
+	loantest.RunPreferenceStoreContract(t, func(t *testing.T) domain.PreferenceStore {
+		logger := logrus.New()
+		logger.SetOutput(logrus.StandardLogger().Out)
+		return NewInMemoryPreferenceStore(logger)
+	})
+}
+
+func TestInMemoryInvestorRepository_Contract(t *testing.T) {
+	loantest.RunInvestorRepositoryContract(t, func(t *testing.T) domain.InvestorRepository {
+		logger := logrus.New()
+		logger.SetOutput(logrus.StandardLogger().Out)
+		return NewInMemoryInvestorRepository(logger)
+	})
+}
+
+func TestInMemoryBorrowerRepository_Contract(t *testing.T) {
+	loantest.RunBorrowerRepositoryContract(t, func(t *testing.T) domain.BorrowerRepository {
+		logger := logrus.New()
+		logger.SetOutput(logrus.StandardLogger().Out)
+		return NewInMemoryBorrowerRepository(logger)
+	})
+}
+
+func TestInMemoryInbox_Contract(t *testing.T) {
+	loantest.RunInboxContract(t, func(t *testing.T) domain.Inbox {
+		logger := logrus.New()
+		logger.SetOutput(logrus.StandardLogger().Out)
+		return NewInMemoryInbox(logger)
+	})
+}
@@ -0,0 +1,96 @@
+package loan
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+)
+
+// SQLiteAuditLog is an AuditLog stored in the loan_audit_log table next to the loans.
+// The table rejects updates and deletes, see migration 0008.
+type SQLiteAuditLog struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+// NewSQLiteAuditLog creates a new SQLite audit log.
+// The schema is expected to be migrated already, see sqlite.Migrate.
+func NewSQLiteAuditLog(db *sql.DB, logger *logrus.Logger) *SQLiteAuditLog {
+	return &SQLiteAuditLog{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Append stores a new entry at the end of the history of its loan
+func (a *SQLiteAuditLog) Append(entry domain.AuditEntry) error {
+	a.logger.WithFields(logrus.Fields{
+		"layer":    "repository",
+		"function": "Append",
+		"loan_id":  entry.LoanID,
+		"action":   entry.Action,
+	}).Info("Appending audit entry")
+
+	_, err := a.db.Exec(`INSERT INTO loan_audit_log (id, loan_id, actor, action, from_state, to_state, payload_digest, recorded_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.ID,
+		entry.LoanID,
+		entry.Actor,
+		string(entry.Action),
+		string(entry.FromState),
+		string(entry.ToState),
+		entry.PayloadDigest,
+		formatTime(entry.Timestamp),
+	)
+	if err != nil {
+		a.logger.WithFields(logrus.Fields{
+			"layer":    "repository",
+			"function": "Append",
+			"loan_id":  entry.LoanID,
+			"error":    err.Error(),
+		}).Error("Failed to insert audit entry")
+		return fmt.Errorf("failed to insert audit entry: %w", err)
+	}
+	return nil
+}
+
+// FindByLoanID retrieves the entries of a loan in the order they were appended
+func (a *SQLiteAuditLog) FindByLoanID(loanID string) ([]domain.AuditEntry, error) {
+	a.logger.WithFields(logrus.Fields{
+		"layer":    "repository",
+		"function": "FindByLoanID",
+		"loan_id":  loanID,
+	}).Info("Finding audit entries by loan ID")
+
+	rows, err := a.db.Query(`SELECT id, loan_id, actor, action, from_state, to_state, payload_digest, recorded_at
+		FROM loan_audit_log WHERE loan_id = ? ORDER BY seq`, loanID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []domain.AuditEntry{}
+	for rows.Next() {
+		var (
+			entry                     domain.AuditEntry
+			action, from, to, stamped string
+		)
+		if err := rows.Scan(&entry.ID, &entry.LoanID, &entry.Actor, &action, &from, &to, &entry.PayloadDigest, &stamped); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		entry.Action = domain.Action(action)
+		entry.FromState = domain.LoanState(from)
+		entry.ToState = domain.LoanState(to)
+		if entry.Timestamp, err = parseTime(stamped); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit entries: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,213 @@
+package loan
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	"github.com/hinha/los-technical/internal/pkg/money"
+)
+
+const borrowerColumns = `id, name, national_id, email, phone, address, monthly_income, monthly_obligations, currency, blacklisted, blacklist_reason, created_at, updated_at`
+
+// SQLiteBorrowerRepository is a BorrowerRepository stored in the borrowers table next to the loans
+type SQLiteBorrowerRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+// NewSQLiteBorrowerRepository creates a new SQLite borrower repository.
+// The schema is expected to be migrated already, see sqlite.Migrate.
+func NewSQLiteBorrowerRepository(db *sql.DB, logger *logrus.Logger) *SQLiteBorrowerRepository {
+	return &SQLiteBorrowerRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Save stores a new borrower
+func (r *SQLiteBorrowerRepository) Save(borrower *domain.Borrower) error {
+	r.logger.WithFields(logrus.Fields{
+		"layer":       "repository",
+		"function":    "Save",
+		"borrower_id": borrower.ID,
+	}).Info("Saving borrower")
+
+	result, err := r.db.Exec(`INSERT INTO borrowers (`+borrowerColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO NOTHING`,
+		borrower.ID,
+		borrower.Name,
+		borrower.NationalID,
+		borrower.Email,
+		borrower.Phone,
+		borrower.Address,
+		borrower.MonthlyIncome.Amount.String(),
+		borrower.MonthlyObligations.Amount.String(),
+		borrower.MonthlyIncome.Currency,
+		borrower.Blacklisted,
+		borrower.BlacklistReason,
+		formatTime(borrower.CreatedAt),
+		formatTime(borrower.UpdatedAt),
+	)
+	if err != nil {
+		r.logger.WithFields(logrus.Fields{
+			"layer":       "repository",
+			"function":    "Save",
+			"borrower_id": borrower.ID,
+			"error":       err.Error(),
+		}).Error("Failed to insert borrower")
+		return fmt.Errorf("failed to insert borrower: %w", err)
+	}
+	if inserted, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to insert borrower: %w", err)
+	} else if inserted == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrBorrowerAlreadyExists, borrower.ID)
+	}
+	return nil
+}
+
+// FindByID retrieves a borrower
+func (r *SQLiteBorrowerRepository) FindByID(id string) (*domain.Borrower, error) {
+	r.logger.WithFields(logrus.Fields{
+		"layer":       "repository",
+		"function":    "FindByID",
+		"borrower_id": id,
+	}).Info("Finding borrower by ID")
+
+	borrower, err := scanBorrower(r.db.QueryRow(`SELECT `+borrowerColumns+` FROM borrowers WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", domain.ErrBorrowerNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query borrower: %w", err)
+	}
+	return borrower, nil
+}
+
+// Update replaces a stored borrower
+func (r *SQLiteBorrowerRepository) Update(borrower *domain.Borrower) error {
+	r.logger.WithFields(logrus.Fields{
+		"layer":       "repository",
+		"function":    "Update",
+		"borrower_id": borrower.ID,
+	}).Info("Updating borrower")
+
+	result, err := r.db.Exec(`UPDATE borrowers SET name = ?, national_id = ?, email = ?, phone = ?, address = ?,
+		monthly_income = ?, monthly_obligations = ?, currency = ?, blacklisted = ?, blacklist_reason = ?, created_at = ?, updated_at = ?
+		WHERE id = ?`,
+		borrower.Name,
+		borrower.NationalID,
+		borrower.Email,
+		borrower.Phone,
+		borrower.Address,
+		borrower.MonthlyIncome.Amount.String(),
+		borrower.MonthlyObligations.Amount.String(),
+		borrower.MonthlyIncome.Currency,
+		borrower.Blacklisted,
+		borrower.BlacklistReason,
+		formatTime(borrower.CreatedAt),
+		formatTime(borrower.UpdatedAt),
+		borrower.ID,
+	)
+	if err != nil {
+		r.logger.WithFields(logrus.Fields{
+			"layer":       "repository",
+			"function":    "Update",
+			"borrower_id": borrower.ID,
+			"error":       err.Error(),
+		}).Error("Failed to update borrower")
+		return fmt.Errorf("failed to update borrower: %w", err)
+	}
+	if updated, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to update borrower: %w", err)
+	} else if updated == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrBorrowerNotFound, borrower.ID)
+	}
+	return nil
+}
+
+// Delete removes a borrower
+func (r *SQLiteBorrowerRepository) Delete(id string) error {
+	r.logger.WithFields(logrus.Fields{
+		"layer":       "repository",
+		"function":    "Delete",
+		"borrower_id": id,
+	}).Info("Deleting borrower")
+
+	result, err := r.db.Exec(`DELETE FROM borrowers WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete borrower: %w", err)
+	}
+	if deleted, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to delete borrower: %w", err)
+	} else if deleted == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrBorrowerNotFound, id)
+	}
+	return nil
+}
+
+// FindAll retrieves the borrowers with pagination
+func (r *SQLiteBorrowerRepository) FindAll(page, limit int) ([]*domain.Borrower, error) {
+	r.logger.WithFields(logrus.Fields{
+		"layer":    "repository",
+		"function": "FindAll",
+		"page":     page,
+		"limit":    limit,
+	}).Info("Finding all borrowers with pagination")
+
+	rows, err := r.db.Query(`SELECT `+borrowerColumns+` FROM borrowers ORDER BY created_at, id LIMIT ? OFFSET ?`, limit, (page-1)*limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query borrowers: %w", err)
+	}
+	defer rows.Close()
+
+	result := []*domain.Borrower{}
+	for rows.Next() {
+		borrower, err := scanBorrower(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan borrower: %w", err)
+		}
+		result = append(result, borrower)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query borrowers: %w", err)
+	}
+	return result, nil
+}
+
+// scanBorrower reads a borrower from a row of borrowerColumns
+func scanBorrower(row interface{ Scan(dest ...any) error }) (*domain.Borrower, error) {
+	var (
+		borrower                      domain.Borrower
+		income, obligations, currency string
+		created, updated              string
+	)
+	err := row.Scan(
+		&borrower.ID, &borrower.Name, &borrower.NationalID, &borrower.Email, &borrower.Phone, &borrower.Address,
+		&income, &obligations, &currency, &borrower.Blacklisted, &borrower.BlacklistReason, &created, &updated,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	incomeAmount, err := money.Parse(income)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse monthly income %q: %w", income, err)
+	}
+	obligationsAmount, err := money.Parse(obligations)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse monthly obligations %q: %w", obligations, err)
+	}
+	borrower.MonthlyIncome = money.New(incomeAmount, currency)
+	borrower.MonthlyObligations = money.New(obligationsAmount, currency)
+	if borrower.CreatedAt, err = parseTime(created); err != nil {
+		return nil, err
+	}
+	if borrower.UpdatedAt, err = parseTime(updated); err != nil {
+		return nil, err
+	}
+	return &borrower, nil
+}
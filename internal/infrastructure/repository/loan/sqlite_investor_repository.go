@@ -0,0 +1,194 @@
+package loan
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	"github.com/hinha/los-technical/internal/pkg/money"
+)
+
+const investorColumns = `id, name, email, phone, locale, kyc_status, kyc_reviewed_at, per_loan_limit, per_loan_limit_currency, total_limit, total_limit_currency, created_at, updated_at`
+
+// SQLiteInvestorRepository is an InvestorRepository stored in the investors table next to the loans
+type SQLiteInvestorRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+// NewSQLiteInvestorRepository creates a new SQLite investor repository.
+// The schema is expected to be migrated already, see sqlite.Migrate.
+func NewSQLiteInvestorRepository(db *sql.DB, logger *logrus.Logger) *SQLiteInvestorRepository {
+	return &SQLiteInvestorRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Save stores a new investor
+func (r *SQLiteInvestorRepository) Save(investor *domain.InvestorProfile) error {
+	r.logger.WithFields(logrus.Fields{
+		"layer":       "repository",
+		"function":    "Save",
+		"investor_id": investor.ID,
+	}).Info("Saving investor")
+
+	perLoan, perLoanCurrency := limitColumns(investor.Limits.PerLoan)
+	total, totalCurrency := limitColumns(investor.Limits.Total)
+	result, err := r.db.Exec(`INSERT INTO investors (`+investorColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO NOTHING`,
+		investor.ID,
+		investor.Name,
+		investor.Email,
+		investor.Phone,
+		investor.Locale,
+		string(investor.KYCStatus),
+		nullableTime(investor.KYCReviewedAt),
+		perLoan,
+		perLoanCurrency,
+		total,
+		totalCurrency,
+		formatTime(investor.CreatedAt),
+		formatTime(investor.UpdatedAt),
+	)
+	if err != nil {
+		r.logger.WithFields(logrus.Fields{
+			"layer":       "repository",
+			"function":    "Save",
+			"investor_id": investor.ID,
+			"error":       err.Error(),
+		}).Error("Failed to insert investor")
+		return fmt.Errorf("failed to insert investor: %w", err)
+	}
+	if inserted, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to insert investor: %w", err)
+	} else if inserted == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrInvestorAlreadyExists, investor.ID)
+	}
+	return nil
+}
+
+// FindByID retrieves an investor
+func (r *SQLiteInvestorRepository) FindByID(id string) (*domain.InvestorProfile, error) {
+	r.logger.WithFields(logrus.Fields{
+		"layer":       "repository",
+		"function":    "FindByID",
+		"investor_id": id,
+	}).Info("Finding investor by ID")
+
+	var (
+		investor                    domain.InvestorProfile
+		kycStatus, created, updated string
+		reviewedAt                  sql.NullString
+		perLoan, perLoanCurrency    sql.NullString
+		total, totalCurrency        sql.NullString
+	)
+	err := r.db.QueryRow(`SELECT `+investorColumns+` FROM investors WHERE id = ?`, id).Scan(
+		&investor.ID, &investor.Name, &investor.Email, &investor.Phone, &investor.Locale, &kycStatus, &reviewedAt,
+		&perLoan, &perLoanCurrency, &total, &totalCurrency, &created, &updated,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", domain.ErrInvestorNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query investor: %w", err)
+	}
+
+	investor.KYCStatus = domain.KYCStatus(kycStatus)
+	if reviewedAt.Valid {
+		t, err := parseTime(reviewedAt.String)
+		if err != nil {
+			return nil, err
+		}
+		investor.KYCReviewedAt = &t
+	}
+	if investor.Limits.PerLoan, err = parseLimit(perLoan, perLoanCurrency); err != nil {
+		return nil, err
+	}
+	if investor.Limits.Total, err = parseLimit(total, totalCurrency); err != nil {
+		return nil, err
+	}
+	if investor.CreatedAt, err = parseTime(created); err != nil {
+		return nil, err
+	}
+	if investor.UpdatedAt, err = parseTime(updated); err != nil {
+		return nil, err
+	}
+	return &investor, nil
+}
+
+// Update replaces a stored investor
+func (r *SQLiteInvestorRepository) Update(investor *domain.InvestorProfile) error {
+	r.logger.WithFields(logrus.Fields{
+		"layer":       "repository",
+		"function":    "Update",
+		"investor_id": investor.ID,
+	}).Info("Updating investor")
+
+	perLoan, perLoanCurrency := limitColumns(investor.Limits.PerLoan)
+	total, totalCurrency := limitColumns(investor.Limits.Total)
+	result, err := r.db.Exec(`UPDATE investors SET name = ?, email = ?, phone = ?, locale = ?, kyc_status = ?, kyc_reviewed_at = ?,
+		per_loan_limit = ?, per_loan_limit_currency = ?, total_limit = ?, total_limit_currency = ?, created_at = ?, updated_at = ?
+		WHERE id = ?`,
+		investor.Name,
+		investor.Email,
+		investor.Phone,
+		investor.Locale,
+		string(investor.KYCStatus),
+		nullableTime(investor.KYCReviewedAt),
+		perLoan,
+		perLoanCurrency,
+		total,
+		totalCurrency,
+		formatTime(investor.CreatedAt),
+		formatTime(investor.UpdatedAt),
+		investor.ID,
+	)
+	if err != nil {
+		r.logger.WithFields(logrus.Fields{
+			"layer":       "repository",
+			"function":    "Update",
+			"investor_id": investor.ID,
+			"error":       err.Error(),
+		}).Error("Failed to update investor")
+		return fmt.Errorf("failed to update investor: %w", err)
+	}
+	if updated, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to update investor: %w", err)
+	} else if updated == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrInvestorNotFound, investor.ID)
+	}
+	return nil
+}
+
+// limitColumns returns the amount and currency columns of a limit, NULL when it is not enforced
+func limitColumns(limit *money.Money) (sql.NullString, sql.NullString) {
+	if limit == nil {
+		return sql.NullString{}, sql.NullString{}
+	}
+	return sql.NullString{String: limit.Amount.String(), Valid: true}, sql.NullString{String: limit.Currency, Valid: true}
+}
+
+// parseLimit reads a limit from its amount and currency columns
+func parseLimit(amount, currency sql.NullString) (*money.Money, error) {
+	if !amount.Valid {
+		return nil, nil
+	}
+	parsed, err := money.Parse(amount.String)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse investment limit %q: %w", amount.String, err)
+	}
+	limit := money.New(parsed, currency.String)
+	return &limit, nil
+}
+
+func nullableTime(t *time.Time) sql.NullString {
+	if t == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: formatTime(*t), Valid: true}
+}
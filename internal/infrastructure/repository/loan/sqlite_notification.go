@@ -0,0 +1,261 @@
+package loan
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+)
+
+// SQLitePreferenceStore is a PreferenceStore stored in the notification_preferences tables next to the loans
+type SQLitePreferenceStore struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+// NewSQLitePreferenceStore creates a new SQLite preference store.
+// The schema is expected to be migrated already, see sqlite.Migrate.
+func NewSQLitePreferenceStore(db *sql.DB, logger *logrus.Logger) *SQLitePreferenceStore {
+	return &SQLitePreferenceStore{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Find retrieves the preferences of a recipient
+func (s *SQLitePreferenceStore) Find(recipientID string) (*domain.NotificationPreferences, error) {
+	s.logger.WithFields(logrus.Fields{
+		"layer":        "repository",
+		"function":     "Find",
+		"recipient_id": recipientID,
+	}).Info("Finding notification preferences")
+
+	// The preferences and their channels are read in one transaction, so a concurrent Save is seen whole or not at all
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	preferences := domain.NotificationPreferences{RecipientID: recipientID}
+	err = tx.QueryRow(`SELECT email, phone, locale FROM notification_preferences WHERE recipient_id = ?`, recipientID).
+		Scan(&preferences.Email, &preferences.Phone, &preferences.Locale)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: recipient %s", domain.ErrPreferencesNotFound, recipientID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notification preferences: %w", err)
+	}
+
+	channels, err := queryStrings(tx, `SELECT channel FROM notification_preference_channels WHERE recipient_id = ? ORDER BY position`, recipientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notification channels: %w", err)
+	}
+	for _, channel := range channels {
+		preferences.Channels = append(preferences.Channels, domain.Channel(channel))
+	}
+	muted, err := queryStrings(tx, `SELECT kind FROM notification_preference_muted WHERE recipient_id = ? ORDER BY position`, recipientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query muted notifications: %w", err)
+	}
+	for _, kind := range muted {
+		preferences.Muted = append(preferences.Muted, domain.NotificationKind(kind))
+	}
+	return &preferences, nil
+}
+
+// Save creates or replaces the preferences of a recipient
+func (s *SQLitePreferenceStore) Save(preferences domain.NotificationPreferences) error {
+	s.logger.WithFields(logrus.Fields{
+		"layer":        "repository",
+		"function":     "Save",
+		"recipient_id": preferences.RecipientID,
+	}).Info("Saving notification preferences")
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = tx.Exec(`INSERT INTO notification_preferences (recipient_id, email, phone, locale) VALUES (?, ?, ?, ?)
+		ON CONFLICT (recipient_id) DO UPDATE SET email = excluded.email, phone = excluded.phone, locale = excluded.locale`,
+		preferences.RecipientID,
+		preferences.Email,
+		preferences.Phone,
+		preferences.Locale,
+	)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":        "repository",
+			"function":     "Save",
+			"recipient_id": preferences.RecipientID,
+			"error":        err.Error(),
+		}).Error("Failed to save notification preferences")
+		return fmt.Errorf("failed to save notification preferences: %w", err)
+	}
+
+	// Channels and muted kinds are rewritten as a whole so they always mirror the preferences
+	for _, table := range []string{"notification_preference_channels", "notification_preference_muted"} {
+		if _, err := tx.Exec(`DELETE FROM `+table+` WHERE recipient_id = ?`, preferences.RecipientID); err != nil {
+			return fmt.Errorf("failed to clear %s: %w", table, err)
+		}
+	}
+	for position, channel := range preferences.Channels {
+		if _, err := tx.Exec(`INSERT INTO notification_preference_channels (recipient_id, position, channel) VALUES (?, ?, ?)`,
+			preferences.RecipientID, position, string(channel)); err != nil {
+			return fmt.Errorf("failed to insert notification channel: %w", err)
+		}
+	}
+	for position, kind := range preferences.Muted {
+		if _, err := tx.Exec(`INSERT INTO notification_preference_muted (recipient_id, position, kind) VALUES (?, ?, ?)`,
+			preferences.RecipientID, position, string(kind)); err != nil {
+			return fmt.Errorf("failed to insert muted notification: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit notification preferences: %w", err)
+	}
+	return nil
+}
+
+// queryStrings reads the single text column of every row the query returns
+func queryStrings(q querier, query string, args ...any) ([]string, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, rows.Err()
+}
+
+const inboxColumns = `id, recipient_id, kind, loan_id, subject, body, created_at, read_at`
+
+// SQLiteInbox is an Inbox stored in the inbox_items table next to the loans
+type SQLiteInbox struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+// NewSQLiteInbox creates a new SQLite inbox.
+// The schema is expected to be migrated already, see sqlite.Migrate.
+func NewSQLiteInbox(db *sql.DB, logger *logrus.Logger) *SQLiteInbox {
+	return &SQLiteInbox{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Add stores an item, an item whose ID is already stored is left as it is
+func (i *SQLiteInbox) Add(item domain.InboxItem) error {
+	i.logger.WithFields(logrus.Fields{
+		"layer":        "repository",
+		"function":     "Add",
+		"recipient_id": item.RecipientID,
+		"item_id":      item.ID,
+		"kind":         item.Kind,
+	}).Info("Adding inbox item")
+
+	_, err := i.db.Exec(`INSERT INTO inbox_items (`+inboxColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (recipient_id, id) DO NOTHING`,
+		item.ID,
+		item.RecipientID,
+		string(item.Kind),
+		item.LoanID,
+		item.Subject,
+		item.Body,
+		formatTime(item.CreatedAt),
+		nullableTime(item.ReadAt),
+	)
+	if err != nil {
+		i.logger.WithFields(logrus.Fields{
+			"layer":        "repository",
+			"function":     "Add",
+			"recipient_id": item.RecipientID,
+			"item_id":      item.ID,
+			"error":        err.Error(),
+		}).Error("Failed to insert inbox item")
+		return fmt.Errorf("failed to insert inbox item: %w", err)
+	}
+	return nil
+}
+
+// FindByRecipient retrieves the items of a recipient, newest first
+func (i *SQLiteInbox) FindByRecipient(recipientID string) ([]domain.InboxItem, error) {
+	i.logger.WithFields(logrus.Fields{
+		"layer":        "repository",
+		"function":     "FindByRecipient",
+		"recipient_id": recipientID,
+	}).Info("Finding inbox items by recipient")
+
+	// The last added item comes first when creation times tie
+	rows, err := i.db.Query(`SELECT `+inboxColumns+` FROM inbox_items WHERE recipient_id = ? ORDER BY created_at DESC, seq DESC`, recipientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query inbox items: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]domain.InboxItem, 0)
+	for rows.Next() {
+		var (
+			item          domain.InboxItem
+			kind, created string
+			readAt        sql.NullString
+		)
+		if err := rows.Scan(&item.ID, &item.RecipientID, &kind, &item.LoanID, &item.Subject, &item.Body, &created, &readAt); err != nil {
+			return nil, fmt.Errorf("failed to scan inbox item: %w", err)
+		}
+		item.Kind = domain.NotificationKind(kind)
+		if item.CreatedAt, err = parseTime(created); err != nil {
+			return nil, err
+		}
+		if readAt.Valid {
+			t, err := parseTime(readAt.String)
+			if err != nil {
+				return nil, err
+			}
+			item.ReadAt = &t
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query inbox items: %w", err)
+	}
+	return items, nil
+}
+
+// MarkRead records when the recipient read an item, an item already read keeps its first time
+func (i *SQLiteInbox) MarkRead(recipientID, id string, at time.Time) error {
+	i.logger.WithFields(logrus.Fields{
+		"layer":        "repository",
+		"function":     "MarkRead",
+		"recipient_id": recipientID,
+		"item_id":      id,
+	}).Info("Marking inbox item as read")
+
+	result, err := i.db.Exec(`UPDATE inbox_items SET read_at = COALESCE(read_at, ?) WHERE recipient_id = ? AND id = ?`,
+		formatTime(at), recipientID, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark inbox item as read: %w", err)
+	}
+	if updated, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to mark inbox item as read: %w", err)
+	} else if updated == 0 {
+		return fmt.Errorf("%w: %s of recipient %s", domain.ErrInboxItemNotFound, id, recipientID)
+	}
+	return nil
+}
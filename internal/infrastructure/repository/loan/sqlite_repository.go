@@ -0,0 +1,801 @@
+package loan
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	"github.com/hinha/los-technical/internal/pkg/money"
+)
+
+// querier is the subset of *sql.DB and *sql.Tx used to read loans
+type querier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+const loanColumns = `id, borrower_id, principal_amount, currency, rate, roi, agreement_letter, agreement_document_id, tenor_months, repayment_scheme, state, created_at, updated_at, version`
+
+// timeLayout is a fixed-width RFC 3339 layout so stored timestamps sort lexically
+const timeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+// SQLiteRepository is a LoanRepository backed by an embedded SQLite database.
+// Approvals, investors, disbursements, installments, repayments, closures and refunds are kept in their own tables keyed by loan ID.
+type SQLiteRepository struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+// NewSQLiteRepository creates a new SQLite loan repository.
+// The schema is expected to be migrated already, see sqlite.Migrate.
+func NewSQLiteRepository(db *sql.DB, logger *logrus.Logger) *SQLiteRepository {
+	return &SQLiteRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Save persists a new loan to the repository
+func (r *SQLiteRepository) Save(loan *domain.Loan) error {
+	r.logger.WithFields(logrus.Fields{
+		"layer":    "repository",
+		"function": "Save",
+		"loan_id":  loan.ID,
+	}).Info("Saving loan to repository")
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var count int
+	if err := tx.QueryRow(`SELECT COUNT(1) FROM loans WHERE id = ?`, loan.ID).Scan(&count); err != nil {
+		return fmt.Errorf("failed to check existing loans: %w", err)
+	}
+	if count > 0 {
+		r.logger.WithFields(logrus.Fields{
+			"layer":    "repository",
+			"function": "Save",
+			"loan_id":  loan.ID,
+		}).Error("Loan ID already taken")
+		return fmt.Errorf("%w: %s", domain.ErrLoanAlreadyExists, loan.ID)
+	}
+
+	_, err = tx.Exec(`INSERT INTO loans (`+loanColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		loan.ID,
+		loan.BorrowerID,
+		loan.PrincipalAmount.Amount,
+		loan.PrincipalAmount.Currency,
+		loan.Rate,
+		loan.ROI,
+		loan.AgreementLetter,
+		loan.AgreementDocumentID,
+		loan.Terms.TenorMonths,
+		string(loan.Terms.Scheme),
+		string(loan.State),
+		formatTime(loan.CreatedAt),
+		formatTime(loan.UpdatedAt),
+		1,
+	)
+	if err != nil {
+		r.logger.WithFields(logrus.Fields{
+			"layer":    "repository",
+			"function": "Save",
+			"loan_id":  loan.ID,
+			"error":    err.Error(),
+		}).Error("Failed to insert loan")
+		return fmt.Errorf("failed to insert loan: %w", err)
+	}
+
+	if err := writeLoanDetails(tx, loan); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit loan: %w", err)
+	}
+	loan.Version = 1
+
+	r.logger.WithFields(logrus.Fields{
+		"layer":    "repository",
+		"function": "Save",
+		"loan_id":  loan.ID,
+	}).Info("Loan saved successfully")
+	return nil
+}
+
+// FindByID retrieves a loan by its ID
+func (r *SQLiteRepository) FindByID(id string) (*domain.Loan, error) {
+	r.logger.WithFields(logrus.Fields{
+		"layer":    "repository",
+		"function": "FindByID",
+		"loan_id":  id,
+	}).Info("Finding loan by ID")
+
+	// The loan and its details are read in one transaction, so an update in between is seen whole or not at all
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	loan, err := scanLoan(tx.QueryRow(`SELECT `+loanColumns+` FROM loans WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		r.logger.WithFields(logrus.Fields{
+			"layer":    "repository",
+			"function": "FindByID",
+			"loan_id":  id,
+		}).Error("Loan not found")
+		return nil, fmt.Errorf("%w: %s", domain.ErrLoanNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find loan: %w", err)
+	}
+
+	if err := loadLoanDetails(tx, loan); err != nil {
+		return nil, err
+	}
+
+	r.logger.WithFields(logrus.Fields{
+		"layer":    "repository",
+		"function": "FindByID",
+		"loan_id":  id,
+	}).Info("Loan found successfully")
+	return loan, nil
+}
+
+// Update updates an existing loan in the repository
+func (r *SQLiteRepository) Update(loan *domain.Loan) error {
+	r.logger.WithFields(logrus.Fields{
+		"layer":    "repository",
+		"function": "Update",
+		"loan_id":  loan.ID,
+	}).Info("Updating loan in repository")
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := tx.Exec(`UPDATE loans
+		SET borrower_id = ?, principal_amount = ?, currency = ?, rate = ?, roi = ?, agreement_letter = ?, agreement_document_id = ?, tenor_months = ?, repayment_scheme = ?, state = ?, created_at = ?, updated_at = ?, version = version + 1
+		WHERE id = ? AND version = ?`,
+		loan.BorrowerID,
+		loan.PrincipalAmount.Amount,
+		loan.PrincipalAmount.Currency,
+		loan.Rate,
+		loan.ROI,
+		loan.AgreementLetter,
+		loan.AgreementDocumentID,
+		loan.Terms.TenorMonths,
+		string(loan.Terms.Scheme),
+		string(loan.State),
+		formatTime(loan.CreatedAt),
+		formatTime(loan.UpdatedAt),
+		loan.ID,
+		loan.Version,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update loan: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update loan: %w", err)
+	}
+	if affected == 0 {
+		// Nothing matched: either the loan is gone or someone else bumped the version
+		var actual int64
+		err := tx.QueryRow(`SELECT version FROM loans WHERE id = ?`, loan.ID).Scan(&actual)
+		if errors.Is(err, sql.ErrNoRows) {
+			r.logger.WithFields(logrus.Fields{
+				"layer":    "repository",
+				"function": "Update",
+				"loan_id":  loan.ID,
+			}).Error("Loan not found for update")
+			return fmt.Errorf("%w: %s", domain.ErrLoanNotFound, loan.ID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read loan version: %w", err)
+		}
+
+		r.logger.WithFields(logrus.Fields{
+			"layer":            "repository",
+			"function":         "Update",
+			"loan_id":          loan.ID,
+			"expected_version": loan.Version,
+			"actual_version":   actual,
+		}).Warn("Loan version conflict")
+		return &domain.VersionConflictError{LoanID: loan.ID, Expected: loan.Version, Actual: actual}
+	}
+
+	// Child rows are rewritten as a whole so they always mirror the aggregate
+	for _, table := range []string{"loan_credit_scores", "loan_score_reasons", "loan_approvals", "loan_investors", "loan_disbursements", "loan_installments", "loan_repayments", "loan_closures", "loan_refunds"} {
+		if _, err := tx.Exec(`DELETE FROM `+table+` WHERE loan_id = ?`, loan.ID); err != nil {
+			return fmt.Errorf("failed to clear %s: %w", table, err)
+		}
+	}
+	if err := writeLoanDetails(tx, loan); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit loan: %w", err)
+	}
+	loan.Version++
+
+	r.logger.WithFields(logrus.Fields{
+		"layer":    "repository",
+		"function": "Update",
+		"loan_id":  loan.ID,
+	}).Info("Loan updated successfully")
+	return nil
+}
+
+// FindByBorrowerID retrieves all loans for a specific borrower
+func (r *SQLiteRepository) FindByBorrowerID(borrowerID string) ([]*domain.Loan, error) {
+	r.logger.WithFields(logrus.Fields{
+		"layer":       "repository",
+		"function":    "FindByBorrowerID",
+		"borrower_id": borrowerID,
+	}).Info("Finding loans by borrower ID")
+
+	result, err := r.queryLoans(`SELECT `+loanColumns+` FROM loans WHERE borrower_id = ? ORDER BY created_at, id`, borrowerID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.logger.WithFields(logrus.Fields{
+		"layer":       "repository",
+		"function":    "FindByBorrowerID",
+		"borrower_id": borrowerID,
+		"count":       len(result),
+	}).Info("Found loans for borrower")
+	return result, nil
+}
+
+// FindByInvestorID retrieves all loans the investor has invested in
+func (r *SQLiteRepository) FindByInvestorID(investorID string) ([]*domain.Loan, error) {
+	r.logger.WithFields(logrus.Fields{
+		"layer":       "repository",
+		"function":    "FindByInvestorID",
+		"investor_id": investorID,
+	}).Info("Finding loans by investor ID")
+
+	result, err := r.queryLoans(`SELECT `+loanColumns+` FROM loans
+		WHERE id IN (SELECT loan_id FROM loan_investors WHERE investor_id = ?)
+		ORDER BY created_at, id`, investorID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.logger.WithFields(logrus.Fields{
+		"layer":       "repository",
+		"function":    "FindByInvestorID",
+		"investor_id": investorID,
+		"count":       len(result),
+	}).Info("Found loans for investor")
+	return result, nil
+}
+
+// FindByState retrieves all loans in a specific state
+func (r *SQLiteRepository) FindByState(state domain.LoanState) ([]*domain.Loan, error) {
+	r.logger.WithFields(logrus.Fields{
+		"layer":    "repository",
+		"function": "FindByState",
+		"state":    state,
+	}).Info("Finding loans by state")
+
+	result, err := r.queryLoans(`SELECT `+loanColumns+` FROM loans WHERE state = ? ORDER BY created_at, id`, string(state))
+	if err != nil {
+		return nil, err
+	}
+
+	r.logger.WithFields(logrus.Fields{
+		"layer":    "repository",
+		"function": "FindByState",
+		"state":    state,
+		"count":    len(result),
+	}).Info("Found loans by state")
+	return result, nil
+}
+
+// FindAll retrieves the loans matching the filter with pagination
+func (r *SQLiteRepository) FindAll(filter domain.LoanFilter, page, limit int) ([]*domain.Loan, error) {
+	r.logger.WithFields(logrus.Fields{
+		"layer":    "repository",
+		"function": "FindAll",
+		"currency": filter.Currency,
+		"page":     page,
+		"limit":    limit,
+	}).Info("Finding all loans with pagination")
+
+	offset := (page - 1) * limit
+	result, err := r.queryLoans(`SELECT `+loanColumns+` FROM loans
+		WHERE (? = '' OR currency = ?)
+		ORDER BY created_at, id LIMIT ? OFFSET ?`, filter.Currency, filter.Currency, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		result = []*domain.Loan{}
+	}
+
+	r.logger.WithFields(logrus.Fields{
+		"layer":    "repository",
+		"function": "FindAll",
+		"page":     page,
+		"limit":    limit,
+		"count":    len(result),
+	}).Info("Found loans with pagination")
+	return result, nil
+}
+
+// queryLoans runs a loan query and loads the details of every returned loan, all in one transaction so every
+// loan is read as it was at the same time
+func (r *SQLiteRepository) queryLoans(query string, args ...any) ([]*domain.Loan, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query loans: %w", err)
+	}
+
+	var result []*domain.Loan
+	for rows.Next() {
+		loan, err := scanLoan(rows)
+		if err != nil {
+			_ = rows.Close()
+			return nil, fmt.Errorf("failed to scan loan: %w", err)
+		}
+		result = append(result, loan)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("failed to query loans: %w", err)
+	}
+
+	// Details are loaded after the cursor is closed, the transaction holds a single connection
+	for _, loan := range result {
+		if err := loadLoanDetails(tx, loan); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// scanLoan reads a loans row into a Loan without its details
+func scanLoan(row interface{ Scan(dest ...any) error }) (*domain.Loan, error) {
+	var (
+		loan      domain.Loan
+		scheme    string
+		state     string
+		createdAt string
+		updatedAt string
+	)
+	err := row.Scan(
+		&loan.ID,
+		&loan.BorrowerID,
+		&loan.PrincipalAmount.Amount,
+		&loan.PrincipalAmount.Currency,
+		&loan.Rate,
+		&loan.ROI,
+		&loan.AgreementLetter,
+		&loan.AgreementDocumentID,
+		&loan.Terms.TenorMonths,
+		&scheme,
+		&state,
+		&createdAt,
+		&updatedAt,
+		&loan.Version,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	loan.Terms.Scheme = domain.RepaymentScheme(scheme)
+	loan.State = domain.LoanState(state)
+	if loan.CreatedAt, err = parseTime(createdAt); err != nil {
+		return nil, err
+	}
+	if loan.UpdatedAt, err = parseTime(updatedAt); err != nil {
+		return nil, err
+	}
+	return &loan, nil
+}
+
+// loadLoanDetails loads the credit score, approval, investors, disbursement, schedule, repayments and closure of a loan
+func loadLoanDetails(q querier, loan *domain.Loan) error {
+	if err := loadCreditScore(q, loan); err != nil {
+		return err
+	}
+
+	var (
+		approval   domain.Approval
+		approvedAt string
+	)
+	err := q.QueryRow(`SELECT validator_id, proof_document_id, proof_url, override_justification, approved_at FROM loan_approvals WHERE loan_id = ?`, loan.ID).
+		Scan(&approval.ValidatorID, &approval.ProofDocumentID, &approval.ProofURL, &approval.OverrideJustification, &approvedAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return fmt.Errorf("failed to load approval: %w", err)
+	default:
+		if approval.Date, err = parseTime(approvedAt); err != nil {
+			return err
+		}
+		loan.ApprovedInfo = &approval
+	}
+
+	rows, err := q.Query(`SELECT investor_id, name, amount, currency, email, locale FROM loan_investors WHERE loan_id = ? ORDER BY position`, loan.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load investors: %w", err)
+	}
+	for rows.Next() {
+		var investor domain.Investor
+		if err := rows.Scan(&investor.ID, &investor.Name, &investor.Amount.Amount, &investor.Amount.Currency, &investor.Email, &investor.Locale); err != nil {
+			_ = rows.Close()
+			return fmt.Errorf("failed to scan investor: %w", err)
+		}
+		loan.Investors = append(loan.Investors, investor)
+	}
+	if err := rows.Close(); err != nil {
+		return fmt.Errorf("failed to load investors: %w", err)
+	}
+
+	var (
+		disbursement domain.Disbursement
+		disbursedAt  string
+	)
+	err = q.QueryRow(`SELECT signed_agreement_document_id, signed_agreement, field_officer_id, disbursed_at FROM loan_disbursements WHERE loan_id = ?`, loan.ID).
+		Scan(&disbursement.SignedAgreementDocumentID, &disbursement.SignedAgreement, &disbursement.FieldOfficerID, &disbursedAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return fmt.Errorf("failed to load disbursement: %w", err)
+	default:
+		if disbursement.Date, err = parseTime(disbursedAt); err != nil {
+			return err
+		}
+		loan.DisbursedInfo = &disbursement
+	}
+
+	if err := loadSchedule(q, loan); err != nil {
+		return err
+	}
+	if err := loadRepayments(q, loan); err != nil {
+		return err
+	}
+	return loadClosure(q, loan)
+}
+
+// loadCreditScore loads the credit score of a loan and its reasons in the order they were given
+func loadCreditScore(q querier, loan *domain.Loan) error {
+	var (
+		score    domain.CreditScore
+		grade    string
+		scoredAt string
+	)
+	err := q.QueryRow(`SELECT score, grade, scorecard, scored_at FROM loan_credit_scores WHERE loan_id = ?`, loan.ID).
+		Scan(&score.Score, &grade, &score.Scorecard, &scoredAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to load credit score: %w", err)
+	}
+	score.Grade = domain.RiskGrade(grade)
+	if score.ScoredAt, err = parseTime(scoredAt); err != nil {
+		return err
+	}
+
+	rows, err := q.Query(`SELECT code FROM loan_score_reasons WHERE loan_id = ? ORDER BY position`, loan.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load score reasons: %w", err)
+	}
+	defer rows.Close()
+
+	score.Reasons = []string{}
+	for rows.Next() {
+		var code string
+		if err := rows.Scan(&code); err != nil {
+			return fmt.Errorf("failed to scan score reason: %w", err)
+		}
+		score.Reasons = append(score.Reasons, code)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to load score reasons: %w", err)
+	}
+	loan.CreditScore = &score
+	return nil
+}
+
+// loadSchedule loads the installments of a loan in due order
+func loadSchedule(q querier, loan *domain.Loan) error {
+	rows, err := q.Query(`SELECT number, due_date, principal, interest, total, balance, fee, fee_paid, interest_paid, principal_paid, paid_at, reminded_at, currency
+		FROM loan_installments WHERE loan_id = ? ORDER BY number`, loan.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load schedule: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			installment domain.Installment
+			dueDate     string
+			paidAt      sql.NullString
+			remindedAt  sql.NullString
+			currency    string
+		)
+		err := rows.Scan(
+			&installment.Number,
+			&dueDate,
+			&installment.Principal.Amount,
+			&installment.Interest.Amount,
+			&installment.Total.Amount,
+			&installment.Balance.Amount,
+			&installment.Fee.Amount,
+			&installment.FeePaid.Amount,
+			&installment.InterestPaid.Amount,
+			&installment.PrincipalPaid.Amount,
+			&paidAt,
+			&remindedAt,
+			&currency,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to scan installment: %w", err)
+		}
+		if installment.DueDate, err = parseTime(dueDate); err != nil {
+			return err
+		}
+		if paidAt.Valid {
+			settledAt, err := parseTime(paidAt.String)
+			if err != nil {
+				return err
+			}
+			installment.PaidAt = &settledAt
+		}
+		if remindedAt.Valid {
+			reminded, err := parseTime(remindedAt.String)
+			if err != nil {
+				return err
+			}
+			installment.RemindedAt = &reminded
+		}
+		for _, amount := range []*money.Money{
+			&installment.Principal, &installment.Interest, &installment.Total, &installment.Balance,
+			&installment.Fee, &installment.FeePaid, &installment.InterestPaid, &installment.PrincipalPaid,
+		} {
+			amount.Currency = currency
+		}
+		loan.Schedule = append(loan.Schedule, installment)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to load schedule: %w", err)
+	}
+	return nil
+}
+
+// loadRepayments loads the repayments of a loan in the order they were recorded
+func loadRepayments(q querier, loan *domain.Loan) error {
+	rows, err := q.Query(`SELECT id, amount, fee, interest, principal, currency, paid_at
+		FROM loan_repayments WHERE loan_id = ? ORDER BY position`, loan.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load repayments: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			repayment domain.Repayment
+			currency  string
+			paidAt    string
+		)
+		err := rows.Scan(
+			&repayment.ID,
+			&repayment.Amount.Amount,
+			&repayment.Fee.Amount,
+			&repayment.Interest.Amount,
+			&repayment.Principal.Amount,
+			&currency,
+			&paidAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to scan repayment: %w", err)
+		}
+		if repayment.PaidAt, err = parseTime(paidAt); err != nil {
+			return err
+		}
+		repayment.Amount.Currency = currency
+		repayment.Fee.Currency = currency
+		repayment.Interest.Currency = currency
+		repayment.Principal.Currency = currency
+		loan.Repayments = append(loan.Repayments, repayment)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to load repayments: %w", err)
+	}
+	return nil
+}
+
+// loadClosure loads the rejection or cancellation of a loan and the refunds paid out with it
+func loadClosure(q querier, loan *domain.Loan) error {
+	var (
+		closure  domain.Closure
+		kind     string
+		closedAt string
+	)
+	err := q.QueryRow(`SELECT kind, actor_id, reason_code, closed_at FROM loan_closures WHERE loan_id = ?`, loan.ID).
+		Scan(&kind, &closure.ActorID, &closure.ReasonCode, &closedAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to load closure: %w", err)
+	}
+	if closure.Date, err = parseTime(closedAt); err != nil {
+		return err
+	}
+	switch domain.LoanState(kind) {
+	case domain.StateRejected:
+		loan.RejectedInfo = &closure
+	case domain.StateCancelled:
+		loan.CancelledInfo = &closure
+	default:
+		return fmt.Errorf("unknown closure kind %q", kind)
+	}
+
+	rows, err := q.Query(`SELECT investor_id, amount, currency, email FROM loan_refunds WHERE loan_id = ? ORDER BY position`, loan.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load refunds: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var refund domain.Refund
+		if err := rows.Scan(&refund.InvestorID, &refund.Amount.Amount, &refund.Amount.Currency, &refund.Email); err != nil {
+			return fmt.Errorf("failed to scan refund: %w", err)
+		}
+		loan.Refunds = append(loan.Refunds, refund)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to load refunds: %w", err)
+	}
+	return nil
+}
+
+// writeLoanDetails inserts the credit score, approval, investors, disbursement, schedule, repayment, closure and refund
+// rows of a loan
+func writeLoanDetails(tx *sql.Tx, loan *domain.Loan) error {
+	if score := loan.CreditScore; score != nil {
+		_, err := tx.Exec(`INSERT INTO loan_credit_scores (loan_id, score, grade, scorecard, scored_at) VALUES (?, ?, ?, ?, ?)`,
+			loan.ID, score.Score, string(score.Grade), score.Scorecard, formatTime(score.ScoredAt))
+		if err != nil {
+			return fmt.Errorf("failed to write credit score: %w", err)
+		}
+		for i, code := range score.Reasons {
+			if _, err := tx.Exec(`INSERT INTO loan_score_reasons (loan_id, position, code) VALUES (?, ?, ?)`, loan.ID, i, code); err != nil {
+				return fmt.Errorf("failed to write score reason: %w", err)
+			}
+		}
+	}
+
+	if loan.ApprovedInfo != nil {
+		_, err := tx.Exec(`INSERT INTO loan_approvals (loan_id, validator_id, proof_document_id, proof_url, override_justification, approved_at) VALUES (?, ?, ?, ?, ?, ?)`,
+			loan.ID, loan.ApprovedInfo.ValidatorID, loan.ApprovedInfo.ProofDocumentID, loan.ApprovedInfo.ProofURL, loan.ApprovedInfo.OverrideJustification, formatTime(loan.ApprovedInfo.Date))
+		if err != nil {
+			return fmt.Errorf("failed to write approval: %w", err)
+		}
+	}
+
+	for i, investor := range loan.Investors {
+		_, err := tx.Exec(`INSERT INTO loan_investors (loan_id, position, investor_id, name, amount, currency, email, locale) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			loan.ID, i, investor.ID, investor.Name, investor.Amount.Amount, investor.Amount.Currency, investor.Email, investor.Locale)
+		if err != nil {
+			return fmt.Errorf("failed to write investor: %w", err)
+		}
+	}
+
+	if loan.DisbursedInfo != nil {
+		_, err := tx.Exec(`INSERT INTO loan_disbursements (loan_id, signed_agreement_document_id, signed_agreement, field_officer_id, disbursed_at) VALUES (?, ?, ?, ?, ?)`,
+			loan.ID, loan.DisbursedInfo.SignedAgreementDocumentID, loan.DisbursedInfo.SignedAgreement, loan.DisbursedInfo.FieldOfficerID, formatTime(loan.DisbursedInfo.Date))
+		if err != nil {
+			return fmt.Errorf("failed to write disbursement: %w", err)
+		}
+	}
+
+	for _, installment := range loan.Schedule {
+		var paidAt sql.NullString
+		if installment.PaidAt != nil {
+			paidAt = sql.NullString{String: formatTime(*installment.PaidAt), Valid: true}
+		}
+		var remindedAt sql.NullString
+		if installment.RemindedAt != nil {
+			remindedAt = sql.NullString{String: formatTime(*installment.RemindedAt), Valid: true}
+		}
+		_, err := tx.Exec(`INSERT INTO loan_installments (loan_id, number, due_date, principal, interest, total, balance,
+			fee, fee_paid, interest_paid, principal_paid, paid_at, reminded_at, currency)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			loan.ID,
+			installment.Number,
+			formatTime(installment.DueDate),
+			installment.Principal.Amount,
+			installment.Interest.Amount,
+			installment.Total.Amount,
+			installment.Balance.Amount,
+			installment.Fee.Amount,
+			installment.FeePaid.Amount,
+			installment.InterestPaid.Amount,
+			installment.PrincipalPaid.Amount,
+			paidAt,
+			remindedAt,
+			installment.Principal.Currency,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to write installment: %w", err)
+		}
+	}
+
+	for kind, closure := range map[domain.LoanState]*domain.Closure{
+		domain.StateRejected:  loan.RejectedInfo,
+		domain.StateCancelled: loan.CancelledInfo,
+	} {
+		if closure == nil {
+			continue
+		}
+		_, err := tx.Exec(`INSERT INTO loan_closures (loan_id, kind, actor_id, reason_code, closed_at) VALUES (?, ?, ?, ?, ?)`,
+			loan.ID, string(kind), closure.ActorID, string(closure.ReasonCode), formatTime(closure.Date))
+		if err != nil {
+			return fmt.Errorf("failed to write closure: %w", err)
+		}
+	}
+
+	for i, refund := range loan.Refunds {
+		_, err := tx.Exec(`INSERT INTO loan_refunds (loan_id, position, investor_id, amount, currency, email) VALUES (?, ?, ?, ?, ?, ?)`,
+			loan.ID, i, refund.InvestorID, refund.Amount.Amount, refund.Amount.Currency, refund.Email)
+		if err != nil {
+			return fmt.Errorf("failed to write refund: %w", err)
+		}
+	}
+
+	for i, repayment := range loan.Repayments {
+		_, err := tx.Exec(`INSERT INTO loan_repayments (loan_id, position, id, amount, fee, interest, principal, currency, paid_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			loan.ID,
+			i,
+			repayment.ID,
+			repayment.Amount.Amount,
+			repayment.Fee.Amount,
+			repayment.Interest.Amount,
+			repayment.Principal.Amount,
+			repayment.Amount.Currency,
+			formatTime(repayment.PaidAt),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to write repayment: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// formatTime stores timestamps as sortable UTC strings
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+func parseTime(value string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse timestamp %q: %w", value, err)
+	}
+	return t, nil
+}
@@ -0,0 +1,296 @@
+package loan
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	"github.com/hinha/los-technical/internal/domain/loan/loantest"
+	"github.com/hinha/los-technical/internal/infrastructure/database/sqlite"
+	"github.com/hinha/los-technical/internal/pkg/money"
+)
+
+// newTestDB opens a fresh, migrated database file
+func newTestDB(t *testing.T, logger *logrus.Logger) *sql.DB {
+	t.Helper()
+
+	db, err := sqlite.Open(filepath.Join(t.TempDir(), "los.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	if err := sqlite.Migrate(db, logger); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	return db
+}
+
+// newTestSQLiteRepository creates a repository on a fresh, migrated database file
+func newTestSQLiteRepository(t *testing.T) *SQLiteRepository {
+	t.Helper()
+
+	logger := logrus.New()
+	logger.SetOutput(logrus.StandardLogger().Out)
+	return NewSQLiteRepository(newTestDB(t, logger), logger)
+}
+
+func TestSQLiteRepository_Contract(t *testing.T) {
+	loantest.RunRepositoryContract(t, func(t *testing.T) domain.LoanRepository {
+		return newTestSQLiteRepository(t)
+	})
+}
+
+func TestSQLiteAuditLog_Contract(t *testing.T) {
+	loantest.RunAuditLogContract(t, func(t *testing.T) domain.AuditLog {
+		logger := logrus.New()
+		logger.SetOutput(logrus.StandardLogger().Out)
+		return NewSQLiteAuditLog(newTestDB(t, logger), logger)
+	})
+}
+
+func TestSQLiteInvestorRepository_Contract(t *testing.T) {
+	loantest.RunInvestorRepositoryContract(t, func(t *testing.T) domain.InvestorRepository {
+		logger := logrus.New()
+		logger.SetOutput(logrus.StandardLogger().Out)
+		return NewSQLiteInvestorRepository(newTestDB(t, logger), logger)
+	})
+}
+
+func TestSQLiteBorrowerRepository_Contract(t *testing.T) {
+	loantest.RunBorrowerRepositoryContract(t, func(t *testing.T) domain.BorrowerRepository {
+		logger := logrus.New()
+		logger.SetOutput(logrus.StandardLogger().Out)
+		return NewSQLiteBorrowerRepository(newTestDB(t, logger), logger)
+	})
+}
+
+func TestSQLitePreferenceStore_Contract(t *testing.T) {
+	loantest.RunPreferenceStoreContract(t, func(t *testing.T) domain.PreferenceStore {
+		logger := logrus.New()
+		logger.SetOutput(logrus.StandardLogger().Out)
+		return NewSQLitePreferenceStore(newTestDB(t, logger), logger)
+	})
+}
+
+func TestSQLiteInbox_Contract(t *testing.T) {
+	loantest.RunInboxContract(t, func(t *testing.T) domain.Inbox {
+		logger := logrus.New()
+		logger.SetOutput(logrus.StandardLogger().Out)
+		return NewSQLiteInbox(newTestDB(t, logger), logger)
+	})
+}
+
+func TestSQLiteAuditLog_AppendOnly(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(logrus.StandardLogger().Out)
+	db := newTestDB(t, logger)
+	log := NewSQLiteAuditLog(db, logger)
+
+	assert.NoError(t, log.Append(loantest.NewAuditEntry("entry-1", "loan-1", domain.ActionCreate, "", domain.StateProposed, 0)))
+
+	_, err := db.Exec(`UPDATE loan_audit_log SET actor = 'tampered'`)
+	assert.ErrorContains(t, err, "append-only")
+	_, err = db.Exec(`DELETE FROM loan_audit_log`)
+	assert.ErrorContains(t, err, "append-only")
+}
+
+func TestSQLiteRepository_SaveAndFindByID(t *testing.T) {
+	repo := newTestSQLiteRepository(t)
+
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 6, time.UTC)
+	loan := &domain.Loan{
+		ID:              "loan-123",
+		BorrowerID:      "borrower-123",
+		PrincipalAmount: money.MustNew("10000", money.DefaultCurrency),
+		Rate:            money.MustParse("0.05"),
+		ROI:             money.MustParse("0.1"),
+		State:           domain.StateProposed,
+		CreatedAt:       createdAt,
+		UpdatedAt:       createdAt,
+	}
+	assert.NoError(t, repo.Save(loan))
+
+	// The loan ID cannot be taken twice
+	duplicate := *loan
+	duplicate.BorrowerID = "borrower-456"
+	assert.ErrorIs(t, repo.Save(&duplicate), domain.ErrLoanAlreadyExists)
+
+	got, err := repo.FindByID("loan-123")
+	assert.NoError(t, err)
+	assert.Equal(t, loan, got)
+
+	got, err = repo.FindByID("non-existent-id")
+	assert.Error(t, err)
+	assert.Nil(t, got)
+}
+
+func TestSQLiteRepository_Update(t *testing.T) {
+	repo := newTestSQLiteRepository(t)
+
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	loan := &domain.Loan{
+		ID:              "loan-123",
+		BorrowerID:      "borrower-123",
+		PrincipalAmount: money.MustNew("10000", money.DefaultCurrency),
+		Rate:            money.MustParse("0.05"),
+		ROI:             money.MustParse("0.1"),
+		State:           domain.StateProposed,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	assert.NoError(t, repo.Save(loan))
+
+	// Walk the loan through its lifecycle so every detail table gets written
+	loan.State = domain.StateDisbursed
+	loan.AgreementLetter = "http://example.com/agreement"
+	loan.AgreementDocumentID = "document-1"
+	loan.ApprovedInfo = &domain.Approval{
+		ValidatorID:     "validator-123",
+		ProofDocumentID: "proof-1",
+		ProofURL:        "http://example.com/proof",
+		Date:            now.Add(time.Hour),
+	}
+	loan.Investors = []domain.Investor{
+		{ID: "investor-1", Amount: money.MustNew("4000", money.DefaultCurrency), Email: "one@example.com"},
+		{ID: "investor-2", Amount: money.MustNew("6000", money.DefaultCurrency), Email: "two@example.com"},
+	}
+	loan.DisbursedInfo = &domain.Disbursement{
+		SignedAgreementDocumentID: "signed-1",
+		SignedAgreement:           "http://example.com/signed",
+		FieldOfficerID:            "officer-123",
+		Date:                      now.Add(2 * time.Hour),
+	}
+	loan.UpdatedAt = now.Add(2 * time.Hour)
+	assert.NoError(t, repo.Update(loan))
+
+	got, err := repo.FindByID("loan-123")
+	assert.NoError(t, err)
+	assert.Equal(t, loan, got)
+
+	// Updating again must replace rather than append the investors
+	loan.Investors = loan.Investors[:1]
+	assert.NoError(t, repo.Update(loan))
+	got, err = repo.FindByID("loan-123")
+	assert.NoError(t, err)
+	assert.Len(t, got.Investors, 1)
+
+	missing := &domain.Loan{ID: "non-existent-id", State: domain.StateProposed}
+	assert.Error(t, repo.Update(missing))
+}
+
+func TestSQLiteRepository_ConsistentReads(t *testing.T) {
+	repo := newTestSQLiteRepository(t)
+
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	loan := &domain.Loan{
+		ID:              "loan-123",
+		BorrowerID:      "borrower-123",
+		PrincipalAmount: money.MustNew("10000", money.DefaultCurrency),
+		State:           domain.StateApproved,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	assert.NoError(t, repo.Save(loan))
+
+	// Every update stores one investor less than the version it brings the loan to
+	const updates = 50
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < updates; i++ {
+			loan.Investors = append(loan.Investors, domain.Investor{ID: fmt.Sprintf("investor-%d", i), Amount: money.MustNew("1", money.DefaultCurrency)})
+			if !assert.NoError(t, repo.Update(loan)) {
+				return
+			}
+		}
+	}()
+
+	// A read between the loans row and its investors would see them from different updates
+	for reading := true; reading; {
+		select {
+		case <-done:
+			reading = false
+		default:
+		}
+		got, err := repo.FindByID("loan-123")
+		if assert.NoError(t, err) {
+			assert.Equal(t, got.Version-1, int64(len(got.Investors)))
+		}
+		all, err := repo.FindAll(domain.LoanFilter{}, 1, 10)
+		if assert.NoError(t, err) && assert.Len(t, all, 1) {
+			assert.Equal(t, all[0].Version-1, int64(len(all[0].Investors)))
+		}
+	}
+}
+
+func TestSQLiteRepository_Finders(t *testing.T) {
+	repo := newTestSQLiteRepository(t)
+
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 1; i <= 10; i++ {
+		state := domain.StateProposed
+		if i%2 == 0 {
+			state = domain.StateApproved
+		}
+		loan := &domain.Loan{
+			ID:              fmt.Sprintf("loan-%02d", i),
+			BorrowerID:      fmt.Sprintf("borrower-%d", i),
+			PrincipalAmount: money.New(money.NewFromInt(int64(i*10000)), money.DefaultCurrency),
+			Rate:            money.MustParse("0.05"),
+			ROI:             money.MustParse("0.1"),
+			State:           state,
+			CreatedAt:       base.Add(time.Duration(i) * time.Minute),
+			UpdatedAt:       base.Add(time.Duration(i) * time.Minute),
+		}
+		assert.NoError(t, repo.Save(loan))
+	}
+
+	byBorrower, err := repo.FindByBorrowerID("borrower-3")
+	assert.NoError(t, err)
+	if assert.Len(t, byBorrower, 1) {
+		assert.Equal(t, "loan-03", byBorrower[0].ID)
+	}
+
+	byBorrower, err = repo.FindByBorrowerID("non-existent-borrower")
+	assert.NoError(t, err)
+	assert.Empty(t, byBorrower)
+
+	approved, err := repo.FindByState(domain.StateApproved)
+	assert.NoError(t, err)
+	assert.Len(t, approved, 5)
+	for _, loan := range approved {
+		assert.Equal(t, domain.StateApproved, loan.State)
+	}
+
+	// Pages are ordered by creation time
+	tests := []struct {
+		name    string
+		page    int
+		limit   int
+		wantIDs []string
+	}{
+		{"First page with 3 items", 1, 3, []string{"loan-01", "loan-02", "loan-03"}},
+		{"Last partial page", 4, 3, []string{"loan-10"}},
+		{"Page beyond available data", 5, 3, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.FindAll(domain.LoanFilter{}, tt.page, tt.limit)
+			assert.NoError(t, err)
+
+			ids := []string{}
+			for _, loan := range got {
+				ids = append(ids, loan.ID)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+		})
+	}
+}
@@ -0,0 +1,364 @@
+package scoring
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	"github.com/hinha/los-technical/internal/pkg/money"
+)
+
+// Reason codes of the factors that cannot be measured, the other codes come from the scorecard
+const (
+	// ReasonNoIncome is given when the borrower has no income to measure debt and loan size against
+	ReasonNoIncome = "NO_INCOME"
+	// ReasonIncomeCurrencyNotScored is given when the scorecard has no income bands for the currency of the income
+	ReasonIncomeCurrencyNotScored = "INCOME_CURRENCY_NOT_SCORED"
+	// ReasonCurrencyMismatch is given when the loan is in another currency than the income of the borrower
+	ReasonCurrencyMismatch = "LOAN_CURRENCY_MISMATCH"
+)
+
+// maxPoints is what a factor awards at best, the score is the weighted average of the points scaled to maxScore
+const (
+	maxPoints = 100
+	maxScore  = 1000
+)
+
+// ErrInvalidScorecard is returned when a scorecard cannot score loans
+var ErrInvalidScorecard = errors.New("invalid scorecard")
+
+// Band awards its points to the values from Min up to the Min of the next band
+type Band struct {
+	Min    money.Decimal
+	Points int
+	// Reason is the code given when a value falls in the band, empty when the band does not lower the score
+	Reason string
+}
+
+// Factor is a weighted measure of the loan and its borrower
+type Factor struct {
+	Weight int
+	// Bands are ordered by Min, the first one starting at zero
+	Bands []Band
+}
+
+// IncomeFactor measures the monthly income of the borrower with bands for each currency of the income
+type IncomeFactor struct {
+	Weight int
+	Bands  map[string][]Band
+}
+
+// Grade is the risk grade of the scores from MinScore up to the MinScore of the next better grade
+type Grade struct {
+	Grade    domain.RiskGrade
+	MinScore int
+}
+
+// Scorecard is a rule-based credit scorer. Each factor awards points from the band its value falls in: the
+// monthly income of the borrower, their debt-to-income ratio with the loan, their repayment history and the
+// size of the loan relative to their income.
+type Scorecard struct {
+	Version      string
+	Income       IncomeFactor
+	DebtToIncome Factor
+	History      Factor
+	// Defaulted replaces the history band of a borrower who has defaulted on a loan
+	Defaulted Band
+	LoanSize  Factor
+	// Grades are ordered from the best grade down
+	Grades []Grade
+	logger *logrus.Logger
+}
+
+// bandConfig is a band as written in the scorecard file, bounds are decimal strings
+type bandConfig struct {
+	Min    string `yaml:"min"`
+	Points int    `yaml:"points"`
+	Reason string `yaml:"reason"`
+}
+
+type factorConfig struct {
+	Weight int          `yaml:"weight"`
+	Bands  []bandConfig `yaml:"bands"`
+}
+
+// config is the layout of the scorecard file
+type config struct {
+	Version string `yaml:"version"`
+	Factors struct {
+		Income struct {
+			Weight int                     `yaml:"weight"`
+			Bands  map[string][]bandConfig `yaml:"bands"`
+		} `yaml:"income"`
+		DebtToIncome factorConfig `yaml:"debt_to_income"`
+		History      struct {
+			Weight    int          `yaml:"weight"`
+			Bands     []bandConfig `yaml:"bands"`
+			Defaulted bandConfig   `yaml:"defaulted"`
+		} `yaml:"history"`
+		LoanSize factorConfig `yaml:"loan_size"`
+	} `yaml:"factors"`
+	Grades []struct {
+		Grade    domain.RiskGrade `yaml:"grade"`
+		MinScore int              `yaml:"min_score"`
+	} `yaml:"grades"`
+}
+
+// LoadScorecard reads the scorecard at path, see ParseScorecard for its layout
+func LoadScorecard(path string, logger *logrus.Logger) (*Scorecard, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scorecard: %w", err)
+	}
+	scorecard, err := ParseScorecard(content, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.WithFields(logrus.Fields{
+		"layer":     "scoring",
+		"function":  "LoadScorecard",
+		"path":      path,
+		"scorecard": scorecard.Version,
+	}).Info("Scorecard loaded")
+	return scorecard, nil
+}
+
+// ParseScorecard parses a YAML scorecard: its version, the weight and bands of the income, debt_to_income,
+// history and loan_size factors, and the minimum score of every grade
+func ParseScorecard(content []byte, logger *logrus.Logger) (*Scorecard, error) {
+	var cfg config
+	if err := yaml.Unmarshal(content, &cfg); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidScorecard, err)
+	}
+	if cfg.Version == "" {
+		return nil, fmt.Errorf("%w: version is required", ErrInvalidScorecard)
+	}
+
+	s := &Scorecard{
+		Version: cfg.Version,
+		Income:  IncomeFactor{Weight: cfg.Factors.Income.Weight, Bands: make(map[string][]Band)},
+		logger:  logger,
+	}
+	if len(cfg.Factors.Income.Bands) == 0 {
+		return nil, fmt.Errorf("%w: income needs bands for at least one currency", ErrInvalidScorecard)
+	}
+	for currency, bands := range cfg.Factors.Income.Bands {
+		if !money.IsSupportedCurrency(currency) {
+			return nil, fmt.Errorf("%w: income bands: %w: %s", ErrInvalidScorecard, money.ErrUnsupportedCurrency, currency)
+		}
+		factor, err := newFactor("income "+currency, factorConfig{Weight: cfg.Factors.Income.Weight, Bands: bands})
+		if err != nil {
+			return nil, err
+		}
+		s.Income.Bands[currency] = factor.Bands
+	}
+	var err error
+	if s.DebtToIncome, err = newFactor("debt_to_income", cfg.Factors.DebtToIncome); err != nil {
+		return nil, err
+	}
+	if s.History, err = newFactor("history", factorConfig{Weight: cfg.Factors.History.Weight, Bands: cfg.Factors.History.Bands}); err != nil {
+		return nil, err
+	}
+	if s.Defaulted, err = newBand("history defaulted", bandConfig{Min: "0", Points: cfg.Factors.History.Defaulted.Points, Reason: cfg.Factors.History.Defaulted.Reason}); err != nil {
+		return nil, err
+	}
+	if s.LoanSize, err = newFactor("loan_size", cfg.Factors.LoanSize); err != nil {
+		return nil, err
+	}
+	if s.Income.Weight+s.DebtToIncome.Weight+s.History.Weight+s.LoanSize.Weight <= 0 {
+		return nil, fmt.Errorf("%w: the factors weigh nothing", ErrInvalidScorecard)
+	}
+
+	seen := make(map[domain.RiskGrade]bool)
+	for _, grade := range cfg.Grades {
+		if err := grade.Grade.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidScorecard, err)
+		}
+		if seen[grade.Grade] {
+			return nil, fmt.Errorf("%w: grade %s is given twice", ErrInvalidScorecard, grade.Grade)
+		}
+		seen[grade.Grade] = true
+		s.Grades = append(s.Grades, Grade{Grade: grade.Grade, MinScore: grade.MinScore})
+	}
+	sort.Slice(s.Grades, func(i, j int) bool { return s.Grades[i].MinScore > s.Grades[j].MinScore })
+	for i := 1; i < len(s.Grades); i++ {
+		if s.Grades[i-1].MinScore == s.Grades[i].MinScore || !s.Grades[i-1].Grade.AtLeast(s.Grades[i].Grade) {
+			return nil, fmt.Errorf("%w: grades must have decreasing minimum scores from A to E, %s and %s do not",
+				ErrInvalidScorecard, s.Grades[i-1].Grade, s.Grades[i].Grade)
+		}
+	}
+	if len(s.Grades) == 0 || s.Grades[len(s.Grades)-1].MinScore > 0 {
+		return nil, fmt.Errorf("%w: a grade must start at score 0", ErrInvalidScorecard)
+	}
+	return s, nil
+}
+
+// newFactor checks the weight and bands of a factor
+func newFactor(name string, cfg factorConfig) (Factor, error) {
+	if cfg.Weight < 0 {
+		return Factor{}, fmt.Errorf("%w: %s weight must not be negative", ErrInvalidScorecard, name)
+	}
+	if len(cfg.Bands) == 0 {
+		return Factor{}, fmt.Errorf("%w: %s needs bands", ErrInvalidScorecard, name)
+	}
+
+	factor := Factor{Weight: cfg.Weight, Bands: make([]Band, len(cfg.Bands))}
+	for i, bandCfg := range cfg.Bands {
+		band, err := newBand(name, bandCfg)
+		if err != nil {
+			return Factor{}, err
+		}
+		if i == 0 && !band.Min.IsZero() {
+			return Factor{}, fmt.Errorf("%w: the first %s band must start at 0", ErrInvalidScorecard, name)
+		}
+		if i > 0 && band.Min.Cmp(factor.Bands[i-1].Min) <= 0 {
+			return Factor{}, fmt.Errorf("%w: %s bands must be in increasing order of min", ErrInvalidScorecard, name)
+		}
+		factor.Bands[i] = band
+	}
+	return factor, nil
+}
+
+func newBand(name string, cfg bandConfig) (Band, error) {
+	minimum, err := money.Parse(cfg.Min)
+	if err != nil {
+		return Band{}, fmt.Errorf("%w: %s band min: %w", ErrInvalidScorecard, name, err)
+	}
+	if cfg.Points < 0 || cfg.Points > maxPoints {
+		return Band{}, fmt.Errorf("%w: %s band points must be between 0 and %d", ErrInvalidScorecard, name, maxPoints)
+	}
+	return Band{Min: minimum, Points: cfg.Points, Reason: cfg.Reason}, nil
+}
+
+// band returns the band the value falls in
+func (f Factor) band(value money.Decimal) Band {
+	return bandOf(f.Bands, value)
+}
+
+// bandOf returns the band of bands the value falls in, values below zero fall in the first band
+func bandOf(bands []Band, value money.Decimal) Band {
+	found := bands[0]
+	for _, band := range bands[1:] {
+		if value.Cmp(band.Min) < 0 {
+			break
+		}
+		found = band
+	}
+	return found
+}
+
+// measure is the points a factor awards and the reason it gives
+type measure struct {
+	weight int
+	points int
+	reason string
+}
+
+// Score scores the loan on the income, debt-to-income ratio, repayment history and loan size of its borrower.
+// ScoredAt is left to the caller.
+func (s *Scorecard) Score(input domain.ScoringInput) (*domain.CreditScore, error) {
+	loan, borrower, profile := input.Loan, input.Borrower, input.Profile
+	income := borrower.MonthlyIncome
+	sameCurrency := loan.PrincipalAmount.Currency == income.Currency
+
+	measures := make([]measure, 0, 4)
+	if bands, ok := s.Income.Bands[income.Currency]; ok {
+		band := bandOf(bands, income.Amount)
+		measures = append(measures, measure{s.Income.Weight, band.Points, band.Reason})
+	} else {
+		measures = append(measures, measure{s.Income.Weight, 0, ReasonIncomeCurrencyNotScored})
+	}
+
+	dti, err := s.debtToIncome(loan, income, profile)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case !income.IsPositive():
+		measures = append(measures, measure{s.DebtToIncome.Weight, 0, ReasonNoIncome})
+	case !sameCurrency:
+		measures = append(measures, measure{s.DebtToIncome.Weight, 0, ReasonCurrencyMismatch})
+	default:
+		band := s.DebtToIncome.band(dti)
+		measures = append(measures, measure{s.DebtToIncome.Weight, band.Points, band.Reason})
+	}
+
+	if profile.DefaultedLoans > 0 {
+		measures = append(measures, measure{s.History.Weight, s.Defaulted.Points, s.Defaulted.Reason})
+	} else {
+		band := s.History.band(money.NewFromInt(int64(profile.RepaidLoans)))
+		measures = append(measures, measure{s.History.Weight, band.Points, band.Reason})
+	}
+
+	switch {
+	case !income.IsPositive():
+		measures = append(measures, measure{s.LoanSize.Weight, 0, ReasonNoIncome})
+	case !sameCurrency:
+		measures = append(measures, measure{s.LoanSize.Weight, 0, ReasonCurrencyMismatch})
+	default:
+		multiple, err := ratio(loan.PrincipalAmount.Amount, income.Amount, 1)
+		if err != nil {
+			return nil, err
+		}
+		band := s.LoanSize.band(multiple)
+		measures = append(measures, measure{s.LoanSize.Weight, band.Points, band.Reason})
+	}
+
+	score := &domain.CreditScore{Reasons: []string{}, Scorecard: s.Version}
+	weighted, weights := 0, 0
+	for _, m := range measures {
+		weighted += m.weight * m.points
+		weights += m.weight
+		if m.reason != "" && m.weight > 0 && !slices.Contains(score.Reasons, m.reason) {
+			score.Reasons = append(score.Reasons, m.reason)
+		}
+	}
+	score.Score = weighted * maxScore / (weights * maxPoints)
+	for _, grade := range s.Grades {
+		if score.Score >= grade.MinScore {
+			score.Grade = grade.Grade
+			break
+		}
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"layer":       "scoring",
+		"function":    "Score",
+		"loan_id":     loan.ID,
+		"borrower_id": borrower.ID,
+		"score":       score.Score,
+		"grade":       score.Grade,
+	}).Info("Loan scored")
+	return score, nil
+}
+
+// debtToIncome returns the monthly debt of the borrower with the first installment of the loan added, as a
+// percentage of their monthly income. It is zero when the loan and the income cannot be compared.
+func (s *Scorecard) debtToIncome(loan *domain.Loan, income money.Money, profile *domain.CreditProfile) (money.Decimal, error) {
+	if !income.IsPositive() || loan.PrincipalAmount.Currency != income.Currency {
+		return money.Decimal{}, nil
+	}
+	schedule, err := domain.GenerateSchedule(loan.PrincipalAmount, loan.Rate, loan.Terms, loan.CreatedAt)
+	if err != nil {
+		return money.Decimal{}, fmt.Errorf("failed to estimate the installments of loan %s: %w", loan.ID, err)
+	}
+	debt, err := profile.MonthlyDebt.Add(schedule[0].Total)
+	if err != nil {
+		return money.Decimal{}, err
+	}
+	return ratio(debt.Amount, income.Amount, 100)
+}
+
+// ratio returns numerator / denominator * scale rounded to two decimal places
+func ratio(numerator, denominator money.Decimal, scale int64) (money.Decimal, error) {
+	r := new(big.Rat).Quo(numerator.Rat(), denominator.Rat())
+	return money.NewFromRat(r.Mul(r, big.NewRat(scale, 1)), 2)
+}
@@ -0,0 +1,210 @@
+package scoring
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	"github.com/hinha/los-technical/internal/pkg/money"
+)
+
+const scorecardPath = "../../../config/scorecard.yaml"
+
+// validScorecard is the smallest scorecard that loads, the invalid ones are made from it
+const validScorecard = `
+version: test
+factors:
+  income:
+    weight: 1
+    bands:
+      IDR: [{min: "0", points: 100}]
+  debt_to_income:
+    weight: 1
+    bands: [{min: "0", points: 100}]
+  history:
+    weight: 1
+    bands: [{min: "0", points: 100}]
+    defaulted: {points: 0, reason: PREVIOUS_DEFAULT}
+  loan_size:
+    weight: 1
+    bands: [{min: "0", points: 100}]
+grades:
+  - {grade: A, min_score: 500}
+  - {grade: E, min_score: 0}
+`
+
+func TestLoadScorecard(t *testing.T) {
+	scorecard, err := LoadScorecard(scorecardPath, logrus.New())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "2025-01", scorecard.Version)
+	assert.Equal(t, 100, scorecard.Income.Weight+scorecard.DebtToIncome.Weight+scorecard.History.Weight+scorecard.LoanSize.Weight)
+	assert.Equal(t, []Grade{
+		{Grade: domain.GradeA, MinScore: 800},
+		{Grade: domain.GradeB, MinScore: 650},
+		{Grade: domain.GradeC, MinScore: 500},
+		{Grade: domain.GradeD, MinScore: 350},
+		{Grade: domain.GradeE, MinScore: 0},
+	}, scorecard.Grades)
+
+	_, err = LoadScorecard(filepath.Join(t.TempDir(), "missing.yaml"), logrus.New())
+	assert.ErrorContains(t, err, "failed to read scorecard")
+
+	path := filepath.Join(t.TempDir(), "scorecard.yaml")
+	if assert.NoError(t, os.WriteFile(path, []byte("version: ["), 0o600)) {
+		_, err = LoadScorecard(path, logrus.New())
+		assert.ErrorIs(t, err, ErrInvalidScorecard)
+	}
+}
+
+func TestParseScorecard_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		old     string
+		new     string
+		wantErr string
+	}{
+		{name: "Missing version", old: "version: test", new: "", wantErr: "version is required"},
+		{name: "Unsupported income currency", old: "IDR: [", new: "XYZ: [", wantErr: "unsupported currency"},
+		{name: "Band not starting at zero", old: `debt_to_income:
+    weight: 1
+    bands: [{min: "0"`, new: `debt_to_income:
+    weight: 1
+    bands: [{min: "10"`, wantErr: "the first debt_to_income band must start at 0"},
+		{name: "Bands out of order", old: `loan_size:
+    weight: 1
+    bands: [{min: "0", points: 100}]`, new: `loan_size:
+    weight: 1
+    bands: [{min: "0", points: 100}, {min: "12", points: 0}, {min: "6", points: 50}]`, wantErr: "loan_size bands must be in increasing order of min"},
+		{name: "Too many points", old: `defaulted: {points: 0`, new: `defaulted: {points: 101`, wantErr: "history defaulted band points must be between 0 and 100"},
+		{name: "Negative weight", old: `history:
+    weight: 1`, new: `history:
+    weight: -1`, wantErr: "history weight must not be negative"},
+		{name: "Unknown grade", old: "grade: A,", new: "grade: S,", wantErr: "invalid risk grade"},
+		{name: "Riskier grade with a higher score", old: "grade: A, min_score: 500}", new: "grade: A, min_score: 500}\n  - {grade: D, min_score: 600}", wantErr: "grades must have decreasing minimum scores from A to E, D and A do not"},
+		{name: "Grades with the same score", old: "grade: A, min_score: 500}", new: "grade: A, min_score: 500}\n  - {grade: B, min_score: 500}", wantErr: "grades must have decreasing minimum scores"},
+		{name: "No grade from zero", old: "grade: E, min_score: 0", new: "grade: E, min_score: 100", wantErr: "a grade must start at score 0"},
+	}
+
+	_, err := ParseScorecard([]byte(validScorecard), logrus.New())
+	assert.NoError(t, err)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := strings.Replace(validScorecard, tt.old, tt.new, 1)
+			if content == validScorecard {
+				t.Fatalf("the test case does not change the scorecard")
+			}
+
+			scorecard, err := ParseScorecard([]byte(content), logrus.New())
+			assert.ErrorIs(t, err, ErrInvalidScorecard)
+			assert.ErrorContains(t, err, tt.wantErr)
+			assert.Nil(t, scorecard)
+		})
+	}
+}
+
+func TestScorecard_Score(t *testing.T) {
+	scorecard, err := LoadScorecard(scorecardPath, logrus.New())
+	if !assert.NoError(t, err) {
+		return
+	}
+	proposedAt := time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+	idr := func(amount string) money.Money { return money.MustNew(amount, money.DefaultCurrency) }
+	previous := func(state domain.LoanState) *domain.Loan { return &domain.Loan{BorrowerID: "borrower-1", State: state} }
+
+	tests := []struct {
+		name        string
+		income      money.Money
+		obligations money.Money
+		principal   money.Money
+		loans       []*domain.Loan
+		want        domain.CreditScore
+	}{
+		{
+			name:        "Established borrower asking little",
+			income:      idr("30000000"),
+			obligations: idr("0"),
+			principal:   idr("12000000"),
+			loans:       []*domain.Loan{previous(domain.StateRepaid), previous(domain.StateRepaid), previous(domain.StateRepaid)},
+			want:        domain.CreditScore{Score: 1000, Grade: domain.GradeA, Reasons: []string{}},
+		},
+		{
+			name:        "Borrower who defaulted before",
+			income:      idr("12000000"),
+			obligations: idr("0"),
+			principal:   idr("12000000"),
+			loans:       []*domain.Loan{previous(domain.StateRepaid), previous(domain.StateDefaulted)},
+			want:        domain.CreditScore{Score: 710, Grade: domain.GradeB, Reasons: []string{"PREVIOUS_DEFAULT"}},
+		},
+		{
+			name:        "Indebted first-time borrower asking a lot",
+			income:      idr("6000000"),
+			obligations: idr("2000000"),
+			principal:   idr("90000000"),
+			want: domain.CreditScore{Score: 285, Grade: domain.GradeE, Reasons: []string{
+				"EXCESSIVE_DEBT_TO_INCOME", "NO_REPAYMENT_HISTORY", "LARGE_LOAN",
+			}},
+		},
+		{
+			name:        "Loan in another currency than the income",
+			income:      idr("30000000"),
+			obligations: idr("0"),
+			principal:   money.MustNew("1000", "USD"),
+			want:        domain.CreditScore{Score: 325, Grade: domain.GradeE, Reasons: []string{ReasonCurrencyMismatch, "NO_REPAYMENT_HISTORY"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			borrower := &domain.Borrower{ID: "borrower-1", MonthlyIncome: tt.income, MonthlyObligations: tt.obligations}
+			profile, err := domain.NewCreditProfile(borrower, tt.loans)
+			if !assert.NoError(t, err) {
+				return
+			}
+			loan := &domain.Loan{
+				ID:              "loan-1",
+				BorrowerID:      "borrower-1",
+				PrincipalAmount: tt.principal,
+				Rate:            money.MustParse("12"),
+				Terms:           domain.RepaymentTerms{TenorMonths: 12, Scheme: domain.SchemeAnnuity},
+				CreatedAt:       proposedAt,
+			}
+
+			score, err := scorecard.Score(domain.ScoringInput{Loan: loan, Borrower: borrower, Profile: profile})
+			assert.NoError(t, err)
+			tt.want.Scorecard = "2025-01"
+			assert.Equal(t, &tt.want, score)
+		})
+	}
+}
+
+func TestScorecard_Score_IncomeCurrencyNotScored(t *testing.T) {
+	// The scorecard only has income bands for IDR
+	scorecard, err := ParseScorecard([]byte(validScorecard), logrus.New())
+	if !assert.NoError(t, err) {
+		return
+	}
+	borrower := &domain.Borrower{ID: "borrower-1", MonthlyIncome: money.MustNew("5000", "USD"), MonthlyObligations: money.MustNew("0", "USD")}
+	profile, err := domain.NewCreditProfile(borrower, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	loan := &domain.Loan{
+		ID:              "loan-1",
+		PrincipalAmount: money.MustNew("1000", "USD"),
+		Rate:            money.MustParse("12"),
+		Terms:           domain.RepaymentTerms{TenorMonths: 12, Scheme: domain.SchemeAnnuity},
+	}
+
+	score, err := scorecard.Score(domain.ScoringInput{Loan: loan, Borrower: borrower, Profile: profile})
+	assert.NoError(t, err)
+	assert.Equal(t, &domain.CreditScore{Score: 750, Grade: domain.GradeA, Reasons: []string{ReasonIncomeCurrencyNotScored}, Scorecard: "test"}, score)
+}
@@ -0,0 +1,89 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+var (
+	// ErrUnsupportedCurrency is returned for currency codes the service does not book
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
+	// ErrTooPrecise is returned when an amount has more decimal places than its currency allows
+	ErrTooPrecise = errors.New("amount is more precise than the currency allows")
+)
+
+// Currency describes how amounts in an ISO 4217 currency are booked
+type Currency struct {
+	Code string
+	// MinorUnits is the number of decimal places an amount may have, e.g. 2 for USD cents
+	MinorUnits int
+}
+
+// currencies lists every supported currency by code
+var currencies = map[string]Currency{
+	"IDR": {Code: "IDR", MinorUnits: 0},
+	"USD": {Code: "USD", MinorUnits: 2},
+}
+
+// LookupCurrency returns the currency for an ISO 4217 code
+func LookupCurrency(code string) (Currency, error) {
+	currency, ok := currencies[code]
+	if !ok {
+		return Currency{}, fmt.Errorf("%w: %q", ErrUnsupportedCurrency, code)
+	}
+	return currency, nil
+}
+
+// IsSupportedCurrency reports whether the code is a supported currency
+func IsSupportedCurrency(code string) bool {
+	_, ok := currencies[code]
+	return ok
+}
+
+// SupportedCurrencies returns the supported currency codes in alphabetical order
+func SupportedCurrencies() []string {
+	codes := make([]string, 0, len(currencies))
+	for code := range currencies {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Validate checks that the currency is supported and the amount fits its minor units.
+// Use it on amounts that come from clients, which must never be rounded silently.
+func (m Money) Validate() error {
+	currency, err := LookupCurrency(m.Currency)
+	if err != nil {
+		return err
+	}
+	if m.Amount.Round(currency.MinorUnits) != m.Amount {
+		return fmt.Errorf("%w: %s allows %d decimal places, got %s", ErrTooPrecise, currency.Code, currency.MinorUnits, m.Amount)
+	}
+	return nil
+}
+
+// Round rounds the amount half away from zero to the minor units of its currency.
+// Use it on computed amounts such as interest before they are booked.
+func (m Money) Round() (Money, error) {
+	currency, err := LookupCurrency(m.Currency)
+	if err != nil {
+		return Money{}, err
+	}
+	return New(m.Amount.Round(currency.MinorUnits), m.Currency), nil
+}
+
+// FromRat rounds an exact rational half away from zero to the minor units of the currency
+func FromRat(r *big.Rat, currency string) (Money, error) {
+	c, err := LookupCurrency(currency)
+	if err != nil {
+		return Money{}, err
+	}
+	amount, err := NewFromRat(r, c.MinorUnits)
+	if err != nil {
+		return Money{}, err
+	}
+	return New(amount, currency), nil
+}
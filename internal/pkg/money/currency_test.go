@@ -0,0 +1,73 @@
+package money
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookupCurrency(t *testing.T) {
+	currency, err := LookupCurrency("USD")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, currency.MinorUnits)
+
+	currency, err = LookupCurrency("IDR")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, currency.MinorUnits)
+
+	_, err = LookupCurrency("EUR")
+	assert.ErrorIs(t, err, ErrUnsupportedCurrency)
+
+	assert.Equal(t, []string{"IDR", "USD"}, SupportedCurrencies())
+}
+
+func TestMoney_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		money   Money
+		wantErr error
+	}{
+		{name: "Whole Rupiah", money: MustNew("1000000", "IDR")},
+		{name: "Fractional Rupiah", money: MustNew("1000.5", "IDR"), wantErr: ErrTooPrecise},
+		{name: "Dollars And Cents", money: MustNew("10.25", "USD")},
+		{name: "Fraction Of A Cent", money: MustNew("10.255", "USD"), wantErr: ErrTooPrecise},
+		{name: "Unsupported Currency", money: MustNew("10", "EUR"), wantErr: ErrUnsupportedCurrency},
+		{name: "Missing Currency", money: MustNew("10", ""), wantErr: ErrUnsupportedCurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.money.Validate()
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestMoney_Round(t *testing.T) {
+	tests := []struct {
+		name  string
+		money Money
+		want  Money
+	}{
+		{name: "Rupiah Rounds Up At Half", money: MustNew("1000.5", "IDR"), want: MustNew("1001", "IDR")},
+		{name: "Rupiah Rounds Down", money: MustNew("1000.4999", "IDR"), want: MustNew("1000", "IDR")},
+		{name: "Cents Round Up", money: MustNew("10.125", "USD"), want: MustNew("10.13", "USD")},
+		{name: "Cents Round Down", money: MustNew("10.1249", "USD"), want: MustNew("10.12", "USD")},
+		{name: "Negative Rounds Away From Zero", money: MustNew("-10.125", "USD"), want: MustNew("-10.13", "USD")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.money.Round()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := MustNew("10", "EUR").Round()
+	assert.ErrorIs(t, err, ErrUnsupportedCurrency)
+}
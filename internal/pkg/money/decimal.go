@@ -0,0 +1,292 @@
+package money
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Scale is the number of decimal places a Decimal keeps
+const Scale = 4
+
+const unit = 10000 // 10^Scale
+
+var (
+	// ErrInvalidDecimal is returned when a string cannot be parsed as a Decimal
+	ErrInvalidDecimal = errors.New("invalid decimal")
+	// ErrOverflow is returned when a value or an operation result does not fit in a Decimal
+	ErrOverflow = errors.New("decimal overflow")
+)
+
+// Decimal is a fixed-point number with Scale decimal places.
+// The zero value is 0 and values are safe to compare with ==.
+type Decimal struct {
+	units int64
+}
+
+// Zero is the Decimal 0
+var Zero = Decimal{}
+
+// NewFromInt returns the Decimal for a whole number
+func NewFromInt(n int64) Decimal {
+	return Decimal{units: n * unit}
+}
+
+// Parse parses a plain decimal string such as "1000000", "-0.25" or "12.5".
+// More than Scale decimal places is an error rather than being rounded away silently.
+func Parse(s string) (Decimal, error) {
+	str := strings.TrimSpace(s)
+	neg := false
+	switch {
+	case strings.HasPrefix(str, "-"):
+		neg = true
+		str = str[1:]
+	case strings.HasPrefix(str, "+"):
+		str = str[1:]
+	}
+
+	whole, frac, _ := strings.Cut(str, ".")
+	if whole == "" && frac == "" || !isDigits(whole) || !isDigits(frac) {
+		return Zero, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+	if len(frac) > Scale {
+		return Zero, fmt.Errorf("%w: %q has more than %d decimal places", ErrInvalidDecimal, s, Scale)
+	}
+
+	digits := whole + frac + strings.Repeat("0", Scale-len(frac))
+	units, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Zero, fmt.Errorf("%w: %q", ErrOverflow, s)
+	}
+	if neg {
+		units = -units
+	}
+	return Decimal{units: units}, nil
+}
+
+// MustParse is like Parse but panics on error. It is meant for constants and tests.
+func MustParse(s string) Decimal {
+	d, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// NewFromFloat converts a float64, rounding half away from zero to Scale decimal places
+func NewFromFloat(f float64) (Decimal, error) {
+	scaled := math.Round(f * unit)
+	// math.MaxInt64 rounds up to 2^63 as a float64, which int64 cannot hold
+	if math.IsNaN(scaled) || scaled >= math.MaxInt64 || scaled < math.MinInt64 {
+		return Zero, fmt.Errorf("%w: %v", ErrOverflow, f)
+	}
+	return Decimal{units: int64(scaled)}, nil
+}
+
+// NewFromRat rounds an exact rational half away from zero to the given number of decimal places, at most Scale.
+// It lets callers do intermediate arithmetic such as compound interest without losing precision.
+func NewFromRat(r *big.Rat, places int) (Decimal, error) {
+	if places > Scale {
+		places = Scale
+	}
+	if places < 0 {
+		places = 0
+	}
+
+	step := int64(1)
+	for i := places; i < Scale; i++ {
+		step *= 10
+	}
+	numerator := new(big.Int).Mul(r.Num(), big.NewInt(unit))
+	denominator := new(big.Int).Mul(r.Denom(), big.NewInt(step))
+	rounded, err := fromScaled(numerator, denominator)
+	if err != nil {
+		return Zero, err
+	}
+	if rounded.units > math.MaxInt64/step || rounded.units < math.MinInt64/step {
+		return Zero, ErrOverflow
+	}
+	return Decimal{units: rounded.units * step}, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Add returns d + o
+func (d Decimal) Add(o Decimal) (Decimal, error) {
+	sum := d.units + o.units
+	if (o.units > 0 && sum < d.units) || (o.units < 0 && sum > d.units) {
+		return Zero, ErrOverflow
+	}
+	return Decimal{units: sum}, nil
+}
+
+// Sub returns d - o
+func (d Decimal) Sub(o Decimal) (Decimal, error) {
+	return d.Add(o.Neg())
+}
+
+// Mul returns d * o rounded half away from zero to Scale decimal places
+func (d Decimal) Mul(o Decimal) (Decimal, error) {
+	product := new(big.Int).Mul(big.NewInt(d.units), big.NewInt(o.units))
+	return fromScaled(product, big.NewInt(unit))
+}
+
+// Div returns d / o rounded half away from zero to Scale decimal places
+func (d Decimal) Div(o Decimal) (Decimal, error) {
+	if o.units == 0 {
+		return Zero, errors.New("division by zero")
+	}
+	numerator := new(big.Int).Mul(big.NewInt(d.units), big.NewInt(unit))
+	return fromScaled(numerator, big.NewInt(o.units))
+}
+
+// fromScaled returns n / divisor as a Decimal, rounding half away from zero
+func fromScaled(n, divisor *big.Int) (Decimal, error) {
+	if divisor.Sign() < 0 {
+		n = new(big.Int).Neg(n)
+		divisor = new(big.Int).Neg(divisor)
+	}
+	quotient, remainder := new(big.Int).QuoRem(n, divisor, new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2)).Cmp(divisor) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(n.Sign())))
+	}
+	if !quotient.IsInt64() {
+		return Zero, ErrOverflow
+	}
+	return Decimal{units: quotient.Int64()}, nil
+}
+
+// Round rounds d half away from zero to the given number of decimal places, at most Scale
+func (d Decimal) Round(places int) Decimal {
+	// Rounding never moves a value further from zero than the next step, so it cannot overflow in practice
+	rounded, _ := NewFromRat(d.Rat(), places)
+	return rounded
+}
+
+// Neg returns -d
+func (d Decimal) Neg() Decimal {
+	return Decimal{units: -d.units}
+}
+
+// Cmp returns -1, 0 or +1 depending on whether d is less than, equal to or greater than o
+func (d Decimal) Cmp(o Decimal) int {
+	switch {
+	case d.units < o.units:
+		return -1
+	case d.units > o.units:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of d
+func (d Decimal) Sign() int {
+	return d.Cmp(Zero)
+}
+
+// IsZero reports whether d is 0
+func (d Decimal) IsZero() bool {
+	return d.units == 0
+}
+
+// IsPositive reports whether d is greater than 0
+func (d Decimal) IsPositive() bool {
+	return d.units > 0
+}
+
+// Rat returns the exact value of d as a rational number
+func (d Decimal) Rat() *big.Rat {
+	return big.NewRat(d.units, unit)
+}
+
+// Float64 returns the nearest float64. Use it for display and logging only, never for arithmetic.
+func (d Decimal) Float64() float64 {
+	return float64(d.units) / unit
+}
+
+// String returns the shortest plain decimal representation, e.g. "1000000" or "12.5"
+func (d Decimal) String() string {
+	units := d.units
+	sign := ""
+	if units < 0 {
+		sign = "-"
+	}
+
+	abs := new(big.Int).Abs(big.NewInt(units)).String()
+	if len(abs) <= Scale {
+		abs = strings.Repeat("0", Scale-len(abs)+1) + abs
+	}
+	whole, frac := abs[:len(abs)-Scale], strings.TrimRight(abs[len(abs)-Scale:], "0")
+	if frac == "" {
+		return sign + whole
+	}
+	return sign + whole + "." + frac
+}
+
+// MarshalJSON encodes the decimal as a JSON string so clients never round it through a float
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON accepts both a JSON string and a bare JSON number
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if strings.HasPrefix(s, `"`) {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	}
+
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// Value stores the decimal as text so no precision is lost in the database
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// Scan reads a decimal stored as text, integer or real
+func (d *Decimal) Scan(src any) error {
+	var (
+		parsed Decimal
+		err    error
+	)
+	switch v := src.(type) {
+	case string:
+		parsed, err = Parse(v)
+	case []byte:
+		parsed, err = Parse(string(v))
+	case int64:
+		parsed = NewFromInt(v)
+	case float64:
+		parsed, err = NewFromFloat(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Decimal", src)
+	}
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
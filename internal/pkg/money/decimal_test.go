@@ -0,0 +1,140 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr error
+	}{
+		{name: "Whole Number", input: "1000000", want: "1000000"},
+		{name: "Fraction", input: "12.50", want: "12.5"},
+		{name: "Leading Dot", input: ".25", want: "0.25"},
+		{name: "Negative", input: "-0.0001", want: "-0.0001"},
+		{name: "Explicit Plus", input: "+7", want: "7"},
+		{name: "Empty", input: "", wantErr: ErrInvalidDecimal},
+		{name: "Not A Number", input: "12a", wantErr: ErrInvalidDecimal},
+		{name: "Exponent", input: "1e6", wantErr: ErrInvalidDecimal},
+		{name: "Too Precise", input: "0.00001", wantErr: ErrInvalidDecimal},
+		{name: "Too Large", input: "99999999999999999999", wantErr: ErrOverflow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
+
+func TestDecimal_Arithmetic(t *testing.T) {
+	// The classic float64 trap: 0.1 + 0.2 != 0.3
+	sum, err := MustParse("0.1").Add(MustParse("0.2"))
+	assert.NoError(t, err)
+	assert.Equal(t, MustParse("0.3"), sum)
+	assert.Equal(t, 0, sum.Cmp(MustParse("0.3")))
+
+	diff, err := MustParse("1").Sub(MustParse("1.0001"))
+	assert.NoError(t, err)
+	assert.Equal(t, "-0.0001", diff.String())
+	assert.Equal(t, -1, diff.Sign())
+
+	// Products are rounded half away from zero to four places
+	product, err := MustParse("1000000").Mul(MustParse("0.125"))
+	assert.NoError(t, err)
+	assert.Equal(t, "125000", product.String())
+	product, err = MustParse("0.0001").Mul(MustParse("0.5"))
+	assert.NoError(t, err)
+	assert.Equal(t, "0.0001", product.String())
+	product, err = MustParse("-0.0001").Mul(MustParse("0.5"))
+	assert.NoError(t, err)
+	assert.Equal(t, "-0.0001", product.String())
+
+	quotient, err := MustParse("100").Div(MustParse("3"))
+	assert.NoError(t, err)
+	assert.Equal(t, "33.3333", quotient.String())
+	_, err = MustParse("1").Div(Zero)
+	assert.Error(t, err)
+
+	largest := MustParse("922337203685477.5807")
+	_, err = largest.Add(MustParse("0.0001"))
+	assert.ErrorIs(t, err, ErrOverflow)
+	_, err = largest.Mul(MustParse("2"))
+	assert.ErrorIs(t, err, ErrOverflow)
+}
+
+func TestNewFromFloat(t *testing.T) {
+	d, err := NewFromFloat(1234.56785)
+	assert.NoError(t, err)
+	assert.Equal(t, "1234.5679", d.String())
+
+	// 2^63 units is one past the largest decimal, -2^63 units is the smallest
+	_, err = NewFromFloat(math.Ldexp(1, 63) / unit)
+	assert.ErrorIs(t, err, ErrOverflow)
+	d, err = NewFromFloat(-math.Ldexp(1, 63) / unit)
+	assert.NoError(t, err)
+	assert.Equal(t, Decimal{units: math.MinInt64}, d)
+
+	_, err = NewFromFloat(math.NaN())
+	assert.ErrorIs(t, err, ErrOverflow)
+	_, err = NewFromFloat(math.Inf(1))
+	assert.ErrorIs(t, err, ErrOverflow)
+}
+
+func TestDecimal_JSON(t *testing.T) {
+	data, err := json.Marshal(MustParse("1000000.5"))
+	assert.NoError(t, err)
+	assert.Equal(t, `"1000000.5"`, string(data))
+
+	var payload struct {
+		Quoted Decimal `json:"quoted"`
+		Bare   Decimal `json:"bare"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(`{"quoted":"0.1","bare":0.2}`), &payload))
+	assert.Equal(t, MustParse("0.1"), payload.Quoted)
+	assert.Equal(t, MustParse("0.2"), payload.Bare)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"quoted":"abc"}`), &payload))
+}
+
+func TestDecimal_Scan(t *testing.T) {
+	tests := []struct {
+		name string
+		src  any
+		want string
+	}{
+		{name: "Text", src: "12.5000", want: "12.5"},
+		{name: "Bytes", src: []byte("0.3"), want: "0.3"},
+		{name: "Integer", src: int64(42), want: "42"},
+		{name: "Real", src: 0.1, want: "0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d Decimal
+			assert.NoError(t, d.Scan(tt.src))
+			assert.Equal(t, tt.want, d.String())
+
+			value, err := d.Value()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, value)
+		})
+	}
+
+	var d Decimal
+	assert.Error(t, d.Scan(true))
+}
@@ -0,0 +1,99 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DefaultCurrency is the ISO 4217 code used when none is given
+const DefaultCurrency = "IDR"
+
+// ErrCurrencyMismatch is returned when two amounts in different currencies are combined
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+// Money is an exact amount in a single currency
+type Money struct {
+	Amount   Decimal `json:"amount" swaggertype:"string" example:"1000000"`
+	Currency string  `json:"currency" example:"IDR"`
+}
+
+// New returns an amount in the given currency
+func New(amount Decimal, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// MustNew parses amount and returns it in the given currency. It panics on error and is meant for constants and tests.
+func MustNew(amount, currency string) Money {
+	return New(MustParse(amount), currency)
+}
+
+// ZeroOf returns a zero amount in the given currency
+func ZeroOf(currency string) Money {
+	return Money{Currency: currency}
+}
+
+func (m Money) checkCurrency(o Money) error {
+	if m.Currency != o.Currency {
+		return fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return nil
+}
+
+// Add returns m + o. Both amounts must be in the same currency.
+func (m Money) Add(o Money) (Money, error) {
+	if err := m.checkCurrency(o); err != nil {
+		return Money{}, err
+	}
+	sum, err := m.Amount.Add(o.Amount)
+	if err != nil {
+		return Money{}, err
+	}
+	return New(sum, m.Currency), nil
+}
+
+// Sub returns m - o. Both amounts must be in the same currency.
+func (m Money) Sub(o Money) (Money, error) {
+	if err := m.checkCurrency(o); err != nil {
+		return Money{}, err
+	}
+	diff, err := m.Amount.Sub(o.Amount)
+	if err != nil {
+		return Money{}, err
+	}
+	return New(diff, m.Currency), nil
+}
+
+// Cmp compares m with o, see Decimal.Cmp. Both amounts must be in the same currency.
+func (m Money) Cmp(o Money) (int, error) {
+	if err := m.checkCurrency(o); err != nil {
+		return 0, err
+	}
+	return m.Amount.Cmp(o.Amount), nil
+}
+
+// IsZero reports whether the amount is 0
+func (m Money) IsZero() bool {
+	return m.Amount.IsZero()
+}
+
+// IsPositive reports whether the amount is greater than 0
+func (m Money) IsPositive() bool {
+	return m.Amount.IsPositive()
+}
+
+// String formats the amount followed by its currency, e.g. "1000000 IDR"
+func (m Money) String() string {
+	return m.Amount.String() + " " + m.Currency
+}
+
+// Sum adds up amounts in the given currency. An empty list sums to zero.
+func Sum(currency string, amounts ...Money) (Money, error) {
+	total := ZeroOf(currency)
+	for _, amount := range amounts {
+		var err error
+		if total, err = total.Add(amount); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
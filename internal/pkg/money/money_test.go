@@ -0,0 +1,52 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMoney_Add(t *testing.T) {
+	sum, err := MustNew("0.1", "IDR").Add(MustNew("0.2", "IDR"))
+	assert.NoError(t, err)
+	assert.Equal(t, MustNew("0.3", "IDR"), sum)
+
+	_, err = MustNew("1", "IDR").Add(MustNew("1", "USD"))
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+	_, err = MustNew("1", "IDR").Sub(MustNew("1", "USD"))
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+}
+
+func TestMoney_Cmp(t *testing.T) {
+	cmp, err := MustNew("10", "IDR").Cmp(MustNew("9.9999", "IDR"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, cmp)
+
+	_, err = MustNew("10", "IDR").Cmp(MustNew("10", "USD"))
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+}
+
+func TestSum(t *testing.T) {
+	total, err := Sum("IDR")
+	assert.NoError(t, err)
+	assert.True(t, total.IsZero())
+	assert.Equal(t, "IDR", total.Currency)
+
+	total, err = Sum("IDR", MustNew("0.1", "IDR"), MustNew("0.2", "IDR"), MustNew("0.7", "IDR"))
+	assert.NoError(t, err)
+	assert.Equal(t, "1 IDR", total.String())
+
+	_, err = Sum("IDR", MustNew("0.1", "IDR"), MustNew("0.2", "USD"))
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+}
+
+func TestMoney_JSON(t *testing.T) {
+	data, err := json.Marshal(MustNew("1500.25", "IDR"))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"amount":"1500.25","currency":"IDR"}`, string(data))
+
+	var m Money
+	assert.NoError(t, json.Unmarshal(data, &m))
+	assert.Equal(t, MustNew("1500.25", "IDR"), m)
+}
@@ -0,0 +1,261 @@
+// Package pdf writes simple text documents as PDF without external tools. Text is set in the
+// standard Helvetica fonts, which every PDF reader provides, so no font is embedded.
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Page geometry of A4 portrait in points
+const (
+	PageWidth  = 595.0
+	PageHeight = 842.0
+	Margin     = 56.0
+)
+
+// Style is the font a line is set in
+type Style struct {
+	Bold bool
+	Size float64
+	// Leading is the distance from one baseline to the next
+	Leading float64
+}
+
+// Styles of the document blocks
+var (
+	TitleStyle     = Style{Bold: true, Size: 16, Leading: 22}
+	HeadingStyle   = Style{Bold: true, Size: 12, Leading: 18}
+	BodyStyle      = Style{Size: 10, Leading: 14}
+	paragraphSpace = 8.0
+)
+
+// Document lays out blocks of text on A4 pages from top to bottom, starting a new page when one is full.
+// The output only depends on the content, so the same content always gives the same bytes.
+type Document struct {
+	title string
+	pages []*bytes.Buffer
+	// y is the baseline of the next line on the last page
+	y float64
+}
+
+// New creates an empty document, title is recorded in the document information
+func New(title string) *Document {
+	d := &Document{title: title}
+	d.newPage()
+	return d
+}
+
+// Title adds the title block
+func (d *Document) Title(text string) {
+	d.block(text, TitleStyle)
+}
+
+// Heading adds a section heading
+func (d *Document) Heading(text string) {
+	d.block(text, HeadingStyle)
+}
+
+// Paragraph adds body text wrapped to the page width
+func (d *Document) Paragraph(text string) {
+	d.block(text, BodyStyle)
+}
+
+// Space adds the gap between two paragraphs
+func (d *Document) Space() {
+	d.y -= paragraphSpace
+}
+
+// PageCount is the number of pages laid out so far
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+// block wraps the text and sets every line of it, breaking the page where needed
+func (d *Document) block(text string, style Style) {
+	for _, line := range Wrap(text, style, PageWidth-2*Margin) {
+		if d.y-style.Leading < Margin {
+			d.newPage()
+		}
+		d.y -= style.Leading
+		font := "F1"
+		if style.Bold {
+			font = "F2"
+		}
+		fmt.Fprintf(d.pages[len(d.pages)-1], "BT /%s %s Tf %s %s Td (%s) Tj ET\n",
+			font, number(style.Size), number(Margin), number(d.y), escape(encode(line)))
+	}
+}
+
+func (d *Document) newPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = PageHeight - Margin
+}
+
+// Bytes writes the document as a PDF file
+func (d *Document) Bytes() []byte {
+	var (
+		out     bytes.Buffer
+		offsets []int
+	)
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1 to 4 are the catalog, page tree, fonts and document information; every page
+	// then takes two objects, the page and its content stream
+	const firstPage = 5
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /F1 << /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>" +
+		" /F2 << /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >> >>")
+	object(fmt.Sprintf("<< /Title (%s) /Producer (los-technical) >>", escape(encode(d.title))))
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font 3 0 R >> /Contents %d 0 R >>",
+			number(PageWidth), number(PageHeight), firstPage+2*i+1))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 4 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes()
+}
+
+// ErrNotPDF is returned when a document has no trailer to append to
+var ErrNotPDF = errors.New("not a PDF document")
+
+var (
+	sizePattern      = regexp.MustCompile(`/Size (\d+)`)
+	rootPattern      = regexp.MustCompile(`/Root (\d+ \d+ R)`)
+	startxrefPattern = regexp.MustCompile(`startxref\s+(\d+)`)
+)
+
+// Append adds an object with the given body to the end of a PDF as an incremental update. The original
+// bytes are kept as they are, so a checksum of them still holds for the updated document.
+func Append(document []byte, body string) ([]byte, error) {
+	size, root, prev := lastMatch(sizePattern, document), lastMatch(rootPattern, document), lastMatch(startxrefPattern, document)
+	if size == "" || root == "" || prev == "" {
+		return nil, ErrNotPDF
+	}
+	var number int
+	if _, err := fmt.Sscan(size, &number); err != nil {
+		return nil, ErrNotPDF
+	}
+
+	out := bytes.NewBuffer(append([]byte(nil), document...))
+	if !bytes.HasSuffix(document, []byte("\n")) {
+		out.WriteByte('\n')
+	}
+	offset := out.Len()
+	fmt.Fprintf(out, "%d 0 obj\n%s\nendobj\n", number, body)
+	xref := out.Len()
+	fmt.Fprintf(out, "xref\n%d 1\n%010d 00000 n \ntrailer\n<< /Size %d /Root %s /Prev %s >>\nstartxref\n%d\n%%%%EOF\n",
+		number, offset, number+1, root, prev, xref)
+	return out.Bytes(), nil
+}
+
+// lastMatch returns the first group of the last match of pattern in document
+func lastMatch(pattern *regexp.Regexp, document []byte) string {
+	matches := pattern.FindAllSubmatch(document, -1)
+	if len(matches) == 0 {
+		return ""
+	}
+	return string(matches[len(matches)-1][1])
+}
+
+// Literal quotes text as a PDF string literal
+func Literal(text string) string {
+	return "(" + escape(encode(text)) + ")"
+}
+
+// Wrap breaks text into lines no wider than width when set in style, at spaces where possible.
+// Line breaks in the text are kept.
+func Wrap(text string, style Style, width float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if line == "" || TextWidth(candidate, style) <= width {
+				line = candidate
+				continue
+			}
+			lines = append(lines, line)
+			line = word
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// TextWidth is the width of text in points when set in style
+func TextWidth(text string, style Style) float64 {
+	units := 0
+	for _, r := range text {
+		if r >= 32 && r < 127 {
+			units += helveticaWidths[r-32]
+		} else {
+			units += 556
+		}
+	}
+	width := float64(units) * style.Size / 1000
+	if style.Bold {
+		// Helvetica-Bold is about a tenth wider than Helvetica
+		width *= 1.1
+	}
+	return width
+}
+
+// helveticaWidths are the advance widths of Helvetica for the printable ASCII characters, in 1/1000 em
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// encode converts text to WinAnsiEncoding, characters it cannot represent become '?'
+func encode(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r >= 32 && r < 127, r >= 0xA0 && r <= 0xFF:
+			b.WriteByte(byte(r))
+		case r == '\t':
+			b.WriteByte(' ')
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// escape quotes the delimiters of a PDF string literal
+func escape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`).Replace(text)
+}
+
+// number formats a coordinate with at most two decimals
+func number(f float64) string {
+	s := strings.TrimRight(fmt.Sprintf("%.2f", f), "0")
+	return strings.TrimSuffix(s, ".")
+}
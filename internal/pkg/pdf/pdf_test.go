@@ -0,0 +1,99 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocument_Bytes(t *testing.T) {
+	d := New("Loan (agreement)")
+	d.Title("Loan Agreement")
+	d.Paragraph(`Principal: 1.000.000 IDR (rate 12%) \ paid monthly`)
+	out := d.Bytes()
+
+	assert.True(t, bytes.HasPrefix(out, []byte("%PDF-1.4\n")))
+	assert.True(t, bytes.HasSuffix(out, []byte("%%EOF\n")))
+	assert.Contains(t, string(out), `/Title (Loan \(agreement\))`)
+	assert.Contains(t, string(out), `(Principal: 1.000.000 IDR \(rate 12%\) \\ paid monthly) Tj`)
+
+	// Every cross-reference entry points at the object it numbers
+	start := bytes.LastIndex(out, []byte("startxref\n"))
+	xref, err := strconv.Atoi(strings.Fields(string(out[start:]))[1])
+	assert.NoError(t, err)
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(string(out[xref:]), -1)
+	if assert.Len(t, entries, 6) {
+		for i, entry := range entries {
+			offset, _ := strconv.Atoi(entry[1])
+			assert.True(t, bytes.HasPrefix(out[offset:], []byte(fmt.Sprintf("%d 0 obj", i+1))), "object %d", i+1)
+		}
+	}
+
+	assert.Equal(t, out, d.Bytes(), "the same content gives the same bytes")
+}
+
+func TestDocument_PageBreaks(t *testing.T) {
+	d := New("Long")
+	for i := 0; i < 150; i++ {
+		d.Paragraph(fmt.Sprintf("Line %d", i))
+	}
+	assert.Equal(t, 3, d.PageCount())
+	assert.Contains(t, string(d.Bytes()), "/Count 3")
+}
+
+func TestAppend(t *testing.T) {
+	d := New("Agreement")
+	d.Paragraph("Signed below")
+	original := d.Bytes()
+
+	updated, err := Append(original, "<< /Type /Sig /Name "+Literal("Budi (borrower)")+" >>")
+	assert.NoError(t, err)
+	assert.Equal(t, original, updated[:len(original)], "the original bytes are kept")
+	assert.True(t, bytes.HasSuffix(updated, []byte("%%EOF\n")))
+	assert.Contains(t, string(updated[len(original):]), "7 0 obj\n<< /Type /Sig /Name (Budi \\(borrower\\)) >>")
+	assert.Contains(t, string(updated), "/Size 8 /Root 1 0 R /Prev ")
+
+	// The new cross-reference section points at the appended object
+	start := bytes.LastIndex(updated, []byte("startxref\n"))
+	xref, err := strconv.Atoi(strings.Fields(string(updated[start:]))[1])
+	assert.NoError(t, err)
+	entry := regexp.MustCompile(`(\d{10}) 00000 n `).FindStringSubmatch(string(updated[xref:]))
+	offset, _ := strconv.Atoi(entry[1])
+	assert.True(t, bytes.HasPrefix(updated[offset:], []byte("7 0 obj")))
+
+	// Updates can be stacked
+	again, err := Append(updated, "<< /Type /Sig >>")
+	assert.NoError(t, err)
+	assert.Contains(t, string(again[len(updated):]), "8 0 obj")
+
+	_, err = Append([]byte("plain text"), "<< >>")
+	assert.ErrorIs(t, err, ErrNotPDF)
+}
+
+func TestWrap(t *testing.T) {
+	testCases := []struct {
+		name  string
+		text  string
+		width float64
+		want  []string
+	}{
+		{name: "Fits on one line", text: "Loan agreement", width: 200, want: []string{"Loan agreement"}},
+		{name: "Breaks at spaces", text: "one two three", width: 40, want: []string{"one two", "three"}},
+		{name: "Keeps line breaks", text: "one\n\ntwo", width: 200, want: []string{"one", "", "two"}},
+		{name: "Long word overflows", text: "supercalifragilistic", width: 10, want: []string{"supercalifragilistic"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, Wrap(tc.text, BodyStyle, tc.width))
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	assert.Equal(t, "Caf\xe9 ? ok", encode("Café € ok"))
+}
@@ -1,11 +1,17 @@
 package utils
 
-import "github.com/go-playground/validator/v10"
+import (
+	"reflect"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/hinha/los-technical/internal/pkg/money"
+)
 
 // ValidateLoanState is a custom validator function to check if a loan is in the required state
 func ValidateLoanState(fl validator.FieldLevel) bool {
 	state := fl.Field().String()
-	validStates := []string{"PROPOSED", "APPROVED", "INVESTED", "DISBURSED"}
+	validStates := []string{"PROPOSED", "APPROVED", "INVESTED", "DISBURSED", "REPAID", "DEFAULTED", "REJECTED", "CANCELLED"}
 
 	for _, validState := range validStates {
 		if state == validState {
@@ -15,3 +21,16 @@ func ValidateLoanState(fl validator.FieldLevel) bool {
 
 	return false
 }
+
+// ValidateCurrency is a custom validator function to check if a currency code is supported
+func ValidateCurrency(fl validator.FieldLevel) bool {
+	return money.IsSupportedCurrency(fl.Field().String())
+}
+
+// DecimalValue is a custom type function that lets tags such as gt=0 validate money.Decimal fields
+func DecimalValue(field reflect.Value) interface{} {
+	if d, ok := field.Interface().(money.Decimal); ok {
+		return d.Float64()
+	}
+	return nil
+}
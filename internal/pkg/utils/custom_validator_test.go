@@ -16,6 +16,10 @@ func TestValidateLoanState(t *testing.T) {
 		{"valid approved state", "APPROVED", true},
 		{"valid invested state", "INVESTED", true},
 		{"valid disbursed state", "DISBURSED", true},
+		{"valid repaid state", "REPAID", true},
+		{"valid defaulted state", "DEFAULTED", true},
+		{"valid rejected state", "REJECTED", true},
+		{"valid cancelled state", "CANCELLED", true},
 		{"invalid state", "invalid", false},
 		{"empty state", "", false},
 	}
@@ -36,6 +40,29 @@ func TestValidateLoanState(t *testing.T) {
 	}
 }
 
+func TestValidateCurrency(t *testing.T) {
+	tests := []struct {
+		name     string
+		currency string
+		want     bool
+	}{
+		{"rupiah", "IDR", true},
+		{"us dollar", "USD", true},
+		{"unsupported currency", "EUR", false},
+		{"lowercase code", "idr", false},
+		{"empty currency", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ValidateCurrency(getMockFieldLevel(tt.currency))
+			if got != tt.want {
+				t.Errorf("ValidateCurrency() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func getMockFieldLevel(value string) validator.FieldLevel {
 	return mockFieldLevel{value: value}
 }
@@ -0,0 +1,220 @@
+package loan
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	"github.com/hinha/los-technical/internal/pkg/utils"
+)
+
+// BorrowerLocks serializes the work on the loans of each borrower: applying for a loan and deleting the
+// borrower, who could otherwise be deleted while their application is created
+type BorrowerLocks struct {
+	locks keyedMutex
+}
+
+// NewBorrowerLocks creates the locks the LoanService and BorrowerService share, see WithBorrowerLocks
+func NewBorrowerLocks() *BorrowerLocks {
+	return &BorrowerLocks{}
+}
+
+// lock locks the borrower and returns the function that unlocks them
+func (b *BorrowerLocks) lock(borrowerID string) func() {
+	return b.locks.Lock(borrowerID)
+}
+
+// BorrowerService registers borrowers and computes their credit profile from their income, obligations
+// and the loans they have applied for
+type BorrowerService struct {
+	borrowers domain.BorrowerRepository
+	loans     domain.LoanRepository
+	// locks are shared with the LoanService, so a borrower is not deleted while applying for a loan
+	locks  *BorrowerLocks
+	logger *logrus.Logger
+}
+
+// NewBorrowerService creates a borrower service over the borrower and loan repositories. locks must be the
+// ones the LoanService applies for loans with.
+func NewBorrowerService(borrowers domain.BorrowerRepository, loans domain.LoanRepository, locks *BorrowerLocks, logger *logrus.Logger) domain.BorrowerService {
+	return &BorrowerService{
+		borrowers: borrowers,
+		loans:     loans,
+		locks:     locks,
+		logger:    logger,
+	}
+}
+
+// RegisterBorrower stores a new borrower. An ID is generated when none is given.
+func (s *BorrowerService) RegisterBorrower(borrower domain.Borrower) (*domain.Borrower, error) {
+	s.logger.WithFields(logrus.Fields{
+		"layer":       "service",
+		"function":    "RegisterBorrower",
+		"borrower_id": borrower.ID,
+	}).Info("Registering borrower")
+
+	if borrower.ID == "" {
+		borrower.ID = utils.GenerateUUID()
+	}
+	now := time.Now()
+	normalizeBorrower(&borrower)
+	borrower.CreatedAt = now
+	borrower.UpdatedAt = now
+	if err := borrower.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.borrowers.Save(&borrower); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":       "service",
+			"function":    "RegisterBorrower",
+			"borrower_id": borrower.ID,
+			"error":       err.Error(),
+		}).Error("Failed to save borrower")
+		return nil, fmt.Errorf("failed to save borrower: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"layer":       "service",
+		"function":    "RegisterBorrower",
+		"borrower_id": borrower.ID,
+	}).Info("Borrower registered successfully")
+	return &borrower, nil
+}
+
+// GetBorrower retrieves a borrower with their credit profile and every loan they have applied for
+func (s *BorrowerService) GetBorrower(id string) (*domain.BorrowerDetails, error) {
+	s.logger.WithFields(logrus.Fields{
+		"layer":       "service",
+		"function":    "GetBorrower",
+		"borrower_id": id,
+	}).Info("Getting borrower")
+
+	borrower, err := s.borrowers.FindByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find borrower: %w", err)
+	}
+
+	loans, err := s.loans.FindByBorrowerID(id)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":       "service",
+			"function":    "GetBorrower",
+			"borrower_id": id,
+			"error":       err.Error(),
+		}).Error("Failed to find loans of borrower")
+		return nil, fmt.Errorf("failed to find loans of borrower: %w", err)
+	}
+	if loans == nil {
+		loans = []*domain.Loan{}
+	}
+
+	profile, err := domain.NewCreditProfile(borrower, loans)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.BorrowerDetails{Borrower: *borrower, CreditProfile: *profile, Loans: loans}, nil
+}
+
+// GetBorrowers lists the borrowers with pagination
+func (s *BorrowerService) GetBorrowers(page, limit int) ([]*domain.Borrower, error) {
+	s.logger.WithFields(logrus.Fields{
+		"layer":    "service",
+		"function": "GetBorrowers",
+		"page":     page,
+		"limit":    limit,
+	}).Info("Getting borrowers")
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+
+	borrowers, err := s.borrowers.FindAll(page, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find borrowers: %w", err)
+	}
+	return borrowers, nil
+}
+
+// UpdateBorrower replaces the identity, income, obligations and blacklisting of a borrower
+func (s *BorrowerService) UpdateBorrower(borrower domain.Borrower) (*domain.Borrower, error) {
+	s.logger.WithFields(logrus.Fields{
+		"layer":       "service",
+		"function":    "UpdateBorrower",
+		"borrower_id": borrower.ID,
+	}).Info("Updating borrower")
+
+	stored, err := s.borrowers.FindByID(borrower.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find borrower: %w", err)
+	}
+
+	normalizeBorrower(&borrower)
+	borrower.CreatedAt = stored.CreatedAt
+	borrower.UpdatedAt = time.Now()
+	if err := borrower.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.borrowers.Update(&borrower); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":       "service",
+			"function":    "UpdateBorrower",
+			"borrower_id": borrower.ID,
+			"error":       err.Error(),
+		}).Error("Failed to update borrower")
+		return nil, fmt.Errorf("failed to update borrower: %w", err)
+	}
+	return &borrower, nil
+}
+
+// DeleteBorrower removes a borrower who has not applied for any loan, their loans would otherwise lose
+// their borrower
+func (s *BorrowerService) DeleteBorrower(id string) error {
+	s.logger.WithFields(logrus.Fields{
+		"layer":       "service",
+		"function":    "DeleteBorrower",
+		"borrower_id": id,
+	}).Info("Deleting borrower")
+
+	// An application of the borrower either creates its loan before the check or finds the borrower deleted
+	unlock := s.locks.lock(id)
+	defer unlock()
+	if _, err := s.borrowers.FindByID(id); err != nil {
+		return fmt.Errorf("failed to find borrower: %w", err)
+	}
+	loans, err := s.loans.FindByBorrowerID(id)
+	if err != nil {
+		return fmt.Errorf("failed to find loans of borrower: %w", err)
+	}
+	if len(loans) > 0 {
+		return fmt.Errorf("%w: borrower %s has %d loans", domain.ErrBorrowerHasLoans, id, len(loans))
+	}
+
+	if err := s.borrowers.Delete(id); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":       "service",
+			"function":    "DeleteBorrower",
+			"borrower_id": id,
+			"error":       err.Error(),
+		}).Error("Failed to delete borrower")
+		return fmt.Errorf("failed to delete borrower: %w", err)
+	}
+	return nil
+}
+
+// normalizeBorrower trims the identity of the borrower and clears the reason of a borrower who is not blacklisted
+func normalizeBorrower(borrower *domain.Borrower) {
+	borrower.Name = strings.TrimSpace(borrower.Name)
+	borrower.NationalID = strings.TrimSpace(borrower.NationalID)
+	borrower.BlacklistReason = strings.TrimSpace(borrower.BlacklistReason)
+	if !borrower.Blacklisted {
+		borrower.BlacklistReason = ""
+	}
+}
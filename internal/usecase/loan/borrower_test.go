@@ -0,0 +1,305 @@
+package loan
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	mock "github.com/hinha/los-technical/internal/domain/loan/mock"
+	"github.com/hinha/los-technical/internal/infrastructure/eventstore"
+	loanRepo "github.com/hinha/los-technical/internal/infrastructure/repository/loan"
+	"github.com/hinha/los-technical/internal/pkg/money"
+)
+
+// newBorrower returns a valid borrower earning 10,000,000 and owing 1,500,000 each month
+func newBorrower(id string) domain.Borrower {
+	return domain.Borrower{
+		ID:                 id,
+		Name:               "Siti",
+		NationalID:         "3171234567890001",
+		Email:              "siti@example.com",
+		MonthlyIncome:      money.MustNew("10000000", money.DefaultCurrency),
+		MonthlyObligations: money.MustNew("1500000", money.DefaultCurrency),
+	}
+}
+
+func TestRegisterBorrower(t *testing.T) {
+	blacklisted := newBorrower("borrower-1")
+	blacklisted.Blacklisted = true
+	negative := newBorrower("borrower-1")
+	negative.MonthlyIncome = money.MustNew("-1", money.DefaultCurrency)
+	anonymous := newBorrower("")
+	untrimmed := newBorrower("borrower-1")
+	untrimmed.Name = " Siti "
+	untrimmed.BlacklistReason = "fraud"
+
+	tests := []struct {
+		name      string
+		borrower  domain.Borrower
+		mockSetup func(*mock.MockBorrowerRepository)
+		errorIs   error
+	}{
+		{
+			name:     "Success",
+			borrower: untrimmed,
+			mockSetup: func(repo *mock.MockBorrowerRepository) {
+				repo.EXPECT().Save(gomock.Any()).DoAndReturn(func(borrower *domain.Borrower) error {
+					assert.Equal(t, "Siti", borrower.Name)
+					// The reason of a borrower who is not blacklisted is cleared
+					assert.Empty(t, borrower.BlacklistReason)
+					assert.False(t, borrower.CreatedAt.IsZero())
+					return nil
+				})
+			},
+		},
+		{
+			name:     "Success - ID Generated",
+			borrower: anonymous,
+			mockSetup: func(repo *mock.MockBorrowerRepository) {
+				repo.EXPECT().Save(gomock.Any()).DoAndReturn(func(borrower *domain.Borrower) error {
+					assert.NotEmpty(t, borrower.ID)
+					return nil
+				})
+			},
+		},
+		{
+			name:      "Negative Income",
+			borrower:  negative,
+			mockSetup: func(repo *mock.MockBorrowerRepository) {},
+			errorIs:   domain.ErrInvalidBorrower,
+		},
+		{
+			name:      "Blacklisted Without Reason",
+			borrower:  blacklisted,
+			mockSetup: func(repo *mock.MockBorrowerRepository) {},
+			errorIs:   domain.ErrInvalidBorrower,
+		},
+		{
+			name:     "Already Registered",
+			borrower: newBorrower("borrower-1"),
+			mockSetup: func(repo *mock.MockBorrowerRepository) {
+				repo.EXPECT().Save(gomock.Any()).Return(domain.ErrBorrowerAlreadyExists)
+			},
+			errorIs: domain.ErrBorrowerAlreadyExists,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock.NewMockBorrowerRepository(ctrl)
+			tc.mockSetup(repo)
+			service := NewBorrowerService(repo, nil, NewBorrowerLocks(), logrus.New())
+
+			borrower, err := service.RegisterBorrower(tc.borrower)
+			if tc.errorIs != nil {
+				assert.ErrorIs(t, err, tc.errorIs)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, borrower.CreatedAt, borrower.UpdatedAt)
+		})
+	}
+}
+
+func TestUpdateBorrower(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	createdAt := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	stored := newBorrower("borrower-1")
+	stored.CreatedAt = createdAt
+	repo := mock.NewMockBorrowerRepository(ctrl)
+	repo.EXPECT().FindByID("borrower-1").Return(&stored, nil)
+	repo.EXPECT().FindByID("borrower-2").Return(nil, domain.ErrBorrowerNotFound)
+	repo.EXPECT().Update(gomock.Any()).Return(nil)
+	service := NewBorrowerService(repo, nil, NewBorrowerLocks(), logrus.New())
+
+	update := newBorrower("borrower-1")
+	update.Blacklisted = true
+	update.BlacklistReason = "fraud"
+	borrower, err := service.UpdateBorrower(update)
+	assert.NoError(t, err)
+	assert.True(t, borrower.Blacklisted)
+	assert.Equal(t, createdAt, borrower.CreatedAt)
+	assert.True(t, borrower.UpdatedAt.After(createdAt))
+
+	_, err = service.UpdateBorrower(newBorrower("borrower-2"))
+	assert.ErrorIs(t, err, domain.ErrBorrowerNotFound)
+}
+
+func TestGetBorrower(t *testing.T) {
+	t.Run("Computes the credit profile from the loans", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		stored := newBorrower("borrower-1")
+		borrowers := mock.NewMockBorrowerRepository(ctrl)
+		borrowers.EXPECT().FindByID("borrower-1").Return(&stored, nil)
+		loans := mock.NewMockLoanRepository(ctrl)
+		loans.EXPECT().FindByBorrowerID("borrower-1").Return([]*domain.Loan{
+			{
+				ID:    "loan-1",
+				State: domain.StateDisbursed,
+				Schedule: []domain.Installment{
+					{Number: 1, Total: money.MustNew("500000", money.DefaultCurrency)},
+				},
+			},
+			{ID: "loan-2", State: domain.StateRepaid},
+		}, nil)
+		service := NewBorrowerService(borrowers, loans, NewBorrowerLocks(), logrus.New())
+
+		details, err := service.GetBorrower("borrower-1")
+		assert.NoError(t, err)
+		assert.Equal(t, "borrower-1", details.ID)
+		assert.Len(t, details.Loans, 2)
+		assert.Equal(t, 1, details.CreditProfile.ActiveLoans)
+		assert.Equal(t, 1, details.CreditProfile.RepaidLoans)
+		assert.Equal(t, money.MustNew("2000000", money.DefaultCurrency), details.CreditProfile.MonthlyDebt)
+		dti := money.MustParse("20")
+		assert.Equal(t, &dti, details.CreditProfile.DebtToIncome)
+	})
+
+	t.Run("No loans", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		stored := newBorrower("borrower-1")
+		borrowers := mock.NewMockBorrowerRepository(ctrl)
+		borrowers.EXPECT().FindByID("borrower-1").Return(&stored, nil)
+		loans := mock.NewMockLoanRepository(ctrl)
+		loans.EXPECT().FindByBorrowerID("borrower-1").Return(nil, nil)
+		service := NewBorrowerService(borrowers, loans, NewBorrowerLocks(), logrus.New())
+
+		details, err := service.GetBorrower("borrower-1")
+		assert.NoError(t, err)
+		assert.NotNil(t, details.Loans)
+		assert.Empty(t, details.Loans)
+	})
+
+	t.Run("Unknown borrower", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		borrowers := mock.NewMockBorrowerRepository(ctrl)
+		borrowers.EXPECT().FindByID("borrower-1").Return(nil, domain.ErrBorrowerNotFound)
+		service := NewBorrowerService(borrowers, mock.NewMockLoanRepository(ctrl), NewBorrowerLocks(), logrus.New())
+
+		_, err := service.GetBorrower("borrower-1")
+		assert.ErrorIs(t, err, domain.ErrBorrowerNotFound)
+	})
+
+	t.Run("Repository error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		stored := newBorrower("borrower-1")
+		borrowers := mock.NewMockBorrowerRepository(ctrl)
+		borrowers.EXPECT().FindByID("borrower-1").Return(&stored, nil)
+		loans := mock.NewMockLoanRepository(ctrl)
+		loans.EXPECT().FindByBorrowerID("borrower-1").Return(nil, errors.New("database error"))
+		service := NewBorrowerService(borrowers, loans, NewBorrowerLocks(), logrus.New())
+
+		_, err := service.GetBorrower("borrower-1")
+		assert.ErrorContains(t, err, "failed to find loans of borrower")
+	})
+}
+
+func TestDeleteBorrower(t *testing.T) {
+	tests := []struct {
+		name      string
+		loans     []*domain.Loan
+		mockSetup func(*mock.MockBorrowerRepository)
+		errorIs   error
+	}{
+		{
+			name: "Success",
+			mockSetup: func(repo *mock.MockBorrowerRepository) {
+				repo.EXPECT().Delete("borrower-1").Return(nil)
+			},
+		},
+		{
+			name:      "Has Loans",
+			loans:     []*domain.Loan{{ID: "loan-1", BorrowerID: "borrower-1", State: domain.StateRepaid}},
+			mockSetup: func(repo *mock.MockBorrowerRepository) {},
+			errorIs:   domain.ErrBorrowerHasLoans,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			stored := newBorrower("borrower-1")
+			borrowers := mock.NewMockBorrowerRepository(ctrl)
+			borrowers.EXPECT().FindByID("borrower-1").Return(&stored, nil)
+			tc.mockSetup(borrowers)
+			loans := mock.NewMockLoanRepository(ctrl)
+			loans.EXPECT().FindByBorrowerID("borrower-1").Return(tc.loans, nil)
+			service := NewBorrowerService(borrowers, loans, NewBorrowerLocks(), logrus.New())
+
+			err := service.DeleteBorrower("borrower-1")
+			if tc.errorIs != nil {
+				assert.ErrorIs(t, err, tc.errorIs)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestDeleteBorrower_WaitsForApplication(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logger := logrus.New()
+	borrowers := loanRepo.NewInMemoryBorrowerRepository(logger)
+	stored := newBorrower("borrower-1")
+	assert.NoError(t, borrowers.Save(&stored))
+	loans := loanRepo.NewInMemoryRepository(logger)
+
+	// The application is held while it is scored, after the borrower was checked and before the loan is stored
+	scoring := make(chan struct{})
+	release := make(chan struct{})
+	scorer := mock.NewMockCreditScorer(ctrl)
+	scorer.EXPECT().Score(gomock.Any()).DoAndReturn(func(domain.ScoringInput) (*domain.CreditScore, error) {
+		close(scoring)
+		<-release
+		return newTestCreditScore(domain.GradeB), nil
+	})
+
+	locks := NewBorrowerLocks()
+	loanService := NewLoanService(loans, nil, borrowers, eventstore.NewInMemoryEventStore(logger), newTestAuditLog(ctrl),
+		nil, nil, nil, scorer, newTestPricer(ctrl), logger, WithBorrowerLocks(locks))
+	borrowerService := NewBorrowerService(borrowers, loans, locks, logger)
+
+	created := make(chan error, 1)
+	go func() {
+		_, err := loanService.CreateLoan("borrower-1", money.MustNew("1000000", money.DefaultCurrency), nil, nil,
+			domain.RepaymentTerms{TenorMonths: 12, Scheme: domain.SchemeAnnuity})
+		created <- err
+	}()
+	<-scoring
+
+	deleted := make(chan error, 1)
+	go func() {
+		deleted <- borrowerService.DeleteBorrower("borrower-1")
+	}()
+	select {
+	case err := <-deleted:
+		t.Fatalf("borrower deleted while applying for a loan: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	assert.NoError(t, <-created)
+	assert.ErrorIs(t, <-deleted, domain.ErrBorrowerHasLoans)
+}
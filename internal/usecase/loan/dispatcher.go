@@ -0,0 +1,120 @@
+package loan
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+)
+
+const (
+	// DefaultMaxDeliveryAttempts is how often a notification is tried before it is dead-lettered
+	DefaultMaxDeliveryAttempts = 5
+	// DefaultRetryBackoff is the wait before the first retry, it doubles with every further attempt
+	DefaultRetryBackoff = time.Minute
+	// defaultDispatchBatch bounds how many messages one dispatch pass sends
+	defaultDispatchBatch = 100
+)
+
+// Dispatcher sends the notifications committed to the outbox through the notifier.
+// Failed sends are retried with exponential backoff and dead-lettered after the last attempt.
+type Dispatcher struct {
+	outbox   domain.Outbox
+	notifier domain.Notifier
+	logger   *logrus.Logger
+
+	maxAttempts int
+	backoff     time.Duration
+}
+
+// DispatcherOption configures optional Dispatcher settings
+type DispatcherOption func(*Dispatcher)
+
+// WithMaxDeliveryAttempts sets how often a notification is tried before it is dead-lettered
+func WithMaxDeliveryAttempts(attempts int) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.maxAttempts = attempts
+	}
+}
+
+// WithRetryBackoff sets the wait before the first retry of a failed notification
+func WithRetryBackoff(backoff time.Duration) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.backoff = backoff
+	}
+}
+
+// NewDispatcher creates a dispatcher of the outbox
+func NewDispatcher(outbox domain.Outbox, notifier domain.Notifier, logger *logrus.Logger, opts ...DispatcherOption) *Dispatcher {
+	d := &Dispatcher{
+		outbox:      outbox,
+		notifier:    notifier,
+		logger:      logger,
+		maxAttempts: DefaultMaxDeliveryAttempts,
+		backoff:     DefaultRetryBackoff,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Dispatch sends the messages due at now and stores how each delivery went. A failed send is recorded
+// on the message rather than returned, only a failing outbox stops the pass. It returns how many were sent.
+func (d *Dispatcher) Dispatch(now time.Time) (int, error) {
+	messages, err := d.outbox.FindDue(now, defaultDispatchBatch)
+	if err != nil {
+		d.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": "Dispatch",
+			"error":    err.Error(),
+		}).Error("Failed to find due outbox messages")
+		return 0, err
+	}
+
+	sent := 0
+	for _, message := range messages {
+		if err := d.notifier.Notify(message.Notification); err != nil {
+			message.MarkFailed(err, now, d.maxAttempts, d.backoff)
+			entry := d.logger.WithFields(logrus.Fields{
+				"layer":        "service",
+				"function":     "Dispatch",
+				"message_id":   message.ID,
+				"loan_id":      message.LoanID,
+				"kind":         message.Kind,
+				"recipient_id": message.Recipient.ID,
+				"attempts":     message.Attempts,
+				"error":        err.Error(),
+			})
+			if message.Status == domain.OutboxDead {
+				entry.Error("Notification dead-lettered")
+			} else {
+				entry.WithField("next_attempt_at", message.NextAttemptAt).Warn("Failed to send notification, retrying later")
+			}
+		} else {
+			message.MarkSent(now)
+			sent++
+		}
+
+		if err := d.outbox.Update(message); err != nil {
+			d.logger.WithFields(logrus.Fields{
+				"layer":      "service",
+				"function":   "Dispatch",
+				"message_id": message.ID,
+				"error":      err.Error(),
+			}).Error("Failed to update outbox message")
+			return sent, err
+		}
+	}
+
+	if len(messages) > 0 {
+		d.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": "Dispatch",
+			"due":      len(messages),
+			"sent":     sent,
+		}).Info("Outbox dispatched")
+	}
+	return sent, nil
+}
@@ -0,0 +1,151 @@
+package loan
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	mock "github.com/hinha/los-technical/internal/domain/loan/mock"
+	"github.com/hinha/los-technical/internal/pkg/money"
+)
+
+func TestDispatcher_Dispatch(t *testing.T) {
+	now := time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+	refundAmount := money.MustNew("300000", money.DefaultCurrency)
+	agreement := domain.OutboxMessage{
+		Notification: domain.Notification{
+			ID:        "message-1",
+			LoanID:    "loan-123",
+			Kind:      domain.NotificationAgreement,
+			Recipient: domain.Recipient{ID: "investor-1", Role: domain.RoleInvestor, Email: "one@example.com"},
+			Agreement: &domain.AgreementEmail{Email: "one@example.com", LoanID: "loan-123", AgreementURL: "http://example.com/agreement"},
+		},
+		Status: domain.OutboxPending,
+	}
+	refund := domain.OutboxMessage{
+		Notification: domain.Notification{
+			ID:        "message-2",
+			LoanID:    "loan-123",
+			Kind:      domain.NotificationRefund,
+			Recipient: domain.Recipient{ID: "investor-2", Role: domain.RoleInvestor, Email: "two@example.com"},
+			Amount:    &refundAmount,
+			Reason:    domain.ReasonFundingExpired,
+		},
+		Status: domain.OutboxPending,
+	}
+	lastAttempt := agreement
+	lastAttempt.Attempts = 2
+
+	testCases := []struct {
+		name         string
+		due          []domain.OutboxMessage
+		mockSetup    func(*mock.MockNotifier)
+		expectedSent int
+		// expected holds the delivery state stored for each due message
+		expected    []func(*testing.T, domain.OutboxMessage)
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name: "Sends every due message",
+			due:  []domain.OutboxMessage{agreement, refund},
+			mockSetup: func(notifier *mock.MockNotifier) {
+				notifier.EXPECT().Notify(agreement.Notification).Return(nil)
+				notifier.EXPECT().Notify(refund.Notification).Return(nil)
+			},
+			expectedSent: 2,
+			expected: []func(*testing.T, domain.OutboxMessage){
+				func(t *testing.T, m domain.OutboxMessage) {
+					assert.Equal(t, domain.OutboxSent, m.Status)
+					assert.Equal(t, now, *m.SentAt)
+				},
+				func(t *testing.T, m domain.OutboxMessage) {
+					assert.Equal(t, domain.OutboxSent, m.Status)
+				},
+			},
+		},
+		{
+			name: "A failed send is retried later without holding back the others",
+			due:  []domain.OutboxMessage{agreement, refund},
+			mockSetup: func(notifier *mock.MockNotifier) {
+				notifier.EXPECT().Notify(agreement.Notification).Return(errors.New("smtp down"))
+				notifier.EXPECT().Notify(refund.Notification).Return(nil)
+			},
+			expectedSent: 1,
+			expected: []func(*testing.T, domain.OutboxMessage){
+				func(t *testing.T, m domain.OutboxMessage) {
+					assert.Equal(t, domain.OutboxPending, m.Status)
+					assert.Equal(t, 1, m.Attempts)
+					assert.Equal(t, "smtp down", m.LastError)
+					assert.Equal(t, now.Add(time.Minute), m.NextAttemptAt)
+				},
+				func(t *testing.T, m domain.OutboxMessage) {
+					assert.Equal(t, domain.OutboxSent, m.Status)
+				},
+			},
+		},
+		{
+			name: "The last failed attempt dead-letters the message",
+			due:  []domain.OutboxMessage{lastAttempt},
+			mockSetup: func(notifier *mock.MockNotifier) {
+				notifier.EXPECT().Notify(gomock.Any()).Return(errors.New("mailbox full"))
+			},
+			expected: []func(*testing.T, domain.OutboxMessage){
+				func(t *testing.T, m domain.OutboxMessage) {
+					assert.Equal(t, domain.OutboxDead, m.Status)
+					assert.Equal(t, 3, m.Attempts)
+					assert.Equal(t, "mailbox full", m.LastError)
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockOutbox := mock.NewMockOutbox(ctrl)
+			mockNotifier := mock.NewMockNotifier(ctrl)
+			tc.mockSetup(mockNotifier)
+
+			mockOutbox.EXPECT().FindDue(now, defaultDispatchBatch).Return(tc.due, nil)
+			var updated []domain.OutboxMessage
+			mockOutbox.EXPECT().Update(gomock.Any()).DoAndReturn(func(m domain.OutboxMessage) error {
+				updated = append(updated, m)
+				return nil
+			}).Times(len(tc.due))
+
+			dispatcher := NewDispatcher(mockOutbox, mockNotifier, logrus.New(), WithMaxDeliveryAttempts(3))
+			sent, err := dispatcher.Dispatch(now)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedSent, sent)
+			if assert.Len(t, updated, len(tc.expected)) {
+				for i, check := range tc.expected {
+					check(t, updated[i])
+				}
+			}
+		})
+	}
+
+	t.Run("A failing outbox stops the pass", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockOutbox := mock.NewMockOutbox(ctrl)
+		mockNotifier := mock.NewMockNotifier(ctrl)
+		mockOutbox.EXPECT().FindDue(now, defaultDispatchBatch).Return([]domain.OutboxMessage{agreement, refund}, nil)
+		mockNotifier.EXPECT().Notify(gomock.Any()).Return(nil)
+		mockOutbox.EXPECT().Update(gomock.Any()).Return(errors.New("disk error"))
+
+		sent, err := NewDispatcher(mockOutbox, mockNotifier, logrus.New()).Dispatch(now)
+		assert.ErrorContains(t, err, "disk error")
+		assert.Equal(t, 1, sent)
+	})
+}
@@ -0,0 +1,178 @@
+package loan
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	"github.com/hinha/los-technical/internal/pkg/utils"
+)
+
+// InvestorService registers investors, records the outcome of their KYC review and builds their portfolios
+// from the loans they have invested in
+type InvestorService struct {
+	investors domain.InvestorRepository
+	loans     domain.LoanRepository
+	logger    *logrus.Logger
+}
+
+// NewInvestorService creates an investor service over the investor and loan repositories
+func NewInvestorService(investors domain.InvestorRepository, loans domain.LoanRepository, logger *logrus.Logger) domain.InvestorService {
+	return &InvestorService{
+		investors: investors,
+		loans:     loans,
+		logger:    logger,
+	}
+}
+
+// RegisterInvestor stores a new investor pending their KYC review. An ID is generated when none is given.
+func (s *InvestorService) RegisterInvestor(investor domain.InvestorProfile) (*domain.InvestorProfile, error) {
+	s.logger.WithFields(logrus.Fields{
+		"layer":       "service",
+		"function":    "RegisterInvestor",
+		"investor_id": investor.ID,
+	}).Info("Registering investor")
+
+	if investor.ID == "" {
+		investor.ID = utils.GenerateUUID()
+	}
+	now := time.Now()
+	investor.Name = strings.TrimSpace(investor.Name)
+	investor.KYCStatus = domain.KYCPending
+	investor.KYCReviewedAt = nil
+	investor.CreatedAt = now
+	investor.UpdatedAt = now
+	if err := investor.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.investors.Save(&investor); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":       "service",
+			"function":    "RegisterInvestor",
+			"investor_id": investor.ID,
+			"error":       err.Error(),
+		}).Error("Failed to save investor")
+		return nil, fmt.Errorf("failed to save investor: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"layer":       "service",
+		"function":    "RegisterInvestor",
+		"investor_id": investor.ID,
+	}).Info("Investor registered successfully")
+	return &investor, nil
+}
+
+// GetInvestor retrieves an investor
+func (s *InvestorService) GetInvestor(id string) (*domain.InvestorProfile, error) {
+	s.logger.WithFields(logrus.Fields{
+		"layer":       "service",
+		"function":    "GetInvestor",
+		"investor_id": id,
+	}).Info("Getting investor")
+
+	investor, err := s.investors.FindByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find investor: %w", err)
+	}
+	return investor, nil
+}
+
+// UpdateInvestor replaces the contact details and limits of an investor. Their KYC status is kept, it only
+// changes through SetKYCStatus.
+func (s *InvestorService) UpdateInvestor(investor domain.InvestorProfile) (*domain.InvestorProfile, error) {
+	s.logger.WithFields(logrus.Fields{
+		"layer":       "service",
+		"function":    "UpdateInvestor",
+		"investor_id": investor.ID,
+	}).Info("Updating investor")
+
+	stored, err := s.investors.FindByID(investor.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find investor: %w", err)
+	}
+
+	stored.Name = strings.TrimSpace(investor.Name)
+	stored.Email = investor.Email
+	stored.Phone = investor.Phone
+	stored.Locale = investor.Locale
+	stored.Limits = investor.Limits
+	stored.UpdatedAt = time.Now()
+	if err := stored.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.investors.Update(stored); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":       "service",
+			"function":    "UpdateInvestor",
+			"investor_id": investor.ID,
+			"error":       err.Error(),
+		}).Error("Failed to update investor")
+		return nil, fmt.Errorf("failed to update investor: %w", err)
+	}
+	return stored, nil
+}
+
+// SetKYCStatus records the outcome of the KYC review of an investor
+func (s *InvestorService) SetKYCStatus(id string, status domain.KYCStatus) (*domain.InvestorProfile, error) {
+	s.logger.WithFields(logrus.Fields{
+		"layer":       "service",
+		"function":    "SetKYCStatus",
+		"investor_id": id,
+		"kyc_status":  status,
+	}).Info("Setting KYC status of investor")
+
+	investor, err := s.investors.FindByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find investor: %w", err)
+	}
+
+	now := time.Now()
+	investor.KYCStatus = status
+	investor.KYCReviewedAt = &now
+	investor.UpdatedAt = now
+	if err := investor.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.investors.Update(investor); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":       "service",
+			"function":    "SetKYCStatus",
+			"investor_id": id,
+			"error":       err.Error(),
+		}).Error("Failed to update investor")
+		return nil, fmt.Errorf("failed to update investor: %w", err)
+	}
+	return investor, nil
+}
+
+// GetPortfolio lists every loan the investor has funded with what they committed and expect in return
+func (s *InvestorService) GetPortfolio(id string) (*domain.Portfolio, error) {
+	s.logger.WithFields(logrus.Fields{
+		"layer":       "service",
+		"function":    "GetPortfolio",
+		"investor_id": id,
+	}).Info("Getting portfolio of investor")
+
+	if _, err := s.investors.FindByID(id); err != nil {
+		return nil, fmt.Errorf("failed to find investor: %w", err)
+	}
+
+	loans, err := s.loans.FindByInvestorID(id)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":       "service",
+			"function":    "GetPortfolio",
+			"investor_id": id,
+			"error":       err.Error(),
+		}).Error("Failed to find loans of investor")
+		return nil, fmt.Errorf("failed to find loans of investor: %w", err)
+	}
+	return domain.NewPortfolio(id, loans)
+}
@@ -0,0 +1,204 @@
+package loan
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	mock "github.com/hinha/los-technical/internal/domain/loan/mock"
+	"github.com/hinha/los-technical/internal/pkg/money"
+)
+
+func TestRegisterInvestor(t *testing.T) {
+	negative := money.MustNew("-1", money.DefaultCurrency)
+	tests := []struct {
+		name      string
+		investor  domain.InvestorProfile
+		mockSetup func(*mock.MockInvestorRepository)
+		errorIs   error
+	}{
+		{
+			name:     "Success - Pending KYC",
+			investor: domain.InvestorProfile{ID: "investor-1", Name: " Budi ", Email: "budi@example.com", KYCStatus: domain.KYCVerified},
+			mockSetup: func(repo *mock.MockInvestorRepository) {
+				repo.EXPECT().Save(gomock.Any()).DoAndReturn(func(investor *domain.InvestorProfile) error {
+					assert.Equal(t, "Budi", investor.Name)
+					assert.Equal(t, domain.KYCPending, investor.KYCStatus)
+					assert.False(t, investor.CreatedAt.IsZero())
+					return nil
+				})
+			},
+		},
+		{
+			name:     "Success - ID Generated",
+			investor: domain.InvestorProfile{Name: "Budi", Email: "budi@example.com"},
+			mockSetup: func(repo *mock.MockInvestorRepository) {
+				repo.EXPECT().Save(gomock.Any()).DoAndReturn(func(investor *domain.InvestorProfile) error {
+					assert.NotEmpty(t, investor.ID)
+					return nil
+				})
+			},
+		},
+		{
+			name:      "Missing Email",
+			investor:  domain.InvestorProfile{ID: "investor-1", Name: "Budi"},
+			mockSetup: func(repo *mock.MockInvestorRepository) {},
+			errorIs:   domain.ErrInvalidInvestor,
+		},
+		{
+			name:      "Negative Limit",
+			investor:  domain.InvestorProfile{ID: "investor-1", Name: "Budi", Email: "budi@example.com", Limits: domain.InvestmentLimits{Total: &negative}},
+			mockSetup: func(repo *mock.MockInvestorRepository) {},
+			errorIs:   domain.ErrInvalidInvestor,
+		},
+		{
+			name:     "Already Registered",
+			investor: domain.InvestorProfile{ID: "investor-1", Name: "Budi", Email: "budi@example.com"},
+			mockSetup: func(repo *mock.MockInvestorRepository) {
+				repo.EXPECT().Save(gomock.Any()).Return(domain.ErrInvestorAlreadyExists)
+			},
+			errorIs: domain.ErrInvestorAlreadyExists,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock.NewMockInvestorRepository(ctrl)
+			tc.mockSetup(repo)
+			service := NewInvestorService(repo, nil, logrus.New())
+
+			investor, err := service.RegisterInvestor(tc.investor)
+			if tc.errorIs != nil {
+				assert.ErrorIs(t, err, tc.errorIs)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, domain.KYCPending, investor.KYCStatus)
+		})
+	}
+}
+
+func TestUpdateInvestor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	reviewedAt := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	limit := money.MustNew("5000", money.DefaultCurrency)
+	repo := mock.NewMockInvestorRepository(ctrl)
+	repo.EXPECT().FindByID("investor-1").Return(&domain.InvestorProfile{
+		ID: "investor-1", Name: "Budi", Email: "budi@example.com", KYCStatus: domain.KYCVerified, KYCReviewedAt: &reviewedAt,
+	}, nil)
+	repo.EXPECT().FindByID("investor-2").Return(nil, domain.ErrInvestorNotFound)
+	repo.EXPECT().Update(gomock.Any()).Return(nil)
+	service := NewInvestorService(repo, nil, logrus.New())
+
+	// The KYC status is kept whatever the update says
+	investor, err := service.UpdateInvestor(domain.InvestorProfile{
+		ID: "investor-1", Name: "Budi Santoso", Email: "budi@example.com", KYCStatus: domain.KYCRejected, Limits: domain.InvestmentLimits{PerLoan: &limit},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Budi Santoso", investor.Name)
+	assert.Equal(t, domain.KYCVerified, investor.KYCStatus)
+	assert.Equal(t, &reviewedAt, investor.KYCReviewedAt)
+	assert.Equal(t, &limit, investor.Limits.PerLoan)
+
+	_, err = service.UpdateInvestor(domain.InvestorProfile{ID: "investor-2", Name: "Ani", Email: "ani@example.com"})
+	assert.ErrorIs(t, err, domain.ErrInvestorNotFound)
+}
+
+func TestSetKYCStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  domain.KYCStatus
+		errorIs error
+	}{
+		{name: "Verified", status: domain.KYCVerified},
+		{name: "Rejected", status: domain.KYCRejected},
+		{name: "Unknown Status", status: "APPROVED", errorIs: domain.ErrInvalidInvestor},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock.NewMockInvestorRepository(ctrl)
+			repo.EXPECT().FindByID("investor-1").Return(&domain.InvestorProfile{
+				ID: "investor-1", Name: "Budi", Email: "budi@example.com", KYCStatus: domain.KYCPending,
+			}, nil)
+			if tc.errorIs == nil {
+				repo.EXPECT().Update(gomock.Any()).Return(nil)
+			}
+			service := NewInvestorService(repo, nil, logrus.New())
+
+			investor, err := service.SetKYCStatus("investor-1", tc.status)
+			if tc.errorIs != nil {
+				assert.ErrorIs(t, err, tc.errorIs)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.status, investor.KYCStatus)
+			assert.NotNil(t, investor.KYCReviewedAt)
+		})
+	}
+}
+
+func TestGetPortfolio(t *testing.T) {
+	t.Run("Lists the funded loans", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		investors := mock.NewMockInvestorRepository(ctrl)
+		investors.EXPECT().FindByID("investor-1").Return(&domain.InvestorProfile{ID: "investor-1"}, nil)
+		loans := mock.NewMockLoanRepository(ctrl)
+		loans.EXPECT().FindByInvestorID("investor-1").Return([]*domain.Loan{{
+			ID:        "loan-1",
+			State:     domain.StateDisbursed,
+			ROI:       money.MustParse("10"),
+			Terms:     domain.RepaymentTerms{TenorMonths: 12, Scheme: domain.SchemeFlat},
+			Investors: []domain.Investor{{ID: "investor-1", Amount: money.MustNew("1000", money.DefaultCurrency)}},
+		}}, nil)
+		service := NewInvestorService(investors, loans, logrus.New())
+
+		portfolio, err := service.GetPortfolio("investor-1")
+		assert.NoError(t, err)
+		assert.Equal(t, "investor-1", portfolio.InvestorID)
+		if assert.Len(t, portfolio.Loans, 1) {
+			assert.Equal(t, money.MustNew("100", money.DefaultCurrency), portfolio.Loans[0].ExpectedReturn)
+		}
+	})
+
+	t.Run("Unknown investor", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		investors := mock.NewMockInvestorRepository(ctrl)
+		investors.EXPECT().FindByID("investor-1").Return(nil, domain.ErrInvestorNotFound)
+		service := NewInvestorService(investors, mock.NewMockLoanRepository(ctrl), logrus.New())
+
+		_, err := service.GetPortfolio("investor-1")
+		assert.ErrorIs(t, err, domain.ErrInvestorNotFound)
+	})
+
+	t.Run("Repository error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		investors := mock.NewMockInvestorRepository(ctrl)
+		investors.EXPECT().FindByID("investor-1").Return(&domain.InvestorProfile{ID: "investor-1"}, nil)
+		loans := mock.NewMockLoanRepository(ctrl)
+		loans.EXPECT().FindByInvestorID("investor-1").Return(nil, errors.New("database error"))
+		service := NewInvestorService(investors, loans, logrus.New())
+
+		_, err := service.GetPortfolio("investor-1")
+		assert.ErrorContains(t, err, "failed to find loans of investor")
+	})
+}
@@ -0,0 +1,234 @@
+package loan
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+)
+
+// NotificationService sends notifications over the channels each recipient prefers and manages their
+// preferences and in-app inbox.
+//
+// A notification that fails on one channel is returned as failed, so the outbox retries it on every
+// channel; the in-app inbox keeps a notification once however often it is sent.
+type NotificationService struct {
+	preferences domain.PreferenceStore
+	inbox       domain.Inbox
+	channels    map[domain.Channel]domain.NotificationChannel
+	logger      *logrus.Logger
+}
+
+// NewNotificationService creates a notification service that delivers over the given channels
+func NewNotificationService(preferences domain.PreferenceStore, inbox domain.Inbox, logger *logrus.Logger, channels ...domain.NotificationChannel) domain.NotificationService {
+	s := &NotificationService{
+		preferences: preferences,
+		inbox:       inbox,
+		channels:    make(map[domain.Channel]domain.NotificationChannel, len(channels)),
+		logger:      logger,
+	}
+	for _, channel := range channels {
+		s.channels[channel.Channel()] = channel
+	}
+	return s
+}
+
+// Notify sends the notification over the channels the recipient prefers for its kind, nothing is sent
+// when the recipient muted it. When none of the preferred channels reaches the recipient the notification
+// is kept in their in-app inbox instead.
+func (s *NotificationService) Notify(notification domain.Notification) error {
+	preferences, err := s.findPreferences("Notify", notification.Recipient.ID)
+	if err != nil {
+		return err
+	}
+	notification.Recipient = preferences.Address(notification.Recipient)
+
+	preferred := preferences.ChannelsFor(notification.Kind)
+	if len(preferred) == 0 {
+		s.logger.WithFields(logrus.Fields{
+			"layer":        "service",
+			"function":     "Notify",
+			"recipient_id": notification.Recipient.ID,
+			"kind":         notification.Kind,
+		}).Info("Notification muted by recipient")
+		return nil
+	}
+
+	var (
+		attempted bool
+		errs      []error
+	)
+	for _, channel := range preferred {
+		sender, ok := s.usable("Notify", notification, channel)
+		if !ok {
+			continue
+		}
+		attempted = true
+		if err := sender.Send(notification); err != nil {
+			errs = append(errs, fmt.Errorf("failed to send %s notification over %s: %w", notification.Kind, channel, err))
+		}
+	}
+	if !attempted {
+		if sender, ok := s.usable("Notify", notification, domain.ChannelInApp); ok {
+			attempted = true
+			if err := sender.Send(notification); err != nil {
+				errs = append(errs, fmt.Errorf("failed to send %s notification over %s: %w", notification.Kind, domain.ChannelInApp, err))
+			}
+		}
+	}
+	if !attempted {
+		errs = append(errs, fmt.Errorf("%w: no channel reaches recipient %s of %s notification", domain.ErrRecipientUnreachable, notification.Recipient.ID, notification.Kind))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":        "service",
+			"function":     "Notify",
+			"recipient_id": notification.Recipient.ID,
+			"kind":         notification.Kind,
+			"error":        err.Error(),
+		}).Error("Failed to send notification")
+		return err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"layer":        "service",
+		"function":     "Notify",
+		"recipient_id": notification.Recipient.ID,
+		"kind":         notification.Kind,
+		"loan_id":      notification.LoanID,
+	}).Info("Notification sent")
+	return nil
+}
+
+// usable returns the registered sender of the channel when it reaches the recipient of the notification
+func (s *NotificationService) usable(function string, notification domain.Notification, channel domain.Channel) (domain.NotificationChannel, bool) {
+	sender, ok := s.channels[channel]
+	if !ok || !notification.Recipient.Reaches(channel) {
+		s.logger.WithFields(logrus.Fields{
+			"layer":        "service",
+			"function":     function,
+			"recipient_id": notification.Recipient.ID,
+			"kind":         notification.Kind,
+			"channel":      channel,
+			"registered":   ok,
+		}).Warn("Skipping channel that cannot reach the recipient")
+		return nil, false
+	}
+	return sender, true
+}
+
+// findPreferences returns the preferences of the recipient, the defaults when they have not set any
+func (s *NotificationService) findPreferences(function, recipientID string) (*domain.NotificationPreferences, error) {
+	preferences, err := s.preferences.Find(recipientID)
+	if errors.Is(err, domain.ErrPreferencesNotFound) {
+		defaults := domain.DefaultPreferences(recipientID)
+		return &defaults, nil
+	}
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":        "service",
+			"function":     function,
+			"recipient_id": recipientID,
+			"error":        err.Error(),
+		}).Error("Failed to find notification preferences")
+		return nil, fmt.Errorf("failed to find notification preferences: %w", err)
+	}
+	return preferences, nil
+}
+
+// GetPreferences retrieves the preferences of a recipient, the defaults when they have not set any
+func (s *NotificationService) GetPreferences(recipientID string) (*domain.NotificationPreferences, error) {
+	s.logger.WithFields(logrus.Fields{
+		"layer":        "service",
+		"function":     "GetPreferences",
+		"recipient_id": recipientID,
+	}).Info("Retrieving notification preferences")
+
+	if recipientID == "" {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": "GetPreferences",
+		}).Error("Recipient ID cannot be empty")
+		return nil, errors.New("recipient ID cannot be empty")
+	}
+	return s.findPreferences("GetPreferences", recipientID)
+}
+
+// SetPreferences validates and stores the preferences of a recipient
+func (s *NotificationService) SetPreferences(preferences domain.NotificationPreferences) error {
+	s.logger.WithFields(logrus.Fields{
+		"layer":        "service",
+		"function":     "SetPreferences",
+		"recipient_id": preferences.RecipientID,
+		"channels":     preferences.Channels,
+		"muted":        preferences.Muted,
+	}).Info("Setting notification preferences")
+
+	if err := preferences.Validate(); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":        "service",
+			"function":     "SetPreferences",
+			"recipient_id": preferences.RecipientID,
+			"error":        err.Error(),
+		}).Error("Invalid notification preferences")
+		return err
+	}
+
+	if err := s.preferences.Save(preferences); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":        "service",
+			"function":     "SetPreferences",
+			"recipient_id": preferences.RecipientID,
+			"error":        err.Error(),
+		}).Error("Failed to save notification preferences")
+		return fmt.Errorf("failed to save notification preferences: %w", err)
+	}
+	return nil
+}
+
+// GetInbox retrieves the in-app notifications of a recipient, newest first
+func (s *NotificationService) GetInbox(recipientID string) ([]domain.InboxItem, error) {
+	s.logger.WithFields(logrus.Fields{
+		"layer":        "service",
+		"function":     "GetInbox",
+		"recipient_id": recipientID,
+	}).Info("Retrieving inbox")
+
+	items, err := s.inbox.FindByRecipient(recipientID)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":        "service",
+			"function":     "GetInbox",
+			"recipient_id": recipientID,
+			"error":        err.Error(),
+		}).Error("Failed to find inbox items")
+		return nil, err
+	}
+	return items, nil
+}
+
+// MarkRead records that the recipient read one of their in-app notifications
+func (s *NotificationService) MarkRead(recipientID, id string) error {
+	s.logger.WithFields(logrus.Fields{
+		"layer":           "service",
+		"function":        "MarkRead",
+		"recipient_id":    recipientID,
+		"notification_id": id,
+	}).Info("Marking notification as read")
+
+	if err := s.inbox.MarkRead(recipientID, id, time.Now()); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":           "service",
+			"function":        "MarkRead",
+			"recipient_id":    recipientID,
+			"notification_id": id,
+			"error":           err.Error(),
+		}).Error("Failed to mark notification as read")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package loan
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	mock "github.com/hinha/los-technical/internal/domain/loan/mock"
+)
+
+// newTestChannel returns a channel mock registered as the given channel
+func newTestChannel(ctrl *gomock.Controller, channel domain.Channel) *mock.MockNotificationChannel {
+	sender := mock.NewMockNotificationChannel(ctrl)
+	sender.EXPECT().Channel().Return(channel).AnyTimes()
+	return sender
+}
+
+func TestNotificationService_Notify(t *testing.T) {
+	borrower := domain.Notification{
+		ID:        "notification-1",
+		LoanID:    "loan-123",
+		Kind:      domain.NotificationLoanApproved,
+		Recipient: domain.Recipient{ID: "borrower-123", Role: domain.RoleBorrower},
+	}
+	investor := domain.Notification{
+		ID:        "notification-2",
+		LoanID:    "loan-123",
+		Kind:      domain.NotificationInvestmentReceived,
+		Recipient: domain.Recipient{ID: "investor-1", Role: domain.RoleInvestor, Email: "one@example.com"},
+	}
+
+	testCases := []struct {
+		name         string
+		notification domain.Notification
+		mockSetup    func(store *mock.MockPreferenceStore, email, sms, inApp *mock.MockNotificationChannel)
+		expectError  bool
+		errorIs      error
+	}{
+		{
+			name:         "Defaults to email when no preferences are set",
+			notification: investor,
+			mockSetup: func(store *mock.MockPreferenceStore, email, sms, inApp *mock.MockNotificationChannel) {
+				store.EXPECT().Find("investor-1").Return(nil, domain.ErrPreferencesNotFound)
+				email.EXPECT().Send(investor).Return(nil)
+			},
+		},
+		{
+			name:         "Addresses the recipient from their preferences",
+			notification: borrower,
+			mockSetup: func(store *mock.MockPreferenceStore, email, sms, inApp *mock.MockNotificationChannel) {
+				store.EXPECT().Find("borrower-123").Return(&domain.NotificationPreferences{
+					RecipientID: "borrower-123",
+					Email:       "borrower@example.com",
+					Phone:       "+6281234567890",
+					Locale:      "id",
+					Channels:    []domain.Channel{domain.ChannelEmail, domain.ChannelSMS},
+				}, nil)
+				addressed := borrower
+				addressed.Recipient.Email = "borrower@example.com"
+				addressed.Recipient.Phone = "+6281234567890"
+				addressed.Recipient.Locale = "id"
+				email.EXPECT().Send(addressed).Return(nil)
+				sms.EXPECT().Send(addressed).Return(nil)
+			},
+		},
+		{
+			name:         "Sends nothing for a muted kind",
+			notification: borrower,
+			mockSetup: func(store *mock.MockPreferenceStore, email, sms, inApp *mock.MockNotificationChannel) {
+				store.EXPECT().Find("borrower-123").Return(&domain.NotificationPreferences{
+					RecipientID: "borrower-123",
+					Muted:       []domain.NotificationKind{domain.NotificationLoanApproved},
+				}, nil)
+			},
+		},
+		{
+			name:         "Falls back to the inbox when no preferred channel reaches the recipient",
+			notification: borrower,
+			mockSetup: func(store *mock.MockPreferenceStore, email, sms, inApp *mock.MockNotificationChannel) {
+				store.EXPECT().Find("borrower-123").Return(nil, domain.ErrPreferencesNotFound)
+				inApp.EXPECT().Send(borrower).Return(nil)
+			},
+		},
+		{
+			name:         "Unreachable recipient",
+			notification: domain.Notification{ID: "notification-3", Kind: domain.NotificationRefund},
+			mockSetup: func(store *mock.MockPreferenceStore, email, sms, inApp *mock.MockNotificationChannel) {
+				store.EXPECT().Find("").Return(nil, domain.ErrPreferencesNotFound)
+			},
+			expectError: true,
+			errorIs:     domain.ErrRecipientUnreachable,
+		},
+		{
+			name:         "Failure on one channel fails the notification",
+			notification: investor,
+			mockSetup: func(store *mock.MockPreferenceStore, email, sms, inApp *mock.MockNotificationChannel) {
+				store.EXPECT().Find("investor-1").Return(&domain.NotificationPreferences{
+					RecipientID: "investor-1",
+					Channels:    []domain.Channel{domain.ChannelEmail, domain.ChannelInApp},
+				}, nil)
+				email.EXPECT().Send(investor).Return(errors.New("connection refused"))
+				inApp.EXPECT().Send(investor).Return(nil)
+			},
+			expectError: true,
+		},
+		{
+			name:         "Preference store error",
+			notification: investor,
+			mockSetup: func(store *mock.MockPreferenceStore, email, sms, inApp *mock.MockNotificationChannel) {
+				store.EXPECT().Find("investor-1").Return(nil, errors.New("database error"))
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockPreferenceStore(ctrl)
+			email := newTestChannel(ctrl, domain.ChannelEmail)
+			sms := newTestChannel(ctrl, domain.ChannelSMS)
+			inApp := newTestChannel(ctrl, domain.ChannelInApp)
+			tc.mockSetup(store, email, sms, inApp)
+
+			service := NewNotificationService(store, mock.NewMockInbox(ctrl), logrus.New(), email, sms, inApp)
+			err := service.Notify(tc.notification)
+
+			if tc.expectError {
+				assert.Error(t, err)
+				if tc.errorIs != nil {
+					assert.ErrorIs(t, err, tc.errorIs)
+				}
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestNotificationService_Preferences(t *testing.T) {
+	t.Run("Recipients without preferences get the defaults", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		store := mock.NewMockPreferenceStore(ctrl)
+		store.EXPECT().Find("investor-1").Return(nil, domain.ErrPreferencesNotFound)
+
+		service := NewNotificationService(store, mock.NewMockInbox(ctrl), logrus.New())
+		preferences, err := service.GetPreferences("investor-1")
+		assert.NoError(t, err)
+		assert.Equal(t, domain.DefaultPreferences("investor-1"), *preferences)
+	})
+
+	t.Run("Empty recipient ID", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		service := NewNotificationService(mock.NewMockPreferenceStore(ctrl), mock.NewMockInbox(ctrl), logrus.New())
+		_, err := service.GetPreferences("")
+		assert.ErrorContains(t, err, "recipient ID cannot be empty")
+	})
+
+	t.Run("Valid preferences are saved", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		preferences := domain.NotificationPreferences{RecipientID: "investor-1", Channels: []domain.Channel{domain.ChannelInApp}}
+		store := mock.NewMockPreferenceStore(ctrl)
+		store.EXPECT().Save(preferences).Return(nil)
+
+		service := NewNotificationService(store, mock.NewMockInbox(ctrl), logrus.New())
+		assert.NoError(t, service.SetPreferences(preferences))
+	})
+
+	t.Run("Invalid preferences are rejected", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// The strict store fails the test on any Save
+		service := NewNotificationService(mock.NewMockPreferenceStore(ctrl), mock.NewMockInbox(ctrl), logrus.New())
+		err := service.SetPreferences(domain.NotificationPreferences{RecipientID: "investor-1", Channels: []domain.Channel{domain.ChannelSMS}})
+		assert.ErrorIs(t, err, domain.ErrInvalidPreferences)
+	})
+}
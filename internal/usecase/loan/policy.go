@@ -0,0 +1,55 @@
+package loan
+
+import (
+	"time"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+)
+
+const (
+	// DefaultMaxActiveLoans is how many active loans a borrower may hold at a time unless configured otherwise
+	DefaultMaxActiveLoans = 1
+	// DefaultLoanCooldown is the time a borrower waits between two applications unless configured otherwise
+	DefaultLoanCooldown = time.Duration(0)
+)
+
+// BorrowerPolicy decides whether a borrower may apply for another loan given the loans they already have.
+// Repaid, rejected and cancelled loans never count against the borrower.
+type BorrowerPolicy struct {
+	// MaxActiveLoans is how many loans that are neither repaid nor closed before disbursement a borrower may
+	// hold at a time, zero or less means no limit
+	MaxActiveLoans int
+	// Cooldown is the time a borrower waits after applying for a loan before they may apply again, whatever
+	// became of that loan
+	Cooldown time.Duration
+}
+
+// DefaultBorrowerPolicy returns the policy allowing DefaultMaxActiveLoans active loans and DefaultLoanCooldown
+// between two applications
+func DefaultBorrowerPolicy() BorrowerPolicy {
+	return BorrowerPolicy{MaxActiveLoans: DefaultMaxActiveLoans, Cooldown: DefaultLoanCooldown}
+}
+
+// Check returns a *domain.BorrowerLoanLimitError when the borrower may not apply for a new loan at now
+func (p BorrowerPolicy) Check(borrowerID string, loans []*domain.Loan, now time.Time) error {
+	active := 0
+	var lastApplied time.Time
+	for _, loan := range loans {
+		if !loan.State.IsTerminal() {
+			active++
+		}
+		if loan.CreatedAt.After(lastApplied) {
+			lastApplied = loan.CreatedAt
+		}
+	}
+
+	if p.MaxActiveLoans > 0 && active >= p.MaxActiveLoans {
+		return &domain.BorrowerLoanLimitError{BorrowerID: borrowerID, ActiveLoans: active, MaxActiveLoans: p.MaxActiveLoans}
+	}
+	if p.Cooldown > 0 && !lastApplied.IsZero() {
+		if retryAt := lastApplied.Add(p.Cooldown); now.Before(retryAt) {
+			return &domain.BorrowerLoanLimitError{BorrowerID: borrowerID, ActiveLoans: active, MaxActiveLoans: p.MaxActiveLoans, RetryAt: retryAt}
+		}
+	}
+	return nil
+}
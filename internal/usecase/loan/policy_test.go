@@ -0,0 +1,81 @@
+package loan
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+)
+
+func TestBorrowerPolicy_Check(t *testing.T) {
+	now := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	loan := func(state domain.LoanState, daysAgo int) *domain.Loan {
+		return &domain.Loan{BorrowerID: "borrower-1", State: state, CreatedAt: now.AddDate(0, 0, -daysAgo)}
+	}
+
+	tests := []struct {
+		name    string
+		policy  BorrowerPolicy
+		loans   []*domain.Loan
+		wantErr *domain.BorrowerLoanLimitError
+	}{
+		{
+			name:   "First loan",
+			policy: DefaultBorrowerPolicy(),
+		},
+		{
+			name:   "Terminal loans are ignored",
+			policy: DefaultBorrowerPolicy(),
+			loans:  []*domain.Loan{loan(domain.StateRepaid, 60), loan(domain.StateRejected, 30), loan(domain.StateCancelled, 10)},
+		},
+		{
+			name:    "Active loan limit reached",
+			policy:  DefaultBorrowerPolicy(),
+			loans:   []*domain.Loan{loan(domain.StateRepaid, 60), loan(domain.StateDefaulted, 30)},
+			wantErr: &domain.BorrowerLoanLimitError{BorrowerID: "borrower-1", ActiveLoans: 1, MaxActiveLoans: 1},
+		},
+		{
+			name:   "Below a higher limit",
+			policy: BorrowerPolicy{MaxActiveLoans: 3},
+			loans:  []*domain.Loan{loan(domain.StateProposed, 2), loan(domain.StateDisbursed, 30)},
+		},
+		{
+			name:   "No limit",
+			policy: BorrowerPolicy{MaxActiveLoans: 0},
+			loans:  []*domain.Loan{loan(domain.StateProposed, 2), loan(domain.StateApproved, 3), loan(domain.StateInvested, 4)},
+		},
+		{
+			name:    "Within cooldown of a rejected application",
+			policy:  BorrowerPolicy{MaxActiveLoans: 1, Cooldown: 30 * 24 * time.Hour},
+			loans:   []*domain.Loan{loan(domain.StateRepaid, 90), loan(domain.StateRejected, 10)},
+			wantErr: &domain.BorrowerLoanLimitError{BorrowerID: "borrower-1", MaxActiveLoans: 1, RetryAt: now.AddDate(0, 0, 20)},
+		},
+		{
+			name:   "Cooldown passed",
+			policy: BorrowerPolicy{MaxActiveLoans: 1, Cooldown: 30 * 24 * time.Hour},
+			loans:  []*domain.Loan{loan(domain.StateRejected, 30)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Check("borrower-1", tt.loans, now)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, domain.ErrBorrowerLoanLimit)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestBorrowerLoanLimitError_Error(t *testing.T) {
+	err := &domain.BorrowerLoanLimitError{BorrowerID: "borrower-1", ActiveLoans: 2, MaxActiveLoans: 2}
+	assert.Equal(t, "borrower borrower-1 already has 2 active loans, at most 2 are allowed at a time", err.Error())
+
+	err.RetryAt = time.Date(2025, 3, 21, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, "borrower borrower-1 applied for a loan too recently and may apply again from 2025-03-21T00:00:00Z", err.Error())
+}
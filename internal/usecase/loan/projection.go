@@ -0,0 +1,230 @@
+package loan
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+)
+
+// Projection keeps the loan read model in the repository in sync with the event stream
+type Projection struct {
+	repo   domain.LoanRepository
+	logger *logrus.Logger
+}
+
+// NewProjection creates a projection onto the repository
+func NewProjection(repo domain.LoanRepository, logger *logrus.Logger) *Projection {
+	return &Projection{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Project stores the loan the events of one command brought it to. The events must already be
+// applied to the loan, which still carries the version it was read at.
+func (p *Projection) Project(loan *domain.Loan, events []domain.Event) error {
+	if len(events) > 0 && events[0].Type == domain.EventLoanProposed {
+		return p.repo.Save(loan)
+	}
+	return p.repo.Update(loan)
+}
+
+// Rebuild replays every stream of the store into the repository, which must not hold the loans yet.
+// It returns the number of loans rebuilt.
+func (p *Projection) Rebuild(store domain.EventStore) (int, error) {
+	p.logger.WithFields(logrus.Fields{
+		"layer":    "service",
+		"function": "Rebuild",
+	}).Info("Rebuilding loan read model from the event stream")
+
+	events, err := store.LoadAll()
+	if err != nil {
+		p.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": "Rebuild",
+			"error":    err.Error(),
+		}).Error("Failed to load events")
+		return 0, err
+	}
+
+	loans := make(map[string]*domain.Loan)
+	for _, batch := range batches(events) {
+		if batch[0].Type == domain.EventLoanImported {
+			return 0, fmt.Errorf("%w: loan %s was imported from a persistent read model and can only be kept there",
+				domain.ErrInvalidEvent, batch[0].LoanID)
+		}
+		loan, exists := loans[batch[0].LoanID]
+		if !exists {
+			loan = &domain.Loan{}
+			loans[batch[0].LoanID] = loan
+		}
+		for _, event := range batch {
+			if err := loan.Apply(event); err != nil {
+				return 0, err
+			}
+		}
+		if err := p.Project(loan, batch); err != nil {
+			p.logger.WithFields(logrus.Fields{
+				"layer":    "service",
+				"function": "Rebuild",
+				"loan_id":  loan.ID,
+				"version":  batch[0].Version,
+				"error":    err.Error(),
+			}).Error("Failed to project events")
+			return 0, fmt.Errorf("failed to project version %d of loan %s: %w", batch[0].Version, loan.ID, err)
+		}
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"layer":    "service",
+		"function": "Rebuild",
+		"events":   len(events),
+		"loans":    len(loans),
+	}).Info("Loan read model rebuilt successfully")
+	return len(loans), nil
+}
+
+// CatchUp projects the events of the loan stream its read model is behind on, as left by a command whose events
+// were appended but not projected. It reports whether the read model was behind.
+func (p *Projection) CatchUp(store domain.EventStore, id string) (bool, error) {
+	loan, err := p.repo.FindByID(id)
+	if err != nil {
+		return false, err
+	}
+	events, err := store.Load(id)
+	if err != nil {
+		return false, err
+	}
+	return p.catchUp(loan, events)
+}
+
+// catchUp replays the stream of the loan and projects every command after the version of the read model
+func (p *Projection) catchUp(loan *domain.Loan, events []domain.Event) (bool, error) {
+	if len(events) == 0 || events[len(events)-1].Version <= loan.Version {
+		return false, nil
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"layer":          "service",
+		"function":       "CatchUp",
+		"loan_id":        loan.ID,
+		"version":        loan.Version,
+		"stream_version": events[len(events)-1].Version,
+	}).Warn("Read model is behind the event stream, projecting the missing events")
+
+	replayed := &domain.Loan{}
+	for _, batch := range batches(events) {
+		for _, event := range batch {
+			if err := replayed.Apply(event); err != nil {
+				return false, err
+			}
+		}
+		if batch[0].Version <= loan.Version {
+			continue
+		}
+		// The repository moves the loan from the version before the command to the version of the command
+		replayed.Version = batch[0].Version - 1
+		if err := p.repo.Update(replayed); err != nil {
+			return false, fmt.Errorf("failed to project version %d of loan %s: %w", batch[0].Version, loan.ID, err)
+		}
+	}
+	return true, nil
+}
+
+// batches splits events into the events of each command. The events of one command are appended together,
+// so a batch ends where the stream or version changes.
+func batches(events []domain.Event) [][]domain.Event {
+	var result [][]domain.Event
+	for start := 0; start < len(events); {
+		end := start + 1
+		for end < len(events) && events[end].LoanID == events[start].LoanID && events[end].Version == events[start].Version {
+			end++
+		}
+		result = append(result, events[start:end])
+		start = end
+	}
+	return result
+}
+
+// seedPageSize is how many loans Seed reads from the repository at a time
+const seedPageSize = 100
+
+// Seed starts the stream of every loan the repository holds without one, with an EventLoanImported at the
+// version of the loan, so a persistent read model written before its events were recorded can still be changed.
+// A loan behind its stream is caught up with it; a stream behind its loan fails, as the read model holds changes
+// the store never recorded. It returns the number of loans seeded.
+func (p *Projection) Seed(store domain.EventStore) (int, error) {
+	p.logger.WithFields(logrus.Fields{
+		"layer":    "service",
+		"function": "Seed",
+	}).Info("Seeding event streams from the loan read model")
+
+	seeded := 0
+	for page := 1; ; page++ {
+		loans, err := p.repo.FindAll(domain.LoanFilter{}, page, seedPageSize)
+		if err != nil {
+			p.logger.WithFields(logrus.Fields{
+				"layer":    "service",
+				"function": "Seed",
+				"error":    err.Error(),
+			}).Error("Failed to find loans")
+			return 0, err
+		}
+		for _, loan := range loans {
+			ok, err := p.seed(store, loan)
+			if err != nil {
+				p.logger.WithFields(logrus.Fields{
+					"layer":    "service",
+					"function": "Seed",
+					"loan_id":  loan.ID,
+					"version":  loan.Version,
+					"error":    err.Error(),
+				}).Error("Failed to seed event stream")
+				return 0, err
+			}
+			if ok {
+				seeded++
+			}
+		}
+		if len(loans) < seedPageSize {
+			break
+		}
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"layer":    "service",
+		"function": "Seed",
+		"loans":    seeded,
+	}).Info("Event streams seeded successfully")
+	return seeded, nil
+}
+
+// seed imports the loan into the store unless its stream already exists, and reports whether it did.
+// A loan behind its existing stream is caught up with it.
+func (p *Projection) seed(store domain.EventStore, loan *domain.Loan) (bool, error) {
+	events, err := store.Load(loan.ID)
+	if err != nil {
+		return false, err
+	}
+	if len(events) > 0 {
+		if _, err := p.catchUp(loan, events); err != nil {
+			return false, err
+		}
+		if version := events[len(events)-1].Version; version < loan.Version {
+			return false, fmt.Errorf("%w: stream of loan %s is at version %d, the read model at version %d",
+				domain.ErrVersionConflict, loan.ID, version, loan.Version)
+		}
+		return false, nil
+	}
+
+	event, err := domain.NewEvent(loan.ID, loan.Version, domain.EventLoanImported, domain.LoanImportedData{Loan: *loan}, loan.UpdatedAt)
+	if err != nil {
+		return false, err
+	}
+	if err := store.Append(loan.ID, loan.Version-1, event); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,192 @@
+package loan
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	mock "github.com/hinha/los-technical/internal/domain/loan/mock"
+	"github.com/hinha/los-technical/internal/pkg/money"
+)
+
+func newTestEvent(t *testing.T, loanID string, version int64, eventType domain.EventType, data any) domain.Event {
+	t.Helper()
+	event, err := domain.NewEvent(loanID, version, eventType, data, time.Date(2025, 1, 1, 8, int(version), 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("failed to build event: %v", err)
+	}
+	return event
+}
+
+func TestProjection_Rebuild(t *testing.T) {
+	principal := money.MustNew("1000", money.DefaultCurrency)
+	events := []domain.Event{
+		newTestEvent(t, "loan-1", 1, domain.EventLoanProposed, domain.LoanProposedData{BorrowerID: "borrower-1", PrincipalAmount: principal}),
+		newTestEvent(t, "loan-2", 1, domain.EventLoanProposed, domain.LoanProposedData{BorrowerID: "borrower-2", PrincipalAmount: principal}),
+		newTestEvent(t, "loan-1", 2, domain.EventLoanApproved, domain.LoanApprovedData{Approval: domain.Approval{ValidatorID: "validator-1"}}),
+		newTestEvent(t, "loan-1", 3, domain.EventInvestmentAdded, domain.InvestmentAddedData{Investor: domain.Investor{ID: "investor-1", Amount: principal}}),
+		newTestEvent(t, "loan-1", 3, domain.EventLoanFullyFunded, struct{}{}),
+	}
+
+	t.Run("Replays every stream onto the repository", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mock.NewMockLoanRepository(ctrl)
+		mockEventStore := mock.NewMockEventStore(ctrl)
+		mockEventStore.EXPECT().LoadAll().Return(events, nil)
+
+		// The repository bumps the version on every write, like the real ones
+		var projected []string
+		gomock.InOrder(
+			mockRepo.EXPECT().Save(gomock.Any()).DoAndReturn(func(l *domain.Loan) error {
+				projected = append(projected, l.ID+" "+string(l.State))
+				l.Version = 1
+				return nil
+			}).Times(2),
+			mockRepo.EXPECT().Update(gomock.Any()).DoAndReturn(func(l *domain.Loan) error {
+				projected = append(projected, l.ID+" "+string(l.State))
+				l.Version++
+				return nil
+			}).Times(2),
+		)
+
+		rebuilt, err := NewProjection(mockRepo, logrus.New()).Rebuild(mockEventStore)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, rebuilt)
+		assert.Equal(t, []string{"loan-1 PROPOSED", "loan-2 PROPOSED", "loan-1 APPROVED", "loan-1 INVESTED"}, projected)
+	})
+
+	t.Run("Error when the store cannot be read", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockEventStore := mock.NewMockEventStore(ctrl)
+		mockEventStore.EXPECT().LoadAll().Return(nil, errors.New("disk error"))
+
+		_, err := NewProjection(mock.NewMockLoanRepository(ctrl), logrus.New()).Rebuild(mockEventStore)
+		assert.ErrorContains(t, err, "disk error")
+	})
+
+	t.Run("Error when the repository refuses a loan", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mock.NewMockLoanRepository(ctrl)
+		mockEventStore := mock.NewMockEventStore(ctrl)
+		mockEventStore.EXPECT().LoadAll().Return(events[:1], nil)
+		mockRepo.EXPECT().Save(gomock.Any()).Return(domain.ErrLoanAlreadyExists)
+
+		_, err := NewProjection(mockRepo, logrus.New()).Rebuild(mockEventStore)
+		assert.ErrorIs(t, err, domain.ErrLoanAlreadyExists)
+		assert.ErrorContains(t, err, "failed to project version 1 of loan loan-1")
+	})
+
+	t.Run("Error on an imported loan", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockEventStore := mock.NewMockEventStore(ctrl)
+		mockEventStore.EXPECT().LoadAll().Return([]domain.Event{
+			newTestEvent(t, "loan-1", 4, domain.EventLoanImported, domain.LoanImportedData{Loan: domain.Loan{ID: "loan-1"}}),
+		}, nil)
+
+		_, err := NewProjection(mock.NewMockLoanRepository(ctrl), logrus.New()).Rebuild(mockEventStore)
+		assert.ErrorIs(t, err, domain.ErrInvalidEvent)
+		assert.ErrorContains(t, err, "loan loan-1 was imported from a persistent read model")
+	})
+}
+
+func TestProjection_Seed(t *testing.T) {
+	updatedAt := time.Date(2025, 1, 2, 8, 0, 0, 0, time.UTC)
+	legacy := &domain.Loan{ID: "loan-1", BorrowerID: "borrower-1", State: domain.StateApproved, AgreementLetter: "letter.pdf", UpdatedAt: updatedAt, Version: 3}
+	recorded := &domain.Loan{ID: "loan-2", BorrowerID: "borrower-2", State: domain.StateProposed, Version: 1}
+
+	t.Run("Imports the loans without a stream", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mock.NewMockLoanRepository(ctrl)
+		mockEventStore := mock.NewMockEventStore(ctrl)
+		mockRepo.EXPECT().FindAll(domain.LoanFilter{}, 1, seedPageSize).Return([]*domain.Loan{legacy, recorded}, nil)
+		mockEventStore.EXPECT().Load("loan-1").Return(nil, nil)
+		mockEventStore.EXPECT().Load("loan-2").Return([]domain.Event{
+			newTestEvent(t, "loan-2", 1, domain.EventLoanProposed, domain.LoanProposedData{BorrowerID: "borrower-2"}),
+		}, nil)
+		mockEventStore.EXPECT().Append("loan-1", int64(2), gomock.Any()).DoAndReturn(func(_ string, _ int64, events ...domain.Event) error {
+			if assert.Len(t, events, 1) {
+				assert.Equal(t, domain.EventLoanImported, events[0].Type)
+				assert.Equal(t, int64(3), events[0].Version)
+				assert.Equal(t, updatedAt, events[0].OccurredAt)
+				imported, err := domain.Replay(events)
+				assert.NoError(t, err)
+				assert.Equal(t, legacy, imported)
+			}
+			return nil
+		})
+
+		seeded, err := NewProjection(mockRepo, logrus.New()).Seed(mockEventStore)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, seeded)
+	})
+
+	t.Run("Catches up a loan behind its stream", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mock.NewMockLoanRepository(ctrl)
+		mockEventStore := mock.NewMockEventStore(ctrl)
+		mockRepo.EXPECT().FindAll(domain.LoanFilter{}, 1, seedPageSize).Return([]*domain.Loan{recorded}, nil)
+		mockEventStore.EXPECT().Load("loan-2").Return([]domain.Event{
+			newTestEvent(t, "loan-2", 1, domain.EventLoanProposed, domain.LoanProposedData{BorrowerID: "borrower-2"}),
+			newTestEvent(t, "loan-2", 2, domain.EventLoanApproved, domain.LoanApprovedData{Approval: domain.Approval{ValidatorID: "validator-1"}}),
+			newTestEvent(t, "loan-2", 3, domain.EventLoanCancelled, domain.LoanClosedData{Closure: domain.Closure{ActorID: "borrower-2", ReasonCode: domain.ReasonBorrowerWithdrawn}}),
+		}, nil)
+
+		// Each command the read model missed is projected from the version before it
+		var projected []string
+		mockRepo.EXPECT().Update(gomock.Any()).DoAndReturn(func(l *domain.Loan) error {
+			projected = append(projected, fmt.Sprintf("%s %s %d", l.ID, l.State, l.Version))
+			l.Version++
+			return nil
+		}).Times(2)
+
+		seeded, err := NewProjection(mockRepo, logrus.New()).Seed(mockEventStore)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, seeded)
+		assert.Equal(t, []string{"loan-2 APPROVED 1", "loan-2 CANCELLED 2"}, projected)
+	})
+
+	t.Run("Error when a stream is behind its loan", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mock.NewMockLoanRepository(ctrl)
+		mockEventStore := mock.NewMockEventStore(ctrl)
+		mockRepo.EXPECT().FindAll(domain.LoanFilter{}, 1, seedPageSize).Return([]*domain.Loan{legacy}, nil)
+		mockEventStore.EXPECT().Load("loan-1").Return([]domain.Event{
+			newTestEvent(t, "loan-1", 1, domain.EventLoanProposed, domain.LoanProposedData{BorrowerID: "borrower-1"}),
+		}, nil)
+
+		_, err := NewProjection(mockRepo, logrus.New()).Seed(mockEventStore)
+		assert.ErrorIs(t, err, domain.ErrVersionConflict)
+		assert.ErrorContains(t, err, "stream of loan loan-1 is at version 1, the read model at version 3")
+	})
+
+	t.Run("Error when the repository cannot be read", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mock.NewMockLoanRepository(ctrl)
+		mockRepo.EXPECT().FindAll(domain.LoanFilter{}, 1, seedPageSize).Return(nil, errors.New("database error"))
+
+		_, err := NewProjection(mockRepo, logrus.New()).Seed(mock.NewMockEventStore(ctrl))
+		assert.ErrorContains(t, err, "database error")
+	})
+}
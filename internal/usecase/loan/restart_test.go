@@ -0,0 +1,102 @@
+package loan
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	domain "github.com/hinha/los-technical/internal/domain/loan"
+	"github.com/hinha/los-technical/internal/infrastructure/database/sqlite"
+	"github.com/hinha/los-technical/internal/infrastructure/eventstore"
+	loanRepo "github.com/hinha/los-technical/internal/infrastructure/repository/loan"
+	"github.com/hinha/los-technical/internal/pkg/money"
+)
+
+// TestRestart_SQLiteAndFileEventStore runs the service on a SQLite read model and a file event store, restarts it
+// and checks the loans stored before, with or without a stream, can still be changed
+func TestRestart_SQLiteAndFileEventStore(t *testing.T) {
+	dir := t.TempDir()
+	logger := logrus.New()
+
+	// start opens the stores as main does and seeds the streams missing from the event file
+	start := func(t *testing.T) (domain.Service, domain.LoanRepository, func()) {
+		t.Helper()
+		ctrl := gomock.NewController(t)
+
+		db, err := sqlite.Open(filepath.Join(dir, "los.db"))
+		if err != nil {
+			t.Fatalf("failed to open database: %v", err)
+		}
+		if err := sqlite.Migrate(db, logger); err != nil {
+			t.Fatalf("failed to migrate database: %v", err)
+		}
+		store, err := eventstore.OpenFileEventStore(filepath.Join(dir, "events.jsonl"), logger)
+		if err != nil {
+			t.Fatalf("failed to open event store: %v", err)
+		}
+		repo := loanRepo.NewSQLiteRepository(db, logger)
+		if _, err := NewProjection(repo, logger).Seed(store); err != nil {
+			t.Fatalf("failed to seed event store: %v", err)
+		}
+
+		service := NewLoanService(repo, nil, newTestBorrowers(ctrl), store, loanRepo.NewSQLiteAuditLog(db, logger),
+			nil, nil, nil, newTestScorer(ctrl), newTestPricer(ctrl), logger)
+		return service, repo, func() {
+			_ = store.Close()
+			_ = db.Close()
+		}
+	}
+
+	service, repo, stop := start(t)
+	created, err := service.CreateLoan("borrower-1", money.MustNew("1000000", money.DefaultCurrency), nil, nil,
+		domain.RepaymentTerms{TenorMonths: 12, Scheme: domain.SchemeAnnuity})
+	if !assert.NoError(t, err) {
+		stop()
+		return
+	}
+
+	// A loan stored before events were recorded has no stream
+	legacy := &domain.Loan{
+		ID:              "legacy-1",
+		BorrowerID:      "borrower-2",
+		PrincipalAmount: money.MustNew("2000000", money.DefaultCurrency),
+		Rate:            money.MustParse("12"),
+		ROI:             money.MustParse("10"),
+		Terms:           domain.RepaymentTerms{TenorMonths: 12, Scheme: domain.SchemeAnnuity},
+		State:           domain.StateProposed,
+		CreatedAt:       time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC),
+		UpdatedAt:       time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC),
+	}
+	assert.NoError(t, repo.Save(legacy))
+	assert.NoError(t, repo.Update(legacy))
+	stop()
+
+	service, _, stop = start(t)
+
+	version, err := service.CancelLoan(created.ID, "borrower-1", domain.ReasonBorrowerWithdrawn, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), version)
+	cancelled, err := service.GetLoan(created.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, domain.StateCancelled, cancelled.State)
+		assert.Equal(t, int64(2), cancelled.Version)
+	}
+
+	version, err = service.RejectLoan("legacy-1", "validator-1", domain.ReasonCreditRisk, domain.IfMatch{2})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), version)
+	rejected, err := service.GetLoan("legacy-1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, domain.StateRejected, rejected.State)
+		assert.Equal(t, int64(3), rejected.Version)
+	}
+
+	// Both streams survive another restart in step with the read model
+	stop()
+	_, _, stop = start(t)
+	stop()
+}
@@ -3,418 +3,2259 @@ package loan
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
 
 	domain "github.com/hinha/los-technical/internal/domain/loan"
+	"github.com/hinha/los-technical/internal/pkg/money"
 	"github.com/hinha/los-technical/internal/pkg/utils"
 )
 
 // LoanService handles loan business logic
 type LoanService struct {
-	repo        domain.LoanRepository
-	emailSender domain.EmailSender
-	logger      *logrus.Logger
+	repo       domain.LoanRepository
+	investors  domain.InvestorRepository
+	borrowers  domain.BorrowerRepository
+	events     domain.EventStore
+	projection *Projection
+	auditLog   domain.AuditLog
+	documents  domain.DocumentStore
+	agreements domain.AgreementRenderer
+	verifier   domain.AgreementVerifier
+	scorer     domain.CreditScorer
+	pricer     domain.PricingEngine
+	logger     *logrus.Logger
+	machine    *domain.StateMachine
+
+	// daysPastDueThreshold is how many days an installment may be overdue before the loan defaults
+	daysPastDueThreshold int
+	// reminderLeadDays is how many days before an installment falls due the borrower is reminded
+	reminderLeadDays int
+	// lateFee is charged in the loan currency on each overdue installment, none is charged while it is zero
+	lateFee money.Decimal
+	// maxDocumentSize is the largest document that can be uploaded, in bytes
+	maxDocumentSize int64
+	// policy decides whether a borrower may apply for another loan
+	policy BorrowerPolicy
+	// applications serializes checking the policy with creating the loan per borrower, so concurrent applications
+	// of a borrower cannot both pass it and the borrower cannot be deleted in between
+	applications *BorrowerLocks
+	// investments serializes checking the limits of an investor with committing their investment, so concurrent
+	// investments of an investor in different loans cannot both pass the total limit
+	investments keyedMutex
+	// minimumGrade is the riskiest grade a loan may have to be approved without an override
+	minimumGrade domain.RiskGrade
 }
 
-// NewLoanService creates a new loan service
-func NewLoanService(repo domain.LoanRepository, emailSender domain.EmailSender, logger *logrus.Logger) domain.Service {
-	return &LoanService{
-		repo:        repo,
-		emailSender: emailSender,
-		logger:      logger,
+// Option configures optional LoanService settings
+type Option func(*LoanService)
+
+// WithDaysPastDueThreshold sets how many days an installment may be overdue before the loan defaults
+func WithDaysPastDueThreshold(days int) Option {
+	return func(s *LoanService) {
+		s.daysPastDueThreshold = days
 	}
 }
 
-// CreateLoan creates a new loan in the PROPOSED state
-func (s *LoanService) CreateLoan(borrowerID string, principal, rate, roi float64) (*domain.Loan, error) {
+// WithReminderLeadDays sets how many days before an installment falls due the borrower is reminded
+func WithReminderLeadDays(days int) Option {
+	return func(s *LoanService) {
+		s.reminderLeadDays = days
+	}
+}
+
+// WithLateFee sets the fee charged in the loan currency on each installment that becomes overdue
+func WithLateFee(fee money.Decimal) Option {
+	return func(s *LoanService) {
+		s.lateFee = fee
+	}
+}
+
+// WithMaxDocumentSize sets the largest document that can be uploaded, in bytes
+func WithMaxDocumentSize(size int64) Option {
+	return func(s *LoanService) {
+		s.maxDocumentSize = size
+	}
+}
+
+// WithBorrowerPolicy sets how many active loans a borrower may hold and how long they wait between applications
+func WithBorrowerPolicy(policy BorrowerPolicy) Option {
+	return func(s *LoanService) {
+		s.policy = policy
+	}
+}
+
+// WithBorrowerLocks shares the per-borrower locks taken while applying for a loan with the BorrowerService,
+// which takes them while deleting a borrower
+func WithBorrowerLocks(locks *BorrowerLocks) Option {
+	return func(s *LoanService) {
+		s.applications = locks
+	}
+}
+
+// WithMinimumGrade sets the riskiest grade a loan may have to be approved without an override
+func WithMinimumGrade(grade domain.RiskGrade) Option {
+	return func(s *LoanService) {
+		s.minimumGrade = grade
+	}
+}
+
+// NewLoanService creates a new loan service. Mutations are recorded as events in the event store
+// and projected onto the repository, which serves every read. The notifications they cause are
+// committed to the outbox of the event store and sent by a Dispatcher. Agreement letters are rendered
+// by agreements and kept in documents, along with the uploaded proofs and signed agreements; the signed
+// agreements are checked against the generated letters by verifier. Only the verified investors kept in
+// investors may invest, within their limits. Only registered borrowers who are not blacklisted may apply,
+// as often as the borrower policy allows. Each loan is scored by scorer and priced from its grade by pricer when it
+// is proposed, and may only be approved with the minimum grade unless the approval is justified.
+func NewLoanService(repo domain.LoanRepository, investors domain.InvestorRepository, borrowers domain.BorrowerRepository, events domain.EventStore, auditLog domain.AuditLog, documents domain.DocumentStore, agreements domain.AgreementRenderer, verifier domain.AgreementVerifier, scorer domain.CreditScorer, pricer domain.PricingEngine, logger *logrus.Logger, opts ...Option) domain.Service {
+	s := &LoanService{
+		repo:                 repo,
+		investors:            investors,
+		borrowers:            borrowers,
+		events:               events,
+		projection:           NewProjection(repo, logger),
+		auditLog:             auditLog,
+		documents:            documents,
+		agreements:           agreements,
+		verifier:             verifier,
+		scorer:               scorer,
+		pricer:               pricer,
+		logger:               logger,
+		machine:              domain.NewStateMachine(),
+		daysPastDueThreshold: domain.DefaultDaysPastDueThreshold,
+		reminderLeadDays:     domain.DefaultReminderLeadDays,
+		maxDocumentSize:      domain.DefaultMaxDocumentSize,
+		policy:               DefaultBorrowerPolicy(),
+		applications:         NewBorrowerLocks(),
+		minimumGrade:         domain.DefaultMinimumGrade,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// maxConflictAttempts bounds how often a read-modify-write is retried after a version conflict
+const maxConflictAttempts = 3
+
+// retryOnConflict runs op again while the repository reports a version conflict.
+// op must re-read the loan so every attempt works on the latest stored version.
+func (s *LoanService) retryOnConflict(function, id string, op func() error) error {
+	var err error
+	for attempt := 1; attempt <= maxConflictAttempts; attempt++ {
+		err = op()
+		if !errors.Is(err, domain.ErrVersionConflict) {
+			return err
+		}
+
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": function,
+			"loan_id":  id,
+			"attempt":  attempt,
+			"error":    err.Error(),
+		}).Warn("Loan was modified concurrently, retrying")
+	}
+	return err
+}
+
+// mutate runs op, a read-modify-write of the loan, retrying it on a version conflict like retryOnConflict
+// unless the client made it conditional on the versions in match. Retrying would then apply the mutation to a
+// version the client has not seen, so a conflict fails the precondition instead.
+func (s *LoanService) mutate(function, id string, match domain.IfMatch, op func() error) error {
+	if len(match) == 0 {
+		return s.retryOnConflict(function, id, op)
+	}
+
+	err := op()
+	var conflict *domain.VersionConflictError
+	if errors.As(err, &conflict) {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": function,
+			"loan_id":  id,
+			"error":    err.Error(),
+		}).Warn("Loan was modified concurrently, not retrying a conditional request")
+		return &domain.PreconditionError{LoanID: id, Expected: match, Actual: conflict.Actual}
+	}
+	return err
+}
+
+// checkIfMatch returns why a conditional mutation may not be applied to the loan at its current version
+func (s *LoanService) checkIfMatch(function string, loan *domain.Loan, match domain.IfMatch) error {
+	if err := match.Check(loan); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": function,
+			"loan_id":  loan.ID,
+			"version":  loan.Version,
+			"error":    err.Error(),
+		}).Error("Loan version does not match the precondition")
+		return err
+	}
+	return nil
+}
+
+// checkTransition returns why the action may not be taken on the loan in its current state
+func (s *LoanService) checkTransition(function string, loan *domain.Loan, action domain.Action) error {
+	if err := s.machine.Can(loan, action); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": function,
+			"loan_id":  loan.ID,
+			"state":    loan.State,
+			"error":    err.Error(),
+		}).Error("Invalid state transition")
+		return err
+	}
+	return nil
+}
+
+// resolveTransition returns the transition the action takes on the loan in its current state
+func (s *LoanService) resolveTransition(function string, loan *domain.Loan, action domain.Action) (domain.Transition, error) {
+	transition, err := s.machine.Resolve(loan, action)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": function,
+			"loan_id":  loan.ID,
+			"state":    loan.State,
+			"error":    err.Error(),
+		}).Error("Invalid state transition")
+		return domain.Transition{}, err
+	}
+	return transition, nil
+}
+
+// change collects the events raised by one command and applies each to the loan as it is raised
+type change struct {
+	loan       *domain.Loan
+	from       domain.LoanState
+	version    int64
+	occurredAt time.Time
+	events     []domain.Event
+	// messages are the notifications committed with the events
+	messages []domain.OutboxMessage
+	// committed is set once the events are appended to the loan stream
+	committed bool
+}
+
+func newChange(loan *domain.Loan) *change {
+	return &change{
+		loan:       loan,
+		from:       loan.State,
+		version:    loan.Version + 1,
+		occurredAt: time.Now(),
+	}
+}
+
+// raise records an event of the command and applies it to the loan
+func (c *change) raise(eventType domain.EventType, data any) error {
+	event, err := domain.NewEvent(c.loan.ID, c.version, eventType, data, c.occurredAt)
+	if err != nil {
+		return err
+	}
+	if err := c.loan.Apply(event); err != nil {
+		return err
+	}
+	c.events = append(c.events, event)
+	return nil
+}
+
+// commit appends the events of a command to the loan stream together with the notifications they cause,
+// then projects them onto the read model.
+// A new loan is projected first so the repository can refuse it before its stream exists. The stream and the
+// read model are not written atomically, so a read model left behind its stream is caught up with it: after
+// a failed projection, and on a conflict with a stream ahead of the loan, so the retry starts from the stream.
+func (s *LoanService) commit(function string, c *change) error {
+	var err error
+	if c.from == "" {
+		err = s.projection.Project(c.loan, c.events)
+		if err == nil {
+			err = s.events.Commit(c.loan.ID, c.version-1, c.events, c.messages)
+			c.committed = err == nil
+		}
+	} else {
+		err = s.events.Commit(c.loan.ID, c.version-1, c.events, c.messages)
+		var conflict *domain.VersionConflictError
+		switch {
+		case err == nil:
+			c.committed = true
+			if err = s.projection.Project(c.loan, c.events); err != nil {
+				err = s.recoverProjection(function, c, err)
+			}
+		case errors.As(err, &conflict) && conflict.Actual > conflict.Expected:
+			if caughtUp := s.catchUp(function, c.loan.ID); caughtUp != nil {
+				err = errors.Join(err, caughtUp)
+			}
+		}
+	}
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": function,
+			"loan_id":  c.loan.ID,
+			"version":  c.version,
+			"error":    err.Error(),
+		}).Error("Failed to commit loan events")
+		return err
+	}
+
+	if c.from != "" && c.loan.State != c.from {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": function,
+			"loan_id":  c.loan.ID,
+			"from":     c.from,
+			"to":       c.loan.State,
+		}).Info("Loan state changed")
+	}
+	return nil
+}
+
+// recoverProjection catches the read model up with the committed events of the change after projecting them
+// failed with cause. The events are committed, so a failure to catch up is not returned as a version conflict,
+// which would have the caller apply the command a second time.
+func (s *LoanService) recoverProjection(function string, c *change, cause error) error {
+	s.logger.WithFields(logrus.Fields{
+		"layer":    "service",
+		"function": function,
+		"loan_id":  c.loan.ID,
+		"version":  c.version,
+		"error":    cause.Error(),
+	}).Warn("Failed to project committed loan events, catching up from the stream")
+
+	if err := s.catchUp(function, c.loan.ID); err != nil {
+		return fmt.Errorf("events of loan %s at version %d are committed but not projected: %s",
+			c.loan.ID, c.version, errors.Join(cause, err))
+	}
+	c.loan.Version = c.version
+	return nil
+}
+
+// catchUp projects the events the read model of the loan is behind on, again while it conflicts with
+// another writer projecting them
+func (s *LoanService) catchUp(function, id string) error {
+	var err error
+	for attempt := 1; attempt <= maxConflictAttempts; attempt++ {
+		var caughtUp bool
+		caughtUp, err = s.projection.CatchUp(s.events, id)
+		if err == nil {
+			if caughtUp {
+				s.logger.WithFields(logrus.Fields{
+					"layer":    "service",
+					"function": function,
+					"loan_id":  id,
+				}).Warn("Loan read model caught up with its event stream")
+			}
+			return nil
+		}
+		if !errors.Is(err, domain.ErrVersionConflict) {
+			break
+		}
+	}
 	s.logger.WithFields(logrus.Fields{
+		"layer":    "service",
+		"function": function,
+		"loan_id":  id,
+		"error":    err.Error(),
+	}).Error("Failed to catch the loan read model up with its event stream")
+	return err
+}
+
+// recordAudit appends the audit entry of a stored mutation. The mutation is already committed, so a
+// failing audit log is logged rather than returned, which would invite the caller to repeat the mutation.
+func (s *LoanService) recordAudit(function string, loan *domain.Loan, actor string, action domain.Action, from domain.LoanState, payload any) {
+	entry := domain.AuditEntry{
+		ID:        utils.GenerateUUID(),
+		LoanID:    loan.ID,
+		Actor:     actor,
+		Action:    action,
+		FromState: from,
+		ToState:   loan.State,
+		Timestamp: loan.UpdatedAt,
+	}
+
+	digest, err := domain.PayloadDigest(payload)
+	if err == nil {
+		entry.PayloadDigest = digest
+		err = s.auditLog.Append(entry)
+	}
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": function,
+			"loan_id":  loan.ID,
+			"action":   action,
+			"actor":    actor,
+			"error":    err.Error(),
+		}).Error("Failed to record audit entry")
+	}
+}
+
+// notify queues the notifications of the transition effects, addressed from the loan the events brought about
+func (c *change) notify(effects []domain.Effect) error {
+	borrower := domain.Recipient{ID: c.loan.BorrowerID, Role: domain.RoleBorrower}
+	principal := c.loan.PrincipalAmount
+	for _, effect := range effects {
+		switch effect {
+		case domain.EffectNotifyApproval:
+			c.queue(domain.Notification{Kind: domain.NotificationLoanApproved, Recipient: borrower, Amount: &principal})
+		case domain.EffectNotifyInvestment:
+			// The investment of the command is the last one added to the loan
+			inv := c.loan.Investors[len(c.loan.Investors)-1]
+			amount := inv.Amount
+			c.queue(domain.Notification{Kind: domain.NotificationInvestmentReceived, Recipient: domain.InvestorRecipient(inv), Amount: &amount})
+		case domain.EffectNotifyFunded:
+			c.queue(domain.Notification{Kind: domain.NotificationLoanFunded, Recipient: borrower, Amount: &principal})
+		case domain.EffectSendAgreements:
+			for _, inv := range c.loan.Investors {
+				agreement, err := domain.NewAgreementEmail(c.loan, inv)
+				if err != nil {
+					return fmt.Errorf("failed to address agreement email to investor %s: %w", inv.ID, err)
+				}
+				c.queue(domain.Notification{
+					Kind:      domain.NotificationAgreement,
+					Recipient: domain.InvestorRecipient(inv),
+					Amount:    &agreement.Amount,
+					Agreement: &agreement,
+				})
+			}
+		case domain.EffectNotifyDisbursement:
+			c.queue(domain.Notification{Kind: domain.NotificationLoanDisbursed, Recipient: borrower, Amount: &principal})
+			for _, inv := range c.loan.Investors {
+				amount := inv.Amount
+				c.queue(domain.Notification{Kind: domain.NotificationInvestmentDisbursed, Recipient: domain.InvestorRecipient(inv), Amount: &amount})
+			}
+		case domain.EffectDistributeRepayment:
+			// The repayment of the command is the last one recorded on the loan
+			repayment := c.loan.Repayments[len(c.loan.Repayments)-1]
+			for _, inv := range c.loan.Investors {
+				share, err := c.loan.InvestorShare(repayment, inv)
+				if err != nil {
+					return fmt.Errorf("failed to share repayment with investor %s: %w", inv.ID, err)
+				}
+				c.queue(domain.Notification{Kind: domain.NotificationRepaymentDistributed, Recipient: domain.InvestorRecipient(inv), Amount: &share})
+			}
+		case domain.EffectNotifyRefunds:
+			var reason domain.ReasonCode
+			if c.loan.CancelledInfo != nil {
+				reason = c.loan.CancelledInfo.ReasonCode
+			}
+			for _, refund := range c.loan.Refunds {
+				amount := refund.Amount
+				recipient := domain.Recipient{ID: refund.InvestorID, Role: domain.RoleInvestor, Email: refund.Email}
+				for _, inv := range c.loan.Investors {
+					if inv.ID == refund.InvestorID {
+						recipient = domain.InvestorRecipient(inv)
+						break
+					}
+				}
+				c.queue(domain.Notification{Kind: domain.NotificationRefund, Recipient: recipient, Amount: &amount, Reason: reason})
+			}
+		default:
+			return fmt.Errorf("unknown transition effect %q", effect)
+		}
+	}
+	return nil
+}
+
+// queue adds a pending notification of the loan that is due right away
+func (c *change) queue(notification domain.Notification) {
+	notification.ID = utils.GenerateUUID()
+	notification.LoanID = c.loan.ID
+	c.messages = append(c.messages, domain.OutboxMessage{
+		Notification:  notification,
+		Status:        domain.OutboxPending,
+		NextAttemptAt: c.occurredAt,
+		CreatedAt:     c.occurredAt,
+	})
+}
+
+// CreateLoan creates a new loan in the PROPOSED state
+func (s *LoanService) CreateLoan(borrowerID string, principal money.Money, rate, roi *money.Decimal, terms domain.RepaymentTerms) (*domain.Loan, error) {
+	fields := logrus.Fields{
 		"layer":            "service",
 		"function":         "CreateLoan",
 		"borrower_id":      borrowerID,
-		"principal_amount": principal,
-		"rate":             rate,
-		"roi":              roi,
-	}).Info("Creating new loan")
+		"principal_amount": principal.String(),
+		"tenor_months":     terms.TenorMonths,
+		"scheme":           terms.Scheme,
+	}
+	if rate != nil {
+		fields["rate"] = rate.String()
+	}
+	if roi != nil {
+		fields["roi"] = roi.String()
+	}
+	s.logger.WithFields(fields).Info("Creating new loan")
+
+	if err := principal.Validate(); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": "CreateLoan",
+			"error":    err.Error(),
+		}).Error("Invalid principal amount")
+		return nil, fmt.Errorf("invalid principal amount: %w", err)
+	}
+	if err := terms.Validate(); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": "CreateLoan",
+			"error":    err.Error(),
+		}).Error("Invalid repayment terms")
+		return nil, err
+	}
+
+	unlock := s.applications.lock(borrowerID)
+	defer unlock()
+	borrower, loans, err := s.checkBorrower(borrowerID)
+	if err != nil {
+		return nil, err
+	}
 
-	loan := &domain.Loan{
-		ID:              utils.GenerateUUID(),
+	loan := &domain.Loan{ID: utils.GenerateUUID()}
+	c := newChange(loan)
+
+	// The loan is scored at the rate asked for, or else at the rate of the riskiest grade so its installment is
+	// never understated, then priced from the grade it is given
+	scoredRate := rate
+	if scoredRate == nil {
+		riskiest, err := s.quote(domain.PricingInput{Grade: domain.GradeE, Principal: principal, Terms: terms})
+		if err != nil {
+			return nil, err
+		}
+		scoredRate = &riskiest.Rate
+	}
+	score, err := s.scoreLoan("CreateLoan", &domain.Loan{
+		ID:              loan.ID,
+		BorrowerID:      borrowerID,
+		PrincipalAmount: principal,
+		Rate:            *scoredRate,
+		Terms:           terms,
+		CreatedAt:       c.occurredAt,
+	}, borrower, loans)
+	if err != nil {
+		return nil, err
+	}
+	quote, err := s.quote(domain.PricingInput{Grade: score.Grade, Principal: principal, Terms: terms})
+	if err != nil {
+		return nil, err
+	}
+	loanRate, loanROI, err := quote.Resolve(rate, roi)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":       "service",
+			"function":    "CreateLoan",
+			"borrower_id": borrowerID,
+			"grade":       score.Grade,
+			"error":       err.Error(),
+		}).Error("Invalid loan pricing")
+		return nil, err
+	}
+
+	err = c.raise(domain.EventLoanProposed, domain.LoanProposedData{
 		BorrowerID:      borrowerID,
 		PrincipalAmount: principal,
-		Rate:            rate,
-		ROI:             roi,
-		State:           domain.StateProposed,
-		CreatedAt:       time.Now(),
-		UpdatedAt:       time.Now(),
+		Rate:            loanRate,
+		ROI:             loanROI,
+		Terms:           terms,
+	})
+	if err != nil {
+		return nil, err
+	}
+	score.ScoredAt = c.occurredAt
+	if err := c.raise(domain.EventLoanScored, domain.LoanScoredData{Score: *score}); err != nil {
+		return nil, err
+	}
+
+	if err := s.commit("CreateLoan", c); err != nil {
+		return nil, err
+	}
+
+	s.recordAudit("CreateLoan", loan, borrowerID, domain.ActionCreate, "", map[string]any{
+		"borrower_id":      borrowerID,
+		"principal_amount": principal,
+		"rate":             loanRate,
+		"roi":              loanROI,
+		"manual_rate":      rate != nil,
+		"manual_roi":       roi != nil,
+		"rate_card":        quote.RateCard,
+		"terms":            terms,
+		"credit_score":     loan.CreditScore,
+	})
+
+	s.logger.WithFields(logrus.Fields{
+		"layer":    "service",
+		"function": "CreateLoan",
+		"loan_id":  loan.ID,
+		"score":    loan.CreditScore.Score,
+		"grade":    loan.CreditScore.Grade,
+		"rate":     loan.Rate.String(),
+		"roi":      loan.ROI.String(),
+	}).Info("Loan created successfully")
+	return loan, nil
+}
+
+// checkBorrower returns why the borrower may not apply for a new loan, otherwise the borrower and their loans
+func (s *LoanService) checkBorrower(borrowerID string) (*domain.Borrower, []*domain.Loan, error) {
+	borrower, err := s.borrowers.FindByID(borrowerID)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":       "service",
+			"function":    "CreateLoan",
+			"borrower_id": borrowerID,
+			"error":       err.Error(),
+		}).Error("Failed to find borrower")
+		return nil, nil, fmt.Errorf("failed to find borrower: %w", err)
+	}
+	if err := borrower.CheckEligibility(); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":       "service",
+			"function":    "CreateLoan",
+			"borrower_id": borrowerID,
+			"error":       err.Error(),
+		}).Error("Borrower may not apply for a loan")
+		return nil, nil, err
+	}
+
+	loans, err := s.repo.FindByBorrowerID(borrowerID)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":       "service",
+			"function":    "CreateLoan",
+			"borrower_id": borrowerID,
+			"error":       err.Error(),
+		}).Error("Failed to find loans of borrower")
+		return nil, nil, fmt.Errorf("failed to find loans of borrower: %w", err)
+	}
+	if err := s.policy.Check(borrowerID, loans, time.Now()); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":       "service",
+			"function":    "CreateLoan",
+			"borrower_id": borrowerID,
+			"error":       err.Error(),
+		}).Error("Borrower policy refuses another loan")
+		return nil, nil, err
+	}
+	return borrower, loans, nil
+}
+
+// scoreLoan returns the credit score of the loan, assessed from the borrower and their other loans.
+// ScoredAt is left to the caller.
+func (s *LoanService) scoreLoan(function string, loan *domain.Loan, borrower *domain.Borrower, loans []*domain.Loan) (*domain.CreditScore, error) {
+	var others []*domain.Loan
+	for _, l := range loans {
+		if l.ID != loan.ID {
+			others = append(others, l)
+		}
+	}
+
+	profile, err := domain.NewCreditProfile(borrower, others)
+	var score *domain.CreditScore
+	if err == nil {
+		score, err = s.scorer.Score(domain.ScoringInput{Loan: loan, Borrower: borrower, Profile: profile})
+	}
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":       "service",
+			"function":    function,
+			"loan_id":     loan.ID,
+			"borrower_id": borrower.ID,
+			"error":       err.Error(),
+		}).Error("Failed to score loan")
+		return nil, fmt.Errorf("failed to score loan: %w", err)
+	}
+	return score, nil
+}
+
+// quote prices a loan proposal from the rate card
+func (s *LoanService) quote(input domain.PricingInput) (*domain.Quote, error) {
+	quote, err := s.pricer.Price(input)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":            "service",
+			"function":         "CreateLoan",
+			"grade":            input.Grade,
+			"principal_amount": input.Principal.String(),
+			"tenor_months":     input.Terms.TenorMonths,
+			"error":            err.Error(),
+		}).Error("Failed to price loan")
+		return nil, fmt.Errorf("failed to price loan: %w", err)
+	}
+	return quote, nil
+}
+
+// rescoreLoan raises the credit score of the loan of the change, assessed from its borrower as they are now
+func (s *LoanService) rescoreLoan(function string, c *change) error {
+	borrower, err := s.borrowers.FindByID(c.loan.BorrowerID)
+	if err != nil {
+		entry := s.logger.WithFields(logrus.Fields{
+			"layer":       "service",
+			"function":    function,
+			"loan_id":     c.loan.ID,
+			"borrower_id": c.loan.BorrowerID,
+			"error":       err.Error(),
+		})
+		if errors.Is(err, domain.ErrBorrowerNotFound) {
+			entry.Warn("Borrower of the loan is not registered")
+		} else {
+			entry.Error("Failed to find borrower")
+		}
+		return fmt.Errorf("failed to find borrower: %w", err)
+	}
+	loans, err := s.repo.FindByBorrowerID(c.loan.BorrowerID)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":       "service",
+			"function":    function,
+			"loan_id":     c.loan.ID,
+			"borrower_id": c.loan.BorrowerID,
+			"error":       err.Error(),
+		}).Error("Failed to find loans of borrower")
+		return fmt.Errorf("failed to find loans of borrower: %w", err)
+	}
+	score, err := s.scoreLoan(function, c.loan, borrower, loans)
+	if err != nil {
+		return err
+	}
+	score.ScoredAt = c.occurredAt
+	return c.raise(domain.EventLoanScored, domain.LoanScoredData{Score: *score})
+}
+
+// approveUnscored returns why a loan whose borrower is not registered, so it cannot be scored, may not be
+// approved: like a loan graded below the minimum, it needs an overrideJustification
+func (s *LoanService) approveUnscored(loan *domain.Loan, validatorID, overrideJustification string) error {
+	if overrideJustification == "" {
+		s.logger.WithFields(logrus.Fields{
+			"layer":       "service",
+			"function":    "ApproveLoan",
+			"loan_id":     loan.ID,
+			"borrower_id": loan.BorrowerID,
+		}).Error("Loan of an unregistered borrower cannot be scored")
+		return fmt.Errorf("%w: loan %s cannot be scored as borrower %s is not registered, approval requires a justification",
+			domain.ErrRiskGradeTooLow, loan.ID, loan.BorrowerID)
+	}
+	s.logger.WithFields(logrus.Fields{
+		"layer":                  "service",
+		"function":               "ApproveLoan",
+		"loan_id":                loan.ID,
+		"borrower_id":            loan.BorrowerID,
+		"validator_id":           validatorID,
+		"override_justification": overrideJustification,
+	}).Warn("Unscored loan of an unregistered borrower is overridden")
+	return nil
+}
+
+// ApproveLoan transitions a loan from PROPOSED to APPROVED state and generates its agreement letter,
+// proofDocumentID must reference a proof uploaded to the loan. A loan graded riskier than the minimum
+// grade is only approved with an overrideJustification.
+func (s *LoanService) ApproveLoan(id, validatorID, proofDocumentID, overrideJustification string, match domain.IfMatch) (int64, error) {
+	overrideJustification = strings.TrimSpace(overrideJustification)
+	s.logger.WithFields(logrus.Fields{
+		"layer":        "service",
+		"function":     "ApproveLoan",
+		"loan_id":      id,
+		"validator_id": validatorID,
+		"document_id":  proofDocumentID,
+		"override":     overrideJustification != "",
+	}).Info("Approving loan")
+
+	proof, err := s.findDocument("ApproveLoan", id, proofDocumentID, domain.DocumentProof)
+	if err != nil {
+		return 0, err
+	}
+
+	var version int64
+	err = s.mutate("ApproveLoan", id, match, func() error {
+		var err error
+		version, err = s.approveLoan(id, validatorID, proof, overrideJustification, match)
+		return err
+	})
+	return version, err
+}
+
+func (s *LoanService) approveLoan(id, validatorID string, proof *domain.Document, overrideJustification string, match domain.IfMatch) (int64, error) {
+	loan, err := s.repo.FindByID(id)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": "ApproveLoan",
+			"loan_id":  id,
+			"error":    err.Error(),
+		}).Error("Failed to find loan")
+		return 0, fmt.Errorf("failed to find loan: %w", err)
+	}
+	if err := s.checkIfMatch("ApproveLoan", loan, match); err != nil {
+		return 0, err
+	}
+
+	transition, err := s.resolveTransition("ApproveLoan", loan, domain.ActionApprove)
+	if err != nil {
+		return 0, err
+	}
+
+	c := newChange(loan)
+	// Loans proposed before they were scored on creation are scored when they are approved. Those proposed before
+	// borrowers were registered may have none to be scored from, and are approved unscored with a justification.
+	if loan.CreditScore == nil {
+		if err := s.rescoreLoan("ApproveLoan", c); err != nil && !errors.Is(err, domain.ErrBorrowerNotFound) {
+			return 0, err
+		}
+	}
+	if loan.CreditScore == nil {
+		if err := s.approveUnscored(loan, validatorID, overrideJustification); err != nil {
+			return 0, err
+		}
+	} else if err := loan.CreditScore.CheckApproval(id, s.minimumGrade, overrideJustification); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": "ApproveLoan",
+			"loan_id":  id,
+			"grade":    loan.CreditScore.Grade,
+			"error":    err.Error(),
+		}).Error("Loan grade is below the minimum")
+		return 0, err
+	}
+	if loan.CreditScore != nil && !loan.CreditScore.Grade.AtLeast(s.minimumGrade) {
+		s.logger.WithFields(logrus.Fields{
+			"layer":                  "service",
+			"function":               "ApproveLoan",
+			"loan_id":                id,
+			"validator_id":           validatorID,
+			"grade":                  loan.CreditScore.Grade,
+			"minimum_grade":          s.minimumGrade,
+			"override_justification": overrideJustification,
+		}).Warn("Loan grade below the minimum is overridden")
+	}
+
+	err = c.raise(domain.EventLoanApproved, domain.LoanApprovedData{Approval: domain.Approval{
+		ValidatorID:           validatorID,
+		ProofDocumentID:       proof.ID,
+		ProofURL:              proof.URL,
+		OverrideJustification: overrideJustification,
+		Date:                  c.occurredAt,
+	}})
+	if err != nil {
+		return 0, err
+	}
+
+	// The agreement letter is generated with the approval, so investors always have one to receive
+	agreement, err := s.storeAgreementLetter("ApproveLoan", loan)
+	if err != nil {
+		return 0, err
+	}
+	defer s.discardAgreementLetter("ApproveLoan", c, agreement)
+	if err := c.raise(domain.EventAgreementGenerated, domain.AgreementGeneratedData{LetterURL: agreement.URL, DocumentID: agreement.ID}); err != nil {
+		return 0, err
+	}
+
+	if err := c.notify(transition.Effects); err != nil {
+		return 0, err
+	}
+	if err := s.commit("ApproveLoan", c); err != nil {
+		return 0, err
+	}
+
+	// A loan approved unscored is recorded without a grade
+	var grade domain.RiskGrade
+	if loan.CreditScore != nil {
+		grade = loan.CreditScore.Grade
+	}
+	s.recordAudit("ApproveLoan", loan, validatorID, domain.ActionApprove, c.from, map[string]any{
+		"validator_id":           validatorID,
+		"proof_document_id":      proof.ID,
+		"proof_url":              proof.URL,
+		"grade":                  grade,
+		"override_justification": overrideJustification,
+	})
+	s.recordAudit("ApproveLoan", loan, domain.SystemActor, domain.ActionAttachAgreement, loan.State,
+		map[string]any{"document_id": agreement.ID, "letter_url": agreement.URL})
+
+	s.logger.WithFields(logrus.Fields{
+		"layer":    "service",
+		"function": "ApproveLoan",
+		"loan_id":  id,
+	}).Info("Loan approved successfully")
+	return loan.Version, nil
+}
+
+// RejectLoan transitions a loan from PROPOSED to REJECTED state
+func (s *LoanService) RejectLoan(id, actorID string, reason domain.ReasonCode, match domain.IfMatch) (int64, error) {
+	s.logger.WithFields(logrus.Fields{
+		"layer":       "service",
+		"function":    "RejectLoan",
+		"loan_id":     id,
+		"actor_id":    actorID,
+		"reason_code": reason,
+	}).Info("Rejecting loan")
+
+	if actorID == "" {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": "RejectLoan",
+			"loan_id":  id,
+		}).Error("Actor ID cannot be empty")
+		return 0, errors.New("actor ID cannot be empty")
+	}
+	if err := reason.Validate(); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": "RejectLoan",
+			"loan_id":  id,
+			"error":    err.Error(),
+		}).Error("Invalid reason code")
+		return 0, err
+	}
+
+	var version int64
+	err := s.mutate("RejectLoan", id, match, func() error {
+		var err error
+		version, err = s.rejectLoan(id, actorID, reason, match)
+		return err
+	})
+	return version, err
+}
+
+func (s *LoanService) rejectLoan(id, actorID string, reason domain.ReasonCode, match domain.IfMatch) (int64, error) {
+	loan, err := s.repo.FindByID(id)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": "RejectLoan",
+			"loan_id":  id,
+			"error":    err.Error(),
+		}).Error("Failed to find loan")
+		return 0, fmt.Errorf("failed to find loan: %w", err)
+	}
+	if err := s.checkIfMatch("RejectLoan", loan, match); err != nil {
+		return 0, err
+	}
+
+	transition, err := s.resolveTransition("RejectLoan", loan, domain.ActionReject)
+	if err != nil {
+		return 0, err
+	}
+
+	c := newChange(loan)
+	err = c.raise(domain.EventLoanRejected, domain.LoanClosedData{Closure: domain.Closure{
+		ActorID:    actorID,
+		ReasonCode: reason,
+		Date:       c.occurredAt,
+	}})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := c.notify(transition.Effects); err != nil {
+		return 0, err
+	}
+	if err := s.commit("RejectLoan", c); err != nil {
+		return 0, err
+	}
+
+	s.recordAudit("RejectLoan", loan, actorID, domain.ActionReject, c.from, map[string]any{"actor_id": actorID, "reason_code": reason})
+
+	s.logger.WithFields(logrus.Fields{
+		"layer":    "service",
+		"function": "RejectLoan",
+		"loan_id":  id,
+	}).Info("Loan rejected successfully")
+	return loan.Version, nil
+}
+
+// CancelLoan transitions a PROPOSED or APPROVED loan to CANCELLED state.
+// Investors of a partially funded loan are refunded and notified.
+func (s *LoanService) CancelLoan(id, actorID string, reason domain.ReasonCode, match domain.IfMatch) (int64, error) {
+	s.logger.WithFields(logrus.Fields{
+		"layer":       "service",
+		"function":    "CancelLoan",
+		"loan_id":     id,
+		"actor_id":    actorID,
+		"reason_code": reason,
+	}).Info("Cancelling loan")
+
+	if actorID == "" {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": "CancelLoan",
+			"loan_id":  id,
+		}).Error("Actor ID cannot be empty")
+		return 0, errors.New("actor ID cannot be empty")
+	}
+	if err := reason.Validate(); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": "CancelLoan",
+			"loan_id":  id,
+			"error":    err.Error(),
+		}).Error("Invalid reason code")
+		return 0, err
+	}
+
+	var version int64
+	err := s.mutate("CancelLoan", id, match, func() error {
+		var err error
+		version, err = s.cancelLoan(id, actorID, reason, match)
+		return err
+	})
+	return version, err
+}
+
+func (s *LoanService) cancelLoan(id, actorID string, reason domain.ReasonCode, match domain.IfMatch) (int64, error) {
+	loan, err := s.repo.FindByID(id)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": "CancelLoan",
+			"loan_id":  id,
+			"error":    err.Error(),
+		}).Error("Failed to find loan")
+		return 0, fmt.Errorf("failed to find loan: %w", err)
+	}
+	if err := s.checkIfMatch("CancelLoan", loan, match); err != nil {
+		return 0, err
+	}
+
+	transition, err := s.resolveTransition("CancelLoan", loan, domain.ActionCancel)
+	if err != nil {
+		return 0, err
+	}
+
+	var refunds []domain.Refund
+	for _, inv := range loan.Investors {
+		refunds = append(refunds, domain.Refund{
+			InvestorID: inv.ID,
+			Amount:     inv.Amount,
+			Email:      inv.Email,
+		})
+	}
+
+	c := newChange(loan)
+	err = c.raise(domain.EventLoanCancelled, domain.LoanClosedData{
+		Closure: domain.Closure{
+			ActorID:    actorID,
+			ReasonCode: reason,
+			Date:       c.occurredAt,
+		},
+		Refunds: refunds,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := c.notify(transition.Effects); err != nil {
+		return 0, err
+	}
+	if err := s.commit("CancelLoan", c); err != nil {
+		return 0, err
+	}
+
+	s.recordAudit("CancelLoan", loan, actorID, domain.ActionCancel, c.from, map[string]any{"actor_id": actorID, "reason_code": reason})
+
+	s.logger.WithFields(logrus.Fields{
+		"layer":    "service",
+		"function": "CancelLoan",
+		"loan_id":  id,
+		"refunds":  len(loan.Refunds),
+	}).Info("Loan cancelled successfully")
+	return loan.Version, nil
+}
+
+// AddInvestment adds an investment to a loan
+// If the total invested amount equals the principal, the loan transitions to INVESTED state
+func (s *LoanService) AddInvestment(id string, investor domain.Investor, match domain.IfMatch) (int64, error) {
+	s.logger.WithFields(logrus.Fields{
+		"layer":       "service",
+		"function":    "AddInvestment",
+		"loan_id":     id,
+		"investor_id": investor.ID,
+		"email":       investor.Email,
+		"amount":      investor.Amount.String(),
+	}).Info("Adding investment to loan")
+
+	unlock := s.investments.Lock(investor.ID)
+	defer unlock()
+
+	var version int64
+	err := s.mutate("AddInvestment", id, match, func() error {
+		var err error
+		version, err = s.addInvestment(id, investor, match)
+		return err
+	})
+	return version, err
+}
+
+func (s *LoanService) addInvestment(id string, investor domain.Investor, match domain.IfMatch) (int64, error) {
+	amount := investor.Amount
+	loan, err := s.repo.FindByID(id)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": "AddInvestment",
+			"loan_id":  id,
+			"error":    err.Error(),
+		}).Error("Failed to find loan")
+		return 0, fmt.Errorf("failed to find loan: %w", err)
+	}
+	if err := s.checkIfMatch("AddInvestment", loan, match); err != nil {
+		return 0, err
+	}
+
+	if err := s.checkTransition("AddInvestment", loan, domain.ActionInvest); err != nil {
+		return 0, err
+	}
+
+	if err := amount.Validate(); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": "AddInvestment",
+			"loan_id":  id,
+			"error":    err.Error(),
+		}).Error("Invalid investment amount")
+		return 0, fmt.Errorf("invalid investment amount: %w", err)
+	}
+	if amount.Currency != loan.PrincipalAmount.Currency {
+		s.logger.WithFields(logrus.Fields{
+			"layer":         "service",
+			"function":      "AddInvestment",
+			"loan_id":       id,
+			"currency":      amount.Currency,
+			"loan_currency": loan.PrincipalAmount.Currency,
+		}).Error("Investment currency does not match loan currency")
+		return 0, fmt.Errorf("%w: investment in %s, loan in %s", money.ErrCurrencyMismatch, amount.Currency, loan.PrincipalAmount.Currency)
+	}
+
+	if err := s.checkInvestor(loan, &investor); err != nil {
+		return 0, err
+	}
+
+	// Calculate total invested amount including the new investment
+	amounts := []money.Money{amount}
+	for _, inv := range loan.Investors {
+		amounts = append(amounts, inv.Amount)
+	}
+	total, err := money.Sum(loan.PrincipalAmount.Currency, amounts...)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": "AddInvestment",
+			"loan_id":  id,
+			"error":    err.Error(),
+		}).Error("Failed to total investments")
+		return 0, fmt.Errorf("failed to total investments: %w", err)
+	}
+
+	// total is in the loan currency, so the amounts can be compared directly
+	if total.Amount.Cmp(loan.PrincipalAmount.Amount) > 0 {
+		s.logger.WithFields(logrus.Fields{
+			"layer":     "service",
+			"function":  "AddInvestment",
+			"loan_id":   id,
+			"total":     total.String(),
+			"principal": loan.PrincipalAmount.String(),
+		}).Error("Investment exceeds principal")
+		return 0, fmt.Errorf("investment exceeds principal: %s > %s", total, loan.PrincipalAmount)
+	}
+
+	c := newChange(loan)
+	err = c.raise(domain.EventInvestmentAdded, domain.InvestmentAddedData{Investor: investor})
+	if err != nil {
+		return 0, err
+	}
+
+	// The loan moves to INVESTED once the investments add up to the principal
+	transition, err := s.resolveTransition("AddInvestment", loan, domain.ActionInvest)
+	if err != nil {
+		return 0, err
+	}
+	if transition.To == domain.StateInvested {
+		if err := c.raise(domain.EventLoanFullyFunded, struct{}{}); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := c.notify(transition.Effects); err != nil {
+		return 0, err
+	}
+	if err := s.commit("AddInvestment", c); err != nil {
+		return 0, err
+	}
+
+	s.recordAudit("AddInvestment", loan, investor.ID, domain.ActionInvest, c.from, map[string]any{
+		"investor_id": investor.ID,
+		"name":        investor.Name,
+		"email":       investor.Email,
+		"locale":      investor.Locale,
+		"amount":      amount,
+	})
+
+	s.logger.WithFields(logrus.Fields{
+		"layer":    "service",
+		"function": "AddInvestment",
+		"loan_id":  id,
+	}).Info("Investment added successfully")
+	return loan.Version, nil
+}
+
+// checkInvestor returns why the investor may not invest in the loan and fills in the contact details the
+// investment leaves out from their profile
+func (s *LoanService) checkInvestor(loan *domain.Loan, investor *domain.Investor) error {
+	profile, err := s.investors.FindByID(investor.ID)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":       "service",
+			"function":    "AddInvestment",
+			"loan_id":     loan.ID,
+			"investor_id": investor.ID,
+			"error":       err.Error(),
+		}).Error("Failed to find investor")
+		return fmt.Errorf("failed to find investor: %w", err)
+	}
+
+	var invested []*domain.Loan
+	if profile.Limits.Total != nil {
+		if invested, err = s.repo.FindByInvestorID(investor.ID); err != nil {
+			return fmt.Errorf("failed to find loans of investor: %w", err)
+		}
+	}
+	if err := profile.CheckInvestment(loan, investor.Amount, invested); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":       "service",
+			"function":    "AddInvestment",
+			"loan_id":     loan.ID,
+			"investor_id": investor.ID,
+			"error":       err.Error(),
+		}).Error("Investor may not invest")
+		return err
+	}
+
+	if investor.Name == "" {
+		investor.Name = profile.Name
+	}
+	if investor.Email == "" {
+		investor.Email = profile.Email
+	}
+	if investor.Locale == "" {
+		investor.Locale = profile.Locale
+	}
+	return nil
+}
+
+// DisburseLoan transitions a loan from INVESTED to DISBURSED state, signedAgreementDocumentID must reference
+// a signed agreement uploaded to the loan
+func (s *LoanService) DisburseLoan(id, fieldOfficerID, signedAgreementDocumentID string, match domain.IfMatch) (int64, error) {
+	s.logger.WithFields(logrus.Fields{
+		"layer":            "service",
+		"function":         "DisburseLoan",
+		"loan_id":          id,
+		"field_officer_id": fieldOfficerID,
+	}).Info("Disbursing loan")
+
+	// Validate input
+	if id == "" {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": "DisburseLoan",
+		}).Error("Loan ID cannot be empty")
+		return 0, errors.New("loan ID cannot be empty")
+	}
+	if fieldOfficerID == "" {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": "DisburseLoan",
+			"loan_id":  id,
+		}).Error("Field officer ID cannot be empty")
+		return 0, errors.New("field officer ID cannot be empty")
+	}
+	if signedAgreementDocumentID == "" {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": "DisburseLoan",
+			"loan_id":  id,
+		}).Error("Signed agreement document ID cannot be empty")
+		return 0, errors.New("signed agreement document ID cannot be empty")
+	}
+
+	signed, err := s.findDocument("DisburseLoan", id, signedAgreementDocumentID, domain.DocumentSignedAgreement)
+	if err != nil {
+		return 0, err
+	}
+
+	var version int64
+	err = s.mutate("DisburseLoan", id, match, func() error {
+		var err error
+		version, err = s.disburseLoan(id, fieldOfficerID, signed, match)
+		return err
+	})
+	return version, err
+}
+
+func (s *LoanService) disburseLoan(id, fieldOfficerID string, signed *domain.Document, match domain.IfMatch) (int64, error) {
+	loan, err := s.repo.FindByID(id)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": "DisburseLoan",
+			"loan_id":  id,
+			"error":    err.Error(),
+		}).Error("Failed to find loan")
+		return 0, fmt.Errorf("failed to find loan: %w", err)
+	}
+	if err := s.checkIfMatch("DisburseLoan", loan, match); err != nil {
+		return 0, err
+	}
+
+	transition, err := s.resolveTransition("DisburseLoan", loan, domain.ActionDisburse)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := s.verifySignedAgreement(loan, signed); err != nil {
+		return 0, err
+	}
+
+	c := newChange(loan)
+	schedule, err := domain.GenerateSchedule(loan.PrincipalAmount, loan.Rate, loan.Terms, c.occurredAt)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": "DisburseLoan",
+			"loan_id":  id,
+			"error":    err.Error(),
+		}).Error("Failed to generate repayment schedule")
+		return 0, fmt.Errorf("failed to generate repayment schedule: %w", err)
+	}
+
+	err = c.raise(domain.EventLoanDisbursed, domain.LoanDisbursedData{
+		Disbursement: domain.Disbursement{
+			SignedAgreementDocumentID: signed.ID,
+			SignedAgreement:           signed.URL,
+			FieldOfficerID:            fieldOfficerID,
+			Date:                      c.occurredAt,
+		},
+		Schedule: schedule,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := c.notify(transition.Effects); err != nil {
+		return 0, err
+	}
+	if err := s.commit("DisburseLoan", c); err != nil {
+		return 0, err
+	}
+
+	s.recordAudit("DisburseLoan", loan, fieldOfficerID, domain.ActionDisburse, c.from, map[string]any{"field_officer_id": fieldOfficerID, "signed_agreement_document_id": signed.ID, "signed_agreement": signed.URL})
+
+	s.logger.WithFields(logrus.Fields{
+		"layer":        "service",
+		"function":     "DisburseLoan",
+		"loan_id":      id,
+		"installments": len(schedule),
+	}).Info("Loan disbursed successfully")
+	return loan.Version, nil
+}
+
+// verifySignedAgreement checks the signed agreement against the agreement letter last generated for the loan
+func (s *LoanService) verifySignedAgreement(loan *domain.Loan, signed *domain.Document) error {
+	if loan.AgreementDocumentID == "" {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": "DisburseLoan",
+			"loan_id":  loan.ID,
+		}).Error("No agreement letter was generated for the loan")
+		return &domain.AgreementVerificationError{LoanID: loan.ID, Problems: []string{"no agreement letter was generated for the loan"}}
+	}
+
+	agreement, err := s.documents.Read(loan.AgreementDocumentID)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":       "service",
+			"function":    "DisburseLoan",
+			"loan_id":     loan.ID,
+			"document_id": loan.AgreementDocumentID,
+			"error":       err.Error(),
+		}).Error("Failed to read agreement letter")
+		return fmt.Errorf("failed to read agreement letter: %w", err)
+	}
+	content, err := s.documents.Read(signed.ID)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":       "service",
+			"function":    "DisburseLoan",
+			"loan_id":     loan.ID,
+			"document_id": signed.ID,
+			"error":       err.Error(),
+		}).Error("Failed to read signed agreement")
+		return fmt.Errorf("failed to read signed agreement: %w", err)
+	}
+
+	if err := s.verifier.VerifySignedAgreement(loan, agreement, content); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":       "service",
+			"function":    "DisburseLoan",
+			"loan_id":     loan.ID,
+			"document_id": signed.ID,
+			"error":       err.Error(),
+		}).Error("Signed agreement rejected")
+		return err
+	}
+	return nil
+}
+
+// GenerateAgreementLetter regenerates the agreement letter of an APPROVED or INVESTED loan from its current
+// terms and investors. The new letter is stored as another PDF document and the loan refers to it from then on;
+// the letters generated before are kept and listed by GetAgreements.
+func (s *LoanService) GenerateAgreementLetter(id string, match domain.IfMatch) (*domain.Document, int64, error) {
+	s.logger.WithFields(logrus.Fields{
+		"layer":    "service",
+		"function": "GenerateAgreementLetter",
+		"loan_id":  id,
+	}).Info("Regenerating agreement letter")
+
+	if id == "" {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": "GenerateAgreementLetter",
+		}).Error("Loan ID cannot be empty")
+		return nil, 0, errors.New("loan ID cannot be empty")
+	}
+
+	var document *domain.Document
+	var version int64
+	err := s.mutate("GenerateAgreementLetter", id, match, func() error {
+		var err error
+		document, version, err = s.generateAgreementLetter(id, match)
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return document, version, nil
+}
+
+func (s *LoanService) generateAgreementLetter(id string, match domain.IfMatch) (*domain.Document, int64, error) {
+	loan, err := s.repo.FindByID(id)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": "GenerateAgreementLetter",
+			"loan_id":  id,
+			"error":    err.Error(),
+		}).Error("Failed to find loan")
+		return nil, 0, fmt.Errorf("failed to find loan: %w", err)
+	}
+	if err := s.checkIfMatch("GenerateAgreementLetter", loan, match); err != nil {
+		return nil, 0, err
+	}
+
+	// Once disbursed the signed letter is binding, before approval there are no terms to agree on
+	if loan.State != domain.StateApproved && loan.State != domain.StateInvested {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": "GenerateAgreementLetter",
+			"loan_id":  id,
+			"state":    loan.State,
+		}).Error("Agreement letter cannot be regenerated in the loan state")
+		return nil, 0, fmt.Errorf("%w: agreement letters are regenerated while the loan is %s or %s, loan %s is %s",
+			domain.ErrInvalidTransition, domain.StateApproved, domain.StateInvested, id, loan.State)
+	}
+
+	c := newChange(loan)
+	document, err := s.storeAgreementLetter("GenerateAgreementLetter", loan)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer s.discardAgreementLetter("GenerateAgreementLetter", c, document)
+
+	if err := c.raise(domain.EventAgreementGenerated, domain.AgreementGeneratedData{LetterURL: document.URL, DocumentID: document.ID}); err != nil {
+		return nil, 0, err
+	}
+
+	if err := s.commit("GenerateAgreementLetter", c); err != nil {
+		return nil, 0, err
+	}
+
+	s.recordAudit("GenerateAgreementLetter", loan, domain.SystemActor, domain.ActionAttachAgreement, loan.State,
+		map[string]any{"document_id": document.ID, "letter_url": document.URL})
+
+	s.logger.WithFields(logrus.Fields{
+		"layer":       "service",
+		"function":    "GenerateAgreementLetter",
+		"loan_id":     id,
+		"document_id": document.ID,
+		"letter_url":  document.URL,
+	}).Info("Agreement letter regenerated successfully")
+	return document, loan.Version, nil
+}
+
+// storeAgreementLetter renders the agreement letter of the loan as it is and stores it as a new document.
+// The letter is stored before the change referring to it is committed, so the caller defers
+// discardAgreementLetter to delete it when the attempt fails or conflicts.
+func (s *LoanService) storeAgreementLetter(function string, loan *domain.Loan) (*domain.Document, error) {
+	content, err := s.agreements.RenderAgreement(loan)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": function,
+			"loan_id":  loan.ID,
+			"error":    err.Error(),
+		}).Error("Failed to render agreement letter")
+		return nil, fmt.Errorf("failed to render agreement letter: %w", err)
+	}
+
+	document, err := s.documents.Save(domain.Document{
+		LoanID:      loan.ID,
+		Kind:        domain.DocumentAgreement,
+		Name:        fmt.Sprintf("agreement-%s.pdf", loan.ID),
+		ContentType: domain.ContentTypePDF,
+	}, content)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": function,
+			"loan_id":  loan.ID,
+			"error":    err.Error(),
+		}).Error("Failed to store agreement letter")
+		return nil, fmt.Errorf("failed to store agreement letter: %w", err)
+	}
+	return document, nil
+}
+
+// discardAgreementLetter deletes a letter stored for a change whose events were not committed, so a failed or
+// conflicting attempt leaves no unreferenced document behind. A letter that cannot be deleted is only logged,
+// the failure of the attempt is what the caller reports.
+func (s *LoanService) discardAgreementLetter(function string, c *change, document *domain.Document) {
+	if c.committed {
+		return
+	}
+	if err := s.documents.Delete(document.ID); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":       "service",
+			"function":    function,
+			"loan_id":     c.loan.ID,
+			"document_id": document.ID,
+			"error":       err.Error(),
+		}).Error("Failed to delete unreferenced agreement letter")
+	}
+}
+
+// GetAgreements lists every agreement letter generated for a loan, oldest first
+func (s *LoanService) GetAgreements(id string) ([]domain.AgreementVersion, error) {
+	s.logger.WithFields(logrus.Fields{
+		"layer":    "service",
+		"function": "GetAgreements",
+		"loan_id":  id,
+	}).Info("Retrieving agreement letters")
+
+	if _, err := s.repo.FindByID(id); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": "GetAgreements",
+			"loan_id":  id,
+			"error":    err.Error(),
+		}).Error("Failed to find loan")
+		return nil, fmt.Errorf("failed to find loan: %w", err)
+	}
+
+	// The read model only holds the current letter, the stream records every one
+	events, err := s.events.Load(id)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": "GetAgreements",
+			"loan_id":  id,
+			"error":    err.Error(),
+		}).Error("Failed to load loan events")
+		return nil, fmt.Errorf("failed to load loan events: %w", err)
+	}
+	versions, err := domain.AgreementVersions(events)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": "GetAgreements",
+			"loan_id":  id,
+			"error":    err.Error(),
+		}).Error("Failed to read agreement letters")
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"layer":    "service",
+		"function": "GetAgreements",
+		"loan_id":  id,
+		"versions": len(versions),
+	}).Info("Agreement letters retrieved successfully")
+	return versions, nil
+}
+
+// UploadDocument stores a proof or signed agreement of a loan. Its content type is sniffed from the
+// content and must be one its kind accepts.
+func (s *LoanService) UploadDocument(id string, upload domain.DocumentUpload) (*domain.Document, error) {
+	s.logger.WithFields(logrus.Fields{
+		"layer":    "service",
+		"function": "UploadDocument",
+		"loan_id":  id,
+		"kind":     upload.Kind,
+		"size":     len(upload.Content),
+	}).Info("Uploading document")
+
+	if id == "" {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": "UploadDocument",
+		}).Error("Loan ID cannot be empty")
+		return nil, errors.New("loan ID cannot be empty")
+	}
+	if len(upload.Content) == 0 {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": "UploadDocument",
+			"loan_id":  id,
+		}).Error("Document cannot be empty")
+		return nil, errors.New("document cannot be empty")
+	}
+	if int64(len(upload.Content)) > s.maxDocumentSize {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": "UploadDocument",
+			"loan_id":  id,
+			"size":     len(upload.Content),
+		}).Error("Document too large")
+		return nil, fmt.Errorf("%w: %d bytes exceeds the limit of %d bytes", domain.ErrDocumentTooLarge, len(upload.Content), s.maxDocumentSize)
+	}
+	contentType, err := upload.SniffContentType()
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": "UploadDocument",
+			"loan_id":  id,
+			"error":    err.Error(),
+		}).Error("Document rejected")
+		return nil, err
+	}
+
+	if _, err := s.repo.FindByID(id); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": "UploadDocument",
+			"loan_id":  id,
+			"error":    err.Error(),
+		}).Error("Failed to find loan")
+		return nil, fmt.Errorf("failed to find loan: %w", err)
+	}
+
+	document, err := s.documents.Save(domain.Document{
+		LoanID:      id,
+		Kind:        upload.Kind,
+		Name:        upload.Name,
+		ContentType: contentType,
+	}, upload.Content)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": "UploadDocument",
+			"loan_id":  id,
+			"error":    err.Error(),
+		}).Error("Failed to store document")
+		return nil, fmt.Errorf("failed to store document: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"layer":       "service",
+		"function":    "UploadDocument",
+		"loan_id":     id,
+		"document_id": document.ID,
+		"checksum":    document.Checksum,
+	}).Info("Document uploaded successfully")
+	return document, nil
+}
+
+// findDocument retrieves a document referenced by a transition and checks it was uploaded to the loan as kind
+func (s *LoanService) findDocument(function, loanID, documentID string, kind domain.DocumentKind) (*domain.Document, error) {
+	document, err := s.documents.Find(documentID)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"layer":       "service",
+			"function":    function,
+			"loan_id":     loanID,
+			"document_id": documentID,
+			"error":       err.Error(),
+		}).Error("Failed to find document")
+		return nil, fmt.Errorf("failed to find document: %w", err)
+	}
+	if document.LoanID != loanID || document.Kind != kind {
+		s.logger.WithFields(logrus.Fields{
+			"layer":       "service",
+			"function":    function,
+			"loan_id":     loanID,
+			"document_id": documentID,
+			"kind":        document.Kind,
+		}).Error("Document does not match")
+		return nil, fmt.Errorf("%w: document %s is not a %s of loan %s", domain.ErrDocumentMismatch, documentID, kind, loanID)
+	}
+	return document, nil
+}
+
+// GetLoan retrieves a loan by ID
+func (s *LoanService) GetLoan(id string) (*domain.Loan, error) {
+	s.logger.WithFields(logrus.Fields{
+		"layer":    "service",
+		"function": "GetLoan",
+		"loan_id":  id,
+	}).Info("Retrieving loan by ID")
+
+	if id == "" {
+		s.logger.WithFields(logrus.Fields{
+			"layer":    "service",
+			"function": "GetLoan",
+		}).Error("Loan ID cannot be empty")
+		return nil, errors.New("loan ID cannot be empty")
 	}
 
-	err := s.repo.Save(loan)
+	loan, err := s.repo.FindByID(id)
 	if err != nil {
 		s.logger.WithFields(logrus.Fields{
 			"layer":    "service",
-			"function": "CreateLoan",
+			"function": "GetLoan",
+			"loan_id":  id,
 			"error":    err.Error(),
-		}).Error("Failed to create loan")
+		}).Error("Failed to find loan")
 		return nil, err
 	}
 
 	s.logger.WithFields(logrus.Fields{
 		"layer":    "service",
-		"function": "CreateLoan",
-		"loan_id":  loan.ID,
-	}).Info("Loan created successfully")
+		"function": "GetLoan",
+		"loan_id":  id,
+	}).Info("Loan retrieved successfully")
 	return loan, nil
 }
 
-// ApproveLoan transitions a loan from PROPOSED to APPROVED state
-func (s *LoanService) ApproveLoan(id, validatorID, proofURL string) error {
+// GetSchedule retrieves the repayment schedule of a disbursed loan
+func (s *LoanService) GetSchedule(id string) ([]domain.Installment, error) {
 	s.logger.WithFields(logrus.Fields{
-		"layer":        "service",
-		"function":     "ApproveLoan",
-		"loan_id":      id,
-		"validator_id": validatorID,
-	}).Info("Approving loan")
+		"layer":    "service",
+		"function": "GetSchedule",
+		"loan_id":  id,
+	}).Info("Retrieving repayment schedule")
 
 	loan, err := s.repo.FindByID(id)
 	if err != nil {
 		s.logger.WithFields(logrus.Fields{
 			"layer":    "service",
-			"function": "ApproveLoan",
+			"function": "GetSchedule",
 			"loan_id":  id,
 			"error":    err.Error(),
 		}).Error("Failed to find loan")
-		return fmt.Errorf("failed to find loan: %w", err)
+		return nil, fmt.Errorf("failed to find loan: %w", err)
 	}
 
-	if loan.State != domain.StateProposed {
+	if loan.Schedule == nil {
 		s.logger.WithFields(logrus.Fields{
 			"layer":    "service",
-			"function": "ApproveLoan",
+			"function": "GetSchedule",
 			"loan_id":  id,
 			"state":    loan.State,
-		}).Error("Loan not in PROPOSED state")
-		return errors.New("loan must be in PROPOSED state to be approved")
+		}).Error("Loan has no repayment schedule")
+		return nil, fmt.Errorf("%w: loan %s is %s", domain.ErrScheduleNotFound, id, loan.State)
 	}
 
-	loan.ApprovedInfo = &domain.Approval{
-		ValidatorID: validatorID,
-		ProofURL:    proofURL,
-		Date:        time.Now(),
-	}
-	loan.State = domain.StateApproved
-	loan.UpdatedAt = time.Now()
+	s.logger.WithFields(logrus.Fields{
+		"layer":        "service",
+		"function":     "GetSchedule",
+		"loan_id":      id,
+		"installments": len(loan.Schedule),
+	}).Info("Repayment schedule retrieved successfully")
+	return loan.Schedule, nil
+}
+
+// GetTransitions lists the actions allowed next on a loan and the states they may lead to
+func (s *LoanService) GetTransitions(id string) ([]domain.NextTransition, error) {
+	s.logger.WithFields(logrus.Fields{
+		"layer":    "service",
+		"function": "GetTransitions",
+		"loan_id":  id,
+	}).Info("Retrieving allowed transitions")
 
-	err = s.repo.Update(loan)
+	loan, err := s.repo.FindByID(id)
 	if err != nil {
 		s.logger.WithFields(logrus.Fields{
 			"layer":    "service",
-			"function": "ApproveLoan",
+			"function": "GetTransitions",
 			"loan_id":  id,
 			"error":    err.Error(),
-		}).Error("Failed to update loan")
-		return err
+		}).Error("Failed to find loan")
+		return nil, fmt.Errorf("failed to find loan: %w", err)
 	}
 
+	next := s.machine.Next(loan)
 	s.logger.WithFields(logrus.Fields{
-		"layer":    "service",
-		"function": "ApproveLoan",
-		"loan_id":  id,
-	}).Info("Loan approved successfully")
-	return nil
+		"layer":       "service",
+		"function":    "GetTransitions",
+		"loan_id":     id,
+		"state":       loan.State,
+		"transitions": len(next),
+	}).Info("Allowed transitions retrieved successfully")
+	return next, nil
 }
 
-// AddInvestment adds an investment to a loan
-// If the total invested amount equals the principal, the loan transitions to INVESTED state
-func (s *LoanService) AddInvestment(id, investorID, email string, amount float64) error {
+// GetHistory retrieves the audit trail of a loan, oldest entry first
+func (s *LoanService) GetHistory(id string) ([]domain.AuditEntry, error) {
 	s.logger.WithFields(logrus.Fields{
-		"layer":       "service",
-		"function":    "AddInvestment",
-		"loan_id":     id,
-		"investor_id": investorID,
-		"email":       email,
-		"amount":      amount,
-	}).Info("Adding investment to loan")
+		"layer":    "service",
+		"function": "GetHistory",
+		"loan_id":  id,
+	}).Info("Retrieving loan history")
 
-	loan, err := s.repo.FindByID(id)
-	if err != nil {
+	// Unknown loans are reported as such rather than as an empty history
+	if _, err := s.repo.FindByID(id); err != nil {
 		s.logger.WithFields(logrus.Fields{
 			"layer":    "service",
-			"function": "AddInvestment",
+			"function": "GetHistory",
 			"loan_id":  id,
 			"error":    err.Error(),
 		}).Error("Failed to find loan")
-		return fmt.Errorf("failed to find loan: %w", err)
+		return nil, fmt.Errorf("failed to find loan: %w", err)
 	}
 
-	if loan.State != domain.StateApproved && loan.State != domain.StateInvested {
+	entries, err := s.auditLog.FindByLoanID(id)
+	if err != nil {
 		s.logger.WithFields(logrus.Fields{
 			"layer":    "service",
-			"function": "AddInvestment",
+			"function": "GetHistory",
 			"loan_id":  id,
-			"state":    loan.State,
-		}).Error("Loan not in APPROVED or INVESTED state")
-		return errors.New("loan must be in APPROVED or INVESTED state to add investment")
+			"error":    err.Error(),
+		}).Error("Failed to find audit entries")
+		return nil, err
 	}
 
-	// Calculate total invested amount including the new investment
-	total := amount
-	for _, inv := range loan.Investors {
-		total += inv.Amount
-	}
+	s.logger.WithFields(logrus.Fields{
+		"layer":    "service",
+		"function": "GetHistory",
+		"loan_id":  id,
+		"entries":  len(entries),
+	}).Info("Loan history retrieved successfully")
+	return entries, nil
+}
+
+// RecordRepayment records a borrower payment on a DISBURSED or DEFAULTED loan and allocates it to the installments.
+// The loan transitions to REPAID once every installment is settled.
+func (s *LoanService) RecordRepayment(id string, amount money.Money, match domain.IfMatch) (*domain.Repayment, int64, error) {
+	s.logger.WithFields(logrus.Fields{
+		"layer":    "service",
+		"function": "RecordRepayment",
+		"loan_id":  id,
+		"amount":   amount.String(),
+	}).Info("Recording repayment")
 
-	if total > loan.PrincipalAmount {
+	if err := amount.Validate(); err != nil {
 		s.logger.WithFields(logrus.Fields{
-			"layer":     "service",
-			"function":  "AddInvestment",
-			"loan_id":   id,
-			"total":     total,
-			"principal": loan.PrincipalAmount,
-		}).Error("Investment exceeds principal")
-		return fmt.Errorf("investment exceeds principal: %.2f > %.2f", total, loan.PrincipalAmount)
+			"layer":    "service",
+			"function": "RecordRepayment",
+			"loan_id":  id,
+			"error":    err.Error(),
+		}).Error("Invalid repayment amount")
+		return nil, 0, fmt.Errorf("invalid repayment amount: %w", err)
 	}
 
-	// Add the new investor
-	loan.Investors = append(loan.Investors, domain.Investor{
-		ID:     investorID,
-		Amount: amount,
-		Email:  email,
+	var repayment *domain.Repayment
+	var version int64
+	err := s.mutate("RecordRepayment", id, match, func() error {
+		var err error
+		repayment, version, err = s.recordRepayment(id, amount, match)
+		return err
 	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return repayment, version, nil
+}
 
-	// If total equals principal, transition to INVESTED state
-	if total == loan.PrincipalAmount {
+func (s *LoanService) recordRepayment(id string, amount money.Money, match domain.IfMatch) (*domain.Repayment, int64, error) {
+	loan, err := s.repo.FindByID(id)
+	if err != nil {
 		s.logger.WithFields(logrus.Fields{
 			"layer":    "service",
-			"function": "AddInvestment",
+			"function": "RecordRepayment",
 			"loan_id":  id,
-		}).Info("Loan fully funded, transitioning to INVESTED state")
-
-		loan.State = domain.StateInvested
-
-		// Send agreement emails to all investors
-		for _, inv := range loan.Investors {
-			s.logger.WithFields(logrus.Fields{
-				"layer":       "service",
-				"function":    "AddInvestment",
-				"loan_id":     id,
-				"investor_id": inv.ID,
-				"email":       inv.Email,
-			}).Info("Sending agreement email to investor")
+			"error":    err.Error(),
+		}).Error("Failed to find loan")
+		return nil, 0, fmt.Errorf("failed to find loan: %w", err)
+	}
+	if err := s.checkIfMatch("RecordRepayment", loan, match); err != nil {
+		return nil, 0, err
+	}
 
-			if err := s.emailSender.SendAgreementEmail(inv.Email, loan.ID, loan.AgreementLetter); err != nil {
-				s.logger.WithFields(logrus.Fields{
-					"layer":       "service",
-					"function":    "AddInvestment",
-					"loan_id":     id,
-					"investor_id": inv.ID,
-					"error":       err.Error(),
-				}).Error("Failed to send agreement email")
-				return fmt.Errorf("failed to send agreement to investor %s: %w", inv.ID, err)
-			}
-		}
+	if err := s.checkTransition("RecordRepayment", loan, domain.ActionRepay); err != nil {
+		return nil, 0, err
 	}
 
-	loan.UpdatedAt = time.Now()
-	err = s.repo.Update(loan)
+	c := newChange(loan)
+	err = c.raise(domain.EventRepaymentRecorded, domain.RepaymentRecordedData{
+		RepaymentID: utils.GenerateUUID(),
+		Amount:      amount,
+		PaidAt:      c.occurredAt,
+	})
 	if err != nil {
 		s.logger.WithFields(logrus.Fields{
 			"layer":    "service",
-			"function": "AddInvestment",
+			"function": "RecordRepayment",
 			"loan_id":  id,
 			"error":    err.Error(),
-		}).Error("Failed to update loan")
-		return err
+		}).Error("Failed to allocate repayment")
+		return nil, 0, fmt.Errorf("failed to allocate repayment: %w", err)
+	}
+
+	repayment := loan.Repayments[len(loan.Repayments)-1]
+
+	// The loan moves to REPAID once every installment is settled
+	transition, err := s.resolveTransition("RecordRepayment", loan, domain.ActionRepay)
+	if err != nil {
+		return nil, 0, err
+	}
+	if transition.To == domain.StateRepaid {
+		if err := c.raise(domain.EventLoanRepaid, struct{}{}); err != nil {
+			return nil, 0, err
+		}
 	}
 
+	if err := c.notify(transition.Effects); err != nil {
+		return nil, 0, err
+	}
+	if err := s.commit("RecordRepayment", c); err != nil {
+		return nil, 0, err
+	}
+
+	s.recordAudit("RecordRepayment", loan, loan.BorrowerID, domain.ActionRepay, c.from, map[string]any{"repayment_id": repayment.ID, "amount": amount})
+
 	s.logger.WithFields(logrus.Fields{
-		"layer":    "service",
-		"function": "AddInvestment",
-		"loan_id":  id,
-	}).Info("Investment added successfully")
-	return nil
+		"layer":        "service",
+		"function":     "RecordRepayment",
+		"loan_id":      id,
+		"repayment_id": repayment.ID,
+		"fee":          repayment.Fee.String(),
+		"interest":     repayment.Interest.String(),
+		"principal":    repayment.Principal.String(),
+	}).Info("Repayment recorded successfully")
+	return &repayment, loan.Version, nil
 }
 
-// DisburseLoan transitions a loan from INVESTED to DISBURSED state
-func (s *LoanService) DisburseLoan(id, fieldOfficerID, signedAgreement string) error {
+// MarkDefaultedLoans moves DISBURSED loans whose oldest unpaid installment is past the days-past-due threshold
+// to the DEFAULTED state and returns them
+func (s *LoanService) MarkDefaultedLoans() ([]*domain.Loan, error) {
 	s.logger.WithFields(logrus.Fields{
-		"layer":            "service",
-		"function":         "DisburseLoan",
-		"loan_id":          id,
-		"field_officer_id": fieldOfficerID,
-	}).Info("Disbursing loan")
+		"layer":     "service",
+		"function":  "MarkDefaultedLoans",
+		"threshold": s.daysPastDueThreshold,
+	}).Info("Checking disbursed loans for defaults")
 
-	// Validate input
-	if id == "" {
-		s.logger.WithFields(logrus.Fields{
-			"layer":    "service",
-			"function": "DisburseLoan",
-		}).Error("Loan ID cannot be empty")
-		return errors.New("loan ID cannot be empty")
-	}
-	if fieldOfficerID == "" {
+	loans, err := s.repo.FindByState(domain.StateDisbursed)
+	if err != nil {
 		s.logger.WithFields(logrus.Fields{
 			"layer":    "service",
-			"function": "DisburseLoan",
-			"loan_id":  id,
-		}).Error("Field officer ID cannot be empty")
-		return errors.New("field officer ID cannot be empty")
+			"function": "MarkDefaultedLoans",
+			"error":    err.Error(),
+		}).Error("Failed to find disbursed loans")
+		return nil, err
 	}
-	if signedAgreement == "" {
-		s.logger.WithFields(logrus.Fields{
-			"layer":    "service",
-			"function": "DisburseLoan",
-			"loan_id":  id,
-		}).Error("Signed agreement cannot be empty")
-		return errors.New("signed agreement cannot be empty")
+
+	now := time.Now()
+	var defaulted []*domain.Loan
+	for _, candidate := range loans {
+		if candidate.DaysPastDue(now) < s.daysPastDueThreshold {
+			continue
+		}
+
+		var loan *domain.Loan
+		err := s.retryOnConflict("MarkDefaultedLoans", candidate.ID, func() error {
+			var err error
+			loan, err = s.markDefaulted(candidate.ID, now)
+			return err
+		})
+		if err != nil {
+			return defaulted, err
+		}
+		if loan != nil {
+			defaulted = append(defaulted, loan)
+		}
 	}
 
+	s.logger.WithFields(logrus.Fields{
+		"layer":     "service",
+		"function":  "MarkDefaultedLoans",
+		"defaulted": len(defaulted),
+	}).Info("Default check completed")
+	return defaulted, nil
+}
+
+// markDefaulted re-reads the loan and defaults it when it is still DISBURSED and past due, it returns nil otherwise
+func (s *LoanService) markDefaulted(id string, now time.Time) (*domain.Loan, error) {
 	loan, err := s.repo.FindByID(id)
 	if err != nil {
 		s.logger.WithFields(logrus.Fields{
 			"layer":    "service",
-			"function": "DisburseLoan",
+			"function": "MarkDefaultedLoans",
 			"loan_id":  id,
 			"error":    err.Error(),
 		}).Error("Failed to find loan")
-		return fmt.Errorf("failed to find loan: %w", err)
+		return nil, fmt.Errorf("failed to find loan: %w", err)
 	}
 
-	if loan.State != domain.StateInvested {
-		s.logger.WithFields(logrus.Fields{
-			"layer":    "service",
-			"function": "DisburseLoan",
-			"loan_id":  id,
-			"state":    loan.State,
-		}).Error("Loan not in INVESTED state")
-		return errors.New("loan must be in INVESTED state to be disbursed")
+	// A repayment may have landed since the loan was listed
+	daysPastDue := loan.DaysPastDue(now)
+	if s.machine.Can(loan, domain.ActionDefault) != nil || daysPastDue < s.daysPastDueThreshold {
+		return nil, nil
 	}
 
-	loan.DisbursedInfo = &domain.Disbursement{
-		SignedAgreement: signedAgreement,
-		FieldOfficerID:  fieldOfficerID,
-		Date:            time.Now(),
+	transition, err := s.resolveTransition("MarkDefaultedLoans", loan, domain.ActionDefault)
+	if err != nil {
+		return nil, err
 	}
-	loan.State = domain.StateDisbursed
-	loan.UpdatedAt = time.Now()
 
-	err = s.repo.Update(loan)
-	if err != nil {
-		s.logger.WithFields(logrus.Fields{
-			"layer":    "service",
-			"function": "DisburseLoan",
-			"loan_id":  id,
-			"error":    err.Error(),
-		}).Error("Failed to update loan")
-		return err
+	c := newChange(loan)
+	c.occurredAt = now
+	if err := c.raise(domain.EventLoanDefaulted, domain.LoanDefaultedData{DaysPastDue: daysPastDue}); err != nil {
+		return nil, err
+	}
+
+	if err := c.notify(transition.Effects); err != nil {
+		return nil, err
+	}
+	if err := s.commit("MarkDefaultedLoans", c); err != nil {
+		return nil, err
 	}
 
+	s.recordAudit("MarkDefaultedLoans", loan, domain.SystemActor, domain.ActionDefault, c.from, map[string]any{"days_past_due": daysPastDue, "threshold": s.daysPastDueThreshold})
+
 	s.logger.WithFields(logrus.Fields{
-		"layer":    "service",
-		"function": "DisburseLoan",
-		"loan_id":  id,
-	}).Info("Loan disbursed successfully")
-	return nil
+		"layer":         "service",
+		"function":      "MarkDefaultedLoans",
+		"loan_id":       id,
+		"days_past_due": daysPastDue,
+	}).Warn("Loan defaulted")
+	return loan, nil
 }
 
-// GenerateAgreementLetter generates an agreement letter for a loan
-func (s *LoanService) GenerateAgreementLetter(id string, letterURL string) error {
+// RemindDueInstallments reminds the borrowers of DISBURSED loans of the installments falling due within the
+// reminder lead time, overdue ones included, and returns how many reminders were queued. Each installment
+// is reminded once.
+func (s *LoanService) RemindDueInstallments() (int, error) {
 	s.logger.WithFields(logrus.Fields{
-		"layer":      "service",
-		"function":   "GenerateAgreementLetter",
-		"loan_id":    id,
-		"letter_url": letterURL,
-	}).Info("Generating agreement letter")
+		"layer":     "service",
+		"function":  "RemindDueInstallments",
+		"lead_days": s.reminderLeadDays,
+	}).Info("Checking disbursed loans for due installments")
 
-	if id == "" {
+	loans, err := s.repo.FindByState(domain.StateDisbursed)
+	if err != nil {
 		s.logger.WithFields(logrus.Fields{
 			"layer":    "service",
-			"function": "GenerateAgreementLetter",
-		}).Error("Loan ID cannot be empty")
-		return errors.New("loan ID cannot be empty")
+			"function": "RemindDueInstallments",
+			"error":    err.Error(),
+		}).Error("Failed to find disbursed loans")
+		return 0, err
 	}
-	if letterURL == "" {
-		s.logger.WithFields(logrus.Fields{
-			"layer":    "service",
-			"function": "GenerateAgreementLetter",
-			"loan_id":  id,
-		}).Error("Letter URL cannot be empty")
-		return errors.New("letter URL cannot be empty")
+
+	now := time.Now()
+	reminded := 0
+	for _, candidate := range loans {
+		if len(candidate.DueForReminder(now, s.reminderLeadDays)) == 0 {
+			continue
+		}
+
+		var count int
+		err := s.retryOnConflict("RemindDueInstallments", candidate.ID, func() error {
+			var err error
+			count, err = s.remindInstallments(candidate.ID, now)
+			return err
+		})
+		if err != nil {
+			return reminded, err
+		}
+		reminded += count
 	}
 
+	s.logger.WithFields(logrus.Fields{
+		"layer":     "service",
+		"function":  "RemindDueInstallments",
+		"reminders": reminded,
+	}).Info("Installment reminders completed")
+	return reminded, nil
+}
+
+// remindInstallments re-reads the loan and reminds the borrower of its due installments, it returns how many there were
+func (s *LoanService) remindInstallments(id string, now time.Time) (int, error) {
 	loan, err := s.repo.FindByID(id)
 	if err != nil {
 		s.logger.WithFields(logrus.Fields{
 			"layer":    "service",
-			"function": "GenerateAgreementLetter",
+			"function": "RemindDueInstallments",
 			"loan_id":  id,
 			"error":    err.Error(),
 		}).Error("Failed to find loan")
-		return fmt.Errorf("failed to find loan: %w", err)
+		return 0, fmt.Errorf("failed to find loan: %w", err)
 	}
 
-	loan.AgreementLetter = letterURL
-	loan.UpdatedAt = time.Now()
+	// A repayment may have settled the installments since the loan was listed
+	due := loan.DueForReminder(now, s.reminderLeadDays)
+	if loan.State != domain.StateDisbursed || len(due) == 0 {
+		return 0, nil
+	}
 
-	err = s.repo.Update(loan)
-	if err != nil {
-		s.logger.WithFields(logrus.Fields{
-			"layer":    "service",
-			"function": "GenerateAgreementLetter",
-			"loan_id":  id,
-			"error":    err.Error(),
-		}).Error("Failed to update loan")
-		return err
+	c := newChange(loan)
+	c.occurredAt = now
+	borrower := domain.Recipient{ID: loan.BorrowerID, Role: domain.RoleBorrower}
+	numbers := make([]int, len(due))
+	for i, installment := range due {
+		if err := c.raise(domain.EventInstallmentReminded, domain.InstallmentRemindedData{Number: installment.Number}); err != nil {
+			return 0, err
+		}
+		amount, err := installment.Outstanding()
+		if err != nil {
+			return 0, fmt.Errorf("failed to total installment %d of loan %s: %w", installment.Number, id, err)
+		}
+		dueDate := installment.DueDate
+		c.queue(domain.Notification{
+			Kind:        domain.NotificationInstallmentDue,
+			Recipient:   borrower,
+			Amount:      &amount,
+			Installment: installment.Number,
+			DueDate:     &dueDate,
+		})
+		numbers[i] = installment.Number
 	}
 
+	if err := s.commit("RemindDueInstallments", c); err != nil {
+		return 0, err
+	}
+
+	s.recordAudit("RemindDueInstallments", loan, domain.SystemActor, domain.ActionRemindInstallment, loan.State, map[string]any{"installments": numbers})
+
 	s.logger.WithFields(logrus.Fields{
-		"layer":    "service",
-		"function": "GenerateAgreementLetter",
-		"loan_id":  id,
-	}).Info("Agreement letter generated successfully")
-	return nil
+		"layer":        "service",
+		"function":     "RemindDueInstallments",
+		"loan_id":      id,
+		"installments": numbers,
+	}).Info("Borrower reminded of due installments")
+	return len(due), nil
 }
 
-// GetLoan retrieves a loan by ID
-func (s *LoanService) GetLoan(id string) (*domain.Loan, error) {
+// ChargeLateFees charges the late fee on the overdue installments of DISBURSED loans and returns how many
+// were charged. Each installment is charged once, and nothing is charged while no late fee is configured.
+func (s *LoanService) ChargeLateFees() (int, error) {
+	if !s.lateFee.IsPositive() {
+		return 0, nil
+	}
+
 	s.logger.WithFields(logrus.Fields{
 		"layer":    "service",
-		"function": "GetLoan",
-		"loan_id":  id,
-	}).Info("Retrieving loan by ID")
+		"function": "ChargeLateFees",
+		"fee":      s.lateFee.String(),
+	}).Info("Checking disbursed loans for late fees")
 
-	if id == "" {
+	loans, err := s.repo.FindByState(domain.StateDisbursed)
+	if err != nil {
 		s.logger.WithFields(logrus.Fields{
 			"layer":    "service",
-			"function": "GetLoan",
-		}).Error("Loan ID cannot be empty")
-		return nil, errors.New("loan ID cannot be empty")
+			"function": "ChargeLateFees",
+			"error":    err.Error(),
+		}).Error("Failed to find disbursed loans")
+		return 0, err
+	}
+
+	now := time.Now()
+	charged := 0
+	for _, candidate := range loans {
+		if len(candidate.DueForLateFee(now)) == 0 {
+			continue
+		}
+
+		var count int
+		err := s.retryOnConflict("ChargeLateFees", candidate.ID, func() error {
+			var err error
+			count, err = s.chargeLateFees(candidate.ID, now)
+			return err
+		})
+		if err != nil {
+			return charged, err
+		}
+		charged += count
 	}
 
+	s.logger.WithFields(logrus.Fields{
+		"layer":    "service",
+		"function": "ChargeLateFees",
+		"charged":  charged,
+	}).Info("Late fees charged")
+	return charged, nil
+}
+
+// chargeLateFees re-reads the loan and charges the late fee on its overdue installments, it returns how many there were
+func (s *LoanService) chargeLateFees(id string, now time.Time) (int, error) {
 	loan, err := s.repo.FindByID(id)
 	if err != nil {
 		s.logger.WithFields(logrus.Fields{
 			"layer":    "service",
-			"function": "GetLoan",
+			"function": "ChargeLateFees",
 			"loan_id":  id,
 			"error":    err.Error(),
 		}).Error("Failed to find loan")
-		return nil, err
+		return 0, fmt.Errorf("failed to find loan: %w", err)
+	}
+
+	// A repayment may have settled the installments since the loan was listed
+	due := loan.DueForLateFee(now)
+	if loan.State != domain.StateDisbursed || len(due) == 0 {
+		return 0, nil
+	}
+
+	fee, err := money.New(s.lateFee, loan.PrincipalAmount.Currency).Round()
+	if err != nil {
+		return 0, fmt.Errorf("invalid late fee for loan %s: %w", id, err)
+	}
+	if !fee.IsPositive() {
+		return 0, nil
+	}
+
+	c := newChange(loan)
+	c.occurredAt = now
+	numbers := make([]int, len(due))
+	for i, installment := range due {
+		if err := c.raise(domain.EventLateFeeCharged, domain.LateFeeChargedData{Number: installment.Number, Fee: fee}); err != nil {
+			return 0, err
+		}
+		numbers[i] = installment.Number
+	}
+
+	if err := s.commit("ChargeLateFees", c); err != nil {
+		return 0, err
 	}
 
+	s.recordAudit("ChargeLateFees", loan, domain.SystemActor, domain.ActionChargeLateFee, loan.State, map[string]any{"installments": numbers, "fee": fee})
+
 	s.logger.WithFields(logrus.Fields{
-		"layer":    "service",
-		"function": "GetLoan",
-		"loan_id":  id,
-	}).Info("Loan retrieved successfully")
-	return loan, nil
+		"layer":        "service",
+		"function":     "ChargeLateFees",
+		"loan_id":      id,
+		"installments": numbers,
+		"fee":          fee.String(),
+	}).Info("Late fees charged on overdue installments")
+	return len(due), nil
 }
 
-// GetLoansByBorrower retrieves all loans for a borrower
-func (s *LoanService) GetLoansByBorrower(borrowerID string) ([]*domain.Loan, error) {
+// GetLoansByBorrower retrieves all loans for a borrower that match the filter
+func (s *LoanService) GetLoansByBorrower(borrowerID string, filter domain.LoanFilter) ([]*domain.Loan, error) {
 	s.logger.WithFields(logrus.Fields{
 		"layer":       "service",
 		"function":    "GetLoansByBorrower",
 		"borrower_id": borrowerID,
+		"currency":    filter.Currency,
 	}).Info("Retrieving loans by borrower ID")
 
 	if borrowerID == "" {
@@ -435,6 +2276,7 @@ func (s *LoanService) GetLoansByBorrower(borrowerID string) ([]*domain.Loan, err
 		}).Error("Failed to find loans by borrower ID")
 		return nil, err
 	}
+	loans = filterLoans(loans, filter)
 
 	s.logger.WithFields(logrus.Fields{
 		"layer":       "service",
@@ -445,12 +2287,13 @@ func (s *LoanService) GetLoansByBorrower(borrowerID string) ([]*domain.Loan, err
 	return loans, nil
 }
 
-// GetLoansByState retrieves all loans in a specific state
-func (s *LoanService) GetLoansByState(state domain.LoanState) ([]*domain.Loan, error) {
+// GetLoansByState retrieves all loans in a specific state that match the filter
+func (s *LoanService) GetLoansByState(state domain.LoanState, filter domain.LoanFilter) ([]*domain.Loan, error) {
 	s.logger.WithFields(logrus.Fields{
 		"layer":    "service",
 		"function": "GetLoansByState",
 		"state":    state,
+		"currency": filter.Currency,
 	}).Info("Retrieving loans by state")
 
 	loans, err := s.repo.FindByState(state)
@@ -463,6 +2306,7 @@ func (s *LoanService) GetLoansByState(state domain.LoanState) ([]*domain.Loan, e
 		}).Error("Failed to find loans by state")
 		return nil, err
 	}
+	loans = filterLoans(loans, filter)
 
 	s.logger.WithFields(logrus.Fields{
 		"layer":    "service",
@@ -473,11 +2317,12 @@ func (s *LoanService) GetLoansByState(state domain.LoanState) ([]*domain.Loan, e
 	return loans, nil
 }
 
-// GetLoans retrieves all loans with pagination
-func (s *LoanService) GetLoans(page, limit int) ([]*domain.Loan, error) {
+// GetLoans retrieves the loans matching the filter with pagination
+func (s *LoanService) GetLoans(filter domain.LoanFilter, page, limit int) ([]*domain.Loan, error) {
 	s.logger.WithFields(logrus.Fields{
 		"layer":    "service",
 		"function": "GetLoans",
+		"currency": filter.Currency,
 		"page":     page,
 		"limit":    limit,
 	}).Info("Retrieving all loans with pagination")
@@ -492,7 +2337,7 @@ func (s *LoanService) GetLoans(page, limit int) ([]*domain.Loan, error) {
 		limit = 10
 	}
 
-	loans, err := s.repo.FindAll(page, limit)
+	loans, err := s.repo.FindAll(filter, page, limit)
 	if err != nil {
 		s.logger.WithFields(logrus.Fields{
 			"layer":    "service",
@@ -513,3 +2358,14 @@ func (s *LoanService) GetLoans(page, limit int) ([]*domain.Loan, error) {
 	}).Info("Loans retrieved successfully")
 	return loans, nil
 }
+
+// filterLoans keeps the loans that match the filter, preserving their order
+func filterLoans(loans []*domain.Loan, filter domain.LoanFilter) []*domain.Loan {
+	result := make([]*domain.Loan, 0, len(loans))
+	for _, loan := range loans {
+		if filter.Matches(loan) {
+			result = append(result, loan)
+		}
+	}
+	return result
+}
@@ -2,7 +2,11 @@ package loan
 
 import (
 	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/sirupsen/logrus"
@@ -10,16 +14,181 @@ import (
 
 	domain "github.com/hinha/los-technical/internal/domain/loan"
 	mock "github.com/hinha/los-technical/internal/domain/loan/mock"
+	"github.com/hinha/los-technical/internal/infrastructure/eventstore"
+	loanRepo "github.com/hinha/los-technical/internal/infrastructure/repository/loan"
+	"github.com/hinha/los-technical/internal/pkg/money"
 )
 
+// newTestAuditLog returns an audit log mock that accepts any entry, for tests that do not check the audit trail
+func newTestAuditLog(ctrl *gomock.Controller) *mock.MockAuditLog {
+	auditLog := mock.NewMockAuditLog(ctrl)
+	auditLog.EXPECT().Append(gomock.Any()).Return(nil).AnyTimes()
+	return auditLog
+}
+
+// newTestEventStore returns an event store mock that accepts any commit, for tests that check the read model
+func newTestEventStore(ctrl *gomock.Controller) *mock.MockEventStore {
+	eventStore := mock.NewMockEventStore(ctrl)
+	acceptCommits(eventStore)
+	return eventStore
+}
+
+// newTestDocumentStore returns a document store mock holding the proof "proof-1", the agreement letter
+// "agreement-1" and the signed agreement "signed-1" of loan-123, for tests that approve or disburse it.
+// Agreement letters saved to it are stored as "agreement-1".
+func newTestDocumentStore(ctrl *gomock.Controller) *mock.MockDocumentStore {
+	documents := mock.NewMockDocumentStore(ctrl)
+	documents.EXPECT().Find("proof-1").Return(&domain.Document{
+		ID: "proof-1", LoanID: "loan-123", Kind: domain.DocumentProof, URL: "http://example.com/documents/proof-1",
+	}, nil).AnyTimes()
+	documents.EXPECT().Find("signed-1").Return(&domain.Document{
+		ID: "signed-1", LoanID: "loan-123", Kind: domain.DocumentSignedAgreement, URL: "http://example.com/documents/signed-1",
+	}, nil).AnyTimes()
+	documents.EXPECT().Find(gomock.Any()).Return(nil, domain.ErrDocumentNotFound).AnyTimes()
+	documents.EXPECT().Read("agreement-1").Return([]byte("agreement"), nil).AnyTimes()
+	documents.EXPECT().Read("signed-1").Return([]byte("agreement signed"), nil).AnyTimes()
+	documents.EXPECT().Read(gomock.Any()).Return(nil, domain.ErrDocumentNotFound).AnyTimes()
+	documents.EXPECT().Save(gomock.Any(), []byte("agreement")).DoAndReturn(func(document domain.Document, content []byte) (*domain.Document, error) {
+		document.ID = "agreement-1"
+		document.Size = int64(len(content))
+		document.URL = "http://example.com/documents/agreement-1"
+		return &document, nil
+	}).AnyTimes()
+	return documents
+}
+
+// newTestAgreements returns an agreement renderer mock that renders every letter as "agreement"
+func newTestAgreements(ctrl *gomock.Controller) *mock.MockAgreementRenderer {
+	agreements := mock.NewMockAgreementRenderer(ctrl)
+	agreements.EXPECT().RenderAgreement(gomock.Any()).Return([]byte("agreement"), nil).AnyTimes()
+	return agreements
+}
+
+// newTestVerifier returns an agreement verifier mock that accepts every signed agreement
+func newTestVerifier(ctrl *gomock.Controller) *mock.MockAgreementVerifier {
+	verifier := mock.NewMockAgreementVerifier(ctrl)
+	verifier.EXPECT().VerifySignedAgreement(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	return verifier
+}
+
+// newTestInvestors returns an investor repository mock where every investor is verified, has no limits
+// and is reached at <id>@example.com,
+// except "investor-pending", still pending KYC, "investor-limited", limited to 300 per loan, and "investor-capped",
+// limited to 800 in total. Other investors are unknown.
+func newTestInvestors(ctrl *gomock.Controller) *mock.MockInvestorRepository {
+	investors := mock.NewMockInvestorRepository(ctrl)
+	perLoan := money.MustNew("300", money.DefaultCurrency)
+	total := money.MustNew("800", money.DefaultCurrency)
+	profiles := map[string]*domain.InvestorProfile{
+		"investor-pending": {ID: "investor-pending", KYCStatus: domain.KYCPending},
+		"investor-limited": {ID: "investor-limited", KYCStatus: domain.KYCVerified, Limits: domain.InvestmentLimits{PerLoan: &perLoan}},
+		"investor-capped":  {ID: "investor-capped", KYCStatus: domain.KYCVerified, Limits: domain.InvestmentLimits{Total: &total}},
+	}
+	investors.EXPECT().FindByID(gomock.Any()).DoAndReturn(func(id string) (*domain.InvestorProfile, error) {
+		if profile, ok := profiles[id]; ok {
+			return profile.Clone(), nil
+		}
+		if strings.HasPrefix(id, "unknown") {
+			return nil, fmt.Errorf("%w: %s", domain.ErrInvestorNotFound, id)
+		}
+		return &domain.InvestorProfile{ID: id, Email: id + "@example.com", KYCStatus: domain.KYCVerified}, nil
+	}).AnyTimes()
+	return investors
+}
+
+// newTestBorrowers returns a borrower repository mock that finds every borrower, blacklisted when their ID has the
+// blacklisted prefix; IDs with the unknown prefix are not found
+func newTestBorrowers(ctrl *gomock.Controller) *mock.MockBorrowerRepository {
+	borrowers := mock.NewMockBorrowerRepository(ctrl)
+	borrowers.EXPECT().FindByID(gomock.Any()).DoAndReturn(func(id string) (*domain.Borrower, error) {
+		switch {
+		case strings.HasPrefix(id, "unknown"):
+			return nil, fmt.Errorf("%w: %s", domain.ErrBorrowerNotFound, id)
+		case strings.HasPrefix(id, "blacklisted"):
+			return &domain.Borrower{ID: id, Blacklisted: true, BlacklistReason: "fraudulent documents"}, nil
+		}
+		return &domain.Borrower{ID: id}, nil
+	}).AnyTimes()
+	return borrowers
+}
+
+// newTestScorer returns a credit scorer mock that grades every loan B
+func newTestScorer(ctrl *gomock.Controller) *mock.MockCreditScorer {
+	scorer := mock.NewMockCreditScorer(ctrl)
+	scorer.EXPECT().Score(gomock.Any()).DoAndReturn(func(domain.ScoringInput) (*domain.CreditScore, error) {
+		return newTestCreditScore(domain.GradeB), nil
+	}).AnyTimes()
+	return scorer
+}
+
+// newTestCreditScore returns the credit score of a loan scored with the grade
+func newTestCreditScore(grade domain.RiskGrade) *domain.CreditScore {
+	return &domain.CreditScore{Score: 700, Grade: grade, Reasons: []string{}, Scorecard: "test"}
+}
+
+// newTestPricer returns a pricing engine quoting 20% and 15% for grade E loans and 12% and 10% for the others,
+// with corridors of 2 percentage points, and refusing to price loans longer than ten years
+func newTestPricer(ctrl *gomock.Controller) *mock.MockPricingEngine {
+	pricer := mock.NewMockPricingEngine(ctrl)
+	pricer.EXPECT().Price(gomock.Any()).DoAndReturn(func(input domain.PricingInput) (*domain.Quote, error) {
+		if input.Terms.TenorMonths > 120 {
+			return nil, fmt.Errorf("%w: no tenor band for %d months", domain.ErrLoanNotPriced, input.Terms.TenorMonths)
+		}
+		quote := &domain.Quote{
+			Rate:         money.MustParse("12"),
+			ROI:          money.MustParse("10"),
+			RateCorridor: domain.Corridor{Min: money.MustParse("10"), Max: money.MustParse("14")},
+			ROICorridor:  domain.Corridor{Min: money.MustParse("8"), Max: money.MustParse("12")},
+			RateCard:     "test",
+		}
+		if input.Grade == domain.GradeE {
+			quote.Rate, quote.ROI = money.MustParse("20"), money.MustParse("15")
+		}
+		return quote, nil
+	}).AnyTimes()
+	return pricer
+}
+
+// newTestDecimal returns a pointer to the decimal, for rates and ROIs given by hand
+func newTestDecimal(value string) *money.Decimal {
+	decimal := money.MustParse(value)
+	return &decimal
+}
+
+// acceptCommits lets the event store mock take any commit not matched by an earlier expectation
+func acceptCommits(eventStore *mock.MockEventStore) {
+	eventStore.EXPECT().Commit(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+}
+
+// expectStaleCommit makes the event store mock reject the next commit of loan-123 at version 1, as another writer
+// appended version 2 first. The read model is caught up with the stream before the retry, so the loan is read
+// once more and its stream loaded, which the other writer already projected.
+func expectStaleCommit(eventStore *mock.MockEventStore) {
+	eventStore.EXPECT().Commit("loan-123", int64(1), gomock.Any(), gomock.Any()).
+		Return(&domain.VersionConflictError{LoanID: "loan-123", Expected: 1, Actual: 2})
+	eventStore.EXPECT().Load("loan-123").Return(nil, nil)
+}
+
+// expectMessages makes the event store mock check the outbox messages of the next commit
+func expectMessages(eventStore *mock.MockEventStore, check func(messages []domain.OutboxMessage)) {
+	eventStore.EXPECT().Commit(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ string, _ int64, _ []domain.Event, messages []domain.OutboxMessage) error {
+			check(messages)
+			return nil
+		})
+}
+
 func TestCreateLoan(t *testing.T) {
 	// Define test cases
 	testCases := []struct {
 		name        string
 		borrowerID  string
-		principal   float64
-		rate        float64
-		roi         float64
+		principal   money.Money
+		rate        *money.Decimal
+		roi         *money.Decimal
+		wantRate    money.Decimal
+		wantROI     money.Decimal
+		terms       domain.RepaymentTerms
 		mockSetup   func(*mock.MockLoanRepository)
 		expectError bool
 		errorMsg    string
@@ -27,26 +196,218 @@ func TestCreateLoan(t *testing.T) {
 		{
 			name:       "Success",
 			borrowerID: "borrower-123",
-			principal:  1000.0,
-			rate:       0.05,
-			roi:        0.1,
+			principal:  money.MustNew("1000", money.DefaultCurrency),
+			rate:       newTestDecimal("13"),
+			roi:        newTestDecimal("9"),
+			terms:      domain.RepaymentTerms{TenorMonths: 12, Scheme: domain.SchemeAnnuity},
+			mockSetup: func(repo *mock.MockLoanRepository) {
+				repo.EXPECT().FindByBorrowerID("borrower-123").Return(nil, nil)
+				repo.EXPECT().Save(gomock.Any()).Return(nil)
+			},
+			wantRate:    money.MustParse("13"),
+			wantROI:     money.MustParse("9"),
+			expectError: false,
+		},
+		{
+			name:       "Success - USD",
+			borrowerID: "borrower-123",
+			principal:  money.MustNew("2500.50", "USD"),
+			rate:       newTestDecimal("13"),
+			roi:        newTestDecimal("9"),
+			terms:      domain.RepaymentTerms{TenorMonths: 12, Scheme: domain.SchemeAnnuity},
+			mockSetup: func(repo *mock.MockLoanRepository) {
+				repo.EXPECT().FindByBorrowerID("borrower-123").Return(nil, nil)
+				repo.EXPECT().Save(gomock.Any()).Return(nil)
+			},
+			wantRate:    money.MustParse("13"),
+			wantROI:     money.MustParse("9"),
+			expectError: false,
+		},
+		{
+			name:       "Success - Priced By Rate Card",
+			borrowerID: "borrower-123",
+			principal:  money.MustNew("1000", money.DefaultCurrency),
+			terms:      domain.RepaymentTerms{TenorMonths: 12, Scheme: domain.SchemeAnnuity},
+			mockSetup: func(repo *mock.MockLoanRepository) {
+				repo.EXPECT().FindByBorrowerID("borrower-123").Return(nil, nil)
+				repo.EXPECT().Save(gomock.Any()).Return(nil)
+			},
+			wantRate:    money.MustParse("12"),
+			wantROI:     money.MustParse("10"),
+			expectError: false,
+		},
+		{
+			name:       "Success - Only ROI Given",
+			borrowerID: "borrower-123",
+			principal:  money.MustNew("1000", money.DefaultCurrency),
+			roi:        newTestDecimal("11.5"),
+			terms:      domain.RepaymentTerms{TenorMonths: 12, Scheme: domain.SchemeAnnuity},
 			mockSetup: func(repo *mock.MockLoanRepository) {
+				repo.EXPECT().FindByBorrowerID("borrower-123").Return(nil, nil)
 				repo.EXPECT().Save(gomock.Any()).Return(nil)
 			},
+			wantRate:    money.MustParse("12"),
+			wantROI:     money.MustParse("11.5"),
 			expectError: false,
 		},
+		{
+			name:       "Rate Outside Corridor",
+			borrowerID: "borrower-123",
+			principal:  money.MustNew("1000", money.DefaultCurrency),
+			rate:       newTestDecimal("15"),
+			terms:      domain.RepaymentTerms{TenorMonths: 12, Scheme: domain.SchemeAnnuity},
+			mockSetup: func(repo *mock.MockLoanRepository) {
+				repo.EXPECT().FindByBorrowerID("borrower-123").Return(nil, nil)
+			},
+			expectError: true,
+			errorMsg:    "rate 15 is outside the allowed corridor of 10 to 14",
+		},
+		{
+			name:       "ROI Outside Corridor",
+			borrowerID: "borrower-123",
+			principal:  money.MustNew("1000", money.DefaultCurrency),
+			roi:        newTestDecimal("0.1"),
+			terms:      domain.RepaymentTerms{TenorMonths: 12, Scheme: domain.SchemeAnnuity},
+			mockSetup: func(repo *mock.MockLoanRepository) {
+				repo.EXPECT().FindByBorrowerID("borrower-123").Return(nil, nil)
+			},
+			expectError: true,
+			errorMsg:    "outside the allowed corridor",
+		},
+		{
+			name:       "Negative Margin",
+			borrowerID: "borrower-123",
+			principal:  money.MustNew("1000", money.DefaultCurrency),
+			rate:       newTestDecimal("11"),
+			roi:        newTestDecimal("11.5"),
+			terms:      domain.RepaymentTerms{TenorMonths: 12, Scheme: domain.SchemeAnnuity},
+			mockSetup: func(repo *mock.MockLoanRepository) {
+				repo.EXPECT().FindByBorrowerID("borrower-123").Return(nil, nil)
+			},
+			expectError: true,
+			errorMsg:    "ROI must be below the rate: ROI 11.5, rate 11",
+		},
+		{
+			name:       "Not Priced By Rate Card",
+			borrowerID: "borrower-123",
+			principal:  money.MustNew("1000", money.DefaultCurrency),
+			terms:      domain.RepaymentTerms{TenorMonths: 240, Scheme: domain.SchemeAnnuity},
+			mockSetup: func(repo *mock.MockLoanRepository) {
+				repo.EXPECT().FindByBorrowerID("borrower-123").Return(nil, nil)
+			},
+			expectError: true,
+			errorMsg:    "failed to price loan: loan not priced by the rate card",
+		},
+		{
+			name:        "Unsupported Currency",
+			borrowerID:  "borrower-123",
+			principal:   money.MustNew("1000", "XYZ"),
+			rate:        newTestDecimal("13"),
+			roi:         newTestDecimal("9"),
+			terms:       domain.RepaymentTerms{TenorMonths: 12, Scheme: domain.SchemeAnnuity},
+			mockSetup:   func(repo *mock.MockLoanRepository) {},
+			expectError: true,
+			errorMsg:    "unsupported currency",
+		},
+		{
+			name:        "Principal Finer Than Currency Minor Units",
+			borrowerID:  "borrower-123",
+			principal:   money.MustNew("1000.5", money.DefaultCurrency),
+			rate:        newTestDecimal("13"),
+			roi:         newTestDecimal("9"),
+			terms:       domain.RepaymentTerms{TenorMonths: 12, Scheme: domain.SchemeAnnuity},
+			mockSetup:   func(repo *mock.MockLoanRepository) {},
+			expectError: true,
+			errorMsg:    "invalid principal amount",
+		},
+		{
+			name:        "Invalid Repayment Terms",
+			borrowerID:  "borrower-123",
+			principal:   money.MustNew("1000", money.DefaultCurrency),
+			rate:        newTestDecimal("13"),
+			roi:         newTestDecimal("9"),
+			terms:       domain.RepaymentTerms{TenorMonths: 0, Scheme: domain.SchemeBullet},
+			mockSetup:   func(repo *mock.MockLoanRepository) {},
+			expectError: true,
+			errorMsg:    "invalid repayment terms",
+		},
 		{
 			name:       "Repository Error",
 			borrowerID: "borrower-123",
-			principal:  1000.0,
-			rate:       0.05,
-			roi:        0.1,
+			principal:  money.MustNew("1000", money.DefaultCurrency),
+			rate:       newTestDecimal("13"),
+			roi:        newTestDecimal("9"),
+			terms:      domain.RepaymentTerms{TenorMonths: 12, Scheme: domain.SchemeAnnuity},
 			mockSetup: func(repo *mock.MockLoanRepository) {
+				repo.EXPECT().FindByBorrowerID("borrower-123").Return(nil, nil)
 				repo.EXPECT().Save(gomock.Any()).Return(errors.New("database error"))
 			},
 			expectError: true,
 			errorMsg:    "database error",
 		},
+		{
+			name:       "Success - Previous Loan Repaid",
+			borrowerID: "borrower-123",
+			principal:  money.MustNew("1000", money.DefaultCurrency),
+			rate:       newTestDecimal("13"),
+			roi:        newTestDecimal("9"),
+			terms:      domain.RepaymentTerms{TenorMonths: 12, Scheme: domain.SchemeAnnuity},
+			mockSetup: func(repo *mock.MockLoanRepository) {
+				repo.EXPECT().FindByBorrowerID("borrower-123").Return([]*domain.Loan{{ID: "loan-1", BorrowerID: "borrower-123", State: domain.StateRepaid}}, nil)
+				repo.EXPECT().Save(gomock.Any()).Return(nil)
+			},
+			wantRate:    money.MustParse("13"),
+			wantROI:     money.MustParse("9"),
+			expectError: false,
+		},
+		{
+			name:       "Borrower Has Active Loan",
+			borrowerID: "borrower-123",
+			principal:  money.MustNew("1000", money.DefaultCurrency),
+			rate:       newTestDecimal("13"),
+			roi:        newTestDecimal("9"),
+			terms:      domain.RepaymentTerms{TenorMonths: 12, Scheme: domain.SchemeAnnuity},
+			mockSetup: func(repo *mock.MockLoanRepository) {
+				repo.EXPECT().FindByBorrowerID("borrower-123").Return([]*domain.Loan{{ID: "loan-1", BorrowerID: "borrower-123", State: domain.StateDisbursed}}, nil)
+			},
+			expectError: true,
+			errorMsg:    "borrower borrower-123 already has 1 active loans, at most 1 are allowed at a time",
+		},
+		{
+			name:       "Loans Of Borrower Not Found",
+			borrowerID: "borrower-123",
+			principal:  money.MustNew("1000", money.DefaultCurrency),
+			rate:       newTestDecimal("13"),
+			roi:        newTestDecimal("9"),
+			terms:      domain.RepaymentTerms{TenorMonths: 12, Scheme: domain.SchemeAnnuity},
+			mockSetup: func(repo *mock.MockLoanRepository) {
+				repo.EXPECT().FindByBorrowerID("borrower-123").Return(nil, errors.New("database error"))
+			},
+			expectError: true,
+			errorMsg:    "failed to find loans of borrower",
+		},
+		{
+			name:        "Unknown Borrower",
+			borrowerID:  "unknown-borrower",
+			principal:   money.MustNew("1000", money.DefaultCurrency),
+			rate:        newTestDecimal("13"),
+			roi:         newTestDecimal("9"),
+			terms:       domain.RepaymentTerms{TenorMonths: 12, Scheme: domain.SchemeAnnuity},
+			mockSetup:   func(repo *mock.MockLoanRepository) {},
+			expectError: true,
+			errorMsg:    "borrower not found",
+		},
+		{
+			name:        "Blacklisted Borrower",
+			borrowerID:  "blacklisted-borrower",
+			principal:   money.MustNew("1000", money.DefaultCurrency),
+			rate:        newTestDecimal("13"),
+			roi:         newTestDecimal("9"),
+			terms:       domain.RepaymentTerms{TenorMonths: 12, Scheme: domain.SchemeAnnuity},
+			mockSetup:   func(repo *mock.MockLoanRepository) {},
+			expectError: true,
+			errorMsg:    "borrower blacklisted",
+		},
 	}
 
 	// Run test cases
@@ -57,17 +418,17 @@ func TestCreateLoan(t *testing.T) {
 			defer ctrl.Finish()
 
 			mockRepo := mock.NewMockLoanRepository(ctrl)
-			mockEmailSender := mock.NewMockEmailSender(ctrl)
+			mockAuditLog := newTestAuditLog(ctrl)
 			logger := logrus.New()
 
 			// Configure mocks
 			tc.mockSetup(mockRepo)
 
 			// Create service
-			service := NewLoanService(mockRepo, mockEmailSender, logger)
+			service := NewLoanService(mockRepo, nil, newTestBorrowers(ctrl), newTestEventStore(ctrl), mockAuditLog, nil, nil, nil, newTestScorer(ctrl), newTestPricer(ctrl), logger)
 
 			// Execute
-			loan, err := service.CreateLoan(tc.borrowerID, tc.principal, tc.rate, tc.roi)
+			loan, err := service.CreateLoan(tc.borrowerID, tc.principal, tc.rate, tc.roi, tc.terms)
 
 			// Assert
 			if tc.expectError {
@@ -79,9 +440,14 @@ func TestCreateLoan(t *testing.T) {
 				assert.NotNil(t, loan)
 				assert.Equal(t, tc.borrowerID, loan.BorrowerID)
 				assert.Equal(t, tc.principal, loan.PrincipalAmount)
-				assert.Equal(t, tc.rate, loan.Rate)
-				assert.Equal(t, tc.roi, loan.ROI)
+				assert.Equal(t, tc.wantRate, loan.Rate)
+				assert.Equal(t, tc.wantROI, loan.ROI)
+				assert.Equal(t, tc.terms, loan.Terms)
 				assert.Equal(t, domain.StateProposed, loan.State)
+				if assert.NotNil(t, loan.CreditScore) {
+					assert.Equal(t, domain.GradeB, loan.CreditScore.Grade)
+					assert.False(t, loan.CreditScore.ScoredAt.IsZero())
+				}
 			}
 		})
 	}
@@ -93,8 +459,10 @@ func TestApproveLoan(t *testing.T) {
 		name        string
 		loanID      string
 		validatorID string
-		proofURL    string
+		documentID  string
+		override    string
 		mockSetup   func(*mock.MockLoanRepository)
+		renderErr   error
 		expectError bool
 		errorMsg    string
 	}{
@@ -102,22 +470,161 @@ func TestApproveLoan(t *testing.T) {
 			name:        "Success",
 			loanID:      "loan-123",
 			validatorID: "validator-123",
-			proofURL:    "http://example.com/proof",
+			documentID:  "proof-1",
 			mockSetup: func(repo *mock.MockLoanRepository) {
 				loan := &domain.Loan{
-					ID:    "loan-123",
-					State: domain.StateProposed,
+					ID:          "loan-123",
+					State:       domain.StateProposed,
+					CreditScore: newTestCreditScore(domain.GradeB),
 				}
 				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
-				repo.EXPECT().Update(gomock.Any()).Return(nil)
+				repo.EXPECT().Update(gomock.Any()).DoAndReturn(func(loan *domain.Loan) error {
+					assert.Equal(t, "proof-1", loan.ApprovedInfo.ProofDocumentID)
+					assert.Equal(t, "http://example.com/documents/proof-1", loan.ApprovedInfo.ProofURL)
+					assert.Equal(t, "agreement-1", loan.AgreementDocumentID)
+					assert.Equal(t, "http://example.com/documents/agreement-1", loan.AgreementLetter)
+					assert.Empty(t, loan.ApprovedInfo.OverrideJustification)
+					return nil
+				})
+			},
+			expectError: false,
+		},
+		{
+			name:        "Grade Below Minimum",
+			loanID:      "loan-123",
+			validatorID: "validator-123",
+			documentID:  "proof-1",
+			mockSetup: func(repo *mock.MockLoanRepository) {
+				loan := &domain.Loan{
+					ID:          "loan-123",
+					State:       domain.StateProposed,
+					CreditScore: newTestCreditScore(domain.GradeD),
+				}
+				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
+			},
+			expectError: true,
+			errorMsg:    "loan loan-123 is graded D, approval requires C or better",
+		},
+		{
+			name:        "Blank Override Justification",
+			loanID:      "loan-123",
+			validatorID: "validator-123",
+			documentID:  "proof-1",
+			override:    " \t ",
+			mockSetup: func(repo *mock.MockLoanRepository) {
+				repo.EXPECT().FindByID("loan-123").Return(&domain.Loan{
+					ID:          "loan-123",
+					State:       domain.StateProposed,
+					CreditScore: newTestCreditScore(domain.GradeD),
+				}, nil)
+			},
+			expectError: true,
+			errorMsg:    "loan loan-123 is graded D, approval requires C or better",
+		},
+		{
+			name:        "Success - Grade Below Minimum Overridden",
+			loanID:      "loan-123",
+			validatorID: "validator-123",
+			documentID:  "proof-1",
+			override:    "  Collateral covers the principal twice\n",
+			mockSetup: func(repo *mock.MockLoanRepository) {
+				loan := &domain.Loan{
+					ID:          "loan-123",
+					State:       domain.StateProposed,
+					CreditScore: newTestCreditScore(domain.GradeD),
+				}
+				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
+				repo.EXPECT().Update(gomock.Any()).DoAndReturn(func(loan *domain.Loan) error {
+					assert.Equal(t, "Collateral covers the principal twice", loan.ApprovedInfo.OverrideJustification)
+					return nil
+				})
+			},
+			expectError: false,
+		},
+		{
+			name:        "Success - Unscored Loan Is Scored",
+			loanID:      "loan-123",
+			validatorID: "validator-123",
+			documentID:  "proof-1",
+			mockSetup: func(repo *mock.MockLoanRepository) {
+				loan := &domain.Loan{
+					ID:         "loan-123",
+					BorrowerID: "borrower-123",
+					State:      domain.StateProposed,
+				}
+				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
+				repo.EXPECT().FindByBorrowerID("borrower-123").Return([]*domain.Loan{loan}, nil)
+				repo.EXPECT().Update(gomock.Any()).DoAndReturn(func(loan *domain.Loan) error {
+					if assert.NotNil(t, loan.CreditScore) {
+						assert.Equal(t, domain.GradeB, loan.CreditScore.Grade)
+						assert.False(t, loan.CreditScore.ScoredAt.IsZero())
+					}
+					return nil
+				})
+			},
+			expectError: false,
+		},
+		{
+			name:        "Unscored Loan Of Unregistered Borrower",
+			loanID:      "loan-123",
+			validatorID: "validator-123",
+			documentID:  "proof-1",
+			mockSetup: func(repo *mock.MockLoanRepository) {
+				repo.EXPECT().FindByID("loan-123").Return(&domain.Loan{
+					ID:         "loan-123",
+					BorrowerID: "unknown-borrower",
+					State:      domain.StateProposed,
+				}, nil)
+			},
+			expectError: true,
+			errorMsg:    "loan loan-123 cannot be scored as borrower unknown-borrower is not registered, approval requires a justification",
+		},
+		{
+			name:        "Success - Unscored Loan Of Unregistered Borrower Overridden",
+			loanID:      "loan-123",
+			validatorID: "validator-123",
+			documentID:  "proof-1",
+			override:    "Legacy loan, borrower file reviewed by hand",
+			mockSetup: func(repo *mock.MockLoanRepository) {
+				repo.EXPECT().FindByID("loan-123").Return(&domain.Loan{
+					ID:         "loan-123",
+					BorrowerID: "unknown-borrower",
+					State:      domain.StateProposed,
+				}, nil)
+				repo.EXPECT().Update(gomock.Any()).DoAndReturn(func(loan *domain.Loan) error {
+					assert.Equal(t, domain.StateApproved, loan.State)
+					assert.Nil(t, loan.CreditScore)
+					if assert.NotNil(t, loan.ApprovedInfo) {
+						assert.Equal(t, "Legacy loan, borrower file reviewed by hand", loan.ApprovedInfo.OverrideJustification)
+					}
+					return nil
+				})
 			},
 			expectError: false,
 		},
+		{
+			name:        "Unknown Proof",
+			loanID:      "loan-123",
+			validatorID: "validator-123",
+			documentID:  "missing",
+			mockSetup:   func(repo *mock.MockLoanRepository) {},
+			expectError: true,
+			errorMsg:    "document not found",
+		},
+		{
+			name:        "Document Of Another Kind",
+			loanID:      "loan-123",
+			validatorID: "validator-123",
+			documentID:  "signed-1",
+			mockSetup:   func(repo *mock.MockLoanRepository) {},
+			expectError: true,
+			errorMsg:    "document signed-1 is not a PROOF of loan loan-123",
+		},
 		{
 			name:        "Loan Not Found",
 			loanID:      "loan-123",
 			validatorID: "validator-123",
-			proofURL:    "http://example.com/proof",
+			documentID:  "proof-1",
 			mockSetup: func(repo *mock.MockLoanRepository) {
 				repo.EXPECT().FindByID("loan-123").Return(nil, errors.New("loan not found"))
 			},
@@ -128,7 +635,7 @@ func TestApproveLoan(t *testing.T) {
 			name:        "Invalid State",
 			loanID:      "loan-123",
 			validatorID: "validator-123",
-			proofURL:    "http://example.com/proof",
+			documentID:  "proof-1",
 			mockSetup: func(repo *mock.MockLoanRepository) {
 				loan := &domain.Loan{
 					ID:    "loan-123",
@@ -139,18 +646,38 @@ func TestApproveLoan(t *testing.T) {
 			expectError: true,
 			errorMsg:    "loan must be in PROPOSED state",
 		},
+		{
+			name:        "Agreement Letter Not Rendered",
+			loanID:      "loan-123",
+			validatorID: "validator-123",
+			documentID:  "proof-1",
+			mockSetup: func(repo *mock.MockLoanRepository) {
+				loan := &domain.Loan{
+					ID:          "loan-123",
+					State:       domain.StateProposed,
+					CreditScore: newTestCreditScore(domain.GradeB),
+				}
+				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
+			},
+			renderErr:   errors.New("template error"),
+			expectError: true,
+			errorMsg:    "failed to render agreement letter",
+		},
 		{
 			name:        "Update Error",
 			loanID:      "loan-123",
 			validatorID: "validator-123",
-			proofURL:    "http://example.com/proof",
+			documentID:  "proof-1",
 			mockSetup: func(repo *mock.MockLoanRepository) {
 				loan := &domain.Loan{
-					ID:    "loan-123",
-					State: domain.StateProposed,
+					ID:          "loan-123",
+					State:       domain.StateProposed,
+					CreditScore: newTestCreditScore(domain.GradeB),
 				}
 				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
 				repo.EXPECT().Update(gomock.Any()).Return(errors.New("update error"))
+				// The committed events cannot be caught up with either
+				repo.EXPECT().FindByID("loan-123").Return(nil, errors.New("find error"))
 			},
 			expectError: true,
 			errorMsg:    "update error",
@@ -165,158 +692,1993 @@ func TestApproveLoan(t *testing.T) {
 			defer ctrl.Finish()
 
 			mockRepo := mock.NewMockLoanRepository(ctrl)
-			mockEmailSender := mock.NewMockEmailSender(ctrl)
+			mockAuditLog := newTestAuditLog(ctrl)
 			logger := logrus.New()
 
+			mockAgreements := newTestAgreements(ctrl)
+			if tc.renderErr != nil {
+				mockAgreements = mock.NewMockAgreementRenderer(ctrl)
+				mockAgreements.EXPECT().RenderAgreement(gomock.Any()).Return(nil, tc.renderErr)
+			}
+
+			// Configure mocks
+			tc.mockSetup(mockRepo)
+
+			// Create service
+			service := NewLoanService(mockRepo, nil, newTestBorrowers(ctrl), newTestEventStore(ctrl), mockAuditLog, newTestDocumentStore(ctrl), mockAgreements, nil, newTestScorer(ctrl), nil, logger)
+
+			// Execute
+			_, err := service.ApproveLoan(tc.loanID, tc.validatorID, tc.documentID, tc.override, nil)
+
+			// Assert
+			if tc.expectError {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tc.errorMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRejectLoan(t *testing.T) {
+	// Define test cases
+	testCases := []struct {
+		name        string
+		loanID      string
+		actorID     string
+		reason      domain.ReasonCode
+		mockSetup   func(*mock.MockLoanRepository)
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:    "Success",
+			loanID:  "loan-123",
+			actorID: "validator-123",
+			reason:  domain.ReasonCreditRisk,
+			mockSetup: func(repo *mock.MockLoanRepository) {
+				repo.EXPECT().FindByID("loan-123").Return(&domain.Loan{ID: "loan-123", State: domain.StateProposed}, nil)
+				repo.EXPECT().Update(gomock.Any()).DoAndReturn(func(loan *domain.Loan) error {
+					assert.Equal(t, domain.StateRejected, loan.State)
+					if assert.NotNil(t, loan.RejectedInfo) {
+						assert.Equal(t, "validator-123", loan.RejectedInfo.ActorID)
+						assert.Equal(t, domain.ReasonCreditRisk, loan.RejectedInfo.ReasonCode)
+					}
+					return nil
+				})
+			},
+		},
+		{
+			name:        "Empty Actor ID",
+			loanID:      "loan-123",
+			reason:      domain.ReasonCreditRisk,
+			mockSetup:   func(repo *mock.MockLoanRepository) {},
+			expectError: true,
+			errorMsg:    "actor ID cannot be empty",
+		},
+		{
+			name:        "Unknown Reason Code",
+			loanID:      "loan-123",
+			actorID:     "validator-123",
+			reason:      "BAD_VIBES",
+			mockSetup:   func(repo *mock.MockLoanRepository) {},
+			expectError: true,
+			errorMsg:    "invalid reason code",
+		},
+		{
+			name:    "Loan Not Found",
+			loanID:  "loan-123",
+			actorID: "validator-123",
+			reason:  domain.ReasonCreditRisk,
+			mockSetup: func(repo *mock.MockLoanRepository) {
+				repo.EXPECT().FindByID("loan-123").Return(nil, domain.ErrLoanNotFound)
+			},
+			expectError: true,
+			errorMsg:    "failed to find loan",
+		},
+		{
+			name:    "Invalid State",
+			loanID:  "loan-123",
+			actorID: "validator-123",
+			reason:  domain.ReasonCreditRisk,
+			mockSetup: func(repo *mock.MockLoanRepository) {
+				repo.EXPECT().FindByID("loan-123").Return(&domain.Loan{ID: "loan-123", State: domain.StateApproved}, nil)
+			},
+			expectError: true,
+			errorMsg:    "loan must be in PROPOSED state to reject",
+		},
+	}
+
+	// Run test cases
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mock.NewMockLoanRepository(ctrl)
+			mockAuditLog := newTestAuditLog(ctrl)
+			logger := logrus.New()
+
+			// Configure mocks
+			tc.mockSetup(mockRepo)
+
+			// Create service
+			service := NewLoanService(mockRepo, nil, nil, newTestEventStore(ctrl), mockAuditLog, nil, nil, nil, nil, nil, logger)
+
+			// Execute
+			_, err := service.RejectLoan(tc.loanID, tc.actorID, tc.reason, nil)
+
+			// Assert
+			if tc.expectError {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tc.errorMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCancelLoan(t *testing.T) {
+	partiallyFunded := func() *domain.Loan {
+		return &domain.Loan{
+			ID:              "loan-123",
+			State:           domain.StateApproved,
+			PrincipalAmount: money.MustNew("1000000", money.DefaultCurrency),
+			Investors: []domain.Investor{
+				{ID: "investor-1", Amount: money.MustNew("300000", money.DefaultCurrency), Email: "one@example.com"},
+				{ID: "investor-2", Amount: money.MustNew("200000", money.DefaultCurrency), Email: "two@example.com"},
+			},
+		}
+	}
+
+	// Define test cases
+	testCases := []struct {
+		name        string
+		loanID      string
+		actorID     string
+		reason      domain.ReasonCode
+		mockSetup   func(*mock.MockLoanRepository, *mock.MockEventStore)
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:    "Success - Proposed Loan",
+			loanID:  "loan-123",
+			actorID: "borrower-123",
+			reason:  domain.ReasonBorrowerWithdrawn,
+			mockSetup: func(repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				repo.EXPECT().FindByID("loan-123").Return(&domain.Loan{ID: "loan-123", State: domain.StateProposed}, nil)
+				repo.EXPECT().Update(gomock.Any()).DoAndReturn(func(loan *domain.Loan) error {
+					assert.Equal(t, domain.StateCancelled, loan.State)
+					assert.Empty(t, loan.Refunds)
+					if assert.NotNil(t, loan.CancelledInfo) {
+						assert.Equal(t, "borrower-123", loan.CancelledInfo.ActorID)
+						assert.Equal(t, domain.ReasonBorrowerWithdrawn, loan.CancelledInfo.ReasonCode)
+					}
+					return nil
+				})
+			},
+		},
+		{
+			name:    "Success - Partially Funded Loan Refunds Investors",
+			loanID:  "loan-123",
+			actorID: "officer-123",
+			reason:  domain.ReasonFundingExpired,
+			mockSetup: func(repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				repo.EXPECT().FindByID("loan-123").Return(partiallyFunded(), nil)
+				repo.EXPECT().Update(gomock.Any()).DoAndReturn(func(loan *domain.Loan) error {
+					assert.Equal(t, domain.StateCancelled, loan.State)
+					assert.Equal(t, []domain.Refund{
+						{InvestorID: "investor-1", Amount: money.MustNew("300000", money.DefaultCurrency), Email: "one@example.com"},
+						{InvestorID: "investor-2", Amount: money.MustNew("200000", money.DefaultCurrency), Email: "two@example.com"},
+					}, loan.Refunds)
+					return nil
+				})
+				expectMessages(store, func(messages []domain.OutboxMessage) {
+					if assert.Len(t, messages, 2) {
+						assert.Equal(t, domain.NotificationRefund, messages[0].Kind)
+						assert.Equal(t, "one@example.com", messages[0].Recipient.Email)
+						assert.Equal(t, money.MustNew("300000", money.DefaultCurrency), *messages[0].Amount)
+						assert.Equal(t, domain.ReasonFundingExpired, messages[0].Reason)
+						assert.Equal(t, "two@example.com", messages[1].Recipient.Email)
+						assert.Equal(t, money.MustNew("200000", money.DefaultCurrency), *messages[1].Amount)
+					}
+				})
+			},
+		},
+		{
+			name:        "Unknown Reason Code",
+			loanID:      "loan-123",
+			actorID:     "officer-123",
+			reason:      "",
+			mockSetup:   func(repo *mock.MockLoanRepository, store *mock.MockEventStore) {},
+			expectError: true,
+			errorMsg:    "invalid reason code",
+		},
+		{
+			name:    "Invalid State",
+			loanID:  "loan-123",
+			actorID: "officer-123",
+			reason:  domain.ReasonOther,
+			mockSetup: func(repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				repo.EXPECT().FindByID("loan-123").Return(&domain.Loan{ID: "loan-123", State: domain.StateInvested}, nil)
+			},
+			expectError: true,
+			errorMsg:    "loan must be in PROPOSED or APPROVED state to cancel",
+		},
+		{
+			name:    "Commit Failure Queues No Emails",
+			loanID:  "loan-123",
+			actorID: "officer-123",
+			reason:  domain.ReasonOther,
+			mockSetup: func(repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				repo.EXPECT().FindByID("loan-123").Return(partiallyFunded(), nil)
+				store.EXPECT().Commit("loan-123", gomock.Any(), gomock.Any(), gomock.Len(2)).Return(errors.New("disk error"))
+			},
+			expectError: true,
+			errorMsg:    "disk error",
+		},
+	}
+
+	// Run test cases
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mock.NewMockLoanRepository(ctrl)
+			mockAuditLog := newTestAuditLog(ctrl)
+			logger := logrus.New()
+
+			// Configure mocks
+			mockEventStore := mock.NewMockEventStore(ctrl)
+			tc.mockSetup(mockRepo, mockEventStore)
+			acceptCommits(mockEventStore)
+
+			// Create service
+			service := NewLoanService(mockRepo, nil, nil, mockEventStore, mockAuditLog, nil, nil, nil, nil, nil, logger)
+
+			// Execute
+			_, err := service.CancelLoan(tc.loanID, tc.actorID, tc.reason, nil)
+
+			// Assert
+			if tc.expectError {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tc.errorMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAddInvestment(t *testing.T) {
+	// Define test cases
+	testCases := []struct {
+		name        string
+		loanID      string
+		investorID  string
+		email       string
+		amount      money.Money
+		mockSetup   func(*mock.MockLoanRepository, *mock.MockEventStore)
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:       "Success - Partial Investment",
+			loanID:     "loan-123",
+			investorID: "investor-123",
+			email:      "investor@example.com",
+			amount:     money.MustNew("500", money.DefaultCurrency),
+			mockSetup: func(repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				loan := &domain.Loan{
+					ID:              "loan-123",
+					State:           domain.StateApproved,
+					PrincipalAmount: money.MustNew("1000", money.DefaultCurrency),
+					AgreementLetter: "http://example.com/agreement",
+				}
+				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
+				repo.EXPECT().Update(gomock.Any()).Return(nil)
+			},
+			expectError: false,
+		},
+		{
+			name:       "Success - Full Investment",
+			loanID:     "loan-123",
+			investorID: "investor-123",
+			email:      "investor@example.com",
+			amount:     money.MustNew("1000", money.DefaultCurrency),
+			mockSetup: func(repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				loan := &domain.Loan{
+					ID:              "loan-123",
+					BorrowerID:      "borrower-123",
+					State:           domain.StateApproved,
+					PrincipalAmount: money.MustNew("1000", money.DefaultCurrency),
+					ROI:             money.MustParse("10"),
+					Terms:           domain.RepaymentTerms{TenorMonths: 6, Scheme: domain.SchemeFlat},
+					AgreementLetter: "http://example.com/agreement",
+				}
+				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
+				expectMessages(store, func(messages []domain.OutboxMessage) {
+					if assert.Len(t, messages, 3) {
+						assert.Equal(t, domain.NotificationInvestmentReceived, messages[0].Kind)
+						assert.Equal(t, "investor@example.com", messages[0].Recipient.Email)
+						assert.Equal(t, domain.NotificationLoanFunded, messages[1].Kind)
+						assert.Equal(t, domain.Recipient{ID: "borrower-123", Role: domain.RoleBorrower}, messages[1].Recipient)
+						assert.Equal(t, domain.NotificationAgreement, messages[2].Kind)
+						assert.Equal(t, "investor@example.com", messages[2].Recipient.Email)
+						assert.Equal(t, domain.OutboxPending, messages[2].Status)
+						assert.Equal(t, &domain.AgreementEmail{
+							Email:  "investor@example.com",
+							LoanID: "loan-123",
+							Amount: money.MustNew("1000", money.DefaultCurrency),
+							ROI:    money.MustParse("10"),
+							// 10% a year over the 6 month tenor
+							ExpectedReturn: money.MustNew("50", money.DefaultCurrency),
+							Terms:          domain.RepaymentTerms{TenorMonths: 6, Scheme: domain.SchemeFlat},
+							AgreementURL:   "http://example.com/agreement",
+						}, messages[2].Agreement)
+					}
+				})
+				repo.EXPECT().Update(gomock.Any()).Return(nil)
+			},
+			expectError: false,
+		},
+		{
+			name:       "Success - Fractional Amounts Fully Fund Loan",
+			loanID:     "loan-123",
+			investorID: "investor-456",
+			email:      "second@example.com",
+			amount:     money.MustNew("0.20", "USD"),
+			mockSetup: func(repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				loan := &domain.Loan{
+					ID:              "loan-123",
+					State:           domain.StateApproved,
+					PrincipalAmount: money.MustNew("0.30", "USD"),
+					AgreementLetter: "http://example.com/agreement",
+					Investors: []domain.Investor{
+						{ID: "investor-123", Amount: money.MustNew("0.10", "USD"), Email: "first@example.com"},
+					},
+				}
+				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
+				repo.EXPECT().Update(gomock.Any()).DoAndReturn(func(loan *domain.Loan) error {
+					assert.Equal(t, domain.StateInvested, loan.State)
+					return nil
+				})
+				expectMessages(store, func(messages []domain.OutboxMessage) {
+					if assert.Len(t, messages, 4) {
+						assert.Equal(t, domain.NotificationInvestmentReceived, messages[0].Kind)
+						assert.Equal(t, "second@example.com", messages[0].Recipient.Email)
+						assert.Equal(t, domain.NotificationLoanFunded, messages[1].Kind)
+						assert.Equal(t, "first@example.com", messages[2].Recipient.Email)
+						assert.Equal(t, "second@example.com", messages[3].Recipient.Email)
+					}
+				})
+			},
+			expectError: false,
+		},
+		{
+			name:       "Currency Mismatch",
+			loanID:     "loan-123",
+			investorID: "investor-123",
+			email:      "investor@example.com",
+			amount:     money.MustNew("500", "USD"),
+			mockSetup: func(repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				loan := &domain.Loan{
+					ID:              "loan-123",
+					State:           domain.StateApproved,
+					PrincipalAmount: money.MustNew("1000", money.DefaultCurrency),
+					AgreementLetter: "http://example.com/agreement",
+				}
+				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
+			},
+			expectError: true,
+			errorMsg:    "currency mismatch",
+		},
+		{
+			name:       "Amount Finer Than Currency Minor Units",
+			loanID:     "loan-123",
+			investorID: "investor-123",
+			email:      "investor@example.com",
+			amount:     money.MustNew("10.005", "USD"),
+			mockSetup: func(repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				loan := &domain.Loan{
+					ID:              "loan-123",
+					State:           domain.StateApproved,
+					PrincipalAmount: money.MustNew("1000", "USD"),
+					AgreementLetter: "http://example.com/agreement",
+				}
+				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
+			},
+			expectError: true,
+			errorMsg:    "invalid investment amount",
+		},
+		{
+			name:       "Loan Not Found",
+			loanID:     "loan-123",
+			investorID: "investor-123",
+			email:      "investor@example.com",
+			amount:     money.MustNew("500", money.DefaultCurrency),
+			mockSetup: func(repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				repo.EXPECT().FindByID("loan-123").Return(nil, errors.New("loan not found"))
+			},
+			expectError: true,
+			errorMsg:    "failed to find loan",
+		},
+		{
+			name:       "Invalid State",
+			loanID:     "loan-123",
+			investorID: "investor-123",
+			email:      "investor@example.com",
+			amount:     money.MustNew("500", money.DefaultCurrency),
+			mockSetup: func(repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				loan := &domain.Loan{
+					ID:    "loan-123",
+					State: domain.StateProposed,
+				}
+				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
+			},
+			expectError: true,
+			errorMsg:    "loan must be in APPROVED state to invest",
+		},
+		{
+			name:       "Agreement Letter Not Generated",
+			loanID:     "loan-123",
+			investorID: "investor-123",
+			email:      "investor@example.com",
+			amount:     money.MustNew("500", money.DefaultCurrency),
+			mockSetup: func(repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				loan := &domain.Loan{
+					ID:              "loan-123",
+					State:           domain.StateApproved,
+					PrincipalAmount: money.MustNew("1000", money.DefaultCurrency),
+				}
+				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
+			},
+			expectError: true,
+			errorMsg:    "cannot invest loan loan-123: agreement letter not generated",
+		},
+		{
+			name:       "Unknown Investor",
+			loanID:     "loan-123",
+			investorID: "unknown-investor",
+			email:      "investor@example.com",
+			amount:     money.MustNew("500", money.DefaultCurrency),
+			mockSetup: func(repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				loan := &domain.Loan{
+					ID:              "loan-123",
+					State:           domain.StateApproved,
+					PrincipalAmount: money.MustNew("1000", money.DefaultCurrency),
+					AgreementLetter: "http://example.com/agreement",
+				}
+				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
+			},
+			expectError: true,
+			errorMsg:    "failed to find investor: investor not found",
+		},
+		{
+			name:       "Investor Not Verified",
+			loanID:     "loan-123",
+			investorID: "investor-pending",
+			email:      "investor@example.com",
+			amount:     money.MustNew("500", money.DefaultCurrency),
+			mockSetup: func(repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				loan := &domain.Loan{
+					ID:              "loan-123",
+					State:           domain.StateApproved,
+					PrincipalAmount: money.MustNew("1000", money.DefaultCurrency),
+					AgreementLetter: "http://example.com/agreement",
+				}
+				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
+			},
+			expectError: true,
+			errorMsg:    "investor not verified: investor investor-pending is PENDING",
+		},
+		{
+			name:       "Per Loan Limit Exceeded",
+			loanID:     "loan-123",
+			investorID: "investor-limited",
+			email:      "investor@example.com",
+			amount:     money.MustNew("200", money.DefaultCurrency),
+			mockSetup: func(repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				loan := &domain.Loan{
+					ID:              "loan-123",
+					State:           domain.StateApproved,
+					PrincipalAmount: money.MustNew("1000", money.DefaultCurrency),
+					AgreementLetter: "http://example.com/agreement",
+					Investors:       []domain.Investor{{ID: "investor-limited", Amount: money.MustNew("200", money.DefaultCurrency)}},
+				}
+				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
+			},
+			expectError: true,
+			errorMsg:    "investment limit exceeded: per loan commitment of 400 IDR would exceed the limit of 300 IDR",
+		},
+		{
+			name:       "Total Limit Exceeded",
+			loanID:     "loan-123",
+			investorID: "investor-capped",
+			email:      "investor@example.com",
+			amount:     money.MustNew("400", money.DefaultCurrency),
+			mockSetup: func(repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				loan := &domain.Loan{
+					ID:              "loan-123",
+					State:           domain.StateApproved,
+					PrincipalAmount: money.MustNew("1000", money.DefaultCurrency),
+					AgreementLetter: "http://example.com/agreement",
+				}
+				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
+				repo.EXPECT().FindByInvestorID("investor-capped").Return([]*domain.Loan{
+					{ID: "loan-1", State: domain.StateDisbursed, Investors: []domain.Investor{{ID: "investor-capped", Amount: money.MustNew("500", money.DefaultCurrency)}}},
+					{ID: "loan-2", State: domain.StateRepaid, Investors: []domain.Investor{{ID: "investor-capped", Amount: money.MustNew("700", money.DefaultCurrency)}}},
+				}, nil)
+			},
+			expectError: true,
+			errorMsg:    "investment limit exceeded: total commitment of 900 IDR would exceed the limit of 800 IDR",
+		},
+		{
+			name:       "Total Limit Counts Outstanding Loans Only",
+			loanID:     "loan-123",
+			investorID: "investor-capped",
+			email:      "investor@example.com",
+			amount:     money.MustNew("300", money.DefaultCurrency),
+			mockSetup: func(repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				loan := &domain.Loan{
+					ID:              "loan-123",
+					State:           domain.StateApproved,
+					PrincipalAmount: money.MustNew("1000", money.DefaultCurrency),
+					AgreementLetter: "http://example.com/agreement",
+				}
+				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
+				repo.EXPECT().FindByInvestorID("investor-capped").Return([]*domain.Loan{
+					{ID: "loan-1", State: domain.StateDisbursed, Investors: []domain.Investor{{ID: "investor-capped", Amount: money.MustNew("500", money.DefaultCurrency)}}},
+					{ID: "loan-2", State: domain.StateRepaid, Investors: []domain.Investor{{ID: "investor-capped", Amount: money.MustNew("700", money.DefaultCurrency)}}},
+				}, nil)
+				repo.EXPECT().Update(gomock.Any()).Return(nil)
+			},
+			expectError: false,
+		},
+		{
+			name:       "Contact Details Taken From Investor Profile",
+			loanID:     "loan-123",
+			investorID: "investor-789",
+			amount:     money.MustNew("500", money.DefaultCurrency),
+			mockSetup: func(repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				loan := &domain.Loan{
+					ID:              "loan-123",
+					State:           domain.StateApproved,
+					PrincipalAmount: money.MustNew("1000", money.DefaultCurrency),
+					AgreementLetter: "http://example.com/agreement",
+				}
+				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
+				expectMessages(store, func(messages []domain.OutboxMessage) {
+					if assert.Len(t, messages, 1) {
+						assert.Equal(t, "investor-789@example.com", messages[0].Recipient.Email)
+					}
+				})
+				repo.EXPECT().Update(gomock.Any()).DoAndReturn(func(loan *domain.Loan) error {
+					assert.Equal(t, domain.Investor{
+						ID: "investor-789", Email: "investor-789@example.com", Amount: money.MustNew("500", money.DefaultCurrency),
+					}, loan.Investors[0])
+					return nil
+				})
+			},
+			expectError: false,
+		},
+		{
+			name:       "Investment Exceeds Principal",
+			loanID:     "loan-123",
+			investorID: "investor-123",
+			email:      "investor@example.com",
+			amount:     money.MustNew("1500", money.DefaultCurrency),
+			mockSetup: func(repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				loan := &domain.Loan{
+					ID:              "loan-123",
+					State:           domain.StateApproved,
+					PrincipalAmount: money.MustNew("1000", money.DefaultCurrency),
+					AgreementLetter: "http://example.com/agreement",
+				}
+				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
+			},
+			expectError: true,
+			errorMsg:    "investment exceeds principal",
+		},
+		{
+			name:       "Partial Investment Notifies The Investor Only",
+			loanID:     "loan-123",
+			investorID: "investor-123",
+			email:      "investor@example.com",
+			amount:     money.MustNew("500", money.DefaultCurrency),
+			mockSetup: func(repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				loan := &domain.Loan{
+					ID:              "loan-123",
+					State:           domain.StateApproved,
+					PrincipalAmount: money.MustNew("1000", money.DefaultCurrency),
+					AgreementLetter: "http://example.com/agreement",
+				}
+				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
+				expectMessages(store, func(messages []domain.OutboxMessage) {
+					if assert.Len(t, messages, 1) {
+						assert.Equal(t, domain.NotificationInvestmentReceived, messages[0].Kind)
+						assert.Equal(t, domain.Recipient{ID: "investor-123", Role: domain.RoleInvestor, Email: "investor@example.com"}, messages[0].Recipient)
+						assert.Equal(t, money.MustNew("500", money.DefaultCurrency), *messages[0].Amount)
+					}
+				})
+				repo.EXPECT().Update(gomock.Any()).Return(nil)
+			},
+			expectError: false,
+		},
+		{
+			name:       "Update Error",
+			loanID:     "loan-123",
+			investorID: "investor-123",
+			email:      "investor@example.com",
+			amount:     money.MustNew("500", money.DefaultCurrency),
+			mockSetup: func(repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				loan := &domain.Loan{
+					ID:              "loan-123",
+					State:           domain.StateApproved,
+					PrincipalAmount: money.MustNew("1000", money.DefaultCurrency),
+					AgreementLetter: "http://example.com/agreement",
+				}
+				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
+				repo.EXPECT().Update(gomock.Any()).Return(errors.New("update error"))
+				// The committed events cannot be caught up with either
+				repo.EXPECT().FindByID("loan-123").Return(nil, errors.New("find error"))
+			},
+			expectError: true,
+			errorMsg:    "update error",
+		},
+		{
+			name:       "Version Conflict Retried",
+			loanID:     "loan-123",
+			investorID: "investor-123",
+			email:      "investor@example.com",
+			amount:     money.MustNew("500", money.DefaultCurrency),
+			mockSetup: func(repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				repo.EXPECT().FindByID("loan-123").DoAndReturn(func(id string) (*domain.Loan, error) {
+					return &domain.Loan{ID: id, State: domain.StateApproved, PrincipalAmount: money.MustNew("1000", money.DefaultCurrency), AgreementLetter: "http://example.com/agreement", Version: 1}, nil
+				}).Times(3)
+				expectStaleCommit(store)
+				repo.EXPECT().Update(gomock.Any()).Return(nil)
+			},
+			expectError: false,
+		},
+		{
+			name:       "Version Conflict Exhausted",
+			loanID:     "loan-123",
+			investorID: "investor-123",
+			email:      "investor@example.com",
+			amount:     money.MustNew("500", money.DefaultCurrency),
+			mockSetup: func(repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				repo.EXPECT().FindByID("loan-123").DoAndReturn(func(id string) (*domain.Loan, error) {
+					return &domain.Loan{ID: id, State: domain.StateApproved, PrincipalAmount: money.MustNew("1000", money.DefaultCurrency), AgreementLetter: "http://example.com/agreement", Version: 1}, nil
+				}).Times(2 * maxConflictAttempts)
+				for attempt := 0; attempt < maxConflictAttempts; attempt++ {
+					expectStaleCommit(store)
+				}
+			},
+			expectError: true,
+			errorMsg:    "modified concurrently",
+		},
+	}
+
+	// Run test cases
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mock.NewMockLoanRepository(ctrl)
+			mockAuditLog := newTestAuditLog(ctrl)
+			logger := logrus.New()
+
+			// Configure mocks
+			mockEventStore := mock.NewMockEventStore(ctrl)
+			tc.mockSetup(mockRepo, mockEventStore)
+			acceptCommits(mockEventStore)
+
+			// Create service
+			service := NewLoanService(mockRepo, newTestInvestors(ctrl), nil, mockEventStore, mockAuditLog, nil, nil, nil, nil, nil, logger)
+
+			// Execute
+			_, err := service.AddInvestment(tc.loanID, domain.Investor{ID: tc.investorID, Email: tc.email, Amount: tc.amount}, nil)
+
+			// Assert
+			if tc.expectError {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tc.errorMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAddInvestment_IfMatch(t *testing.T) {
+	approved := func(id string) (*domain.Loan, error) {
+		return &domain.Loan{ID: id, State: domain.StateApproved, PrincipalAmount: money.MustNew("1000", money.DefaultCurrency), AgreementLetter: "http://example.com/agreement", Version: 1}, nil
+	}
+
+	// Define test cases
+	testCases := []struct {
+		name        string
+		match       domain.IfMatch
+		mockSetup   func(*mock.MockLoanRepository, *mock.MockEventStore)
+		wantVersion int64
+		wantErr     error
+		errorMsg    string
+	}{
+		{
+			name:  "Matching Version",
+			match: domain.IfMatch{1},
+			mockSetup: func(repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				repo.EXPECT().FindByID("loan-123").DoAndReturn(approved)
+				repo.EXPECT().Update(gomock.Any()).DoAndReturn(func(loan *domain.Loan) error {
+					loan.Version++
+					return nil
+				})
+			},
+			wantVersion: 2,
+		},
+		{
+			name:  "Stale Version",
+			match: domain.IfMatch{3},
+			mockSetup: func(repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				repo.EXPECT().FindByID("loan-123").DoAndReturn(approved)
+			},
+			wantErr:  domain.ErrPreconditionFailed,
+			errorMsg: "expected version 3, found 1",
+		},
+		{
+			name:  "Version Conflict Not Retried",
+			match: domain.IfMatch{1},
+			mockSetup: func(repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				repo.EXPECT().FindByID("loan-123").DoAndReturn(approved).Times(2)
+				expectStaleCommit(store)
+			},
+			wantErr:  domain.ErrPreconditionFailed,
+			errorMsg: "expected version 1, found 2",
+		},
+		{
+			name:  "Loan Not Found",
+			match: domain.IfMatch{1},
+			mockSetup: func(repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				repo.EXPECT().FindByID("loan-123").Return(nil, domain.ErrLoanNotFound)
+			},
+			wantErr:  domain.ErrLoanNotFound,
+			errorMsg: "loan not found",
+		},
+	}
+
+	// Run test cases
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mock.NewMockLoanRepository(ctrl)
+			mockEventStore := mock.NewMockEventStore(ctrl)
+			tc.mockSetup(mockRepo, mockEventStore)
+			acceptCommits(mockEventStore)
+
+			// Create service
+			service := NewLoanService(mockRepo, newTestInvestors(ctrl), nil, mockEventStore, newTestAuditLog(ctrl), nil, nil, nil, nil, nil, logrus.New())
+
+			// Execute
+			version, err := service.AddInvestment("loan-123", domain.Investor{ID: "investor-123", Email: "investor@example.com", Amount: money.MustNew("500", money.DefaultCurrency)}, tc.match)
+
+			// Assert
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				assert.Contains(t, err.Error(), tc.errorMsg)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantVersion, version)
+		})
+	}
+}
+
+func TestAddInvestment_ConcurrentTotalLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// investor-capped may invest 800 in total, so only one of the investments of 500 fits
+	const loans = 8
+	repo := loanRepo.NewInMemoryRepository(logrus.New())
+	for i := 0; i < loans; i++ {
+		assert.NoError(t, repo.Save(&domain.Loan{
+			ID:              fmt.Sprintf("loan-%d", i),
+			State:           domain.StateApproved,
+			PrincipalAmount: money.MustNew("1000", money.DefaultCurrency),
+			AgreementLetter: "http://example.com/agreement",
+		}))
+	}
+	// Committing is slow enough for unserialized investments to all pass the limit before any is stored
+	store := mock.NewMockEventStore(ctrl)
+	store.EXPECT().Commit(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(string, int64, []domain.Event, []domain.OutboxMessage) error {
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		}).AnyTimes()
+	service := NewLoanService(repo, newTestInvestors(ctrl), nil, store, newTestAuditLog(ctrl), nil, nil, nil, nil, nil, logrus.New())
+
+	var wg sync.WaitGroup
+	errs := make([]error, loans)
+	for i := 0; i < loans; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = service.AddInvestment(fmt.Sprintf("loan-%d", i), domain.Investor{ID: "investor-capped", Amount: money.MustNew("500", money.DefaultCurrency)}, nil)
+		}(i)
+	}
+	wg.Wait()
+
+	invested := 0
+	for _, err := range errs {
+		if err == nil {
+			invested++
+			continue
+		}
+		assert.ErrorIs(t, err, domain.ErrInvestmentLimitExceeded)
+	}
+	assert.Equal(t, 1, invested)
+}
+
+func TestDisburseLoan(t *testing.T) {
+	// Define test cases
+	testCases := []struct {
+		name           string
+		loanID         string
+		fieldOfficerID string
+		documentID     string
+		mockSetup      func(*mock.MockLoanRepository)
+		verifyErr      error
+		expectError    bool
+		errorMsg       string
+	}{
+		{
+			name:           "Success",
+			loanID:         "loan-123",
+			fieldOfficerID: "officer-123",
+			documentID:     "signed-1",
+			mockSetup: func(repo *mock.MockLoanRepository) {
+				loan := &domain.Loan{
+					ID:                  "loan-123",
+					State:               domain.StateInvested,
+					PrincipalAmount:     money.MustNew("1200000", money.DefaultCurrency),
+					Rate:                money.MustParse("12"),
+					Terms:               domain.RepaymentTerms{TenorMonths: 6, Scheme: domain.SchemeFlat},
+					AgreementDocumentID: "agreement-1",
+				}
+				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
+				repo.EXPECT().Update(gomock.Any()).DoAndReturn(func(loan *domain.Loan) error {
+					assert.Equal(t, domain.StateDisbursed, loan.State)
+					assert.Equal(t, "signed-1", loan.DisbursedInfo.SignedAgreementDocumentID)
+					assert.Equal(t, "http://example.com/documents/signed-1", loan.DisbursedInfo.SignedAgreement)
+					if assert.Len(t, loan.Schedule, 6) {
+						assert.Equal(t, money.MustNew("200000", money.DefaultCurrency), loan.Schedule[0].Principal)
+						assert.Equal(t, money.MustNew("12000", money.DefaultCurrency), loan.Schedule[0].Interest)
+						assert.True(t, loan.Schedule[0].DueDate.After(loan.DisbursedInfo.Date))
+					}
+					return nil
+				})
+			},
+			expectError: false,
+		},
+		{
+			name:           "Empty Loan ID",
+			loanID:         "",
+			fieldOfficerID: "officer-123",
+			documentID:     "signed-1",
+			mockSetup:      func(repo *mock.MockLoanRepository) {},
+			expectError:    true,
+			errorMsg:       "loan ID cannot be empty",
+		},
+		{
+			name:           "Empty Field Officer ID",
+			loanID:         "loan-123",
+			fieldOfficerID: "",
+			documentID:     "signed-1",
+			mockSetup:      func(repo *mock.MockLoanRepository) {},
+			expectError:    true,
+			errorMsg:       "field officer ID cannot be empty",
+		},
+		{
+			name:           "Empty Signed Agreement",
+			loanID:         "loan-123",
+			fieldOfficerID: "officer-123",
+			documentID:     "",
+			mockSetup:      func(repo *mock.MockLoanRepository) {},
+			expectError:    true,
+			errorMsg:       "signed agreement document ID cannot be empty",
+		},
+		{
+			name:           "Signed Agreement Of Another Loan",
+			loanID:         "loan-456",
+			fieldOfficerID: "officer-123",
+			documentID:     "signed-1",
+			mockSetup:      func(repo *mock.MockLoanRepository) {},
+			expectError:    true,
+			errorMsg:       "document does not match",
+		},
+		{
+			name:           "Loan Not Found",
+			loanID:         "loan-123",
+			fieldOfficerID: "officer-123",
+			documentID:     "signed-1",
+			mockSetup: func(repo *mock.MockLoanRepository) {
+				repo.EXPECT().FindByID("loan-123").Return(nil, errors.New("loan not found"))
+			},
+			expectError: true,
+			errorMsg:    "failed to find loan",
+		},
+		{
+			name:           "Invalid State",
+			loanID:         "loan-123",
+			fieldOfficerID: "officer-123",
+			documentID:     "signed-1",
+			mockSetup: func(repo *mock.MockLoanRepository) {
+				loan := &domain.Loan{
+					ID:    "loan-123",
+					State: domain.StateApproved,
+				}
+				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
+			},
+			expectError: true,
+			errorMsg:    "loan must be in INVESTED state",
+		},
+		{
+			name:           "No Agreement Letter Generated",
+			loanID:         "loan-123",
+			fieldOfficerID: "officer-123",
+			documentID:     "signed-1",
+			mockSetup: func(repo *mock.MockLoanRepository) {
+				loan := &domain.Loan{
+					ID:              "loan-123",
+					State:           domain.StateInvested,
+					PrincipalAmount: money.MustNew("1200000", money.DefaultCurrency),
+					Rate:            money.MustParse("12"),
+					Terms:           domain.RepaymentTerms{TenorMonths: 6, Scheme: domain.SchemeFlat},
+				}
+				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
+			},
+			expectError: true,
+			errorMsg:    "no agreement letter was generated for the loan",
+		},
+		{
+			name:           "Agreement Letter Missing From Store",
+			loanID:         "loan-123",
+			fieldOfficerID: "officer-123",
+			documentID:     "signed-1",
+			mockSetup: func(repo *mock.MockLoanRepository) {
+				loan := &domain.Loan{
+					ID:                  "loan-123",
+					State:               domain.StateInvested,
+					PrincipalAmount:     money.MustNew("1200000", money.DefaultCurrency),
+					Rate:                money.MustParse("12"),
+					Terms:               domain.RepaymentTerms{TenorMonths: 6, Scheme: domain.SchemeFlat},
+					AgreementDocumentID: "agreement-0",
+				}
+				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
+			},
+			expectError: true,
+			errorMsg:    "failed to read agreement letter",
+		},
+		{
+			name:           "Signed Agreement Rejected",
+			loanID:         "loan-123",
+			fieldOfficerID: "officer-123",
+			documentID:     "signed-1",
+			mockSetup: func(repo *mock.MockLoanRepository) {
+				loan := &domain.Loan{
+					ID:                  "loan-123",
+					State:               domain.StateInvested,
+					PrincipalAmount:     money.MustNew("1200000", money.DefaultCurrency),
+					Rate:                money.MustParse("12"),
+					Terms:               domain.RepaymentTerms{TenorMonths: 6, Scheme: domain.SchemeFlat},
+					AgreementDocumentID: "agreement-1",
+				}
+				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
+			},
+			verifyErr:   &domain.AgreementVerificationError{LoanID: "loan-123", Problems: []string{"the BORROWER signature is missing"}},
+			expectError: true,
+			errorMsg:    "signed agreement of loan loan-123 could not be verified: the BORROWER signature is missing",
+		},
+		{
+			name:           "Missing Repayment Terms",
+			loanID:         "loan-123",
+			fieldOfficerID: "officer-123",
+			documentID:     "signed-1",
+			mockSetup: func(repo *mock.MockLoanRepository) {
+				loan := &domain.Loan{
+					ID:                  "loan-123",
+					State:               domain.StateInvested,
+					PrincipalAmount:     money.MustNew("1200000", money.DefaultCurrency),
+					AgreementDocumentID: "agreement-1",
+				}
+				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
+			},
+			expectError: true,
+			errorMsg:    "cannot disburse loan loan-123: invalid repayment terms",
+		},
+		{
+			name:           "Update Error",
+			loanID:         "loan-123",
+			fieldOfficerID: "officer-123",
+			documentID:     "signed-1",
+			mockSetup: func(repo *mock.MockLoanRepository) {
+				loan := &domain.Loan{
+					ID:                  "loan-123",
+					State:               domain.StateInvested,
+					PrincipalAmount:     money.MustNew("1200000", money.DefaultCurrency),
+					Rate:                money.MustParse("12"),
+					Terms:               domain.RepaymentTerms{TenorMonths: 6, Scheme: domain.SchemeFlat},
+					AgreementDocumentID: "agreement-1",
+				}
+				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
+				repo.EXPECT().Update(gomock.Any()).Return(errors.New("update error"))
+				// The committed events cannot be caught up with either
+				repo.EXPECT().FindByID("loan-123").Return(nil, errors.New("find error"))
+			},
+			expectError: true,
+			errorMsg:    "update error",
+		},
+	}
+
+	// Run test cases
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mock.NewMockLoanRepository(ctrl)
+			mockAuditLog := newTestAuditLog(ctrl)
+			logger := logrus.New()
+
+			mockVerifier := mock.NewMockAgreementVerifier(ctrl)
+
+			// Configure mocks
+			tc.mockSetup(mockRepo)
+			mockVerifier.EXPECT().VerifySignedAgreement(gomock.Any(), []byte("agreement"), []byte("agreement signed")).Return(tc.verifyErr).AnyTimes()
+
+			// Create service
+			service := NewLoanService(mockRepo, nil, nil, newTestEventStore(ctrl), mockAuditLog, newTestDocumentStore(ctrl), nil, mockVerifier, nil, nil, logger)
+
+			// Execute
+			_, err := service.DisburseLoan(tc.loanID, tc.fieldOfficerID, tc.documentID, nil)
+
+			// Assert
+			if tc.expectError {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tc.errorMsg)
+				if tc.verifyErr != nil {
+					assert.ErrorIs(t, err, domain.ErrAgreementNotVerified)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestGenerateAgreementLetter(t *testing.T) {
+	letter := []byte("%PDF-1.4 agreement")
+	stored := &domain.Document{
+		ID:          "document-1",
+		LoanID:      "loan-123",
+		Kind:        domain.DocumentAgreement,
+		Name:        "agreement-loan-123.pdf",
+		ContentType: domain.ContentTypePDF,
+		Size:        int64(len(letter)),
+		URL:         "http://example.com/documents/document-1",
+	}
+
+	// Define test cases
+	testCases := []struct {
+		name        string
+		loanID      string
+		mockSetup   func(*mock.MockLoanRepository, *mock.MockDocumentStore, *mock.MockAgreementRenderer)
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:   "Success",
+			loanID: "loan-123",
+			mockSetup: func(repo *mock.MockLoanRepository, documents *mock.MockDocumentStore, agreements *mock.MockAgreementRenderer) {
+				loan := &domain.Loan{
+					ID:    "loan-123",
+					State: domain.StateInvested,
+				}
+				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
+				agreements.EXPECT().RenderAgreement(loan).Return(letter, nil)
+				documents.EXPECT().Save(domain.Document{
+					LoanID:      "loan-123",
+					Kind:        domain.DocumentAgreement,
+					Name:        "agreement-loan-123.pdf",
+					ContentType: domain.ContentTypePDF,
+				}, letter).Return(stored, nil)
+				repo.EXPECT().Update(gomock.Any()).DoAndReturn(func(loan *domain.Loan) error {
+					assert.Equal(t, "http://example.com/documents/document-1", loan.AgreementLetter)
+					assert.Equal(t, "document-1", loan.AgreementDocumentID)
+					return nil
+				})
+			},
+			expectError: false,
+		},
+		{
+			name:        "Empty Loan ID",
+			loanID:      "",
+			mockSetup:   func(*mock.MockLoanRepository, *mock.MockDocumentStore, *mock.MockAgreementRenderer) {},
+			expectError: true,
+			errorMsg:    "loan ID cannot be empty",
+		},
+		{
+			name:   "Loan Not Found",
+			loanID: "loan-123",
+			mockSetup: func(repo *mock.MockLoanRepository, documents *mock.MockDocumentStore, agreements *mock.MockAgreementRenderer) {
+				repo.EXPECT().FindByID("loan-123").Return(nil, errors.New("loan not found"))
+			},
+			expectError: true,
+			errorMsg:    "failed to find loan",
+		},
+		{
+			name:   "Proposed Loan",
+			loanID: "loan-123",
+			mockSetup: func(repo *mock.MockLoanRepository, documents *mock.MockDocumentStore, agreements *mock.MockAgreementRenderer) {
+				repo.EXPECT().FindByID("loan-123").Return(&domain.Loan{ID: "loan-123", State: domain.StateProposed}, nil)
+			},
+			expectError: true,
+			errorMsg:    "agreement letters are regenerated while the loan is APPROVED or INVESTED, loan loan-123 is PROPOSED",
+		},
+		{
+			name:   "Disbursed Loan",
+			loanID: "loan-123",
+			mockSetup: func(repo *mock.MockLoanRepository, documents *mock.MockDocumentStore, agreements *mock.MockAgreementRenderer) {
+				repo.EXPECT().FindByID("loan-123").Return(&domain.Loan{ID: "loan-123", State: domain.StateDisbursed}, nil)
+			},
+			expectError: true,
+			errorMsg:    "loan loan-123 is DISBURSED",
+		},
+		{
+			name:   "Render Error",
+			loanID: "loan-123",
+			mockSetup: func(repo *mock.MockLoanRepository, documents *mock.MockDocumentStore, agreements *mock.MockAgreementRenderer) {
+				repo.EXPECT().FindByID("loan-123").Return(&domain.Loan{ID: "loan-123", State: domain.StateApproved}, nil)
+				agreements.EXPECT().RenderAgreement(gomock.Any()).Return(nil, errors.New("template error"))
+			},
+			expectError: true,
+			errorMsg:    "failed to render agreement letter",
+		},
+		{
+			name:   "Document Store Error",
+			loanID: "loan-123",
+			mockSetup: func(repo *mock.MockLoanRepository, documents *mock.MockDocumentStore, agreements *mock.MockAgreementRenderer) {
+				repo.EXPECT().FindByID("loan-123").Return(&domain.Loan{ID: "loan-123", State: domain.StateApproved}, nil)
+				agreements.EXPECT().RenderAgreement(gomock.Any()).Return(letter, nil)
+				documents.EXPECT().Save(gomock.Any(), letter).Return(nil, errors.New("disk full"))
+			},
+			expectError: true,
+			errorMsg:    "failed to store agreement letter",
+		},
+		{
+			name:   "Update Error",
+			loanID: "loan-123",
+			mockSetup: func(repo *mock.MockLoanRepository, documents *mock.MockDocumentStore, agreements *mock.MockAgreementRenderer) {
+				loan := &domain.Loan{
+					ID:    "loan-123",
+					State: domain.StateInvested,
+				}
+				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
+				agreements.EXPECT().RenderAgreement(loan).Return(letter, nil)
+				documents.EXPECT().Save(gomock.Any(), letter).Return(stored, nil)
+				repo.EXPECT().Update(gomock.Any()).Return(errors.New("update error"))
+				// The committed events cannot be caught up with either
+				repo.EXPECT().FindByID("loan-123").Return(nil, errors.New("find error"))
+			},
+			expectError: true,
+			errorMsg:    "update error",
+		},
+	}
+
+	// Run test cases
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mock.NewMockLoanRepository(ctrl)
+			mockDocuments := mock.NewMockDocumentStore(ctrl)
+			mockAgreements := mock.NewMockAgreementRenderer(ctrl)
+			mockAuditLog := newTestAuditLog(ctrl)
+			logger := logrus.New()
+
+			// Configure mocks
+			tc.mockSetup(mockRepo, mockDocuments, mockAgreements)
+
+			// Create service
+			service := NewLoanService(mockRepo, nil, nil, newTestEventStore(ctrl), mockAuditLog, mockDocuments, mockAgreements, nil, nil, nil, logger)
+
+			// Execute
+			document, _, err := service.GenerateAgreementLetter(tc.loanID, nil)
+
+			// Assert
+			if tc.expectError {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tc.errorMsg)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, stored, document)
+			}
+		})
+	}
+}
+
+func TestUploadDocument(t *testing.T) {
+	pdf := []byte("%PDF-1.4 signed agreement")
+	png := []byte("\x89PNG\r\n\x1a\n visit photo")
+
+	// Define test cases
+	testCases := []struct {
+		name        string
+		loanID      string
+		upload      domain.DocumentUpload
+		opts        []Option
+		mockSetup   func(*mock.MockLoanRepository, *mock.MockDocumentStore)
+		expectError bool
+		errorIs     error
+		errorMsg    string
+	}{
+		{
+			name:   "Success",
+			loanID: "loan-123",
+			upload: domain.DocumentUpload{Kind: domain.DocumentSignedAgreement, Name: "signed.pdf", Content: pdf},
+			mockSetup: func(repo *mock.MockLoanRepository, documents *mock.MockDocumentStore) {
+				repo.EXPECT().FindByID("loan-123").Return(&domain.Loan{ID: "loan-123", State: domain.StateInvested}, nil)
+				documents.EXPECT().Save(domain.Document{
+					LoanID:      "loan-123",
+					Kind:        domain.DocumentSignedAgreement,
+					Name:        "signed.pdf",
+					ContentType: domain.ContentTypePDF,
+				}, pdf).Return(&domain.Document{ID: "document-1", Checksum: domain.Checksum(pdf)}, nil)
+			},
+		},
+		{
+			name:   "Content Type Is Sniffed",
+			loanID: "loan-123",
+			upload: domain.DocumentUpload{Kind: domain.DocumentProof, Name: "visit.pdf", Content: png},
+			mockSetup: func(repo *mock.MockLoanRepository, documents *mock.MockDocumentStore) {
+				repo.EXPECT().FindByID("loan-123").Return(&domain.Loan{ID: "loan-123", State: domain.StateProposed}, nil)
+				documents.EXPECT().Save(gomock.Any(), png).DoAndReturn(func(document domain.Document, _ []byte) (*domain.Document, error) {
+					assert.Equal(t, domain.ContentTypePNG, document.ContentType)
+					return &document, nil
+				})
+			},
+		},
+		{
+			name:        "Empty Loan ID",
+			loanID:      "",
+			upload:      domain.DocumentUpload{Kind: domain.DocumentProof, Content: png},
+			mockSetup:   func(repo *mock.MockLoanRepository, documents *mock.MockDocumentStore) {},
+			expectError: true,
+			errorMsg:    "loan ID cannot be empty",
+		},
+		{
+			name:        "Empty Document",
+			loanID:      "loan-123",
+			upload:      domain.DocumentUpload{Kind: domain.DocumentProof},
+			mockSetup:   func(repo *mock.MockLoanRepository, documents *mock.MockDocumentStore) {},
+			expectError: true,
+			errorMsg:    "document cannot be empty",
+		},
+		{
+			name:        "Too Large",
+			loanID:      "loan-123",
+			upload:      domain.DocumentUpload{Kind: domain.DocumentSignedAgreement, Content: pdf},
+			opts:        []Option{WithMaxDocumentSize(10)},
+			mockSetup:   func(repo *mock.MockLoanRepository, documents *mock.MockDocumentStore) {},
+			expectError: true,
+			errorIs:     domain.ErrDocumentTooLarge,
+		},
+		{
+			name:        "Unsupported Type",
+			loanID:      "loan-123",
+			upload:      domain.DocumentUpload{Kind: domain.DocumentSignedAgreement, Content: png},
+			mockSetup:   func(repo *mock.MockLoanRepository, documents *mock.MockDocumentStore) {},
+			expectError: true,
+			errorIs:     domain.ErrUnsupportedDocumentType,
+		},
+		{
+			name:        "Generated Kind",
+			loanID:      "loan-123",
+			upload:      domain.DocumentUpload{Kind: domain.DocumentAgreement, Content: pdf},
+			mockSetup:   func(repo *mock.MockLoanRepository, documents *mock.MockDocumentStore) {},
+			expectError: true,
+			errorIs:     domain.ErrInvalidDocumentKind,
+		},
+		{
+			name:   "Loan Not Found",
+			loanID: "loan-123",
+			upload: domain.DocumentUpload{Kind: domain.DocumentProof, Content: png},
+			mockSetup: func(repo *mock.MockLoanRepository, documents *mock.MockDocumentStore) {
+				repo.EXPECT().FindByID("loan-123").Return(nil, domain.ErrLoanNotFound)
+			},
+			expectError: true,
+			errorIs:     domain.ErrLoanNotFound,
+		},
+		{
+			name:   "Store Error",
+			loanID: "loan-123",
+			upload: domain.DocumentUpload{Kind: domain.DocumentProof, Content: png},
+			mockSetup: func(repo *mock.MockLoanRepository, documents *mock.MockDocumentStore) {
+				repo.EXPECT().FindByID("loan-123").Return(&domain.Loan{ID: "loan-123", State: domain.StateProposed}, nil)
+				documents.EXPECT().Save(gomock.Any(), png).Return(nil, errors.New("disk full"))
+			},
+			expectError: true,
+			errorMsg:    "failed to store document",
+		},
+	}
+
+	// Run test cases
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mock.NewMockLoanRepository(ctrl)
+			mockDocuments := mock.NewMockDocumentStore(ctrl)
+			tc.mockSetup(mockRepo, mockDocuments)
+
+			// Create service
+			service := NewLoanService(mockRepo, nil, nil, newTestEventStore(ctrl), newTestAuditLog(ctrl), mockDocuments, nil, nil, nil, nil, logrus.New(), tc.opts...)
+
+			// Execute
+			document, err := service.UploadDocument(tc.loanID, tc.upload)
+
+			// Assert
+			if tc.expectError {
+				assert.Error(t, err)
+				if tc.errorIs != nil {
+					assert.ErrorIs(t, err, tc.errorIs)
+				}
+				assert.Contains(t, err.Error(), tc.errorMsg)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, document)
+		})
+	}
+}
+
+func TestGetLoan(t *testing.T) {
+	// Define test cases
+	testCases := []struct {
+		name        string
+		loanID      string
+		mockSetup   func(*mock.MockLoanRepository)
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:   "Success",
+			loanID: "loan-123",
+			mockSetup: func(repo *mock.MockLoanRepository) {
+				loan := &domain.Loan{
+					ID: "loan-123",
+				}
+				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
+			},
+			expectError: false,
+		},
+		{
+			name:        "Empty Loan ID",
+			loanID:      "",
+			mockSetup:   func(repo *mock.MockLoanRepository) {},
+			expectError: true,
+			errorMsg:    "loan ID cannot be empty",
+		},
+		{
+			name:   "Loan Not Found",
+			loanID: "loan-123",
+			mockSetup: func(repo *mock.MockLoanRepository) {
+				repo.EXPECT().FindByID("loan-123").Return(nil, errors.New("loan not found"))
+			},
+			expectError: true,
+			errorMsg:    "loan not found",
+		},
+	}
+
+	// Run test cases
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mock.NewMockLoanRepository(ctrl)
+			mockAuditLog := newTestAuditLog(ctrl)
+			logger := logrus.New()
+
+			// Configure mocks
+			tc.mockSetup(mockRepo)
+
+			// Create service
+			service := NewLoanService(mockRepo, nil, nil, newTestEventStore(ctrl), mockAuditLog, nil, nil, nil, nil, nil, logger)
+
+			// Execute
+			loan, err := service.GetLoan(tc.loanID)
+
+			// Assert
+			if tc.expectError {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tc.errorMsg)
+				assert.Nil(t, loan)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, loan)
+				assert.Equal(t, tc.loanID, loan.ID)
+			}
+		})
+	}
+}
+
+func TestGetSchedule(t *testing.T) {
+	// Define test cases
+	testCases := []struct {
+		name          string
+		loanID        string
+		mockSetup     func(*mock.MockLoanRepository)
+		expectedCount int
+		expectedErr   error
+		errorMsg      string
+	}{
+		{
+			name:   "Success",
+			loanID: "loan-123",
+			mockSetup: func(repo *mock.MockLoanRepository) {
+				loan := &domain.Loan{
+					ID:    "loan-123",
+					State: domain.StateDisbursed,
+					Schedule: []domain.Installment{
+						{Number: 1, Total: money.MustNew("500", money.DefaultCurrency)},
+						{Number: 2, Total: money.MustNew("500", money.DefaultCurrency)},
+					},
+				}
+				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
+			},
+			expectedCount: 2,
+		},
+		{
+			name:   "Loan Not Disbursed",
+			loanID: "loan-123",
+			mockSetup: func(repo *mock.MockLoanRepository) {
+				loan := &domain.Loan{
+					ID:    "loan-123",
+					State: domain.StateInvested,
+				}
+				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
+			},
+			expectedErr: domain.ErrScheduleNotFound,
+			errorMsg:    "loan loan-123 is INVESTED",
+		},
+		{
+			name:   "Loan Not Found",
+			loanID: "loan-123",
+			mockSetup: func(repo *mock.MockLoanRepository) {
+				repo.EXPECT().FindByID("loan-123").Return(nil, domain.ErrLoanNotFound)
+			},
+			expectedErr: domain.ErrLoanNotFound,
+			errorMsg:    "failed to find loan",
+		},
+	}
+
+	// Run test cases
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mock.NewMockLoanRepository(ctrl)
+			mockAuditLog := newTestAuditLog(ctrl)
+			logger := logrus.New()
+
+			// Configure mocks
+			tc.mockSetup(mockRepo)
+
+			// Create service
+			service := NewLoanService(mockRepo, nil, nil, newTestEventStore(ctrl), mockAuditLog, nil, nil, nil, nil, nil, logger)
+
+			// Execute
+			schedule, err := service.GetSchedule(tc.loanID)
+
+			// Assert
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Contains(t, err.Error(), tc.errorMsg)
+				assert.Nil(t, schedule)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, schedule, tc.expectedCount)
+			}
+		})
+	}
+}
+
+func TestGetTransitions(t *testing.T) {
+	// Define test cases
+	testCases := []struct {
+		name        string
+		loanID      string
+		mockSetup   func(*mock.MockLoanRepository)
+		expected    []domain.NextTransition
+		expectedErr error
+	}{
+		{
+			name:   "Approved Loan",
+			loanID: "loan-123",
+			mockSetup: func(repo *mock.MockLoanRepository) {
+				loan := &domain.Loan{
+					ID:              "loan-123",
+					State:           domain.StateApproved,
+					PrincipalAmount: money.MustNew("1000", money.DefaultCurrency),
+					AgreementLetter: "http://example.com/agreement",
+				}
+				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
+			},
+			expected: []domain.NextTransition{
+				{Action: domain.ActionCancel, To: []domain.LoanState{domain.StateCancelled}},
+				{Action: domain.ActionInvest, To: []domain.LoanState{domain.StateInvested, domain.StateApproved}},
+			},
+		},
+		{
+			name:   "Closed Loan",
+			loanID: "loan-123",
+			mockSetup: func(repo *mock.MockLoanRepository) {
+				loan := &domain.Loan{
+					ID:    "loan-123",
+					State: domain.StateRejected,
+				}
+				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
+			},
+		},
+		{
+			name:   "Loan Not Found",
+			loanID: "loan-123",
+			mockSetup: func(repo *mock.MockLoanRepository) {
+				repo.EXPECT().FindByID("loan-123").Return(nil, domain.ErrLoanNotFound)
+			},
+			expectedErr: domain.ErrLoanNotFound,
+		},
+	}
+
+	// Run test cases
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mock.NewMockLoanRepository(ctrl)
+			mockAuditLog := newTestAuditLog(ctrl)
+			logger := logrus.New()
+
+			// Configure mocks
+			tc.mockSetup(mockRepo)
+
+			// Create service
+			service := NewLoanService(mockRepo, nil, nil, newTestEventStore(ctrl), mockAuditLog, nil, nil, nil, nil, nil, logger)
+
+			// Execute
+			transitions, err := service.GetTransitions(tc.loanID)
+
+			// Assert
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, transitions)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, transitions)
+			}
+		})
+	}
+}
+
+func TestGetHistory(t *testing.T) {
+	// Define test cases
+	testCases := []struct {
+		name          string
+		loanID        string
+		mockSetup     func(*mock.MockLoanRepository, *mock.MockAuditLog)
+		expectedCount int
+		expectedErr   error
+		errorMsg      string
+	}{
+		{
+			name:   "Success",
+			loanID: "loan-123",
+			mockSetup: func(repo *mock.MockLoanRepository, auditLog *mock.MockAuditLog) {
+				repo.EXPECT().FindByID("loan-123").Return(&domain.Loan{ID: "loan-123", State: domain.StateApproved}, nil)
+				auditLog.EXPECT().FindByLoanID("loan-123").Return([]domain.AuditEntry{
+					{LoanID: "loan-123", Action: domain.ActionCreate, ToState: domain.StateProposed},
+					{LoanID: "loan-123", Action: domain.ActionApprove, FromState: domain.StateProposed, ToState: domain.StateApproved},
+				}, nil)
+			},
+			expectedCount: 2,
+		},
+		{
+			name:   "Loan Not Found",
+			loanID: "loan-123",
+			mockSetup: func(repo *mock.MockLoanRepository, auditLog *mock.MockAuditLog) {
+				repo.EXPECT().FindByID("loan-123").Return(nil, domain.ErrLoanNotFound)
+			},
+			expectedErr: domain.ErrLoanNotFound,
+			errorMsg:    "failed to find loan",
+		},
+		{
+			name:   "Audit Log Error",
+			loanID: "loan-123",
+			mockSetup: func(repo *mock.MockLoanRepository, auditLog *mock.MockAuditLog) {
+				repo.EXPECT().FindByID("loan-123").Return(&domain.Loan{ID: "loan-123"}, nil)
+				auditLog.EXPECT().FindByLoanID("loan-123").Return(nil, errors.New("database error"))
+			},
+			errorMsg: "database error",
+		},
+	}
+
+	// Run test cases
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mock.NewMockLoanRepository(ctrl)
+			mockAuditLog := mock.NewMockAuditLog(ctrl)
+			logger := logrus.New()
+
+			// Configure mocks
+			tc.mockSetup(mockRepo, mockAuditLog)
+
+			// Create service
+			service := NewLoanService(mockRepo, nil, nil, newTestEventStore(ctrl), mockAuditLog, nil, nil, nil, nil, nil, logger)
+
+			// Execute
+			history, err := service.GetHistory(tc.loanID)
+
+			// Assert
+			if tc.errorMsg != "" {
+				assert.Error(t, err)
+				if tc.expectedErr != nil {
+					assert.ErrorIs(t, err, tc.expectedErr)
+				}
+				assert.Contains(t, err.Error(), tc.errorMsg)
+				assert.Nil(t, history)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, history, tc.expectedCount)
+			}
+		})
+	}
+}
+
+func TestGetAgreements(t *testing.T) {
+	generatedAt := time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+	event := func(t *testing.T, version int64, eventType domain.EventType, data any) domain.Event {
+		e, err := domain.NewEvent("loan-123", version, eventType, data, generatedAt)
+		if err != nil {
+			t.Fatalf("failed to build event: %v", err)
+		}
+		return e
+	}
+
+	// Define test cases
+	testCases := []struct {
+		name        string
+		loanID      string
+		mockSetup   func(*testing.T, *mock.MockLoanRepository, *mock.MockEventStore)
+		expected    []domain.AgreementVersion
+		expectedErr error
+		errorMsg    string
+	}{
+		{
+			name:   "Success",
+			loanID: "loan-123",
+			mockSetup: func(t *testing.T, repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				repo.EXPECT().FindByID("loan-123").Return(&domain.Loan{ID: "loan-123", State: domain.StateInvested}, nil)
+				store.EXPECT().Load("loan-123").Return([]domain.Event{
+					event(t, 1, domain.EventLoanProposed, domain.LoanProposedData{BorrowerID: "borrower-123"}),
+					event(t, 2, domain.EventLoanApproved, domain.LoanApprovedData{}),
+					event(t, 2, domain.EventAgreementGenerated, domain.AgreementGeneratedData{LetterURL: "http://example.com/documents/agreement-1", DocumentID: "agreement-1"}),
+					event(t, 3, domain.EventAgreementGenerated, domain.AgreementGeneratedData{LetterURL: "http://example.com/documents/agreement-2", DocumentID: "agreement-2"}),
+				}, nil)
+			},
+			expected: []domain.AgreementVersion{
+				{Version: 1, DocumentID: "agreement-1", URL: "http://example.com/documents/agreement-1", GeneratedAt: generatedAt},
+				{Version: 2, DocumentID: "agreement-2", URL: "http://example.com/documents/agreement-2", GeneratedAt: generatedAt, Current: true},
+			},
+		},
+		{
+			name:   "Loan Not Found",
+			loanID: "loan-123",
+			mockSetup: func(t *testing.T, repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				repo.EXPECT().FindByID("loan-123").Return(nil, domain.ErrLoanNotFound)
+			},
+			expectedErr: domain.ErrLoanNotFound,
+			errorMsg:    "failed to find loan",
+		},
+		{
+			name:   "Event Store Error",
+			loanID: "loan-123",
+			mockSetup: func(t *testing.T, repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				repo.EXPECT().FindByID("loan-123").Return(&domain.Loan{ID: "loan-123"}, nil)
+				store.EXPECT().Load("loan-123").Return(nil, errors.New("database error"))
+			},
+			errorMsg: "failed to load loan events: database error",
+		},
+	}
+
+	// Run test cases
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mock.NewMockLoanRepository(ctrl)
+			mockEventStore := mock.NewMockEventStore(ctrl)
+
 			// Configure mocks
-			tc.mockSetup(mockRepo)
+			tc.mockSetup(t, mockRepo, mockEventStore)
 
 			// Create service
-			service := NewLoanService(mockRepo, mockEmailSender, logger)
+			service := NewLoanService(mockRepo, nil, nil, mockEventStore, newTestAuditLog(ctrl), nil, nil, nil, nil, nil, logrus.New())
 
 			// Execute
-			err := service.ApproveLoan(tc.loanID, tc.validatorID, tc.proofURL)
+			versions, err := service.GetAgreements(tc.loanID)
 
 			// Assert
-			if tc.expectError {
+			if tc.errorMsg != "" {
 				assert.Error(t, err)
+				if tc.expectedErr != nil {
+					assert.ErrorIs(t, err, tc.expectedErr)
+				}
 				assert.Contains(t, err.Error(), tc.errorMsg)
+				assert.Nil(t, versions)
 			} else {
 				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, versions)
 			}
 		})
 	}
 }
 
-func TestAddInvestment(t *testing.T) {
+func TestAuditTrail(t *testing.T) {
+	t.Run("Records the actor, states and payload digest of a mutation", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mock.NewMockLoanRepository(ctrl)
+		mockAuditLog := mock.NewMockAuditLog(ctrl)
+		mockRepo.EXPECT().FindByID("loan-123").Return(&domain.Loan{ID: "loan-123", State: domain.StateProposed, CreditScore: newTestCreditScore(domain.GradeB)}, nil)
+		mockRepo.EXPECT().Update(gomock.Any()).Return(nil)
+
+		var entries []domain.AuditEntry
+		mockAuditLog.EXPECT().Append(gomock.Any()).DoAndReturn(func(e domain.AuditEntry) error {
+			entries = append(entries, e)
+			return nil
+		}).Times(2)
+
+		service := NewLoanService(mockRepo, nil, nil, newTestEventStore(ctrl), mockAuditLog, newTestDocumentStore(ctrl), newTestAgreements(ctrl), nil, nil, nil, logrus.New())
+		_, err := service.ApproveLoan("loan-123", "validator-123", "proof-1", "", nil)
+		assert.NoError(t, err)
+
+		digest, err := domain.PayloadDigest(map[string]any{
+			"validator_id":           "validator-123",
+			"proof_document_id":      "proof-1",
+			"proof_url":              "http://example.com/documents/proof-1",
+			"grade":                  domain.GradeB,
+			"override_justification": "",
+		})
+		assert.NoError(t, err)
+		if !assert.Len(t, entries, 2) {
+			return
+		}
+		entry := entries[0]
+		assert.NotEmpty(t, entry.ID)
+		assert.Equal(t, "loan-123", entry.LoanID)
+		assert.Equal(t, "validator-123", entry.Actor)
+		assert.Equal(t, domain.ActionApprove, entry.Action)
+		assert.Equal(t, domain.StateProposed, entry.FromState)
+		assert.Equal(t, domain.StateApproved, entry.ToState)
+		assert.Equal(t, digest, entry.PayloadDigest)
+		assert.False(t, entry.Timestamp.IsZero())
+
+		// The agreement letter generated with the approval is recorded as the system's
+		digest, err = domain.PayloadDigest(map[string]any{
+			"document_id": "agreement-1",
+			"letter_url":  "http://example.com/documents/agreement-1",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, domain.SystemActor, entries[1].Actor)
+		assert.Equal(t, domain.ActionAttachAgreement, entries[1].Action)
+		assert.Equal(t, domain.StateApproved, entries[1].FromState)
+		assert.Equal(t, domain.StateApproved, entries[1].ToState)
+		assert.Equal(t, digest, entries[1].PayloadDigest)
+	})
+
+	t.Run("Failed mutations are not recorded", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mock.NewMockLoanRepository(ctrl)
+		mockRepo.EXPECT().FindByID("loan-123").Return(&domain.Loan{ID: "loan-123", State: domain.StateApproved}, nil)
+
+		// The strict mock fails the test on any Append
+		service := NewLoanService(mockRepo, nil, nil, newTestEventStore(ctrl), mock.NewMockAuditLog(ctrl), newTestDocumentStore(ctrl), nil, nil, nil, nil, logrus.New())
+		_, err := service.ApproveLoan("loan-123", "validator-123", "proof-1", "", nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("A failing audit log does not fail the stored mutation", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mock.NewMockLoanRepository(ctrl)
+		mockAuditLog := mock.NewMockAuditLog(ctrl)
+		mockRepo.EXPECT().FindByID("loan-123").Return(&domain.Loan{ID: "loan-123", State: domain.StateProposed}, nil)
+		mockRepo.EXPECT().Update(gomock.Any()).Return(nil)
+		mockAuditLog.EXPECT().Append(gomock.Any()).Return(errors.New("disk full"))
+
+		service := NewLoanService(mockRepo, nil, nil, newTestEventStore(ctrl), mockAuditLog, nil, nil, nil, nil, nil, logrus.New())
+		_, err := service.RejectLoan("loan-123", "validator-123", domain.ReasonCreditRisk, nil)
+		assert.NoError(t, err)
+	})
+}
+
+// disbursedLoan returns a 1,200,000 IDR loan repaid FLAT over 3 months at 12% a year:
+// 400,000 principal and 12,000 interest a month
+func disbursedLoan(t *testing.T, state domain.LoanState, disbursedAt time.Time) *domain.Loan {
+	t.Helper()
+	loan := &domain.Loan{
+		ID:              "loan-123",
+		State:           state,
+		PrincipalAmount: money.MustNew("1200000", money.DefaultCurrency),
+		Rate:            money.MustParse("12"),
+		Terms:           domain.RepaymentTerms{TenorMonths: 3, Scheme: domain.SchemeFlat},
+		DisbursedInfo:   &domain.Disbursement{Date: disbursedAt},
+	}
+	schedule, err := domain.GenerateSchedule(loan.PrincipalAmount, loan.Rate, loan.Terms, disbursedAt)
+	if err != nil {
+		t.Fatalf("failed to generate schedule: %v", err)
+	}
+	loan.Schedule = schedule
+	return loan
+}
+
+func TestRecordRepayment(t *testing.T) {
 	// Define test cases
 	testCases := []struct {
-		name        string
-		loanID      string
-		investorID  string
-		email       string
-		amount      float64
-		mockSetup   func(*mock.MockLoanRepository, *mock.MockEmailSender)
-		expectError bool
-		errorMsg    string
+		name          string
+		loanID        string
+		amount        money.Money
+		mockSetup     func(*testing.T, *mock.MockLoanRepository, *mock.MockEventStore)
+		expectedState domain.LoanState
+		expectError   bool
+		errorMsg      string
 	}{
 		{
-			name:       "Success - Partial Investment",
-			loanID:     "loan-123",
-			investorID: "investor-123",
-			email:      "investor@example.com",
-			amount:     500.0,
-			mockSetup: func(repo *mock.MockLoanRepository, emailSender *mock.MockEmailSender) {
-				loan := &domain.Loan{
-					ID:              "loan-123",
-					State:           domain.StateApproved,
-					PrincipalAmount: 1000.0,
-				}
-				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
-				repo.EXPECT().Update(gomock.Any()).Return(nil)
+			name:   "Success - Partial Repayment",
+			loanID: "loan-123",
+			amount: money.MustNew("412000", money.DefaultCurrency),
+			mockSetup: func(t *testing.T, repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				repo.EXPECT().FindByID("loan-123").Return(disbursedLoan(t, domain.StateDisbursed, time.Now()), nil)
+				repo.EXPECT().Update(gomock.Any()).DoAndReturn(func(loan *domain.Loan) error {
+					assert.Equal(t, domain.StateDisbursed, loan.State)
+					assert.True(t, loan.Schedule[0].IsSettled())
+					assert.Len(t, loan.Repayments, 1)
+					return nil
+				})
 			},
-			expectError: false,
+			expectedState: domain.StateDisbursed,
 		},
 		{
-			name:       "Success - Full Investment",
-			loanID:     "loan-123",
-			investorID: "investor-123",
-			email:      "investor@example.com",
-			amount:     1000.0,
-			mockSetup: func(repo *mock.MockLoanRepository, emailSender *mock.MockEmailSender) {
-				loan := &domain.Loan{
-					ID:              "loan-123",
-					State:           domain.StateApproved,
-					PrincipalAmount: 1000.0,
-					AgreementLetter: "http://example.com/agreement",
-				}
-				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
-				emailSender.EXPECT().SendAgreementEmail("investor@example.com", "loan-123", "http://example.com/agreement").Return(nil)
-				repo.EXPECT().Update(gomock.Any()).Return(nil)
+			name:   "Success - Fully Repaid",
+			loanID: "loan-123",
+			amount: money.MustNew("1236000", money.DefaultCurrency),
+			mockSetup: func(t *testing.T, repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				repo.EXPECT().FindByID("loan-123").Return(disbursedLoan(t, domain.StateDisbursed, time.Now()), nil)
+				repo.EXPECT().Update(gomock.Any()).DoAndReturn(func(loan *domain.Loan) error {
+					assert.Equal(t, domain.StateRepaid, loan.State)
+					return nil
+				})
 			},
-			expectError: false,
+			expectedState: domain.StateRepaid,
 		},
 		{
-			name:       "Loan Not Found",
-			loanID:     "loan-123",
-			investorID: "investor-123",
-			email:      "investor@example.com",
-			amount:     500.0,
-			mockSetup: func(repo *mock.MockLoanRepository, emailSender *mock.MockEmailSender) {
-				repo.EXPECT().FindByID("loan-123").Return(nil, errors.New("loan not found"))
+			name:   "Success - Defaulted Loan Repaid",
+			loanID: "loan-123",
+			amount: money.MustNew("1236000", money.DefaultCurrency),
+			mockSetup: func(t *testing.T, repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				repo.EXPECT().FindByID("loan-123").Return(disbursedLoan(t, domain.StateDefaulted, time.Now().AddDate(-1, 0, 0)), nil)
+				repo.EXPECT().Update(gomock.Any()).DoAndReturn(func(loan *domain.Loan) error {
+					assert.Equal(t, domain.StateRepaid, loan.State)
+					return nil
+				})
 			},
+			expectedState: domain.StateRepaid,
+		},
+		{
+			name:        "Invalid Amount Precision",
+			loanID:      "loan-123",
+			amount:      money.MustNew("100.5", money.DefaultCurrency),
+			mockSetup:   func(t *testing.T, repo *mock.MockLoanRepository, store *mock.MockEventStore) {},
 			expectError: true,
-			errorMsg:    "failed to find loan",
+			errorMsg:    "invalid repayment amount",
 		},
 		{
-			name:       "Invalid State",
-			loanID:     "loan-123",
-			investorID: "investor-123",
-			email:      "investor@example.com",
-			amount:     500.0,
-			mockSetup: func(repo *mock.MockLoanRepository, emailSender *mock.MockEmailSender) {
-				loan := &domain.Loan{
-					ID:    "loan-123",
-					State: domain.StateProposed,
-				}
-				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
+			name:   "Loan Not Found",
+			loanID: "loan-123",
+			amount: money.MustNew("1000", money.DefaultCurrency),
+			mockSetup: func(t *testing.T, repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				repo.EXPECT().FindByID("loan-123").Return(nil, domain.ErrLoanNotFound)
 			},
 			expectError: true,
-			errorMsg:    "loan must be in APPROVED or INVESTED state",
+			errorMsg:    "failed to find loan",
 		},
 		{
-			name:       "Investment Exceeds Principal",
-			loanID:     "loan-123",
-			investorID: "investor-123",
-			email:      "investor@example.com",
-			amount:     1500.0,
-			mockSetup: func(repo *mock.MockLoanRepository, emailSender *mock.MockEmailSender) {
-				loan := &domain.Loan{
-					ID:              "loan-123",
-					State:           domain.StateApproved,
-					PrincipalAmount: 1000.0,
-				}
-				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
+			name:   "Invalid State",
+			loanID: "loan-123",
+			amount: money.MustNew("1000", money.DefaultCurrency),
+			mockSetup: func(t *testing.T, repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				repo.EXPECT().FindByID("loan-123").Return(&domain.Loan{ID: "loan-123", State: domain.StateInvested}, nil)
 			},
 			expectError: true,
-			errorMsg:    "investment exceeds principal",
+			errorMsg:    "loan must be in DISBURSED or DEFAULTED state",
 		},
 		{
-			name:       "Email Sending Error",
-			loanID:     "loan-123",
-			investorID: "investor-123",
-			email:      "investor@example.com",
-			amount:     1000.0,
-			mockSetup: func(repo *mock.MockLoanRepository, emailSender *mock.MockEmailSender) {
-				loan := &domain.Loan{
-					ID:              "loan-123",
-					State:           domain.StateApproved,
-					PrincipalAmount: 1000.0,
-					AgreementLetter: "http://example.com/agreement",
-				}
-				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
-				emailSender.EXPECT().SendAgreementEmail("investor@example.com", "loan-123", "http://example.com/agreement").Return(errors.New("email error"))
+			name:   "Overpayment",
+			loanID: "loan-123",
+			amount: money.MustNew("1236001", money.DefaultCurrency),
+			mockSetup: func(t *testing.T, repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				repo.EXPECT().FindByID("loan-123").Return(disbursedLoan(t, domain.StateDisbursed, time.Now()), nil)
 			},
 			expectError: true,
-			errorMsg:    "failed to send agreement to investor",
+			errorMsg:    "exceeds the outstanding 1236000 IDR",
 		},
 		{
-			name:       "Update Error",
-			loanID:     "loan-123",
-			investorID: "investor-123",
-			email:      "investor@example.com",
-			amount:     500.0,
-			mockSetup: func(repo *mock.MockLoanRepository, emailSender *mock.MockEmailSender) {
-				loan := &domain.Loan{
-					ID:              "loan-123",
-					State:           domain.StateApproved,
-					PrincipalAmount: 1000.0,
-				}
-				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
-				repo.EXPECT().Update(gomock.Any()).Return(errors.New("update error"))
+			name:   "Currency Mismatch",
+			loanID: "loan-123",
+			amount: money.MustNew("1000", "USD"),
+			mockSetup: func(t *testing.T, repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				repo.EXPECT().FindByID("loan-123").Return(disbursedLoan(t, domain.StateDisbursed, time.Now()), nil)
 			},
 			expectError: true,
-			errorMsg:    "update error",
+			errorMsg:    "currency mismatch",
+		},
+		{
+			name:   "Retries On Version Conflict",
+			loanID: "loan-123",
+			amount: money.MustNew("1000", money.DefaultCurrency),
+			mockSetup: func(t *testing.T, repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				repo.EXPECT().FindByID("loan-123").DoAndReturn(func(string) (*domain.Loan, error) {
+					loan := disbursedLoan(t, domain.StateDisbursed, time.Now())
+					loan.Version = 1
+					return loan, nil
+				}).Times(3)
+				expectStaleCommit(store)
+				repo.EXPECT().Update(gomock.Any()).Return(nil)
+			},
+			expectedState: domain.StateDisbursed,
 		},
 	}
 
@@ -328,123 +2690,85 @@ func TestAddInvestment(t *testing.T) {
 			defer ctrl.Finish()
 
 			mockRepo := mock.NewMockLoanRepository(ctrl)
-			mockEmailSender := mock.NewMockEmailSender(ctrl)
+			mockAuditLog := newTestAuditLog(ctrl)
 			logger := logrus.New()
 
 			// Configure mocks
-			tc.mockSetup(mockRepo, mockEmailSender)
+			mockEventStore := mock.NewMockEventStore(ctrl)
+			tc.mockSetup(t, mockRepo, mockEventStore)
+			acceptCommits(mockEventStore)
 
 			// Create service
-			service := NewLoanService(mockRepo, mockEmailSender, logger)
+			service := NewLoanService(mockRepo, nil, nil, mockEventStore, mockAuditLog, nil, nil, nil, nil, nil, logger)
 
 			// Execute
-			err := service.AddInvestment(tc.loanID, tc.investorID, tc.email, tc.amount)
+			repayment, _, err := service.RecordRepayment(tc.loanID, tc.amount, nil)
 
 			// Assert
 			if tc.expectError {
 				assert.Error(t, err)
 				assert.Contains(t, err.Error(), tc.errorMsg)
+				assert.Nil(t, repayment)
 			} else {
 				assert.NoError(t, err)
+				if assert.NotNil(t, repayment) {
+					assert.Equal(t, tc.amount, repayment.Amount)
+					assert.NotEmpty(t, repayment.ID)
+				}
 			}
 		})
 	}
 }
 
-func TestDisburseLoan(t *testing.T) {
+func TestMarkDefaultedLoans(t *testing.T) {
 	// Define test cases
 	testCases := []struct {
-		name            string
-		loanID          string
-		fieldOfficerID  string
-		signedAgreement string
-		mockSetup       func(*mock.MockLoanRepository)
-		expectError     bool
-		errorMsg        string
+		name              string
+		opts              []Option
+		mockSetup         func(*testing.T, *mock.MockLoanRepository)
+		expectedDefaulted []string
+		expectError       bool
 	}{
 		{
-			name:            "Success",
-			loanID:          "loan-123",
-			fieldOfficerID:  "officer-123",
-			signedAgreement: "http://example.com/signed",
-			mockSetup: func(repo *mock.MockLoanRepository) {
-				loan := &domain.Loan{
-					ID:    "loan-123",
-					State: domain.StateInvested,
-				}
-				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
-				repo.EXPECT().Update(gomock.Any()).Return(nil)
+			name: "Defaults Loans Past The Threshold",
+			mockSetup: func(t *testing.T, repo *mock.MockLoanRepository) {
+				// The first installment of a loan disbursed 5 months ago is about 120 days overdue
+				overdue := disbursedLoan(t, domain.StateDisbursed, time.Now().AddDate(0, -5, 0))
+				current := disbursedLoan(t, domain.StateDisbursed, time.Now())
+				current.ID = "loan-456"
+				repo.EXPECT().FindByState(domain.StateDisbursed).Return([]*domain.Loan{overdue, current}, nil)
+				repo.EXPECT().FindByID("loan-123").Return(disbursedLoan(t, domain.StateDisbursed, time.Now().AddDate(0, -5, 0)), nil)
+				repo.EXPECT().Update(gomock.Any()).DoAndReturn(func(loan *domain.Loan) error {
+					assert.Equal(t, "loan-123", loan.ID)
+					assert.Equal(t, domain.StateDefaulted, loan.State)
+					return nil
+				})
 			},
-			expectError: false,
-		},
-		{
-			name:            "Empty Loan ID",
-			loanID:          "",
-			fieldOfficerID:  "officer-123",
-			signedAgreement: "http://example.com/signed",
-			mockSetup:       func(repo *mock.MockLoanRepository) {},
-			expectError:     true,
-			errorMsg:        "loan ID cannot be empty",
-		},
-		{
-			name:            "Empty Field Officer ID",
-			loanID:          "loan-123",
-			fieldOfficerID:  "",
-			signedAgreement: "http://example.com/signed",
-			mockSetup:       func(repo *mock.MockLoanRepository) {},
-			expectError:     true,
-			errorMsg:        "field officer ID cannot be empty",
-		},
-		{
-			name:            "Empty Signed Agreement",
-			loanID:          "loan-123",
-			fieldOfficerID:  "officer-123",
-			signedAgreement: "",
-			mockSetup:       func(repo *mock.MockLoanRepository) {},
-			expectError:     true,
-			errorMsg:        "signed agreement cannot be empty",
+			expectedDefaulted: []string{"loan-123"},
 		},
 		{
-			name:            "Loan Not Found",
-			loanID:          "loan-123",
-			fieldOfficerID:  "officer-123",
-			signedAgreement: "http://example.com/signed",
-			mockSetup: func(repo *mock.MockLoanRepository) {
-				repo.EXPECT().FindByID("loan-123").Return(nil, errors.New("loan not found"))
+			name: "Configurable Threshold",
+			opts: []Option{WithDaysPastDueThreshold(180)},
+			mockSetup: func(t *testing.T, repo *mock.MockLoanRepository) {
+				overdue := disbursedLoan(t, domain.StateDisbursed, time.Now().AddDate(0, -5, 0))
+				repo.EXPECT().FindByState(domain.StateDisbursed).Return([]*domain.Loan{overdue}, nil)
 			},
-			expectError: true,
-			errorMsg:    "failed to find loan",
 		},
 		{
-			name:            "Invalid State",
-			loanID:          "loan-123",
-			fieldOfficerID:  "officer-123",
-			signedAgreement: "http://example.com/signed",
-			mockSetup: func(repo *mock.MockLoanRepository) {
-				loan := &domain.Loan{
-					ID:    "loan-123",
-					State: domain.StateApproved,
-				}
-				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
+			name: "Skips Loans Repaid Since Listing",
+			mockSetup: func(t *testing.T, repo *mock.MockLoanRepository) {
+				overdue := disbursedLoan(t, domain.StateDisbursed, time.Now().AddDate(0, -5, 0))
+				repo.EXPECT().FindByState(domain.StateDisbursed).Return([]*domain.Loan{overdue}, nil)
+				repaid := disbursedLoan(t, domain.StateRepaid, time.Now().AddDate(0, -5, 0))
+				repo.EXPECT().FindByID("loan-123").Return(repaid, nil)
 			},
-			expectError: true,
-			errorMsg:    "loan must be in INVESTED state",
 		},
 		{
-			name:            "Update Error",
-			loanID:          "loan-123",
-			fieldOfficerID:  "officer-123",
-			signedAgreement: "http://example.com/signed",
-			mockSetup: func(repo *mock.MockLoanRepository) {
-				loan := &domain.Loan{
-					ID:    "loan-123",
-					State: domain.StateInvested,
-				}
-				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
-				repo.EXPECT().Update(gomock.Any()).Return(errors.New("update error"))
+			name: "Repository Error",
+			mockSetup: func(t *testing.T, repo *mock.MockLoanRepository) {
+				repo.EXPECT().FindByState(domain.StateDisbursed).Return(nil, errors.New("database error"))
 			},
 			expectError: true,
-			errorMsg:    "update error",
 		},
 	}
 
@@ -456,91 +2780,96 @@ func TestDisburseLoan(t *testing.T) {
 			defer ctrl.Finish()
 
 			mockRepo := mock.NewMockLoanRepository(ctrl)
-			mockEmailSender := mock.NewMockEmailSender(ctrl)
+			mockAuditLog := newTestAuditLog(ctrl)
 			logger := logrus.New()
 
 			// Configure mocks
-			tc.mockSetup(mockRepo)
+			tc.mockSetup(t, mockRepo)
 
 			// Create service
-			service := NewLoanService(mockRepo, mockEmailSender, logger)
+			service := NewLoanService(mockRepo, nil, nil, newTestEventStore(ctrl), mockAuditLog, nil, nil, nil, nil, nil, logger, tc.opts...)
 
 			// Execute
-			err := service.DisburseLoan(tc.loanID, tc.fieldOfficerID, tc.signedAgreement)
+			defaulted, err := service.MarkDefaultedLoans()
 
 			// Assert
 			if tc.expectError {
 				assert.Error(t, err)
-				assert.Contains(t, err.Error(), tc.errorMsg)
-			} else {
-				assert.NoError(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			var ids []string
+			for _, loan := range defaulted {
+				ids = append(ids, loan.ID)
 			}
+			assert.Equal(t, tc.expectedDefaulted, ids)
 		})
 	}
 }
 
-func TestGenerateAgreementLetter(t *testing.T) {
+func TestRemindDueInstallments(t *testing.T) {
 	// Define test cases
 	testCases := []struct {
-		name        string
-		loanID      string
-		letterURL   string
-		mockSetup   func(*mock.MockLoanRepository)
-		expectError bool
-		errorMsg    string
+		name             string
+		opts             []Option
+		mockSetup        func(*testing.T, *mock.MockLoanRepository, *mock.MockEventStore)
+		expectedReminded int
+		expectError      bool
 	}{
 		{
-			name:      "Success",
-			loanID:    "loan-123",
-			letterURL: "http://example.com/letter",
-			mockSetup: func(repo *mock.MockLoanRepository) {
-				loan := &domain.Loan{
-					ID: "loan-123",
-				}
-				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
-				repo.EXPECT().Update(gomock.Any()).Return(nil)
+			name: "Reminds Installments Due Within The Lead Days",
+			mockSetup: func(t *testing.T, repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				// The first installment of a loan disbursed a month ago falls due about now
+				due := disbursedLoan(t, domain.StateDisbursed, time.Now().AddDate(0, -1, 1))
+				due.BorrowerID = "borrower-123"
+				later := disbursedLoan(t, domain.StateDisbursed, time.Now())
+				later.ID = "loan-456"
+				repo.EXPECT().FindByState(domain.StateDisbursed).Return([]*domain.Loan{due, later}, nil)
+				repo.EXPECT().FindByID("loan-123").Return(due, nil)
+				store.EXPECT().Commit("loan-123", gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ string, _ int64, events []domain.Event, messages []domain.OutboxMessage) error {
+						if assert.Len(t, events, 1) {
+							assert.Equal(t, domain.EventInstallmentReminded, events[0].Type)
+						}
+						if assert.Len(t, messages, 1) {
+							assert.Equal(t, domain.NotificationInstallmentDue, messages[0].Kind)
+							assert.Equal(t, domain.Recipient{ID: "borrower-123", Role: domain.RoleBorrower}, messages[0].Recipient)
+							assert.Equal(t, 1, messages[0].Installment)
+							assert.Equal(t, money.MustNew("412000", money.DefaultCurrency), *messages[0].Amount)
+						}
+						return nil
+					})
+				repo.EXPECT().Update(gomock.Any()).DoAndReturn(func(loan *domain.Loan) error {
+					assert.NotNil(t, loan.Schedule[0].RemindedAt)
+					assert.Nil(t, loan.Schedule[1].RemindedAt)
+					return nil
+				})
 			},
-			expectError: false,
-		},
-		{
-			name:        "Empty Loan ID",
-			loanID:      "",
-			letterURL:   "http://example.com/letter",
-			mockSetup:   func(repo *mock.MockLoanRepository) {},
-			expectError: true,
-			errorMsg:    "loan ID cannot be empty",
+			expectedReminded: 1,
 		},
 		{
-			name:        "Empty Letter URL",
-			loanID:      "loan-123",
-			letterURL:   "",
-			mockSetup:   func(repo *mock.MockLoanRepository) {},
-			expectError: true,
-			errorMsg:    "letter URL cannot be empty",
+			name: "Configurable Lead Days",
+			opts: []Option{WithReminderLeadDays(0)},
+			mockSetup: func(t *testing.T, repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				soon := disbursedLoan(t, domain.StateDisbursed, time.Now().AddDate(0, -1, 2))
+				repo.EXPECT().FindByState(domain.StateDisbursed).Return([]*domain.Loan{soon}, nil)
+			},
 		},
 		{
-			name:      "Loan Not Found",
-			loanID:    "loan-123",
-			letterURL: "http://example.com/letter",
-			mockSetup: func(repo *mock.MockLoanRepository) {
-				repo.EXPECT().FindByID("loan-123").Return(nil, errors.New("loan not found"))
+			name: "Skips Installments Already Reminded",
+			mockSetup: func(t *testing.T, repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				reminded := disbursedLoan(t, domain.StateDisbursed, time.Now().AddDate(0, -1, 1))
+				remindedAt := time.Now().AddDate(0, 0, -1)
+				reminded.Schedule[0].RemindedAt = &remindedAt
+				repo.EXPECT().FindByState(domain.StateDisbursed).Return([]*domain.Loan{reminded}, nil)
 			},
-			expectError: true,
-			errorMsg:    "failed to find loan",
 		},
 		{
-			name:      "Update Error",
-			loanID:    "loan-123",
-			letterURL: "http://example.com/letter",
-			mockSetup: func(repo *mock.MockLoanRepository) {
-				loan := &domain.Loan{
-					ID: "loan-123",
-				}
-				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
-				repo.EXPECT().Update(gomock.Any()).Return(errors.New("update error"))
+			name: "Repository Error",
+			mockSetup: func(t *testing.T, repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				repo.EXPECT().FindByState(domain.StateDisbursed).Return(nil, errors.New("database error"))
 			},
 			expectError: true,
-			errorMsg:    "update error",
 		},
 	}
 
@@ -552,64 +2881,84 @@ func TestGenerateAgreementLetter(t *testing.T) {
 			defer ctrl.Finish()
 
 			mockRepo := mock.NewMockLoanRepository(ctrl)
-			mockEmailSender := mock.NewMockEmailSender(ctrl)
-			logger := logrus.New()
+			mockEventStore := mock.NewMockEventStore(ctrl)
 
 			// Configure mocks
-			tc.mockSetup(mockRepo)
+			tc.mockSetup(t, mockRepo, mockEventStore)
 
 			// Create service
-			service := NewLoanService(mockRepo, mockEmailSender, logger)
+			service := NewLoanService(mockRepo, nil, nil, mockEventStore, newTestAuditLog(ctrl), nil, nil, nil, nil, nil, logrus.New(), tc.opts...)
 
 			// Execute
-			err := service.GenerateAgreementLetter(tc.loanID, tc.letterURL)
+			reminded, err := service.RemindDueInstallments()
 
 			// Assert
 			if tc.expectError {
 				assert.Error(t, err)
-				assert.Contains(t, err.Error(), tc.errorMsg)
-			} else {
-				assert.NoError(t, err)
+				return
 			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedReminded, reminded)
 		})
 	}
 }
 
-func TestGetLoan(t *testing.T) {
+func TestChargeLateFees(t *testing.T) {
+	lateFee := WithLateFee(money.MustParse("25000"))
+
 	// Define test cases
 	testCases := []struct {
-		name        string
-		loanID      string
-		mockSetup   func(*mock.MockLoanRepository)
-		expectError bool
-		errorMsg    string
+		name            string
+		opts            []Option
+		mockSetup       func(*testing.T, *mock.MockLoanRepository, *mock.MockEventStore)
+		expectedCharged int
+		expectError     bool
 	}{
 		{
-			name:   "Success",
-			loanID: "loan-123",
-			mockSetup: func(repo *mock.MockLoanRepository) {
-				loan := &domain.Loan{
-					ID: "loan-123",
-				}
-				repo.EXPECT().FindByID("loan-123").Return(loan, nil)
+			name: "Charges Overdue Installments",
+			opts: []Option{lateFee},
+			mockSetup: func(t *testing.T, repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				// The first installment of a loan disbursed a month and two days ago is overdue
+				overdue := disbursedLoan(t, domain.StateDisbursed, time.Now().AddDate(0, -1, -2))
+				current := disbursedLoan(t, domain.StateDisbursed, time.Now())
+				current.ID = "loan-456"
+				repo.EXPECT().FindByState(domain.StateDisbursed).Return([]*domain.Loan{overdue, current}, nil)
+				repo.EXPECT().FindByID("loan-123").Return(overdue, nil)
+				store.EXPECT().Commit("loan-123", gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ string, _ int64, events []domain.Event, _ []domain.OutboxMessage) error {
+						if assert.Len(t, events, 1) {
+							assert.Equal(t, domain.EventLateFeeCharged, events[0].Type)
+						}
+						return nil
+					})
+				repo.EXPECT().Update(gomock.Any()).DoAndReturn(func(loan *domain.Loan) error {
+					assert.Equal(t, money.MustNew("25000", money.DefaultCurrency), loan.Schedule[0].Fee)
+					assert.True(t, loan.Schedule[1].Fee.IsZero())
+					return nil
+				})
 			},
-			expectError: false,
+			expectedCharged: 1,
 		},
 		{
-			name:        "Empty Loan ID",
-			loanID:      "",
-			mockSetup:   func(repo *mock.MockLoanRepository) {},
-			expectError: true,
-			errorMsg:    "loan ID cannot be empty",
+			name: "Skips Installments Already Charged",
+			opts: []Option{lateFee},
+			mockSetup: func(t *testing.T, repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				charged := disbursedLoan(t, domain.StateDisbursed, time.Now().AddDate(0, -1, -2))
+				charged.Schedule[0].Fee = money.MustNew("25000", money.DefaultCurrency)
+				repo.EXPECT().FindByState(domain.StateDisbursed).Return([]*domain.Loan{charged}, nil)
+			},
 		},
 		{
-			name:   "Loan Not Found",
-			loanID: "loan-123",
-			mockSetup: func(repo *mock.MockLoanRepository) {
-				repo.EXPECT().FindByID("loan-123").Return(nil, errors.New("loan not found"))
+			name:      "No Late Fee Configured",
+			mockSetup: func(t *testing.T, repo *mock.MockLoanRepository, store *mock.MockEventStore) {},
+		},
+		{
+			name: "Repository Error",
+			opts: []Option{lateFee},
+			mockSetup: func(t *testing.T, repo *mock.MockLoanRepository, store *mock.MockEventStore) {
+				repo.EXPECT().FindByState(domain.StateDisbursed).Return(nil, errors.New("database error"))
 			},
 			expectError: true,
-			errorMsg:    "loan not found",
 		},
 	}
 
@@ -621,32 +2970,123 @@ func TestGetLoan(t *testing.T) {
 			defer ctrl.Finish()
 
 			mockRepo := mock.NewMockLoanRepository(ctrl)
-			mockEmailSender := mock.NewMockEmailSender(ctrl)
-			logger := logrus.New()
+			mockEventStore := mock.NewMockEventStore(ctrl)
 
 			// Configure mocks
-			tc.mockSetup(mockRepo)
+			tc.mockSetup(t, mockRepo, mockEventStore)
 
 			// Create service
-			service := NewLoanService(mockRepo, mockEmailSender, logger)
+			service := NewLoanService(mockRepo, nil, nil, mockEventStore, newTestAuditLog(ctrl), nil, nil, nil, nil, nil, logrus.New(), tc.opts...)
 
 			// Execute
-			loan, err := service.GetLoan(tc.loanID)
+			charged, err := service.ChargeLateFees()
 
 			// Assert
 			if tc.expectError {
 				assert.Error(t, err)
-				assert.Contains(t, err.Error(), tc.errorMsg)
-				assert.Nil(t, loan)
-			} else {
-				assert.NoError(t, err)
-				assert.NotNil(t, loan)
-				assert.Equal(t, tc.loanID, loan.ID)
+				return
 			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedCharged, charged)
 		})
 	}
 }
 
+func TestLifecycleNotifications(t *testing.T) {
+	investors := []domain.Investor{
+		{ID: "investor-1", Name: "One", Email: "one@example.com", Amount: money.MustNew("900000", money.DefaultCurrency)},
+		{ID: "investor-2", Email: "two@example.com", Locale: "id", Amount: money.MustNew("300000", money.DefaultCurrency)},
+	}
+	borrower := domain.Recipient{ID: "borrower-123", Role: domain.RoleBorrower}
+
+	t.Run("Approval tells the borrower", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mock.NewMockLoanRepository(ctrl)
+		mockEventStore := mock.NewMockEventStore(ctrl)
+		mockRepo.EXPECT().FindByID("loan-123").Return(&domain.Loan{
+			ID:              "loan-123",
+			BorrowerID:      "borrower-123",
+			State:           domain.StateProposed,
+			PrincipalAmount: money.MustNew("1200000", money.DefaultCurrency),
+			CreditScore:     newTestCreditScore(domain.GradeB),
+		}, nil)
+		expectMessages(mockEventStore, func(messages []domain.OutboxMessage) {
+			if assert.Len(t, messages, 1) {
+				assert.Equal(t, domain.NotificationLoanApproved, messages[0].Kind)
+				assert.Equal(t, "loan-123", messages[0].LoanID)
+				assert.Equal(t, borrower, messages[0].Recipient)
+				assert.Equal(t, money.MustNew("1200000", money.DefaultCurrency), *messages[0].Amount)
+			}
+		})
+		mockRepo.EXPECT().Update(gomock.Any()).Return(nil)
+
+		service := NewLoanService(mockRepo, nil, nil, mockEventStore, newTestAuditLog(ctrl), newTestDocumentStore(ctrl), newTestAgreements(ctrl), nil, nil, nil, logrus.New())
+		_, err := service.ApproveLoan("loan-123", "validator-123", "proof-1", "", nil)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Disbursement tells the borrower and every investor", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mock.NewMockLoanRepository(ctrl)
+		mockEventStore := mock.NewMockEventStore(ctrl)
+		mockRepo.EXPECT().FindByID("loan-123").Return(&domain.Loan{
+			ID:                  "loan-123",
+			BorrowerID:          "borrower-123",
+			State:               domain.StateInvested,
+			PrincipalAmount:     money.MustNew("1200000", money.DefaultCurrency),
+			Rate:                money.MustParse("12"),
+			Terms:               domain.RepaymentTerms{TenorMonths: 3, Scheme: domain.SchemeFlat},
+			Investors:           investors,
+			AgreementDocumentID: "agreement-1",
+		}, nil)
+		expectMessages(mockEventStore, func(messages []domain.OutboxMessage) {
+			if assert.Len(t, messages, 3) {
+				assert.Equal(t, domain.NotificationLoanDisbursed, messages[0].Kind)
+				assert.Equal(t, borrower, messages[0].Recipient)
+				assert.Equal(t, domain.NotificationInvestmentDisbursed, messages[1].Kind)
+				assert.Equal(t, domain.Recipient{ID: "investor-1", Role: domain.RoleInvestor, Name: "One", Email: "one@example.com"}, messages[1].Recipient)
+				assert.Equal(t, money.MustNew("900000", money.DefaultCurrency), *messages[1].Amount)
+				assert.Equal(t, "id", messages[2].Recipient.Locale)
+			}
+		})
+		mockRepo.EXPECT().Update(gomock.Any()).Return(nil)
+
+		service := NewLoanService(mockRepo, nil, nil, mockEventStore, newTestAuditLog(ctrl), newTestDocumentStore(ctrl), nil, newTestVerifier(ctrl), nil, nil, logrus.New())
+		_, err := service.DisburseLoan("loan-123", "officer-123", "signed-1", nil)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Repayments are shared by investment", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mock.NewMockLoanRepository(ctrl)
+		mockEventStore := mock.NewMockEventStore(ctrl)
+		loan := disbursedLoan(t, domain.StateDisbursed, time.Now())
+		loan.ROI = money.MustParse("9")
+		loan.Investors = investors
+		loan.Schedule[0].Fee = money.MustNew("25000", money.DefaultCurrency)
+		mockRepo.EXPECT().FindByID("loan-123").Return(loan, nil)
+		expectMessages(mockEventStore, func(messages []domain.OutboxMessage) {
+			// The 25,000 fee and the 3,000 interest above the ROI are kept, 409,000 goes to the investors
+			if assert.Len(t, messages, 2) {
+				assert.Equal(t, domain.NotificationRepaymentDistributed, messages[0].Kind)
+				assert.Equal(t, money.MustNew("306750", money.DefaultCurrency), *messages[0].Amount)
+				assert.Equal(t, money.MustNew("102250", money.DefaultCurrency), *messages[1].Amount)
+			}
+		})
+		mockRepo.EXPECT().Update(gomock.Any()).Return(nil)
+
+		service := NewLoanService(mockRepo, nil, nil, mockEventStore, newTestAuditLog(ctrl), nil, nil, nil, nil, nil, logrus.New())
+		_, _, err := service.RecordRepayment("loan-123", money.MustNew("437000", money.DefaultCurrency), nil)
+		assert.NoError(t, err)
+	})
+}
+
 func TestGetLoansByBorrower(t *testing.T) {
 	// Define test cases
 	testCases := []struct {
@@ -698,17 +3138,17 @@ func TestGetLoansByBorrower(t *testing.T) {
 			defer ctrl.Finish()
 
 			mockRepo := mock.NewMockLoanRepository(ctrl)
-			mockEmailSender := mock.NewMockEmailSender(ctrl)
+			mockAuditLog := newTestAuditLog(ctrl)
 			logger := logrus.New()
 
 			// Configure mocks
 			tc.mockSetup(mockRepo)
 
 			// Create service
-			service := NewLoanService(mockRepo, mockEmailSender, logger)
+			service := NewLoanService(mockRepo, nil, nil, newTestEventStore(ctrl), mockAuditLog, nil, nil, nil, nil, nil, logger)
 
 			// Execute
-			loans, err := service.GetLoansByBorrower(tc.borrowerID)
+			loans, err := service.GetLoansByBorrower(tc.borrowerID, domain.LoanFilter{})
 
 			// Assert
 			if tc.expectError {
@@ -732,6 +3172,7 @@ func TestGetLoansByState(t *testing.T) {
 	testCases := []struct {
 		name        string
 		state       domain.LoanState
+		filter      domain.LoanFilter
 		mockSetup   func(*mock.MockLoanRepository)
 		expectError bool
 		errorMsg    string
@@ -750,6 +3191,21 @@ func TestGetLoansByState(t *testing.T) {
 			expectError: false,
 			expectedLen: 2,
 		},
+		{
+			name:   "Success - Currency Filter",
+			state:  domain.StateApproved,
+			filter: domain.LoanFilter{Currency: "USD"},
+			mockSetup: func(repo *mock.MockLoanRepository) {
+				loans := []*domain.Loan{
+					{ID: "loan-1", State: domain.StateApproved, PrincipalAmount: money.MustNew("1000", "IDR")},
+					{ID: "loan-2", State: domain.StateApproved, PrincipalAmount: money.MustNew("10", "USD")},
+					{ID: "loan-3", State: domain.StateApproved, PrincipalAmount: money.MustNew("20", "USD")},
+				}
+				repo.EXPECT().FindByState(domain.StateApproved).Return(loans, nil)
+			},
+			expectError: false,
+			expectedLen: 2,
+		},
 		{
 			name:  "Repository Error",
 			state: domain.StateApproved,
@@ -770,17 +3226,17 @@ func TestGetLoansByState(t *testing.T) {
 			defer ctrl.Finish()
 
 			mockRepo := mock.NewMockLoanRepository(ctrl)
-			mockEmailSender := mock.NewMockEmailSender(ctrl)
+			mockAuditLog := newTestAuditLog(ctrl)
 			logger := logrus.New()
 
 			// Configure mocks
 			tc.mockSetup(mockRepo)
 
 			// Create service
-			service := NewLoanService(mockRepo, mockEmailSender, logger)
+			service := NewLoanService(mockRepo, nil, nil, newTestEventStore(ctrl), mockAuditLog, nil, nil, nil, nil, nil, logger)
 
 			// Execute
-			loans, err := service.GetLoansByState(tc.state)
+			loans, err := service.GetLoansByState(tc.state, tc.filter)
 
 			// Assert
 			if tc.expectError {
@@ -791,8 +3247,9 @@ func TestGetLoansByState(t *testing.T) {
 				assert.NoError(t, err)
 				assert.NotNil(t, loans)
 				assert.Len(t, loans, tc.expectedLen)
-				if tc.expectedLen > 0 {
-					assert.Equal(t, tc.state, loans[0].State)
+				for _, loan := range loans {
+					assert.Equal(t, tc.state, loan.State)
+					assert.True(t, tc.filter.Matches(loan))
 				}
 			}
 		})
@@ -819,7 +3276,7 @@ func TestGetLoans(t *testing.T) {
 					{ID: "loan-1"},
 					{ID: "loan-2"},
 				}
-				repo.EXPECT().FindAll(1, 10).Return(loans, nil)
+				repo.EXPECT().FindAll(domain.LoanFilter{}, 1, 10).Return(loans, nil)
 			},
 			expectError: false,
 			expectedLen: 2,
@@ -833,7 +3290,7 @@ func TestGetLoans(t *testing.T) {
 					{ID: "loan-1"},
 					{ID: "loan-2"},
 				}
-				repo.EXPECT().FindAll(1, 10).Return(loans, nil)
+				repo.EXPECT().FindAll(domain.LoanFilter{}, 1, 10).Return(loans, nil)
 			},
 			expectError: false,
 			expectedLen: 2,
@@ -843,7 +3300,7 @@ func TestGetLoans(t *testing.T) {
 			page:  1,
 			limit: 10,
 			mockSetup: func(repo *mock.MockLoanRepository) {
-				repo.EXPECT().FindAll(1, 10).Return(nil, errors.New("database error"))
+				repo.EXPECT().FindAll(domain.LoanFilter{}, 1, 10).Return(nil, errors.New("database error"))
 			},
 			expectError: true,
 			errorMsg:    "database error",
@@ -859,17 +3316,17 @@ func TestGetLoans(t *testing.T) {
 			defer ctrl.Finish()
 
 			mockRepo := mock.NewMockLoanRepository(ctrl)
-			mockEmailSender := mock.NewMockEmailSender(ctrl)
+			mockAuditLog := newTestAuditLog(ctrl)
 			logger := logrus.New()
 
 			// Configure mocks
 			tc.mockSetup(mockRepo)
 
 			// Create service
-			service := NewLoanService(mockRepo, mockEmailSender, logger)
+			service := NewLoanService(mockRepo, nil, nil, newTestEventStore(ctrl), mockAuditLog, nil, nil, nil, nil, nil, logger)
 
 			// Execute
-			loans, err := service.GetLoans(tc.page, tc.limit)
+			loans, err := service.GetLoans(domain.LoanFilter{}, tc.page, tc.limit)
 
 			// Assert
 			if tc.expectError {
@@ -887,9 +3344,17 @@ func TestGetLoans(t *testing.T) {
 
 func TestNewLoanService(t *testing.T) {
 	type args struct {
-		repo        domain.LoanRepository
-		emailSender domain.EmailSender
-		logger      *logrus.Logger
+		repo       domain.LoanRepository
+		investors  domain.InvestorRepository
+		borrowers  domain.BorrowerRepository
+		eventStore domain.EventStore
+		auditLog   domain.AuditLog
+		documents  domain.DocumentStore
+		agreements domain.AgreementRenderer
+		verifier   domain.AgreementVerifier
+		scorer     domain.CreditScorer
+		pricer     domain.PricingEngine
+		logger     *logrus.Logger
 	}
 	tests := []struct {
 		name string
@@ -899,16 +3364,214 @@ func TestNewLoanService(t *testing.T) {
 		{
 			name: "Success",
 			args: args{
-				repo:        nil,
-				emailSender: nil,
-				logger:      nil,
+				repo:       nil,
+				investors:  nil,
+				borrowers:  nil,
+				eventStore: nil,
+				auditLog:   nil,
+				documents:  nil,
+				agreements: nil,
+				verifier:   nil,
+				scorer:     nil,
+				pricer:     nil,
+				logger:     nil,
 			},
-			want: NewLoanService(nil, nil, nil),
+			want: NewLoanService(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil),
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			assert.Equalf(t, tt.want, NewLoanService(tt.args.repo, tt.args.emailSender, tt.args.logger), "NewLoanService(%v, %v, %v)", tt.args.repo, tt.args.emailSender, tt.args.logger)
+			assert.Equalf(t, tt.want, NewLoanService(tt.args.repo, tt.args.investors, tt.args.borrowers, tt.args.eventStore, tt.args.auditLog, tt.args.documents, tt.args.agreements, tt.args.verifier, tt.args.scorer, tt.args.pricer, tt.args.logger),
+				"NewLoanService(%v, %v, %v, %v, %v, %v, %v, %v, %v, %v, %v)", tt.args.repo, tt.args.investors, tt.args.borrowers, tt.args.eventStore, tt.args.auditLog, tt.args.documents, tt.args.agreements, tt.args.verifier, tt.args.scorer, tt.args.pricer, tt.args.logger)
 		})
 	}
 }
+
+func TestLoanEvents(t *testing.T) {
+	t.Run("Each command appends its events at the next loan version", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mock.NewMockLoanRepository(ctrl)
+		mockEventStore := mock.NewMockEventStore(ctrl)
+		loan := &domain.Loan{
+			ID:              "loan-123",
+			PrincipalAmount: money.MustNew("1000", money.DefaultCurrency),
+			Investors:       []domain.Investor{{ID: "investor-1", Amount: money.MustNew("600", money.DefaultCurrency), Email: "one@example.com"}},
+			AgreementLetter: "http://example.com/agreement",
+			State:           domain.StateApproved,
+			Version:         3,
+		}
+		mockRepo.EXPECT().FindByID("loan-123").Return(loan, nil)
+
+		var (
+			appended []domain.Event
+			queued   []domain.OutboxMessage
+		)
+		gomock.InOrder(
+			mockEventStore.EXPECT().Commit("loan-123", int64(3), gomock.Any(), gomock.Any()).DoAndReturn(func(_ string, _ int64, events []domain.Event, messages []domain.OutboxMessage) error {
+				appended, queued = events, messages
+				return nil
+			}),
+			mockRepo.EXPECT().Update(gomock.Any()).DoAndReturn(func(l *domain.Loan) error {
+				assert.Equal(t, domain.StateInvested, l.State)
+				assert.Len(t, l.Investors, 2)
+				return nil
+			}),
+		)
+
+		service := NewLoanService(mockRepo, newTestInvestors(ctrl), nil, mockEventStore, newTestAuditLog(ctrl), nil, nil, nil, nil, nil, logrus.New())
+		_, err := service.AddInvestment("loan-123", domain.Investor{ID: "investor-2", Email: "two@example.com", Amount: money.MustNew("400", money.DefaultCurrency)}, nil)
+		assert.NoError(t, err)
+
+		if assert.Len(t, appended, 2) {
+			assert.Equal(t, domain.EventInvestmentAdded, appended[0].Type)
+			assert.Equal(t, domain.EventLoanFullyFunded, appended[1].Type)
+			assert.Equal(t, int64(4), appended[0].Version)
+			assert.Equal(t, int64(4), appended[1].Version)
+
+			var data domain.InvestmentAddedData
+			assert.NoError(t, appended[0].Decode(&data))
+			assert.Equal(t, "investor-2", data.Investor.ID)
+		}
+
+		// The new investor is thanked, the borrower told and both investors get the agreement
+		if assert.Len(t, queued, 4) {
+			assert.Equal(t, domain.NotificationInvestmentReceived, queued[0].Kind)
+			assert.Equal(t, domain.NotificationLoanFunded, queued[1].Kind)
+			assert.Equal(t, "one@example.com", queued[2].Recipient.Email)
+			assert.Equal(t, "two@example.com", queued[3].Recipient.Email)
+			assert.Equal(t, appended[0].OccurredAt, queued[0].NextAttemptAt)
+		}
+	})
+
+	t.Run("Loans are projected before their stream is started", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mock.NewMockLoanRepository(ctrl)
+		mockEventStore := mock.NewMockEventStore(ctrl)
+		mockRepo.EXPECT().FindByBorrowerID("borrower-123").Return(nil, nil)
+		mockRepo.EXPECT().Save(gomock.Any()).Return(fmt.Errorf("%w: loan-123", domain.ErrLoanAlreadyExists))
+
+		// The strict mock fails the test on any Commit
+		service := NewLoanService(mockRepo, nil, newTestBorrowers(ctrl), mockEventStore, newTestAuditLog(ctrl), nil, nil, nil, newTestScorer(ctrl), newTestPricer(ctrl), logrus.New())
+		_, err := service.CreateLoan("borrower-123", money.MustNew("1000", money.DefaultCurrency), nil, nil,
+			domain.RepaymentTerms{TenorMonths: 12, Scheme: domain.SchemeAnnuity})
+		assert.ErrorContains(t, err, "already exists")
+	})
+
+	t.Run("A stale stream is retried and leaves the read model untouched", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mock.NewMockLoanRepository(ctrl)
+		mockEventStore := mock.NewMockEventStore(ctrl)
+		mockRepo.EXPECT().FindByID("loan-123").DoAndReturn(func(string) (*domain.Loan, error) {
+			return &domain.Loan{ID: "loan-123", State: domain.StateProposed, CreditScore: newTestCreditScore(domain.GradeB), Version: 1}, nil
+		}).Times(2 * maxConflictAttempts)
+		for attempt := 0; attempt < maxConflictAttempts; attempt++ {
+			expectStaleCommit(mockEventStore)
+		}
+
+		// The letter stored by each attempt is deleted once its commit is rejected
+		documents := newTestDocumentStore(ctrl)
+		documents.EXPECT().Delete("agreement-1").Return(nil).Times(maxConflictAttempts)
+
+		// The strict audit log fails the test on any Append
+		service := NewLoanService(mockRepo, nil, nil, mockEventStore, mock.NewMockAuditLog(ctrl), documents, newTestAgreements(ctrl), nil, nil, nil, logrus.New())
+		_, err := service.ApproveLoan("loan-123", "validator-123", "proof-1", "", nil)
+		assert.ErrorIs(t, err, domain.ErrVersionConflict)
+	})
+
+	t.Run("A regenerated letter is deleted when its events are not committed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mock.NewMockLoanRepository(ctrl)
+		mockEventStore := mock.NewMockEventStore(ctrl)
+		mockRepo.EXPECT().FindByID("loan-123").Return(&domain.Loan{ID: "loan-123", State: domain.StateApproved, Version: 2}, nil)
+		mockEventStore.EXPECT().Commit("loan-123", int64(2), gomock.Any(), gomock.Any()).Return(errors.New("disk full"))
+		documents := newTestDocumentStore(ctrl)
+		documents.EXPECT().Delete("agreement-1").Return(nil)
+
+		service := NewLoanService(mockRepo, nil, nil, mockEventStore, newTestAuditLog(ctrl), documents, newTestAgreements(ctrl), nil, nil, nil, logrus.New())
+		_, _, err := service.GenerateAgreementLetter("loan-123", nil)
+		assert.ErrorContains(t, err, "disk full")
+	})
+}
+
+// failingUpdates is a loan repository whose next updates fail, as a database gone away after the event store
+// accepted a commit
+type failingUpdates struct {
+	domain.LoanRepository
+	failures int
+}
+
+func (r *failingUpdates) Update(loan *domain.Loan) error {
+	if r.failures > 0 {
+		r.failures--
+		return errors.New("database is locked")
+	}
+	return r.LoanRepository.Update(loan)
+}
+
+func TestCommit_ProjectionFailure(t *testing.T) {
+	// start creates a loan, then fails the given number of updates of the read model
+	start := func(t *testing.T, failures int) (domain.Service, *failingUpdates, domain.EventStore, string) {
+		t.Helper()
+		ctrl := gomock.NewController(t)
+		logger := logrus.New()
+		repo := &failingUpdates{LoanRepository: loanRepo.NewInMemoryRepository(logger)}
+		store := eventstore.NewInMemoryEventStore(logger)
+		service := NewLoanService(repo, nil, newTestBorrowers(ctrl), store, newTestAuditLog(ctrl),
+			nil, nil, nil, newTestScorer(ctrl), newTestPricer(ctrl), logger)
+		created, err := service.CreateLoan("borrower-123", money.MustNew("1000", money.DefaultCurrency), nil, nil,
+			domain.RepaymentTerms{TenorMonths: 12, Scheme: domain.SchemeAnnuity})
+		if err != nil {
+			t.Fatalf("failed to create loan: %v", err)
+		}
+		repo.failures = failures
+		return service, repo, store, created.ID
+	}
+
+	t.Run("A failed projection is caught up from the stream", func(t *testing.T) {
+		service, repo, store, id := start(t, 1)
+
+		version, err := service.CancelLoan(id, "borrower-123", domain.ReasonBorrowerWithdrawn, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), version)
+
+		projected, err := repo.FindByID(id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, domain.StateCancelled, projected.State)
+			assert.Equal(t, int64(2), projected.Version)
+		}
+		// The events of the cancellation were committed once
+		events, err := store.Load(id)
+		if assert.NoError(t, err) {
+			assert.Len(t, batches(events), 2)
+		}
+	})
+
+	t.Run("A loan left behind its stream is caught up by the next command", func(t *testing.T) {
+		service, repo, _, id := start(t, 2)
+
+		_, err := service.CancelLoan(id, "borrower-123", domain.ReasonBorrowerWithdrawn, nil)
+		assert.ErrorContains(t, err, "committed but not projected")
+		assert.NotErrorIs(t, err, domain.ErrVersionConflict)
+		projected, err := repo.FindByID(id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, domain.StateProposed, projected.State)
+		}
+
+		// The next command conflicts with the stream, catches the loan up and finds it cancelled
+		_, err = service.CancelLoan(id, "borrower-123", domain.ReasonBorrowerWithdrawn, nil)
+		assert.ErrorContains(t, err, "is CANCELLED")
+		projected, err = repo.FindByID(id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, domain.StateCancelled, projected.State)
+			assert.Equal(t, int64(2), projected.Version)
+		}
+	})
+}